@@ -57,7 +57,11 @@ func CommaSplit(csv string) []string {
 	return SepSplit(csv, ",")
 }
 
-func ExecSilent(executable string, args []string) (string, error) {
+// ExecHandler runs external commands for Exec and ExecSilent, tests
+// can replace it to capture them.
+var ExecHandler = execCommand
+
+func execCommand(executable string, args []string) (string, error) {
 	path, err := exec.LookPath(executable)
 	if err != nil {
 		return "", err
@@ -71,6 +75,10 @@ func ExecSilent(executable string, args []string) (string, error) {
 	}
 }
 
+func ExecSilent(executable string, args []string) (string, error) {
+	return ExecHandler(executable, args)
+}
+
 func Exec(executable string, args []string) (string, error) {
 	out, err := ExecSilent(executable, args)
 	if err != nil {
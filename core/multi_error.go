@@ -0,0 +1,85 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// TargetError is the error of an operation on a single target.
+type TargetError struct {
+	Target string
+	Err    error
+}
+
+func (e TargetError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Target, e.Err)
+}
+
+// MultiError collects the outcome of an operation performed on several
+// targets, so that failures on some of them are not hidden by the
+// others.
+type MultiError struct {
+	sync.Mutex
+
+	Succeeded int
+	Errors    []TargetError
+}
+
+func NewMultiError() *MultiError {
+	return &MultiError{
+		Errors: make([]TargetError, 0),
+	}
+}
+
+// Add records the result for target, nil errors count as successes.
+func (m *MultiError) Add(target string, err error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if err == nil {
+		m.Succeeded++
+	} else {
+		m.Errors = append(m.Errors, TargetError{Target: target, Err: err})
+	}
+}
+
+// Merge adds the results of another MultiError to this one.
+func (m *MultiError) Merge(other *MultiError) {
+	other.Lock()
+	succeeded := other.Succeeded
+	errors := append([]TargetError{}, other.Errors...)
+	other.Unlock()
+
+	m.Lock()
+	defer m.Unlock()
+	m.Succeeded += succeeded
+	m.Errors = append(m.Errors, errors...)
+}
+
+func (m *MultiError) Failed() int {
+	m.Lock()
+	defer m.Unlock()
+	return len(m.Errors)
+}
+
+// ErrorOrNil returns nil if no target failed, the MultiError itself
+// otherwise.
+func (m *MultiError) ErrorOrNil() error {
+	if m.Failed() == 0 {
+		return nil
+	}
+	return m
+}
+
+func (m *MultiError) Error() string {
+	m.Lock()
+	defer m.Unlock()
+
+	failures := make([]string, len(m.Errors))
+	for i, e := range m.Errors {
+		failures[i] = e.Error()
+	}
+
+	return fmt.Sprintf("%d succeeded, %d failed: %s", m.Succeeded, len(m.Errors), strings.Join(failures, "; "))
+}
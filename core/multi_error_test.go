@@ -0,0 +1,65 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMultiErrorAggregation(t *testing.T) {
+	m := NewMultiError()
+	if m.ErrorOrNil() != nil {
+		t.Fatal("expected nil error without failures")
+	}
+
+	m.Add("10.0.0.1", nil)
+	m.Add("10.0.0.2", errors.New("no hardware address"))
+	m.Add("10.0.0.3", nil)
+	m.Add("10.0.0.4", errors.New("send failed"))
+
+	if m.Succeeded != 2 {
+		t.Fatalf("expected 2 successes, got %d", m.Succeeded)
+	} else if m.Failed() != 2 {
+		t.Fatalf("expected 2 failures, got %d", m.Failed())
+	}
+
+	err := m.ErrorOrNil()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	multi, ok := err.(*MultiError)
+	if !ok {
+		t.Fatalf("expected *MultiError, got %T", err)
+	} else if multi.Errors[0].Target != "10.0.0.2" || multi.Errors[1].Err.Error() != "send failed" {
+		t.Fatalf("unexpected failures %v", multi.Errors)
+	}
+}
+
+func TestMultiErrorFormat(t *testing.T) {
+	m := NewMultiError()
+	m.Add("a", nil)
+	m.Add("b", errors.New("boom"))
+	m.Add("c", errors.New("bang"))
+
+	exp := "1 succeeded, 2 failed: b: boom; c: bang"
+	if got := m.Error(); got != exp {
+		t.Fatalf("expected '%s', got '%s'", exp, got)
+	}
+}
+
+func TestMultiErrorMerge(t *testing.T) {
+	a := NewMultiError()
+	a.Add("a", nil)
+	a.Add("b", errors.New("boom"))
+
+	b := NewMultiError()
+	b.Add("c", nil)
+	b.Add("d", errors.New("bang"))
+
+	a.Merge(b)
+	if a.Succeeded != 2 || a.Failed() != 2 {
+		t.Fatalf("expected 2 succeeded and 2 failed, got %d and %d", a.Succeeded, a.Failed())
+	} else if a.Errors[1].Target != "d" {
+		t.Fatalf("expected 'd', got '%s'", a.Errors[1].Target)
+	}
+}
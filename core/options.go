@@ -14,6 +14,8 @@ type Options struct {
 	Commands      *string
 	CpuProfile    *string
 	MemProfile    *string
+	AssumeYes     *bool
+	Quiet         *bool
 }
 
 func ParseOptions() (Options, error) {
@@ -29,6 +31,8 @@ func ParseOptions() (Options, error) {
 		Commands:      flag.String("eval", "", "Run one or more commands separated by ; in the interactive session, used to set variables via command line."),
 		CpuProfile:    flag.String("cpu-profile", "", "Write cpu profile `file`."),
 		MemProfile:    flag.String("mem-profile", "", "Write memory profile to `file`."),
+		AssumeYes:     flag.Bool("yes", false, "Confirm every dangerous command without asking, if confirm.dangerous is true."),
+		Quiet:         flag.Bool("quiet", false, "Suppress the banner, the prompt and the logs which are not errors, printing a JSON ready line once the session is initialized."),
 	}
 
 	flag.Parse()
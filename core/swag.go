@@ -63,32 +63,8 @@ func isDumbTerminal() bool {
 }
 
 func InitSwag(disableColors bool) {
-	if disableColors || isDumbTerminal() {
-		BOLD = ""
-		DIM = ""
-		RED = ""
-		GREEN = ""
-		BLUE = ""
-		YELLOW = ""
-		FG_BLACK = ""
-		FG_WHITE = ""
-		BG_DGRAY = ""
-		BG_RED = ""
-		BG_GREEN = ""
-		BG_YELLOW = ""
-		BG_LBLUE = ""
-		RESET = ""
-
-		LogColors = map[int]string{
-			DEBUG:     "",
-			INFO:      "",
-			IMPORTANT: "",
-			WARNING:   "",
-			ERROR:     "",
-			FATAL:     "",
-		}
-
-		HasColors = false
+	if disableColors || noColorRequested() || isDumbTerminal() {
+		SetTheme(ThemeOff)
 	}
 }
 
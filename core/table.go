@@ -10,11 +10,13 @@ import (
 
 var ansi = regexp.MustCompile("\033\\[(?:[0-9]{1,3}(?:;[0-9]{1,3})*)?[m|K]")
 
+// StripColors removes the escape sequences of the colors and styles.
+func StripColors(s string) string {
+	return ansi.ReplaceAllString(s, "")
+}
+
 func viewLen(s string) int {
-	for _, m := range ansi.FindAllString(s, -1) {
-		s = strings.Replace(s, m, "", -1)
-	}
-	return utf8.RuneCountInString(s)
+	return utf8.RuneCountInString(StripColors(s))
 }
 
 func maxLen(strings []string) int {
@@ -14,6 +14,14 @@ func TestViewLen(t *testing.T) {
 	}
 }
 
+func TestStripColors(t *testing.T) {
+	exp := "tcp 10.0.0.1:80 > 10.0.0.2:5000"
+	got := StripColors("\033[44m\033[30mtcp\033[0m 10.0.0.1:\033[1m80\033[0m > 10.0.0.2:5000")
+	if got != exp {
+		t.Fatalf("expected '%s', got '%s'", exp, got)
+	}
+}
+
 func TestMaxLen(t *testing.T) {
 	exp := 7
 	got := maxLen([]string{"go", "python", "ruby", "crystal"})
@@ -0,0 +1,163 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Theme holds the escape sequences of every effect used by the console.
+type Theme struct {
+	Bold     string
+	Dim      string
+	Red      string
+	Green    string
+	Blue     string
+	Yellow   string
+	FgBlack  string
+	FgWhite  string
+	BgDGray  string
+	BgRed    string
+	BgGreen  string
+	BgYellow string
+	BgLBlue  string
+	Reset    string
+}
+
+const (
+	ThemeDefault = "default"
+	ThemeOff     = "off"
+)
+
+var Themes = map[string]Theme{
+	ThemeDefault: {
+		Bold:     "\033[1m",
+		Dim:      "\033[2m",
+		Red:      "\033[31m",
+		Green:    "\033[32m",
+		Blue:     "\033[34m",
+		Yellow:   "\033[33m",
+		FgBlack:  "\033[30m",
+		FgWhite:  "\033[97m",
+		BgDGray:  "\033[100m",
+		BgRed:    "\033[41m",
+		BgGreen:  "\033[42m",
+		BgYellow: "\033[43m",
+		BgLBlue:  "\033[104m",
+		Reset:    "\033[0m",
+	},
+	// darker foregrounds, yellow and dim text are unreadable on white
+	"light": {
+		Bold:     "\033[1m",
+		Dim:      "\033[90m",
+		Red:      "\033[31m",
+		Green:    "\033[32m",
+		Blue:     "\033[34m",
+		Yellow:   "\033[35m",
+		FgBlack:  "\033[30m",
+		FgWhite:  "\033[97m",
+		BgDGray:  "\033[47m",
+		BgRed:    "\033[41m",
+		BgGreen:  "\033[42m",
+		BgYellow: "\033[45m",
+		BgLBlue:  "\033[44m",
+		Reset:    "\033[0m",
+	},
+	// no colors, only text attributes
+	"mono": {
+		Bold:     "\033[1m",
+		Dim:      "\033[2m",
+		Red:      "\033[1m",
+		Green:    "",
+		Blue:     "",
+		Yellow:   "\033[4m",
+		FgBlack:  "",
+		FgWhite:  "",
+		BgDGray:  "\033[2m",
+		BgRed:    "\033[7m",
+		BgGreen:  "\033[7m",
+		BgYellow: "\033[7m",
+		BgLBlue:  "\033[7m",
+		Reset:    "\033[0m",
+	},
+	ThemeOff: {},
+}
+
+var CurrentTheme = ThemeDefault
+
+func ThemeNames() []string {
+	names := make([]string, 0)
+	for name := range Themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetTheme applies the theme to the effects used by Bold, Dim and
+// the other helpers, so that everything printed from now on uses it.
+func SetTheme(name string) error {
+	theme, found := Themes[name]
+	if !found {
+		return fmt.Errorf("Unknown theme '%s', available themes are: %s.", name, strings.Join(ThemeNames(), ", "))
+	}
+
+	BOLD = theme.Bold
+	DIM = theme.Dim
+	RED = theme.Red
+	GREEN = theme.Green
+	BLUE = theme.Blue
+	YELLOW = theme.Yellow
+	FG_BLACK = theme.FgBlack
+	FG_WHITE = theme.FgWhite
+	BG_DGRAY = theme.BgDGray
+	BG_RED = theme.BgRed
+	BG_GREEN = theme.BgGreen
+	BG_YELLOW = theme.BgYellow
+	BG_LBLUE = theme.BgLBlue
+	RESET = theme.Reset
+
+	LogColors = map[int]string{
+		DEBUG:     DIM + FG_BLACK + BG_DGRAY,
+		INFO:      FG_WHITE + BG_GREEN,
+		IMPORTANT: FG_WHITE + BG_LBLUE,
+		WARNING:   FG_WHITE + BG_YELLOW,
+		ERROR:     FG_WHITE + BG_RED,
+		FATAL:     FG_WHITE + BG_RED + BOLD,
+	}
+
+	HasColors = name != ThemeOff
+	CurrentTheme = name
+
+	return nil
+}
+
+// ThemeFor maps a ui.colors value, on, off or the name of a theme,
+// to the theme to use.
+func ThemeFor(value string) (string, error) {
+	switch value = strings.ToLower(Trim(value)); value {
+	case "on", "true":
+		return ThemeDefault, nil
+	case "false":
+		return ThemeOff, nil
+	}
+
+	if _, found := Themes[value]; !found {
+		return "", fmt.Errorf("Unknown theme '%s', use on, off or one of: %s.", value, strings.Join(ThemeNames(), ", "))
+	}
+	return value, nil
+}
+
+func SetColors(value string) error {
+	name, err := ThemeFor(value)
+	if err != nil {
+		return err
+	}
+	return SetTheme(name)
+}
+
+// see https://no-color.org/
+func noColorRequested() bool {
+	return os.Getenv("NO_COLOR") != ""
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"os"
+	"testing"
+)
+
+func TestThemeFor(t *testing.T) {
+	var units = []struct {
+		value string
+		exp   string
+		err   bool
+	}{
+		{"on", ThemeDefault, false},
+		{" ON ", ThemeDefault, false},
+		{"true", ThemeDefault, false},
+		{"off", ThemeOff, false},
+		{"false", ThemeOff, false},
+		{"light", "light", false},
+		{"Mono", "mono", false},
+		{"neon", "", true},
+		{"", "", true},
+	}
+
+	for _, u := range units {
+		got, err := ThemeFor(u.value)
+		if u.err {
+			if err == nil {
+				t.Fatalf("expected error for '%s'", u.value)
+			}
+		} else if err != nil {
+			t.Fatalf("unexpected error for '%s': %v", u.value, err)
+		} else if got != u.exp {
+			t.Fatalf("expected '%s', got '%s'", u.exp, got)
+		}
+	}
+}
+
+func TestSetColors(t *testing.T) {
+	defer SetTheme(ThemeDefault)
+
+	if err := SetColors("off"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if got := Bold("gohpers"); got != "gohpers" {
+		t.Fatalf("expected 'gohpers', got '%s'", got)
+	} else if HasColors {
+		t.Fatal("expected colors to be disabled")
+	} else if LogColors[ERROR] != "" {
+		t.Fatalf("expected no log colors, got '%s'", LogColors[ERROR])
+	}
+
+	if err := SetColors("light"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if exp, got := "\033[35mgohpers\033[0m", Yellow("gohpers"); got != exp {
+		t.Fatalf("expected '%s', got '%s'", exp, got)
+	} else if !HasColors || CurrentTheme != "light" {
+		t.Fatalf("expected 'light' to be the current theme, got '%s'", CurrentTheme)
+	}
+
+	if err := SetColors("neon"); err == nil {
+		t.Fatal("expected error for an unknown theme")
+	} else if CurrentTheme != "light" {
+		t.Fatalf("expected 'light' to be kept, got '%s'", CurrentTheme)
+	}
+
+	if err := SetColors("on"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if exp, got := "\033[1mgohpers\033[0m", Bold("gohpers"); got != exp {
+		t.Fatalf("expected '%s', got '%s'", exp, got)
+	}
+}
+
+func TestInitSwagNoColor(t *testing.T) {
+	defer SetTheme(ThemeDefault)
+	defer os.Setenv("NO_COLOR", os.Getenv("NO_COLOR"))
+
+	os.Setenv("NO_COLOR", "1")
+	InitSwag(false)
+	if CurrentTheme != ThemeOff {
+		t.Fatalf("expected '%s', got '%s'", ThemeOff, CurrentTheme)
+	}
+}
@@ -0,0 +1,31 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+// how many times and how often ApplyVerify checks a change, as some
+// drivers apply or revert them asynchronously
+var (
+	VerifyAttempts = 5
+	VerifyDelay    = 100 * time.Millisecond
+)
+
+// ApplyVerify runs apply and then verify, retrying the latter for a short
+// while, so that changes silently ignored or reverted are reported.
+func ApplyVerify(apply func() error, verify func() error) error {
+	if err := apply(); err != nil {
+		return err
+	}
+
+	var err error
+	for attempt := 1; attempt <= VerifyAttempts; attempt++ {
+		if err = verify(); err == nil {
+			return nil
+		} else if attempt < VerifyAttempts {
+			time.Sleep(VerifyDelay)
+		}
+	}
+	return fmt.Errorf("The change has been applied but didn't take: %s", err)
+}
@@ -0,0 +1,47 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyVerify(t *testing.T) {
+	prevDelay := VerifyDelay
+	VerifyDelay = time.Millisecond
+	defer func() { VerifyDelay = prevDelay }()
+
+	var units = []struct {
+		applyErr error
+		// verify attempts failing before the change takes, -1 for never
+		failures int
+		verified int
+		err      string
+	}{
+		{nil, 0, 1, ""},
+		{nil, 2, 3, ""},
+		{nil, -1, VerifyAttempts, "didn't take: still old"},
+		{errors.New("nope"), 0, 0, "nope"},
+	}
+
+	for _, u := range units {
+		verified := 0
+		err := ApplyVerify(func() error {
+			return u.applyErr
+		}, func() error {
+			if verified++; u.failures < 0 || verified <= u.failures {
+				return errors.New("still old")
+			}
+			return nil
+		})
+
+		if verified != u.verified {
+			t.Fatalf("expected %d verifications, got %d", u.verified, verified)
+		} else if u.err == "" && err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if u.err != "" && (err == nil || !strings.Contains(err.Error(), u.err)) {
+			t.Fatalf("expected '%s', got '%v'", u.err, err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"sync"
+)
+
+// WorkerPool runs jobs on a fixed number of goroutines, Submit blocks
+// while every worker is busy so that callers never spawn more than
+// size concurrent jobs.
+type WorkerPool struct {
+	sync.Mutex
+
+	jobs     chan func() error
+	quit     chan struct{}
+	workers  sync.WaitGroup
+	stopOnce sync.Once
+	err      error
+}
+
+func NewWorkerPool(size int) *WorkerPool {
+	if size < 1 {
+		size = 1
+	}
+
+	p := &WorkerPool{
+		jobs: make(chan func() error),
+		quit: make(chan struct{}),
+	}
+
+	p.workers.Add(size)
+	for i := 0; i < size; i++ {
+		go p.worker()
+	}
+
+	return p
+}
+
+func (p *WorkerPool) worker() {
+	defer p.workers.Done()
+
+	for job := range p.jobs {
+		// drain whatever is left once the pool is canceled
+		if p.Canceled() {
+			continue
+		}
+
+		if err := job(); err != nil {
+			p.setError(err)
+		}
+	}
+}
+
+func (p *WorkerPool) setError(err error) {
+	p.Lock()
+	first := p.err == nil
+	if first {
+		p.err = err
+	}
+	p.Unlock()
+
+	if first {
+		p.Cancel()
+	}
+}
+
+// Submit queues a job for execution, blocking until a worker is
+// available. It returns false if the pool has been canceled and the
+// job was discarded. Submit must not be called after Wait.
+func (p *WorkerPool) Submit(job func() error) bool {
+	if p.Canceled() {
+		return false
+	}
+
+	select {
+	case p.jobs <- job:
+		return true
+	case <-p.quit:
+		return false
+	}
+}
+
+// Cancel prevents any pending or further job from being executed,
+// jobs that are already running are not interrupted.
+func (p *WorkerPool) Cancel() {
+	p.stopOnce.Do(func() {
+		close(p.quit)
+	})
+}
+
+func (p *WorkerPool) Canceled() bool {
+	select {
+	case <-p.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+// Done returns a channel which is closed when the pool gets canceled,
+// either explicitly or because a job returned an error.
+func (p *WorkerPool) Done() <-chan struct{} {
+	return p.quit
+}
+
+// Wait waits for every submitted job to complete, releases the workers
+// and returns the first error returned by a job, if any.
+func (p *WorkerPool) Wait() error {
+	close(p.jobs)
+	p.workers.Wait()
+
+	p.Lock()
+	defer p.Unlock()
+	return p.err
+}
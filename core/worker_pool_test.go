@@ -0,0 +1,123 @@
+package core
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkerPoolRunsEveryJob(t *testing.T) {
+	var done int32
+
+	pool := NewWorkerPool(4)
+	for i := 0; i < 100; i++ {
+		if !pool.Submit(func() error {
+			atomic.AddInt32(&done, 1)
+			return nil
+		}) {
+			t.Fatal("job should have been accepted")
+		}
+	}
+
+	if err := pool.Wait(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if done != 100 {
+		t.Fatalf("expected 100 jobs, got %d", done)
+	}
+}
+
+func TestWorkerPoolIsBounded(t *testing.T) {
+	var running, peak int32
+
+	pool := NewWorkerPool(3)
+	for i := 0; i < 30; i++ {
+		pool.Submit(func() error {
+			now := atomic.AddInt32(&running, 1)
+			for {
+				old := atomic.LoadInt32(&peak)
+				if now <= old || atomic.CompareAndSwapInt32(&peak, old, now) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return nil
+		})
+	}
+	pool.Wait()
+
+	if peak > 3 {
+		t.Fatalf("expected at most 3 concurrent jobs, got %d", peak)
+	}
+}
+
+func TestWorkerPoolFirstError(t *testing.T) {
+	first := errors.New("first")
+
+	pool := NewWorkerPool(1)
+	pool.Submit(func() error { return first })
+	pool.Submit(func() error { return errors.New("second") })
+
+	if err := pool.Wait(); err != first {
+		t.Fatalf("expected '%v', got '%v'", first, err)
+	} else if !pool.Canceled() {
+		t.Fatal("pool should have been canceled by the error")
+	}
+}
+
+func TestWorkerPoolCancelMidScan(t *testing.T) {
+	var scanned int32
+
+	pool := NewWorkerPool(2)
+	// simulate a scan loop being stopped by the user while in progress
+	submitted := 0
+	for port := 1; port <= 1000; port++ {
+		if port == 10 {
+			pool.Cancel()
+		}
+
+		if !pool.Submit(func() error {
+			atomic.AddInt32(&scanned, 1)
+			time.Sleep(time.Millisecond)
+			return nil
+		}) {
+			break
+		}
+		submitted++
+	}
+
+	if err := pool.Wait(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if submitted != 9 {
+		t.Fatalf("expected 9 submitted jobs, got %d", submitted)
+	} else if scanned > int32(submitted) {
+		t.Fatalf("expected at most %d scanned ports, got %d", submitted, scanned)
+	}
+
+	select {
+	case <-pool.Done():
+	default:
+		t.Fatal("done channel should be closed")
+	}
+}
+
+func TestWorkerPoolCancelFromJob(t *testing.T) {
+	var scanned int32
+
+	pool := NewWorkerPool(1)
+	for i := 0; i < 50; i++ {
+		pool.Submit(func() error {
+			if atomic.AddInt32(&scanned, 1) == 5 {
+				pool.Cancel()
+			}
+			return nil
+		})
+	}
+
+	if err := pool.Wait(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if scanned != 5 {
+		t.Fatalf("expected 5 executed jobs, got %d", scanned)
+	}
+}
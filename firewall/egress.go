@@ -0,0 +1,42 @@
+package firewall
+
+import (
+	"fmt"
+)
+
+// EgressTable is the routing table, and the priority of the
+// rule using it, for the traffic forwarded out of Egress.Out.
+const EgressTable = 4747
+
+// Egress makes the traffic of Subnet, received on In because we're
+// spoofing it, leave through Out instead of the default route.
+type Egress struct {
+	In      string
+	Out     string
+	Subnet  string
+	Gateway string
+
+	applied []rule
+}
+
+func NewEgress(in, out, subnet, gateway string) *Egress {
+	return &Egress{
+		In:      in,
+		Out:     out,
+		Subnet:  subnet,
+		Gateway: gateway,
+		applied: make([]rule, 0),
+	}
+}
+
+func (e *Egress) Enabled() bool {
+	return len(e.applied) > 0
+}
+
+func (e *Egress) String() string {
+	via := e.Out
+	if e.Gateway != "" {
+		via = fmt.Sprintf("%s via %s", e.Out, e.Gateway)
+	}
+	return fmt.Sprintf("%s (%s) -> %s", e.Subnet, e.In, via)
+}
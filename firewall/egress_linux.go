@@ -0,0 +1,81 @@
+package firewall
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/bettercap/bettercap/core"
+)
+
+// egressGateway reads the next hop of the default route of iface.
+func egressGateway(iface string) (string, error) {
+	out, err := core.ExecSilent("ip", []string{"route", "show", "default", "dev", iface})
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		for i, field := range fields {
+			if field == "via" && i+1 < len(fields) {
+				return fields[i+1], nil
+			}
+		}
+	}
+
+	// point to point links, like most VPNs, don't need one
+	if ifi, err := net.InterfaceByName(iface); err == nil && ifi.Flags&net.FlagPointToPoint != 0 {
+		return "", nil
+	}
+	return "", fmt.Errorf("No default route found for %s, the gateway has to be set explicitly.", iface)
+}
+
+func (e *Egress) rules() []rule {
+	table := fmt.Sprintf("%d", EgressTable)
+
+	route := []string{"route", "replace", "default"}
+	if e.Gateway != "" {
+		route = append(route, "via", e.Gateway)
+	}
+	route = append(route, "dev", e.Out, "table", table)
+
+	policy := []string{"iif", e.In, "from", e.Subnet, "lookup", table, "priority", table}
+	nat := []string{"POSTROUTING", "-s", e.Subnet, "-o", e.Out, "-j", "MASQUERADE"}
+	outbound := []string{"FORWARD", "-i", e.In, "-o", e.Out, "-s", e.Subnet, "-j", "ACCEPT"}
+	inbound := []string{"FORWARD", "-i", e.Out, "-o", e.In, "-d", e.Subnet,
+		"-m", "conntrack", "--ctstate", "RELATED,ESTABLISHED", "-j", "ACCEPT"}
+
+	return []rule{
+		// the spoofed hosts still have to reach each other
+		{"ip", []string{"route", "replace", e.Subnet, "dev", e.In, "table", table}, []string{"route", "flush", "table", table}},
+		{"ip", route, nil},
+		{"ip", append([]string{"rule", "add"}, policy...), append([]string{"rule", "del"}, policy...)},
+		// the upstream network doesn't know how to reach the subnet
+		{"iptables", append([]string{"-t", "nat", "-A"}, nat...), append([]string{"-t", "nat", "-D"}, nat...)},
+		// before any DROP rule of the chain
+		{"iptables", append([]string{"-I"}, outbound...), append([]string{"-D"}, outbound...)},
+		{"iptables", append([]string{"-I"}, inbound...), append([]string{"-D"}, inbound...)},
+	}
+}
+
+// Enable adds the routes and rules, keeping track of the applied ones so
+// that only those are removed by Disable, even if something fails halfway.
+func (e *Egress) Enable() (err error) {
+	if e.Enabled() {
+		return fmt.Errorf("Egress %s already enabled.", e)
+	} else if e.Gateway == "" {
+		if e.Gateway, err = egressGateway(e.Out); err != nil {
+			return err
+		}
+	}
+
+	e.applied, err = applyRules(e.rules())
+	return err
+}
+
+func (e *Egress) Disable() error {
+	err := removeRules(e.applied)
+	e.applied = make([]rule, 0)
+	return err
+}
@@ -0,0 +1,16 @@
+// +build windows darwin
+
+package firewall
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func (e *Egress) Enable() error {
+	return fmt.Errorf("Selecting the egress interface is not supported on %s.", runtime.GOOS)
+}
+
+func (e *Egress) Disable() error {
+	return nil
+}
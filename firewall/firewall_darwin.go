@@ -94,6 +94,23 @@ func (f PfFirewall) EnableForwarding(enabled bool) error {
 	return f.enableParam("net.inet.ip.forwarding", enabled)
 }
 
+// IsIPv6ForwardingEnabled is true if the IPv6 packets of the spoofed
+// hosts are forwarded.
+func IsIPv6ForwardingEnabled(iface *network.Endpoint) bool {
+	out, err := PfFirewall{iface: iface}.sysCtlRead("net.inet6.ip6.forwarding")
+	if err != nil {
+		log.Printf("ERROR: %s", err)
+		return false
+	}
+
+	// sysCtlRead only returns the value
+	return core.Trim(out) == "1"
+}
+
+func EnableIPv6Forwarding(iface *network.Endpoint, enabled bool) error {
+	return PfFirewall{iface: iface}.enableParam("net.inet6.ip6.forwarding", enabled)
+}
+
 func (f PfFirewall) generateRule(r *Redirection) string {
 	src_a := "any"
 	dst_a := "any"
@@ -173,3 +190,7 @@ func (f PfFirewall) Restore() {
 	}
 	os.Remove(f.filename)
 }
+
+func ForwardDropRules() ([]string, error) {
+	return nil, fmt.Errorf("macOS does not support checking the pf forwarding rules.")
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 
 	"github.com/bettercap/bettercap/core"
 	"github.com/bettercap/bettercap/network"
@@ -17,6 +18,7 @@ type LinuxFirewall struct {
 
 const (
 	IPV4ForwardingFile = "/proc/sys/net/ipv4/ip_forward"
+	IPV6ForwardingFile = "/proc/sys/net/ipv6/conf/all/forwarding"
 )
 
 func Make(iface *network.Endpoint) FirewallManager {
@@ -62,6 +64,20 @@ func (f LinuxFirewall) EnableForwarding(enabled bool) error {
 	return f.enableFeature(IPV4ForwardingFile, enabled)
 }
 
+// IsIPv6ForwardingEnabled is true if the IPv6 packets of the spoofed
+// hosts are forwarded.
+func IsIPv6ForwardingEnabled(iface *network.Endpoint) bool {
+	if out, err := ioutil.ReadFile(IPV6ForwardingFile); err != nil {
+		return false
+	} else {
+		return core.Trim(string(out)) == "1"
+	}
+}
+
+func EnableIPv6Forwarding(iface *network.Endpoint, enabled bool) error {
+	return LinuxFirewall{iface: iface}.enableFeature(IPV6ForwardingFile, enabled)
+}
+
 func (f *LinuxFirewall) getCommandLine(r *Redirection, enabled bool) (cmdLine []string) {
 	action := "-A"
 	if !enabled {
@@ -138,3 +154,23 @@ func (f LinuxFirewall) Restore() {
 		fmt.Printf("%s", err)
 	}
 }
+
+// ForwardDropRules returns the policy and the rules of the FORWARD
+// chain which drop or reject the packets we're supposed to forward.
+func ForwardDropRules() ([]string, error) {
+	out, err := core.ExecSilent("iptables", []string{"-S", "FORWARD"})
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]string, 0)
+	for _, line := range strings.Split(out, "\n") {
+		line = core.Trim(line)
+		if line == "-P FORWARD DROP" {
+			rules = append(rules, line)
+		} else if strings.HasPrefix(line, "-A FORWARD ") && (strings.HasSuffix(line, "-j DROP") || strings.Contains(line, "-j REJECT")) {
+			rules = append(rules, line)
+		}
+	}
+	return rules, nil
+}
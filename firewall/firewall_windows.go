@@ -48,6 +48,27 @@ func (f WindowsFirewall) EnableForwarding(enabled bool) error {
 	return nil
 }
 
+// IsIPv6ForwardingEnabled is true if the IPv6 packets of the spoofed
+// hosts are forwarded.
+func IsIPv6ForwardingEnabled(iface *network.Endpoint) bool {
+	if out, err := core.Exec("netsh", []string{"interface", "ipv6", "dump"}); err != nil {
+		fmt.Printf("%s\n", err)
+		return false
+	} else {
+		return strings.Contains(out, "forwarding=enabled")
+	}
+}
+
+func EnableIPv6Forwarding(iface *network.Endpoint, enabled bool) error {
+	v := "enabled"
+	if !enabled {
+		v = "disabled"
+	}
+
+	_, err := core.Exec("netsh", []string{"interface", "ipv6", "set", "interface", fmt.Sprintf("%d", iface.Index), fmt.Sprintf("forwarding=\"%s\"", v)})
+	return err
+}
+
 func (f WindowsFirewall) generateRule(r *Redirection, enabled bool) []string {
 	// https://stackoverflow.com/questions/24646165/netsh-port-forwarding-from-local-port-to-local-port-not-working
 	rule := []string{
@@ -117,3 +138,7 @@ func (f WindowsFirewall) Restore() {
 		fmt.Printf("%s", err)
 	}
 }
+
+func ForwardDropRules() ([]string, error) {
+	return nil, fmt.Errorf("Windows does not support checking the forwarding rules.")
+}
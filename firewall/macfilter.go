@@ -0,0 +1,50 @@
+package firewall
+
+import (
+	"fmt"
+	"net"
+)
+
+const (
+	// frames sent by the address to this computer
+	MacFilterIn = "in"
+	// frames sent by this computer to the address
+	MacFilterOut  = "out"
+	MacFilterBoth = "both"
+
+	MacFilterDrop  = "drop"
+	MacFilterAllow = "allow"
+)
+
+// MacFilter drops or allows the frames a hardware address exchanges
+// with this computer in one or both directions.
+type MacFilter struct {
+	Mac       net.HardwareAddr
+	Direction string
+	Action    string
+
+	applied []rule
+}
+
+func NewMacFilter(mac net.HardwareAddr, direction, action string) (*MacFilter, error) {
+	if direction != MacFilterIn && direction != MacFilterOut && direction != MacFilterBoth {
+		return nil, fmt.Errorf("Invalid direction '%s', expected %s, %s or %s.", direction, MacFilterIn, MacFilterOut, MacFilterBoth)
+	} else if action != MacFilterDrop && action != MacFilterAllow {
+		return nil, fmt.Errorf("Invalid action '%s', expected %s or %s.", action, MacFilterDrop, MacFilterAllow)
+	}
+
+	return &MacFilter{
+		Mac:       mac,
+		Direction: direction,
+		Action:    action,
+		applied:   make([]rule, 0),
+	}, nil
+}
+
+func (f *MacFilter) Enabled() bool {
+	return len(f.applied) > 0
+}
+
+func (f *MacFilter) String() string {
+	return fmt.Sprintf("%s %s %s", f.Action, f.Mac, f.Direction)
+}
@@ -0,0 +1,49 @@
+package firewall
+
+import (
+	"fmt"
+)
+
+// rules uses ebtables, which only sees the frames of bridged interfaces
+// like the ones of a transparent bridge between the target and the network.
+func (f *MacFilter) rules() []rule {
+	target := "DROP"
+	if f.Action == MacFilterAllow {
+		target = "ACCEPT"
+	}
+
+	mac := f.Mac.String()
+	matches := make([][]string, 0)
+	if f.Direction != MacFilterOut {
+		matches = append(matches, []string{"INPUT", "-s", mac}, []string{"FORWARD", "-s", mac})
+	}
+	if f.Direction != MacFilterIn {
+		matches = append(matches, []string{"OUTPUT", "-d", mac}, []string{"FORWARD", "-d", mac})
+	}
+
+	rules := make([]rule, 0, len(matches))
+	for _, match := range matches {
+		match = append(match, "-j", target)
+		rules = append(rules, rule{
+			Executable: "ebtables",
+			Add:        append([]string{"-I"}, match...),
+			Del:        append([]string{"-D"}, match...),
+		})
+	}
+	return rules
+}
+
+// Enable inserts the rules before the existing ones of each chain.
+func (f *MacFilter) Enable() (err error) {
+	if f.Enabled() {
+		return fmt.Errorf("Filter %s already enabled.", f)
+	}
+	f.applied, err = applyRules(f.rules())
+	return err
+}
+
+func (f *MacFilter) Disable() error {
+	err := removeRules(f.applied)
+	f.applied = make([]rule, 0)
+	return err
+}
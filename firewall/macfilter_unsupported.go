@@ -0,0 +1,16 @@
+// +build windows darwin
+
+package firewall
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func (f *MacFilter) Enable() error {
+	return fmt.Errorf("Filtering by hardware address is not supported on %s.", runtime.GOOS)
+}
+
+func (f *MacFilter) Disable() error {
+	return nil
+}
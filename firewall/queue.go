@@ -0,0 +1,31 @@
+package firewall
+
+import (
+	"fmt"
+	"net"
+)
+
+// Queue sends the IPv4 packets a host exchanges through this computer,
+// like when it is being spoofed, to an NFQUEUE.
+type Queue struct {
+	Num int
+	IP  net.IP
+
+	applied []rule
+}
+
+func NewQueue(num int, ip net.IP) *Queue {
+	return &Queue{
+		Num:     num,
+		IP:      ip,
+		applied: make([]rule, 0),
+	}
+}
+
+func (q *Queue) Enabled() bool {
+	return len(q.applied) > 0
+}
+
+func (q *Queue) String() string {
+	return fmt.Sprintf("%s to queue %d", q.IP, q.Num)
+}
@@ -0,0 +1,37 @@
+package firewall
+
+import (
+	"fmt"
+)
+
+// rules queue what the host sends and receives in FORWARD, bypassing
+// the queue if nothing is bound to it so that the host is never cut off.
+func (q *Queue) rules() []rule {
+	ip := q.IP.String()
+	rules := make([]rule, 0, 2)
+	for _, match := range [][]string{{"-s", ip}, {"-d", ip}} {
+		match = append([]string{"FORWARD"}, match...)
+		match = append(match, "-j", "NFQUEUE", "--queue-num", fmt.Sprintf("%d", q.Num), "--queue-bypass")
+		rules = append(rules, rule{
+			Executable: "iptables",
+			Add:        append([]string{"-I"}, match...),
+			Del:        append([]string{"-D"}, match...),
+		})
+	}
+	return rules
+}
+
+// Enable inserts the rules before the existing ones of the chain.
+func (q *Queue) Enable() (err error) {
+	if q.Enabled() {
+		return fmt.Errorf("Queue of %s already enabled.", q.IP)
+	}
+	q.applied, err = applyRules(q.rules())
+	return err
+}
+
+func (q *Queue) Disable() error {
+	err := removeRules(q.applied)
+	q.applied = make([]rule, 0)
+	return err
+}
@@ -0,0 +1,16 @@
+// +build windows darwin
+
+package firewall
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func (q *Queue) Enable() error {
+	return fmt.Errorf("Queueing forwarded packets is not supported on %s.", runtime.GOOS)
+}
+
+func (q *Queue) Disable() error {
+	return nil
+}
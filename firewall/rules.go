@@ -0,0 +1,45 @@
+package firewall
+
+import (
+	"fmt"
+
+	"github.com/bettercap/bettercap/core"
+)
+
+// rule is a command changing the system and the one reverting it.
+type rule struct {
+	Executable string
+	Add        []string
+	// nil if removing a previous rule takes care of it
+	Del []string
+}
+
+// applyRules runs the rules in order and returns the applied ones, if
+// one fails those applied so far are removed.
+func applyRules(rules []rule) ([]rule, error) {
+	applied := make([]rule, 0, len(rules))
+	for _, r := range rules {
+		if _, err := core.Exec(r.Executable, r.Add); err != nil {
+			if derr := removeRules(applied); derr != nil {
+				return nil, fmt.Errorf("%s (while rolling back: %s)", err, derr)
+			}
+			return nil, err
+		}
+		applied = append(applied, r)
+	}
+	return applied, nil
+}
+
+// removeRules reverts the rules in reverse order, going on if one
+// fails in order to leave as little as possible behind.
+func removeRules(applied []rule) (err error) {
+	for i := len(applied) - 1; i >= 0; i-- {
+		r := applied[i]
+		if r.Del == nil {
+			continue
+		} else if _, derr := core.Exec(r.Executable, r.Del); derr != nil && err == nil {
+			err = derr
+		}
+	}
+	return err
+}
@@ -19,39 +19,57 @@ func main() {
 	}
 	defer sess.Close()
 
-	if !core.HasColors {
-		if *sess.Options.NoColors {
-			fmt.Printf("\n\nWARNING: Terminal colors have been disabled, view will be very limited.\n\n")
-		} else {
-			fmt.Printf("\n\nWARNING: This terminal does not support colors, view will be very limited.\n\n")
+	if !sess.Quiet() {
+		if !core.HasColors {
+			if *sess.Options.NoColors {
+				fmt.Printf("\n\nWARNING: Terminal colors have been disabled, view will be very limited.\n\n")
+			} else {
+				fmt.Printf("\n\nWARNING: This terminal does not support colors, view will be very limited.\n\n")
+			}
 		}
-	}
 
-	appName := fmt.Sprintf("%s v%s", core.Name, core.Version)
+		appName := fmt.Sprintf("%s v%s", core.Name, core.Version)
 
-	fmt.Printf("%s (type '%s' for a list of commands)\n\n", core.Bold(appName), core.Bold("help"))
+		fmt.Printf("%s (type '%s' for a list of commands)\n\n", core.Bold(appName), core.Bold("help"))
+	}
 
 	sess.Register(modules.NewEventsStream(sess))
 	sess.Register(modules.NewTicker(sess))
+	sess.Register(modules.NewUIDashboard(sess))
 	sess.Register(modules.NewUpdateModule(sess))
 	sess.Register(modules.NewMacChanger(sess))
+	sess.Register(modules.NewMacMonitor(sess))
+	sess.Register(modules.NewArpGuard(sess))
 	sess.Register(modules.NewProber(sess))
 	sess.Register(modules.NewDiscovery(sess))
 	sess.Register(modules.NewArpSpoofer(sess))
+	sess.Register(modules.NewNDPSpoofer(sess))
+	sess.Register(modules.NewNetForward(sess))
+	sess.Register(modules.NewNetFilter(sess))
+	sess.Register(modules.NewNetThrottle(sess))
 	sess.Register(modules.NewDHCP6Spoofer(sess))
+	sess.Register(modules.NewDHCPServer(sess))
 	sess.Register(modules.NewDNSSpoofer(sess))
+	sess.Register(modules.NewNetResponder(sess))
 	sess.Register(modules.NewSniffer(sess))
+	sess.Register(modules.NewNetReplay(sess))
 	sess.Register(modules.NewPacketProxy(sess))
 	sess.Register(modules.NewAnyProxy(sess))
 	sess.Register(modules.NewTcpProxy(sess))
 	sess.Register(modules.NewHttpProxy(sess))
 	sess.Register(modules.NewHttpsProxy(sess))
+	sess.Register(modules.NewHttpReplay(sess))
 	sess.Register(modules.NewHttpServer(sess))
 	sess.Register(modules.NewRestAPI(sess))
+	sess.Register(modules.NewRPCServer(sess))
+	sess.Register(modules.NewAgents(sess))
 	sess.Register(modules.NewWOL(sess))
+	sess.Register(modules.NewPacketInjector(sess))
 	sess.Register(modules.NewWiFiModule(sess))
 	sess.Register(modules.NewBLERecon(sess))
+	sess.Register(modules.NewHIDRecon(sess))
 	sess.Register(modules.NewSynScanner(sess))
+	sess.Register(modules.NewNetFuzzer(sess))
 	sess.Register(modules.NewGPS(sess))
 	sess.Register(modules.NewMySQLServer(sess))
 
@@ -64,7 +82,7 @@ func main() {
 	// line, therefore they need to be executed first otherwise
 	// modules might already be started.
 	for _, cmd := range session.ParseCommands(*sess.Options.Commands) {
-		if err = sess.Run(cmd); err != nil {
+		if err = sess.RunFrom(session.OriginCommandLine, cmd); err != nil {
 			log.Error("Error while running '%s': %s", core.Bold(cmd), core.Red(err.Error()))
 		}
 	}
@@ -72,7 +90,7 @@ func main() {
 	// Some modules are enabled by default in order
 	// to make the interactive session useful.
 	for _, modName := range core.CommaSplit(*sess.Options.AutoStart) {
-		if err = sess.Run(modName + " on"); err != nil {
+		if err = sess.RunFrom(session.OriginCommandLine, modName+" on"); err != nil {
 			log.Fatal("Error while starting module %s: %s", modName, err)
 		}
 	}
@@ -84,6 +102,17 @@ func main() {
 		}
 	}
 
+	// Then the modules added with 'autostart add', configured
+	// by the caplet if any.
+	if err = sess.AutoStart(); err != nil {
+		log.Error("Error while starting the autostart modules: %s", err)
+	}
+
+	// Tools embedding the session wait for this line.
+	if err = sess.Ready(); err != nil {
+		log.Error("%s", err)
+	}
+
 	// Eventually start the interactive session.
 	for sess.Active {
 		line, err := sess.ReadLine()
@@ -95,7 +124,7 @@ func main() {
 		}
 
 		for _, cmd := range session.ParseCommands(line) {
-			if err = sess.Run(cmd); err != nil {
+			if err = sess.RunFrom(session.OriginConsole, cmd); err != nil {
 				log.Error("%s", err)
 			}
 		}
@@ -0,0 +1,493 @@
+package modules
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/network"
+	"github.com/bettercap/bettercap/session"
+)
+
+const (
+	agentsDefaultPort = "8083"
+	agentsTimeout     = 10 * time.Second
+)
+
+type remoteAgent struct {
+	Address  string
+	URL      string
+	LastSync time.Time
+	Hosts    int
+	APs      int
+	Error    error
+}
+
+// agentsConfig is what Configure reads from the parameters, it's replaced
+// as a whole so that the requests already running keep using their own.
+type agentsConfig struct {
+	client   *http.Client
+	username string
+	password string
+	insecure bool
+	tag      string
+	period   time.Duration
+}
+
+// Agents controls remote bettercap instances through their api.rest
+// module and merges what they find into this session.
+type Agents struct {
+	session.SessionModule
+	lock      *sync.Mutex
+	agents    map[string]*remoteAgent
+	config    *agentsConfig
+	quit      chan bool
+	waitGroup *sync.WaitGroup
+}
+
+func NewAgents(s *session.Session) *Agents {
+	a := &Agents{
+		SessionModule: session.NewSessionModule("agents", s),
+		agents:        make(map[string]*remoteAgent),
+		lock:          &sync.Mutex{},
+		waitGroup:     &sync.WaitGroup{},
+	}
+
+	a.AddParam(session.NewStringParameter("agents.username", "", "",
+		"api.rest.username of the agents."))
+
+	a.AddParam(session.NewStringParameter("agents.password", "", "",
+		"api.rest.password of the agents."))
+
+	a.AddParam(session.NewStringParameter("agents.certificate", "", "",
+		"PEM file with the api.rest.certificate of every agent, the agents presenting other certificates are refused."))
+
+	a.AddParam(session.NewBoolParameter("agents.insecure", "false",
+		"If true and agents.certificate is empty, the certificates of the agents are not verified and plain http:// agents are allowed, exposing the credentials to anyone in the middle."))
+
+	a.AddParam(session.NewDurationParameter("agents.period", "10s",
+		"How often the hosts and access points of the agents are merged into this session."))
+
+	a.AddParam(session.NewStringParameter("agents.tag", "agent", "",
+		"Tag of the hosts and access points found by the agents, the address of the agent is their agent meta value."))
+
+	a.AddHandler(session.NewModuleHandler("agents on", "",
+		"Start merging the hosts and access points of the agents into this session.",
+		func(args []string) error {
+			return a.Start()
+		}))
+
+	a.AddHandler(session.NewModuleHandler("agents off", "",
+		"Stop merging the hosts and access points of the agents.",
+		func(args []string) error {
+			return a.Stop()
+		}))
+
+	a.AddHandler(session.NewModuleHandler("agents.add ADDRESS", `agents\.add\s+([^\s]+)`,
+		"Add the bettercap instance running api.rest on ADDRESS (host, host:port or URL) to the agents.",
+		func(args []string) error {
+			return a.addAgent(args[0])
+		}))
+
+	a.AddHandler(session.NewModuleHandler("agents.remove ADDRESS", `agents\.remove\s+([^\s]+)`,
+		"Remove the agent on ADDRESS.",
+		func(args []string) error {
+			return a.removeAgent(args[0])
+		}))
+
+	a.AddHandler(session.NewModuleHandler("agents.show", "",
+		"Show the agents and the last time they were synced.",
+		func(args []string) error {
+			return a.show()
+		}))
+
+	a.AddHandler(session.NewDangerousModuleHandler("agents.run ADDRESS COMMAND", `agents\.run\s+([^\s]+)\s+(.+)`,
+		"Run COMMAND on the agent on ADDRESS, or on every agent if ADDRESS is all.",
+		func(args []string) error {
+			return a.run(args[0], core.Trim(args[1]))
+		}))
+
+	return a
+}
+
+func (a Agents) Name() string {
+	return "agents"
+}
+
+func (a Agents) Description() string {
+	return "Run commands on remote bettercap instances exposing their session with api.rest and merge the hosts and access points they find into this one."
+}
+
+func (a Agents) Author() string {
+	return "Simone Margaritelli <evilsocket@protonmail.com>"
+}
+
+// agentURL returns the base URL of the api.rest of the agent.
+func agentURL(address string) string {
+	if strings.HasPrefix(address, "http://") || strings.HasPrefix(address, "https://") {
+		return strings.TrimRight(address, "/")
+	} else if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(strings.Trim(address, "[]"), agentsDefaultPort)
+	}
+	return "https://" + address
+}
+
+// pinnedCertificates returns a verifier accepting only the certificates
+// of the PEM file.
+func pinnedCertificates(fileName string) (func([][]byte, [][]*x509.Certificate) error, error) {
+	raw, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	pinned := make([][]byte, 0)
+	for block, rest := pem.Decode(raw); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type == "CERTIFICATE" {
+			pinned = append(pinned, block.Bytes)
+		}
+	}
+
+	if len(pinned) == 0 {
+		return nil, fmt.Errorf("No certificates found in %s.", fileName)
+	}
+
+	return func(certs [][]byte, chains [][]*x509.Certificate) error {
+		if len(certs) > 0 {
+			for _, cert := range pinned {
+				if bytes.Equal(cert, certs[0]) {
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("the certificate of the agent is not in %s", fileName)
+	}, nil
+}
+
+func (a *Agents) Configure() error {
+	var err error
+	var certificate string
+
+	c := &agentsConfig{}
+	if err, c.username = a.StringParam("agents.username"); err != nil {
+		return err
+	} else if err, c.password = a.StringParam("agents.password"); err != nil {
+		return err
+	} else if err, certificate = a.StringParam("agents.certificate"); err != nil {
+		return err
+	} else if err, c.insecure = a.BoolParam("agents.insecure"); err != nil {
+		return err
+	} else if err, c.period = a.DurationParam("agents.period"); err != nil {
+		return err
+	} else if err, c.tag = a.StringParam("agents.tag"); err != nil {
+		return err
+	}
+
+	// api.rest certificates are self signed, so they're pinned rather
+	// than verified against a CA
+	tlsConfig := &tls.Config{InsecureSkipVerify: true}
+	if certificate == "" {
+		if !c.insecure {
+			return fmt.Errorf("agents.certificate is empty, set it to the certificates of the agents or set agents.insecure to true to skip their verification.")
+		}
+		log.Warning("agents.insecure is true, the certificates of the agents are not verified and their credentials can be intercepted.")
+	} else if certificate, err = core.ExpandPath(certificate); err != nil {
+		return err
+	} else if tlsConfig.VerifyPeerCertificate, err = pinnedCertificates(certificate); err != nil {
+		return err
+	}
+
+	c.client = &http.Client{
+		Timeout: agentsTimeout,
+		Transport: &http.Transport{
+			TLSClientConfig: tlsConfig,
+		},
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.config = c
+	return nil
+}
+
+// settings returns the configuration applied by the last Configure.
+func (a *Agents) settings() *agentsConfig {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.config
+}
+
+// request sends a request to the api.rest of the agent and decodes the
+// JSON it replies with into result, if not nil.
+func (a *Agents) request(c *agentsConfig, agent *remoteAgent, method, path string, body interface{}, result interface{}) error {
+	var payload io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(raw)
+	}
+
+	if strings.HasPrefix(agent.URL, "http://") && !c.insecure {
+		return fmt.Errorf("%s is not using https, set agents.insecure to true to send the credentials in clear text.", agent.URL)
+	}
+
+	req, err := http.NewRequest(method, agent.URL+path, payload)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.username, c.password)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s", resp.Status, core.Trim(string(msg)))
+	} else if result != nil {
+		return json.NewDecoder(resp.Body).Decode(result)
+	}
+	return nil
+}
+
+func (a *Agents) getAgent(address string) (*remoteAgent, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if agent, found := a.agents[address]; found {
+		return agent, nil
+	}
+	return nil, fmt.Errorf("Agent %s not found.", address)
+}
+
+func (a *Agents) list() []*remoteAgent {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	list := make([]*remoteAgent, 0, len(a.agents))
+	for _, agent := range a.agents {
+		list = append(list, agent)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Address < list[j].Address
+	})
+	return list
+}
+
+func (a *Agents) addAgent(address string) error {
+	if _, err := a.getAgent(address); err == nil {
+		return fmt.Errorf("Agent %s already added.", address)
+	} else if err := a.Configure(); err != nil {
+		return err
+	}
+
+	agent := &remoteAgent{
+		Address: address,
+		URL:     agentURL(address),
+	}
+
+	iface := &network.Endpoint{}
+	if err := a.request(a.settings(), agent, "GET", "/api/session/interface", nil, iface); err != nil {
+		return fmt.Errorf("Could not reach the agent on %s: %s", agent.URL, err)
+	}
+
+	a.lock.Lock()
+	a.agents[address] = agent
+	a.lock.Unlock()
+
+	log.Info("Agent %s added, it is running on %s (%s).", address, iface.Name(), iface.IpAddress)
+	return nil
+}
+
+func (a *Agents) removeAgent(address string) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if _, found := a.agents[address]; !found {
+		return fmt.Errorf("Agent %s not found.", address)
+	}
+	delete(a.agents, address)
+	return nil
+}
+
+func (a *Agents) run(address, command string) error {
+	agents := a.list()
+	if address != "all" {
+		agent, err := a.getAgent(address)
+		if err != nil {
+			return err
+		}
+		agents = []*remoteAgent{agent}
+	} else if len(agents) == 0 {
+		return fmt.Errorf("No agents, add them with agents.add.")
+	}
+
+	if err := a.Configure(); err != nil {
+		return err
+	}
+
+	c := a.settings()
+	result := core.NewMultiError()
+	for _, agent := range agents {
+		err := a.request(c, agent, "POST", "/api/session", CommandRequest{Command: command}, nil)
+		if err == nil {
+			log.Info("[%s] %s", core.Green(agent.Address), command)
+		}
+		result.Add(agent.Address, err)
+	}
+	return result.ErrorOrNil()
+}
+
+// tagRemote marks the endpoint as found by the agent.
+func (a *Agents) tagRemote(c *agentsConfig, e *network.Endpoint, agent *remoteAgent) {
+	if c.tag != "" && !e.HasTag(c.tag) {
+		e.Tags = append(e.Tags, c.tag)
+	}
+	e.Meta.Set("agent", agent.Address)
+}
+
+// sync merges the hosts, gateway and access points of the agent.
+func (a *Agents) sync(c *agentsConfig, agent *remoteAgent) error {
+	var lan struct {
+		Hosts []*network.Endpoint `json:"hosts"`
+	}
+	var wifi struct {
+		AccessPoints []*network.AccessPoint `json:"aps"`
+	}
+	gateway := &network.Endpoint{}
+
+	if err := a.request(c, agent, "GET", "/api/session/lan", nil, &lan); err != nil {
+		return err
+	} else if err := a.request(c, agent, "GET", "/api/session/gateway", nil, gateway); err != nil {
+		return err
+	} else if err := a.request(c, agent, "GET", "/api/session/wifi", nil, &wifi); err != nil {
+		return err
+	}
+
+	hosts := append(lan.Hosts, gateway)
+	for _, h := range hosts {
+		a.tagRemote(c, h, agent)
+	}
+	for _, ap := range wifi.AccessPoints {
+		a.tagRemote(c, ap.Endpoint, agent)
+	}
+
+	a.Session.Lan.AddRemote(hosts)
+	if a.Session.WiFi != nil {
+		a.Session.WiFi.AddRemote(wifi.AccessPoints)
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	agent.LastSync = time.Now()
+	agent.Hosts = len(lan.Hosts)
+	agent.APs = len(wifi.AccessPoints)
+	return nil
+}
+
+func (a *Agents) syncAll() {
+	c := a.settings()
+	for _, agent := range a.list() {
+		err := a.sync(c, agent)
+
+		a.lock.Lock()
+		if err != nil && agent.Error == nil {
+			log.Warning("Agent %s is not reachable: %s", agent.Address, err)
+		} else if err == nil && agent.Error != nil {
+			log.Info("Agent %s is reachable again.", agent.Address)
+		}
+		agent.Error = err
+		a.lock.Unlock()
+	}
+}
+
+func (a *Agents) show() error {
+	agents := a.list()
+	if len(agents) == 0 {
+		log.Info("No agents, add them with agents.add.")
+		return nil
+	}
+
+	rows := make([][]string, 0, len(agents))
+	for _, agent := range agents {
+		a.lock.Lock()
+		status := core.Green("ok")
+		if agent.Error != nil {
+			status = core.Red(agent.Error.Error())
+		} else if agent.LastSync.IsZero() {
+			status = core.Dim("not synced")
+		}
+
+		lastSync := ""
+		if !agent.LastSync.IsZero() {
+			lastSync = agent.LastSync.Format("15:04:05")
+		}
+
+		rows = append(rows, []string{
+			agent.Address,
+			agent.URL,
+			status,
+			lastSync,
+			fmt.Sprintf("%d", agent.Hosts),
+			fmt.Sprintf("%d", agent.APs),
+		})
+		a.lock.Unlock()
+	}
+
+	core.AsTable(os.Stdout, []string{"Agent", "URL", "Status", "Last Sync", "Hosts", "APs"}, rows)
+	a.Session.Refresh()
+	return nil
+}
+
+func (a *Agents) Start() error {
+	if a.Running() {
+		return session.ErrAlreadyStarted
+	} else if err := a.Configure(); err != nil {
+		return err
+	}
+
+	a.quit = make(chan bool)
+	return a.SetRunning(true, func() {
+		a.waitGroup.Add(1)
+		defer a.waitGroup.Done()
+
+		log.Info("Merging the hosts of %d agents every %s.", len(a.list()), a.settings().period)
+		for {
+			a.syncAll()
+
+			select {
+			case <-time.After(a.settings().period):
+			case <-a.quit:
+				return
+			}
+		}
+	})
+}
+
+func (a *Agents) Stop() error {
+	return a.SetRunning(false, func() {
+		close(a.quit)
+		a.waitGroup.Wait()
+	})
+}
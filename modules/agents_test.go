@@ -0,0 +1,218 @@
+package modules
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bettercap/bettercap/network"
+	"github.com/bettercap/bettercap/session"
+	btls "github.com/bettercap/bettercap/tls"
+)
+
+func TestAgentURL(t *testing.T) {
+	tests := []struct {
+		address  string
+		expected string
+	}{
+		{"192.168.1.10", "https://192.168.1.10:8083"},
+		{"192.168.1.10:9000", "https://192.168.1.10:9000"},
+		{"agent.lan", "https://agent.lan:8083"},
+		{"fe80::1", "https://[fe80::1]:8083"},
+		{"[fe80::1]:9000", "https://[fe80::1]:9000"},
+		{"http://agent.lan:8081/", "http://agent.lan:8081"},
+	}
+
+	for _, test := range tests {
+		if got := agentURL(test.address); got != test.expected {
+			t.Fatalf("expected '%s', got '%s'", test.expected, got)
+		}
+	}
+}
+
+func newTestAgentServer(commands *[]string) *httptest.Server {
+	host := network.NewEndpointNoResolve("10.0.0.10", "de:ad:be:ef:00:01", "", 0)
+	gateway := network.NewEndpointNoResolve("10.0.0.1", "de:ad:be:ef:00:02", "", 0)
+	ap := network.NewAccessPoint("remote", "de:ad:be:ef:00:03", 2437, -50)
+
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "user" || pass != "pass" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/api/session/interface":
+			json.NewEncoder(w).Encode(host)
+		case "/api/session/lan":
+			json.NewEncoder(w).Encode(map[string]interface{}{"hosts": []*network.Endpoint{host}})
+		case "/api/session/gateway":
+			json.NewEncoder(w).Encode(gateway)
+		case "/api/session/wifi":
+			json.NewEncoder(w).Encode(map[string]interface{}{"aps": []*network.AccessPoint{ap}})
+		case "/api/session":
+			var cmd CommandRequest
+			json.NewDecoder(r.Body).Decode(&cmd)
+			if cmd.Command == "fail" {
+				http.Error(w, "unknown command", http.StatusBadRequest)
+				return
+			}
+			*commands = append(*commands, cmd.Command)
+			json.NewEncoder(w).Encode(APIResponse{Success: true})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestAgentsSyncAndRun(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+	s.WiFi = network.NewWiFi(s.Interface, nil, nil)
+
+	commands := []string{}
+	server := newTestAgentServer(&commands)
+	defer server.Close()
+
+	a := NewAgents(s.Session)
+	s.Env.Set("agents.username", "user")
+	s.Env.Set("agents.password", "wrong")
+	if err := a.addAgent(server.URL); err == nil || !strings.Contains(err.Error(), "agents.insecure") {
+		t.Fatalf("expected the unverified agent to be refused, got '%v'", err)
+	}
+
+	s.Env.Set("agents.insecure", "true")
+	if err := a.addAgent(server.URL); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("expected an authentication error, got '%v'", err)
+	} else if len(a.list()) != 0 {
+		t.Fatal("expected the agent not to be added")
+	}
+
+	s.Env.Set("agents.password", "pass")
+	if err := a.addAgent(server.URL); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	a.syncAll()
+	agent, _ := a.getAgent(server.URL)
+	if agent.Error != nil {
+		t.Fatalf("unexpected error: %v", agent.Error)
+	} else if agent.Hosts != 1 || agent.APs != 1 {
+		t.Fatalf("expected 1 host and 1 access point, got %d and %d", agent.Hosts, agent.APs)
+	}
+
+	for _, mac := range []string{"de:ad:be:ef:00:01", "de:ad:be:ef:00:02"} {
+		if e, found := s.Lan.Get(mac); !found {
+			t.Fatalf("expected host %s to be merged", mac)
+		} else if !e.HasTag("agent") || e.Meta.Get("agent") != server.URL {
+			t.Fatalf("unexpected host %+v", e)
+		}
+	}
+	if ap, found := s.WiFi.Get("de:ad:be:ef:00:03"); !found || !ap.HasTag("agent") {
+		t.Fatal("expected the access point to be merged")
+	}
+
+	if err := a.run("all", "net.probe on"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(commands) != 1 || commands[0] != "net.probe on" {
+		t.Fatalf("expected 'net.probe on', got '%v'", commands)
+	} else if err := a.run(server.URL, "fail"); err == nil || !strings.Contains(err.Error(), "unknown command") {
+		t.Fatalf("expected 'unknown command', got '%v'", err)
+	} else if err := a.run("192.168.1.99", "net.probe on"); err == nil {
+		t.Fatal("expected an error for an unknown agent")
+	}
+}
+
+func TestAgentsConfigureWhileSyncing(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+	s.WiFi = network.NewWiFi(s.Interface, nil, nil)
+
+	commands := []string{}
+	server := newTestAgentServer(&commands)
+	defer server.Close()
+
+	a := NewAgents(s.Session)
+	s.Env.Set("agents.username", "user")
+	s.Env.Set("agents.password", "pass")
+	s.Env.Set("agents.insecure", "true")
+	s.Env.Set("agents.period", "1ms")
+	if err := a.addAgent(server.URL); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := a.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// run configures again while the agents are synced
+	for i := 0; i < 10; i++ {
+		if err := a.run("all", "net.probe on"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := a.Stop(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(commands) != 10 {
+		t.Fatalf("expected 10 commands, got %d", len(commands))
+	}
+}
+
+func writeAgentCertificate(t *testing.T, raw []byte) string {
+	fp, err := ioutil.TempFile("", "bettercap-agent")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer fp.Close()
+
+	pem.Encode(fp, &pem.Block{Type: "CERTIFICATE", Bytes: raw})
+	return fp.Name()
+}
+
+func TestAgentsCertificate(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	commands := []string{}
+	server := newTestAgentServer(&commands)
+	defer server.Close()
+
+	cfg := btls.DefaultLegitConfig
+	cfg.Bits = 1024
+	err, _, other := btls.CreateCertificate(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pinned := writeAgentCertificate(t, server.Certificate().Raw)
+	defer os.Remove(pinned)
+	wrong := writeAgentCertificate(t, other)
+	defer os.Remove(wrong)
+
+	a := NewAgents(s.Session)
+	s.Env.Set("agents.username", "user")
+	s.Env.Set("agents.password", "pass")
+
+	s.Env.Set("agents.certificate", wrong)
+	if err := a.addAgent(server.URL); err == nil || !strings.Contains(err.Error(), "certificate") {
+		t.Fatalf("expected a certificate error, got '%v'", err)
+	}
+
+	s.Env.Set("agents.certificate", pinned)
+	if err := a.addAgent(server.URL); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
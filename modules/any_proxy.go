@@ -139,3 +139,12 @@ func (p *AnyProxy) Stop() error {
 
 	return p.SetRunning(false, func() {})
 }
+
+// Revert stops the proxy if it's running, so that its redirection is removed
+// before the firewall is restored.
+func (p *AnyProxy) Revert() (bool, error) {
+	if !p.Running() {
+		return false, nil
+	}
+	return true, p.Stop()
+}
@@ -23,6 +23,9 @@ type RestAPI struct {
 	certFile     string
 	keyFile      string
 	useWebsocket bool
+	wsPackets    bool
+	useGraphQL   bool
+	useMetrics   bool
 	upgrader     websocket.Upgrader
 	quit         chan bool
 }
@@ -74,6 +77,18 @@ func NewRestAPI(s *session.Session) *RestAPI {
 		"false",
 		"If true the /api/events route will be available as a websocket endpoint instead of HTTPS."))
 
+	api.AddParam(session.NewBoolParameter("api.rest.websocket.packets",
+		"false",
+		"If true the /api/ws websocket endpoint will stream the net.sniff.leak.* events of the sniffed packets as well as the other events."))
+
+	api.AddParam(session.NewBoolParameter("api.rest.graphql",
+		"false",
+		"If true the /api/graphql route will be available to query the session hosts, access points, BLE devices and events."))
+
+	api.AddParam(session.NewBoolParameter("api.rest.metrics",
+		"true",
+		"If true the /metrics route will publish the statistics of the session and its modules for Prometheus."))
+
 	api.AddHandler(session.NewModuleHandler("api.rest on", "",
 		"Start REST API server.",
 		func(args []string) error {
@@ -134,6 +149,12 @@ func (api *RestAPI) Configure() error {
 		return err
 	} else if err, api.useWebsocket = api.BoolParam("api.rest.websocket"); err != nil {
 		return err
+	} else if err, api.wsPackets = api.BoolParam("api.rest.websocket.packets"); err != nil {
+		return err
+	} else if err, api.useGraphQL = api.BoolParam("api.rest.graphql"); err != nil {
+		return err
+	} else if err, api.useMetrics = api.BoolParam("api.rest.metrics"); err != nil {
+		return err
 	}
 
 	if !core.Exists(api.certFile) || !core.Exists(api.keyFile) {
@@ -171,6 +192,13 @@ func (api *RestAPI) Configure() error {
 	router.HandleFunc("/api/session/started-at", api.sessionRoute)
 	router.HandleFunc("/api/session/wifi", api.sessionRoute)
 	router.HandleFunc("/api/session/wifi/{mac}", api.sessionRoute)
+	router.HandleFunc("/api/ws", api.wsRoute)
+	if api.useMetrics {
+		router.HandleFunc("/metrics", api.metricsRoute)
+	}
+	if api.useGraphQL {
+		router.HandleFunc("/api/graphql", api.graphqlRoute)
+	}
 
 	api.server.Handler = router
 
@@ -130,7 +130,7 @@ func (api *RestAPI) runSessionCommand(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Bad Request", 400)
 	} else if err = json.NewDecoder(r.Body).Decode(&cmd); err != nil {
 		http.Error(w, "Bad Request", 400)
-	} else if err = session.I.Run(cmd.Command); err != nil {
+	} else if err = session.I.RunFrom(session.OriginAPI, cmd.Command); err != nil {
 		http.Error(w, err.Error(), 400)
 	} else {
 		toJSON(w, APIResponse{Success: true})
@@ -0,0 +1,684 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// This is the small subset of GraphQL needed by /api/graphql: queries
+// with aliases, arguments and variables, without fragments, directives
+// or introspection other than __typename.
+
+// selection sets, lists and objects can't be nested deeper than this,
+// so that a query can't exhaust the stack while it's parsed
+const gqlMaxDepth = 32
+
+const (
+	gqlEOF = iota
+	gqlPunct
+	gqlName
+	gqlInt
+	gqlFloat
+	gqlString
+)
+
+type gqlToken struct {
+	kind  int
+	value string
+	pos   int
+}
+
+func isGqlLetter(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isGqlDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func gqlLex(src string) ([]gqlToken, error) {
+	tokens := make([]gqlToken, 0)
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+
+		case c == '#':
+			for i < len(src) && src[i] != '\n' && src[i] != '\r' {
+				i++
+			}
+
+		case strings.HasPrefix(src[i:], "..."):
+			tokens = append(tokens, gqlToken{gqlPunct, "...", i})
+			i += 3
+
+		case strings.IndexByte("!$():=@[]{}|", c) != -1:
+			tokens = append(tokens, gqlToken{gqlPunct, string(c), i})
+			i++
+
+		case isGqlLetter(c):
+			start := i
+			for i < len(src) && (isGqlLetter(src[i]) || isGqlDigit(src[i])) {
+				i++
+			}
+			tokens = append(tokens, gqlToken{gqlName, src[start:i], start})
+
+		case c == '-' || isGqlDigit(c):
+			start, kind := i, gqlInt
+			if c == '-' {
+				i++
+			}
+			for i < len(src) && isGqlDigit(src[i]) {
+				i++
+			}
+			if i < len(src) && src[i] == '.' {
+				kind = gqlFloat
+				for i++; i < len(src) && isGqlDigit(src[i]); i++ {
+				}
+			}
+			if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+				kind = gqlFloat
+				if i++; i < len(src) && (src[i] == '+' || src[i] == '-') {
+					i++
+				}
+				for i < len(src) && isGqlDigit(src[i]) {
+					i++
+				}
+			}
+			if !isGqlDigit(src[i-1]) {
+				return nil, fmt.Errorf("invalid number '%s' at %d", src[start:i], start)
+			}
+			tokens = append(tokens, gqlToken{kind, src[start:i], start})
+
+		case c == '"':
+			if strings.HasPrefix(src[i:], `"""`) {
+				return nil, fmt.Errorf("block strings are not supported")
+			}
+			start := i
+			for i++; i < len(src) && src[i] != '"' && src[i] != '\n'; i++ {
+				if src[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(src) || src[i] != '"' {
+				return nil, fmt.Errorf("unterminated string at %d", start)
+			}
+			i++
+			value, err := strconv.Unquote(src[start:i])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at %d", start)
+			}
+			tokens = append(tokens, gqlToken{gqlString, value, start})
+
+		default:
+			return nil, fmt.Errorf("unexpected character '%c' at %d", c, i)
+		}
+	}
+	return append(tokens, gqlToken{gqlEOF, "", len(src)}), nil
+}
+
+type gqlSelection struct {
+	Alias      string
+	Name       string
+	Args       map[string]interface{}
+	Selections []*gqlSelection
+}
+
+type gqlOperation struct {
+	Kind       string
+	Name       string
+	Selections []*gqlSelection
+}
+
+type gqlParser struct {
+	tokens    []gqlToken
+	pos       int
+	variables map[string]interface{}
+	// values of the variables defined by the current operation
+	values map[string]interface{}
+	depth  int
+}
+
+// enter is called before parsing a nested selection set, list or object,
+// every successful call must be followed by leave.
+func (p *gqlParser) enter() error {
+	if p.depth >= gqlMaxDepth {
+		return fmt.Errorf("the query is nested more than %d levels", gqlMaxDepth)
+	}
+	p.depth++
+	return nil
+}
+
+func (p *gqlParser) leave() {
+	p.depth--
+}
+
+func (p *gqlParser) next() gqlToken {
+	t := p.tokens[p.pos]
+	if t.kind != gqlEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *gqlParser) is(kind int, value string) bool {
+	t := p.tokens[p.pos]
+	return t.kind == kind && (value == "" || t.value == value)
+}
+
+func (p *gqlParser) unexpected(t gqlToken) error {
+	if t.kind == gqlEOF {
+		return fmt.Errorf("unexpected end of the query")
+	}
+	return fmt.Errorf("unexpected '%s' at %d", t.value, t.pos)
+}
+
+func (p *gqlParser) expect(kind int, value string) (gqlToken, error) {
+	t := p.next()
+	if t.kind != kind || (value != "" && t.value != value) {
+		return t, p.unexpected(t)
+	}
+	return t, nil
+}
+
+// gqlParse parses the operations of the query, replacing the variables
+// with their values.
+func gqlParse(query string, variables map[string]interface{}) ([]*gqlOperation, error) {
+	tokens, err := gqlLex(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &gqlParser{tokens: tokens, variables: variables}
+	ops := make([]*gqlOperation, 0)
+	for !p.is(gqlEOF, "") {
+		op, err := p.parseOperation()
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("the query is empty")
+	}
+	return ops, nil
+}
+
+func (p *gqlParser) parseOperation() (op *gqlOperation, err error) {
+	op = &gqlOperation{Kind: "query"}
+	p.values = make(map[string]interface{})
+
+	if p.is(gqlName, "") {
+		t := p.next()
+		switch t.value {
+		case "query", "mutation", "subscription":
+			op.Kind = t.value
+		case "fragment":
+			return nil, fmt.Errorf("fragments are not supported")
+		default:
+			return nil, p.unexpected(t)
+		}
+
+		if p.is(gqlName, "") {
+			op.Name = p.next().value
+		}
+		if p.is(gqlPunct, "(") {
+			if err = p.parseVariables(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if op.Selections, err = p.parseSelections(); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+func (p *gqlParser) parseVariables() error {
+	p.next()
+	for !p.is(gqlPunct, ")") {
+		if _, err := p.expect(gqlPunct, "$"); err != nil {
+			return err
+		}
+		name, err := p.expect(gqlName, "")
+		if err != nil {
+			return err
+		} else if _, err = p.expect(gqlPunct, ":"); err != nil {
+			return err
+		} else if err = p.skipType(); err != nil {
+			return err
+		}
+
+		value, found := p.variables[name.value]
+		if p.is(gqlPunct, "=") {
+			p.next()
+			dflt, err := p.parseValue(true)
+			if err != nil {
+				return err
+			} else if !found {
+				value = dflt
+			}
+		}
+		p.values[name.value] = value
+	}
+	p.next()
+	return nil
+}
+
+// skipType skips the type of a variable, the arguments are checked
+// against the schema instead.
+func (p *gqlParser) skipType() error {
+	if p.is(gqlPunct, "[") {
+		p.next()
+		if err := p.enter(); err != nil {
+			return err
+		}
+		defer p.leave()
+		if err := p.skipType(); err != nil {
+			return err
+		} else if _, err := p.expect(gqlPunct, "]"); err != nil {
+			return err
+		}
+	} else if _, err := p.expect(gqlName, ""); err != nil {
+		return err
+	}
+
+	if p.is(gqlPunct, "!") {
+		p.next()
+	}
+	return nil
+}
+
+func (p *gqlParser) parseSelections() ([]*gqlSelection, error) {
+	if _, err := p.expect(gqlPunct, "{"); err != nil {
+		return nil, err
+	} else if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	list := make([]*gqlSelection, 0)
+	for !p.is(gqlPunct, "}") {
+		if p.is(gqlPunct, "...") {
+			return nil, fmt.Errorf("fragments are not supported")
+		}
+		sel, err := p.parseSelection()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, sel)
+	}
+	p.next()
+
+	if len(list) == 0 {
+		return nil, fmt.Errorf("empty selection set")
+	}
+	return list, nil
+}
+
+func (p *gqlParser) parseSelection() (*gqlSelection, error) {
+	name, err := p.expect(gqlName, "")
+	if err != nil {
+		return nil, err
+	}
+
+	sel := &gqlSelection{
+		Alias: name.value,
+		Name:  name.value,
+		Args:  make(map[string]interface{}),
+	}
+
+	if p.is(gqlPunct, ":") {
+		p.next()
+		if name, err = p.expect(gqlName, ""); err != nil {
+			return nil, err
+		}
+		sel.Name = name.value
+	}
+
+	if p.is(gqlPunct, "(") {
+		p.next()
+		for !p.is(gqlPunct, ")") {
+			arg, err := p.expect(gqlName, "")
+			if err != nil {
+				return nil, err
+			} else if _, err = p.expect(gqlPunct, ":"); err != nil {
+				return nil, err
+			}
+
+			value, err := p.parseValue(false)
+			if err != nil {
+				return nil, err
+			}
+			// null and missing variables are the same as no argument
+			if value != nil {
+				sel.Args[arg.value] = value
+			}
+		}
+		p.next()
+	}
+
+	if p.is(gqlPunct, "@") {
+		return nil, fmt.Errorf("directives are not supported")
+	} else if p.is(gqlPunct, "{") {
+		if sel.Selections, err = p.parseSelections(); err != nil {
+			return nil, err
+		}
+	}
+	return sel, nil
+}
+
+func (p *gqlParser) parseValue(constant bool) (interface{}, error) {
+	t := p.next()
+	switch t.kind {
+	case gqlPunct:
+		switch t.value {
+		case "$":
+			if constant {
+				return nil, p.unexpected(t)
+			}
+			name, err := p.expect(gqlName, "")
+			if err != nil {
+				return nil, err
+			}
+			value, found := p.values[name.value]
+			if !found {
+				return nil, fmt.Errorf("variable '$%s' is not defined", name.value)
+			}
+			return value, nil
+
+		case "[":
+			if err := p.enter(); err != nil {
+				return nil, err
+			}
+			defer p.leave()
+			list := make([]interface{}, 0)
+			for !p.is(gqlPunct, "]") {
+				value, err := p.parseValue(constant)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, value)
+			}
+			p.next()
+			return list, nil
+
+		case "{":
+			if err := p.enter(); err != nil {
+				return nil, err
+			}
+			defer p.leave()
+			obj := make(map[string]interface{})
+			for !p.is(gqlPunct, "}") {
+				name, err := p.expect(gqlName, "")
+				if err != nil {
+					return nil, err
+				} else if _, err = p.expect(gqlPunct, ":"); err != nil {
+					return nil, err
+				}
+				if obj[name.value], err = p.parseValue(constant); err != nil {
+					return nil, err
+				}
+			}
+			p.next()
+			return obj, nil
+		}
+
+	case gqlName:
+		switch t.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		// enum values
+		return t.value, nil
+
+	case gqlInt:
+		return strconv.ParseInt(t.value, 10, 64)
+
+	case gqlFloat:
+		return strconv.ParseFloat(t.value, 64)
+
+	case gqlString:
+		return t.value, nil
+	}
+	return nil, p.unexpected(t)
+}
+
+// gqlResolver returns the value of a field of the parent object.
+type gqlResolver func(parent interface{}, args map[string]interface{}) (interface{}, error)
+
+// gqlField describes a field of the schema, its type is the name of a
+// scalar or object type, in brackets for lists, and if there's no
+// resolver it's read from the parent map with the same name.
+type gqlField struct {
+	Type    string
+	Args    map[string]string
+	Resolve gqlResolver
+}
+
+// gqlSchema maps the name of every object type to its fields, the root
+// type is Query.
+type gqlSchema map[string]map[string]*gqlField
+
+type gqlError struct {
+	Message string `json:"message"`
+}
+
+type gqlResponse struct {
+	Data   interface{} `json:"data"`
+	Errors []gqlError  `json:"errors,omitempty"`
+}
+
+// gqlObject is a result object, which keeps the fields in the order they
+// have been selected.
+type gqlObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (o *gqlObject) Set(key string, value interface{}) {
+	if _, found := o.values[key]; !found {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *gqlObject) MarshalJSON() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		v, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func gqlFailure(err error) *gqlResponse {
+	return &gqlResponse{Errors: []gqlError{{Message: err.Error()}}}
+}
+
+// Execute runs the operation of the query with the given name, which can
+// be empty if the query has only one operation.
+func (s gqlSchema) Execute(root interface{}, query string, operation string, variables map[string]interface{}) *gqlResponse {
+	ops, err := gqlParse(query, variables)
+	if err != nil {
+		return gqlFailure(err)
+	}
+
+	var op *gqlOperation
+	for _, o := range ops {
+		if (operation == "" && len(ops) == 1) || (operation != "" && o.Name == operation) {
+			op = o
+		}
+	}
+
+	if op == nil && operation == "" {
+		return gqlFailure(fmt.Errorf("the operation name is required for multiple operations"))
+	} else if op == nil {
+		return gqlFailure(fmt.Errorf("unknown operation '%s'", operation))
+	} else if op.Kind != "query" {
+		return gqlFailure(fmt.Errorf("only queries are supported"))
+	}
+
+	data, err := s.executeObject("Query", root, op.Selections)
+	if err != nil {
+		return gqlFailure(err)
+	}
+	return &gqlResponse{Data: data}
+}
+
+func (s gqlSchema) executeObject(typeName string, parent interface{}, selections []*gqlSelection) (*gqlObject, error) {
+	fields := s[typeName]
+	obj := &gqlObject{values: make(map[string]interface{})}
+
+	for _, sel := range selections {
+		if sel.Name == "__typename" {
+			obj.Set(sel.Alias, typeName)
+			continue
+		}
+
+		field, found := fields[sel.Name]
+		if !found {
+			return nil, fmt.Errorf("cannot query field '%s' on type '%s'", sel.Name, typeName)
+		}
+
+		args, err := gqlArguments(field, sel)
+		if err != nil {
+			return nil, err
+		}
+
+		var value interface{}
+		if field.Resolve != nil {
+			if value, err = field.Resolve(parent, args); err != nil {
+				return nil, fmt.Errorf("%s: %s", sel.Alias, err)
+			}
+		} else if doc, ok := parent.(map[string]interface{}); ok {
+			value = doc[sel.Name]
+		}
+
+		if value, err = s.complete(field.Type, value, sel); err != nil {
+			return nil, err
+		}
+		obj.Set(sel.Alias, value)
+	}
+
+	return obj, nil
+}
+
+func (s gqlSchema) complete(typeName string, value interface{}, sel *gqlSelection) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(typeName, "[") {
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("field '%s' is not a list", sel.Name)
+		}
+
+		elem := typeName[1 : len(typeName)-1]
+		list := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			v, err := s.complete(elem, item, sel)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	}
+
+	if _, found := s[typeName]; found {
+		if sel.Selections == nil {
+			return nil, fmt.Errorf("field '%s' of type '%s' must have a selection of subfields", sel.Name, typeName)
+		}
+		return s.executeObject(typeName, value, sel.Selections)
+	} else if sel.Selections != nil {
+		return nil, fmt.Errorf("field '%s' of type '%s' must not have a selection", sel.Name, typeName)
+	}
+
+	v, err := gqlCoerce(typeName, value)
+	if err != nil {
+		return nil, fmt.Errorf("field '%s': %s", sel.Name, err)
+	}
+	return v, nil
+}
+
+func gqlArguments(field *gqlField, sel *gqlSelection) (map[string]interface{}, error) {
+	args := make(map[string]interface{})
+	for name, value := range sel.Args {
+		typeName, found := field.Args[name]
+		if !found {
+			return nil, fmt.Errorf("unknown argument '%s' on field '%s'", name, sel.Name)
+		}
+
+		v, err := gqlCoerce(typeName, value)
+		if err != nil {
+			return nil, fmt.Errorf("argument '%s' of field '%s': %s", name, sel.Name, err)
+		}
+		args[name] = v
+	}
+	return args, nil
+}
+
+// gqlCoerce converts the value to the scalar type, numbers coming from
+// JSON documents are always float64.
+func gqlCoerce(typeName string, value interface{}) (interface{}, error) {
+	switch typeName {
+	case "Int":
+		switch v := value.(type) {
+		case int64:
+			return v, nil
+		case int:
+			return int64(v), nil
+		case float64:
+			if v == math.Trunc(v) {
+				return int64(v), nil
+			}
+		}
+
+	case "Float":
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case int64:
+			return float64(v), nil
+		}
+
+	case "String":
+		if v, ok := value.(string); ok {
+			return v, nil
+		}
+
+	case "Boolean":
+		if v, ok := value.(bool); ok {
+			return v, nil
+		}
+
+	case "JSON":
+		return value, nil
+	}
+
+	return nil, fmt.Errorf("'%v' is not a valid %s", value, typeName)
+}
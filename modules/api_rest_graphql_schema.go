@@ -0,0 +1,409 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bettercap/bettercap/network"
+	"github.com/bettercap/bettercap/session"
+)
+
+// POST requests to /api/graphql bigger than this are refused
+const gqlMaxBody = 1024 * 1024
+
+var gqlPageArgs = map[string]string{
+	"filter": "String",
+	"first":  "Int",
+	"offset": "Int",
+}
+
+var gqlEndpointFields = map[string]*gqlField{
+	"ipv4":        {Type: "String"},
+	"ipv6":        {Type: "String"},
+	"mac":         {Type: "String"},
+	"hostname":    {Type: "String"},
+	"alias":       {Type: "String"},
+	"vendor":      {Type: "String"},
+	"tags":        {Type: "[String]"},
+	"note":        {Type: "String"},
+	"first_seen":  {Type: "String"},
+	"last_seen":   {Type: "String"},
+	"ports":       {Type: "[Int]", Resolve: gqlPorts},
+	"meta":        {Type: "[MetaValue]", Resolve: gqlMeta},
+	"traffic":     {Type: "Traffic"},
+	"fingerprint": {Type: "Fingerprint"},
+}
+
+var gqlStationFields = map[string]*gqlField{
+	"frequency":      {Type: "Int"},
+	"channel":        {Type: "Int", Resolve: gqlChannel},
+	"rssi":           {Type: "Int"},
+	"sent":           {Type: "Int"},
+	"received":       {Type: "Int"},
+	"encryption":     {Type: "String"},
+	"cipher":         {Type: "String"},
+	"authentication": {Type: "String"},
+}
+
+// the fields matched by the filter argument
+var (
+	gqlEndpointFilter = []string{"ipv4", "ipv6", "mac", "hostname", "alias", "vendor", "tags", "note"}
+	gqlStationFilter  = append([]string{"encryption"}, gqlEndpointFilter...)
+	gqlBLEFilter      = []string{"mac", "name", "vendor"}
+)
+
+func gqlMergeFields(sets ...map[string]*gqlField) map[string]*gqlField {
+	fields := make(map[string]*gqlField)
+	for _, set := range sets {
+		for name, field := range set {
+			fields[name] = field
+		}
+	}
+	return fields
+}
+
+// gqlSessionSchema is the schema of /api/graphql, the objects are the
+// JSON documents returned by the /api/session routes.
+var gqlSessionSchema = gqlSchema{
+	"Query": {
+		"interface": {Type: "Host", Resolve: gqlInterface},
+		"gateway":   {Type: "Host", Resolve: gqlGateway},
+		"hosts":     {Type: "[Host]", Args: gqlPageArgs, Resolve: gqlHosts},
+		"host":      {Type: "Host", Args: map[string]string{"mac": "String", "ip": "String"}, Resolve: gqlHost},
+		"aps":       {Type: "[AccessPoint]", Args: gqlPageArgs, Resolve: gqlAccessPoints},
+		"ap":        {Type: "AccessPoint", Args: map[string]string{"mac": "String"}, Resolve: gqlAccessPoint},
+		"ble":       {Type: "[BLEDevice]", Args: gqlPageArgs, Resolve: gqlBLEDevices},
+		"events": {
+			Type:    "[Event]",
+			Args:    map[string]string{"tag": "String", "first": "Int", "offset": "Int", "last": "Int"},
+			Resolve: gqlEvents,
+		},
+	},
+	"Host":    gqlEndpointFields,
+	"Station": gqlMergeFields(gqlEndpointFields, gqlStationFields),
+	"AccessPoint": gqlMergeFields(gqlEndpointFields, gqlStationFields, map[string]*gqlField{
+		"clients": {Type: "[Station]", Args: gqlPageArgs, Resolve: gqlClients},
+	}),
+	"BLEDevice": {
+		"mac":       {Type: "String"},
+		"name":      {Type: "String"},
+		"vendor":    {Type: "String"},
+		"rssi":      {Type: "Int"},
+		"last_seen": {Type: "String"},
+	},
+	"Event": {
+		"tag":  {Type: "String"},
+		"time": {Type: "String"},
+		"data": {Type: "JSON"},
+	},
+	"MetaValue": {
+		"name":  {Type: "String"},
+		"value": {Type: "JSON"},
+	},
+	"Traffic": {
+		"sent":         {Type: "Int"},
+		"received":     {Type: "Int"},
+		"pkt_sent":     {Type: "Int"},
+		"pkt_received": {Type: "Int"},
+	},
+	"Fingerprint": {
+		"os":      {Type: "String"},
+		"device":  {Type: "String"},
+		"model":   {Type: "String"},
+		"sources": {Type: "JSON"},
+	},
+}
+
+// gqlDocument converts an object of the session to its JSON document.
+func gqlDocument(o interface{}) (interface{}, error) {
+	raw, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc interface{}
+	if err = json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func gqlDocuments(list interface{}) ([]interface{}, error) {
+	doc, err := gqlDocument(list)
+	if err != nil {
+		return nil, err
+	}
+	docs, _ := doc.([]interface{})
+	return docs, nil
+}
+
+// gqlMatches returns true if one of the fields of the document, or one
+// of its elements for lists, contains the filter ignoring the case.
+func gqlMatches(doc interface{}, filter string, fields []string) bool {
+	obj, _ := doc.(map[string]interface{})
+	for _, name := range fields {
+		values, ok := obj[name].([]interface{})
+		if !ok {
+			values = []interface{}{obj[name]}
+		}
+		for _, value := range values {
+			if s, ok := value.(string); ok && strings.Contains(strings.ToLower(s), filter) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// gqlPage filters the documents and returns the ones selected by the
+// offset and first arguments.
+func gqlPage(docs []interface{}, args map[string]interface{}, fields []string) []interface{} {
+	if filter, ok := args["filter"].(string); ok && filter != "" {
+		filter = strings.ToLower(filter)
+		filtered := make([]interface{}, 0)
+		for _, doc := range docs {
+			if gqlMatches(doc, filter, fields) {
+				filtered = append(filtered, doc)
+			}
+		}
+		docs = filtered
+	}
+	return gqlSlice(docs, args)
+}
+
+func gqlSlice(docs []interface{}, args map[string]interface{}) []interface{} {
+	if offset, ok := args["offset"].(int64); ok && offset > 0 {
+		if offset > int64(len(docs)) {
+			offset = int64(len(docs))
+		}
+		docs = docs[offset:]
+	}
+	if first, ok := args["first"].(int64); ok && first >= 0 && first < int64(len(docs)) {
+		docs = docs[:first]
+	}
+	return docs
+}
+
+func gqlSession(parent interface{}) *session.Session {
+	return parent.(*session.Session)
+}
+
+func gqlInterface(parent interface{}, args map[string]interface{}) (interface{}, error) {
+	return gqlDocument(gqlSession(parent).Interface)
+}
+
+func gqlGateway(parent interface{}, args map[string]interface{}) (interface{}, error) {
+	return gqlDocument(gqlSession(parent).Gateway)
+}
+
+func gqlHosts(parent interface{}, args map[string]interface{}) (interface{}, error) {
+	s := gqlSession(parent)
+	hosts := make([]*network.Endpoint, 0)
+	if s.Lan != nil {
+		hosts = s.Lan.List()
+	}
+	sort.Sort(ByAddressSorter(hosts))
+
+	docs, err := gqlDocuments(hosts)
+	if err != nil {
+		return nil, err
+	}
+	return gqlPage(docs, args, gqlEndpointFilter), nil
+}
+
+func gqlHost(parent interface{}, args map[string]interface{}) (interface{}, error) {
+	s := gqlSession(parent)
+	if s.Lan == nil {
+		return nil, nil
+	}
+
+	if mac, ok := args["mac"].(string); ok {
+		if e, found := s.Lan.Get(network.NormalizeMac(mac)); found {
+			return gqlDocument(e)
+		}
+		return nil, nil
+	} else if ip, ok := args["ip"].(string); ok {
+		if e := s.Lan.GetByIp(ip); e != nil {
+			return gqlDocument(e)
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("either mac or ip is required")
+}
+
+func gqlAccessPoints(parent interface{}, args map[string]interface{}) (interface{}, error) {
+	s := gqlSession(parent)
+	aps := make([]*network.AccessPoint, 0)
+	if s.WiFi != nil {
+		aps = s.WiFi.List()
+	}
+	sort.Slice(aps, func(i, j int) bool {
+		return aps[i].HwAddress < aps[j].HwAddress
+	})
+
+	docs, err := gqlDocuments(aps)
+	if err != nil {
+		return nil, err
+	}
+	return gqlPage(docs, args, gqlStationFilter), nil
+}
+
+func gqlAccessPoint(parent interface{}, args map[string]interface{}) (interface{}, error) {
+	s := gqlSession(parent)
+	mac, ok := args["mac"].(string)
+	if !ok {
+		return nil, fmt.Errorf("mac is required")
+	} else if s.WiFi == nil {
+		return nil, nil
+	}
+
+	if ap, found := s.WiFi.Get(network.NormalizeMac(mac)); found {
+		return gqlDocument(ap)
+	}
+	return nil, nil
+}
+
+func gqlClients(parent interface{}, args map[string]interface{}) (interface{}, error) {
+	doc, _ := parent.(map[string]interface{})
+	clients, _ := doc["clients"].([]interface{})
+	sort.Slice(clients, func(i, j int) bool {
+		a, _ := clients[i].(map[string]interface{})
+		b, _ := clients[j].(map[string]interface{})
+		return fmt.Sprintf("%v", a["mac"]) < fmt.Sprintf("%v", b["mac"])
+	})
+	return gqlPage(clients, args, gqlStationFilter), nil
+}
+
+func gqlBLEDevices(parent interface{}, args map[string]interface{}) (interface{}, error) {
+	s := gqlSession(parent)
+	devices := make([]*network.BLEDevice, 0)
+	if s.BLE != nil {
+		devices = s.BLE.Devices()
+	}
+
+	// the devices have no exported fields on every platform
+	docs, err := gqlDocuments(devices)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(docs, func(i, j int) bool {
+		a, _ := docs[i].(map[string]interface{})
+		b, _ := docs[j].(map[string]interface{})
+		return fmt.Sprintf("%v", a["mac"]) < fmt.Sprintf("%v", b["mac"])
+	})
+	return gqlPage(docs, args, gqlBLEFilter), nil
+}
+
+func gqlEvents(parent interface{}, args map[string]interface{}) (interface{}, error) {
+	s := gqlSession(parent)
+	tag, _ := args["tag"].(string)
+
+	events := make([]session.Event, 0)
+	for _, e := range s.Events.Sorted() {
+		if strings.HasPrefix(e.Tag, tag) {
+			events = append(events, e)
+		}
+	}
+	if last, ok := args["last"].(int64); ok && last >= 0 && last < int64(len(events)) {
+		events = events[int64(len(events))-last:]
+	}
+
+	docs := make([]interface{}, len(events))
+	for i := range events {
+		docs[i] = events[i]
+	}
+	docs = gqlSlice(docs, args)
+
+	// only the selected events are converted
+	return gqlDocuments(docs)
+}
+
+// gqlPorts returns the open ports found by syn.scan.
+func gqlPorts(parent interface{}, args map[string]interface{}) (interface{}, error) {
+	doc, _ := parent.(map[string]interface{})
+	meta, _ := doc["meta"].(map[string]interface{})
+	values, _ := meta["values"].(map[string]interface{})
+	list, _ := values["tcp-ports"].(string)
+
+	ports := make([]interface{}, 0)
+	for _, port := range strings.Split(list, ",") {
+		if n, err := strconv.Atoi(strings.TrimSpace(port)); err == nil {
+			ports = append(ports, int64(n))
+		}
+	}
+	return ports, nil
+}
+
+func gqlMeta(parent interface{}, args map[string]interface{}) (interface{}, error) {
+	doc, _ := parent.(map[string]interface{})
+	meta, _ := doc["meta"].(map[string]interface{})
+	values, _ := meta["values"].(map[string]interface{})
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		list = append(list, map[string]interface{}{
+			"name":  name,
+			"value": values[name],
+		})
+	}
+	return list, nil
+}
+
+func gqlChannel(parent interface{}, args map[string]interface{}) (interface{}, error) {
+	doc, _ := parent.(map[string]interface{})
+	if freq, ok := doc["frequency"].(float64); ok {
+		return int64(network.Dot11Freq2Chan(int(freq))), nil
+	}
+	return nil, nil
+}
+
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// graphqlRoute runs the queries sent either as the parameters of a GET
+// request or as the JSON body of a POST one.
+func (api *RestAPI) graphqlRoute(w http.ResponseWriter, r *http.Request) {
+	setSecurityHeaders(w)
+
+	if !api.checkAuth(r) {
+		setAuthFailed(w, r)
+		return
+	}
+
+	req := graphqlRequest{}
+	if r.Method == "GET" {
+		q := r.URL.Query()
+		req.Query = q.Get("query")
+		req.OperationName = q.Get("operationName")
+		if vars := q.Get("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				http.Error(w, "Bad Request", 400)
+				return
+			}
+		}
+	} else if r.Method == "POST" {
+		r.Body = http.MaxBytesReader(w, r.Body, gqlMaxBody)
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Bad Request", 400)
+			return
+		}
+	} else {
+		http.Error(w, "Bad Request", 400)
+		return
+	}
+
+	toJSON(w, gqlSessionSchema.Execute(session.I, req.Query, req.OperationName, req.Variables))
+}
@@ -0,0 +1,190 @@
+package modules
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/bettercap/bettercap/network"
+	"github.com/bettercap/bettercap/session"
+)
+
+func gqlTestSession(t *testing.T) *session.TestSession {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, host := range []struct {
+		ip  string
+		mac string
+	}{
+		{"192.168.1.30", "aa:00:00:00:00:30"},
+		{"192.168.1.10", "aa:00:00:00:00:10"},
+		{"192.168.1.20", "aa:00:00:00:00:20"},
+	} {
+		s.Lan.AddIfNew(host.ip, host.mac)
+	}
+
+	e, _ := s.Lan.Get("aa:00:00:00:00:20")
+	e.Hostname = "printer"
+	e.Meta.SetInts("tcp-ports", []int{22, 631})
+
+	s.WiFi = network.NewWiFi(s.Interface, func(ap *network.AccessPoint) {}, func(ap *network.AccessPoint) {})
+	s.WiFi.AddIfNew("office", "bb:00:00:00:00:01", 2437, -40)
+	ap, _ := s.WiFi.Get("bb:00:00:00:00:01")
+	ap.AddClient("cc:00:00:00:00:02", 2437, -60)
+	ap.AddClient("cc:00:00:00:00:01", 2437, -50)
+
+	s.Events.Add("endpoint.new", e)
+	s.Events.Add("wifi.ap.new", ap)
+	s.Events.Add("endpoint.lost", e)
+
+	return s
+}
+
+func TestGraphQLQueries(t *testing.T) {
+	s := gqlTestSession(t)
+	defer s.Close()
+
+	var units = []struct {
+		query     string
+		variables map[string]interface{}
+		expected  string
+	}{
+		{
+			`{ hosts { ipv4 } }`,
+			nil,
+			`{"hosts":[{"ipv4":"192.168.1.10"},{"ipv4":"192.168.1.20"},{"ipv4":"192.168.1.30"}]}`,
+		},
+		{
+			`{ hosts(offset: 1, first: 1) { mac } }`,
+			nil,
+			`{"hosts":[{"mac":"aa:00:00:00:00:20"}]}`,
+		},
+		{
+			`query Printer($f: String) { hosts(filter: $f) { name: hostname, ports } }`,
+			map[string]interface{}{"f": "PRINT"},
+			`{"hosts":[{"name":"printer","ports":[22,631]}]}`,
+		},
+		{
+			`query ($ip: String = "192.168.1.30") { host(ip: $ip) { __typename mac } missing: host(mac: "ff:ff:ff:ff:ff:ff") { mac } }`,
+			nil,
+			`{"host":{"__typename":"Host","mac":"aa:00:00:00:00:30"},"missing":null}`,
+		},
+		{
+			`{ gateway { ipv4 } interface { hostname } }`,
+			nil,
+			`{"gateway":{"ipv4":"192.168.1.1"},"interface":{"hostname":"test0"}}`,
+		},
+		{
+			`{ aps { hostname channel clients(first: 1) { mac rssi } } }`,
+			nil,
+			`{"aps":[{"hostname":"office","channel":6,"clients":[{"mac":"cc:00:00:00:00:01","rssi":-50}]}]}`,
+		},
+		{
+			`{ ble { mac } }`,
+			nil,
+			`{"ble":[]}`,
+		},
+		{
+			`{ events(tag: "endpoint.") { tag } last: events(last: 1) { tag } }`,
+			nil,
+			`{"events":[{"tag":"endpoint.new"},{"tag":"endpoint.lost"}],"last":[{"tag":"endpoint.lost"}]}`,
+		},
+	}
+
+	for _, u := range units {
+		res := gqlSessionSchema.Execute(s.Session, u.query, "", u.variables)
+		if len(res.Errors) > 0 {
+			t.Fatalf("unexpected error for '%s': %s", u.query, res.Errors[0].Message)
+		}
+
+		raw, err := json.Marshal(res.Data)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if got := string(raw); got != u.expected {
+			t.Fatalf("expected '%s', got '%s'", u.expected, got)
+		}
+	}
+}
+
+func TestGraphQLErrors(t *testing.T) {
+	s := gqlTestSession(t)
+	defer s.Close()
+
+	var units = []struct {
+		query    string
+		expected string
+	}{
+		{``, "the query is empty"},
+		{`{ hosts { ipv4 }`, "unexpected end of the query"},
+		{`{ hosts }`, "field 'hosts' of type 'Host' must have a selection of subfields"},
+		{`{ hosts { mac { x } } }`, "field 'mac' of type 'String' must not have a selection"},
+		{`{ routers { mac } }`, "cannot query field 'routers' on type 'Query'"},
+		{`{ hosts(limit: 1) { mac } }`, "unknown argument 'limit' on field 'hosts'"},
+		{`{ hosts(first: "one") { mac } }`, "argument 'first' of field 'hosts': 'one' is not a valid Int"},
+		{`{ hosts(first: $n) { mac } }`, "variable '$n' is not defined"},
+		{`{ host { mac } }`, "host: either mac or ip is required"},
+		{`{ hosts { ...fields } }`, "fragments are not supported"},
+		{`mutation { hosts { mac } }`, "only queries are supported"},
+		{`query A { hosts { mac } } query B { aps { mac } }`, "the operation name is required for multiple operations"},
+		{"{ " + strings.Repeat("hosts { ", 40) + "mac" + strings.Repeat(" }", 41), "the query is nested more than 32 levels"},
+		{"{ hosts(filter: " + strings.Repeat("[", 40) + strings.Repeat("]", 40) + ") { mac } }", "the query is nested more than 32 levels"},
+		{"query Q($f: " + strings.Repeat("[", 40) + "String" + strings.Repeat("]", 40) + ") { hosts { mac } }", "the query is nested more than 32 levels"},
+	}
+
+	for _, u := range units {
+		res := gqlSessionSchema.Execute(s.Session, u.query, "", nil)
+		if res.Data != nil {
+			t.Fatalf("expected no data for '%s'", u.query)
+		} else if len(res.Errors) != 1 {
+			t.Fatalf("expected one error for '%s', got %d", u.query, len(res.Errors))
+		} else if got := res.Errors[0].Message; got != u.expected {
+			t.Fatalf("expected '%s', got '%s'", u.expected, got)
+		}
+	}
+}
+
+func TestRestAPIGraphQL(t *testing.T) {
+	s := gqlTestSession(t)
+	defer s.Close()
+
+	api := NewRestAPI(s.Session)
+	api.username = "user"
+	api.password = "pass"
+
+	req := httptest.NewRequest("GET", "/api/graphql?query="+url.QueryEscape("{ hosts { ipv4 } }"), nil)
+	rec := httptest.NewRecorder()
+	api.graphqlRoute(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected %d, got %d", http.StatusUnauthorized, rec.Code)
+	}
+
+	body := `{"query":"query H($mac: String) { host(mac: $mac) { hostname } }","operationName":"H","variables":{"mac":"AA:00:00:00:00:20"}}`
+	req = httptest.NewRequest("POST", "/api/graphql", strings.NewReader(body))
+	req.SetBasicAuth("user", "pass")
+	rec = httptest.NewRecorder()
+	api.graphqlRoute(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	expected := `{"data":{"host":{"hostname":"printer"}}}`
+	if got := strings.TrimSpace(rec.Body.String()); got != expected {
+		t.Fatalf("expected '%s', got '%s'", expected, got)
+	}
+
+	for _, body := range []string{"{ hosts", `{"query":"` + strings.Repeat(" ", gqlMaxBody) + `{ hosts { ipv4 } }"}`} {
+		req = httptest.NewRequest("POST", "/api/graphql", strings.NewReader(body))
+		req.SetBasicAuth("user", "pass")
+		rec = httptest.NewRecorder()
+		api.graphqlRoute(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected %d, got %d", http.StatusBadRequest, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package modules
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bettercap/bettercap/network"
+	"github.com/bettercap/bettercap/session"
+)
+
+var metricLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func metricLabel(name string, value string) string {
+	return metricLabels(map[string]string{name: value})
+}
+
+// metricLabels renders the labels of a sample sorted by name.
+func metricLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, name, metricLabelEscaper.Replace(labels[name]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// writeMetrics renders the metrics in the Prometheus text exposition
+// format, the samples of a metric grouped after its help and type.
+func writeMetrics(buf *bytes.Buffer, metrics []session.Metric) {
+	order := make([]string, 0)
+	byName := make(map[string][]session.Metric)
+	for _, m := range metrics {
+		if _, found := byName[m.Name]; !found {
+			order = append(order, m.Name)
+		}
+		byName[m.Name] = append(byName[m.Name], m)
+	}
+
+	for _, name := range order {
+		samples := byName[name]
+		fmt.Fprintf(buf, "# HELP %s %s\n", name, samples[0].Help)
+		fmt.Fprintf(buf, "# TYPE %s %s\n", name, samples[0].Type)
+		for _, sample := range samples {
+			fmt.Fprintf(buf, "%s%s %s\n", name, metricLabels(sample.Labels), strconv.FormatFloat(sample.Value, 'f', -1, 64))
+		}
+	}
+}
+
+// hostMetrics returns the traffic of every host the packet queue has
+// seen.
+func hostMetrics(s *session.Session) []session.Metric {
+	hosts := append([]*network.Endpoint{s.Gateway}, s.Lan.List()...)
+	sort.Slice(hosts[1:], func(i, j int) bool {
+		return hosts[i+1].IpAddressUint32 < hosts[j+1].IpAddressUint32
+	})
+
+	metrics := make([]session.Metric, 0, len(hosts)*2)
+	for _, e := range hosts {
+		if e == nil {
+			continue
+		}
+		sent, rcvd := e.Traffic.Bytes()
+		labels := map[string]string{"ip": e.IpAddress, "mac": e.HwAddress}
+		metrics = append(metrics,
+			session.NewCounter("bettercap_host_sent_bytes_total", "Number of bytes sent by the host.", sent, labels),
+			session.NewCounter("bettercap_host_received_bytes_total", "Number of bytes received by the host.", rcvd, labels))
+	}
+	return metrics
+}
+
+func runtimeMetrics() []session.Metric {
+	mem := runtime.MemStats{}
+	runtime.ReadMemStats(&mem)
+
+	return []session.Metric{
+		session.NewGauge("go_goroutines", "Number of goroutines that currently exist.", float64(runtime.NumGoroutine()), nil),
+		session.NewGauge("go_memstats_alloc_bytes", "Number of bytes allocated and still in use.", float64(mem.Alloc), nil),
+		session.NewGauge("go_memstats_sys_bytes", "Number of bytes obtained from the system.", float64(mem.Sys), nil),
+		session.NewGauge("go_memstats_heap_objects", "Number of allocated objects.", float64(mem.HeapObjects), nil),
+		session.NewCounter("go_gc_cycles_total", "Number of completed GC cycles.", uint64(mem.NumGC), nil),
+		{
+			Name:  "go_gc_pause_seconds_total",
+			Type:  "counter",
+			Help:  "Total time the GC stopped the world.",
+			Value: float64(mem.PauseTotalNs) / 1e9,
+		},
+	}
+}
+
+// buildMetrics renders the session state in the Prometheus text
+// exposition format.
+func buildMetrics(s *session.Session) []byte {
+	metrics := make([]session.Metric, 0)
+
+	if s.Queue != nil {
+		stats := &s.Queue.Stats
+		stats.RLock()
+		captured, received, sent, errors := stats.PktReceived, stats.Received, stats.Sent, stats.Errors
+		stats.RUnlock()
+
+		metrics = append(metrics,
+			session.NewCounter("bettercap_packets_captured_total", "Number of packets captured.", captured, nil),
+			session.NewCounter("bettercap_packets_received_bytes_total", "Number of bytes captured.", received, nil),
+			session.NewCounter("bettercap_packets_sent_bytes_total", "Number of bytes injected.", sent, nil),
+			session.NewCounter("bettercap_packets_errors_total", "Number of errors while injecting packets.", errors, nil))
+	}
+
+	if s.Events != nil {
+		metrics = append(metrics, session.NewCounter("bettercap_events_total", "Number of events emitted.", s.Events.Emitted(), nil))
+	}
+
+	if s.Lan != nil {
+		metrics = append(metrics, session.NewGauge("bettercap_lan_hosts", "Number of hosts discovered on the network.", float64(len(s.Lan.List())), nil))
+		metrics = append(metrics, hostMetrics(s)...)
+	}
+
+	if s.WiFi != nil {
+		metrics = append(metrics, session.NewGauge("bettercap_wifi_access_points", "Number of WiFi access points discovered.", float64(len(s.WiFi.List())), nil))
+	}
+
+	if s.BLE != nil {
+		metrics = append(metrics, session.NewGauge("bettercap_ble_devices", "Number of BLE devices discovered.", float64(len(s.BLE.Devices())), nil))
+	}
+
+	modules := make([]session.Module, len(s.Modules))
+	copy(modules, s.Modules)
+	sort.Slice(modules, func(i, j int) bool {
+		return modules[i].Name() < modules[j].Name()
+	})
+
+	for _, m := range modules {
+		running := 0.0
+		if m.Running() {
+			running = 1
+		}
+		metrics = append(metrics, session.NewGauge("bettercap_module_running", "Whether the module is running.", running, map[string]string{"module": m.Name()}))
+	}
+
+	for _, m := range modules {
+		if r, ok := m.(session.MetricsReporter); ok {
+			for _, metric := range r.Metrics() {
+				labels := map[string]string{"module": m.Name()}
+				for name, value := range metric.Labels {
+					labels[name] = value
+				}
+				metric.Labels = labels
+				metrics = append(metrics, metric)
+			}
+		}
+	}
+
+	metrics = append(metrics, runtimeMetrics()...)
+
+	buf := &bytes.Buffer{}
+	writeMetrics(buf, metrics)
+	return buf.Bytes()
+}
+
+func (api *RestAPI) metricsRoute(w http.ResponseWriter, r *http.Request) {
+	setSecurityHeaders(w)
+
+	if !api.checkAuth(r) {
+		setAuthFailed(w, r)
+		return
+	} else if r.Method != "GET" {
+		http.Error(w, "Bad Request", 400)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write(buildMetrics(api.Session))
+}
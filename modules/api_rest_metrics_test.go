@@ -0,0 +1,92 @@
+package modules
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bettercap/bettercap/session"
+)
+
+func TestRestAPIMetrics(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	mc := NewMacChanger(s.Session)
+	s.Register(mc)
+	s.Register(NewDiscovery(s.Session))
+	s.Register(NewDNSSpoofer(s.Session))
+	s.Lan.AddIfNew("192.168.1.10", "aa:00:00:00:00:10")
+	if err := mc.SetRunning(true, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s.Events.Add("test", nil)
+	s.Events.Add("test", nil)
+
+	api := NewRestAPI(s.Session)
+	api.username = "user"
+	api.password = "pass"
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	rec := httptest.NewRecorder()
+	api.metricsRoute(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected %d, got %d", http.StatusUnauthorized, rec.Code)
+	}
+
+	req.SetBasicAuth("user", "pass")
+	rec = httptest.NewRecorder()
+	api.metricsRoute(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	body := rec.Body.String()
+	for _, exp := range []string{
+		"# TYPE bettercap_module_running gauge\n",
+		"bettercap_module_running{module=\"mac.changer\"} 1\n",
+		"bettercap_module_running{module=\"net.recon\"} 0\n",
+		"bettercap_events_total 2\n",
+		"bettercap_lan_hosts 1\n",
+		"bettercap_host_sent_bytes_total{ip=\"192.168.1.10\",mac=\"aa:00:00:00:00:10\"} 0\n",
+		"# TYPE bettercap_module_packets_injected_total counter\n",
+		"bettercap_module_packets_injected_total{kind=\"reply\",module=\"dns.spoof\"} 0\n",
+		"# TYPE go_goroutines gauge\n",
+	} {
+		if !strings.Contains(body, exp) {
+			t.Fatalf("expected '%s' in '%s'", exp, body)
+		}
+	}
+}
+
+func TestMetricLabel(t *testing.T) {
+	if got := metricLabel("module", "a\"b\\c\n"); got != `{module="a\"b\\c\n"}` {
+		t.Fatalf("unexpected label '%s'", got)
+	}
+}
+
+func TestWriteMetrics(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writeMetrics(buf, []session.Metric{
+		session.NewCounter("a_total", "A.", 1, map[string]string{"kind": "x"}),
+		session.NewGauge("b", "B.", 0.5, nil),
+		session.NewCounter("a_total", "A.", 2, map[string]string{"kind": "y"}),
+	})
+
+	exp := "# HELP a_total A.\n" +
+		"# TYPE a_total counter\n" +
+		"a_total{kind=\"x\"} 1\n" +
+		"a_total{kind=\"y\"} 2\n" +
+		"# HELP b B.\n" +
+		"# TYPE b gauge\n" +
+		"b 0.5\n"
+	if got := buf.String(); got != exp {
+		t.Fatalf("expected '%s', got '%s'", exp, got)
+	}
+}
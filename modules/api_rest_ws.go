@@ -21,31 +21,21 @@ const (
 	pingPeriod = (pongWait * 9) / 10
 )
 
+// streamEvent and sendPing don't log, as they're called while consuming
+// the events and the logs are events as well.
 func (api *RestAPI) streamEvent(ws *websocket.Conn, event session.Event) error {
 	msg, err := json.Marshal(event)
 	if err != nil {
-		log.Error("Error while creating websocket message: %s", err)
 		return err
 	}
 
 	ws.SetWriteDeadline(time.Now().Add(writeWait))
-	if err := ws.WriteMessage(websocket.TextMessage, msg); err != nil {
-		if !strings.Contains(err.Error(), "closed connection") {
-			log.Error("Error while writing websocket message: %s", err)
-			return err
-		}
-	}
-
-	return nil
+	return ws.WriteMessage(websocket.TextMessage, msg)
 }
 
 func (api *RestAPI) sendPing(ws *websocket.Conn) error {
 	ws.SetWriteDeadline(time.Now().Add(writeWait))
-	if err := ws.WriteMessage(websocket.PingMessage, []byte{}); err != nil {
-		log.Error("Error while writing websocket ping message: %s", err)
-		return err
-	}
-	return nil
+	return ws.WriteMessage(websocket.PingMessage, []byte{})
 }
 
 func (api *RestAPI) streamWriter(ws *websocket.Conn, w http.ResponseWriter, r *http.Request) {
@@ -58,6 +48,7 @@ func (api *RestAPI) streamWriter(ws *websocket.Conn, w http.ResponseWriter, r *h
 		log.Debug("Sending %d events.", n)
 		for _, event := range events {
 			if err := api.streamEvent(ws, event); err != nil {
+				log.Debug("Error while writing websocket message: %s", err)
 				return
 			}
 		}
@@ -65,11 +56,22 @@ func (api *RestAPI) streamWriter(ws *websocket.Conn, w http.ResponseWriter, r *h
 
 	session.I.Events.Clear()
 
+	api.streamLive(ws, session.I.Events, nil)
+}
+
+// streamLive streams the new events accepted by filter, every one if nil,
+// until the connection is closed or the module stopped. The events come
+// from a bounded subscription, so a slow client only loses its oldest
+// events instead of blocking the pool, and the connection is closed on
+// the first error.
+func (api *RestAPI) streamLive(ws *websocket.Conn, events *session.EventPool, filter func(e session.Event) bool) {
 	log.Debug("Listening for events and streaming to ws endpoint ...")
 
 	pingTicker := time.NewTicker(pingPeriod)
-	listener := session.I.Events.Listen()
-	defer session.I.Events.Unlisten(listener)
+	defer pingTicker.Stop()
+	listener, unsubscribe := events.Subscribe("")
+	defer unsubscribe()
+	defer ws.Close()
 
 	for {
 		select {
@@ -77,12 +79,15 @@ func (api *RestAPI) streamWriter(ws *websocket.Conn, w http.ResponseWriter, r *h
 			if err := api.sendPing(ws); err != nil {
 				return
 			}
-		case event := <-listener:
-			if err := api.streamEvent(ws, event); err != nil {
+		case event, ok := <-listener:
+			if !ok {
+				return
+			} else if filter != nil && !filter(event) {
+				continue
+			} else if err := api.streamEvent(ws, event); err != nil {
 				return
 			}
 		case <-api.quit:
-			log.Info("Stopping websocket events streamer ...")
 			return
 		}
 	}
@@ -102,17 +107,50 @@ func (api *RestAPI) streamReader(ws *websocket.Conn) {
 	}
 }
 
-func (api *RestAPI) startStreamingEvents(w http.ResponseWriter, r *http.Request) {
+func (api *RestAPI) upgrade(w http.ResponseWriter, r *http.Request) *websocket.Conn {
 	ws, err := api.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		if _, ok := err.(websocket.HandshakeError); !ok {
 			log.Error("Error while updating api.rest connection to websocket: %s", err)
 		}
-		return
+		return nil
 	}
 
 	log.Debug("Websocket streaming started for %s", r.RemoteAddr)
+	return ws
+}
 
-	go api.streamWriter(ws, w, r)
-	api.streamReader(ws)
+func (api *RestAPI) startStreamingEvents(w http.ResponseWriter, r *http.Request) {
+	if ws := api.upgrade(w, r); ws != nil {
+		go api.streamWriter(ws, w, r)
+		api.streamReader(ws)
+	}
+}
+
+// wsAccepts filters out the sniffed packets unless the module has been
+// configured to stream them.
+func (api *RestAPI) wsAccepts(e session.Event) bool {
+	return api.wsPackets || !strings.HasPrefix(e.Tag, "net.sniff.leak.")
+}
+
+// wsRoute streams the live events, without the ones already buffered
+// for /api/events nor clearing them.
+func (api *RestAPI) wsRoute(w http.ResponseWriter, r *http.Request) {
+	setSecurityHeaders(w)
+
+	if !api.checkAuth(r) {
+		setAuthFailed(w, r)
+		return
+	} else if r.Method != "GET" {
+		http.Error(w, "Bad Request", 400)
+		return
+	}
+
+	if ws := api.upgrade(w, r); ws != nil {
+		go func() {
+			defer ws.Close()
+			api.streamLive(ws, api.Session.Events, api.wsAccepts)
+		}()
+		api.streamReader(ws)
+	}
 }
@@ -0,0 +1,79 @@
+package modules
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bettercap/bettercap/session"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestRestAPIWebsocket(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	api := NewRestAPI(s.Session)
+	api.username = "user"
+	api.password = "pass"
+
+	server := httptest.NewServer(http.HandlerFunc(api.wsRoute))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	if _, res, err := websocket.DefaultDialer.Dial(url, nil); err == nil {
+		t.Fatal("expected error without credentials")
+	} else if res == nil || res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected %d, got %v", http.StatusUnauthorized, res)
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("user:pass")))
+	ws, _, err := websocket.DefaultDialer.Dial(url, header)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer ws.Close()
+
+	read := func() string {
+		event := session.Event{}
+		ws.SetReadDeadline(time.Now().Add(2 * time.Second))
+		if err := ws.ReadJSON(&event); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return event.Tag
+	}
+
+	// until the listener of the connection is registered
+	done := make(chan bool)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+				s.Events.Add("endpoint.new", nil)
+			}
+		}
+	}()
+	for read() != "endpoint.new" {
+	}
+	close(done)
+
+	s.Events.Add("net.sniff.leak.tcp", nil)
+	s.Events.Add("test.new", nil)
+	for {
+		if tag := read(); tag == "test.new" {
+			break
+		} else if tag != "endpoint.new" && !strings.HasPrefix(tag, "sys.") {
+			t.Fatalf("expected '%s', got '%s'", "test.new", tag)
+		}
+	}
+}
@@ -0,0 +1,357 @@
+package modules
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/network"
+	"github.com/bettercap/bettercap/packets"
+	"github.com/bettercap/bettercap/session"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+const (
+	arpGuardGatewayChanged = "gateway mac changed"
+	arpGuardBindingChanged = "binding changed"
+)
+
+// the alerts are posted to the webhook as soon as they're fired
+var arpGuardWebhookOptions = webhookOptions{batch: 1, flush: time.Second, retries: 3}
+
+type ArpGuardEvent struct {
+	IP         string `json:"ip"`
+	MAC        string `json:"mac"`
+	Vendor     string `json:"vendor"`
+	Expected   string `json:"expected"`
+	Attacker   string `json:"attacker"`
+	Reason     string `json:"reason"`
+	Gratuitous bool   `json:"gratuitous"`
+	Restored   bool   `json:"restored"`
+}
+
+// arpBinding is the legitimate hardware address of an IPv4 address and
+// the last one which claimed it instead.
+type arpBinding struct {
+	MAC      net.HardwareAddr
+	Seen     time.Time
+	Conflict net.HardwareAddr
+	Alerted  time.Time
+}
+
+// ArpGuard watches the ARP traffic read by the packet queue for changes
+// of the bindings it learned, like the ones of an ARP spoofing attack.
+type ArpGuard struct {
+	session.SessionModule
+	window   time.Duration
+	restore  bool
+	lock     *sync.Mutex
+	bindings map[string]*arpBinding
+	webhook  *webhookSink
+}
+
+func NewArpGuard(s *session.Session) *ArpGuard {
+	g := &ArpGuard{
+		SessionModule: session.NewSessionModule("arp.guard", s),
+		lock:          &sync.Mutex{},
+		bindings:      make(map[string]*arpBinding),
+	}
+
+	g.AddParam(session.NewDurationParameter("arp.guard.window",
+		"1m",
+		"The changes of the same binding are reported at most once within this time."))
+
+	g.AddParam(session.NewBoolParameter("arp.guard.restore",
+		"false",
+		"If true, every poisoning packet is answered with an ARP reply re-asserting the legitimate binding."))
+
+	g.AddParam(session.NewStringParameter("arp.guard.webhook",
+		"",
+		`^(https?://.+)?$`,
+		"If not empty, the alerts are also posted as JSON to this http:// or https:// URL."))
+
+	g.AddHandler(session.NewModuleHandler("arp.guard on", "",
+		"Start watching the LAN for ARP poisoning.",
+		func(args []string) error {
+			return g.Start()
+		}))
+
+	g.AddHandler(session.NewModuleHandler("arp.guard off", "",
+		"Stop watching the LAN for ARP poisoning.",
+		func(args []string) error {
+			return g.Stop()
+		}))
+
+	g.AddHandler(session.NewModuleHandler("arp.guard.show", "",
+		"Show the bindings learned so far and the addresses trying to replace them.",
+		func(args []string) error {
+			return g.Show()
+		}))
+
+	g.AddHandler(session.NewModuleHandler("arp.guard.forget IP", `arp\.guard\.forget\s+(\d+\.\d+\.\d+\.\d+)`,
+		"Forget the binding of IP, so the next hardware address claiming it is trusted.",
+		func(args []string) error {
+			return g.forget(args[0])
+		}))
+
+	return g
+}
+
+func (g *ArpGuard) Name() string {
+	return "arp.guard"
+}
+
+func (g *ArpGuard) Description() string {
+	return "Fires an arp.guard.alert event when the hardware address of the gateway or of a known host changes, optionally restoring the legitimate binding."
+}
+
+func (g *ArpGuard) Author() string {
+	return "Simone Margaritelli <evilsocket@protonmail.com>"
+}
+
+// learn trusts mac as the hardware address of ip, unless a binding for
+// it is known already.
+func (g *ArpGuard) learn(ip net.IP, mac net.HardwareAddr, now time.Time) {
+	if ip == nil || len(mac) != 6 || network.IsZeroMac(mac) || network.IsBroadcastMac(mac) {
+		return
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if _, found := g.bindings[ip.String()]; !found {
+		g.bindings[ip.String()] = &arpBinding{MAC: mac, Seen: now}
+	}
+}
+
+func (g *ArpGuard) reset() {
+	g.lock.Lock()
+	g.bindings = make(map[string]*arpBinding)
+	g.lock.Unlock()
+
+	now := time.Now()
+	// the gateway first, the LAN might have been poisoned already
+	if gw := g.Session.Gateway; gw != nil && gw != g.Session.Interface {
+		g.learn(gw.IP, gw.HW, now)
+	}
+	for _, e := range g.Session.Lan.List() {
+		g.learn(e.IP, e.HW, now)
+	}
+}
+
+func (g *ArpGuard) Configure() (err error) {
+	var webhook string
+
+	if g.Running() {
+		return session.ErrAlreadyStarted
+	} else if g.Session.Queue == nil {
+		return fmt.Errorf("arp.guard needs the packet queue, which is not available on this interface.")
+	} else if err, g.window = g.DurationParam("arp.guard.window"); err != nil {
+		return err
+	} else if err, g.restore = g.BoolParam("arp.guard.restore"); err != nil {
+		return err
+	} else if err, webhook = g.StringParam("arp.guard.webhook"); err != nil {
+		return err
+	}
+
+	g.webhook = nil
+	if webhook != "" {
+		if g.webhook, err = newWebhookSink(webhook, arpGuardWebhookOptions, false); err != nil {
+			return err
+		}
+	}
+
+	g.reset()
+	return nil
+}
+
+func (g *ArpGuard) isLocal(ip net.IP) bool {
+	return g.Session.Interface.Net != nil && g.Session.Interface.Net.Contains(ip)
+}
+
+// attacker returns the address the hardware address is legitimately
+// bound to, if any.
+func (g *ArpGuard) attacker(mac net.HardwareAddr) string {
+	for ip, b := range g.bindings {
+		if bytes.Equal(b.MAC, mac) {
+			return ip
+		}
+	}
+	return ""
+}
+
+// check returns the alert for ip being claimed by mac, or nil if it's
+// the legitimate binding or the same change has been reported within
+// the time window.
+func (g *ArpGuard) check(ip net.IP, mac net.HardwareAddr, now time.Time) (*ArpGuardEvent, net.HardwareAddr) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	key := ip.String()
+	b, found := g.bindings[key]
+	if !found {
+		g.bindings[key] = &arpBinding{MAC: mac, Seen: now}
+		return nil, nil
+	} else if bytes.Equal(b.MAC, mac) {
+		b.Seen = now
+		return nil, nil
+	}
+
+	alerted := bytes.Equal(b.Conflict, mac) && now.Sub(b.Alerted) <= g.window
+	b.Conflict = mac
+	if alerted {
+		return nil, b.MAC
+	}
+	b.Alerted = now
+
+	reason := arpGuardBindingChanged
+	if gw := g.Session.Gateway; gw != nil && gw.IP.Equal(ip) {
+		reason = arpGuardGatewayChanged
+	}
+
+	hw := network.NormalizeMac(mac.String())
+	return &ArpGuardEvent{
+		IP:       key,
+		MAC:      hw,
+		Vendor:   network.OuiLookup(hw),
+		Expected: network.NormalizeMac(b.MAC.String()),
+		Attacker: g.attacker(mac),
+		Reason:   reason,
+	}, b.MAC
+}
+
+// arpCorrection returns the reply telling victim that ip is at mac, or
+// every host if victim is unknown.
+func arpCorrection(ip net.IP, mac net.HardwareAddr, victim net.IP, victimHW net.HardwareAddr) (error, []byte) {
+	if victimHW == nil || network.IsZeroMac(victimHW) || network.IsBroadcastMac(victimHW) {
+		return packets.NewARPReply(ip, mac, ip, layers.EthernetBroadcast)
+	}
+	return packets.NewARPReply(ip, mac, victim, victimHW)
+}
+
+func (g *ArpGuard) onPacket(pkt gopacket.Packet) {
+	larp := pkt.Layer(layers.LayerTypeARP)
+	if larp == nil {
+		return
+	}
+
+	arp := larp.(*layers.ARP)
+	mac := net.HardwareAddr(arp.SourceHwAddress)
+	ip := net.IP(arp.SourceProtAddress)
+	if ip.IsUnspecified() || !g.isLocal(ip) || bytes.Equal(mac, g.Session.Interface.HW) {
+		return
+	} else if network.IsBroadcastMac(mac) || network.IsZeroMac(mac) {
+		return
+	}
+
+	event, legit := g.check(ip, mac, time.Now())
+	if legit == nil {
+		return
+	}
+
+	victim := net.IP(arp.DstProtAddress)
+	victimHW := net.HardwareAddr(arp.DstHwAddress)
+	restored := false
+	if g.restore {
+		if err, raw := arpCorrection(ip, legit, victim, victimHW); err != nil {
+			log.Error("arp.guard: error creating the ARP reply for %s: %s", ip, err)
+		} else if err := g.Session.Queue.Send(raw); err != nil {
+			log.Error("arp.guard: error sending the ARP reply for %s: %s", ip, err)
+		} else {
+			restored = true
+		}
+	}
+
+	if event != nil {
+		event.Gratuitous = victim.Equal(ip)
+		event.Restored = restored
+		g.alert(*event)
+	}
+}
+
+func (g *ArpGuard) alert(event ArpGuardEvent) {
+	log.Debug("arp.guard: %s of %s, %s instead of %s", event.Reason, event.IP, event.MAC, event.Expected)
+	g.Session.Events.Add("arp.guard.alert", event)
+
+	if g.webhook != nil {
+		g.webhook.Push(session.NewEvent("arp.guard.alert", event))
+	}
+}
+
+func (g *ArpGuard) forget(ip string) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if _, found := g.bindings[ip]; !found {
+		return fmt.Errorf("No binding known for %s.", ip)
+	}
+	delete(g.bindings, ip)
+	return nil
+}
+
+func (g *ArpGuard) Show() error {
+	g.lock.Lock()
+	addresses := make([]net.IP, 0, len(g.bindings))
+	for ip := range g.bindings {
+		addresses = append(addresses, net.ParseIP(ip).To4())
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return bytes.Compare(addresses[i], addresses[j]) < 0
+	})
+
+	rows := make([][]string, 0, len(addresses))
+	for _, ip := range addresses {
+		b := g.bindings[ip.String()]
+		conflict := core.Dim("-")
+		if b.Conflict != nil {
+			conflict = core.Red(network.NormalizeMac(b.Conflict.String()))
+		}
+		hw := network.NormalizeMac(b.MAC.String())
+		rows = append(rows, []string{
+			ip.String(),
+			hw,
+			network.OuiLookup(hw),
+			conflict,
+			b.Seen.Format("15:04:05"),
+		})
+	}
+	g.lock.Unlock()
+
+	if len(rows) == 0 {
+		fmt.Println("No bindings learned yet.")
+		return nil
+	}
+
+	fmt.Println()
+	core.AsTable(os.Stdout, []string{"IP", "MAC", "Vendor", "Conflicting MAC", "Seen"}, rows)
+	fmt.Println()
+	return nil
+}
+
+func (g *ArpGuard) Start() error {
+	if err := g.Configure(); err != nil {
+		return err
+	}
+
+	return g.SetRunning(true, func() {
+		log.Info("arp.guard watching %d bindings (restore %v)", len(g.bindings), g.restore)
+		g.Session.Queue.AddPacketListener(g.Name(), g.onPacket)
+	})
+}
+
+func (g *ArpGuard) Stop() error {
+	return g.SetRunning(false, func() {
+		g.Session.Queue.RemovePacketListener(g.Name())
+		if g.webhook != nil {
+			g.webhook.Close()
+			g.webhook = nil
+		}
+	})
+}
@@ -0,0 +1,205 @@
+package modules
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bettercap/bettercap/packets"
+	"github.com/bettercap/bettercap/session"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func arpGuardPacket(t *testing.T, sender string, ip string, target string, targetIP string) gopacket.Packet {
+	senderHW, _ := net.ParseMAC(sender)
+	targetHW, _ := net.ParseMAC(target)
+
+	eth, arp := packets.NewARPTo(net.ParseIP(ip), senderHW, net.ParseIP(targetIP), targetHW, layers.ARPReply)
+	err, raw := packets.Serialize(&eth, &arp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return gopacket.NewPacket(raw, layers.LayerTypeEthernet, gopacket.Default)
+}
+
+func arpGuardAlerts(s *session.TestSession) []ArpGuardEvent {
+	alerts := make([]ArpGuardEvent, 0)
+	for _, e := range s.Events.Sorted() {
+		if e.Tag == "arp.guard.alert" {
+			alerts = append(alerts, e.Data.(ArpGuardEvent))
+		}
+	}
+	return alerts
+}
+
+func newTestArpGuard(s *session.Session) *ArpGuard {
+	g := NewArpGuard(s)
+	g.window = time.Minute
+	g.reset()
+	return g
+}
+
+func TestArpGuardGateway(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	g := newTestArpGuard(s.Session)
+	victim := "de:ad:be:ef:00:10"
+	attacker := "de:ad:be:ef:00:20"
+
+	// the legitimate gateway and a new host
+	g.onPacket(arpGuardPacket(t, session.TestGatewayMAC, session.TestGatewayIP, victim, "192.168.1.10"))
+	g.onPacket(arpGuardPacket(t, attacker, "192.168.1.20", victim, "192.168.1.10"))
+	if alerts := arpGuardAlerts(s); len(alerts) != 0 {
+		t.Fatalf("expected no alerts, got %v", alerts)
+	}
+
+	// the poisoning is reported once per window
+	g.onPacket(arpGuardPacket(t, attacker, session.TestGatewayIP, victim, "192.168.1.10"))
+	g.onPacket(arpGuardPacket(t, attacker, session.TestGatewayIP, victim, "192.168.1.10"))
+
+	alerts := arpGuardAlerts(s)
+	if len(alerts) != 1 {
+		t.Fatalf("expected 1 alert, got %v", alerts)
+	}
+
+	alert := alerts[0]
+	if alert.Reason != arpGuardGatewayChanged {
+		t.Fatalf("expected '%s', got '%s'", arpGuardGatewayChanged, alert.Reason)
+	} else if alert.IP != session.TestGatewayIP {
+		t.Fatalf("expected '%s', got '%s'", session.TestGatewayIP, alert.IP)
+	} else if alert.MAC != attacker {
+		t.Fatalf("expected '%s', got '%s'", attacker, alert.MAC)
+	} else if alert.Expected != session.TestGatewayMAC {
+		t.Fatalf("expected '%s', got '%s'", session.TestGatewayMAC, alert.Expected)
+	} else if alert.Attacker != "192.168.1.20" {
+		t.Fatalf("expected '192.168.1.20', got '%s'", alert.Attacker)
+	} else if alert.Gratuitous || alert.Restored {
+		t.Fatalf("unexpected alert %+v", alert)
+	}
+}
+
+func TestArpGuardBindings(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	g := newTestArpGuard(s.Session)
+	host := "de:ad:be:ef:00:30"
+	clone := "de:ad:be:ef:00:40"
+	broadcast := "ff:ff:ff:ff:ff:ff"
+
+	g.onPacket(arpGuardPacket(t, host, "192.168.1.30", broadcast, "192.168.1.30"))
+	// outside of the subnet and our own
+	g.onPacket(arpGuardPacket(t, clone, "10.0.0.1", broadcast, "10.0.0.1"))
+	g.onPacket(arpGuardPacket(t, session.TestInterfaceMAC, "192.168.1.30", broadcast, "192.168.1.30"))
+	if alerts := arpGuardAlerts(s); len(alerts) != 0 {
+		t.Fatalf("expected no alerts, got %v", alerts)
+	}
+
+	g.onPacket(arpGuardPacket(t, clone, "192.168.1.30", broadcast, "192.168.1.30"))
+	alerts := arpGuardAlerts(s)
+	if len(alerts) != 1 {
+		t.Fatalf("expected 1 alert, got %v", alerts)
+	} else if alerts[0].Reason != arpGuardBindingChanged || !alerts[0].Gratuitous || alerts[0].Attacker != "" {
+		t.Fatalf("unexpected alert %+v", alerts[0])
+	}
+
+	// a forgotten binding is learned again
+	if err := g.forget("192.168.1.30"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := g.forget("192.168.1.30"); err == nil {
+		t.Fatalf("expected error for an unknown binding")
+	}
+	g.onPacket(arpGuardPacket(t, clone, "192.168.1.30", broadcast, "192.168.1.30"))
+	g.onPacket(arpGuardPacket(t, clone, "192.168.1.30", broadcast, "192.168.1.30"))
+	if alerts := arpGuardAlerts(s); len(alerts) != 1 {
+		t.Fatalf("expected 1 alert, got %v", alerts)
+	}
+}
+
+func TestArpGuardCorrection(t *testing.T) {
+	gwHW, _ := net.ParseMAC(session.TestGatewayMAC)
+	victimHW, _ := net.ParseMAC("de:ad:be:ef:00:10")
+	gw := net.ParseIP(session.TestGatewayIP)
+	victim := net.ParseIP("192.168.1.10")
+
+	var units = []struct {
+		victimHW net.HardwareAddr
+		dstIP    string
+		dstHW    string
+	}{
+		{victimHW, "192.168.1.10", "de:ad:be:ef:00:10"},
+		{net.HardwareAddr{0, 0, 0, 0, 0, 0}, session.TestGatewayIP, "ff:ff:ff:ff:ff:ff"},
+	}
+
+	for _, u := range units {
+		err, raw := arpCorrection(gw, gwHW, victim, u.victimHW)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		pkt := gopacket.NewPacket(raw, layers.LayerTypeEthernet, gopacket.Default)
+		arp := pkt.Layer(layers.LayerTypeARP).(*layers.ARP)
+		if arp.Operation != layers.ARPReply {
+			t.Fatalf("expected a reply, got %d", arp.Operation)
+		} else if got := net.IP(arp.SourceProtAddress).String(); got != session.TestGatewayIP {
+			t.Fatalf("expected '%s', got '%s'", session.TestGatewayIP, got)
+		} else if got := net.HardwareAddr(arp.SourceHwAddress).String(); got != session.TestGatewayMAC {
+			t.Fatalf("expected '%s', got '%s'", session.TestGatewayMAC, got)
+		} else if got := net.IP(arp.DstProtAddress).String(); got != u.dstIP {
+			t.Fatalf("expected '%s', got '%s'", u.dstIP, got)
+		} else if got := net.HardwareAddr(arp.DstHwAddress).String(); got != u.dstHW {
+			t.Fatalf("expected '%s', got '%s'", u.dstHW, got)
+		}
+	}
+}
+
+func TestArpGuardWebhook(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	posted := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		posted <- body
+	}))
+	defer server.Close()
+
+	g := newTestArpGuard(s.Session)
+	if g.webhook, err = newWebhookSink(server.URL, arpGuardWebhookOptions, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer g.webhook.Close()
+
+	g.onPacket(arpGuardPacket(t, "de:ad:be:ef:00:20", session.TestGatewayIP, "de:ad:be:ef:00:10", "192.168.1.10"))
+
+	select {
+	case body := <-posted:
+		var events []struct {
+			Tag  string        `json:"tag"`
+			Data ArpGuardEvent `json:"data"`
+		}
+		if err := json.Unmarshal(body, &events); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if len(events) != 1 || events[0].Tag != "arp.guard.alert" || events[0].Data.IP != session.TestGatewayIP {
+			t.Fatalf("unexpected events %s", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the alert to be posted")
+	}
+}
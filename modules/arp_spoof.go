@@ -6,11 +6,14 @@ import (
 	"sync"
 	"time"
 
+	"github.com/bettercap/bettercap/core"
 	"github.com/bettercap/bettercap/log"
 	"github.com/bettercap/bettercap/network"
 	"github.com/bettercap/bettercap/packets"
 	"github.com/bettercap/bettercap/session"
 
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/pcap"
 	"github.com/malfunkt/iprange"
 )
 
@@ -20,9 +23,37 @@ type ArpSpoofer struct {
 	macs       []net.HardwareAddr
 	wAddresses []net.IP
 	wMacs      []net.HardwareAddr
-	internal   bool
-	ban        bool
-	waitGroup  *sync.WaitGroup
+	// set for the @path forms of the targets and the whitelist
+	targetsFile   *network.TargetsFile
+	whitelistFile *network.TargetsFile
+	internal      bool
+	ban           bool
+	mode          string
+	waitGroup     *sync.WaitGroup
+
+	handle        *pcap.Handle
+	pktSourceChan chan gopacket.Packet
+	answered      *arpAnsweredList
+
+	resolveTimeout time.Duration
+	resolved       *arpResolvedList
+
+	auto         bool
+	autoFilter   *arpAutoFilter
+	autoAdded    map[string]bool
+	autoListener <-chan session.Event
+	targetsLock  *sync.RWMutex
+
+	// the gateway is poisoned as well for the targets of the full
+	// duplex profiles
+	dAddresses     []net.IP
+	dMacs          []net.HardwareAddr
+	profilesLock   *sync.Mutex
+	profiles       []*arpSpoofProfile
+	useProfiles    bool
+	activeProfiles string
+
+	stats *arpSpoofStats
 }
 
 func NewArpSpoofer(s *session.Session) *ArpSpoofer {
@@ -34,24 +65,53 @@ func NewArpSpoofer(s *session.Session) *ArpSpoofer {
 		wMacs:         make([]net.HardwareAddr, 0),
 		ban:           false,
 		internal:      false,
+		mode:          arpModeActive,
 		waitGroup:     &sync.WaitGroup{},
+		answered:      newArpAnsweredList(),
+		resolved:      newArpResolvedList(),
+		autoAdded:     make(map[string]bool),
+		autoFilter:    &arpAutoFilter{},
+		targetsLock:   &sync.RWMutex{},
+		dAddresses:    make([]net.IP, 0),
+		dMacs:         make([]net.HardwareAddr, 0),
+		profilesLock:  &sync.Mutex{},
+		profiles:      make([]*arpSpoofProfile, 0),
+		stats:         newArpSpoofStats(),
 	}
 
-	p.AddParam(session.NewStringParameter("arp.spoof.targets", session.ParamSubnet, "", "Comma separated list of IP addresses, MAC addresses or aliases to spoof, also supports nmap style IP ranges."))
+	p.AddParam(session.NewStringParameter("arp.spoof.targets", session.ParamSubnet, "", "Comma separated list of IP addresses, MAC addresses or aliases to spoof, also supports nmap style IP ranges, or @path to read them from a file which is reloaded when it changes."))
 
-	p.AddParam(session.NewStringParameter("arp.spoof.whitelist", "", "", "Comma separated list of IP addresses, MAC addresses or aliases to skip while spoofing."))
+	p.AddParam(session.NewStringParameter("arp.spoof.whitelist", "", "", "Comma separated list of IP addresses, MAC addresses or aliases to skip while spoofing, or @path to read them from a file which is reloaded when it changes."))
 
 	p.AddParam(session.NewBoolParameter("arp.spoof.internal",
 		"false",
 		"If true, local connections among computers of the network will be spoofed, otherwise only connections going to and coming from the external network."))
 
-	p.AddHandler(session.NewModuleHandler("arp.spoof on", "",
+	p.AddParam(session.NewStringParameter("arp.spoof.mode",
+		arpModeActive,
+		"^(active|reactive)$",
+		"In active mode the targets are periodically poisoned, in reactive mode only their ARP requests for the gateway (or any neighbour if arp.spoof.internal is true) are answered."))
+
+	p.AddParam(session.NewDurationParameter("arp.spoof.resolve.timeout",
+		"2s",
+		"How long to wait for the ARP replies of targets missing from the ARP cache before giving up on them, 0 to disable."))
+
+	p.AddParam(session.NewBoolParameter("arp.spoof.auto",
+		"false",
+		"If true, new hosts discovered by net.recon and matching arp.spoof.filter will be spoofed as well, and restored when lost."))
+
+	p.AddParam(session.NewStringParameter("arp.spoof.filter",
+		"",
+		"",
+		"CIDR or regular expression on the hostname or alias of the new hosts to spoof in auto mode, empty for every host."))
+
+	p.AddHandler(session.NewDangerousModuleHandler("arp.spoof on", "",
 		"Start ARP spoofer.",
 		func(args []string) error {
 			return p.Start()
 		}))
 
-	p.AddHandler(session.NewModuleHandler("arp.ban on", "",
+	p.AddHandler(session.NewDangerousModuleHandler("arp.ban on", "",
 		"Start ARP spoofer in ban mode, meaning the target(s) connectivity will not work.",
 		func(args []string) error {
 			p.ban = true
@@ -70,6 +130,46 @@ func NewArpSpoofer(s *session.Session) *ArpSpoofer {
 			return p.Stop()
 		}))
 
+	p.AddHandler(session.NewModuleHandler("arp.spoof.pause", "",
+		"Stop poisoning the targets without restoring their ARP cache or forgetting them.",
+		func(args []string) error {
+			return p.Pause()
+		}))
+
+	p.AddHandler(session.NewModuleHandler("arp.spoof.resume", "",
+		"Resume poisoning the targets after arp.spoof.pause.",
+		func(args []string) error {
+			return p.Resume()
+		}))
+
+	p.AddHandler(session.NewModuleHandler("arp.spoof.profile add NAME TARGETS [OPTIONS]", `arp\.spoof\.profile\s+add\s+([^\s]+)\s+([^\s]+)(.*)`,
+		"Add or replace a profile spoofing TARGETS, with the whitelist=LIST, fullduplex[=BOOL] and window=HH:MM-HH:MM options. While profiles are defined they replace arp.spoof.targets and arp.spoof.whitelist, every profile being active during its window.",
+		func(args []string) error {
+			prof, err := parseArpSpoofProfile(args[0], args[1], args[2])
+			if err != nil {
+				return err
+			}
+			return p.AddProfile(prof)
+		}))
+
+	p.AddHandler(session.NewModuleHandler("arp.spoof.profile del NAME", `arp\.spoof\.profile\s+del\s+([^\s]+)`,
+		"Remove a profile, restoring the targets it was spoofing.",
+		func(args []string) error {
+			return p.DelProfile(args[0])
+		}))
+
+	p.AddHandler(session.NewModuleHandler("arp.spoof.profile list", "",
+		"Print the profiles and whether their window is active.",
+		func(args []string) error {
+			return p.showProfiles()
+		}))
+
+	p.AddHandler(session.NewModuleHandler("arp.spoof.stats", "",
+		"Print the poisoning and restoring packets sent to each target and when it was last touched.",
+		func(args []string) error {
+			return p.showStats()
+		}))
+
 	return p
 }
 
@@ -85,10 +185,17 @@ func (p ArpSpoofer) Author() string {
 	return "Simone Margaritelli <evilsocket@protonmail.com>"
 }
 
+// the hardware address to spoof with and the hosts for arp.spoof.auto
+// are known once these are started
+func (p ArpSpoofer) Dependencies() []string {
+	return []string{"mac.changer", "net.recon"}
+}
+
 func (p *ArpSpoofer) Configure() error {
 	var err error
 	var targets string
 	var whitelist string
+	var filter string
 
 	if err, p.internal = p.BoolParam("arp.spoof.internal"); err != nil {
 		return err
@@ -96,12 +203,58 @@ func (p *ArpSpoofer) Configure() error {
 		return err
 	} else if err, whitelist = p.StringParam("arp.spoof.whitelist"); err != nil {
 		return err
-	} else if p.addresses, p.macs, err = network.ParseTargets(targets, p.Session.Lan.Aliases()); err != nil {
+	} else if err, p.mode = p.StringParam("arp.spoof.mode"); err != nil {
+		return err
+	} else if err, p.resolveTimeout = p.DurationParam("arp.spoof.resolve.timeout"); err != nil {
+		return err
+	} else if err, p.auto = p.BoolParam("arp.spoof.auto"); err != nil {
+		return err
+	} else if err, filter = p.StringParam("arp.spoof.filter"); err != nil {
 		return err
-	} else if p.wAddresses, p.wMacs, err = network.ParseTargets(whitelist, p.Session.Lan.Aliases()); err != nil {
+	} else if p.autoFilter, err = parseArpAutoFilter(filter); err != nil {
+		return err
+	} else if err = p.Session.UpdateInjection(); err != nil {
+		return err
+	}
+
+	addresses, macs, targetsFile, err := parseArpTargets(targets, p.Session.Lan.Aliases())
+	if err != nil {
+		return err
+	}
+	wAddresses, wMacs, whitelistFile, err := parseArpTargets(whitelist, p.Session.Lan.Aliases())
+	if err != nil {
 		return err
 	}
 
+	p.profilesLock.Lock()
+	p.useProfiles = len(p.profiles) > 0
+	p.activeProfiles = ""
+	p.profilesLock.Unlock()
+
+	if p.useProfiles {
+		if targetsFile != nil || whitelistFile != nil {
+			log.Info("The arp.spoof.targets and arp.spoof.whitelist files are ignored while profiles are defined.")
+		}
+		targetsFile, whitelistFile = nil, nil
+	}
+
+	p.targetsLock.Lock()
+	p.addresses, p.macs, p.targetsFile = addresses, macs, targetsFile
+	p.wAddresses, p.wMacs, p.whitelistFile = wAddresses, wMacs, whitelistFile
+	p.dAddresses, p.dMacs = make([]net.IP, 0), make([]net.HardwareAddr, 0)
+	p.autoAdded = make(map[string]bool)
+	p.targetsLock.Unlock()
+
+	p.applyProfiles(time.Now(), true)
+
+	p.resolved.Clear()
+
+	if p.mode == arpModeReactive {
+		if err = p.openReactiveHandle(); err != nil {
+			return err
+		}
+	}
+
 	log.Debug(" addresses=%v macs=%v whitelisted-addresses=%v whitelisted-macs=%v", p.addresses, p.macs, p.wAddresses, p.wMacs)
 
 	if p.ban {
@@ -116,13 +269,41 @@ func (p *ArpSpoofer) Configure() error {
 }
 
 func (p *ArpSpoofer) Start() error {
-	if err := p.Configure(); err != nil {
+	// Configure would replace the targets and the handle in use
+	if p.Running() {
+		return session.ErrAlreadyStarted
+	} else if err := p.Session.RequirePrivileges(p.Name(), session.CapNetRaw, session.CapNetAdmin); err != nil {
+		return err
+	} else if err := p.Session.CheckSafe(p.Name(), p.Session.Interface.Name()); err != nil {
+		return err
+	} else if err := p.Configure(); err != nil {
 		return err
 	}
 
-	return p.SetRunning(true, func() {
+	p.stats.Reset()
+	p.startAuto()
+
+	err := p.SetRunning(true, func() {
+		p.waitGroup.Add(1)
+		defer p.waitGroup.Done()
+
+		if p.targetsFile != nil || p.whitelistFile != nil {
+			p.waitGroup.Add(1)
+			go p.targetsWorker()
+		}
+
+		p.waitGroup.Add(1)
+		go p.profilesWorker()
+
+		if p.mode == arpModeReactive {
+			p.reactiveWorker()
+			return
+		}
+
+		p.resolveTargets()
+
 		neighbours := []net.IP{}
-		nTargets := len(p.addresses) + len(p.macs)
+		nTargets := len(p.targetAddresses()) + len(p.targetMacs())
 
 		if p.internal {
 			list, _ := iprange.ParseList(p.Session.Interface.CIDR())
@@ -134,54 +315,112 @@ func (p *ArpSpoofer) Start() error {
 			log.Info("ARP spoofer started, probing %d targets.", nTargets)
 		}
 
-		p.waitGroup.Add(1)
-		defer p.waitGroup.Done()
-
 		gwIP := p.Session.Gateway.IP
 		myMAC := p.Session.Interface.HW
 		for p.Running() {
-			p.sendArp(gwIP, myMAC, true, false)
-			for _, address := range neighbours {
-				if !p.Session.Skip(address) {
-					p.sendArp(address, myMAC, true, false)
+			if !p.Paused() {
+				p.sendArp(gwIP, myMAC, true, false)
+				if dIps, dMacs := p.duplexTargets(); len(dIps)+len(dMacs) > 0 {
+					p.sendGatewayArp(dIps, dMacs, true)
+				}
+				for _, address := range neighbours {
+					if !p.Session.Skip(address) {
+						p.sendArp(address, myMAC, true, false)
+					}
 				}
 			}
 
 			time.Sleep(1 * time.Second)
 		}
 	})
+	if err != nil {
+		// nothing is going to stop them
+		p.stopAuto()
+		if p.mode == arpModeReactive {
+			p.handle.Close()
+		}
+	}
+	return err
 }
 
+// Pause stops poisoning the targets while keeping them, and the
+// reactive mode handle, around for Resume.
+func (p *ArpSpoofer) Pause() error {
+	if err := p.SetPaused(true); err != nil {
+		return err
+	}
+	log.Info("ARP spoofer paused, the targets are not being poisoned.")
+	return nil
+}
+
+func (p *ArpSpoofer) Resume() error {
+	if err := p.SetPaused(false); err != nil {
+		return err
+	}
+	log.Info("ARP spoofer resumed.")
+	return nil
+}
+
+// unSpoof restores the ARP cache of the targets, the returned error is
+// a *core.MultiError with the targets that could not be restored.
 func (p *ArpSpoofer) unSpoof() error {
-	nTargets := len(p.addresses) + len(p.macs)
+	nTargets := len(p.targetAddresses()) + len(p.targetMacs())
 	log.Info("Restoring ARP cache of %d targets.", nTargets)
-	p.sendArp(p.Session.Gateway.IP, p.Session.Gateway.HW, false, false)
+
+	result := p.sendArp(p.Session.Gateway.IP, p.Session.Gateway.HW, false, false)
+	if dIps, dMacs := p.duplexTargets(); len(dIps)+len(dMacs) > 0 {
+		result.Merge(p.sendGatewayArp(dIps, dMacs, false))
+	}
 
 	if p.internal {
 		list, _ := iprange.ParseList(p.Session.Interface.CIDR())
 		neighbours := list.Expand()
 		for _, address := range neighbours {
 			if !p.Session.Skip(address) {
-				if realMAC, err := findMAC(p.Session, address, false); err == nil {
-					p.sendArp(address, realMAC, false, false)
+				if realMAC, err := p.lookup(address, false); err == nil {
+					result.Merge(p.sendArp(address, realMAC, false, false))
 				}
 			}
 		}
 	}
 
-	return nil
+	return result.ErrorOrNil()
 }
 
 func (p *ArpSpoofer) Stop() error {
-	return p.SetRunning(false, func() {
+	var restoreErr error
+
+	if err := p.SetRunning(false, func() {
 		log.Info("Waiting for ARP spoofer to stop ...")
-		p.unSpoof()
+		p.stopAuto()
+		if p.mode == arpModeReactive {
+			p.pktSourceChan <- nil
+			p.handle.Close()
+			p.unSpoofAnswered()
+		} else {
+			restoreErr = p.unSpoof()
+		}
 		p.ban = false
 		p.waitGroup.Wait()
-	})
+	}); err != nil {
+		return err
+	}
+
+	return restoreErr
+}
+
+// Revert restores the ARP cache of the targets if the spoofer is running.
+func (p *ArpSpoofer) Revert() (bool, error) {
+	if !p.Running() {
+		return false, nil
+	}
+	return true, p.Stop()
 }
 
 func (p *ArpSpoofer) isWhitelisted(ip string, mac net.HardwareAddr) bool {
+	p.targetsLock.RLock()
+	defer p.targetsLock.RUnlock()
+
 	for _, addr := range p.wAddresses {
 		if ip == addr.String() {
 			return true
@@ -197,31 +436,34 @@ func (p *ArpSpoofer) isWhitelisted(ip string, mac net.HardwareAddr) bool {
 	return false
 }
 
-func (p *ArpSpoofer) sendArp(saddr net.IP, smac net.HardwareAddr, check_running bool, probe bool) {
+func (p *ArpSpoofer) sendArp(saddr net.IP, smac net.HardwareAddr, check_running bool, probe bool) *core.MultiError {
 	p.waitGroup.Add(1)
 	defer p.waitGroup.Done()
 
+	result := core.NewMultiError()
 	targets := make(map[string]net.HardwareAddr)
-	for _, ip := range p.addresses {
+	for _, ip := range p.targetAddresses() {
 		if p.Session.Skip(ip) {
 			log.Debug("Skipping address %s from ARP spoofing.", ip)
 			continue
 		}
 
 		// do we have this ip mac address?
-		hw, err := findMAC(p.Session, ip, probe)
+		hw, err := p.lookup(ip, probe)
 		if err != nil {
 			log.Debug("Could not find hardware address for %s, retrying in one second.", ip.String())
+			result.Add(ip.String(), err)
 			continue
 		}
 
 		targets[ip.String()] = hw
 	}
 
-	for _, hw := range p.macs {
+	for _, hw := range p.targetMacs() {
 		ip, err := network.ArpInverseLookup(p.Session.Interface.Name(), hw.String(), false)
 		if err != nil {
 			log.Warning("Could not find IP address for %s, retrying in one second.", hw.String())
+			result.Add(hw.String(), err)
 			continue
 		}
 
@@ -235,7 +477,7 @@ func (p *ArpSpoofer) sendArp(saddr net.IP, smac net.HardwareAddr, check_running
 
 	for ip, mac := range targets {
 		if check_running && !p.Running() {
-			return
+			break
 		} else if p.isWhitelisted(ip, mac) {
 			log.Debug("%s (%s) is whitelisted, skipping from spoofing loop.", ip, mac)
 			continue
@@ -245,9 +487,12 @@ func (p *ArpSpoofer) sendArp(saddr net.IP, smac net.HardwareAddr, check_running
 
 		if err, pkt := packets.NewARPReply(saddr, smac, net.ParseIP(ip), mac); err != nil {
 			log.Error("Error while creating ARP spoof packet for %s: %s", ip, err)
+			result.Add(ip, err)
 		} else {
 			log.Debug("Sending %d bytes of ARP packet to %s:%s.", len(pkt), ip, mac.String())
-			p.Session.Queue.Send(pkt)
+			result.Add(ip, p.injectArp(ip, mac, smac, pkt))
 		}
 	}
+
+	return result
 }
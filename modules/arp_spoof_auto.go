@@ -0,0 +1,139 @@
+package modules
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/network"
+	"github.com/bettercap/bettercap/session"
+)
+
+// arpAutoFilter selects the new hosts to spoof automatically, either
+// by address (CIDR) or by hostname or alias (regular expression).
+type arpAutoFilter struct {
+	cidr *net.IPNet
+	expr *regexp.Regexp
+}
+
+func parseArpAutoFilter(filter string) (*arpAutoFilter, error) {
+	f := &arpAutoFilter{}
+	if filter = core.Trim(filter); filter == "" {
+		return f, nil
+	} else if _, cidr, err := net.ParseCIDR(filter); err == nil {
+		f.cidr = cidr
+	} else if f.expr, err = regexp.Compile(filter); err != nil {
+		return nil, fmt.Errorf("'%s' is neither a CIDR nor a valid regular expression: %s", filter, err)
+	}
+	return f, nil
+}
+
+// Matches returns true if the filter is empty or matches the endpoint.
+func (f *arpAutoFilter) Matches(e *network.Endpoint) bool {
+	if f.cidr != nil {
+		return f.cidr.Contains(e.IP)
+	} else if f.expr != nil {
+		return f.expr.MatchString(e.Hostname) || (e.Alias != "" && f.expr.MatchString(e.Alias))
+	}
+	return true
+}
+
+// targetAddresses returns a copy of the addresses being spoofed,
+// which are changed by the auto mode while the spoofer runs.
+func (p *ArpSpoofer) targetAddresses() []net.IP {
+	p.targetsLock.RLock()
+	defer p.targetsLock.RUnlock()
+	return append([]net.IP{}, p.addresses...)
+}
+
+func (p *ArpSpoofer) addAutoTarget(e *network.Endpoint) bool {
+	p.targetsLock.Lock()
+	defer p.targetsLock.Unlock()
+
+	for _, ip := range p.addresses {
+		if ip.Equal(e.IP) {
+			return false
+		}
+	}
+
+	p.addresses = append(p.addresses, e.IP)
+	p.autoAdded[e.IpAddress] = true
+	// the host could be missing from the ARP cache
+	p.resolved.Set(e.IpAddress, e.HW)
+	return true
+}
+
+// removeAutoTarget drops the endpoint if it's been added by the auto
+// mode, the statically configured targets are left untouched.
+func (p *ArpSpoofer) removeAutoTarget(e *network.Endpoint) bool {
+	p.targetsLock.Lock()
+	defer p.targetsLock.Unlock()
+
+	if !p.autoAdded[e.IpAddress] {
+		return false
+	}
+	delete(p.autoAdded, e.IpAddress)
+
+	for i, ip := range p.addresses {
+		if ip.Equal(e.IP) {
+			p.addresses = append(p.addresses[:i], p.addresses[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// autoWorker updates the targets on every endpoint.new and endpoint.lost
+// event, notify is called for every change with the event pool unlocked.
+func (p *ArpSpoofer) autoWorker(listener <-chan session.Event, notify func(e *network.Endpoint, added bool)) {
+	for event := range listener {
+		if event.Tag != "endpoint.new" && event.Tag != "endpoint.lost" {
+			continue
+		}
+
+		e, ok := event.Data.(*network.Endpoint)
+		if !ok {
+			continue
+		}
+
+		// logging from here would deadlock, the event pool is
+		// locked until this listener consumes the next event
+		if event.Tag == "endpoint.new" {
+			if p.autoFilter.Matches(e) && !p.Session.Skip(e.IP) && !p.isWhitelisted(e.IpAddress, e.HW) && p.addAutoTarget(e) {
+				go notify(e, true)
+			}
+		} else if p.removeAutoTarget(e) {
+			go notify(e, false)
+		}
+	}
+}
+
+func (p *ArpSpoofer) onAutoTarget(e *network.Endpoint, added bool) {
+	if added {
+		log.Info("ARP spoofer automatically targeting %s.", e.String())
+		return
+	}
+
+	log.Info("Target %s lost, restoring its ARP cache.", e.String())
+	p.restoreTarget(e.IP, e.HW)
+}
+
+func (p *ArpSpoofer) startAuto() {
+	if !p.auto {
+		return
+	} else if !p.Session.IsOn("net.recon") {
+		log.Warning("arp.spoof.auto relies on net.recon to discover new hosts, make sure it's running.")
+	}
+
+	p.autoListener = p.Session.Events.Listen()
+	go p.autoWorker(p.autoListener, p.onAutoTarget)
+}
+
+func (p *ArpSpoofer) stopAuto() {
+	if p.autoListener != nil {
+		p.Session.Events.Unlisten(p.autoListener)
+		p.autoListener = nil
+	}
+}
@@ -0,0 +1,139 @@
+package modules
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bettercap/bettercap/network"
+	"github.com/bettercap/bettercap/session"
+)
+
+func TestArpSpoofAutoFilter(t *testing.T) {
+	lab := network.NewEndpointNoResolve("192.168.1.10", "aa:00:00:00:00:10", "lab-printer", 24)
+	phone := network.NewEndpointNoResolve("10.0.0.5", "aa:00:00:00:00:05", "", 24)
+	phone.Alias = "phone-bob"
+
+	var units = []struct {
+		filter string
+		lab    bool
+		phone  bool
+		err    bool
+	}{
+		{"", true, true, false},
+		{"192.168.1.0/24", true, false, false},
+		{"^lab-", true, false, false},
+		{"phone", false, true, false},
+		{"(", false, false, true},
+	}
+
+	for _, u := range units {
+		f, err := parseArpAutoFilter(u.filter)
+		if u.err {
+			if err == nil {
+				t.Fatalf("expected error for '%s'", u.filter)
+			}
+			continue
+		} else if err != nil {
+			t.Fatalf("unexpected error for '%s': %v", u.filter, err)
+		}
+
+		if got := f.Matches(lab); got != u.lab {
+			t.Fatalf("expected '%v' for lab with '%s', got '%v'", u.lab, u.filter, got)
+		} else if got := f.Matches(phone); got != u.phone {
+			t.Fatalf("expected '%v' for phone with '%s', got '%v'", u.phone, u.filter, got)
+		}
+	}
+}
+
+func TestArpSpoofAutoWorker(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	p := NewArpSpoofer(s.Session)
+	p.addresses = []net.IP{net.ParseIP("192.168.1.20")}
+	if p.autoFilter, err = parseArpAutoFilter("192.168.1.0/24"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	static := network.NewEndpointNoResolve("192.168.1.20", "aa:00:00:00:00:20", "", 24)
+	matching := network.NewEndpointNoResolve("192.168.1.10", "aa:00:00:00:00:10", "", 24)
+	outside := network.NewEndpointNoResolve("10.0.0.5", "aa:00:00:00:00:05", "", 24)
+
+	listener := make(chan session.Event)
+	notified := make(chan string, 8)
+	done := make(chan bool)
+	go func() {
+		p.autoWorker(listener, func(e *network.Endpoint, added bool) {
+			if added {
+				notified <- "+" + e.IpAddress
+			} else {
+				notified <- "-" + e.IpAddress
+			}
+		})
+		done <- true
+	}()
+
+	events := []session.Event{
+		session.NewEvent("endpoint.new", outside),
+		session.NewEvent("sys.log", session.LogMessage{}),
+		session.NewEvent("endpoint.new", matching),
+		session.NewEvent("endpoint.new", matching),
+		session.NewEvent("endpoint.lost", static),
+	}
+	for _, e := range events {
+		listener <- e
+	}
+
+	if got := <-notified; got != "+192.168.1.10" {
+		t.Fatalf("expected '+192.168.1.10', got '%s'", got)
+	} else if got := len(p.targetAddresses()); got != 2 {
+		t.Fatalf("expected 2 targets, got %d", got)
+	} else if hw, found := p.resolved.Get("192.168.1.10"); !found || hw.String() != "aa:00:00:00:00:10" {
+		t.Fatalf("expected 'aa:00:00:00:00:10', got '%v'", hw)
+	}
+
+	listener <- session.NewEvent("endpoint.lost", matching)
+	close(listener)
+	<-done
+
+	if got := <-notified; got != "-192.168.1.10" {
+		t.Fatalf("expected '-192.168.1.10', got '%s'", got)
+	}
+
+	// the static target is never dropped
+	if targets := p.targetAddresses(); len(targets) != 1 || targets[0].String() != "192.168.1.20" {
+		t.Fatalf("expected '[192.168.1.20]', got '%v'", targets)
+	}
+
+	select {
+	case got := <-notified:
+		t.Fatalf("unexpected notification '%s'", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestArpSpoofAutoStartTwice(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	p := NewArpSpoofer(s.Session)
+	listener := s.Events.Listen()
+	defer s.Events.Unlisten(listener)
+	p.autoListener = listener
+	p.SetRunning(true, nil)
+
+	if err := s.Set("arp.spoof.auto", "true"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := p.Start(); err != session.ErrAlreadyStarted {
+		t.Fatalf("expected '%v', got '%v'", session.ErrAlreadyStarted, err)
+	} else if p.autoListener != listener {
+		t.Fatal("expected the listener of the running spoofer to be kept")
+	}
+}
@@ -0,0 +1,457 @@
+package modules
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/network"
+	"github.com/bettercap/bettercap/packets"
+)
+
+// how often the time windows of the profiles are checked
+const arpProfilesCheckPeriod = 1 * time.Second
+
+// arpTimeWindow is the part of the day a profile is active in, in minutes
+// since midnight, it wraps around midnight if From is after To.
+type arpTimeWindow struct {
+	From int
+	To   int
+	set  bool
+}
+
+// parseArpTimeWindow parses HH:MM-HH:MM, "" means the whole day.
+func parseArpTimeWindow(s string) (arpTimeWindow, error) {
+	w := arpTimeWindow{}
+	if s == "" {
+		return w, nil
+	}
+
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return w, fmt.Errorf("'%s' is not a valid HH:MM-HH:MM time window.", s)
+	}
+
+	from, err := time.Parse("15:04", parts[0])
+	if err != nil {
+		return w, fmt.Errorf("'%s' is not a valid HH:MM-HH:MM time window.", s)
+	}
+	to, err := time.Parse("15:04", parts[1])
+	if err != nil {
+		return w, fmt.Errorf("'%s' is not a valid HH:MM-HH:MM time window.", s)
+	}
+
+	w.From = from.Hour()*60 + from.Minute()
+	w.To = to.Hour()*60 + to.Minute()
+	if w.From == w.To {
+		return w, fmt.Errorf("The time window '%s' is empty.", s)
+	}
+	w.set = true
+	return w, nil
+}
+
+func (w arpTimeWindow) Contains(t time.Time) bool {
+	if !w.set {
+		return true
+	}
+
+	m := t.Hour()*60 + t.Minute()
+	if w.From < w.To {
+		return m >= w.From && m < w.To
+	}
+	return m >= w.From || m < w.To
+}
+
+func (w arpTimeWindow) String() string {
+	if !w.set {
+		return "always"
+	}
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", w.From/60, w.From%60, w.To/60, w.To%60)
+}
+
+// arpSpoofProfile is a named set of targets spoofed during its window.
+type arpSpoofProfile struct {
+	Name       string
+	Targets    string
+	Whitelist  string
+	FullDuplex bool
+	Window     arpTimeWindow
+}
+
+// parseArpSpoofProfile parses the space separated whitelist=LIST,
+// fullduplex[=BOOL] and window=HH:MM-HH:MM options of a profile.
+func parseArpSpoofProfile(name, targets, options string) (*arpSpoofProfile, error) {
+	var err error
+
+	prof := &arpSpoofProfile{Name: name, Targets: targets}
+	for _, opt := range strings.Fields(options) {
+		parts := strings.SplitN(opt, "=", 2)
+		key, value := parts[0], ""
+		if len(parts) == 2 {
+			value = parts[1]
+		}
+
+		switch key {
+		case "whitelist":
+			prof.Whitelist = value
+		case "fullduplex":
+			if value == "" {
+				prof.FullDuplex = true
+			} else if prof.FullDuplex, err = strconv.ParseBool(value); err != nil {
+				return nil, fmt.Errorf("'%s' is not a valid fullduplex value.", value)
+			}
+		case "window":
+			if prof.Window, err = parseArpTimeWindow(value); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("Unknown profile option '%s', expected whitelist=LIST, fullduplex[=BOOL] or window=HH:MM-HH:MM.", key)
+		}
+	}
+
+	for _, list := range []string{prof.Targets, prof.Whitelist} {
+		if file, err := network.NewTargetsFile(list); err != nil {
+			return nil, err
+		} else if file != nil {
+			return nil, fmt.Errorf("Profiles don't support the @path form of the targets.")
+		}
+	}
+	return prof, nil
+}
+
+// AddProfile adds the profile, or replaces the one with the same name,
+// and applies it right away if the spoofer is running.
+func (p *ArpSpoofer) AddProfile(prof *arpSpoofProfile) error {
+	aliases := p.Session.Lan.Aliases()
+	if _, _, err := network.ParseTargets(prof.Targets, aliases); err != nil {
+		return err
+	} else if _, _, err := network.ParseTargets(prof.Whitelist, aliases); err != nil {
+		return err
+	}
+
+	p.profilesLock.Lock()
+	replaced := false
+	for i, other := range p.profiles {
+		if other.Name == prof.Name {
+			p.profiles[i] = prof
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		p.profiles = append(p.profiles, prof)
+	}
+	if p.Running() {
+		// the targets of the parameters are replaced from now on
+		p.useProfiles = true
+	}
+	p.profilesLock.Unlock()
+
+	if replaced {
+		log.Info("ARP spoofer profile %s updated.", core.Bold(prof.Name))
+	} else {
+		log.Info("ARP spoofer profile %s added.", core.Bold(prof.Name))
+	}
+
+	if p.Running() {
+		p.applyProfiles(time.Now(), true)
+	}
+	return nil
+}
+
+// DelProfile removes the profile, restoring its targets if the spoofer
+// is running and no other active profile spoofs them.
+func (p *ArpSpoofer) DelProfile(name string) error {
+	p.profilesLock.Lock()
+	found := false
+	for i, prof := range p.profiles {
+		if prof.Name == name {
+			p.profiles = append(p.profiles[:i], p.profiles[i+1:]...)
+			found = true
+			break
+		}
+	}
+	// back to the targets of the parameters
+	last := found && p.useProfiles && len(p.profiles) == 0
+	if last {
+		p.useProfiles = false
+		p.activeProfiles = ""
+	}
+	p.profilesLock.Unlock()
+
+	if !found {
+		return fmt.Errorf("No ARP spoofer profile named %s.", name)
+	}
+	log.Info("ARP spoofer profile %s removed.", core.Bold(name))
+
+	if !p.Running() {
+		return nil
+	} else if last {
+		return p.applyParamTargets()
+	}
+	p.applyProfiles(time.Now(), true)
+	return nil
+}
+
+// applyParamTargets spoofs the targets of arp.spoof.targets and
+// arp.spoof.whitelist again once the last profile has been removed.
+func (p *ArpSpoofer) applyParamTargets() error {
+	err, targets := p.StringParam("arp.spoof.targets")
+	if err != nil {
+		return err
+	}
+	err, whitelist := p.StringParam("arp.spoof.whitelist")
+	if err != nil {
+		return err
+	}
+
+	aliases := p.Session.Lan.Aliases()
+	ips, macs, targetsFile, err := parseArpTargets(targets, aliases)
+	if err != nil {
+		return err
+	}
+	wIps, wMacs, whitelistFile, err := parseArpTargets(whitelist, aliases)
+	if err != nil {
+		return err
+	}
+	if targetsFile != nil || whitelistFile != nil {
+		log.Warning("The arp.spoof.targets and arp.spoof.whitelist files have been read once, restart the spoofer to reload them when they change.")
+	}
+
+	removedIPs, removedMacs := p.setTargets(ips, macs)
+	p.setWhitelist(wIps, wMacs)
+	removedDIps, removedDMacs := p.setDuplexTargets(make([]net.IP, 0), make([]net.HardwareAddr, 0))
+
+	p.restoreRemoved(removedIPs, removedMacs)
+	if p.mode != arpModeReactive {
+		p.sendGatewayArp(removedDIps, removedDMacs, false)
+	}
+
+	log.Info("No ARP spoofer profiles left, spoofing arp.spoof.targets: %d addresses and %d macs.", len(ips), len(macs))
+	return nil
+}
+
+func (p *ArpSpoofer) showProfiles() error {
+	p.profilesLock.Lock()
+	profiles := append([]*arpSpoofProfile{}, p.profiles...)
+	p.profilesLock.Unlock()
+
+	if len(profiles) == 0 {
+		return fmt.Errorf("No profiles defined.")
+	}
+
+	rows := make([][]string, 0, len(profiles))
+	now := time.Now()
+	for _, prof := range profiles {
+		active := no()
+		if prof.Window.Contains(now) {
+			active = yes()
+		}
+		fullDuplex := no()
+		if prof.FullDuplex {
+			fullDuplex = yes()
+		}
+
+		rows = append(rows, []string{
+			prof.Name,
+			prof.Targets,
+			prof.Whitelist,
+			fullDuplex,
+			prof.Window.String(),
+			active,
+		})
+	}
+
+	fmt.Println()
+	core.AsTable(os.Stdout, []string{"Name", "Targets", "Whitelist", "Full Duplex", "Window", "Active"}, rows)
+	fmt.Println()
+	return nil
+}
+
+func appendNewIPs(list []net.IP, ips []net.IP) []net.IP {
+	for _, ip := range ips {
+		if !containsIP(list, ip) {
+			list = append(list, ip)
+		}
+	}
+	return list
+}
+
+func appendNewMacs(list []net.HardwareAddr, macs []net.HardwareAddr) []net.HardwareAddr {
+	for _, mac := range macs {
+		if !containsMac(list, mac) {
+			list = append(list, mac)
+		}
+	}
+	return list
+}
+
+// applyProfiles spoofs the union of the targets of the profiles active
+// at now, if they changed or force is true, and restores the ones which
+// are not spoofed anymore.
+func (p *ArpSpoofer) applyProfiles(now time.Time, force bool) {
+	p.profilesLock.Lock()
+	if !p.useProfiles {
+		p.profilesLock.Unlock()
+		return
+	}
+
+	active := make([]*arpSpoofProfile, 0)
+	names := make([]string, 0)
+	for _, prof := range p.profiles {
+		if prof.Window.Contains(now) {
+			active = append(active, prof)
+			names = append(names, prof.Name)
+		}
+	}
+
+	activeNames := strings.Join(names, ", ")
+	if !force && activeNames == p.activeProfiles {
+		p.profilesLock.Unlock()
+		return
+	}
+	p.activeProfiles = activeNames
+	p.profilesLock.Unlock()
+
+	aliases := p.Session.Lan.Aliases()
+	ips, macs := make([]net.IP, 0), make([]net.HardwareAddr, 0)
+	wIps, wMacs := make([]net.IP, 0), make([]net.HardwareAddr, 0)
+	dIps, dMacs := make([]net.IP, 0), make([]net.HardwareAddr, 0)
+	for _, prof := range active {
+		pIps, pMacs, err := network.ParseTargets(prof.Targets, aliases)
+		if err != nil {
+			log.Warning("Skipping ARP spoofer profile %s: %s", prof.Name, err)
+			continue
+		}
+		pwIps, pwMacs, err := network.ParseTargets(prof.Whitelist, aliases)
+		if err != nil {
+			log.Warning("Skipping ARP spoofer profile %s: %s", prof.Name, err)
+			continue
+		}
+
+		ips, macs = appendNewIPs(ips, pIps), appendNewMacs(macs, pMacs)
+		wIps, wMacs = appendNewIPs(wIps, pwIps), appendNewMacs(wMacs, pwMacs)
+		if prof.FullDuplex {
+			dIps, dMacs = appendNewIPs(dIps, pIps), appendNewMacs(dMacs, pMacs)
+		}
+	}
+
+	removedIPs, removedMacs := p.setTargets(ips, macs)
+	p.setWhitelist(wIps, wMacs)
+	removedDIps, removedDMacs := p.setDuplexTargets(dIps, dMacs)
+
+	if p.Running() {
+		p.restoreRemoved(removedIPs, removedMacs)
+		if p.mode != arpModeReactive {
+			p.sendGatewayArp(removedDIps, removedDMacs, false)
+		}
+	}
+
+	if activeNames == "" {
+		log.Info("No ARP spoofer profile is active.")
+	} else {
+		log.Info("ARP spoofer profiles %s active: %d addresses and %d macs.", core.Bold(activeNames), len(ips), len(macs))
+	}
+}
+
+// profilesEnabled is true if the targets come from the profiles instead
+// of arp.spoof.targets and arp.spoof.whitelist.
+func (p *ArpSpoofer) profilesEnabled() bool {
+	p.profilesLock.Lock()
+	defer p.profilesLock.Unlock()
+	return p.useProfiles
+}
+
+// profilesWorker applies the profiles as their windows start and end,
+// the wait group is incremented by the caller.
+func (p *ArpSpoofer) profilesWorker() {
+	defer p.waitGroup.Done()
+
+	for p.Running() {
+		time.Sleep(arpProfilesCheckPeriod)
+		if p.Running() {
+			p.applyProfiles(time.Now(), false)
+		}
+	}
+}
+
+// setDuplexTargets replaces the targets the gateway is poisoned for, it
+// returns the ones which are not anymore.
+func (p *ArpSpoofer) setDuplexTargets(ips []net.IP, macs []net.HardwareAddr) (removedIPs []net.IP, removedMacs []net.HardwareAddr) {
+	p.targetsLock.Lock()
+	defer p.targetsLock.Unlock()
+
+	removedIPs = make([]net.IP, 0)
+	for _, ip := range p.dAddresses {
+		if !containsIP(ips, ip) {
+			removedIPs = append(removedIPs, ip)
+		}
+	}
+
+	removedMacs = make([]net.HardwareAddr, 0)
+	for _, mac := range p.dMacs {
+		if !containsMac(macs, mac) {
+			removedMacs = append(removedMacs, mac)
+		}
+	}
+
+	p.dAddresses = ips
+	p.dMacs = macs
+	return
+}
+
+func (p *ArpSpoofer) duplexTargets() ([]net.IP, []net.HardwareAddr) {
+	p.targetsLock.RLock()
+	defer p.targetsLock.RUnlock()
+	return append([]net.IP{}, p.dAddresses...), append([]net.HardwareAddr{}, p.dMacs...)
+}
+
+// sendGatewayArp tells the gateway that the targets are at our address
+// if spoof is true, otherwise at their real one.
+func (p *ArpSpoofer) sendGatewayArp(ips []net.IP, macs []net.HardwareAddr, spoof bool) *core.MultiError {
+	result := core.NewMultiError()
+	gw := p.Session.Gateway
+	if gw == nil || gw == p.Session.Interface {
+		return result
+	}
+
+	targets := make(map[string]net.HardwareAddr)
+	for _, ip := range ips {
+		if hw, err := p.lookup(ip, false); err != nil {
+			result.Add(ip.String(), err)
+		} else {
+			targets[ip.String()] = hw
+		}
+	}
+	for _, hw := range macs {
+		if ip, err := network.ArpInverseLookup(p.Session.Interface.Name(), hw.String(), false); err != nil {
+			result.Add(hw.String(), err)
+		} else {
+			targets[ip] = hw
+		}
+	}
+
+	for ip, hw := range targets {
+		if p.Session.Skip(net.ParseIP(ip)) || p.isWhitelisted(ip, hw) {
+			continue
+		}
+
+		smac := hw
+		if spoof {
+			smac = p.Session.Interface.HW
+		}
+
+		if err, pkt := packets.NewARPReply(net.ParseIP(ip), smac, gw.IP, gw.HW); err != nil {
+			log.Error("Error while creating ARP packet for the gateway about %s: %s", ip, err)
+			result.Add(ip, err)
+		} else {
+			result.Add(ip, p.injectArp(gw.IpAddress, gw.HW, smac, pkt))
+		}
+	}
+	return result
+}
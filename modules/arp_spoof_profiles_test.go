@@ -0,0 +1,133 @@
+package modules
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bettercap/bettercap/session"
+)
+
+func TestArpTimeWindow(t *testing.T) {
+	at := func(hour, min int) time.Time {
+		return time.Date(2018, 3, 1, hour, min, 0, 0, time.Local)
+	}
+
+	var units = []struct {
+		window   string
+		at       time.Time
+		expected bool
+		err      bool
+	}{
+		{"", at(3, 0), true, false},
+		{"09:00-17:30", at(9, 0), true, false},
+		{"09:00-17:30", at(17, 29), true, false},
+		{"09:00-17:30", at(17, 30), false, false},
+		{"09:00-17:30", at(8, 59), false, false},
+		{"22:00-06:00", at(23, 0), true, false},
+		{"22:00-06:00", at(5, 59), true, false},
+		{"22:00-06:00", at(12, 0), false, false},
+		{"10:00-10:00", at(10, 0), false, true},
+		{"10:00", at(10, 0), false, true},
+		{"25:00-26:00", at(10, 0), false, true},
+	}
+
+	for _, u := range units {
+		w, err := parseArpTimeWindow(u.window)
+		if u.err {
+			if err == nil {
+				t.Fatalf("expected error for '%s'", u.window)
+			}
+			continue
+		} else if err != nil {
+			t.Fatalf("unexpected error for '%s': %v", u.window, err)
+		}
+
+		if got := w.Contains(u.at); got != u.expected {
+			t.Fatalf("expected '%v' for '%s' at %s, got '%v'", u.expected, u.window, u.at.Format("15:04"), got)
+		}
+	}
+}
+
+func TestArpSpoofProfileOptions(t *testing.T) {
+	prof, err := parseArpSpoofProfile("office", "192.168.1.0/30", " whitelist=192.168.1.2 fullduplex window=09:00-17:00")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if prof.Whitelist != "192.168.1.2" {
+		t.Fatalf("expected '%s', got '%s'", "192.168.1.2", prof.Whitelist)
+	} else if !prof.FullDuplex {
+		t.Fatalf("expected full duplex")
+	} else if w := prof.Window.String(); w != "09:00-17:00" {
+		t.Fatalf("expected '%s', got '%s'", "09:00-17:00", w)
+	}
+
+	for _, options := range []string{"fullduplex=maybe", "window=9-17", "ttl=10"} {
+		if _, err := parseArpSpoofProfile("office", "192.168.1.10", options); err == nil {
+			t.Fatalf("expected error for '%s'", options)
+		}
+	}
+
+	if _, err := parseArpSpoofProfile("office", "@/tmp/targets", ""); err == nil {
+		t.Fatalf("expected error for a targets file")
+	}
+}
+
+func TestArpSpoofProfilesApply(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	p := NewArpSpoofer(s.Session)
+	for _, args := range [][]string{
+		{"day", "192.168.1.10,192.168.1.11", "whitelist=192.168.1.11 window=08:00-20:00"},
+		{"night", "192.168.1.20", "fullduplex window=20:00-08:00"},
+		{"always", "192.168.1.10", ""},
+	} {
+		prof, err := parseArpSpoofProfile(args[0], args[1], args[2])
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if err = p.AddProfile(prof); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	p.useProfiles = true
+
+	check := func(at time.Time, targets, whitelist, duplex string) {
+		p.applyProfiles(at, false)
+
+		dIps, _ := p.duplexTargets()
+		if got := fmt.Sprintf("%v", p.targetAddresses()); got != targets {
+			t.Fatalf("expected '%s', got '%s'", targets, got)
+		} else if got := fmt.Sprintf("%v", p.wAddresses); got != whitelist {
+			t.Fatalf("expected '%s', got '%s'", whitelist, got)
+		} else if got := fmt.Sprintf("%v", dIps); got != duplex {
+			t.Fatalf("expected '%s', got '%s'", duplex, got)
+		}
+	}
+
+	day := time.Date(2018, 3, 1, 12, 0, 0, 0, time.Local)
+	night := time.Date(2018, 3, 1, 23, 0, 0, 0, time.Local)
+
+	check(day, "[192.168.1.10 192.168.1.11]", "[192.168.1.11]", "[]")
+	check(night, "[192.168.1.20 192.168.1.10]", "[]", "[192.168.1.20]")
+
+	if err := p.DelProfile("night"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := p.DelProfile("night"); err == nil {
+		t.Fatalf("expected error deleting a missing profile")
+	}
+	p.applyProfiles(night, true)
+	check(night, "[192.168.1.10]", "[]", "[]")
+
+	// the targets of the parameters are used again without profiles
+	for _, name := range []string{"day", "always"} {
+		if err := p.DelProfile(name); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if p.profilesEnabled() {
+		t.Fatal("expected the profiles to be disabled once the last one is removed")
+	}
+}
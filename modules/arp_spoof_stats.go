@@ -0,0 +1,168 @@
+package modules
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/session"
+)
+
+// packets sent to a single host and when it was last touched
+type arpTargetStats struct {
+	IP           string
+	MAC          string
+	Poisoned     uint64
+	Restored     uint64
+	LastActivity time.Time
+}
+
+type arpSpoofStats struct {
+	sync.Mutex
+	Started time.Time
+	targets map[string]*arpTargetStats
+}
+
+func newArpSpoofStats() *arpSpoofStats {
+	return &arpSpoofStats{
+		targets: make(map[string]*arpTargetStats),
+	}
+}
+
+// Reset forgets every target, it's called whenever the spoofer starts.
+func (s *arpSpoofStats) Reset() {
+	s.Lock()
+	defer s.Unlock()
+	s.Started = time.Now()
+	s.targets = make(map[string]*arpTargetStats)
+}
+
+// Add counts a poisoning or restoring packet sent to a host.
+func (s *arpSpoofStats) Add(ip string, mac net.HardwareAddr, restore bool, at time.Time) {
+	s.Lock()
+	defer s.Unlock()
+
+	t, found := s.targets[ip]
+	if !found {
+		t = &arpTargetStats{IP: ip}
+		s.targets[ip] = t
+	}
+
+	t.MAC = mac.String()
+	t.LastActivity = at
+	if restore {
+		t.Restored++
+	} else {
+		t.Poisoned++
+	}
+}
+
+// Summary returns when the spoofer started and the packets it sent.
+func (s *arpSpoofStats) Summary() (started time.Time, poisoned uint64, restored uint64) {
+	s.Lock()
+	defer s.Unlock()
+	started = s.Started
+	for _, t := range s.targets {
+		poisoned += t.Poisoned
+		restored += t.Restored
+	}
+	return
+}
+
+// Targets returns a copy of the stats, the most recently touched first.
+func (s *arpSpoofStats) Targets() []arpTargetStats {
+	s.Lock()
+	defer s.Unlock()
+
+	list := make([]arpTargetStats, 0, len(s.targets))
+	for _, t := range s.targets {
+		list = append(list, *t)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].LastActivity.Equal(list[j].LastActivity) {
+			return list[i].LastActivity.After(list[j].LastActivity)
+		}
+		return list[i].IP < list[j].IP
+	})
+	return list
+}
+
+// injectArp sends an ARP reply to a host and counts it as poisoning if
+// it claims one of the addresses is ours, as restoring otherwise.
+func (p *ArpSpoofer) injectArp(ip string, mac net.HardwareAddr, smac net.HardwareAddr, pkt []byte) error {
+	if err := p.Session.Inject(pkt); err != nil {
+		return err
+	}
+	p.stats.Add(ip, mac, !bytes.Equal(smac, p.Session.Interface.HW), time.Now())
+	return nil
+}
+
+func (p *ArpSpoofer) showStats() error {
+	started, poisoned, restored := p.stats.Summary()
+	if started.IsZero() {
+		return fmt.Errorf("No stats yet.")
+	}
+
+	targets := p.stats.Targets()
+
+	fmt.Println()
+	fmt.Printf("  Started  : %s\n", started.Format("15:04:05"))
+	fmt.Printf("  Poisoned : %d packets\n", poisoned)
+	fmt.Printf("  Restored : %d packets\n", restored)
+	fmt.Printf("  Total    : %d packets to %d targets\n", poisoned+restored, len(targets))
+	fmt.Println()
+
+	if len(targets) == 0 {
+		return nil
+	}
+
+	rows := make([][]string, 0, len(targets))
+	now := time.Now()
+	for _, t := range targets {
+		mac, _ := net.ParseMAC(t.MAC)
+		active := no()
+		if p.Running() && p.isTarget(net.ParseIP(t.IP), mac) {
+			active = yes()
+		}
+
+		rows = append(rows, []string{
+			t.IP,
+			t.MAC,
+			active,
+			fmt.Sprintf("%d", t.Poisoned),
+			fmt.Sprintf("%d", t.Restored),
+			fmt.Sprintf("%s (%s ago)", t.LastActivity.Format("15:04:05"), now.Sub(t.LastActivity).Round(time.Second)),
+		})
+	}
+
+	core.AsTable(os.Stdout, []string{"IP", "MAC", "Active", "Poisoned", "Restored", "Last Activity"}, rows)
+	fmt.Println()
+	return nil
+}
+
+// Metrics publishes the packets sent and the hosts being spoofed.
+func (p *ArpSpoofer) Metrics() []session.Metric {
+	_, poisoned, restored := p.stats.Summary()
+
+	active := 0
+	if p.Running() {
+		for _, t := range p.stats.Targets() {
+			if mac, err := net.ParseMAC(t.MAC); err == nil && p.isTarget(net.ParseIP(t.IP), mac) {
+				active++
+			}
+		}
+	}
+
+	help := "Number of packets the module injected."
+	return []session.Metric{
+		session.NewCounter("bettercap_module_packets_injected_total", help, poisoned, map[string]string{"kind": "poison"}),
+		session.NewCounter("bettercap_module_packets_injected_total", help, restored, map[string]string{"kind": "restore"}),
+		session.NewGauge("bettercap_arp_spoof_targets", "Number of hosts being spoofed.", float64(active), nil),
+	}
+}
@@ -0,0 +1,50 @@
+package modules
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestArpSpoofStats(t *testing.T) {
+	stats := newArpSpoofStats()
+	if started, _, _ := stats.Summary(); !started.IsZero() {
+		t.Fatal("expected no stats before the spoofer starts")
+	}
+
+	stats.Reset()
+
+	now := time.Now()
+	a, _ := net.ParseMAC("aa:00:00:00:00:01")
+	b, _ := net.ParseMAC("aa:00:00:00:00:02")
+	stats.Add("192.168.1.10", a, false, now)
+	stats.Add("192.168.1.10", a, false, now.Add(time.Second))
+	stats.Add("192.168.1.11", b, false, now.Add(2*time.Second))
+	stats.Add("192.168.1.10", a, true, now.Add(3*time.Second))
+
+	if started, poisoned, restored := stats.Summary(); started.IsZero() {
+		t.Fatal("expected the start time to be set")
+	} else if poisoned != 3 || restored != 1 {
+		t.Fatalf("expected 3 poisoned and 1 restored, got %d and %d", poisoned, restored)
+	}
+
+	var units = []arpTargetStats{
+		{"192.168.1.10", "aa:00:00:00:00:01", 2, 1, now.Add(3 * time.Second)},
+		{"192.168.1.11", "aa:00:00:00:00:02", 1, 0, now.Add(2 * time.Second)},
+	}
+
+	targets := stats.Targets()
+	if len(targets) != len(units) {
+		t.Fatalf("expected %d targets, got %d", len(units), len(targets))
+	}
+	for i, u := range units {
+		if targets[i] != u {
+			t.Fatalf("expected '%+v', got '%+v'", u, targets[i])
+		}
+	}
+
+	stats.Reset()
+	if len(stats.Targets()) != 0 {
+		t.Fatal("expected the targets to be forgotten")
+	}
+}
@@ -0,0 +1,185 @@
+package modules
+
+import (
+	"bytes"
+	"net"
+	"time"
+
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/network"
+	"github.com/bettercap/bettercap/packets"
+)
+
+// how often the @path targets and whitelist files are checked for changes
+const arpTargetsCheckPeriod = 1 * time.Second
+
+// parseArpTargets parses either a list of targets or, in the @path form,
+// the file with the list which is then returned to check it for changes.
+func parseArpTargets(targets string, aliasMap *network.Aliases) ([]net.IP, []net.HardwareAddr, *network.TargetsFile, error) {
+	file, err := network.NewTargetsFile(targets)
+	if err != nil {
+		return nil, nil, nil, err
+	} else if file == nil {
+		ips, macs, err := network.ParseTargets(targets, aliasMap)
+		return ips, macs, nil, err
+	}
+
+	ips, macs, skipped, err := file.Parse(aliasMap)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for _, err := range skipped {
+		log.Warning("Skipping malformed targets line %s", err)
+	}
+	return ips, macs, file, nil
+}
+
+// targetMacs returns a copy of the hardware addresses being spoofed,
+// which are changed by the targets file while the spoofer runs.
+func (p *ArpSpoofer) targetMacs() []net.HardwareAddr {
+	p.targetsLock.RLock()
+	defer p.targetsLock.RUnlock()
+	return append([]net.HardwareAddr{}, p.macs...)
+}
+
+func containsIP(list []net.IP, ip net.IP) bool {
+	for _, other := range list {
+		if other.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsMac(list []net.HardwareAddr, mac net.HardwareAddr) bool {
+	for _, other := range list {
+		if bytes.Equal(other, mac) {
+			return true
+		}
+	}
+	return false
+}
+
+// setTargets replaces the targets of the file keeping the ones added by
+// the auto mode, it returns the targets which are not spoofed anymore.
+func (p *ArpSpoofer) setTargets(ips []net.IP, macs []net.HardwareAddr) (removedIPs []net.IP, removedMacs []net.HardwareAddr) {
+	p.targetsLock.Lock()
+	defer p.targetsLock.Unlock()
+
+	removedIPs = make([]net.IP, 0)
+	addresses := append([]net.IP{}, ips...)
+	for _, ip := range p.addresses {
+		if containsIP(ips, ip) {
+			// listed in the file now, not up to the auto mode anymore
+			delete(p.autoAdded, ip.String())
+		} else if p.autoAdded[ip.String()] {
+			addresses = append(addresses, ip)
+		} else {
+			removedIPs = append(removedIPs, ip)
+		}
+	}
+
+	removedMacs = make([]net.HardwareAddr, 0)
+	for _, mac := range p.macs {
+		if !containsMac(macs, mac) {
+			removedMacs = append(removedMacs, mac)
+		}
+	}
+
+	p.addresses = addresses
+	p.macs = macs
+	return
+}
+
+func (p *ArpSpoofer) setWhitelist(ips []net.IP, macs []net.HardwareAddr) {
+	p.targetsLock.Lock()
+	defer p.targetsLock.Unlock()
+	p.wAddresses = ips
+	p.wMacs = macs
+}
+
+// reloadTargets re-reads the targets and the whitelist files if they
+// changed, returning the targets which are not spoofed anymore.
+func (p *ArpSpoofer) reloadTargets() (removedIPs []net.IP, removedMacs []net.HardwareAddr) {
+	if p.profilesEnabled() {
+		// profiles added while running override the files
+		return
+	}
+
+	aliases := p.Session.Lan.Aliases()
+
+	if p.targetsFile != nil {
+		if changed, err := p.targetsFile.Changed(); err != nil {
+			log.Warning("Could not check the targets file: %s", err)
+		} else if changed {
+			if ips, macs, skipped, err := p.targetsFile.Parse(aliases); err != nil {
+				log.Warning("Could not read the targets file: %s", err)
+			} else {
+				for _, err := range skipped {
+					log.Warning("Skipping malformed targets line %s", err)
+				}
+				removedIPs, removedMacs = p.setTargets(ips, macs)
+				log.Info("ARP spoofer targets reloaded from %s: %d addresses and %d macs.", p.targetsFile.Path, len(ips), len(macs))
+			}
+		}
+	}
+
+	if p.whitelistFile != nil {
+		if changed, err := p.whitelistFile.Changed(); err != nil {
+			log.Warning("Could not check the whitelist file: %s", err)
+		} else if changed {
+			if ips, macs, skipped, err := p.whitelistFile.Parse(aliases); err != nil {
+				log.Warning("Could not read the whitelist file: %s", err)
+			} else {
+				for _, err := range skipped {
+					log.Warning("Skipping malformed whitelist line %s", err)
+				}
+				p.setWhitelist(ips, macs)
+				log.Info("ARP spoofer whitelist reloaded from %s.", p.whitelistFile.Path)
+			}
+		}
+	}
+
+	return
+}
+
+// restoreTarget sends the real gateway address to a target which is
+// not spoofed anymore.
+func (p *ArpSpoofer) restoreTarget(ip net.IP, hw net.HardwareAddr) {
+	if err, pkt := packets.NewARPReply(p.Session.Gateway.IP, p.Session.Gateway.HW, ip, hw); err != nil {
+		log.Error("Error while creating ARP restore packet for %s: %s", ip, err)
+	} else if err := p.injectArp(ip.String(), hw, p.Session.Gateway.HW, pkt); err != nil {
+		log.Warning("Could not restore the ARP cache of %s: %s", ip, err)
+	}
+}
+
+// targetsWorker follows the changes of the files while the spoofer runs,
+// the wait group is incremented by the caller.
+func (p *ArpSpoofer) targetsWorker() {
+	defer p.waitGroup.Done()
+
+	for p.Running() {
+		time.Sleep(arpTargetsCheckPeriod)
+
+		p.restoreRemoved(p.reloadTargets())
+	}
+}
+
+// restoreRemoved restores the targets which are not spoofed anymore.
+func (p *ArpSpoofer) restoreRemoved(removedIPs []net.IP, removedMacs []net.HardwareAddr) {
+	for _, ip := range removedIPs {
+		if hw, err := p.lookup(ip, false); err != nil {
+			log.Debug("Could not restore removed target %s: %s", ip, err)
+		} else {
+			p.restoreTarget(ip, hw)
+		}
+	}
+
+	for _, hw := range removedMacs {
+		if ip, err := network.ArpInverseLookup(p.Session.Interface.Name(), hw.String(), false); err != nil {
+			log.Debug("Could not restore removed target %s: %s", hw, err)
+		} else {
+			p.restoreTarget(net.ParseIP(ip), hw)
+		}
+	}
+}
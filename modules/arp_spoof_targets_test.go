@@ -0,0 +1,68 @@
+package modules
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bettercap/bettercap/session"
+)
+
+func TestArpSpoofTargetsFile(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	dir, err := ioutil.TempDir("", "arp.spoof")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "targets")
+	write := func(data string, at time.Time) {
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if err := os.Chtimes(path, at, at); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	now := time.Now()
+	write("192.168.1.10\n192.168.1.11\naa:00:00:00:00:01\n", now)
+
+	p := NewArpSpoofer(s.Session)
+	if p.addresses, p.macs, p.targetsFile, err = parseArpTargets("@"+path, s.Lan.Aliases()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if p.targetsFile == nil {
+		t.Fatal("expected a targets file")
+	}
+
+	// added by the auto mode, kept across reloads
+	auto := net.ParseIP("192.168.1.50")
+	p.addresses = append(p.addresses, auto)
+	p.autoAdded[auto.String()] = true
+
+	if ips, macs := p.reloadTargets(); len(ips) != 0 || len(macs) != 0 {
+		t.Fatalf("expected no changes, got '%v' '%v'", ips, macs)
+	}
+
+	write("192.168.1.11\n192.168.1.12\nnope nope\naa:00:00:00:00:02\n", now.Add(time.Minute))
+
+	ips, macs := p.reloadTargets()
+	if got := fmt.Sprintf("%v", ips); got != "[192.168.1.10]" {
+		t.Fatalf("expected '[192.168.1.10]', got '%s'", got)
+	} else if got := fmt.Sprintf("%v", macs); got != "[aa:00:00:00:00:01]" {
+		t.Fatalf("expected '[aa:00:00:00:00:01]', got '%s'", got)
+	} else if got := fmt.Sprintf("%v", p.targetAddresses()); got != "[192.168.1.11 192.168.1.12 192.168.1.50]" {
+		t.Fatalf("expected '[192.168.1.11 192.168.1.12 192.168.1.50]', got '%s'", got)
+	} else if got := fmt.Sprintf("%v", p.targetMacs()); got != "[aa:00:00:00:00:02]" {
+		t.Fatalf("expected '[aa:00:00:00:00:02]', got '%s'", got)
+	}
+}
@@ -0,0 +1,69 @@
+package modules
+
+import (
+	"bytes"
+	"math/rand"
+)
+
+// interesting values for a single byte
+var bleFuzzBytes = []byte{0x00, 0x01, 0x7f, 0x80, 0xfe, 0xff}
+
+// bleFuzzPayloads returns the payloads written to a characteristic, the
+// boundary ones first and then rounds random mutations of its current
+// value, no longer than size+1 bytes.
+func bleFuzzPayloads(value []byte, rounds int, size int, rnd *rand.Rand) [][]byte {
+	payloads := [][]byte{
+		{},
+		{0x00},
+		{0xff},
+		bytes.Repeat([]byte{0x00}, size),
+		bytes.Repeat([]byte{0xff}, size),
+		// one byte more than what's expected
+		bytes.Repeat([]byte{'A'}, size+1),
+		[]byte("%s%n%x%s%n%x"),
+	}
+
+	for i := 0; i < rounds; i++ {
+		payloads = append(payloads, bleMutate(value, size, rnd))
+	}
+
+	for i := range payloads {
+		if len(payloads[i]) > size+1 {
+			payloads[i] = payloads[i][:size+1]
+		}
+	}
+	return payloads
+}
+
+func bleMutate(value []byte, size int, rnd *rand.Rand) []byte {
+	mutated := make([]byte, len(value))
+	copy(mutated, value)
+
+	if len(mutated) == 0 {
+		mutated = make([]byte, 1+rnd.Intn(size))
+		rnd.Read(mutated)
+		return mutated
+	}
+
+	switch rnd.Intn(5) {
+	case 0:
+		// flip a bit
+		idx := rnd.Intn(len(mutated))
+		mutated[idx] ^= 1 << uint(rnd.Intn(8))
+	case 1:
+		// replace a byte with an interesting one
+		mutated[rnd.Intn(len(mutated))] = bleFuzzBytes[rnd.Intn(len(bleFuzzBytes))]
+	case 2:
+		// truncate
+		mutated = mutated[:rnd.Intn(len(mutated))]
+	case 3:
+		// append random bytes
+		tail := make([]byte, 1+rnd.Intn(size))
+		rnd.Read(tail)
+		mutated = append(mutated, tail...)
+	default:
+		// random bytes of the same length
+		rnd.Read(mutated)
+	}
+	return mutated
+}
@@ -0,0 +1,40 @@
+package modules
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestBLEFuzzPayloads(t *testing.T) {
+	value := []byte{0x01, 0x02, 0x03, 0x04}
+	payloads := bleFuzzPayloads(value, 64, 20, rand.New(rand.NewSource(1)))
+
+	if len(payloads) != 7+64 {
+		t.Fatalf("expected %d payloads, got %d", 7+64, len(payloads))
+	} else if !bytes.Equal(payloads[5], bytes.Repeat([]byte{'A'}, 21)) {
+		t.Fatalf("unexpected boundary payload '%x'", payloads[5])
+	}
+
+	changed := 0
+	for _, payload := range payloads {
+		if len(payload) > 21 {
+			t.Fatalf("payload '%x' is longer than 21 bytes", payload)
+		} else if !bytes.Equal(payload, value) {
+			changed++
+		}
+	}
+
+	if changed < len(payloads)-2 {
+		t.Fatalf("expected the payloads to be mutated, %d of %d are", changed, len(payloads))
+	} else if !bytes.Equal(value, []byte{0x01, 0x02, 0x03, 0x04}) {
+		t.Fatalf("the original value has been changed to '%x'", value)
+	}
+
+	// without a value to start from
+	for _, payload := range bleFuzzPayloads(nil, 16, 8, rand.New(rand.NewSource(1)))[7:] {
+		if len(payload) == 0 || len(payload) > 8 {
+			t.Fatalf("unexpected random payload '%x'", payload)
+		}
+	}
+}
@@ -23,14 +23,19 @@ const (
 
 type BLERecon struct {
 	session.SessionModule
-	gattDevice  gatt.Device
-	currDevice  *network.BLEDevice
-	writeUUID   *gatt.UUID
-	writeData   []byte
-	connected   bool
-	connTimeout time.Duration
-	quit        chan bool
-	done        chan bool
+	gattDevice    gatt.Device
+	currDevice    *network.BLEDevice
+	writeUUID     *gatt.UUID
+	writeData     []byte
+	subscribeUUID *gatt.UUID
+	unsubscribe   chan bool
+	fuzzing       bool
+	fuzzRounds    int
+	fuzzSize      int
+	connected     bool
+	connTimeout   time.Duration
+	quit          chan bool
+	done          chan bool
 }
 
 func NewBLERecon(s *session.Session) *BLERecon {
@@ -42,6 +47,7 @@ func NewBLERecon(s *session.Session) *BLERecon {
 		connTimeout:   time.Duration(10) * time.Second,
 		currDevice:    nil,
 		connected:     false,
+		unsubscribe:   make(chan bool, 1),
 	}
 
 	d.AddHandler(session.NewModuleHandler("ble.recon on", "",
@@ -69,9 +75,7 @@ func NewBLERecon(s *session.Session) *BLERecon {
 				return fmt.Errorf("An enumeration for %s is already running, please wait.", d.currDevice.Device.ID())
 			}
 
-			d.writeData = nil
-			d.writeUUID = nil
-
+			d.resetAction()
 			return d.enumAllTheThings(network.NormalizeMac(args[0]))
 		}))
 
@@ -91,6 +95,43 @@ func NewBLERecon(s *session.Session) *BLERecon {
 			return d.writeBuffer(mac, uuid, data)
 		}))
 
+	d.AddHandler(session.NewModuleHandler("ble.subscribe MAC UUID", "ble.subscribe "+macRegexp+" ([a-fA-F0-9]+)",
+		"Subscribe to the notifications or indications of the characteristics with the given UUID of the BLE device, every value is reported as a ble.device.notification event.",
+		func(args []string) error {
+			if d.isEnumerating() {
+				return fmt.Errorf("An operation on %s is already running, please wait or use ble.unsubscribe.", d.currDevice.Device.ID())
+			}
+
+			uuid, err := gatt.ParseUUID(args[1])
+			if err != nil {
+				return fmt.Errorf("Error parsing %s: %s", args[1], err)
+			}
+			return d.subscribeTo(network.NormalizeMac(args[0]), uuid)
+		}))
+
+	d.AddHandler(session.NewModuleHandler("ble.unsubscribe", "",
+		"Stop receiving the notifications of the subscribed characteristics and disconnect from the device.",
+		func(args []string) error {
+			return d.unsubscribeAll()
+		}))
+
+	d.AddHandler(session.NewDangerousModuleHandler("ble.fuzz MAC", "ble.fuzz "+macRegexp,
+		"Write mutated payloads to every writable characteristics of the BLE device, reporting write errors and changed values as ble.fuzz.response events and disconnections as ble.fuzz.disconnected ones.",
+		func(args []string) error {
+			if d.isEnumerating() {
+				return fmt.Errorf("An operation on %s is already running, please wait.", d.currDevice.Device.ID())
+			}
+			return d.fuzzDevice(network.NormalizeMac(args[0]))
+		}))
+
+	d.AddParam(session.NewIntParameter("ble.fuzz.rounds",
+		"32",
+		"Number of random mutations ble.fuzz writes to every characteristics, after the boundary values."))
+
+	d.AddParam(session.NewIntParameter("ble.fuzz.size",
+		"20",
+		"Expected maximum size in bytes of the values of the characteristics, payloads are at most one byte longer."))
+
 	return d
 }
 
@@ -167,6 +208,7 @@ func (d *BLERecon) Start() error {
 }
 
 func (d *BLERecon) writeBuffer(mac string, uuid gatt.UUID, data []byte) error {
+	d.resetAction()
 	d.writeUUID = &uuid
 	d.writeData = data
 	return d.enumAllTheThings(mac)
@@ -176,6 +218,8 @@ func (d *BLERecon) enumAllTheThings(mac string) error {
 	dev, found := d.Session.BLE.Get(mac)
 	if !found || dev == nil {
 		return fmt.Errorf("BLE device with address %s not found.", mac)
+	} else if dev.Restored() {
+		return fmt.Errorf("BLE device %s has been restored from a saved session, wait for ble.recon to discover it again.", mac)
 	} else if d.Running() {
 		d.gattDevice.StopScanning()
 	}
@@ -232,6 +276,10 @@ func (d *BLERecon) onPeriphDiscovered(p gatt.Peripheral, a *gatt.Advertisement,
 }
 
 func (d *BLERecon) onPeriphDisconnected(p gatt.Peripheral, err error) {
+	if d.isSubscribed() {
+		d.unsubscribeAll()
+	}
+
 	if d.Running() {
 		// restore scanning
 		log.Info("Device disconnected, restoring BLE discovery.")
@@ -270,5 +318,11 @@ func (d *BLERecon) onPeriphConnected(p gatt.Peripheral, err error) {
 		return
 	}
 
-	d.showServices(p, services)
+	if d.subscribeUUID != nil {
+		d.subscribe(p, services)
+	} else if d.fuzzing {
+		d.fuzz(p, services)
+	} else {
+		d.showServices(p, services)
+	}
 }
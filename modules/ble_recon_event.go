@@ -0,0 +1,19 @@
+package modules
+
+// BLENotificationEvent is a value notified or indicated by a subscribed
+// characteristic.
+type BLENotificationEvent struct {
+	Device         string
+	Characteristic string
+	Data           []byte
+}
+
+// BLEFuzzEvent is an unexpected reaction of a device to a fuzzed write.
+type BLEFuzzEvent struct {
+	Device         string
+	Characteristic string
+	Payload        []byte
+	// value read back after the write, if readable
+	Response []byte
+	Error    string
+}
@@ -0,0 +1,192 @@
+// +build !windows
+// +build !darwin
+
+package modules
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/network"
+
+	"github.com/bettercap/gatt"
+)
+
+func (d *BLERecon) resetAction() {
+	d.writeData = nil
+	d.writeUUID = nil
+	d.subscribeUUID = nil
+	d.fuzzing = false
+}
+
+func (d *BLERecon) isSubscribed() bool {
+	return d.subscribeUUID != nil && d.connected
+}
+
+func (d *BLERecon) subscribeTo(mac string, uuid gatt.UUID) error {
+	d.resetAction()
+	d.subscribeUUID = &uuid
+	// drop a stale unsubscribe request
+	select {
+	case <-d.unsubscribe:
+	default:
+	}
+	return d.enumAllTheThings(mac)
+}
+
+func (d *BLERecon) fuzzDevice(mac string) (err error) {
+	d.resetAction()
+	if err, d.fuzzRounds = d.IntParam("ble.fuzz.rounds"); err != nil {
+		return err
+	} else if err, d.fuzzSize = d.IntParam("ble.fuzz.size"); err != nil {
+		return err
+	}
+	d.fuzzing = true
+	return d.enumAllTheThings(mac)
+}
+
+// findCharacteristic discovers the characteristics of every service and
+// returns the one with the given UUID.
+func (d *BLERecon) findCharacteristic(p gatt.Peripheral, services []*gatt.Service, uuid gatt.UUID) (*gatt.Characteristic, error) {
+	for _, svc := range services {
+		chars, err := p.DiscoverCharacteristics(nil, svc)
+		if err != nil {
+			log.Error("Error while enumerating chars for service %s: %s", svc.UUID(), err)
+			continue
+		}
+
+		for _, ch := range chars {
+			if uuid.Equal(ch.UUID()) {
+				return ch, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("Characteristics %s not found.", uuid)
+}
+
+// subscribe streams the notifications of the characteristic as session
+// events until ble.unsubscribe or the device disconnects.
+func (d *BLERecon) subscribe(p gatt.Peripheral, services []*gatt.Service) {
+	ch, err := d.findCharacteristic(p, services, *d.subscribeUUID)
+	if err != nil {
+		log.Error("%s", err)
+		return
+	}
+
+	// the client configuration descriptor is needed to subscribe
+	if _, err := p.DiscoverDescriptors(nil, ch); err != nil {
+		log.Error("Error while enumerating descriptors for %s: %s", ch.UUID(), err)
+		return
+	}
+
+	mac := network.NormalizeMac(p.ID())
+	onValue := func(c *gatt.Characteristic, data []byte, err error) {
+		if err != nil {
+			log.Warning("Error while receiving a notification from %s: %s", c.UUID(), err)
+			return
+		}
+		d.Session.Events.Add("ble.device.notification", BLENotificationEvent{
+			Device:         mac,
+			Characteristic: c.UUID().String(),
+			Data:           data,
+		})
+	}
+
+	subscribe := p.SetNotifyValue
+	if mask := ch.Properties(); mask&gatt.CharNotify == 0 {
+		if mask&gatt.CharIndicate == 0 {
+			log.Error("Characteristics %s doesn't support notifications nor indications.", ch.UUID())
+			return
+		}
+		subscribe = p.SetIndicateValue
+	}
+
+	if err := subscribe(ch, onValue); err != nil {
+		log.Error("Error while subscribing to %s: %s", ch.UUID(), err)
+		return
+	}
+
+	log.Info("Subscribed to %s of %s, use ble.unsubscribe to stop.", ch.UUID(), mac)
+	<-d.unsubscribe
+
+	if d.connected {
+		if err := subscribe(ch, nil); err != nil {
+			log.Warning("Error while unsubscribing from %s: %s", ch.UUID(), err)
+		}
+	}
+}
+
+func (d *BLERecon) unsubscribeAll() error {
+	if !d.isSubscribed() {
+		return fmt.Errorf("Not subscribed to any characteristics.")
+	}
+
+	select {
+	case d.unsubscribe <- true:
+	default:
+	}
+	return nil
+}
+
+// fuzz writes mutated payloads to every writable characteristic and
+// reports the write errors, the changes of the values read back and the
+// disconnections.
+func (d *BLERecon) fuzz(p gatt.Peripheral, services []*gatt.Service) {
+	mac := network.NormalizeMac(p.ID())
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	for _, svc := range services {
+		chars, err := p.DiscoverCharacteristics(nil, svc)
+		if err != nil {
+			log.Error("Error while enumerating chars for service %s: %s", svc.UUID(), err)
+			continue
+		}
+
+		for _, ch := range chars {
+			_, isReadable, isWritable, withResponse := parseProperties(ch)
+			if !isWritable {
+				continue
+			}
+
+			var value []byte
+			if isReadable {
+				value, _ = p.ReadCharacteristic(ch)
+			}
+
+			payloads := bleFuzzPayloads(value, d.fuzzRounds, d.fuzzSize, rnd)
+			log.Info("Fuzzing characteristics %s of %s with %d payloads ...", ch.UUID(), mac, len(payloads))
+
+			for _, payload := range payloads {
+				event := BLEFuzzEvent{
+					Device:         mac,
+					Characteristic: ch.UUID().String(),
+					Payload:        payload,
+				}
+
+				if err := p.WriteCharacteristic(ch, payload, !withResponse); err != nil {
+					event.Error = err.Error()
+				} else if isReadable {
+					if raw, err := p.ReadCharacteristic(ch); err != nil {
+						event.Error = err.Error()
+					} else if !bytes.Equal(raw, value) {
+						log.Debug("%s of %s changed from %x to %x", ch.UUID(), mac, value, raw)
+						event.Response, value = raw, raw
+					}
+				}
+
+				if !d.connected {
+					event.Error = "device disconnected"
+					d.Session.Events.Add("ble.fuzz.disconnected", event)
+					return
+				} else if event.Error != "" || event.Response != nil {
+					d.Session.Events.Add("ble.fuzz.response", event)
+				}
+			}
+		}
+	}
+
+	log.Info("Fuzzing of %s completed.", mac)
+}
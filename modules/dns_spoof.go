@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"fmt"
 	"net"
+	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/firewall"
 	"github.com/bettercap/bettercap/log"
 	"github.com/bettercap/bettercap/packets"
 	"github.com/bettercap/bettercap/session"
@@ -19,13 +22,25 @@ import (
 )
 
 type DNSSpoofer struct {
+	// first for the alignment 64 bit atomic operations need on 32 bit platforms
+	injected uint64
+
 	session.SessionModule
 	Handle        *pcap.Handle
 	Domains       []glob.Glob
 	Address       net.IP
+	Pointers      map[string]string
+	Upstreams     *dnsUpstreams
 	All           bool
 	waitGroup     *sync.WaitGroup
 	pktSourceChan chan gopacket.Packet
+	forwards      chan struct{}
+
+	dot            bool
+	dotPort        int
+	dotListener    net.Listener
+	dotRedirection *firewall.Redirection
+	doh            bool
 }
 
 func NewDNSSpoofer(s *session.Session) *DNSSpoofer {
@@ -34,7 +49,9 @@ func NewDNSSpoofer(s *session.Session) *DNSSpoofer {
 		Handle:        nil,
 		All:           false,
 		Domains:       make([]glob.Glob, 0),
+		Pointers:      make(map[string]string),
 		waitGroup:     &sync.WaitGroup{},
+		forwards:      make(chan struct{}, dnsUpstreamMaxForwards),
 	}
 
 	spoof.AddParam(session.NewStringParameter("dns.spoof.domains",
@@ -47,10 +64,32 @@ func NewDNSSpoofer(s *session.Session) *DNSSpoofer {
 		session.IPv4Validator,
 		"IP address to map the domains to."))
 
+	spoof.AddParam(session.NewStringParameter("dns.spoof.ptr",
+		"",
+		``,
+		"Comma separated list of ip=name entries used to reply to reverse (PTR) lookups."))
+
+	spoof.AddParam(session.NewStringParameter("dns.spoof.nameservers",
+		"",
+		``,
+		"Comma separated list of name servers (ip or ip:port) used in round robin to resolve the queries which are not spoofed, dead ones are skipped for a while."))
+
 	spoof.AddParam(session.NewBoolParameter("dns.spoof.all",
 		"false",
 		"If true the module will reply to every DNS request, otherwise it will only reply to the one targeting the local pc."))
 
+	spoof.AddParam(session.NewBoolParameter("dns.spoof.dot",
+		"false",
+		"If true, the DNS-over-TLS connections to port 853 are redirected to the spoofer and terminated with a certificate signed by the https.proxy certification authority, the queries which are not spoofed are resolved with dns.spoof.nameservers or fail."))
+
+	spoof.AddParam(session.NewIntParameter("dns.spoof.dot.port",
+		"8853",
+		"Port to bind the DNS-over-TLS spoofer to."))
+
+	spoof.AddParam(session.NewBoolParameter("dns.spoof.doh",
+		"false",
+		"If true, the DNS-over-HTTPS queries intercepted by http.proxy or https.proxy are spoofed as well, the other ones go through to the real server."))
+
 	spoof.AddHandler(session.NewModuleHandler("dns.spoof on", "",
 		"Start the DNS spoofer in the background.",
 		func(args []string) error {
@@ -82,6 +121,9 @@ func (s *DNSSpoofer) Configure() error {
 	var err error
 	var addr string
 	var domains []string
+	var pointers []string
+	var nameservers []string
+	var doh bool
 
 	if s.Running() {
 		return session.ErrAlreadyStarted
@@ -98,8 +140,19 @@ func (s *DNSSpoofer) Configure() error {
 
 	if err, s.All = s.BoolParam("dns.spoof.all"); err != nil {
 		return err
+	} else if err, s.dot = s.BoolParam("dns.spoof.dot"); err != nil {
+		return err
+	} else if err, s.dotPort = s.IntParam("dns.spoof.dot.port"); err != nil {
+		return err
+	} else if err, doh = s.BoolParam("dns.spoof.doh"); err != nil {
+		return err
 	}
 
+	// read by the http and https proxies
+	s.StatusLock.Lock()
+	s.doh = doh
+	s.StatusLock.Unlock()
+
 	if err, domains = s.ListParam("dns.spoof.domains"); err != nil {
 		return err
 	}
@@ -118,6 +171,21 @@ func (s *DNSSpoofer) Configure() error {
 
 	s.Address = net.ParseIP(addr)
 
+	if err, pointers = s.ListParam("dns.spoof.ptr"); err != nil {
+		return err
+	} else if s.Pointers, err = parsePointers(pointers); err != nil {
+		return err
+	}
+
+	s.Upstreams = nil
+	if err, nameservers = s.ListParam("dns.spoof.nameservers"); err != nil {
+		return err
+	} else if len(nameservers) > 0 {
+		if s.Upstreams, err = newDNSUpstreams(nameservers); err != nil {
+			return err
+		}
+	}
+
 	if !s.Session.Firewall.IsForwardingEnabled() {
 		log.Info("Enabling forwarding.")
 		s.Session.Firewall.EnableForwarding(true)
@@ -126,16 +194,175 @@ func (s *DNSSpoofer) Configure() error {
 	return nil
 }
 
-func (s *DNSSpoofer) dnsReply(pkt gopacket.Packet, peth *layers.Ethernet, pudp *layers.UDP, domain string, req *layers.DNS, target net.HardwareAddr) {
-	redir := fmt.Sprintf("(->%s)", s.Address)
-	who := target.String()
+// reverseName returns the in-addr.arpa or ip6.arpa name used to
+// perform a reverse (PTR) lookup of the given address.
+func reverseName(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa", ip4[3], ip4[2], ip4[1], ip4[0])
+	}
+
+	nibbles := make([]string, 0, 32)
+	ip16 := ip.To16()
+	for i := len(ip16) - 1; i >= 0; i-- {
+		nibbles = append(nibbles, fmt.Sprintf("%x", ip16[i]&0x0f), fmt.Sprintf("%x", ip16[i]>>4))
+	}
+	return strings.Join(nibbles, ".") + ".ip6.arpa"
+}
+
+// parsePointers converts a list of ip=name entries to a map
+// indexed by the reverse lookup name of each address.
+func parsePointers(entries []string) (map[string]string, error) {
+	pointers := make(map[string]string)
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("'%s' is not a valid ip=name PTR entry", entry)
+		}
+
+		ip := net.ParseIP(core.Trim(parts[0]))
+		name := core.Trim(parts[1])
+		if ip == nil {
+			return nil, fmt.Errorf("'%s' is not a valid IP address", parts[0])
+		} else if name == "" {
+			return nil, fmt.Errorf("empty host name for PTR entry '%s'", entry)
+		}
+
+		pointers[reverseName(ip)] = name
+	}
+	return pointers, nil
+}
 
+func (s *DNSSpoofer) ptrFor(q layers.DNSQuestion) (string, bool) {
+	name, found := s.Pointers[strings.ToLower(strings.TrimSuffix(string(q.Name), "."))]
+	return name, found
+}
+
+func (s *DNSSpoofer) addressAnswers(req *layers.DNS) []layers.DNSResourceRecord {
+	answers := make([]layers.DNSResourceRecord, 0)
+	for _, q := range req.Questions {
+		answers = append(answers,
+			layers.DNSResourceRecord{
+				Name:  []byte(q.Name),
+				Type:  q.Type,
+				Class: q.Class,
+				TTL:   1024,
+				IP:    s.Address,
+			})
+	}
+	return answers
+}
+
+func (s *DNSSpoofer) ptrAnswers(req *layers.DNS) []layers.DNSResourceRecord {
+	answers := make([]layers.DNSResourceRecord, 0)
+	for _, q := range req.Questions {
+		if q.Type != layers.DNSTypePTR {
+			continue
+		} else if name, found := s.ptrFor(q); found {
+			answers = append(answers,
+				layers.DNSResourceRecord{
+					Name:  []byte(q.Name),
+					Type:  layers.DNSTypePTR,
+					Class: q.Class,
+					TTL:   1024,
+					PTR:   []byte(name),
+				})
+		}
+	}
+	return answers
+}
+
+// replyTo builds the reply to the query with the spoofed answers.
+func replyTo(req *layers.DNS, answers []layers.DNSResourceRecord) *layers.DNS {
+	return &layers.DNS{
+		ID:        req.ID,
+		QR:        true,
+		OpCode:    layers.DNSOpCodeQuery,
+		QDCount:   req.QDCount,
+		Questions: req.Questions,
+		Answers:   answers,
+	}
+}
+
+// spoofedReply returns the spoofed reply to the query, or nil if it has
+// to be resolved by the real name server, with the domain and where it
+// is redirected to for the logs.
+func (s *DNSSpoofer) spoofedReply(req *layers.DNS) (reply *layers.DNS, domain string, redirect string) {
+	for _, q := range req.Questions {
+		qName := string(q.Name)
+		if q.Type == layers.DNSTypePTR {
+			// reverse lookups are only spoofed if explicitly mapped,
+			// anything else goes through to the real name server
+			if name, found := s.ptrFor(q); found {
+				return replyTo(req, s.ptrAnswers(req)), qName, name
+			}
+			log.Debug("Skipping PTR lookup %s", qName)
+		} else if s.shouldSpoof(qName) {
+			return replyTo(req, s.addressAnswers(req)), qName, s.Address.String()
+		} else {
+			log.Debug("Skipping domain %s", qName)
+		}
+	}
+	return nil, "", ""
+}
+
+// logSpoofed logs the spoofed reply with the transport the query
+// arrived on, udp, dot or doh.
+func (s *DNSSpoofer) logSpoofed(transport string, domain string, redirect string, who string) {
+	redir := fmt.Sprintf("(->%s)", redirect)
+	log.Info("[%s] Sending spoofed DNS reply for %s %s to %s over %s.", core.Green("dns"), core.Red(domain), core.Dim(redir), core.Bold(who), transport)
+}
+
+func (s *DNSSpoofer) dnsReply(pkt gopacket.Packet, peth *layers.Ethernet, pudp *layers.UDP, domain string, redirect string, reply *layers.DNS, target net.HardwareAddr) {
+	who := target.String()
 	if t, found := s.Session.Lan.Get(target.String()); found {
 		who = t.String()
 	}
 
-	log.Info("[%s] Sending spoofed DNS reply for %s %s to %s.", core.Green("dns"), core.Red(domain), core.Dim(redir), core.Bold(who))
+	s.logSpoofed("udp", domain, redirect, who)
+	s.sendDNS(pkt, peth, pudp, reply, target)
+}
 
+// forward resolves the query with the configured name servers and sends
+// their response to the target.
+func (s *DNSSpoofer) forward(pkt gopacket.Packet, peth *layers.Ethernet, pudp *layers.UDP, req *layers.DNS, target net.HardwareAddr) {
+	qName := string(req.Questions[0].Name)
+	server, raw, err := s.Upstreams.Exchange(req.LayerContents())
+	if err != nil {
+		log.Warning("[%s] Can't resolve %s: %s.", core.Green("dns"), qName, err)
+		return
+	}
+
+	resp := &layers.DNS{}
+	if err := resp.DecodeFromBytes(raw, gopacket.NilDecodeFeedback); err != nil {
+		log.Debug("Error decoding response from %s: %s", server, err)
+		return
+	} else if resp.ID != req.ID {
+		log.Debug("Unexpected response id %d from %s.", resp.ID, server)
+		return
+	}
+
+	log.Debug("Forwarding response for %s from %s.", qName, server)
+	s.sendDNS(pkt, peth, pudp, resp, target)
+}
+
+// forwardAsync forwards the query in the background, unless there are
+// already dnsUpstreamMaxForwards being resolved, it's dropped then.
+func (s *DNSSpoofer) forwardAsync(pkt gopacket.Packet, peth *layers.Ethernet, pudp *layers.UDP, req *layers.DNS, target net.HardwareAddr) bool {
+	select {
+	case s.forwards <- struct{}{}:
+	default:
+		log.Debug("Dropping query for %s, %d queries are already being forwarded.", string(req.Questions[0].Name), dnsUpstreamMaxForwards)
+		return false
+	}
+
+	go func() {
+		defer func() { <-s.forwards }()
+		s.forward(pkt, peth, pudp, req, target)
+	}()
+	return true
+}
+
+func (s *DNSSpoofer) sendDNS(pkt gopacket.Packet, peth *layers.Ethernet, pudp *layers.UDP, dns *layers.DNS, target net.HardwareAddr) {
 	var err error
 	var src, dst net.IP
 
@@ -169,27 +396,6 @@ func (s *DNSSpoofer) dnsReply(pkt gopacket.Packet, peth *layers.Ethernet, pudp *
 		EthernetType: eType,
 	}
 
-	answers := make([]layers.DNSResourceRecord, 0)
-	for _, q := range req.Questions {
-		answers = append(answers,
-			layers.DNSResourceRecord{
-				Name:  []byte(q.Name),
-				Type:  q.Type,
-				Class: q.Class,
-				TTL:   1024,
-				IP:    s.Address,
-			})
-	}
-
-	dns := layers.DNS{
-		ID:        req.ID,
-		QR:        true,
-		OpCode:    layers.DNSOpCodeQuery,
-		QDCount:   req.QDCount,
-		Questions: req.Questions,
-		Answers:   answers,
-	}
-
 	var raw []byte
 
 	if ipv6 {
@@ -208,7 +414,7 @@ func (s *DNSSpoofer) dnsReply(pkt gopacket.Packet, peth *layers.Ethernet, pudp *
 
 		udp.SetNetworkLayerForChecksum(&ip6)
 
-		err, raw = packets.Serialize(&eth, &ip6, &udp, &dns)
+		err, raw = packets.Serialize(&eth, &ip6, &udp, dns)
 		if err != nil {
 			log.Error("Error serializing packet: %s.", err)
 			return
@@ -229,7 +435,7 @@ func (s *DNSSpoofer) dnsReply(pkt gopacket.Packet, peth *layers.Ethernet, pudp *
 
 		udp.SetNetworkLayerForChecksum(&ip4)
 
-		err, raw = packets.Serialize(&eth, &ip4, &udp, &dns)
+		err, raw = packets.Serialize(&eth, &ip4, &udp, dns)
 		if err != nil {
 			log.Error("Error serializing packet: %s.", err)
 			return
@@ -239,6 +445,15 @@ func (s *DNSSpoofer) dnsReply(pkt gopacket.Packet, peth *layers.Ethernet, pudp *
 	log.Debug("Sending %d bytes of packet ...", len(raw))
 	if err := s.Session.Queue.Send(raw); err != nil {
 		log.Error("Error sending packet: %s", err)
+	} else {
+		atomic.AddUint64(&s.injected, 1)
+	}
+}
+
+// Metrics publishes the number of replies sent.
+func (s *DNSSpoofer) Metrics() []session.Metric {
+	return []session.Metric{
+		session.NewCounter("bettercap_module_packets_injected_total", "Number of packets the module injected.", atomic.LoadUint64(&s.injected), map[string]string{"kind": "reply"}),
 	}
 }
 
@@ -263,14 +478,10 @@ func (s *DNSSpoofer) onPacket(pkt gopacket.Packet) {
 		dns, parsed := pkt.Layer(layers.LayerTypeDNS).(*layers.DNS)
 		if parsed && dns.OpCode == layers.DNSOpCodeQuery && len(dns.Questions) > 0 && len(dns.Answers) == 0 {
 			udp := typeUDP.(*layers.UDP)
-			for _, q := range dns.Questions {
-				qName := string(q.Name)
-				if s.shouldSpoof(qName) {
-					s.dnsReply(pkt, eth, udp, qName, dns, eth.SrcMAC)
-					break
-				} else {
-					log.Debug("Skipping domain %s", qName)
-				}
+			if reply, domain, redirect := s.spoofedReply(dns); reply != nil {
+				s.dnsReply(pkt, eth, udp, domain, redirect, reply, eth.SrcMAC)
+			} else if s.Upstreams != nil {
+				s.forwardAsync(pkt, eth, udp, dns, eth.SrcMAC)
 			}
 		}
 	}
@@ -279,9 +490,14 @@ func (s *DNSSpoofer) onPacket(pkt gopacket.Packet) {
 func (s *DNSSpoofer) Start() error {
 	if err := s.Configure(); err != nil {
 		return err
+	} else if s.dot {
+		if err := s.startDoT(); err != nil {
+			s.Handle.Close()
+			return err
+		}
 	}
 
-	return s.SetRunning(true, func() {
+	err := s.SetRunning(true, func() {
 		s.waitGroup.Add(1)
 		defer s.waitGroup.Done()
 
@@ -295,12 +511,36 @@ func (s *DNSSpoofer) Start() error {
 			s.onPacket(packet)
 		}
 	})
+	if err != nil {
+		// don't leave the listener nor the redirection behind
+		s.stopDoT()
+		s.Handle.Close()
+	}
+	return err
+}
+
+// spoofingDoH returns true if the DNS-over-HTTPS queries the proxies
+// receive are to be answered.
+func (s *DNSSpoofer) spoofingDoH() bool {
+	s.StatusLock.RLock()
+	defer s.StatusLock.RUnlock()
+	return s.Started && s.doh
 }
 
 func (s *DNSSpoofer) Stop() error {
 	return s.SetRunning(false, func() {
 		s.pktSourceChan <- nil
 		s.Handle.Close()
+		s.stopDoT()
 		s.waitGroup.Wait()
 	})
 }
+
+// Revert stops the spoofer if it's running, so that the DoT redirection is removed
+// before the firewall is restored.
+func (s *DNSSpoofer) Revert() (bool, error) {
+	if !s.Running() {
+		return false, nil
+	}
+	return true, s.Stop()
+}
@@ -0,0 +1,69 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/bettercap/bettercap/log"
+
+	"github.com/elazarl/goproxy"
+)
+
+const (
+	dnsDoHContentType = "application/dns-message"
+	// the path of the RFC 8484 examples, used by the public resolvers
+	dnsDoHPath = "/dns-query"
+)
+
+// dohQuery returns the raw query of a DNS-over-HTTPS request as defined
+// by RFC 8484, or nil if it's not one. The GET requests need either the
+// usual path or the DNS message accept header, as a dns parameter alone
+// is found in the query string of plain web pages as well.
+func dohQuery(req *http.Request) ([]byte, error) {
+	if req.Method == "GET" {
+		param := req.URL.Query().Get("dns")
+		if param != "" && (req.URL.Path == dnsDoHPath || strings.Contains(req.Header.Get("Accept"), dnsDoHContentType)) {
+			return base64.RawURLEncoding.DecodeString(strings.TrimRight(param, "="))
+		}
+	} else if req.Method == "POST" && strings.HasPrefix(req.Header.Get("Content-Type"), dnsDoHContentType) {
+		raw, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		// goes through to the real server if not spoofed
+		req.Body = ioutil.NopCloser(bytes.NewReader(raw))
+		return raw, err
+	}
+	return nil, nil
+}
+
+// dohResponse answers the DNS-over-HTTPS queries spoofed by dns.spoof if
+// it's running with dns.spoof.doh, the other ones go to the real server.
+func (p *HTTPProxy) dohResponse(req *http.Request) *http.Response {
+	err, mod := p.sess.Module("dns.spoof")
+	if err != nil {
+		return nil
+	}
+	spoof, ok := mod.(*DNSSpoofer)
+	if !ok || !spoof.spoofingDoH() {
+		return nil
+	}
+
+	raw, err := dohQuery(req)
+	if err != nil {
+		log.Debug("Could not read DNS-over-HTTPS query from %s: %s", req.RemoteAddr, err)
+		return nil
+	} else if raw == nil {
+		return nil
+	}
+
+	_, reply, err := spoof.answerRaw(raw, "doh", req.RemoteAddr)
+	if err != nil {
+		log.Debug("Could not answer DNS-over-HTTPS query from %s: %s", req.RemoteAddr, err)
+		return nil
+	} else if reply == nil {
+		return nil
+	}
+	return goproxy.NewResponse(req, dnsDoHContentType, http.StatusOK, string(reply))
+}
@@ -0,0 +1,53 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestDNSSpoofDoHQuery(t *testing.T) {
+	query := rawDNSQuery(t, "www.example.com")
+
+	get, _ := http.NewRequest("GET", "https://dns.example.net/dns-query?dns="+base64.RawURLEncoding.EncodeToString(query), nil)
+	post, _ := http.NewRequest("POST", "https://dns.example.net/dns-query", bytes.NewReader(query))
+	post.Header.Set("Content-Type", dnsDoHContentType)
+	form, _ := http.NewRequest("POST", "https://www.example.net/login", bytes.NewReader(query))
+	form.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	page, _ := http.NewRequest("GET", "https://www.example.net/index.html", nil)
+	param, _ := http.NewRequest("GET", "https://www.example.net/search?dns="+base64.RawURLEncoding.EncodeToString(query), nil)
+	accept, _ := http.NewRequest("GET", "https://dns.example.net/resolve?dns="+base64.RawURLEncoding.EncodeToString(query), nil)
+	accept.Header.Set("Accept", dnsDoHContentType)
+
+	var units = []struct {
+		req   *http.Request
+		query bool
+	}{
+		{get, true},
+		{post, true},
+		{form, false},
+		{page, false},
+		{param, false},
+		{accept, true},
+	}
+
+	for _, u := range units {
+		raw, err := dohQuery(u.req)
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", u.req.URL, err)
+		} else if u.query && !bytes.Equal(raw, query) {
+			t.Fatalf("expected the query for %s, got '%x'", u.req.URL, raw)
+		} else if !u.query && raw != nil {
+			t.Fatalf("expected no query for %s, got '%x'", u.req.URL, raw)
+		}
+	}
+
+	// the body goes through to the real server if not spoofed
+	if body, err := ioutil.ReadAll(post.Body); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !bytes.Equal(body, query) {
+		t.Fatalf("expected the body to be kept, got '%x'", body)
+	}
+}
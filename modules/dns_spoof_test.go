@@ -0,0 +1,228 @@
+package modules
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bettercap/bettercap/session"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func TestDNSSpoofReverseName(t *testing.T) {
+	var units = []struct {
+		ip   string
+		name string
+	}{
+		{"192.168.1.10", "10.1.168.192.in-addr.arpa"},
+		{"10.0.0.1", "1.0.0.10.in-addr.arpa"},
+		{"2001:db8::567:89ab", "b.a.9.8.7.6.5.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa"},
+	}
+
+	for _, u := range units {
+		got := reverseName(net.ParseIP(u.ip))
+		if got != u.name {
+			t.Fatalf("expected '%s', got '%s'", u.name, got)
+		}
+	}
+}
+
+func TestDNSSpoofParsePointers(t *testing.T) {
+	pointers, err := parsePointers([]string{"192.168.1.10=router.lan", " 10.0.0.1 = gw.lan "})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(pointers) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(pointers))
+	} else if got := pointers["10.1.168.192.in-addr.arpa"]; got != "router.lan" {
+		t.Fatalf("expected 'router.lan', got '%s'", got)
+	} else if got := pointers["1.0.0.10.in-addr.arpa"]; got != "gw.lan" {
+		t.Fatalf("expected 'gw.lan', got '%s'", got)
+	}
+
+	for _, bad := range []string{"192.168.1.10", "nope=host", "192.168.1.10="} {
+		if _, err := parsePointers([]string{bad}); err == nil {
+			t.Fatalf("expected error for '%s'", bad)
+		}
+	}
+}
+
+func TestDNSSpoofPTRAnswers(t *testing.T) {
+	pointers, err := parsePointers([]string{"192.168.1.10=router.lan"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	spoof := DNSSpoofer{Pointers: pointers}
+
+	query := &layers.DNS{
+		ID:     0xbeef,
+		OpCode: layers.DNSOpCodeQuery,
+		RD:     true,
+		Questions: []layers.DNSQuestion{
+			{Name: []byte("10.1.168.192.in-addr.arpa"), Type: layers.DNSTypePTR, Class: layers.DNSClassIN},
+			{Name: []byte("11.1.168.192.in-addr.arpa"), Type: layers.DNSTypePTR, Class: layers.DNSClassIN},
+		},
+	}
+
+	buf := gopacket.NewSerializeBuffer()
+	if err := query.SerializeTo(buf, gopacket.SerializeOptions{FixLengths: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pkt := gopacket.NewPacket(buf.Bytes(), layers.LayerTypeDNS, gopacket.Default)
+	req, ok := pkt.Layer(layers.LayerTypeDNS).(*layers.DNS)
+	if !ok {
+		t.Fatal("expected DNS layer")
+	}
+
+	if _, found := spoof.ptrFor(req.Questions[0]); !found {
+		t.Fatal("expected mapped question to be found")
+	} else if _, found := spoof.ptrFor(req.Questions[1]); found {
+		t.Fatal("expected unmapped question not to be found")
+	}
+
+	answers := spoof.ptrAnswers(req)
+	if len(answers) != 1 {
+		t.Fatalf("expected 1 answer, got %d", len(answers))
+	} else if answers[0].Type != layers.DNSTypePTR {
+		t.Fatalf("expected PTR answer, got %s", answers[0].Type)
+	} else if got := string(answers[0].PTR); got != "router.lan" {
+		t.Fatalf("expected 'router.lan', got '%s'", got)
+	}
+}
+
+func startEchoDNS(t *testing.T, hits *int32) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(hits, 1)
+			conn.WriteTo(buf[:n], from)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func deadDNSAddress(t *testing.T) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer conn.Close()
+	return conn.LocalAddr().String()
+}
+
+func TestDNSSpoofUpstreamsParse(t *testing.T) {
+	u, err := newDNSUpstreams([]string{"8.8.8.8", "127.0.0.1:5353", "[::1]:53"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exp := []string{"8.8.8.8:53", "127.0.0.1:5353", "[::1]:53"}
+	for i, srv := range u.servers {
+		if srv.Address != exp[i] {
+			t.Fatalf("expected '%s', got '%s'", exp[i], srv.Address)
+		}
+	}
+
+	for _, bad := range []string{"dns.google", "1.1.1.1:0", "1.1.1.1:dns"} {
+		if _, err := newDNSUpstreams([]string{bad}); err == nil {
+			t.Fatalf("expected error for '%s'", bad)
+		}
+	}
+}
+
+func TestDNSSpoofUpstreamsFailover(t *testing.T) {
+	var hits int32
+	dead := deadDNSAddress(t)
+	alive := startEchoDNS(t, &hits)
+
+	u, err := newDNSUpstreams([]string{dead, alive})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	u.timeout = 200 * time.Millisecond
+
+	for i := 0; i < dnsUpstreamMaxFailures+2; i++ {
+		server, resp, err := u.Exchange([]byte("query"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if server != alive {
+			t.Fatalf("expected '%s', got '%s'", alive, server)
+		} else if string(resp) != "query" {
+			t.Fatalf("expected 'query', got '%s'", resp)
+		}
+	}
+
+	if !time.Now().Before(u.servers[0].deadUntil) {
+		t.Fatal("expected the dead server to be skipped")
+	} else if atomic.LoadInt32(&hits) != dnsUpstreamMaxFailures+2 {
+		t.Fatalf("expected %d queries, got %d", dnsUpstreamMaxFailures+2, hits)
+	}
+}
+
+func TestDNSSpoofUpstreamsRoundRobin(t *testing.T) {
+	var hitsA, hitsB int32
+	a := startEchoDNS(t, &hitsA)
+	b := startEchoDNS(t, &hitsB)
+
+	u, err := newDNSUpstreams([]string{a, b})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		if _, _, err := u.Exchange([]byte("query")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if atomic.LoadInt32(&hitsA) != 2 || atomic.LoadInt32(&hitsB) != 2 {
+		t.Fatalf("expected 2 queries per server, got %d and %d", hitsA, hitsB)
+	}
+}
+
+func TestDNSSpoofForwardLimit(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	spoof := NewDNSSpoofer(s.Session)
+	if spoof.Upstreams, err = newDNSUpstreams([]string{deadDNSAddress(t)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	spoof.Upstreams.timeout = 100 * time.Millisecond
+
+	req := &layers.DNS{ID: 1, Questions: []layers.DNSQuestion{{Name: []byte("www.example.com")}}}
+	for i := 0; i < dnsUpstreamMaxForwards; i++ {
+		spoof.forwards <- struct{}{}
+	}
+	if spoof.forwardAsync(nil, nil, nil, req, nil) {
+		t.Fatal("expected the query to be dropped")
+	}
+
+	<-spoof.forwards
+	if !spoof.forwardAsync(nil, nil, nil, req, nil) {
+		t.Fatal("expected the query to be forwarded")
+	}
+
+	// released once the upstream fails
+	select {
+	case spoof.forwards <- struct{}{}:
+	case <-time.After(time.Second):
+		t.Fatal("expected the forward to be released")
+	}
+}
@@ -0,0 +1,137 @@
+package modules
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+)
+
+const (
+	dnsUpstreamTimeout     = 2 * time.Second
+	dnsUpstreamMaxFailures = 3
+	dnsUpstreamBackoff     = 30 * time.Second
+	// queries being forwarded at once, the ones exceeding it are dropped
+	dnsUpstreamMaxForwards = 64
+)
+
+type dnsUpstream struct {
+	Address   string
+	failures  int
+	deadUntil time.Time
+}
+
+// dnsUpstreams forwards queries to a list of resolvers in round robin,
+// skipping for a while the ones which keep failing.
+type dnsUpstreams struct {
+	sync.Mutex
+	servers []*dnsUpstream
+	next    int
+	timeout time.Duration
+}
+
+func newDNSUpstreams(addresses []string) (*dnsUpstreams, error) {
+	u := &dnsUpstreams{
+		servers: make([]*dnsUpstream, 0),
+		timeout: dnsUpstreamTimeout,
+	}
+
+	for _, address := range addresses {
+		address = core.Trim(address)
+		if host, port, err := net.SplitHostPort(address); err == nil {
+			if net.ParseIP(host) == nil {
+				return nil, fmt.Errorf("'%s' is not a valid name server address", address)
+			} else if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+				return nil, fmt.Errorf("'%s' is not a valid name server port", port)
+			}
+		} else if net.ParseIP(address) == nil {
+			return nil, fmt.Errorf("'%s' is not a valid name server address", address)
+		} else {
+			address = net.JoinHostPort(address, "53")
+		}
+
+		u.servers = append(u.servers, &dnsUpstream{Address: address})
+	}
+
+	return u, nil
+}
+
+// candidates returns the servers to try for the next query, starting
+// from the next one in the rotation; dead servers are only used if
+// every server is dead.
+func (u *dnsUpstreams) candidates() []*dnsUpstream {
+	u.Lock()
+	defer u.Unlock()
+
+	now := time.Now()
+	alive := make([]*dnsUpstream, 0, len(u.servers))
+	dead := make([]*dnsUpstream, 0)
+	for i := range u.servers {
+		srv := u.servers[(u.next+i)%len(u.servers)]
+		if now.Before(srv.deadUntil) {
+			dead = append(dead, srv)
+		} else {
+			alive = append(alive, srv)
+		}
+	}
+	u.next = (u.next + 1) % len(u.servers)
+
+	return append(alive, dead...)
+}
+
+func (u *dnsUpstreams) failed(srv *dnsUpstream) {
+	u.Lock()
+	defer u.Unlock()
+
+	srv.failures++
+	if srv.failures >= dnsUpstreamMaxFailures {
+		srv.failures = 0
+		srv.deadUntil = time.Now().Add(dnsUpstreamBackoff)
+	}
+}
+
+func (u *dnsUpstreams) succeeded(srv *dnsUpstream) {
+	u.Lock()
+	defer u.Unlock()
+
+	srv.failures = 0
+	srv.deadUntil = time.Time{}
+}
+
+func (u *dnsUpstreams) query(srv *dnsUpstream, req []byte) ([]byte, error) {
+	conn, err := net.DialTimeout("udp", srv.Address, u.timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(u.timeout))
+	if _, err = conn.Write(req); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 65535)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// Exchange sends the raw DNS query to the first responsive server and
+// returns its raw response.
+func (u *dnsUpstreams) Exchange(req []byte) (string, []byte, error) {
+	var err error
+	for _, srv := range u.candidates() {
+		var resp []byte
+		if resp, err = u.query(srv, req); err == nil {
+			u.succeeded(srv)
+			return srv.Address, resp, nil
+		}
+		u.failed(srv)
+	}
+	return "", nil, fmt.Errorf("no name server replied: %s", err)
+}
@@ -0,0 +1,169 @@
+package modules
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/session"
+)
+
+// how often the expired coalescing windows are checked
+const coalesceCheckPeriod = 100 * time.Millisecond
+
+// coalesceRule groups the events with a tag starting with Prefix and the
+// same values of Fields, a field of the event data each.
+type coalesceRule struct {
+	Prefix string
+	Window time.Duration
+	Fields []string
+}
+
+// parses a comma separated list of TAG=WINDOW[:FIELD+FIELD...] rules
+func parseCoalesceRules(rules string) ([]coalesceRule, error) {
+	parsed := make([]coalesceRule, 0)
+	for _, rule := range core.CommaSplit(rules) {
+		parts := strings.SplitN(rule, "=", 2)
+		if len(parts) != 2 || core.Trim(parts[0]) == "" {
+			return nil, fmt.Errorf("Invalid coalescing rule '%s', expected TAG=WINDOW[:FIELD+FIELD].", rule)
+		}
+
+		r := coalesceRule{
+			Prefix: core.Trim(parts[0]),
+			Fields: make([]string, 0),
+		}
+
+		spec := strings.SplitN(parts[1], ":", 2)
+		window, err := time.ParseDuration(core.Trim(spec[0]))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid window of coalescing rule '%s': %s", rule, err)
+		} else if window <= 0 {
+			return nil, fmt.Errorf("The window of coalescing rule '%s' must be greater than 0.", rule)
+		}
+		r.Window = window
+
+		if len(spec) == 2 {
+			for _, field := range strings.Split(spec[1], "+") {
+				if field = core.Trim(field); field != "" {
+					r.Fields = append(r.Fields, field)
+				}
+			}
+		}
+
+		parsed = append(parsed, r)
+	}
+	return parsed, nil
+}
+
+// eventField returns the value of a field of a struct, a pointer to it
+// or a map with string keys, empty if missing.
+func eventField(data interface{}, name string) string {
+	v := reflect.ValueOf(data)
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		v = v.Elem()
+	}
+
+	var field reflect.Value
+	switch {
+	case !v.IsValid():
+		return ""
+	case v.Kind() == reflect.Struct:
+		field = v.FieldByName(name)
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		field = v.MapIndex(reflect.ValueOf(name))
+	}
+
+	if !field.IsValid() || !field.CanInterface() {
+		return ""
+	}
+	return fmt.Sprintf("%v", field.Interface())
+}
+
+type coalesceGroup struct {
+	Tag     string
+	Key     string
+	Window  time.Duration
+	First   time.Time
+	expires time.Time
+	// events held back after the first one
+	Count int
+}
+
+// eventsCoalescer shows the first event of a group and holds back the
+// others until the window expires, the groups are then summarized.
+type eventsCoalescer struct {
+	rules  []coalesceRule
+	groups map[string]*coalesceGroup
+	// expired groups replaced by a new window before being summarized
+	replaced []*coalesceGroup
+}
+
+func newEventsCoalescer(rules []coalesceRule) *eventsCoalescer {
+	return &eventsCoalescer{
+		rules:    rules,
+		groups:   make(map[string]*coalesceGroup),
+		replaced: make([]*coalesceGroup, 0),
+	}
+}
+
+func (c *eventsCoalescer) Empty() bool {
+	return len(c.rules) == 0
+}
+
+// Add returns true if the event has to be shown right away.
+func (c *eventsCoalescer) Add(e session.Event) bool {
+	for _, rule := range c.rules {
+		if !IgnoreFilter(rule.Prefix).Matches(e.Tag) {
+			continue
+		}
+
+		values := make([]string, 0, len(rule.Fields))
+		for _, field := range rule.Fields {
+			values = append(values, fmt.Sprintf("%s=%s", field, eventField(e.Data, field)))
+		}
+		key := strings.Join(values, " ")
+
+		id := e.Tag + "\x00" + key
+		if g, found := c.groups[id]; found {
+			if e.Time.Before(g.expires) {
+				g.Count++
+				return false
+			} else if g.Count > 0 {
+				c.replaced = append(c.replaced, g)
+			}
+		}
+
+		c.groups[id] = &coalesceGroup{
+			Tag:     e.Tag,
+			Key:     key,
+			Window:  rule.Window,
+			First:   e.Time,
+			expires: e.Time.Add(rule.Window),
+		}
+		return true
+	}
+	return true
+}
+
+// Expired removes the groups with a window expired at now, returning
+// by time the ones with held back events.
+func (c *eventsCoalescer) Expired(now time.Time) []*coalesceGroup {
+	expired := c.replaced
+	c.replaced = make([]*coalesceGroup, 0)
+	for id, g := range c.groups {
+		if !now.Before(g.expires) {
+			delete(c.groups, id)
+			if g.Count > 0 {
+				expired = append(expired, g)
+			}
+		}
+	}
+
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].First.Before(expired[j].First)
+	})
+	return expired
+}
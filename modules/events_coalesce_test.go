@@ -0,0 +1,127 @@
+package modules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bettercap/bettercap/session"
+)
+
+func TestParseCoalesceRules(t *testing.T) {
+	var units = []struct {
+		rules string
+		count int
+		err   bool
+	}{
+		{"", 0, false},
+		{"net.sniff.=2s:Source+Destination, endpoint.new=500ms", 2, false},
+		{"net.sniff.", 0, true},
+		{"=2s", 0, true},
+		{"net.sniff.=nope", 0, true},
+		{"net.sniff.=0s", 0, true},
+	}
+
+	for _, u := range units {
+		rules, err := parseCoalesceRules(u.rules)
+		if u.err {
+			if err == nil {
+				t.Fatalf("expected error for '%s'", u.rules)
+			}
+		} else if err != nil {
+			t.Fatalf("unexpected error for '%s': %v", u.rules, err)
+		} else if len(rules) != u.count {
+			t.Fatalf("expected %d rules for '%s', got %d", u.count, u.rules, len(rules))
+		}
+	}
+
+	rules, _ := parseCoalesceRules("net.sniff.=2s:Source+ Destination")
+	if rules[0].Window != 2*time.Second {
+		t.Fatalf("expected '2s', got '%s'", rules[0].Window)
+	} else if len(rules[0].Fields) != 2 || rules[0].Fields[1] != "Destination" {
+		t.Fatalf("unexpected fields '%v'", rules[0].Fields)
+	}
+}
+
+func TestEventField(t *testing.T) {
+	e := SnifferEvent{Source: "192.168.1.2", Destination: "8.8.8.8"}
+	var units = []struct {
+		data     interface{}
+		field    string
+		expected string
+	}{
+		{e, "Source", "192.168.1.2"},
+		{&e, "Destination", "8.8.8.8"},
+		{e, "Nope", ""},
+		{map[string]interface{}{"port": 53}, "port", "53"},
+		{nil, "Source", ""},
+		{"string", "Source", ""},
+	}
+
+	for _, u := range units {
+		if got := eventField(u.data, u.field); got != u.expected {
+			t.Fatalf("expected '%s', got '%s'", u.expected, got)
+		}
+	}
+}
+
+func TestEventsCoalescer(t *testing.T) {
+	rules, err := parseCoalesceRules("net.sniff.=1s:Source")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c := newEventsCoalescer(rules)
+
+	at := time.Now()
+	event := func(tag string, src string, after time.Duration) session.Event {
+		e := session.NewEvent(tag, SnifferEvent{Source: src})
+		e.Time = at.Add(after)
+		return e
+	}
+
+	var units = []struct {
+		event session.Event
+		shown bool
+	}{
+		{event("net.sniff.dns", "a", 0), true},
+		{event("net.sniff.dns", "a", 100*time.Millisecond), false},
+		{event("net.sniff.dns", "b", 200*time.Millisecond), true},
+		{event("net.sniff.https", "a", 300*time.Millisecond), true},
+		{event("endpoint.new", "a", 400*time.Millisecond), true},
+		{event("endpoint.new", "a", 500*time.Millisecond), true},
+		{event("net.sniff.dns", "a", 600*time.Millisecond), false},
+	}
+
+	for i, u := range units {
+		if got := c.Add(u.event); got != u.shown {
+			t.Fatalf("expected '%v' for event %d, got '%v'", u.shown, i, got)
+		}
+	}
+
+	if expired := c.Expired(at.Add(500 * time.Millisecond)); len(expired) != 0 {
+		t.Fatalf("expected no expired groups, got %d", len(expired))
+	}
+
+	expired := c.Expired(at.Add(time.Second))
+	if len(expired) != 1 {
+		t.Fatalf("expected 1 expired group, got %d", len(expired))
+	} else if g := expired[0]; g.Tag != "net.sniff.dns" || g.Key != "Source=a" || g.Count != 2 {
+		t.Fatalf("unexpected group %+v", *g)
+	}
+
+	// a new window starts once expired, even if not summarized yet
+	if !c.Add(event("net.sniff.dns", "b", 1200*time.Millisecond)) {
+		t.Fatal("expected event to be shown")
+	} else if c.Add(event("net.sniff.dns", "b", 1300*time.Millisecond)) {
+		t.Fatal("expected event to be held back")
+	} else if !c.Add(event("net.sniff.dns", "b", 2300*time.Millisecond)) {
+		t.Fatal("expected event to be shown")
+	} else if expired := c.Expired(at.Add(2400 * time.Millisecond)); len(expired) != 1 || expired[0].Count != 1 {
+		t.Fatalf("expected the replaced group, got '%v'", expired)
+	}
+
+	if !c.Add(event("net.sniff.dns", "a", 2500*time.Millisecond)) {
+		t.Fatal("expected event to be shown")
+	} else if expired := c.Expired(at.Add(time.Minute)); len(expired) != 0 {
+		t.Fatalf("expected no held back events, got %d groups", len(expired))
+	}
+}
@@ -2,7 +2,6 @@ package modules
 
 import (
 	"fmt"
-	"os"
 	"strconv"
 	"time"
 
@@ -13,8 +12,9 @@ import (
 
 type EventsStream struct {
 	session.SessionModule
-	output        *os.File
+	output        *eventsOutputs
 	ignoreList    *IgnoreList
+	coalescer     *eventsCoalescer
 	waitFor       string
 	waitChan      chan *session.Event
 	eventListener <-chan session.Event
@@ -24,11 +24,12 @@ type EventsStream struct {
 func NewEventsStream(s *session.Session) *EventsStream {
 	stream := &EventsStream{
 		SessionModule: session.NewSessionModule("events.stream", s),
-		output:        os.Stdout,
+		output:        newConsoleOutputs(),
 		quit:          make(chan bool),
 		waitChan:      make(chan *session.Event),
 		waitFor:       "",
 		ignoreList:    NewIgnoreList(),
+		coalescer:     newEventsCoalescer(nil),
 	}
 
 	stream.AddHandler(session.NewModuleHandler("events.stream on", "",
@@ -110,7 +111,33 @@ func NewEventsStream(s *session.Session) *EventsStream {
 	stream.AddParam(session.NewStringParameter("events.stream.output",
 		"",
 		"",
-		"If not empty, events will be written to this file instead of the standard output."))
+		"If not empty, comma separated list of outputs to use instead of the standard output: - for the standard output, a file, RFC 5424 messages to syslog://HOST:PORT (UDP), syslog+tcp://HOST:PORT, syslog+tls://HOST:PORT or the local syslog daemon with syslog://, or batches of JSON events posted to a http:// or https:// webhook."))
+
+	stream.AddParam(session.NewStringParameter("events.stream.output.format",
+		eventsFormatText,
+		"^(text|json)$",
+		"Format of the events written to the standard output and files, text or json for a JSON document per line."))
+
+	stream.AddParam(session.NewBoolParameter("events.stream.output.insecure",
+		"false",
+		"If true the certificates of the syslog+tls and https webhook outputs will not be verified."))
+
+	stream.AddParam(session.NewIntParameter("events.stream.output.webhook.batch",
+		"100",
+		"Maximum number of events posted to the webhook in a single request."))
+
+	stream.AddParam(session.NewDurationParameter("events.stream.output.webhook.flush",
+		"5s",
+		"How often the pending events are posted to the webhook."))
+
+	stream.AddParam(session.NewIntParameter("events.stream.output.webhook.retries",
+		"3",
+		"How many times a request to the webhook is retried before its events are dropped."))
+
+	stream.AddParam(session.NewStringParameter("events.stream.coalesce",
+		"",
+		"",
+		"Comma separated list of TAG=WINDOW[:FIELD+FIELD] rules, the events with a tag starting with TAG and the same FIELD values of their data within WINDOW are shown once followed by a line with their count (e.g. net.sniff.leak.=2s:Source+Destination)."))
 
 	return stream
 }
@@ -129,16 +156,42 @@ func (s EventsStream) Author() string {
 
 func (s *EventsStream) Configure() (err error) {
 	var output string
+	var format string
+	var insecure bool
+	var coalesce string
+	var rules []coalesceRule
+	var opts webhookOptions
+
+	if s.Running() {
+		return session.ErrAlreadyStarted
+	} else if err, coalesce = s.StringParam("events.stream.coalesce"); err != nil {
+		return err
+	} else if rules, err = parseCoalesceRules(coalesce); err != nil {
+		return err
+	}
+	s.coalescer = newEventsCoalescer(rules)
 
-	if err, output = s.StringParam("events.stream.output"); err == nil {
-		if output == "" {
-			s.output = os.Stdout
-		} else if output, err = core.ExpandPath(output); err == nil {
-			s.output, err = os.OpenFile(output, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-		}
+	if err, output = s.StringParam("events.stream.output"); err != nil {
+		return err
+	} else if err, format = s.StringParam("events.stream.output.format"); err != nil {
+		return err
+	} else if err, insecure = s.BoolParam("events.stream.output.insecure"); err != nil {
+		return err
+	} else if err, opts.batch = s.IntParam("events.stream.output.webhook.batch"); err != nil {
+		return err
+	} else if err, opts.flush = s.DurationParam("events.stream.output.webhook.flush"); err != nil {
+		return err
+	} else if err, opts.retries = s.IntParam("events.stream.output.webhook.retries"); err != nil {
+		return err
 	}
 
-	return err
+	outputs, err := newEventsOutputs(output, format, opts, insecure)
+	if err != nil {
+		return err
+	}
+	s.output = outputs
+
+	return nil
 }
 
 func (s *EventsStream) Start() error {
@@ -150,6 +203,13 @@ func (s *EventsStream) Start() error {
 		s.eventListener = s.Session.Events.Listen()
 		defer s.Session.Events.Unlisten(s.eventListener)
 
+		var expired <-chan time.Time
+		if !s.coalescer.Empty() {
+			ticker := time.NewTicker(coalesceCheckPeriod)
+			defer ticker.Stop()
+			expired = ticker.C
+		}
+
 		for {
 			var e session.Event
 			select {
@@ -159,10 +219,22 @@ func (s *EventsStream) Start() error {
 					s.waitChan <- &e
 				}
 
-				if !s.ignoreList.Ignored(e) {
-					s.View(e, true)
-				} else {
+				if s.ignoreList.Ignored(e) {
 					log.Debug("Skipping ignored event %v", e)
+				} else if s.coalescer.Add(e) {
+					if s.output.HasText() {
+						s.View(e, true)
+					}
+					s.output.Push(e)
+				}
+
+			case now := <-expired:
+				groups := s.coalescer.Expired(now)
+				if s.output.HasText() {
+					s.viewCoalesced(groups)
+				}
+				for _, g := range groups {
+					s.output.Push(session.Event{Tag: coalescedEventTag, Time: now, Data: g})
 				}
 
 			case <-s.quit:
@@ -222,8 +294,7 @@ func (s *EventsStream) startWaitingFor(tag string, timeout int) error {
 func (s *EventsStream) Stop() error {
 	return s.SetRunning(false, func() {
 		s.quit <- true
-		if s.output != os.Stdout {
-			s.output.Close()
-		}
+		s.output.Close()
+		s.output = newConsoleOutputs()
 	})
 }
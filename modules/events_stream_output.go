@@ -0,0 +1,329 @@
+package modules
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/session"
+)
+
+const (
+	eventsFormatText = "text"
+	eventsFormatJSON = "json"
+	// tag of the events pushed to the sinks instead of the coalesced ones
+	coalescedEventTag = "events.coalesced"
+	// events kept while the webhook is being called, the new ones are
+	// dropped when full
+	webhookBufferSize = 4096
+	webhookTimeout    = 10 * time.Second
+	webhookRetryDelay = time.Second
+)
+
+// eventSink receives the events instead of their text view.
+type eventSink interface {
+	Push(e session.Event)
+	Close() error
+}
+
+// eventDocument encodes the event as the /api/events route does.
+func eventDocument(e session.Event) []byte {
+	raw, err := json.Marshal(e)
+	if err != nil {
+		// the sniffer events can carry objects which can't be encoded
+		if se, ok := e.Data.(SnifferEvent); ok {
+			se.Data = nil
+			e.Data = se
+		} else {
+			e.Data = fmt.Sprintf("%v", e.Data)
+		}
+		raw, _ = json.Marshal(e)
+	}
+	return raw
+}
+
+// jsonLinesSink writes an event document per line.
+type jsonLinesSink struct {
+	w io.Writer
+}
+
+func (j *jsonLinesSink) Push(e session.Event) {
+	j.w.Write(append(eventDocument(e), '\n'))
+}
+
+func (j *jsonLinesSink) Close() error {
+	if closer, ok := j.w.(io.Closer); ok && j.w != os.Stdout {
+		return closer.Close()
+	}
+	return nil
+}
+
+func isWebhookOutput(output string) bool {
+	return strings.HasPrefix(output, "http://") || strings.HasPrefix(output, "https://")
+}
+
+type webhookOptions struct {
+	batch   int
+	flush   time.Duration
+	retries int
+}
+
+// webhookSink posts the events as JSON arrays of at most batch events,
+// every flush period or as soon as a batch is full, and retries failed
+// requests with an exponential backoff.
+type webhookSink struct {
+	url        string
+	host       string
+	client     *http.Client
+	opts       webhookOptions
+	retryDelay time.Duration
+	events     chan []byte
+	dropped    uint64
+	quit       chan bool
+	done       chan bool
+}
+
+func newWebhookSink(output string, opts webhookOptions, insecure bool) (*webhookSink, error) {
+	u, err := url.Parse(output)
+	if err != nil {
+		return nil, err
+	} else if u.Host == "" {
+		return nil, fmt.Errorf("No webhook host specified in %s.", output)
+	} else if opts.batch <= 0 {
+		return nil, fmt.Errorf("The webhook batch size must be greater than 0.")
+	} else if opts.flush <= 0 {
+		return nil, fmt.Errorf("The webhook flush period must be greater than 0.")
+	}
+
+	w := &webhookSink{
+		url:  output,
+		host: u.Host,
+		client: &http.Client{
+			Timeout: webhookTimeout,
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+			},
+		},
+		opts:       opts,
+		retryDelay: webhookRetryDelay,
+		events:     make(chan []byte, webhookBufferSize),
+		quit:       make(chan bool),
+		done:       make(chan bool),
+	}
+	go w.worker()
+	return w, nil
+}
+
+// Push never blocks, the event is dropped if the buffer is full.
+func (w *webhookSink) Push(e session.Event) {
+	select {
+	case w.events <- eventDocument(e):
+	default:
+		atomic.AddUint64(&w.dropped, 1)
+	}
+}
+
+func (w *webhookSink) worker() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.opts.flush)
+	defer ticker.Stop()
+
+	pending := make([][]byte, 0, w.opts.batch)
+	for {
+		select {
+		case doc := <-w.events:
+			if pending = append(pending, doc); len(pending) >= w.opts.batch {
+				w.send(pending)
+				pending = pending[:0]
+			}
+
+		case <-ticker.C:
+			if len(pending) > 0 {
+				w.send(pending)
+				pending = pending[:0]
+			}
+
+		case <-w.quit:
+		drain:
+			for {
+				select {
+				case doc := <-w.events:
+					pending = append(pending, doc)
+				default:
+					break drain
+				}
+			}
+			for len(pending) > 0 {
+				n := w.opts.batch
+				if n > len(pending) {
+					n = len(pending)
+				}
+				w.send(pending[:n])
+				pending = pending[n:]
+			}
+			return
+		}
+	}
+}
+
+func (w *webhookSink) post(body []byte) error {
+	req, err := http.NewRequest("POST", w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", res.Status)
+	}
+	return nil
+}
+
+// send posts the batch, retrying until it's accepted or the retries are
+// over.
+func (w *webhookSink) send(batch [][]byte) {
+	body := append(append([]byte{'['}, bytes.Join(batch, []byte{','})...), ']')
+	for attempt := 0; ; attempt++ {
+		err := w.post(body)
+		if err == nil {
+			return
+		} else if attempt >= w.opts.retries {
+			log.Warning("Could not send %d events to %s: %s", len(batch), w.host, err)
+			return
+		}
+
+		select {
+		case <-time.After(w.retryDelay << uint(attempt)):
+		case <-w.quit:
+			// no more waiting while closing
+		}
+	}
+}
+
+// Close sends the pending events and waits for the last request.
+func (w *webhookSink) Close() error {
+	close(w.quit)
+	<-w.done
+
+	if dropped := atomic.LoadUint64(&w.dropped); dropped > 0 {
+		log.Warning("%d events have not been sent to %s as the buffer was full.", dropped, w.host)
+	}
+	return nil
+}
+
+// eventsOutputs are the outputs of events.stream, the text ones get the
+// views of the events and the sinks their JSON documents.
+type eventsOutputs struct {
+	text    []io.Writer
+	sinks   []eventSink
+	console bool
+}
+
+func newConsoleOutputs() *eventsOutputs {
+	return &eventsOutputs{
+		text:    []io.Writer{os.Stdout},
+		console: true,
+	}
+}
+
+// newEventsOutputs opens the comma separated list of outputs, an empty
+// one or - is the standard output, files are written in the format.
+func newEventsOutputs(list string, format string, opts webhookOptions, insecure bool) (*eventsOutputs, error) {
+	o := &eventsOutputs{
+		text:  make([]io.Writer, 0),
+		sinks: make([]eventSink, 0),
+	}
+
+	for _, output := range strings.Split(list, ",") {
+		if err := o.open(strings.TrimSpace(output), format, opts, insecure); err != nil {
+			o.Close()
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+func (o *eventsOutputs) open(output string, format string, opts webhookOptions, insecure bool) error {
+	if isSyslogOutput(output) {
+		w, err := newSyslogWriter(output, insecure)
+		if err != nil {
+			return err
+		}
+		o.text = append(o.text, w)
+		return nil
+	} else if isWebhookOutput(output) {
+		w, err := newWebhookSink(output, opts, insecure)
+		if err != nil {
+			return err
+		}
+		o.sinks = append(o.sinks, w)
+		return nil
+	}
+
+	var w io.Writer = os.Stdout
+	if output == "" || output == "-" {
+		o.console = o.console || format == eventsFormatText
+	} else if path, err := core.ExpandPath(output); err != nil {
+		return err
+	} else if w, err = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); err != nil {
+		return err
+	}
+
+	if format == eventsFormatJSON {
+		o.sinks = append(o.sinks, &jsonLinesSink{w: w})
+	} else {
+		o.text = append(o.text, w)
+	}
+	return nil
+}
+
+// HasText is true if there are outputs for the views of the events.
+func (o *eventsOutputs) HasText() bool {
+	return len(o.text) > 0
+}
+
+// Write sends the view of an event to every text output.
+func (o *eventsOutputs) Write(p []byte) (int, error) {
+	for _, w := range o.text {
+		w.Write(p)
+	}
+	return len(p), nil
+}
+
+// Push sends the event to every sink.
+func (o *eventsOutputs) Push(e session.Event) {
+	for _, sink := range o.sinks {
+		sink.Push(e)
+	}
+}
+
+func (o *eventsOutputs) Close() {
+	for _, w := range o.text {
+		if closer, ok := w.(io.Closer); ok && w != os.Stdout {
+			closer.Close()
+		}
+	}
+	for _, sink := range o.sinks {
+		sink.Close()
+	}
+}
@@ -0,0 +1,150 @@
+package modules
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bettercap/bettercap/session"
+)
+
+func TestEventsDocument(t *testing.T) {
+	var units = []struct {
+		event session.Event
+		exp   string
+	}{
+		{
+			session.Event{Tag: "mod.started", Data: "net.recon"},
+			`{"tag":"mod.started","time":"0001-01-01T00:00:00Z","data":"net.recon"}`,
+		},
+		{
+			session.Event{Tag: "net.sniff.leak.http", Data: SnifferEvent{Message: "GET /", Data: func() {}}},
+			`{"tag":"net.sniff.leak.http","time":"0001-01-01T00:00:00Z","data":{"PacketTime":"0001-01-01T00:00:00Z","Protocol":"","Source":"","Destination":"","Message":"GET /","Data":null}}`,
+		},
+		{
+			session.Event{Tag: "custom", Data: func() {}},
+			`{"tag":"custom","time":"0001-01-01T00:00:00Z","data":"`,
+		},
+	}
+
+	for _, u := range units {
+		got := string(eventDocument(u.event))
+		if len(got) < len(u.exp) || got[:len(u.exp)] != u.exp {
+			t.Fatalf("expected '%s', got '%s'", u.exp, got)
+		}
+	}
+}
+
+func TestEventsOutputsFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bcap-events-")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	first, second := filepath.Join(dir, "first.jsonl"), filepath.Join(dir, "second.jsonl")
+	o, err := newEventsOutputs(first+", "+second, eventsFormatJSON, webhookOptions{}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if o.HasText() || o.console {
+		t.Fatal("expected no text outputs")
+	}
+
+	o.Push(session.NewEvent("endpoint.new", "one"))
+	o.Push(session.NewEvent("endpoint.lost", "two"))
+	o.Close()
+
+	for _, path := range []string{first, second} {
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer file.Close()
+
+		tags := make([]string, 0)
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			e := session.Event{}
+			if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tags = append(tags, e.Tag)
+		}
+
+		if len(tags) != 2 || tags[0] != "endpoint.new" || tags[1] != "endpoint.lost" {
+			t.Fatalf("unexpected events %v in %s", tags, path)
+		}
+	}
+
+	o, err = newEventsOutputs("-,"+first, eventsFormatText, webhookOptions{}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer o.Close()
+
+	if len(o.text) != 2 || len(o.sinks) != 0 || !o.console {
+		t.Fatalf("expected the console and a text file, got %d text outputs and %d sinks", len(o.text), len(o.sinks))
+	}
+
+	for _, bad := range []string{"http://", "syslog+tcp://", "/nope/nope/events.log"} {
+		if _, err := newEventsOutputs(bad, eventsFormatText, webhookOptions{batch: 1, flush: time.Second}, false); err == nil {
+			t.Fatalf("expected error for '%s'", bad)
+		}
+	}
+}
+
+func TestEventsOutputsWebhook(t *testing.T) {
+	var lock sync.Mutex
+	requests := 0
+	batches := make([][]session.Event, 0)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		// the first request fails and is retried
+		if requests++; requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		} else if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("unexpected content type '%s'", ct)
+		}
+
+		batch := make([]session.Event, 0)
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		batches = append(batches, batch)
+	}))
+	defer server.Close()
+
+	w, err := newWebhookSink(server.URL, webhookOptions{batch: 2, flush: time.Hour, retries: 1}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w.retryDelay = time.Millisecond
+
+	for _, tag := range []string{"one", "two", "three"} {
+		w.Push(session.NewEvent(tag, nil))
+	}
+	// the last event is sent while closing
+	w.Close()
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	if requests != 3 {
+		t.Fatalf("expected 3 requests, got %d", requests)
+	} else if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 {
+		t.Fatalf("unexpected batches %v", batches)
+	} else if batches[0][0].Tag != "one" || batches[0][1].Tag != "two" || batches[1][0].Tag != "three" {
+		t.Fatalf("unexpected batches %v", batches)
+	}
+}
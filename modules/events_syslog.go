@@ -0,0 +1,270 @@
+package modules
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/session"
+)
+
+const (
+	syslogScheme    = "syslog"
+	syslogTCPScheme = "syslog+tcp"
+	syslogTLSScheme = "syslog+tls"
+	syslogPort      = "514"
+	// RFC 5425
+	syslogTLSPort = "6514"
+	// user-level messages
+	syslogFacility = 1
+	// messages kept while the server is not reachable, the oldest
+	// ones are dropped when full
+	syslogBufferSize  = 1024
+	syslogRetryPeriod = 5 * time.Second
+	syslogDialTimeout = 2 * time.Second
+)
+
+const (
+	syslogCritical = 2
+	syslogError    = 3
+	syslogWarning  = 4
+	syslogNotice   = 5
+	syslogInfo     = 6
+	syslogDebug    = 7
+)
+
+var (
+	syslogLocalPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+	// what the views write, [time] [tag] message
+	syslogViewParser = regexp.MustCompile(`(?s)^\[[^\]]*\] \[([^\]]+)\] (.*)$`)
+	syslogColors     = regexp.MustCompile("\033\\[[0-9;]*m")
+	syslogLevels     = map[string]int{
+		core.LogLabels[core.DEBUG]:     syslogDebug,
+		core.LogLabels[core.INFO]:      syslogInfo,
+		core.LogLabels[core.IMPORTANT]: syslogNotice,
+		core.LogLabels[core.WARNING]:   syslogWarning,
+		core.LogLabels[core.ERROR]:     syslogError,
+		core.LogLabels[core.FATAL]:     syslogCritical,
+	}
+)
+
+func isSyslogOutput(output string) bool {
+	for _, scheme := range []string{syslogScheme, syslogTCPScheme, syslogTLSScheme} {
+		if strings.HasPrefix(output, scheme+"://") {
+			return true
+		}
+	}
+	return false
+}
+
+// syslogSeverity maps the tag of an event, and the level of the log
+// ones, to a syslog severity.
+func syslogSeverity(tag string, message string) (int, string) {
+	if tag == "sys.log" {
+		if parts := strings.SplitN(message, " ", 2); len(parts) == 2 && len(parts[0]) > 2 {
+			if severity, found := syslogLevels[strings.Trim(parts[0], "[]")]; found {
+				return severity, parts[1]
+			}
+		}
+		return syslogInfo, message
+	}
+
+	switch {
+	case tag == "net.sniff.creds" || tag == "wifi.handshake" || tag == "mac.duplicate" || tag == "net.fuzz.anomaly" || tag == "ble.fuzz.disconnected":
+		return syslogWarning, message
+	case tag == "update.available" || strings.HasSuffix(tag, ".lost"):
+		return syslogNotice, message
+	case tag == session.ProgressEventTag:
+		return syslogDebug, message
+	}
+	return syslogInfo, message
+}
+
+// syslogWriter sends each write of the events views as a RFC 5424
+// message, the messages are buffered while the server is not reachable.
+type syslogWriter struct {
+	sync.Mutex
+	network  string
+	address  string
+	hostname string
+	tls      *tls.Config
+	conn     net.Conn
+	lastDial time.Time
+	pending  [][]byte
+	dropped  int
+}
+
+// newSyslogWriter parses syslog://host[:port] (UDP), syslog+tcp://host[:port],
+// syslog+tls://host[:port] or, for the local daemon, syslog:// and
+// syslog:///path/to/socket; insecure skips the verification of the
+// server certificate.
+func newSyslogWriter(output string, insecure bool) (*syslogWriter, error) {
+	u, err := url.Parse(output)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &syslogWriter{
+		hostname: "-",
+		pending:  make([][]byte, 0),
+	}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		w.hostname = hostname
+	}
+
+	switch {
+	case u.Host != "":
+		w.network, w.address = "udp", u.Host
+		port := syslogPort
+		if u.Scheme == syslogTCPScheme {
+			w.network = "tcp"
+		} else if u.Scheme == syslogTLSScheme {
+			w.network, port = "tls", syslogTLSPort
+			w.tls = &tls.Config{
+				ServerName:         u.Hostname(),
+				InsecureSkipVerify: insecure,
+			}
+		}
+		if u.Port() == "" {
+			w.address = net.JoinHostPort(u.Hostname(), port)
+		}
+	case u.Scheme == syslogTCPScheme || u.Scheme == syslogTLSScheme:
+		return nil, fmt.Errorf("No syslog server specified in %s.", output)
+	case u.Path != "":
+		w.network, w.address = "unixgram", u.Path
+	default:
+		for _, path := range syslogLocalPaths {
+			if _, err := os.Stat(path); err == nil {
+				w.network, w.address = "unixgram", path
+				break
+			}
+		}
+		if w.address == "" {
+			return nil, fmt.Errorf("Could not find the socket of the local syslog daemon in %s.", strings.Join(syslogLocalPaths, ", "))
+		}
+	}
+
+	// only fail on the first connection, later ones are retried
+	if err := w.dial(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *syslogWriter) dial() (err error) {
+	w.lastDial = time.Now()
+	if w.network == "tls" {
+		w.conn, err = tls.DialWithDialer(&net.Dialer{Timeout: syslogDialTimeout}, "tcp", w.address, w.tls)
+		return
+	}
+
+	if w.conn, err = net.DialTimeout(w.network, w.address, syslogDialTimeout); err != nil && w.network == "unixgram" {
+		// some daemons only listen on stream sockets
+		if w.conn, err = net.DialTimeout("unix", w.address, syslogDialTimeout); err == nil {
+			w.network = "unix"
+		}
+	}
+	return
+}
+
+// format builds the message, the tag of the event is used as MSGID.
+func (w *syslogWriter) format(now time.Time, view string) []byte {
+	view = strings.TrimRight(syslogColors.ReplaceAllString(view, ""), "\n ")
+
+	tag := "-"
+	message := view
+	if m := syslogViewParser.FindStringSubmatch(view); m != nil {
+		tag, message = m[1], m[2]
+	}
+
+	severity, message := syslogSeverity(tag, message)
+	return w.message(now, severity, tag, message)
+}
+
+func (w *syslogWriter) message(now time.Time, severity int, msgID string, message string) []byte {
+	if msgID = strings.Replace(msgID, " ", "_", -1); len(msgID) > 32 {
+		msgID = msgID[:32]
+	}
+
+	return []byte(fmt.Sprintf("<%d>1 %s %s %s %d %s - %s",
+		syslogFacility*8+severity,
+		now.Format("2006-01-02T15:04:05.000000Z07:00"),
+		w.hostname,
+		core.Name,
+		os.Getpid(),
+		msgID,
+		message))
+}
+
+func (w *syslogWriter) send(msg []byte) (err error) {
+	if w.network == "tcp" || w.network == "tls" {
+		// octet counting framing of RFC 6587
+		_, err = fmt.Fprintf(w.conn, "%d %s", len(msg), msg)
+	} else if w.network == "unix" {
+		_, err = fmt.Fprintf(w.conn, "%s\n", msg)
+	} else {
+		_, err = w.conn.Write(msg)
+	}
+	return
+}
+
+func (w *syslogWriter) buffer(msg []byte) {
+	if len(w.pending) >= syslogBufferSize {
+		w.pending = w.pending[1:]
+		w.dropped++
+	}
+	w.pending = append(w.pending, msg)
+}
+
+// flush sends the buffered messages in order, reconnecting at most once
+// every syslogRetryPeriod.
+func (w *syslogWriter) flush() {
+	if w.conn == nil {
+		if time.Since(w.lastDial) < syslogRetryPeriod || w.dial() != nil {
+			return
+		}
+		if w.dropped > 0 {
+			dropped := w.message(time.Now(), syslogWarning, "sys.log", fmt.Sprintf("%d events dropped while the syslog server was not reachable.", w.dropped))
+			w.pending = append([][]byte{dropped}, w.pending...)
+			w.dropped = 0
+		}
+	}
+
+	for len(w.pending) > 0 {
+		if err := w.send(w.pending[0]); err != nil {
+			w.conn.Close()
+			w.conn = nil
+			return
+		}
+		w.pending = w.pending[1:]
+	}
+}
+
+// Write never fails, the message is buffered if it can't be sent.
+func (w *syslogWriter) Write(p []byte) (int, error) {
+	w.Lock()
+	defer w.Unlock()
+
+	w.buffer(w.format(time.Now(), string(p)))
+	w.flush()
+	return len(p), nil
+}
+
+func (w *syslogWriter) Close() error {
+	w.Lock()
+	defer w.Unlock()
+
+	if w.flush(); w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
@@ -0,0 +1,209 @@
+package modules
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	btls "github.com/bettercap/bettercap/tls"
+)
+
+func TestEventsSyslogFormat(t *testing.T) {
+	w := &syslogWriter{hostname: "box"}
+	now := time.Date(2018, 3, 1, 10, 20, 30, 123456000, time.UTC)
+
+	var units = []struct {
+		view string
+		exp  string
+	}{
+		{
+			"[10:20:30] [" + core.Green("endpoint.new") + "] Endpoint 192.168.1.2 detected as aa:bb:cc:dd:ee:ff.\n",
+			"<14>1 2018-03-01T10:20:30.123456Z box bettercap %d endpoint.new - Endpoint 192.168.1.2 detected as aa:bb:cc:dd:ee:ff.",
+		},
+		{
+			"[10:20:30] [sys.log] [war] something happened\n",
+			"<12>1 2018-03-01T10:20:30.123456Z box bettercap %d sys.log - something happened",
+		},
+		{
+			"[10:20:30] [endpoint.lost] Endpoint 192.168.1.2 lost.\n",
+			"<13>1 2018-03-01T10:20:30.123456Z box bettercap %d endpoint.lost - Endpoint 192.168.1.2 lost.",
+		},
+		{
+			"not a view",
+			"<14>1 2018-03-01T10:20:30.123456Z box bettercap %d - - not a view",
+		},
+	}
+
+	for _, u := range units {
+		exp := fmt.Sprintf(u.exp, os.Getpid())
+		if got := string(w.format(now, u.view)); got != exp {
+			t.Fatalf("expected '%s', got '%s'", exp, got)
+		}
+	}
+}
+
+func TestEventsSyslogOutput(t *testing.T) {
+	for _, bad := range []string{"syslog+tcp://", "syslog+tls://", "syslog:///nope/nope"} {
+		if _, err := newSyslogWriter(bad, false); err == nil {
+			t.Fatalf("expected error for '%s'", bad)
+		}
+	}
+
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer udp.Close()
+
+	w, err := newSyslogWriter("syslog://"+udp.LocalAddr().String(), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer w.Close()
+
+	fmt.Fprintf(w, "[10:20:30] [mac.changed] eth0 aa:bb:cc:dd:ee:ff\n")
+	buf := make([]byte, 1024)
+	udp.SetReadDeadline(time.Now().Add(time.Second))
+	if n, _, err := udp.ReadFrom(buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if msg := string(buf[:n]); !strings.HasPrefix(msg, "<14>1 ") || !strings.HasSuffix(msg, " mac.changed - eth0 aa:bb:cc:dd:ee:ff") {
+		t.Fatalf("unexpected message '%s'", msg)
+	}
+}
+
+func TestEventsSyslogReconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer ln.Close()
+
+	w, err := newSyslogWriter("syslog+tcp://"+ln.Addr().String(), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer w.Close()
+
+	// the first connection is lost
+	if conn, err := ln.Accept(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else {
+		conn.Close()
+	}
+
+	w.Lock()
+	w.conn.Close()
+	w.conn = nil
+	w.Unlock()
+
+	for i := 0; i < syslogBufferSize+2; i++ {
+		fmt.Fprintf(w, "[10:20:30] [syn.scan] event %d\n", i)
+	}
+
+	w.Lock()
+	if len(w.pending) != syslogBufferSize || w.dropped != 2 {
+		t.Fatalf("expected %d buffered and 2 dropped events, got %d and %d", syslogBufferSize, len(w.pending), w.dropped)
+	}
+	w.lastDial = time.Time{}
+	w.Unlock()
+
+	done := make(chan []string)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			done <- nil
+			return
+		}
+		defer conn.Close()
+
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		messages := make([]string, 0)
+		reader := bufio.NewReader(conn)
+		for len(messages) < syslogBufferSize+1 {
+			var size int
+			if _, err := fmt.Fscanf(reader, "%d ", &size); err != nil {
+				break
+			}
+			msg := make([]byte, size)
+			if _, err := io.ReadFull(reader, msg); err != nil {
+				break
+			}
+			messages = append(messages, string(msg))
+		}
+		done <- messages
+	}()
+
+	// drops one more event, then reconnects
+	fmt.Fprintf(w, "[10:20:30] [syn.scan] event last\n")
+
+	messages := <-done
+	if len(messages) != syslogBufferSize+1 {
+		t.Fatalf("expected %d messages, got %d", syslogBufferSize+1, len(messages))
+	} else if !strings.HasSuffix(messages[0], "sys.log - 3 events dropped while the syslog server was not reachable.") {
+		t.Fatalf("unexpected message '%s'", messages[0])
+	} else if !strings.HasSuffix(messages[1], "syn.scan - event 3") {
+		t.Fatalf("unexpected message '%s'", messages[1])
+	} else if !strings.HasSuffix(messages[len(messages)-1], "syn.scan - event last") {
+		t.Fatalf("unexpected message '%s'", messages[len(messages)-1])
+	}
+}
+
+func TestEventsSyslogTLS(t *testing.T) {
+	cfg := btls.DefaultLegitConfig
+	cfg.Bits = 1024
+	err, key, cert := btls.CreateCertificate(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{cert}, PrivateKey: key}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer ln.Close()
+
+	done := make(chan string)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			// the first client rejects the self signed certificate
+			conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			var size int
+			reader := bufio.NewReader(conn)
+			if _, err := fmt.Fscanf(reader, "%d ", &size); err == nil {
+				msg := make([]byte, size)
+				io.ReadFull(reader, msg)
+				done <- string(msg)
+			}
+			conn.Close()
+		}
+	}()
+
+	if _, err := newSyslogWriter("syslog+tls://"+ln.Addr().String(), false); err == nil {
+		t.Fatal("expected error for an untrusted certificate")
+	}
+
+	w, err := newSyslogWriter("syslog+tls://"+ln.Addr().String(), true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer w.Close()
+
+	fmt.Fprintf(w, "[10:20:30] [mac.changed] eth0 aa:bb:cc:dd:ee:ff\n")
+	if msg := <-done; !strings.HasSuffix(msg, " mac.changed - eth0 aa:bb:cc:dd:ee:ff") {
+		t.Fatalf("unexpected message '%s'", msg)
+	}
+}
@@ -4,8 +4,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"os"
 	"strings"
+	"time"
 
 	"github.com/bettercap/bettercap/core"
 	"github.com/bettercap/bettercap/network"
@@ -76,6 +76,26 @@ func (s *EventsStream) viewWiFiEvent(e session.Event) {
 			core.Dim(desc),
 			core.Bold(probe.SSID),
 			core.Yellow(rssi))
+	} else if e.Tag == "wifi.handshake" {
+		hs := e.Data.(WiFiHandshakeEvent)
+		what := "handshake"
+		if hs.PMKID {
+			what = "PMKID"
+		}
+
+		saved := "saved to " + hs.File
+		if hs.File == "" {
+			saved = "use wifi.assoc export to save it"
+		}
+
+		fmt.Fprintf(s.output, "[%s] [%s] Captured %s of %s (%s) from station %s, %s\n",
+			e.Time.Format(eventTimeFormat),
+			core.Green(e.Tag),
+			what,
+			core.Bold(hs.ESSID),
+			hs.AP,
+			hs.Station,
+			saved)
 	}
 }
 
@@ -102,6 +122,13 @@ func (s *EventsStream) viewEndpointEvent(e session.Event) {
 			core.Dim(name),
 			core.Green(t.HwAddress),
 			core.Dim(vend))
+	} else if e.Tag == "endpoint.fingerprint" {
+		fmt.Fprintf(s.output, "[%s] [%s] Endpoint %s%s fingerprinted as %s.\n",
+			e.Time.Format(eventTimeFormat),
+			core.Green(e.Tag),
+			core.Bold(t.IpAddress),
+			core.Dim(name),
+			core.Yellow(t.Fingerprint.String()))
 	} else if e.Tag == "endpoint.lost" {
 		fmt.Fprintf(s.output, "[%s] [%s] Endpoint %s%s lost.\n",
 			e.Time.Format(eventTimeFormat),
@@ -157,13 +184,104 @@ func (s *EventsStream) viewSnifferEvent(e session.Event) {
 		misc)
 }
 
+func (s *EventsStream) viewCredsEvent(e session.Event) {
+	creds := e.Data.(SnifferCredentials)
+	password := creds.Password
+	if !s.Session.ShowSecrets() {
+		password = redact(password)
+	}
+
+	fmt.Fprintf(s.output, "[%s] [%s] %s %s > %s | %s : %s\n",
+		e.Time.Format(eventTimeFormat),
+		core.Green(e.Tag),
+		core.W(core.BG_RED+core.FG_BLACK, creds.Protocol),
+		creds.Source,
+		creds.Destination,
+		core.Bold(creds.Username),
+		core.Yellow(password))
+}
+
+func (s *EventsStream) viewFuzzEvent(e session.Event) {
+	fe := e.Data.(NetFuzzEvent)
+	if fe.Anomaly != "" {
+		fmt.Fprintf(s.output, "[%s] [%s] %s:%d %s %s: %s\n",
+			e.Time.Format(eventTimeFormat),
+			core.Green(e.Tag),
+			core.Bold(fe.Address),
+			fe.Port,
+			fe.Service,
+			core.Dim(fe.Case),
+			core.Red(fe.Anomaly))
+	} else {
+		fmt.Fprintf(s.output, "[%s] [%s] %s:%d %s %s\n",
+			e.Time.Format(eventTimeFormat),
+			core.Green(e.Tag),
+			core.Bold(fe.Address),
+			fe.Port,
+			core.Yellow(fe.Service),
+			fe.Banner)
+	}
+}
+
 func (s *EventsStream) viewSynScanEvent(e session.Event) {
 	se := e.Data.(SynScanEvent)
-	fmt.Fprintf(s.output, "[%s] [%s] Found open port %d for %s\n",
+	if se.Service == "" {
+		fmt.Fprintf(s.output, "[%s] [%s] Found open port %d for %s\n",
+			e.Time.Format(eventTimeFormat),
+			core.Green(e.Tag),
+			se.Port,
+			core.Bold(se.Address))
+		return
+	}
+
+	banner := ""
+	if se.Banner != "" {
+		banner = " " + core.Dim(se.Banner)
+	}
+	fmt.Fprintf(s.output, "[%s] [%s] Found %s on %s port %d for %s%s\n",
 		e.Time.Format(eventTimeFormat),
 		core.Green(e.Tag),
+		core.Yellow(se.Service),
+		se.Protocol,
 		se.Port,
-		core.Bold(se.Address))
+		core.Bold(se.Address),
+		banner)
+}
+
+func (s *EventsStream) viewMacDuplicateEvent(e session.Event) {
+	dup := e.Data.(MacDuplicateEvent)
+	vendor := ""
+	if dup.Vendor != "" {
+		vendor = fmt.Sprintf(" (%s)", dup.Vendor)
+	}
+	ours := ""
+	if dup.Ours {
+		ours = core.Red(" conflicting with our interface")
+	}
+
+	fmt.Fprintf(s.output, "[%s] [%s] %s%s used by %s, %s%s\n",
+		e.Time.Format(eventTimeFormat),
+		core.Bold(core.Yellow(e.Tag)),
+		core.Bold(dup.MAC),
+		core.Dim(vendor),
+		strings.Join(dup.Addresses, ", "),
+		dup.Reason,
+		ours)
+}
+
+func (s *EventsStream) viewMacChangedEvent(e session.Event) {
+	changed := e.Data.(MacChangedEvent)
+	old := ""
+	if changed.Old != "" {
+		old = changed.Old + " > "
+	}
+
+	fmt.Fprintf(s.output, "[%s] [%s] %s %s%s\n",
+		e.Time.Format(eventTimeFormat),
+		core.Green(e.Tag),
+		changed.Interface,
+		core.Dim(old),
+		core.Bold(changed.New))
 }
 
 func (s *EventsStream) viewUpdateEvent(e session.Event) {
@@ -176,6 +294,133 @@ func (s *EventsStream) viewUpdateEvent(e session.Event) {
 		*update.HTMLURL)
 }
 
+func (s *EventsStream) viewSNIEvent(e session.Event) {
+	sni := e.Data.(HTTPSProxySNIEvent)
+	action := "intercepted"
+	if sni.Tunneled {
+		action = "tunneled"
+	}
+
+	fmt.Fprintf(s.output, "[%s] [%s] %s > %s (%s)\n",
+		e.Time.Format(eventTimeFormat),
+		core.Green(e.Tag),
+		core.Bold(sni.Client),
+		core.Yellow(sni.ServerName),
+		core.Dim(action))
+}
+
+func (s *EventsStream) viewPinningEvent(e session.Event) {
+	pinning := e.Data.(HTTPSProxyPinningEvent)
+
+	fmt.Fprintf(s.output, "[%s] [%s] %s aborted the handshake with %s, it might be pinning its certificate (%s).\n",
+		e.Time.Format(eventTimeFormat),
+		core.Green(e.Tag),
+		core.Bold(pinning.Client),
+		core.Yellow(pinning.ServerName),
+		core.Dim(fmt.Sprintf("%d aborted", pinning.Aborted)))
+}
+
+func (s *EventsStream) viewDHCPLeaseEvent(e session.Event) {
+	lease := e.Data.(DHCPLeaseEvent)
+	name := ""
+	if lease.Hostname != "" {
+		name = fmt.Sprintf(" (%s)", core.Yellow(lease.Hostname))
+	}
+
+	fmt.Fprintf(s.output, "[%s] [%s] %s leased to %s%s until %s\n",
+		e.Time.Format(eventTimeFormat),
+		core.Green(e.Tag),
+		core.Bold(lease.IP),
+		lease.MAC,
+		name,
+		core.Dim(lease.Expires.Format(eventTimeFormat)))
+}
+
+func (s *EventsStream) viewArpGuardEvent(e session.Event) {
+	alert := e.Data.(ArpGuardEvent)
+	attacker := ""
+	if alert.Attacker != "" {
+		attacker = fmt.Sprintf(" (%s)", core.Yellow(alert.Attacker))
+	}
+	notes := []string{alert.Reason}
+	if alert.Gratuitous {
+		notes = append(notes, "gratuitous")
+	}
+	if alert.Restored {
+		notes = append(notes, "restored")
+	}
+
+	fmt.Fprintf(s.output, "[%s] [%s] %s is being claimed by %s%s instead of %s (%s)\n",
+		e.Time.Format(eventTimeFormat),
+		core.Red(e.Tag),
+		core.Bold(alert.IP),
+		core.Red(alert.MAC),
+		attacker,
+		alert.Expected,
+		core.Dim(strings.Join(notes, ", ")))
+}
+
+func (s *EventsStream) viewResponderHashEvent(e session.Event) {
+	hash := e.Data.(ResponderHash)
+	user := hash.User
+	if hash.Domain != "" {
+		user = hash.Domain + "\\" + user
+	}
+
+	fmt.Fprintf(s.output, "[%s] [%s] NTLMv%d hash of %s from %s (%s)\n",
+		e.Time.Format(eventTimeFormat),
+		core.Green(e.Tag),
+		hash.Version,
+		core.Red(user),
+		core.Bold(hash.Client),
+		core.Dim(hash.Protocol))
+}
+
+const progressBarWidth = 20
+
+func (s *EventsStream) viewProgressEvent(e session.Event) {
+	progress := e.Data.(session.ProgressEvent)
+	// the console only shows steps of 10% to avoid flooding it
+	if progress.Percent%10 != 0 {
+		return
+	}
+
+	filled := progress.Percent * progressBarWidth / 100
+	bar := strings.Repeat("#", filled) + strings.Repeat(" ", progressBarWidth-filled)
+
+	fmt.Fprintf(s.output, "[%s] [%s] %s [%s] %3d%% %s\n",
+		e.Time.Format(eventTimeFormat),
+		core.Green(e.Tag),
+		progress.Module,
+		bar,
+		progress.Percent,
+		core.Dim(progress.Label))
+}
+
+func (s *EventsStream) viewCoalesced(groups []*coalesceGroup) {
+	if len(groups) == 0 {
+		return
+	}
+
+	for _, g := range groups {
+		key := ""
+		if g.Key != "" {
+			key = " " + core.Dim(g.Key)
+		}
+
+		fmt.Fprintf(s.output, "[%s] [%s] %d more similar events in %s%s\n",
+			time.Now().Format(eventTimeFormat),
+			core.Green(g.Tag),
+			g.Count,
+			g.Window,
+			key)
+	}
+
+	if s.output.console {
+		s.Session.Refresh()
+	}
+}
+
 func (s *EventsStream) View(e session.Event, refresh bool) {
 	if e.Tag == "sys.log" {
 		s.viewLogEvent(e)
@@ -185,19 +430,41 @@ func (s *EventsStream) View(e session.Event, refresh bool) {
 		s.viewWiFiEvent(e)
 	} else if strings.HasPrefix(e.Tag, "ble.") {
 		s.viewBLEEvent(e)
+	} else if strings.HasPrefix(e.Tag, "hid.") {
+		s.viewHIDEvent(e)
 	} else if strings.HasPrefix(e.Tag, "mod.") {
 		s.viewModuleEvent(e)
+	} else if e.Tag == "net.sniff.creds" {
+		s.viewCredsEvent(e)
 	} else if strings.HasPrefix(e.Tag, "net.sniff.") {
 		s.viewSnifferEvent(e)
-	} else if e.Tag == "syn.scan" {
+	} else if e.Tag == "syn.scan" || e.Tag == "syn.scan.service" {
 		s.viewSynScanEvent(e)
+	} else if strings.HasPrefix(e.Tag, "net.fuzz.") {
+		s.viewFuzzEvent(e)
+	} else if e.Tag == "mac.duplicate" {
+		s.viewMacDuplicateEvent(e)
+	} else if e.Tag == "mac.changed" || e.Tag == "mac.rotated" {
+		s.viewMacChangedEvent(e)
+	} else if e.Tag == "https.proxy.sni" {
+		s.viewSNIEvent(e)
+	} else if e.Tag == "https.proxy.pinning" {
+		s.viewPinningEvent(e)
+	} else if e.Tag == "dhcp.lease" {
+		s.viewDHCPLeaseEvent(e)
+	} else if e.Tag == "arp.guard.alert" {
+		s.viewArpGuardEvent(e)
+	} else if e.Tag == "net.responder.hash" {
+		s.viewResponderHashEvent(e)
 	} else if e.Tag == "update.available" {
 		s.viewUpdateEvent(e)
+	} else if e.Tag == session.ProgressEventTag {
+		s.viewProgressEvent(e)
 	} else {
 		fmt.Fprintf(s.output, "[%s] [%s] %v\n", e.Time.Format(eventTimeFormat), core.Green(e.Tag), e)
 	}
 
-	if refresh && s.output == os.Stdout {
+	if refresh && s.output.console {
 		s.Session.Refresh()
 	}
 }
@@ -47,6 +47,30 @@ func (s *EventsStream) viewBLEEvent(e session.Event) {
 			name,
 			dev.Device.ID(),
 			vend)
+	} else if e.Tag == "ble.device.notification" {
+		n := e.Data.(BLENotificationEvent)
+		fmt.Fprintf(s.output, "[%s] [%s] %s of %s notified %s\n",
+			e.Time.Format(eventTimeFormat),
+			core.Green(e.Tag),
+			n.Characteristic,
+			n.Device,
+			parseRawData(n.Data))
+	} else if e.Tag == "ble.fuzz.response" || e.Tag == "ble.fuzz.disconnected" {
+		f := e.Data.(BLEFuzzEvent)
+		what := ""
+		if f.Error != "" {
+			what = core.Red(f.Error)
+		} else {
+			what = "value changed to " + parseRawData(f.Response)
+		}
+
+		fmt.Fprintf(s.output, "[%s] [%s] writing %x to %s of %s: %s\n",
+			e.Time.Format(eventTimeFormat),
+			core.Yellow(e.Tag),
+			f.Payload,
+			f.Characteristic,
+			f.Device,
+			what)
 	} /* else {
 		fmt.Fprintf(s.output,"[%s] [%s]\n",
 			e.Time.Format(eventTimeFormat),
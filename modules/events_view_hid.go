@@ -0,0 +1,39 @@
+package modules
+
+import (
+	"fmt"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/network"
+	"github.com/bettercap/bettercap/session"
+)
+
+func (s *EventsStream) viewHIDEvent(e session.Event) {
+	dev := e.Data.(*network.HIDDevice)
+
+	dev.Lock()
+	devType := dev.Type
+	dev.Unlock()
+
+	name := ""
+	if devType != network.HIDTypeUnknown {
+		name = " " + core.Bold(devType.String())
+	}
+
+	if e.Tag == "hid.device.new" {
+		fmt.Fprintf(s.output, "[%s] [%s] New%s HID device detected as %s on channels %v.\n",
+			e.Time.Format(eventTimeFormat),
+			core.Green(e.Tag),
+			name,
+			core.Bold(dev.String()),
+			dev.Channels())
+	} else if e.Tag == "hid.device.lost" {
+		fmt.Fprintf(s.output, "[%s] [%s]%s HID device %s lost.\n",
+			e.Time.Format(eventTimeFormat),
+			core.Green(e.Tag),
+			name,
+			core.Red(dev.String()))
+	} else {
+		fmt.Fprintf(s.output, "[%s] [%s] %v\n", e.Time.Format(eventTimeFormat), core.Green(e.Tag), e)
+	}
+}
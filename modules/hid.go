@@ -0,0 +1,189 @@
+package modules
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/network"
+	"github.com/bettercap/bettercap/session"
+)
+
+const (
+	hidAddressRegexp = "([a-fA-F0-9]{2}:[a-fA-F0-9]{2}:[a-fA-F0-9]{2}:[a-fA-F0-9]{2}:[a-fA-F0-9]{2})"
+)
+
+type HIDRecon struct {
+	session.SessionModule
+	radio     *network.NRF24
+	hopPeriod time.Duration
+	lna       bool
+	// held while sniffing or injecting, as both retune the radio
+	radioLock sync.Mutex
+	waitGroup *sync.WaitGroup
+}
+
+func NewHIDRecon(s *session.Session) *HIDRecon {
+	h := &HIDRecon{
+		SessionModule: session.NewSessionModule("hid", s),
+		waitGroup:     &sync.WaitGroup{},
+	}
+
+	h.AddParam(session.NewBoolParameter("hid.lna",
+		"true",
+		"If true, enable the low noise amplifier of the CrazyRadio PA."))
+
+	h.AddParam(session.NewIntParameter("hid.hop.period",
+		"100",
+		"Time in milliseconds to stay on each channel while sniffing."))
+
+	h.AddParam(session.NewStringParameter("hid.force.type",
+		"",
+		"^(logitech|amazon|microsoft)?$",
+		"If set, inject with the protocol of this device type (logitech, amazon or microsoft) instead of the detected one."))
+
+	h.AddHandler(session.NewModuleHandler("hid.recon on", "",
+		"Start sniffing for wireless keyboards and mice with a nRF24 dongle.",
+		func(args []string) error {
+			return h.Start()
+		}))
+
+	h.AddHandler(session.NewModuleHandler("hid.recon off", "",
+		"Stop sniffing for wireless keyboards and mice.",
+		func(args []string) error {
+			return h.Stop()
+		}))
+
+	h.AddHandler(session.NewModuleHandler("hid.show", "",
+		"Show the wireless keyboards and mice detected so far.",
+		func(args []string) error {
+			return h.Show()
+		}))
+
+	h.AddHandler(session.NewDangerousModuleHandler("hid.inject ADDRESS LAYOUT FILENAME", "hid.inject "+hidAddressRegexp+" ([a-zA-Z]{2}) (.+)",
+		"Inject the keystrokes of a DuckyScript file into the device with the address, using the keyboard layout (US or GB).",
+		func(args []string) error {
+			return h.inject(args[0], args[1], args[2])
+		}))
+
+	return h
+}
+
+func (h *HIDRecon) Name() string {
+	return "hid"
+}
+
+func (h *HIDRecon) Description() string {
+	return "Discover vulnerable Logitech Unifying, AmazonBasics and Microsoft wireless keyboards and mice with a nRF24 dongle and inject keystrokes into them."
+}
+
+func (h *HIDRecon) Author() string {
+	return "Simone Margaritelli <evilsocket@protonmail.com>"
+}
+
+func (h *HIDRecon) Configure() error {
+	var err error
+	var period int
+
+	if h.Running() {
+		return session.ErrAlreadyStarted
+	} else if err, h.lna = h.BoolParam("hid.lna"); err != nil {
+		return err
+	} else if err, period = h.IntParam("hid.hop.period"); err != nil {
+		return err
+	} else if period <= 0 {
+		return fmt.Errorf("hid.hop.period must be greater than 0.")
+	}
+
+	h.hopPeriod = time.Duration(period) * time.Millisecond
+
+	if h.radio == nil {
+		log.Info("[%s] Opening the nRF24 dongle ...", core.Green("hid"))
+		if h.radio, err = network.OpenNRF24(); err != nil {
+			return err
+		}
+	}
+
+	if h.lna {
+		if err = h.radio.EnableLNA(); err != nil {
+			return err
+		}
+	}
+
+	return h.radio.EnterPromiscuousMode(nil)
+}
+
+// sniff receives the payloads on the channel for the hop period.
+func (h *HIDRecon) sniff(channel int) {
+	h.radioLock.Lock()
+	defer h.radioLock.Unlock()
+
+	if err := h.radio.SetChannel(channel); err != nil {
+		log.Debug("[%s] Could not switch to channel %d: %s", core.Green("hid"), channel, err)
+		return
+	}
+
+	for deadline := time.Now().Add(h.hopPeriod); time.Now().Before(deadline) && h.Running(); {
+		address, payload, err := h.radio.ReceivePromiscuous()
+		if err != nil {
+			log.Debug("[%s] Error while receiving: %s", core.Green("hid"), err)
+			return
+		} else if address != nil {
+			h.Session.HID.AddIfNew(address, channel, payload)
+		}
+	}
+}
+
+func (h *HIDRecon) pruner() {
+	defer h.waitGroup.Done()
+
+	for h.Running() {
+		for _, dev := range h.Session.HID.Devices() {
+			dev.Lock()
+			lastSeen := dev.LastSeen
+			dev.Unlock()
+
+			if time.Since(lastSeen) > hidPresentInterval {
+				h.Session.HID.Remove(dev.String())
+			}
+		}
+		time.Sleep(1 * time.Second)
+	}
+}
+
+func (h *HIDRecon) Start() error {
+	if err := h.Configure(); err != nil {
+		return err
+	}
+
+	h.waitGroup.Add(2)
+	return h.SetRunning(true, func() {
+		defer h.waitGroup.Done()
+
+		go h.pruner()
+
+		log.Info("[%s] Sniffing on %d channels ...", core.Green("hid"), len(network.NRF24Channels))
+
+		for h.Running() {
+			for _, channel := range network.NRF24Channels {
+				if !h.Running() {
+					break
+				}
+				h.sniff(channel)
+			}
+		}
+	})
+}
+
+func (h *HIDRecon) Stop() error {
+	return h.SetRunning(false, func() {
+		h.waitGroup.Wait()
+		// hid.inject might be transmitting
+		h.radioLock.Lock()
+		defer h.radioLock.Unlock()
+		h.radio.Close()
+		h.radio = nil
+	})
+}
@@ -0,0 +1,144 @@
+package modules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// hidCommand is a keystroke or a pause if the key code and the modifiers
+// are zero.
+type hidCommand struct {
+	Key   hidKey
+	Sleep time.Duration
+}
+
+func (c hidCommand) IsSleep() bool {
+	return c.Key.Code == 0 && c.Key.Modifiers == 0
+}
+
+func hidParseDelay(line int, arg string) (time.Duration, error) {
+	ms, err := strconv.Atoi(arg)
+	if err != nil || ms < 0 {
+		return 0, fmt.Errorf("line %d: '%s' is not a valid delay in milliseconds", line, arg)
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// hidComboTokens splits a combination, some payloads use CTRL-ALT for
+// CTRL ALT.
+func hidComboTokens(text string) []string {
+	tokens := make([]string, 0)
+	for _, field := range strings.Fields(text) {
+		if len(field) == 1 {
+			tokens = append(tokens, field)
+			continue
+		}
+		for _, token := range strings.Split(field, "-") {
+			if token != "" {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	return tokens
+}
+
+// hidParseCombo parses a combination of modifiers and keys such as
+// CTRL ALT DELETE or GUI r.
+func hidParseCombo(line int, tokens []string, keymap hidKeymap) (hidKey, error) {
+	key := hidKey{}
+	for _, token := range tokens {
+		upper := strings.ToUpper(token)
+		if mod, found := hidModifiers[upper]; found {
+			key.Modifiers |= mod
+		} else if code, found := hidSpecialKeys[upper]; found && key.Code == 0 {
+			key.Code = code
+		} else if runes := []rune(token); len(runes) == 1 && key.Code == 0 {
+			k, found := keymap[runes[0]]
+			if !found {
+				return key, fmt.Errorf("line %d: character '%s' not found in the keyboard layout", line, token)
+			}
+			key.Code = k.Code
+			key.Modifiers |= k.Modifiers
+		} else {
+			return key, fmt.Errorf("line %d: unknown key '%s'", line, token)
+		}
+	}
+	return key, nil
+}
+
+// parseDuckyScript parses a DuckyScript payload into keystrokes and
+// pauses for the keyboard layout.
+func parseDuckyScript(script string, keymap hidKeymap) ([]hidCommand, error) {
+	commands := make([]hidCommand, 0)
+	defaultDelay := time.Duration(0)
+	// the commands of the previous line, for REPEAT
+	prev := []hidCommand(nil)
+
+	for i, raw := range strings.Split(strings.Replace(script, "\r\n", "\n", -1), "\n") {
+		line := i + 1
+		text := strings.TrimLeft(raw, " \t")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		parts := strings.SplitN(text, " ", 2)
+		cmd, arg := strings.ToUpper(parts[0]), ""
+		if len(parts) == 2 {
+			arg = parts[1]
+		}
+
+		lineCommands := make([]hidCommand, 0)
+		switch cmd {
+		case "REM":
+			continue
+		case "DEFAULT_DELAY", "DEFAULTDELAY":
+			delay, err := hidParseDelay(line, strings.TrimSpace(arg))
+			if err != nil {
+				return nil, err
+			}
+			defaultDelay = delay
+			continue
+		case "DELAY":
+			delay, err := hidParseDelay(line, strings.TrimSpace(arg))
+			if err != nil {
+				return nil, err
+			}
+			lineCommands = append(lineCommands, hidCommand{Sleep: delay})
+		case "STRING":
+			for _, c := range arg {
+				key, found := keymap[c]
+				if !found {
+					return nil, fmt.Errorf("line %d: character '%c' not found in the keyboard layout", line, c)
+				}
+				lineCommands = append(lineCommands, hidCommand{Key: key})
+			}
+		case "REPEAT":
+			n, err := strconv.Atoi(strings.TrimSpace(arg))
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("line %d: '%s' is not a valid number of repetitions", line, arg)
+			} else if prev == nil {
+				return nil, fmt.Errorf("line %d: nothing to repeat", line)
+			}
+			for j := 0; j < n; j++ {
+				commands = append(commands, prev...)
+			}
+			continue
+		default:
+			key, err := hidParseCombo(line, hidComboTokens(text), keymap)
+			if err != nil {
+				return nil, err
+			}
+			lineCommands = append(lineCommands, hidCommand{Key: key})
+		}
+
+		if defaultDelay > 0 {
+			lineCommands = append(lineCommands, hidCommand{Sleep: defaultDelay})
+		}
+		commands = append(commands, lineCommands...)
+		prev = lineCommands
+	}
+
+	return commands, nil
+}
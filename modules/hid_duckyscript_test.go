@@ -0,0 +1,88 @@
+package modules
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHIDParseDuckyScript(t *testing.T) {
+	us := hidKeymaps["US"]
+	gb := hidKeymaps["GB"]
+
+	var units = []struct {
+		script   string
+		keymap   hidKeymap
+		expected []hidCommand
+	}{
+		{
+			"REM open the run dialog\nGUI r\n",
+			us,
+			[]hidCommand{{Key: hidKey{0x15, hidModGUI}}},
+		},
+		{
+			"CTRL-ALT DEL",
+			us,
+			[]hidCommand{{Key: hidKey{0x4c, hidModCtrl | hidModAlt}}},
+		},
+		{
+			"STRING aB@",
+			us,
+			[]hidCommand{{Key: hidKey{0x04, 0}}, {Key: hidKey{0x05, hidModShift}}, {Key: hidKey{0x1f, hidModShift}}},
+		},
+		{
+			"STRING @",
+			gb,
+			[]hidCommand{{Key: hidKey{0x34, hidModShift}}},
+		},
+		{
+			"DELAY 500\r\nENTER",
+			us,
+			[]hidCommand{{Sleep: 500 * time.Millisecond}, {Key: hidKey{0x28, 0}}},
+		},
+		{
+			"DEFAULT_DELAY 10\nSTRING a\nENTER",
+			us,
+			[]hidCommand{
+				{Key: hidKey{0x04, 0}}, {Sleep: 10 * time.Millisecond},
+				{Key: hidKey{0x28, 0}}, {Sleep: 10 * time.Millisecond},
+			},
+		},
+		{
+			"TAB\nREPEAT 2",
+			us,
+			[]hidCommand{{Key: hidKey{0x2b, 0}}, {Key: hidKey{0x2b, 0}}, {Key: hidKey{0x2b, 0}}},
+		},
+	}
+
+	for _, u := range units {
+		got, err := parseDuckyScript(u.script, u.keymap)
+		if err != nil {
+			t.Fatalf("unexpected error for '%s': %v", u.script, err)
+		} else if !reflect.DeepEqual(got, u.expected) {
+			t.Fatalf("expected '%v', got '%v'", u.expected, got)
+		}
+	}
+}
+
+func TestHIDParseDuckyScriptErrors(t *testing.T) {
+	var units = []struct {
+		script   string
+		expected string
+	}{
+		{"DELAY soon", "line 1: 'soon' is not a valid delay in milliseconds"},
+		{"REPEAT 3", "line 1: nothing to repeat"},
+		{"STRING a\nREPEAT 0", "line 2: '0' is not a valid number of repetitions"},
+		{"STRING £", "line 1: character '£' not found in the keyboard layout"},
+		{"CTRL FOO", "line 1: unknown key 'FOO'"},
+	}
+
+	for _, u := range units {
+		_, err := parseDuckyScript(u.script, hidKeymaps["US"])
+		if err == nil {
+			t.Fatalf("expected error for '%s'", u.script)
+		} else if err.Error() != u.expected {
+			t.Fatalf("expected '%s', got '%s'", u.expected, err)
+		}
+	}
+}
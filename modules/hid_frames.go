@@ -0,0 +1,154 @@
+package modules
+
+import (
+	"time"
+
+	"github.com/bettercap/bettercap/network"
+)
+
+// hidFrame is a payload to transmit followed by a pause, frames without
+// data are only pauses.
+type hidFrame struct {
+	Data  []byte
+	Delay time.Duration
+}
+
+// hidFrameBuilder encodes the keystrokes in the protocol of a dongle.
+type hidFrameBuilder interface {
+	BuildFrames(dev *network.HIDDevice, commands []hidCommand) []hidFrame
+}
+
+func hidBuilderFor(t network.HIDType) hidFrameBuilder {
+	switch t {
+	case network.HIDTypeLogitech:
+		return &hidLogitechBuilder{}
+	case network.HIDTypeAmazon:
+		return &hidAmazonBuilder{}
+	case network.HIDTypeMicrosoft:
+		return &hidMicrosoftBuilder{}
+	}
+	return nil
+}
+
+const (
+	hidLogitechDelay = 12 * time.Millisecond
+	// the hello frame sets the keepalive timeout to 1200ms
+	hidLogitechKeepAliveEvery = 500 * time.Millisecond
+	hidAmazonDelay            = 5 * time.Millisecond
+	hidMicrosoftDelay         = 5 * time.Millisecond
+)
+
+var (
+	hidLogitechHello     = []byte{0x00, 0x4f, 0x00, 0x04, 0xb0, 0x10, 0x00, 0x00, 0x00, 0xed}
+	hidLogitechKeepAlive = []byte{0x00, 0x40, 0x04, 0xb0, 0x0c}
+)
+
+// hidLogitechBuilder sends unencrypted keystrokes to Unifying dongles.
+type hidLogitechBuilder struct{}
+
+func hidLogitechChecksum(data []byte) {
+	sum := byte(0)
+	last := len(data) - 1
+	for _, b := range data[:last] {
+		sum += b
+	}
+	data[last] = -sum
+}
+
+func (b *hidLogitechBuilder) keyFrame(key hidKey) []byte {
+	data := []byte{0x00, 0xc1, key.Modifiers, key.Code, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	hidLogitechChecksum(data)
+	return data
+}
+
+func (b *hidLogitechBuilder) BuildFrames(dev *network.HIDDevice, commands []hidCommand) []hidFrame {
+	frames := []hidFrame{{Data: hidLogitechHello, Delay: hidLogitechDelay}}
+	for _, cmd := range commands {
+		if cmd.IsSleep() {
+			// keep the link alive during long pauses
+			for left := cmd.Sleep; left > 0; left -= hidLogitechKeepAliveEvery {
+				delay := left
+				if delay > hidLogitechKeepAliveEvery {
+					delay = hidLogitechKeepAliveEvery
+				}
+				frames = append(frames, hidFrame{Data: hidLogitechKeepAlive, Delay: delay})
+			}
+			continue
+		}
+
+		frames = append(frames,
+			hidFrame{Data: b.keyFrame(cmd.Key), Delay: hidLogitechDelay},
+			hidFrame{Data: b.keyFrame(hidKey{}), Delay: hidLogitechDelay})
+	}
+	return frames
+}
+
+// hidAmazonBuilder sends keystrokes to AmazonBasics dongles.
+type hidAmazonBuilder struct{}
+
+func (b *hidAmazonBuilder) keyFrame(key hidKey) []byte {
+	return []byte{0x0f, key.Modifiers, 0x00, key.Code, 0x00, 0x00, 0x00, 0x00, 0x00}
+}
+
+func (b *hidAmazonBuilder) BuildFrames(dev *network.HIDDevice, commands []hidCommand) []hidFrame {
+	frames := make([]hidFrame, 0)
+	for _, cmd := range commands {
+		if cmd.IsSleep() {
+			frames = append(frames, hidFrame{Delay: cmd.Sleep})
+			continue
+		}
+
+		frames = append(frames,
+			hidFrame{Data: b.keyFrame(cmd.Key), Delay: hidAmazonDelay},
+			hidFrame{Data: b.keyFrame(hidKey{}), Delay: hidAmazonDelay})
+	}
+	return frames
+}
+
+// hidMicrosoftBuilder sends keystrokes to Microsoft dongles, XOR'ing them
+// with the address for the ones which do so.
+type hidMicrosoftBuilder struct {
+	seq uint16
+}
+
+func (b *hidMicrosoftBuilder) keyFrame(dev *network.HIDDevice, key hidKey) []byte {
+	data := []byte{
+		0x08, 0x90, 0x01, 0x01,
+		byte(b.seq & 0xff), byte(b.seq >> 8),
+		0x43, key.Modifiers, 0x00, key.Code,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00,
+	}
+	b.seq++
+
+	// the checksum is the negated XOR of every other byte
+	sum := byte(0)
+	last := len(data) - 1
+	for _, c := range data[:last] {
+		sum ^= c
+	}
+	data[last] = ^sum
+
+	if dev.Encrypted {
+		data[0] = 0x0a
+		for i := 4; i < len(data); i++ {
+			data[i] ^= dev.Address[(i-4)%len(dev.Address)]
+		}
+	}
+	return data
+}
+
+func (b *hidMicrosoftBuilder) BuildFrames(dev *network.HIDDevice, commands []hidCommand) []hidFrame {
+	frames := make([]hidFrame, 0)
+	for _, cmd := range commands {
+		if cmd.IsSleep() {
+			frames = append(frames, hidFrame{Delay: cmd.Sleep})
+			continue
+		}
+
+		frames = append(frames,
+			hidFrame{Data: b.keyFrame(dev, cmd.Key), Delay: hidMicrosoftDelay},
+			hidFrame{Data: b.keyFrame(dev, hidKey{}), Delay: hidMicrosoftDelay})
+	}
+	return frames
+}
@@ -0,0 +1,82 @@
+package modules
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/bettercap/bettercap/network"
+)
+
+func TestHIDLogitechFrames(t *testing.T) {
+	dev := network.NewHIDDevice([]byte{0x01, 0x02, 0x03, 0x04, 0x05})
+	commands := []hidCommand{{Key: hidKey{0x04, hidModShift}}, {Sleep: 1200 * time.Millisecond}}
+
+	frames := hidBuilderFor(network.HIDTypeLogitech).BuildFrames(dev, commands)
+	// hello, key down, key up and three keepalives
+	if len(frames) != 6 {
+		t.Fatalf("expected 6 frames, got %d", len(frames))
+	} else if !bytes.Equal(frames[0].Data, hidLogitechHello) {
+		t.Fatalf("expected the hello frame, got %x", frames[0].Data)
+	}
+
+	expected := []byte{0x00, 0xc1, 0x02, 0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0x39}
+	if !bytes.Equal(frames[1].Data, expected) {
+		t.Fatalf("expected '%x', got '%x'", expected, frames[1].Data)
+	}
+
+	for _, frame := range frames {
+		sum := byte(0)
+		for _, b := range frame.Data {
+			sum += b
+		}
+		if sum != 0 {
+			t.Fatalf("wrong checksum for '%x'", frame.Data)
+		}
+	}
+
+	total := time.Duration(0)
+	for _, frame := range frames[3:] {
+		if !bytes.Equal(frame.Data, hidLogitechKeepAlive) {
+			t.Fatalf("expected a keepalive frame, got '%x'", frame.Data)
+		}
+		total += frame.Delay
+	}
+	if total != 1200*time.Millisecond {
+		t.Fatalf("expected a 1.2s pause, got %s", total)
+	}
+}
+
+func TestHIDMicrosoftFrames(t *testing.T) {
+	address := []byte{0xa1, 0xb2, 0xc3, 0xd4, 0xe5}
+	commands := []hidCommand{{Key: hidKey{0x04, 0}}}
+
+	dev := network.NewHIDDevice(address)
+	plain := hidBuilderFor(network.HIDTypeMicrosoft).BuildFrames(dev, commands)
+	if len(plain) != 2 {
+		t.Fatalf("expected 2 frames, got %d", len(plain))
+	}
+
+	for i, frame := range plain {
+		sum := byte(0)
+		for _, b := range frame.Data {
+			sum ^= b
+		}
+		if sum != 0xff {
+			t.Fatalf("wrong checksum for '%x'", frame.Data)
+		} else if int(frame.Data[4]) != i {
+			t.Fatalf("expected sequence %d, got %d", i, frame.Data[4])
+		}
+	}
+
+	dev.Encrypted = true
+	xored := hidBuilderFor(network.HIDTypeMicrosoft).BuildFrames(dev, commands)
+	if xored[0].Data[0] != 0x0a {
+		t.Fatalf("expected an encrypted frame, got '%x'", xored[0].Data)
+	}
+	for i := 4; i < len(plain[0].Data); i++ {
+		if got := xored[0].Data[i] ^ address[(i-4)%len(address)]; got != plain[0].Data[i] {
+			t.Fatalf("expected '%x', got '%x' at %d", plain[0].Data[i], got, i)
+		}
+	}
+}
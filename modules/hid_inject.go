@@ -0,0 +1,125 @@
+package modules
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/network"
+)
+
+// deviceType returns the protocol to inject with, hid.force.type if set.
+func (h *HIDRecon) deviceType(dev *network.HIDDevice) (network.HIDType, error) {
+	err, forced := h.StringParam("hid.force.type")
+	if err != nil {
+		return network.HIDTypeUnknown, err
+	} else if forced != "" {
+		return network.ParseHIDType(forced)
+	}
+
+	dev.Lock()
+	defer dev.Unlock()
+	return dev.Type, nil
+}
+
+// locate returns the channel the device is listening on, trying first the
+// ones it has been seen on.
+func (h *HIDRecon) locate(dev *network.HIDDevice) (int, error) {
+	channels := append(dev.Channels(), network.NRF24Channels...)
+	for _, channel := range channels {
+		if err := h.radio.SetChannel(channel); err != nil {
+			return 0, err
+		} else if h.radio.Ping() {
+			return channel, nil
+		}
+	}
+	return 0, fmt.Errorf("Device %s is not listening on any channel.", dev)
+}
+
+func (h *HIDRecon) transmit(dev *network.HIDDevice, frames []hidFrame) error {
+	h.radioLock.Lock()
+	defer h.radioLock.Unlock()
+	if h.radio == nil {
+		return fmt.Errorf("hid.recon has been stopped.")
+	}
+	// back to sniffing once done
+	defer h.radio.EnterPromiscuousMode(nil)
+
+	if err := h.radio.EnterSnifferMode(dev.Address); err != nil {
+		return err
+	}
+
+	channel, err := h.locate(dev)
+	if err != nil {
+		return err
+	}
+
+	log.Info("[%s] Injecting %d frames into %s on channel %d ...", core.Green("hid"), len(frames), dev, channel)
+
+	sent, missed := 0, 0
+	for _, frame := range frames {
+		if frame.Data != nil {
+			acked, err := h.radio.Transmit(frame.Data)
+			if err != nil {
+				return err
+			} else if !acked {
+				missed++
+			}
+			sent++
+		}
+		time.Sleep(frame.Delay)
+	}
+
+	if missed > 0 {
+		log.Warning("[%s] %d of %d frames have not been acknowledged by %s.", core.Green("hid"), missed, sent, dev)
+	} else {
+		log.Info("[%s] Injected %d frames into %s.", core.Green("hid"), sent, dev)
+	}
+	return nil
+}
+
+func (h *HIDRecon) inject(address string, layout string, filename string) error {
+	if !h.Running() {
+		return fmt.Errorf("hid.recon must be running to inject keystrokes.")
+	}
+
+	dev, found := h.Session.HID.Get(address)
+	if !found {
+		return fmt.Errorf("HID device with address %s not found.", address)
+	}
+
+	keymap, found := hidKeymaps[strings.ToUpper(layout)]
+	if !found {
+		return fmt.Errorf("Keyboard layout '%s' not supported, use one of %s.", layout, strings.Join(hidLayouts(), ", "))
+	}
+
+	devType, err := h.deviceType(dev)
+	if err != nil {
+		return err
+	}
+
+	builder := hidBuilderFor(devType)
+	if builder == nil {
+		return fmt.Errorf("The type of %s is still unknown, wait for more payloads or set hid.force.type.", dev)
+	}
+
+	path, err := core.ExpandPath(filename)
+	if err != nil {
+		return err
+	}
+
+	script, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	commands, err := parseDuckyScript(string(script), keymap)
+	if err != nil {
+		return fmt.Errorf("%s: %s", filename, err)
+	}
+
+	return h.transmit(dev, builder.BuildFrames(dev, commands))
+}
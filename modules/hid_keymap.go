@@ -0,0 +1,152 @@
+package modules
+
+import (
+	"sort"
+)
+
+// HID keyboard modifiers
+const (
+	hidModCtrl  = 0x01
+	hidModShift = 0x02
+	hidModAlt   = 0x04
+	hidModGUI   = 0x08
+)
+
+// hidKey is a HID usage code with its modifiers.
+type hidKey struct {
+	Code      byte
+	Modifiers byte
+}
+
+// hidKeymap maps the characters of a keyboard layout to their keys.
+type hidKeymap map[rune]hidKey
+
+// keys which don't depend on the layout
+var hidSpecialKeys = map[string]byte{
+	"ENTER":       0x28,
+	"ESC":         0x29,
+	"ESCAPE":      0x29,
+	"BACKSPACE":   0x2a,
+	"TAB":         0x2b,
+	"SPACE":       0x2c,
+	"CAPSLOCK":    0x39,
+	"F1":          0x3a,
+	"F2":          0x3b,
+	"F3":          0x3c,
+	"F4":          0x3d,
+	"F5":          0x3e,
+	"F6":          0x3f,
+	"F7":          0x40,
+	"F8":          0x41,
+	"F9":          0x42,
+	"F10":         0x43,
+	"F11":         0x44,
+	"F12":         0x45,
+	"PRINTSCREEN": 0x46,
+	"SCROLLLOCK":  0x47,
+	"PAUSE":       0x48,
+	"BREAK":       0x48,
+	"INSERT":      0x49,
+	"HOME":        0x4a,
+	"PAGEUP":      0x4b,
+	"DELETE":      0x4c,
+	"DEL":         0x4c,
+	"END":         0x4d,
+	"PAGEDOWN":    0x4e,
+	"RIGHT":       0x4f,
+	"RIGHTARROW":  0x4f,
+	"LEFT":        0x50,
+	"LEFTARROW":   0x50,
+	"DOWN":        0x51,
+	"DOWNARROW":   0x51,
+	"UP":          0x52,
+	"UPARROW":     0x52,
+	"NUMLOCK":     0x53,
+	"MENU":        0x65,
+	"APP":         0x65,
+}
+
+var hidModifiers = map[string]byte{
+	"CTRL":    hidModCtrl,
+	"CONTROL": hidModCtrl,
+	"SHIFT":   hidModShift,
+	"ALT":     hidModAlt,
+	"GUI":     hidModGUI,
+	"WINDOWS": hidModGUI,
+	"COMMAND": hidModGUI,
+}
+
+// the characters shared by the US and GB layouts
+func hidBaseKeymap() hidKeymap {
+	m := hidKeymap{
+		' ':  {0x2c, 0},
+		'\n': {0x28, 0},
+		'\t': {0x2b, 0},
+	}
+
+	for i := 0; i < 26; i++ {
+		m[rune('a'+i)] = hidKey{byte(0x04 + i), 0}
+		m[rune('A'+i)] = hidKey{byte(0x04 + i), hidModShift}
+	}
+
+	digits := "1234567890"
+	for i, c := range digits {
+		m[c] = hidKey{byte(0x1e + i), 0}
+	}
+
+	for c, code := range map[rune]byte{
+		'-': 0x2d, '=': 0x2e, '[': 0x2f, ']': 0x30, ';': 0x33,
+		'\'': 0x34, '`': 0x35, ',': 0x36, '.': 0x37, '/': 0x38,
+	} {
+		m[c] = hidKey{code, 0}
+	}
+
+	for c, code := range map[rune]byte{
+		'!': 0x1e, '$': 0x21, '%': 0x22, '^': 0x23, '&': 0x24, '*': 0x25,
+		'(': 0x26, ')': 0x27, '_': 0x2d, '+': 0x2e, '{': 0x2f, '}': 0x30,
+		':': 0x33, '<': 0x36, '>': 0x37, '?': 0x38,
+	} {
+		m[c] = hidKey{code, hidModShift}
+	}
+
+	return m
+}
+
+func hidUSKeymap() hidKeymap {
+	m := hidBaseKeymap()
+	m['\\'] = hidKey{0x31, 0}
+	m['|'] = hidKey{0x31, hidModShift}
+	m['@'] = hidKey{0x1f, hidModShift}
+	m['#'] = hidKey{0x20, hidModShift}
+	m['"'] = hidKey{0x34, hidModShift}
+	m['~'] = hidKey{0x35, hidModShift}
+	return m
+}
+
+func hidGBKeymap() hidKeymap {
+	m := hidBaseKeymap()
+	// the non-US backslash and hash keys
+	m['\\'] = hidKey{0x64, 0}
+	m['|'] = hidKey{0x64, hidModShift}
+	m['#'] = hidKey{0x32, 0}
+	m['~'] = hidKey{0x32, hidModShift}
+	m['"'] = hidKey{0x1f, hidModShift}
+	m['£'] = hidKey{0x20, hidModShift}
+	m['@'] = hidKey{0x34, hidModShift}
+	m['¬'] = hidKey{0x35, hidModShift}
+	return m
+}
+
+var hidKeymaps = map[string]hidKeymap{
+	"US": hidUSKeymap(),
+	"GB": hidGBKeymap(),
+}
+
+func hidLayouts() []string {
+	layouts := make([]string, 0, len(hidKeymaps))
+	for name := range hidKeymaps {
+		layouts = append(layouts, name)
+	}
+	sort.Strings(layouts)
+	return layouts
+}
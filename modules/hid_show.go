@@ -0,0 +1,79 @@
+package modules
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/network"
+)
+
+var (
+	hidAliveInterval   = time.Duration(5) * time.Second
+	hidPresentInterval = time.Duration(30) * time.Second
+)
+
+func (h *HIDRecon) getRow(dev *network.HIDDevice) []string {
+	address := dev.String()
+
+	dev.Lock()
+	devType := dev.Type
+	encrypted := dev.Encrypted
+	sinceSeen := time.Since(dev.LastSeen)
+	lastSeen := dev.LastSeen.Format("15:04:05")
+	dev.Unlock()
+
+	if sinceSeen <= hidAliveInterval {
+		lastSeen = core.Bold(lastSeen)
+	} else if sinceSeen > hidPresentInterval {
+		lastSeen = core.Dim(lastSeen)
+		address = core.Dim(address)
+	}
+
+	name := devType.String()
+	if devType == network.HIDTypeUnknown {
+		name = core.Dim(name)
+	} else if encrypted {
+		name = fmt.Sprintf("%s (%s)", name, core.Yellow("xor"))
+	} else {
+		name = core.Green(name)
+	}
+
+	channels := make([]string, 0)
+	for _, ch := range dev.Channels() {
+		channels = append(channels, strconv.Itoa(ch))
+	}
+
+	return []string{
+		address,
+		name,
+		strings.Join(channels, ", "),
+		strconv.FormatUint(dev.Payloads(), 10),
+		lastSeen,
+	}
+}
+
+func (h *HIDRecon) Show() error {
+	devices := h.Session.HID.Devices()
+
+	sort.Sort(ByHIDAddressSorter(devices))
+
+	rows := make([][]string, 0)
+	for _, dev := range devices {
+		rows = append(rows, h.getRow(dev))
+	}
+	nrows := len(rows)
+
+	columns := []string{"Address", "Type", "Channels", "Payloads", "Last Seen"}
+
+	if nrows > 0 {
+		core.AsTable(os.Stdout, columns, rows)
+	}
+
+	h.Session.Refresh()
+	return nil
+}
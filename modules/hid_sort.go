@@ -0,0 +1,15 @@
+package modules
+
+import (
+	"bytes"
+
+	"github.com/bettercap/bettercap/network"
+)
+
+type ByHIDAddressSorter []*network.HIDDevice
+
+func (a ByHIDAddressSorter) Len() int      { return len(a) }
+func (a ByHIDAddressSorter) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a ByHIDAddressSorter) Less(i, j int) bool {
+	return bytes.Compare(a[i].Address, a[j].Address) < 0
+}
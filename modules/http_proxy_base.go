@@ -13,6 +13,8 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bettercap/bettercap/core"
@@ -31,6 +33,9 @@ const (
 )
 
 type HTTPProxy struct {
+	// first for the alignment 64 bit atomic operations need on 32 bit platforms
+	requests uint64
+
 	Name        string
 	Address     string
 	Server      *http.Server
@@ -45,7 +50,17 @@ type HTTPProxy struct {
 	isRunning   bool
 	stripper    *SSLStripper
 	sniListener net.Listener
+	sniLog      *sniLogger
+	recorder    *requestRecorder
+	tunneled    []string
+	skipped     []string
+	pinning     *pinningTracker
+	upstream    *upstreamProxy
+	bandwidth   int
 	sess        *session.Session
+
+	// recorder is read by every request and replaced by Configure and Stop
+	recorderLock sync.RWMutex
 }
 
 func stripPort(s string) string {
@@ -62,6 +77,7 @@ func NewHTTPProxy(s *session.Session) *HTTPProxy {
 		Proxy:    goproxy.NewProxyHttpServer(),
 		sess:     s,
 		stripper: NewSSLStripper(s, false),
+		pinning:  newPinningTracker(),
 		isTLS:    false,
 		Server:   nil,
 	}
@@ -235,9 +251,37 @@ func (p *HTTPProxy) ConfigureTLS(address string, proxyPort int, httpPort int, sc
 	return nil
 }
 
+// ConfigureBandwidth limits each direction of every client connection
+// to rate bytes per second, 0 means unlimited.
+func (p *HTTPProxy) ConfigureBandwidth(rate int) error {
+	if rate < 0 {
+		return fmt.Errorf("%s bandwidth can't be negative.", p.Name)
+	} else if rate > 0 {
+		log.Info("(%s) throttling connections to %d bytes/s", core.Green(p.Name), rate)
+	}
+	p.bandwidth = rate
+	return nil
+}
+
+// ConfigureStripper sets the sslstrip replacement rules and the file the
+// stripped hosts are saved to when the proxy stops, empty to not save them.
+func (p *HTTPProxy) ConfigureStripper(rules []string, stateFile string) (err error) {
+	if stateFile != "" {
+		if stateFile, err = core.ExpandPath(stateFile); err != nil {
+			return err
+		}
+	}
+	return p.stripper.Configure(rules, stateFile)
+}
+
 func (p *HTTPProxy) httpWorker() error {
+	ln, err := net.Listen("tcp", p.Server.Addr)
+	if err != nil {
+		return err
+	}
+
 	p.isRunning = true
-	return p.Server.ListenAndServe()
+	return p.Server.Serve(newThrottledListener(ln, p.bandwidth))
 }
 
 type dumbResponseWriter struct {
@@ -280,6 +324,7 @@ func (p *HTTPProxy) httpsWorker() error {
 			continue
 		}
 
+		c = newThrottledConn(c, p.bandwidth)
 		go func(c net.Conn) {
 			now := time.Now()
 			c.SetReadDeadline(now.Add(httpReadTimeout))
@@ -297,7 +342,21 @@ func (p *HTTPProxy) httpsWorker() error {
 				return
 			}
 
-			log.Debug("Got new SNI from %s for %s", core.Bold(stripPort(c.RemoteAddr().String())), core.Yellow(hostname))
+			client := stripPort(c.RemoteAddr().String())
+			log.Debug("Got new SNI from %s for %s", core.Bold(client), core.Yellow(hostname))
+
+			if sniMatches(p.tunneled, hostname) || sniSkipped(p.skipped, hostname) {
+				p.onSNI(client, hostname, true)
+				p.tunnel(tlsConn, hostname)
+				return
+			}
+			p.onSNI(client, hostname, false)
+
+			// the requests of the client carry its address, telling us
+			// it accepted our certificate
+			id := c.RemoteAddr().String()
+			p.pinning.Begin(id, client, hostname)
+			conn := &pinningConn{Conn: tlsConn, onEnd: func() { p.onPinningEnd(id) }}
 
 			req := &http.Request{
 				Method: "CONNECT",
@@ -305,10 +364,11 @@ func (p *HTTPProxy) httpsWorker() error {
 					Opaque: hostname,
 					Host:   net.JoinHostPort(hostname, "443"),
 				},
-				Host:   hostname,
-				Header: make(http.Header),
+				Host:       hostname,
+				Header:     make(http.Header),
+				RemoteAddr: id,
 			}
-			resp := dumbResponseWriter{tlsConn}
+			resp := dumbResponseWriter{conn}
 			p.Proxy.ServeHTTP(resp, req)
 		}(c)
 	}
@@ -350,9 +410,19 @@ func (p *HTTPProxy) Stop() error {
 
 	p.sess.UnkCmdCallback = nil
 
+	if err := p.stripper.Save(); err != nil {
+		log.Error("Could not save the stripped hosts: %s", err)
+	}
+
+	p.setRecorder(nil)
+
 	if p.isTLS {
 		p.isRunning = false
 		p.sniListener.Close()
+		if p.sniLog != nil {
+			p.sniLog.Close()
+			p.sniLog = nil
+		}
 		return nil
 	} else {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -360,3 +430,10 @@ func (p *HTTPProxy) Stop() error {
 		return p.Server.Shutdown(ctx)
 	}
 }
+
+// Metrics publishes the number of requests proxied so far.
+func (p *HTTPProxy) Metrics() []session.Metric {
+	return []session.Metric{
+		session.NewCounter("bettercap_proxy_requests_total", "Number of requests the proxy received.", atomic.LoadUint64(&p.requests), nil),
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"sync/atomic"
 
 	"github.com/bettercap/bettercap/core"
 	"github.com/bettercap/bettercap/log"
@@ -21,9 +22,20 @@ func (p *HTTPProxy) fixRequestHeaders(req *http.Request) {
 
 func (p *HTTPProxy) onRequestFilter(req *http.Request, ctx *goproxy.ProxyCtx) (*http.Request, *http.Response) {
 	log.Debug("(%s) < %s %s %s%s", core.Green(p.Name), req.RemoteAddr, req.Method, req.Host, req.URL.Path)
+	atomic.AddUint64(&p.requests, 1)
+
+	if p.isTLS {
+		p.pinning.Intercepted(req.RemoteAddr)
+	}
 
 	p.fixRequestHeaders(req)
 
+	if res := p.dohResponse(req); res != nil {
+		return req, res
+	}
+
+	ctx.RoundTripper = goproxy.RoundTripperFunc(p.roundTrip)
+
 	redir := p.stripper.Preprocess(req, ctx)
 	if redir != nil {
 		// we need to redirect the user in order to make
@@ -1,7 +1,11 @@
 package modules
 
 import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"net"
+	"sort"
 	"sync"
 
 	"github.com/bettercap/bettercap/log"
@@ -60,3 +64,60 @@ func (t *HostTracker) Unstrip(stripped string) *Host {
 	}
 	return nil
 }
+
+// List returns the stripped hosts with their originals, sorted.
+func (t *HostTracker) List() ([]string, map[string]*Host) {
+	t.RLock()
+	defer t.RUnlock()
+
+	stripped := make([]string, 0, len(t.hosts))
+	hosts := make(map[string]*Host, len(t.hosts))
+	for name, host := range t.hosts {
+		stripped = append(stripped, name)
+		hosts[name] = host
+	}
+	sort.Strings(stripped)
+	return stripped, hosts
+}
+
+func (t *HostTracker) Clear() {
+	t.Lock()
+	defer t.Unlock()
+	t.hosts = make(map[string]*Host)
+}
+
+// Save writes the stripped hosts and their originals to fileName as a
+// JSON object.
+func (t *HostTracker) Save(fileName string) error {
+	t.RLock()
+	state := make(map[string]string, len(t.hosts))
+	for stripped, host := range t.hosts {
+		state[stripped] = host.Hostname
+	}
+	t.RUnlock()
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fileName, data, 0644)
+}
+
+// Load tracks the hosts saved in fileName, resolving them again, and
+// returns how many there are.
+func (t *HostTracker) Load(fileName string) (int, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return 0, err
+	}
+
+	state := make(map[string]string)
+	if err := json.Unmarshal(data, &state); err != nil {
+		return 0, fmt.Errorf("%s: %s", fileName, err)
+	}
+
+	for stripped, original := range state {
+		t.Track(original, stripped)
+	}
+	return len(state), nil
+}
@@ -0,0 +1,94 @@
+package modules
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bettercap/bettercap/core"
+)
+
+// the replacements sslstrip has always used, the hosts matching none of
+// them get a wwww. prefix
+const defaultStripRules = "www.:wwwww.,webmail.:wwebmail.,mail.:wmail.,m.:wmobile.,:wwww."
+
+// stripRule replaces the first label of the hosts if From ends with a
+// dot (www.), their end if it starts with one (.com) or prefixes them
+// with To if From is empty.
+type stripRule struct {
+	From string
+	To   string
+}
+
+func parseStripRules(list []string) ([]stripRule, error) {
+	rules := make([]stripRule, 0, len(list))
+	for _, item := range list {
+		parts := strings.SplitN(item, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Invalid sslstrip rule '%s', expected FROM:TO.", item)
+		}
+
+		rule := stripRule{
+			From: strings.ToLower(strings.TrimSpace(parts[0])),
+			To:   strings.ToLower(strings.TrimSpace(parts[1])),
+		}
+		if rule.To == "" || rule.To == rule.From {
+			return nil, fmt.Errorf("Invalid sslstrip rule '%s', the replacement must differ from what it replaces.", item)
+		} else if rule.From == "." {
+			return nil, fmt.Errorf("Invalid sslstrip rule '%s'.", item)
+		} else if rule.From != "" && !strings.HasPrefix(rule.From, ".") && !strings.HasSuffix(rule.From, ".") {
+			return nil, fmt.Errorf("Invalid sslstrip rule '%s', FROM must be a label followed by a dot (www.) or a dot followed by a suffix (.com).", item)
+		}
+
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// stripHost returns the host changed by the first matching rule, the
+// host itself if none matches.
+func stripHost(rules []stripRule, host string) string {
+	lower := strings.ToLower(host)
+	for _, rule := range rules {
+		if rule.From == "" {
+			return rule.To + host
+		} else if strings.HasSuffix(rule.From, ".") && strings.HasPrefix(lower, rule.From) && len(lower) > len(rule.From) {
+			return rule.To + host[len(rule.From):]
+		} else if strings.HasPrefix(rule.From, ".") && strings.HasSuffix(lower, rule.From) && len(lower) > len(rule.From) {
+			return host[:len(host)-len(rule.From)] + rule.To
+		}
+	}
+	return host
+}
+
+// ShowStripped prints the hosts stripped so far and what they resolve to.
+func (s *SSLStripper) ShowStripped() error {
+	stripped, hosts := s.hosts.List()
+	if len(stripped) == 0 {
+		fmt.Println("No stripped hosts.")
+		return nil
+	}
+
+	rows := make([][]string, 0, len(stripped))
+	for _, name := range stripped {
+		host := hosts[name]
+		host.Resolved.Wait()
+
+		address := core.Dim("unresolved")
+		if host.Address != nil {
+			address = host.Address.String()
+		}
+		rows = append(rows, []string{core.Yellow(name), host.Hostname, address})
+	}
+
+	fmt.Println()
+	core.AsTable(os.Stdout, []string{"Stripped", "Original", "Address"}, rows)
+	fmt.Println()
+	return nil
+}
+
+// ClearStripped forgets the stripped hosts, saved ones included.
+func (s *SSLStripper) ClearStripped() error {
+	s.hosts.Clear()
+	return s.Save()
+}
@@ -0,0 +1,111 @@
+package modules
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bettercap/bettercap/session"
+)
+
+func TestSSLStripRules(t *testing.T) {
+	for _, bad := range []string{"www", "www.:", "www.:www.", "com:net", ".:x", "mail:wmail."} {
+		if _, err := parseStripRules([]string{bad}); err == nil {
+			t.Fatalf("expected error for '%s'", bad)
+		}
+	}
+
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	strip := NewSSLStripper(s.Session, false)
+
+	var units = []struct {
+		url string
+		exp string
+	}{
+		{"https://www.example.com/login", "http://wwwww.example.com/login"},
+		{"https://mail.example.com", "http://wmail.example.com"},
+		{"https://m.example.com:8443/", "http://wmobile.example.com:8443/"},
+		{"https://mobile.example.com?a=b", "http://wwww.mobile.example.com?a=b"},
+		{"https://WWW.Example.com", "http://wwwww.Example.com"},
+	}
+	for _, u := range units {
+		if got := strip.processURL(u.url); got != u.exp {
+			t.Fatalf("expected '%s', got '%s'", u.exp, got)
+		}
+	}
+
+	// custom lookalike tlds, no fallback
+	if err := strip.Configure([]string{".com:.corn", "accounts.:acc0unts."}, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var custom = []struct {
+		url string
+		exp string
+	}{
+		{"https://www.example.com/login", "http://www.example.corn/login"},
+		{"https://accounts.example.org", "http://acc0unts.example.org"},
+		{"https://example.net", "http://example.net"},
+	}
+	for _, u := range custom {
+		if got := strip.processURL(u.url); got != u.exp {
+			t.Fatalf("expected '%s', got '%s'", u.exp, got)
+		}
+	}
+}
+
+func TestSSLStripState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bettercap-sslstrip")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	stateFile := filepath.Join(dir, "state.json")
+
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	rules := strings.Split(defaultStripRules, ",")
+	strip := NewSSLStripper(s.Session, false)
+	if err := strip.Configure(rules, stateFile); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := strip.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if _, err := os.Stat(stateFile); err == nil {
+		t.Fatal("expected nothing to be saved without stripped hosts")
+	}
+
+	strip.hosts.Track("localhost", "wwww.localhost")
+	if err := strip.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	restored := NewSSLStripper(s.Session, false)
+	if err := restored.Configure(rules, stateFile); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if host := restored.hosts.Unstrip("wwww.localhost"); host == nil || host.Hostname != "localhost" {
+		t.Fatalf("expected 'localhost', got '%v'", host)
+	}
+
+	if err := restored.ClearStripped(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if stripped, _ := restored.hosts.List(); len(stripped) != 0 {
+		t.Fatalf("expected no stripped hosts, got %v", stripped)
+	}
+
+	again := NewSSLStripper(s.Session, false)
+	if err := again.Configure(rules, stateFile); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if stripped, _ := again.hosts.List(); len(stripped) != 0 {
+		t.Fatalf("expected the cleared state to be saved, got %v", stripped)
+	}
+}
@@ -23,12 +23,6 @@ import (
 var (
 	maxRedirs        = 5
 	httpsLinksParser = regexp.MustCompile(`https://[^"'/]+`)
-	subdomains       = map[string]string{
-		"www":     "wwwww",
-		"webmail": "wwebmail",
-		"mail":    "wmail",
-		"m":       "wmobile",
-	}
 )
 
 type SSLStripper struct {
@@ -39,9 +33,12 @@ type SSLStripper struct {
 	handle        *pcap.Handle
 	pktSourceChan chan gopacket.Packet
 	redirs        map[string]int
+	rules         []stripRule
+	stateFile     string
 }
 
 func NewSSLStripper(s *session.Session, enabled bool) *SSLStripper {
+	rules, _ := parseStripRules(strings.Split(defaultStripRules, ","))
 	strip := &SSLStripper{
 		enabled: false,
 		cookies: NewCookieTracker(),
@@ -49,11 +46,40 @@ func NewSSLStripper(s *session.Session, enabled bool) *SSLStripper {
 		session: s,
 		handle:  nil,
 		redirs:  make(map[string]int),
+		rules:   rules,
 	}
 	strip.Enable(enabled)
 	return strip
 }
 
+// Configure sets the rules the hosts are stripped with and the file the
+// stripped hosts are kept in across sessions, loading the ones saved.
+func (s *SSLStripper) Configure(rules []string, stateFile string) (err error) {
+	if s.rules, err = parseStripRules(rules); err != nil {
+		return err
+	} else if s.stateFile = stateFile; stateFile == "" || !core.Exists(stateFile) {
+		return nil
+	}
+
+	n, err := s.hosts.Load(stateFile)
+	if err != nil {
+		return err
+	} else if n > 0 {
+		log.Debug("[%s] Loaded %d stripped hosts from %s", core.Green("sslstrip"), n, stateFile)
+	}
+	return nil
+}
+
+// Save writes the stripped hosts to the state file, if any.
+func (s *SSLStripper) Save() error {
+	if s.stateFile == "" {
+		return nil
+	} else if stripped, _ := s.hosts.List(); len(stripped) == 0 && !core.Exists(s.stateFile) {
+		return nil
+	}
+	return s.hosts.Save(s.stateFile)
+}
+
 func (s *SSLStripper) Enabled() bool {
 	return s.enabled
 }
@@ -208,23 +234,21 @@ func (s *SSLStripper) processURL(url string) string {
 	// first we remove the https schema
 	url = strings.Replace(url, "https://", "http://", 1)
 
-	// search for a known subdomain and replace it
-	found := false
-	for sub, repl := range subdomains {
-		what := fmt.Sprintf("://%s", sub)
-		with := fmt.Sprintf("://%s", repl)
-		if strings.Contains(url, what) {
-			url = strings.Replace(url, what, with, 1)
-			found = true
-			break
-		}
+	start := strings.Index(url, "://")
+	if start == -1 {
+		return url
 	}
-	// fallback
-	if !found {
-		url = strings.Replace(url, "://", "://wwww.", 1)
+	start += 3
+
+	// then we replace the host with a lookalike by the rules
+	end := strings.IndexAny(url[start:], ":/?#")
+	if end == -1 {
+		end = len(url)
+	} else {
+		end += start
 	}
 
-	return url
+	return url[:start] + stripHost(s.rules, url[start:end]) + url[end:]
 }
 
 // sslstrip preprocessing, takes care of:
@@ -0,0 +1,191 @@
+package modules
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+)
+
+// bodies bigger than this are not recorded
+const httpRecordMaxBody = 1024 * 1024
+
+// HTTPRecord is a request seen by the proxy and the response the real
+// server sent back, one per line in the record file.
+type HTTPRecord struct {
+	Time       time.Time   `json:"time"`
+	Client     string      `json:"client"`
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	Headers    http.Header `json:"headers"`
+	Body       string      `json:"body"`
+	Status     int         `json:"status"`
+	ResHeaders http.Header `json:"response_headers"`
+	ResBody    string      `json:"response_body"`
+}
+
+type requestRecorder struct {
+	sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+}
+
+func newRequestRecorder(fileName string) (*requestRecorder, error) {
+	fileName, err := core.ExpandPath(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &requestRecorder{file: file, encoder: json.NewEncoder(file)}, nil
+}
+
+// Record saves the request, or does nothing once the recorder is closed.
+func (r *requestRecorder) Record(rec HTTPRecord) error {
+	r.Lock()
+	defer r.Unlock()
+	if r.encoder == nil {
+		return nil
+	}
+	return r.encoder.Encode(rec)
+}
+
+func (r *requestRecorder) Close() error {
+	r.Lock()
+	defer r.Unlock()
+	r.encoder = nil
+	return r.file.Close()
+}
+
+// LoadHTTPRecords reads every request saved in a record file.
+func LoadHTTPRecords(fileName string) ([]HTTPRecord, error) {
+	fileName, err := core.ExpandPath(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	records := make([]HTTPRecord, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 4096), 4*httpRecordMaxBody)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var rec HTTPRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("%s:%d: %s", fileName, line, err)
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
+
+// peekBody returns up to httpRecordMaxBody bytes of the body, leaving
+// it readable from the beginning, and whether it was read entirely.
+func peekBody(body io.ReadCloser) (io.ReadCloser, []byte, bool) {
+	if body == nil {
+		return body, nil, true
+	}
+
+	raw, err := ioutil.ReadAll(io.LimitReader(body, httpRecordMaxBody+1))
+	rest := io.MultiReader(bytes.NewReader(raw), body)
+	restored := struct {
+		io.Reader
+		io.Closer
+	}{rest, body}
+
+	if err != nil || len(raw) > httpRecordMaxBody {
+		return restored, nil, false
+	}
+	return restored, raw, true
+}
+
+// ConfigureRecorder sets the file to append every proxied request and
+// its response to, an empty name disables it.
+func (p *HTTPProxy) ConfigureRecorder(fileName string) error {
+	if fileName == "" {
+		p.setRecorder(nil)
+		return nil
+	}
+
+	recorder, err := newRequestRecorder(fileName)
+	if err != nil {
+		p.setRecorder(nil)
+		return err
+	}
+	p.setRecorder(recorder)
+	log.Debug("Recording requests to %s", fileName)
+	return nil
+}
+
+// setRecorder replaces the recorder and closes the previous one, the
+// requests still in flight are not recorded.
+func (p *HTTPProxy) setRecorder(recorder *requestRecorder) {
+	p.recorderLock.Lock()
+	old := p.recorder
+	p.recorder = recorder
+	p.recorderLock.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+}
+
+func (p *HTTPProxy) getRecorder() *requestRecorder {
+	p.recorderLock.RLock()
+	defer p.recorderLock.RUnlock()
+	return p.recorder
+}
+
+// record saves a request as it was sent to the real server along
+// with its response.
+func (p *HTTPProxy) record(req *http.Request, reqBody []byte, res *http.Response) {
+	var resBody []byte
+	var complete bool
+
+	recorder := p.getRecorder()
+	if recorder == nil {
+		return
+	}
+
+	if res.Body, resBody, complete = peekBody(res.Body); !complete {
+		log.Debug("(%s) not recording %s %s%s, response too big", core.Green(p.Name), req.Method, req.Host, req.URL.Path)
+		return
+	}
+
+	rec := HTTPRecord{
+		Time:       time.Now(),
+		Client:     stripPort(req.RemoteAddr),
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		Headers:    req.Header,
+		Body:       string(reqBody),
+		Status:     res.StatusCode,
+		ResHeaders: res.Header,
+		ResBody:    string(resBody),
+	}
+
+	if err := recorder.Record(rec); err != nil {
+		log.Warning("Error while recording %s %s: %s", req.Method, rec.URL, err)
+	}
+}
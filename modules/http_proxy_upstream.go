@@ -0,0 +1,255 @@
+package modules
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+
+	"github.com/elazarl/goproxy"
+)
+
+const upstreamDialTimeout = 10 * time.Second
+
+// upstreamError is returned when the connection to the upstream
+// proxy fails, as opposed to the connection to the origin server.
+type upstreamError struct {
+	err error
+}
+
+func (e upstreamError) Error() string {
+	return fmt.Sprintf("upstream proxy: %s", e.err)
+}
+
+func isUpstreamError(err error) bool {
+	switch e := err.(type) {
+	case upstreamError:
+		return true
+	case *url.Error:
+		return isUpstreamError(e.Err)
+	case *net.OpError:
+		// returned by http.Transport when it can't reach the proxy
+		return e.Op == "proxyconnect"
+	}
+	return false
+}
+
+// upstreamProxy chains the outbound connections of the proxy
+// through another HTTP(S) proxy, except for the bypassed hosts.
+type upstreamProxy struct {
+	URL    *url.URL
+	Bypass []string
+}
+
+func newUpstreamProxy(raw string, bypass []string) (*upstreamProxy, error) {
+	if raw = core.Trim(raw); raw == "" {
+		return nil, nil
+	} else if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	} else if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("Unsupported upstream proxy scheme '%s', use http or https.", u.Scheme)
+	} else if u.Hostname() == "" {
+		return nil, fmt.Errorf("Upstream proxy '%s' has no host.", raw)
+	}
+
+	if u.Port() == "" {
+		port := "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+		u.Host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	return &upstreamProxy{
+		URL:    u,
+		Bypass: bypass,
+	}, nil
+}
+
+// Bypassed follows the no_proxy conventions: '*' matches everything,
+// '.domain.com' and '*.domain.com' match the domain and its subdomains
+// and CIDRs match IP addresses.
+func (u *upstreamProxy) Bypassed(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	ip := net.ParseIP(host)
+
+	for _, entry := range u.Bypass {
+		entry = strings.ToLower(core.Trim(entry))
+		if entry == "" {
+			continue
+		} else if entry == "*" || entry == host {
+			return true
+		} else if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return true
+			}
+		} else if domain := strings.TrimPrefix(entry, "*"); strings.HasPrefix(domain, ".") {
+			if host == domain[1:] || strings.HasSuffix(host, domain) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (u *upstreamProxy) authorization() string {
+	if u.URL.User == nil {
+		return ""
+	}
+	password, _ := u.URL.User.Password()
+	auth := u.URL.User.Username() + ":" + password
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(auth))
+}
+
+// Proxy selects the upstream for plain HTTP requests, HTTPS ones
+// are tunneled with a CONNECT by DialTLS.
+func (u *upstreamProxy) Proxy(req *http.Request) (*url.URL, error) {
+	if req.URL.Scheme == "https" || u.Bypassed(req.URL.Host) {
+		return nil, nil
+	}
+	return u.URL, nil
+}
+
+// Dial opens a CONNECT tunnel to addr through the upstream proxy.
+func (u *upstreamProxy) Dial(network, addr string) (net.Conn, error) {
+	if u.Bypassed(addr) {
+		return net.DialTimeout(network, addr, upstreamDialTimeout)
+	}
+
+	conn, err := net.DialTimeout(network, u.URL.Host, upstreamDialTimeout)
+	if err != nil {
+		return nil, upstreamError{err}
+	}
+
+	if u.URL.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{
+			InsecureSkipVerify: true,
+			ServerName:         u.URL.Hostname(),
+		})
+		if err = tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return nil, upstreamError{err}
+		}
+		conn = tlsConn
+	}
+
+	req := &http.Request{
+		Method: "CONNECT",
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if auth := u.authorization(); auth != "" {
+		req.Header.Set("Proxy-Authorization", auth)
+	}
+
+	conn.SetDeadline(time.Now().Add(upstreamDialTimeout))
+	if err = req.Write(conn); err != nil {
+		conn.Close()
+		return nil, upstreamError{err}
+	}
+
+	// the origin won't talk before we do, so it's safe to
+	// discard the buffered reader once the response is read
+	res, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		conn.Close()
+		return nil, upstreamError{err}
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, upstreamError{fmt.Errorf("CONNECT to %s refused with '%s'", addr, res.Status)}
+	}
+
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// DialTLS opens a TLS connection to the origin through the upstream proxy.
+func (u *upstreamProxy) DialTLS(network, addr string) (net.Conn, error) {
+	conn, err := u.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	host, _, _ := net.SplitHostPort(addr)
+	tlsConn := tls.Client(conn, &tls.Config{
+		InsecureSkipVerify: true,
+		ServerName:         host,
+	})
+	if err = tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+func (p *HTTPProxy) ConfigureUpstream(upstream string, bypass []string) (err error) {
+	if p.upstream, err = newUpstreamProxy(upstream, bypass); err != nil {
+		return err
+	}
+
+	tr := &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		Proxy:           http.ProxyFromEnvironment,
+	}
+
+	if p.upstream != nil {
+		tr.Proxy = p.upstream.Proxy
+		tr.DialTLS = p.upstream.DialTLS
+		log.Info("(%s) chaining to upstream proxy %s://%s", core.Green(p.Name), p.upstream.URL.Scheme, p.upstream.URL.Host)
+	}
+
+	if p.Proxy.Tr != nil {
+		p.Proxy.Tr.CloseIdleConnections()
+	}
+	p.Proxy.Tr = tr
+
+	return nil
+}
+
+// dial connects to addr, through the upstream proxy if configured.
+func (p *HTTPProxy) dial(addr string) (net.Conn, error) {
+	if p.upstream != nil {
+		return p.upstream.Dial("tcp", addr)
+	}
+	return net.DialTimeout("tcp", addr, upstreamDialTimeout)
+}
+
+func (p *HTTPProxy) roundTrip(req *http.Request, ctx *goproxy.ProxyCtx) (*http.Response, error) {
+	var body []byte
+	recording := p.getRecorder() != nil
+	if recording {
+		req.Body, body, recording = peekBody(req.Body)
+	}
+
+	res, err := p.Proxy.Tr.RoundTrip(req)
+	if err == nil && recording {
+		p.record(req, body, res)
+	} else if err != nil {
+		if isUpstreamError(err) {
+			log.Warning("(%s) upstream proxy error for %s: %s", core.Green(p.Name), req.Host, err)
+		} else {
+			log.Debug("(%s) error while contacting %s: %s", core.Green(p.Name), req.Host, err)
+		}
+	}
+	return res, err
+}
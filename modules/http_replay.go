@@ -0,0 +1,434 @@
+package modules
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/session"
+)
+
+const (
+	httpReplayTimeout = 10 * time.Second
+	// above this many compared lines only the sizes are reported
+	httpReplayMaxDiff = 1000000
+)
+
+// {{NAME}} is replaced by the value of the NAME variable
+var httpReplayVarParser = regexp.MustCompile(`\{\{([^{}\s]+)\}\}`)
+
+type HTTPReplayResult struct {
+	Index      int
+	Method     string
+	URL        string
+	OrigStatus int
+	Status     int
+	OrigSize   int
+	Size       int
+	Diff       []string
+	Error      error
+}
+
+// HttpReplay sends requests recorded by http.proxy.record or
+// https.proxy.record to another host and compares the responses.
+type HttpReplay struct {
+	session.SessionModule
+	records  []HTTPRecord
+	selected []int
+	target   *url.URL
+	showDiff bool
+	client   *http.Client
+}
+
+func NewHttpReplay(s *session.Session) *HttpReplay {
+	r := &HttpReplay{
+		SessionModule: session.NewSessionModule("http.replay", s),
+		client: &http.Client{
+			Timeout: httpReplayTimeout,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+			// redirects are part of the response to compare
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+
+	r.AddParam(session.NewStringParameter("http.replay.file",
+		"",
+		"",
+		"File with the requests recorded by http.proxy.record or https.proxy.record."))
+
+	r.AddParam(session.NewStringParameter("http.replay.target",
+		"",
+		`^(https?://.+)?$`,
+		"Base URL (http://host:port/prefix) to send the requests to instead of their original host."))
+
+	r.AddParam(session.NewStringParameter("http.replay.select",
+		"",
+		`^([\d\s,\-]+)?$`,
+		"Comma separated list of request numbers and ranges (1,3-5) as shown by http.replay.list, empty for all of them."))
+
+	r.AddParam(session.NewBoolParameter("http.replay.show.diff",
+		"false",
+		"If true, print the lines of the response bodies which differ from the recorded ones."))
+
+	r.AddHandler(session.NewModuleHandler("http.replay.list", "",
+		"Show the requests in http.replay.file.",
+		func(args []string) error {
+			return r.Show()
+		}))
+
+	r.AddHandler(session.NewModuleHandler("http.replay on", "",
+		"Replay the selected requests against http.replay.target.",
+		func(args []string) error {
+			return r.Start()
+		}))
+
+	r.AddHandler(session.NewModuleHandler("http.replay off", "",
+		"Stop replaying requests.",
+		func(args []string) error {
+			return r.Stop()
+		}))
+
+	return r
+}
+
+func (r *HttpReplay) Name() string {
+	return "http.replay"
+}
+
+func (r *HttpReplay) Description() string {
+	return "Replay HTTP requests recorded by the proxies against a different host and compare the responses with the original ones."
+}
+
+func (r *HttpReplay) Author() string {
+	return "Simone Margaritelli <evilsocket@protonmail.com>"
+}
+
+func (r *HttpReplay) loadRecords() (err error) {
+	var fileName string
+
+	if err, fileName = r.StringParam("http.replay.file"); err != nil {
+		return err
+	} else if fileName == "" {
+		return fmt.Errorf("http.replay.file is empty, set it to a file written by http.proxy.record.")
+	} else if r.records, err = LoadHTTPRecords(fileName); err != nil {
+		return err
+	} else if len(r.records) == 0 {
+		return fmt.Errorf("No requests in %s.", fileName)
+	}
+	return nil
+}
+
+func (r *HttpReplay) Configure() (err error) {
+	var target string
+	var selection string
+
+	if r.Running() {
+		return session.ErrAlreadyStarted
+	} else if err = r.loadRecords(); err != nil {
+		return err
+	} else if err, target = r.StringParam("http.replay.target"); err != nil {
+		return err
+	} else if target == "" {
+		return fmt.Errorf("http.replay.target is empty, set it to the base URL to replay the requests against.")
+	} else if r.target, err = url.Parse(target); err != nil {
+		return err
+	} else if err, selection = r.StringParam("http.replay.select"); err != nil {
+		return err
+	} else if r.selected, err = parseReplaySelection(selection, len(r.records)); err != nil {
+		return err
+	} else if err, r.showDiff = r.BoolParam("http.replay.show.diff"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// parseReplaySelection returns the zero based indexes of the 1 based
+// numbers and ranges in the list, or every index if it's empty.
+func parseReplaySelection(list string, total int) ([]int, error) {
+	selected := make([]int, 0)
+	if strings.TrimSpace(list) == "" {
+		for i := 0; i < total; i++ {
+			selected = append(selected, i)
+		}
+		return selected, nil
+	}
+
+	for _, part := range strings.Split(list, ",") {
+		part = core.Trim(part)
+		if part == "" {
+			continue
+		}
+
+		bounds := strings.SplitN(part, "-", 2)
+		from, err := strconv.Atoi(core.Trim(bounds[0]))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid request number '%s'.", part)
+		}
+
+		to := from
+		if len(bounds) == 2 {
+			if to, err = strconv.Atoi(core.Trim(bounds[1])); err != nil {
+				return nil, fmt.Errorf("Invalid request range '%s'.", part)
+			}
+		}
+
+		if from < 1 || to > total || from > to {
+			return nil, fmt.Errorf("Request range '%s' is out of 1-%d.", part, total)
+		}
+
+		for i := from; i <= to; i++ {
+			selected = append(selected, i-1)
+		}
+	}
+	return selected, nil
+}
+
+func (r *HttpReplay) substitute(s string) (string, error) {
+	var err error
+	replaced := httpReplayVarParser.ReplaceAllStringFunc(s, func(token string) string {
+		name := httpReplayVarParser.FindStringSubmatch(token)[1]
+		if found, value := r.Session.Env.Get(name); found {
+			return value
+		} else if err == nil {
+			err = fmt.Errorf("Variable %s is not set.", name)
+		}
+		return token
+	})
+	return replaced, err
+}
+
+// request builds the recorded request with the target's scheme and
+// host, and the path prefixed by the target's one.
+func (r *HttpReplay) request(rec HTTPRecord) (*http.Request, error) {
+	raw, err := r.substitute(rec.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	u.Scheme = r.target.Scheme
+	u.Host = r.target.Host
+	u.Path = strings.TrimRight(r.target.Path, "/") + u.Path
+	u.RawPath = ""
+
+	body, err := r.substitute(rec.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(rec.Method, u.String(), strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	for name, values := range rec.Headers {
+		if name == "Content-Length" || name == "Host" {
+			continue
+		}
+		for _, value := range values {
+			if value, err = r.substitute(value); err != nil {
+				return nil, err
+			}
+			req.Header.Add(name, value)
+		}
+	}
+
+	return req, nil
+}
+
+func (r *HttpReplay) replay(index int) HTTPReplayResult {
+	rec := r.records[index]
+	result := HTTPReplayResult{
+		Index:      index + 1,
+		Method:     rec.Method,
+		URL:        rec.URL,
+		OrigStatus: rec.Status,
+		OrigSize:   len(rec.ResBody),
+	}
+
+	req, err := r.request(rec)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	result.URL = req.URL.String()
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	defer res.Body.Close()
+
+	raw, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	result.Status = res.StatusCode
+	result.Size = len(raw)
+	result.Diff = diffLines(rec.ResBody, string(raw))
+	return result
+}
+
+// diffLines returns the lines removed from a prefixed by '-' and the
+// ones added in b prefixed by '+', in order.
+func diffLines(a, b string) []string {
+	if a == b {
+		return nil
+	}
+
+	x := strings.Split(a, "\n")
+	y := strings.Split(b, "\n")
+	if len(x)*len(y) > httpReplayMaxDiff {
+		return []string{fmt.Sprintf("- (%d bytes)", len(a)), fmt.Sprintf("+ (%d bytes)", len(b))}
+	}
+
+	// longest common subsequence of the suffixes
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	diff := make([]string, 0)
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		if x[i] == y[j] {
+			i++
+			j++
+		} else if lcs[i+1][j] >= lcs[i][j+1] {
+			diff = append(diff, "- "+x[i])
+			i++
+		} else {
+			diff = append(diff, "+ "+y[j])
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		diff = append(diff, "- "+x[i])
+	}
+	for ; j < len(y); j++ {
+		diff = append(diff, "+ "+y[j])
+	}
+	return diff
+}
+
+func (r *HttpReplay) Show() error {
+	if err := r.loadRecords(); err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(r.records))
+	for i, rec := range r.records {
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", i+1),
+			rec.Time.Format("2006-01-02 15:04:05"),
+			rec.Client,
+			rec.Method,
+			rec.URL,
+			fmt.Sprintf("%d", rec.Status),
+		})
+	}
+
+	fmt.Println()
+	core.AsTable(os.Stdout, []string{"#", "Time", "Client", "Method", "URL", "Status"}, rows)
+	fmt.Println()
+	return nil
+}
+
+func (r *HttpReplay) report(results []HTTPReplayResult) {
+	rows := make([][]string, 0, len(results))
+	for _, res := range results {
+		status := fmt.Sprintf("%d", res.OrigStatus)
+		diff := core.Dim("same")
+
+		if res.Error != nil {
+			status = fmt.Sprintf("%s -> %s", status, core.Red("error"))
+			diff = res.Error.Error()
+		} else {
+			if res.Status != res.OrigStatus {
+				status = fmt.Sprintf("%s -> %s", status, core.Yellow(fmt.Sprintf("%d", res.Status)))
+			}
+			if len(res.Diff) > 0 {
+				diff = core.Yellow(fmt.Sprintf("%d lines (%d -> %d bytes)", len(res.Diff), res.OrigSize, res.Size))
+			}
+		}
+
+		rows = append(rows, []string{fmt.Sprintf("%d", res.Index), res.Method, res.URL, status, diff})
+	}
+
+	fmt.Println()
+	core.AsTable(os.Stdout, []string{"#", "Method", "URL", "Status", "Diff"}, rows)
+	fmt.Println()
+
+	if r.showDiff {
+		for _, res := range results {
+			if len(res.Diff) > 0 {
+				fmt.Printf("%s %s %s\n\n", core.Bold(fmt.Sprintf("#%d", res.Index)), res.Method, res.URL)
+				for _, line := range res.Diff {
+					if line[0] == '-' {
+						fmt.Println(core.Red(line))
+					} else {
+						fmt.Println(core.Green(line))
+					}
+				}
+				fmt.Println()
+			}
+		}
+	}
+}
+
+func (r *HttpReplay) Start() error {
+	if err := r.Configure(); err != nil {
+		return err
+	}
+
+	return r.SetRunning(true, func() {
+		defer r.SetRunning(false, nil)
+
+		log.Info("Replaying %d requests against %s ...", len(r.selected), core.Bold(r.target.String()))
+
+		results := make([]HTTPReplayResult, 0, len(r.selected))
+		for _, index := range r.selected {
+			if !r.Running() {
+				break
+			}
+			results = append(results, r.replay(index))
+		}
+
+		r.report(results)
+	})
+}
+
+func (r *HttpReplay) Stop() error {
+	return r.SetRunning(false, nil)
+}
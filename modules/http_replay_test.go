@@ -0,0 +1,202 @@
+package modules
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bettercap/bettercap/session"
+)
+
+func TestParseReplaySelection(t *testing.T) {
+	var units = []struct {
+		list     string
+		expected []int
+		fails    bool
+	}{
+		{"", []int{0, 1, 2, 3, 4}, false},
+		{"1", []int{0}, false},
+		{"1, 3-4", []int{0, 2, 3}, false},
+		{"5-5", []int{4}, false},
+		{"0", nil, true},
+		{"6", nil, true},
+		{"4-2", nil, true},
+		{"1-x", nil, true},
+	}
+
+	for _, u := range units {
+		got, err := parseReplaySelection(u.list, 5)
+		if u.fails {
+			if err == nil {
+				t.Fatalf("expected error for '%s'", u.list)
+			}
+		} else if err != nil {
+			t.Fatalf("unexpected error for '%s': %v", u.list, err)
+		} else if !reflect.DeepEqual(got, u.expected) {
+			t.Fatalf("expected '%v', got '%v'", u.expected, got)
+		}
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	var units = []struct {
+		a        string
+		b        string
+		expected []string
+	}{
+		{"a\nb", "a\nb", nil},
+		{"a\nb\nc", "a\nc", []string{"- b"}},
+		{"a\nc", "a\nb\nc", []string{"+ b"}},
+		{"a\nb", "a\nx", []string{"- b", "+ x"}},
+	}
+
+	for _, u := range units {
+		if got := diffLines(u.a, u.b); !reflect.DeepEqual(got, u.expected) {
+			t.Fatalf("expected '%v', got '%v'", u.expected, got)
+		}
+	}
+}
+
+func TestHttpReplay(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	dir, err := ioutil.TempDir("", "replay")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// record a request as the proxy would
+	fileName := filepath.Join(dir, "requests.log")
+	proxy := NewHTTPProxy(s.Session)
+	if err := proxy.ConfigureRecorder(fileName); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req := httptest.NewRequest("POST", "http://original.lan/api/item?id=1", strings.NewReader("token={{TOKEN}}"))
+	req.Header.Set("Authorization", "Bearer {{TOKEN}}")
+	res := &http.Response{
+		StatusCode: 200,
+		Header:     make(http.Header),
+		Body:       ioutil.NopCloser(strings.NewReader("ok\nsecret")),
+		Request:    req,
+	}
+	proxy.record(req, []byte("token={{TOKEN}}"), res)
+	proxy.recorder.Close()
+
+	if body, _ := ioutil.ReadAll(res.Body); string(body) != "ok\nsecret" {
+		t.Fatalf("expected the response body to be left readable, got '%s'", body)
+	}
+
+	var got *http.Request
+	var gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := ioutil.ReadAll(r.Body)
+		got, gotBody = r, string(raw)
+		w.WriteHeader(403)
+		w.Write([]byte("ok\ndenied"))
+	}))
+	defer server.Close()
+
+	replay := NewHttpReplay(s.Session)
+	s.Register(replay)
+
+	if err := replay.Configure(); err == nil {
+		t.Fatal("expected error without http.replay.file")
+	}
+
+	for name, value := range map[string]string{
+		"http.replay.file":   fileName,
+		"http.replay.target": server.URL + "/staging/",
+	} {
+		if err := s.Set(name, value); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := replay.Configure(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(replay.records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(replay.records))
+	} else if rec := replay.records[0]; rec.Method != "POST" || rec.Status != 200 || rec.ResBody != "ok\nsecret" {
+		t.Fatalf("unexpected record: %+v", rec)
+	}
+
+	if result := replay.replay(0); result.Error == nil {
+		t.Fatal("expected error with TOKEN not set")
+	}
+
+	s.Env.Set("TOKEN", "abc")
+	result := replay.replay(0)
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	} else if result.OrigStatus != 200 || result.Status != 403 {
+		t.Fatalf("expected '200 -> 403', got '%d -> %d'", result.OrigStatus, result.Status)
+	} else if expected := []string{"- secret", "+ denied"}; !reflect.DeepEqual(result.Diff, expected) {
+		t.Fatalf("expected '%v', got '%v'", expected, result.Diff)
+	}
+
+	target, _ := url.Parse(server.URL)
+	if got.Host != target.Host {
+		t.Fatalf("expected '%s', got '%s'", target.Host, got.Host)
+	} else if got.URL.Path != "/staging/api/item" || got.URL.RawQuery != "id=1" {
+		t.Fatalf("expected '/staging/api/item?id=1', got '%s'", got.URL.String())
+	} else if auth := got.Header.Get("Authorization"); auth != "Bearer abc" {
+		t.Fatalf("expected 'Bearer abc', got '%s'", auth)
+	} else if gotBody != "token=abc" {
+		t.Fatalf("expected 'token=abc', got '%s'", gotBody)
+	}
+}
+
+func TestHttpProxyRecorderStop(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	dir, err := ioutil.TempDir("", "record")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, "requests.log")
+	proxy := NewHTTPProxy(s.Session)
+	if err := proxy.ConfigureRecorder(fileName); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// requests still in flight while the recorder is removed
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				req := httptest.NewRequest("GET", "http://original.lan/", nil)
+				res := &http.Response{StatusCode: 200, Header: make(http.Header), Body: ioutil.NopCloser(strings.NewReader("ok"))}
+				proxy.record(req, nil, res)
+			}
+		}()
+	}
+	proxy.setRecorder(nil)
+	wg.Wait()
+
+	if proxy.getRecorder() != nil {
+		t.Fatal("expected the recorder to be removed")
+	} else if _, err := LoadHTTPRecords(fileName); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,204 @@
+package modules
+
+import (
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+)
+
+type HTTPSProxyPinningEvent struct {
+	Client     string
+	ServerName string
+	// handshakes aborted so far for this server name
+	Aborted int
+}
+
+// pinningStats are the intercepted and aborted handshakes of a server
+// name, clients pinning its certificate abort every one of them.
+type pinningStats struct {
+	ServerName  string
+	Clients     []string
+	Intercepted int
+	Aborted     int
+	LastAborted time.Time
+}
+
+func (s *pinningStats) Pinned() bool {
+	return s.Aborted > 0 && s.Intercepted == 0
+}
+
+type pinningHandshake struct {
+	client     string
+	serverName string
+	done       bool
+}
+
+// pinningTracker follows every intercepted connection until either the
+// client sends its first request or it goes away.
+type pinningTracker struct {
+	sync.Mutex
+	pending map[string]*pinningHandshake
+	hosts   map[string]*pinningStats
+}
+
+func newPinningTracker() *pinningTracker {
+	return &pinningTracker{
+		pending: make(map[string]*pinningHandshake),
+		hosts:   make(map[string]*pinningStats),
+	}
+}
+
+func (t *pinningTracker) statsOf(serverName string) *pinningStats {
+	stats, found := t.hosts[serverName]
+	if !found {
+		stats = &pinningStats{ServerName: serverName, Clients: make([]string, 0)}
+		t.hosts[serverName] = stats
+	}
+	return stats
+}
+
+// Begin tracks the connection with the id, its remote address.
+func (t *pinningTracker) Begin(id string, client string, serverName string) {
+	t.Lock()
+	defer t.Unlock()
+	t.pending[id] = &pinningHandshake{client: client, serverName: serverName}
+}
+
+// Intercepted marks the connection as working, the client accepted our
+// certificate.
+func (t *pinningTracker) Intercepted(id string) {
+	t.Lock()
+	defer t.Unlock()
+
+	if h, found := t.pending[id]; found && !h.done {
+		h.done = true
+		t.statsOf(h.serverName).Intercepted++
+	}
+}
+
+// End stops tracking the connection, if the client went away without
+// sending any request it returns the event to report the first time it
+// does so for the server name.
+func (t *pinningTracker) End(id string) *HTTPSProxyPinningEvent {
+	t.Lock()
+	defer t.Unlock()
+
+	h, found := t.pending[id]
+	if !found {
+		return nil
+	}
+	delete(t.pending, id)
+
+	if h.done {
+		return nil
+	}
+
+	stats := t.statsOf(h.serverName)
+	stats.Aborted++
+	stats.LastAborted = time.Now()
+	known := false
+	for _, client := range stats.Clients {
+		if client == h.client {
+			known = true
+			break
+		}
+	}
+	if known {
+		return nil
+	}
+	stats.Clients = append(stats.Clients, h.client)
+
+	return &HTTPSProxyPinningEvent{
+		Client:     h.client,
+		ServerName: h.serverName,
+		Aborted:    stats.Aborted,
+	}
+}
+
+// Report returns the server names with aborted handshakes, the likely
+// pinned ones and the most aborted first.
+func (t *pinningTracker) Report() []pinningStats {
+	t.Lock()
+	defer t.Unlock()
+
+	report := make([]pinningStats, 0)
+	for _, stats := range t.hosts {
+		if stats.Aborted > 0 {
+			report = append(report, *stats)
+		}
+	}
+
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Pinned() != report[j].Pinned() {
+			return report[i].Pinned()
+		} else if report[i].Aborted != report[j].Aborted {
+			return report[i].Aborted > report[j].Aborted
+		}
+		return report[i].ServerName < report[j].ServerName
+	})
+	return report
+}
+
+// pinningConn reports the end of an intercepted connection to the tracker
+// the first time a read fails or it's closed.
+type pinningConn struct {
+	net.Conn
+	once  sync.Once
+	onEnd func()
+}
+
+func (c *pinningConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if err != nil {
+		c.once.Do(c.onEnd)
+	}
+	return n, err
+}
+
+func (c *pinningConn) Close() error {
+	c.once.Do(c.onEnd)
+	return c.Conn.Close()
+}
+
+// onPinningEnd is called when an intercepted connection ends.
+func (p *HTTPProxy) onPinningEnd(id string) {
+	if e := p.pinning.End(id); e != nil {
+		p.sess.Events.Add(p.Name+".pinning", *e)
+	}
+}
+
+func (p *HTTPProxy) ShowPinningReport() error {
+	report := p.pinning.Report()
+	if len(report) == 0 {
+		log.Info("No aborted handshakes so far.")
+		return nil
+	}
+
+	rows := make([][]string, 0, len(report))
+	for _, stats := range report {
+		status := core.Yellow("sometimes aborted")
+		if stats.Pinned() {
+			status = core.Red("likely pinned")
+		}
+
+		rows = append(rows, []string{
+			stats.ServerName,
+			strings.Join(stats.Clients, ", "),
+			strconv.Itoa(stats.Intercepted),
+			strconv.Itoa(stats.Aborted),
+			status,
+			stats.LastAborted.Format("15:04:05"),
+		})
+	}
+
+	core.AsTable(os.Stdout, []string{"Server Name", "Clients", "Intercepted", "Aborted", "Status", "Last Aborted"}, rows)
+	p.sess.Refresh()
+	return nil
+}
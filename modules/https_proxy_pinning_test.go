@@ -0,0 +1,54 @@
+package modules
+
+import (
+	"testing"
+)
+
+func TestPinningTracker(t *testing.T) {
+	tracker := newPinningTracker()
+
+	// pinned.com is always aborted, mixed.com once
+	tracker.Begin("192.168.1.10:1000", "192.168.1.10", "pinned.com")
+	tracker.Begin("192.168.1.10:1001", "192.168.1.10", "pinned.com")
+	tracker.Begin("192.168.1.11:1000", "192.168.1.11", "pinned.com")
+	tracker.Begin("192.168.1.10:1002", "192.168.1.10", "mixed.com")
+	tracker.Begin("192.168.1.11:1001", "192.168.1.11", "mixed.com")
+	tracker.Begin("192.168.1.12:1000", "192.168.1.12", "fine.com")
+
+	tracker.Intercepted("192.168.1.11:1001")
+	tracker.Intercepted("192.168.1.11:1001")
+	tracker.Intercepted("192.168.1.12:1000")
+
+	var units = []struct {
+		id      string
+		reports bool
+	}{
+		{"192.168.1.10:1000", true},
+		// the same client aborting again is not reported twice
+		{"192.168.1.10:1001", false},
+		{"192.168.1.11:1000", true},
+		{"192.168.1.10:1002", true},
+		{"192.168.1.11:1001", false},
+		{"192.168.1.12:1000", false},
+		// already ended
+		{"192.168.1.10:1000", false},
+	}
+
+	for _, u := range units {
+		if e := tracker.End(u.id); (e != nil) != u.reports {
+			t.Fatalf("expected %v for %s, got %v", u.reports, u.id, e)
+		}
+	}
+
+	report := tracker.Report()
+	if len(report) != 2 {
+		t.Fatalf("expected 2 server names, got %d", len(report))
+	}
+
+	pinned, mixed := report[0], report[1]
+	if pinned.ServerName != "pinned.com" || !pinned.Pinned() || pinned.Aborted != 3 || len(pinned.Clients) != 2 {
+		t.Fatalf("unexpected stats %+v", pinned)
+	} else if mixed.ServerName != "mixed.com" || mixed.Pinned() || mixed.Aborted != 1 || mixed.Intercepted != 1 {
+		t.Fatalf("unexpected stats %+v", mixed)
+	}
+}
@@ -0,0 +1,154 @@
+package modules
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+)
+
+type HTTPSProxySNIEvent struct {
+	Client     string
+	ServerName string
+	Tunneled   bool
+}
+
+// sniLogger appends every server name seen by the proxy to a file.
+type sniLogger struct {
+	sync.Mutex
+	file *os.File
+}
+
+func newSNILogger(fileName string) (*sniLogger, error) {
+	fileName, err := core.ExpandPath(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &sniLogger{file: file}, nil
+}
+
+func (l *sniLogger) Log(t time.Time, e HTTPSProxySNIEvent) error {
+	l.Lock()
+	defer l.Unlock()
+
+	action := "mitm"
+	if e.Tunneled {
+		action = "tunnel"
+	}
+
+	_, err := fmt.Fprintf(l.file, "%s %s %s %s\n", t.Format(time.RFC3339), e.Client, e.ServerName, action)
+	return err
+}
+
+func (l *sniLogger) Close() error {
+	l.Lock()
+	defer l.Unlock()
+	return l.file.Close()
+}
+
+// sniMatches returns true if the hostname is in the list, entries
+// starting with *. match every subdomain.
+func sniMatches(patterns []string, hostname string) bool {
+	hostname = strings.ToLower(hostname)
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(core.Trim(pattern))
+		if pattern == "" {
+			continue
+		} else if strings.HasPrefix(pattern, "*.") {
+			if strings.HasSuffix(hostname, pattern[1:]) {
+				return true
+			}
+		} else if hostname == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// sniSkipped returns true if the hostname matches one of the shell
+// patterns, such as *.apple.com or api-*.bank.com.
+func sniSkipped(patterns []string, hostname string) bool {
+	hostname = strings.ToLower(hostname)
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(strings.ToLower(pattern), hostname); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ConfigureSNI sets the server names which are tunneled instead of
+// intercepted, the patterns of the skipped ones and the optional file to
+// log every server name to.
+func (p *HTTPProxy) ConfigureSNI(tunneled []string, skipped []string, logFile string) (err error) {
+	for _, pattern := range skipped {
+		if _, err = path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("Invalid server name pattern '%s': %s", pattern, err)
+		}
+	}
+
+	p.tunneled = tunneled
+	p.skipped = skipped
+	p.sniLog = nil
+	if logFile != "" {
+		if p.sniLog, err = newSNILogger(logFile); err != nil {
+			return err
+		}
+		log.Debug("Logging server names to %s", logFile)
+	}
+	return nil
+}
+
+func (p *HTTPProxy) onSNI(client string, hostname string, tunneled bool) {
+	e := HTTPSProxySNIEvent{
+		Client:     client,
+		ServerName: hostname,
+		Tunneled:   tunneled,
+	}
+
+	p.sess.Events.Add(p.Name+".sni", e)
+	if p.sniLog != nil {
+		if err := p.sniLog.Log(time.Now(), e); err != nil {
+			log.Warning("Error while logging server name %s: %s", hostname, err)
+		}
+	}
+}
+
+// tunnel forwards the connection as it is to the real server, the
+// ClientHello already read by the SNI parser is replayed by conn.
+func (p *HTTPProxy) tunnel(conn net.Conn, hostname string) {
+	defer conn.Close()
+
+	upstream, err := p.dial(net.JoinHostPort(hostname, "443"))
+	if err != nil {
+		log.Warning("Error while tunneling to %s: %s", hostname, err)
+		return
+	}
+	defer upstream.Close()
+
+	conn.SetDeadline(time.Time{})
+
+	done := make(chan bool, 2)
+	go func() {
+		io.Copy(upstream, conn)
+		done <- true
+	}()
+	go func() {
+		io.Copy(conn, upstream)
+		done <- true
+	}()
+	// either side closing ends the tunnel
+	<-done
+}
@@ -0,0 +1,86 @@
+package modules
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSNIMatches(t *testing.T) {
+	patterns := []string{"bank.com", " *.google.com", ""}
+
+	var units = []struct {
+		hostname string
+		exp      bool
+	}{
+		{"bank.com", true},
+		{"BANK.com", true},
+		{"www.bank.com", false},
+		{"mail.google.com", true},
+		{"google.com", false},
+		{"notgoogle.com", false},
+		{"", false},
+	}
+
+	for _, u := range units {
+		if got := sniMatches(patterns, u.hostname); got != u.exp {
+			t.Fatalf("expected '%v' for '%s', got '%v'", u.exp, u.hostname, got)
+		}
+	}
+}
+
+func TestSNILogger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sni")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, "sni.log")
+	l, err := newSNILogger(fileName)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	when := time.Date(2018, 3, 1, 10, 0, 0, 0, time.UTC)
+	l.Log(when, HTTPSProxySNIEvent{"192.168.1.10", "bank.com", true})
+	l.Log(when, HTTPSProxySNIEvent{"192.168.1.11", "mail.google.com", false})
+	l.Close()
+
+	exp := "2018-03-01T10:00:00Z 192.168.1.10 bank.com tunnel\n" +
+		"2018-03-01T10:00:00Z 192.168.1.11 mail.google.com mitm\n"
+	if raw, err := ioutil.ReadFile(fileName); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if string(raw) != exp {
+		t.Fatalf("expected '%s', got '%s'", exp, raw)
+	}
+}
+
+func TestSNISkipped(t *testing.T) {
+	patterns := []string{"*.apple.com", "api-*.BANK.com"}
+
+	var units = []struct {
+		hostname string
+		exp      bool
+	}{
+		{"push.apple.com", true},
+		{"apple.com", false},
+		{"a.b.apple.com", true},
+		{"api-eu.bank.com", true},
+		{"API-US.bank.com", true},
+		{"api.bank.com", false},
+	}
+
+	for _, u := range units {
+		if got := sniSkipped(patterns, u.hostname); got != u.exp {
+			t.Fatalf("expected '%v' for '%s', got '%v'", u.exp, u.hostname, got)
+		}
+	}
+
+	p := &HTTPProxy{}
+	if err := p.ConfigureSNI(nil, []string{"[bank.com"}, ""); err == nil {
+		t.Fatal("expected an error for an invalid pattern")
+	}
+}
@@ -1,10 +1,15 @@
 package modules
 
 import (
+	"bytes"
+	"crypto/rand"
 	"fmt"
+	"math/big"
 	"net"
 	"runtime"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/bettercap/bettercap/core"
 	"github.com/bettercap/bettercap/log"
@@ -12,16 +17,64 @@ import (
 	"github.com/bettercap/bettercap/session"
 )
 
+const (
+	macChangerCurrentVar = "mac.changer.current"
+	macSeedPrefix        = "seed:"
+	macVendorPrefix      = "vendor:"
+	// attempts to satisfy mac.changer.rotate.mindiff
+	macRotateRetries = 64
+)
+
+// MacChangedEvent is fired every time mac.changer applies an address.
+type MacChangedEvent struct {
+	Interface string `json:"interface"`
+	Old       string `json:"old"`
+	New       string `json:"new"`
+}
+
 type MacChanger struct {
 	session.SessionModule
-	iface       string
-	originalMac net.HardwareAddr
-	fakeMac     net.HardwareAddr
+	iface        string
+	originalMac  net.HardwareAddr
+	restoreMac   net.HardwareAddr
+	permanentMac net.HardwareAddr
+	fakeMac      net.HardwareAddr
+	ssid         string
+	netns        string
+	rename       string
+	settle       time.Duration
+	// save and restore the conntrack entries around the link down and up
+	preserveConntrack bool
+	// what to do once the address has been changed
+	bounceIface bool
+	renewDHCP   bool
+	// sysfs root the bond and bridge members are detected from
+	sysfs string
+	// set to mac.changer.iface when the address of its master is changed
+	member string
+	// set while the interface is renamed
+	originalName string
+	// registry keys of the Windows adapter
+	adapter *windowsAdapter
+	// pending restore of mac.changer.timed
+	revertLock  sync.Mutex
+	revertTimer *time.Timer
+	// mac.changer.rotate-on subscription
+	rotateOn       string
+	rotateDebounce time.Duration
+	rotateLock     sync.Mutex
+	rotateStop     func()
+	// mac.changer.rotate on worker
+	periodStop chan struct{}
+	// how the address has last been changed, for the status
+	backendLock sync.Mutex
+	backend     string
 }
 
 func NewMacChanger(s *session.Session) *MacChanger {
 	mc := &MacChanger{
 		SessionModule: session.NewSessionModule("mac.changer", s),
+		sysfs:         network.SysClassNet,
 	}
 
 	mc.AddParam(session.NewStringParameter("mac.changer.iface",
@@ -29,14 +82,140 @@ func NewMacChanger(s *session.Session) *MacChanger {
 		"",
 		"Name of the interface to use."))
 
+	s.RegisterCompleter("mac.changer.iface", func(prefix string) []string {
+		names := []string{}
+		if ifaces, err := net.Interfaces(); err == nil {
+			for _, iface := range ifaces {
+				names = append(names, iface.Name)
+			}
+		}
+		return names
+	})
+
 	mc.AddParam(session.NewStringParameter("mac.changer.address",
 		session.ParamRandomMAC,
-		"[a-fA-F0-9]{2}:[a-fA-F0-9]{2}:[a-fA-F0-9]{2}:[a-fA-F0-9]{2}:[a-fA-F0-9]{2}:[a-fA-F0-9]{2}",
-		"Hardware address to apply to the interface."))
+		"^seed:.+|^vendor:.+|[a-fA-F0-9]{2}:[a-fA-F0-9]{2}:[a-fA-F0-9]{2}:[a-fA-F0-9]{2}:[a-fA-F0-9]{2}:[a-fA-F0-9]{2}",
+		"Hardware address to apply to the interface, use seed:STRING to derive it deterministically from STRING or vendor:NAME for a random one with an OUI of NAME."))
+
+	mc.AddParam(session.NewStringParameter("mac.changer.vendor",
+		"",
+		"",
+		"If set, a vendor name (like apple) or one of its OUIs (like 00:1A:2B) to apply a random address with any of the OUIs of the vendor, instead of mac.changer.address."))
+
+	mc.AddParam(session.NewBoolParameter("mac.changer.restore.permanent",
+		"false",
+		"If true and the original address is itself randomized, restore the permanent hardware address instead (Linux only)."))
+
+	mc.AddParam(session.NewIntParameter("mac.changer.rotate.mindiff",
+		"0",
+		"Number of trailing octets a new random or vendor:NAME address must differ in from the previous one (0 to 6), so that trackers can't correlate them by prefix."))
+
+	mc.AddParam(session.NewStringParameter("mac.changer.netns",
+		"",
+		"",
+		"If set, apply the address to the interface inside this network namespace, either a name or a path like /proc/PID/ns/net (Linux only)."))
+
+	mc.AddParam(session.NewStringParameter("mac.changer.rename",
+		"",
+		`^[a-zA-Z0-9_\.\-]{0,15}$`,
+		"If set, rename the interface to this after applying the address and give it its original name back when off (Linux only), other running modules referencing the old name might stop working."))
+
+	mc.AddParam(session.NewDurationParameter("mac.changer.settle",
+		"0",
+		"How long to wait after changing the address with the link down before bringing it back up, for slow drivers which apply it late (0 to disable)."))
+
+	mc.AddParam(session.NewBoolParameter("mac.changer.preserve-conntrack",
+		"false",
+		"Experimental and best-effort: if true and the link has to be brought down to change the address, save the conntrack entries of the interface addresses before and insert back the ones which are gone after, to reduce the connections dropped (Linux only, requires conntrack-tools)."))
+
+	mc.AddParam(session.NewBoolParameter("mac.changer.bounce_iface",
+		"false",
+		"If true, bring the link down and up after changing the address, so that the switches and the peers learn it."))
+
+	mc.AddParam(session.NewBoolParameter("mac.changer.renew_dhcp",
+		"false",
+		"If true, renew the DHCP lease after changing the address (dhclient or udhcpc on Linux, ipconfig on macOS and Windows) and read the addresses and the gateway of the interface again."))
+
+	mc.AddParam(session.NewBoolParameter("mac.changer.master",
+		"false",
+		"If mac.changer.iface is a member of a bond or a bridge, change the address of its master instead of refusing to (Linux only)."))
+
+	mc.AddParam(session.NewStringParameter("mac.changer.rotate-on",
+		"",
+		"",
+		"If set and mac.changer.address is random, apply a new address while running every time an event with a tag starting with this (like wifi.client.new) is fired."))
+
+	mc.AddParam(session.NewDurationParameter("mac.changer.rotate-on.debounce",
+		"5s",
+		"Events of mac.changer.rotate-on fired within this time from a rotation are ignored."))
+
+	mc.AddParam(session.NewIntParameter("mac.changer.period",
+		"60",
+		"Number of seconds between the addresses applied by mac.changer.rotate on."))
+
+	mc.AddParam(session.NewStringParameter("mac.changer.period.oui",
+		"",
+		`^$|^[a-fA-F0-9]{2}[:\-]?[a-fA-F0-9]{2}[:\-]?[a-fA-F0-9]{2}$`,
+		"If set, the addresses applied by mac.changer.rotate on have this OUI prefix (like 00:11:22), otherwise they follow mac.changer.vendor or mac.changer.address if random, or are random."))
+
+	mc.AddParam(session.NewStringParameter("mac.changer.per-ssid",
+		"",
+		"",
+		"Comma separated list of SSID=ADDRESS entries (ADDRESS can also be seed:STRING or vendor:NAME) applied by mac.changer.ssid and by wifi.connect before associating, SSIDs not in the list get mac.changer.address (by wifi.connect only when leaving a listed one)."))
+
+	mc.AddHandler(session.NewModuleHandler("mac.changer.ssid SSID", `mac\.changer\.ssid\s+(.+)`,
+		"Apply the address associated to SSID in mac.changer.per-ssid, to be used before associating to it.",
+		func(args []string) error {
+			return mc.ForSSID(args[0])
+		}))
+
+	mc.AddHandler(session.NewModuleHandler("mac.changer.restore-permanent", "",
+		"Restore the permanent (burned-in) hardware address of the interface, regardless of the current state.",
+		func(args []string) error {
+			return mc.RestorePermanent()
+		}))
+
+	mc.AddHandler(session.NewDangerousModuleHandler("mac.changer.selftest", "",
+		"If the module is not running, apply a random address and restore the original one right away, reporting whether both worked and how long they took.",
+		func(args []string) error {
+			return mc.SelfTest()
+		}))
 
-	mc.AddHandler(session.NewModuleHandler("mac.changer on", "",
+	mc.AddHandler(session.NewDangerousModuleHandler("mac.changer.reassoc", "",
+		"Bring the WiFi interface down, apply a new random address and reassociate to the current SSID in one step.",
+		func(args []string) error {
+			return mc.Reassociate()
+		}))
+
+	mc.AddHandler(session.NewDangerousModuleHandler("mac.changer.timed DURATION", `mac\.changer\.timed\s+([^\s]+)`,
+		"Apply the configured address and restore the original one after DURATION (like 30s or 5m), unless mac.changer off is used first.",
+		func(args []string) error {
+			d, err := time.ParseDuration(args[0])
+			if err != nil {
+				return err
+			} else if d <= 0 {
+				return fmt.Errorf("The duration must be greater than 0.")
+			}
+			mc.ssid = ""
+			return mc.Timed(d)
+		}))
+
+	mc.AddHandler(session.NewDangerousModuleHandler("mac.changer.rotate on", "",
+		"Start the module if needed and apply a new address every mac.changer.period seconds, firing a mac.rotated event each time.",
+		func(args []string) error {
+			return mc.StartPeriodicRotation()
+		}))
+
+	mc.AddHandler(session.NewModuleHandler("mac.changer.rotate off", "",
+		"Stop rotating the address, the last one applied is kept until mac.changer off.",
+		func(args []string) error {
+			return mc.StopPeriodicRotation()
+		}))
+
+	mc.AddHandler(session.NewDangerousModuleHandler("mac.changer on", "",
 		"Start mac changer module.",
 		func(args []string) error {
+			mc.ssid = ""
 			return mc.Start()
 		}))
 
@@ -61,64 +240,801 @@ func (mc *MacChanger) Author() string {
 	return "Simone Margaritelli <evilsocket@protonmail.com>"
 }
 
+func parseChangerMac(address string) (net.HardwareAddr, error) {
+	if strings.HasPrefix(address, macSeedPrefix) {
+		return network.SeededMac(strings.TrimPrefix(address, macSeedPrefix)), nil
+	} else if strings.HasPrefix(address, macVendorPrefix) {
+		return vendorChangerMac(strings.TrimPrefix(address, macVendorPrefix))
+	}
+	return network.ParseMac(address)
+}
+
+// vendorChangerMac returns a random address with one of the OUIs of the
+// vendor, given by name or by one of its OUIs.
+func vendorChangerMac(name string) (net.HardwareAddr, error) {
+	vendor, prefixes, err := network.OuiPool(name)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(prefixes))))
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := prefixes[n.Int64()]
+	log.Debug("Using OUI %s of %s for '%s'", prefix, vendor, name)
+	return network.VendorMac(prefix)
+}
+
+// parses a list of SSID=ADDRESS entries, the SSID itself may contain '='
+func parseSSIDMacs(entries []string) (map[string]net.HardwareAddr, error) {
+	macs := make(map[string]net.HardwareAddr)
+	for _, entry := range entries {
+		idx := strings.LastIndex(entry, "=")
+		if idx <= 0 {
+			return nil, fmt.Errorf("'%s' is not a valid SSID=ADDRESS entry.", entry)
+		}
+
+		ssid := core.Trim(entry[:idx])
+		if hw, err := parseChangerMac(core.Trim(entry[idx+1:])); err != nil {
+			return nil, fmt.Errorf("Invalid address for SSID %s: %s", ssid, err)
+		} else {
+			macs[ssid] = hw
+		}
+	}
+	return macs, nil
+}
+
+// addressFor returns the address to use for the given SSID, or the
+// default one if there's no specific address for it.
+func (mc *MacChanger) addressFor(ssid string) (net.HardwareAddr, error) {
+	if ssid != "" {
+		if err, entries := mc.ListParam("mac.changer.per-ssid"); err != nil {
+			return nil, err
+		} else if macs, err := parseSSIDMacs(entries); err != nil {
+			return nil, err
+		} else if hw, found := macs[ssid]; found {
+			return hw, nil
+		}
+		log.Debug("No specific address for SSID %s, using mac.changer.address.", ssid)
+	}
+
+	if err, vendor := mc.StringParam("mac.changer.vendor"); err != nil {
+		return nil, err
+	} else if vendor != "" {
+		return mc.distantMac(func() (net.HardwareAddr, error) {
+			return vendorChangerMac(vendor)
+		})
+	}
+
+	// the parameter resolves <random mac> to a new address every time
+	if _, raw := mc.Session.Env.Get("mac.changer.address"); raw == session.ParamRandomMAC {
+		return mc.distantMac(randomUnicastMac)
+	}
+
+	if err, address := mc.StringParam("mac.changer.address"); err != nil {
+		return nil, err
+	} else if strings.HasPrefix(address, macVendorPrefix) {
+		return mc.distantMac(func() (net.HardwareAddr, error) {
+			return vendorChangerMac(strings.TrimPrefix(address, macVendorPrefix))
+		})
+	} else {
+		return parseChangerMac(address)
+	}
+}
+
+// differsInLast returns true if every one of the last n octets of a
+// and b is different.
+func differsInLast(a, b net.HardwareAddr, n int) bool {
+	if len(a) != len(b) {
+		return true
+	}
+	for i := len(a) - n; i < len(a); i++ {
+		if i >= 0 && a[i] == b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// previousMac returns the address a new one is applied over.
+func (mc *MacChanger) previousMac() net.HardwareAddr {
+	if mc.Running() && mc.fakeMac != nil {
+		return mc.fakeMac
+	}
+	return mc.originalMac
+}
+
+// distantMac generates addresses until one differs from the previous
+// address in the last mac.changer.rotate.mindiff octets, or returns
+// the last one generated if none does.
+func (mc *MacChanger) distantMac(generate func() (net.HardwareAddr, error)) (net.HardwareAddr, error) {
+	err, minDiff := mc.IntParam("mac.changer.rotate.mindiff")
+	if err != nil {
+		return nil, err
+	} else if minDiff < 0 || minDiff > 6 {
+		return nil, fmt.Errorf("mac.changer.rotate.mindiff must be between 0 and 6.")
+	}
+
+	previous := mc.previousMac()
+	for attempt := 1; ; attempt++ {
+		mac, err := generate()
+		if err != nil {
+			return nil, err
+		} else if minDiff == 0 || previous == nil || differsInLast(mac, previous, minDiff) {
+			return mac, nil
+		} else if attempt == macRotateRetries {
+			log.Warning("Could not generate an address differing from %s in the last %d octets after %d attempts, using %s.", previous, minDiff, attempt, mac)
+			return mac, nil
+		}
+	}
+}
+
 func (mc *MacChanger) Configure() (err error) {
-	var changeTo string
+	var restorePermanent bool
 
 	if err, mc.iface = mc.StringParam("mac.changer.iface"); err != nil {
 		return err
-	} else if err, changeTo = mc.StringParam("mac.changer.address"); err != nil {
+	} else if err, restorePermanent = mc.BoolParam("mac.changer.restore.permanent"); err != nil {
+		return err
+	} else if err, mc.netns = mc.StringParam("mac.changer.netns"); err != nil {
+		return err
+	} else if mc.netns != "" && runtime.GOOS != "linux" {
+		return fmt.Errorf("mac.changer.netns is only supported on Linux.")
+	} else if err, mc.rename = mc.StringParam("mac.changer.rename"); err != nil {
+		return err
+	} else if mc.rename != "" && runtime.GOOS != "linux" {
+		return fmt.Errorf("mac.changer.rename is only supported on Linux.")
+	} else if err, mc.settle = mc.DurationParam("mac.changer.settle"); err != nil {
+		return err
+	} else if err, mc.rotateOn = mc.StringParam("mac.changer.rotate-on"); err != nil {
+		return err
+	} else if err = checkRotateOn(mc.rotateOn); err != nil {
+		return err
+	} else if err, mc.rotateDebounce = mc.DurationParam("mac.changer.rotate-on.debounce"); err != nil {
+		return err
+	} else if err, mc.bounceIface = mc.BoolParam("mac.changer.bounce_iface"); err != nil {
+		return err
+	} else if err, mc.renewDHCP = mc.BoolParam("mac.changer.renew_dhcp"); err != nil {
+		return err
+	} else if err, mc.preserveConntrack = mc.BoolParam("mac.changer.preserve-conntrack"); err != nil {
 		return err
+	} else if mc.preserveConntrack && runtime.GOOS != "linux" {
+		return fmt.Errorf("mac.changer.preserve-conntrack is only supported on Linux.")
+	} else if err = mc.checkMaster(); err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "windows" {
+		if mc.adapter, err = findAdapter(mc.iface); err != nil {
+			return err
+		}
+	}
+
+	if mc.netns != "" {
+		if mc.originalMac, err = mc.namespacedMac(); err != nil {
+			return err
+		}
+	} else if mc.member != "" {
+		if mc.originalMac, err = network.InterfaceAddress(mc.sysfs, mc.iface); err != nil {
+			return err
+		}
+	} else {
+		mc.originalMac = mc.Session.Interface.HW
 	}
+	mc.restoreMac = mc.originalMac
 
-	changeTo = network.NormalizeMac(changeTo)
-	if mc.fakeMac, err = net.ParseMAC(changeTo); err != nil {
+	if mc.fakeMac, err = mc.addressFor(mc.ssid); err != nil {
 		return err
 	}
 
-	mc.originalMac = mc.Session.Interface.HW
+	var permErr error
+	if mc.netns != "" {
+		mc.permanentMac = nil
+		permErr = fmt.Errorf("The permanent address can't be read inside the %s network namespace.", mc.netns)
+	} else if mc.permanentMac, permErr = network.PermanentMAC(mc.iface); permErr != nil {
+		log.Debug("%s", permErr)
+	} else if !bytes.Equal(mc.permanentMac, mc.originalMac) {
+		log.Debug("Current address %s of %s differs from the permanent one %s.", mc.originalMac, mc.iface, mc.permanentMac)
+	}
+
+	if network.IsLocallyAdministered(mc.originalMac) {
+		if !restorePermanent {
+			log.Warning("The original address %s of %s is itself randomized, set mac.changer.restore.permanent to true to restore the permanent one.", mc.originalMac, mc.iface)
+		} else if permErr != nil {
+			log.Warning("Could not read the permanent address of %s, %s will be restored: %s", mc.iface, mc.originalMac, permErr)
+		} else {
+			mc.restoreMac = mc.permanentMac
+		}
+	}
 
 	return nil
 }
 
-func (mc *MacChanger) setMac(mac net.HardwareAddr) error {
-	var args []string
+// checkMaster refuses to change the address of a bond or bridge member,
+// as its master manages it, unless mac.changer.master is true and the
+// module is moved to the master.
+func (mc *MacChanger) checkMaster() error {
+	mc.member = ""
+	if mc.netns != "" || runtime.GOOS != "linux" {
+		return nil
+	}
+
+	err, useMaster := mc.BoolParam("mac.changer.master")
+	if err != nil {
+		return err
+	}
+
+	master, err := network.InterfaceMaster(mc.sysfs, mc.iface)
+	if err != nil {
+		return fmt.Errorf("Could not read the master of %s: %s", mc.iface, err)
+	} else if master == nil {
+		return nil
+	} else if !useMaster {
+		return fmt.Errorf("%s is a member of the %s, which manages its address: set mac.changer.iface to %s, or mac.changer.master to true, to change the address of %s instead.", mc.iface, master, master.Name, master.Name)
+	}
+
+	log.Info("%s is a member of the %s, changing the address of %s instead.", mc.iface, master, master.Name)
+	mc.member, mc.iface = mc.iface, master.Name
+	return nil
+}
 
-	os := runtime.GOOS
-	if strings.Contains(os, "bsd") || os == "darwin" {
-		args = []string{mc.iface, "ether", mac.String()}
-	} else if os == "linux" || os == "android" {
-		args = []string{mc.iface, "hw", "ether", mac.String()}
+// command returns the command line to run inside mac.changer.netns if set,
+// names are handled by ip netns while paths are entered with nsenter.
+func (mc *MacChanger) command(executable string, args []string) (string, []string) {
+	if mc.netns == "" {
+		return executable, args
+	} else if strings.Contains(mc.netns, "/") {
+		return "nsenter", append([]string{"--net=" + mc.netns, executable}, args...)
+	}
+	return "ip", append([]string{"netns", "exec", mc.netns, executable}, args...)
+}
+
+func (mc *MacChanger) exec(executable string, args []string) (string, error) {
+	return core.Exec(mc.command(executable, args))
+}
+
+// namespacedMac reads the current address of the interface inside
+// mac.changer.netns, where it is not visible to the session.
+func (mc *MacChanger) namespacedMac() (net.HardwareAddr, error) {
+	out, err := mc.exec("cat", []string{fmt.Sprintf("/sys/class/net/%s/address", mc.iface)})
+	if err != nil {
+		return nil, fmt.Errorf("Could not read the address of %s in the %s network namespace: %s", mc.iface, mc.netns, err)
+	}
+	return net.ParseMAC(core.Trim(out))
+}
+
+// RestorePermanent applies the burned-in address of the interface,
+// whether the module is running or not.
+func (mc *MacChanger) RestorePermanent() error {
+	if err, iface := mc.StringParam("mac.changer.iface"); err != nil {
+		return err
+	} else if err, netns := mc.StringParam("mac.changer.netns"); err != nil {
+		return err
+	} else if netns != "" {
+		return fmt.Errorf("mac.changer.restore-permanent is not supported inside network namespaces.")
 	} else {
-		return fmt.Errorf("OS %s is not supported by mac.changer module.", os)
+		mc.iface = iface
+	}
+
+	if err := mc.checkMaster(); err != nil {
+		return err
+	} else if mc.member != "" {
+		return fmt.Errorf("mac.changer.restore-permanent is not supported on the master of %s.", mc.member)
+	}
+
+	permanent, err := network.PermanentMAC(mc.iface)
+	if err != nil {
+		return err
+	} else if err = mc.setMac(permanent); err != nil {
+		return err
+	}
+	mc.afterChange(true)
+
+	if mc.Running() {
+		// nothing left to restore when turned off
+		mc.stopRotation()
+		if err := mc.restoreName(); err != nil {
+			log.Error("Error while restoring the interface name: %s", err)
+		}
+		mc.SetRunning(false, nil)
+		mc.Session.Env.Unset(macChangerCurrentVar)
+	}
+
+	log.Info("Interface mac address restored to the permanent %s", core.Bold(permanent.String()))
+	return nil
+}
+
+// macSetArgs returns the ifconfig arguments to set the address of the
+// interface on goos.
+func macSetArgs(goos, iface string, mac net.HardwareAddr) ([]string, error) {
+	if strings.Contains(goos, "bsd") || goos == "darwin" {
+		return []string{iface, "ether", mac.String()}, nil
+	} else if goos == "linux" || goos == "android" {
+		return []string{iface, "hw", "ether", mac.String()}, nil
+	}
+	return nil, fmt.Errorf("OS %s is not supported by mac.changer module.", goos)
+}
+
+func (mc *MacChanger) setMac(mac net.HardwareAddr) error {
+	apply := func() error {
+		return mc.applyRegistryMac(mac)
+	}
+	if runtime.GOOS != "windows" {
+		args, err := macSetArgs(runtime.GOOS, mc.iface, mac)
+		if err != nil {
+			return err
+		}
+		apply = func() error {
+			return mc.applyMac(mac, args)
+		}
+	}
+	verify := func() error {
+		return mc.verifyMac(mac)
+	}
+
+	if mc.netns != "" || mc.member != "" {
+		// the namespaced interface or the master is not the session one
+		err := core.ApplyVerify(apply, verify)
+		if err == nil {
+			if mc.member != "" {
+				// the members of a bond might follow its address
+				mc.refreshInterface()
+			}
+			mc.Session.Events.Add("mac.changed", MacChangedEvent{mc.iface, "", mac.String()})
+		}
+		return err
 	}
 
-	_, err := core.Exec("ifconfig", args)
+	old := mc.Session.Interface.HW.String()
+
+	// some drivers reset the promiscuous mode flag when the
+	// hardware address changes, make sure it is preserved
+	wasPromisc, promiscErr := network.GetInterfacePromisc(mc.iface)
+
+	err := core.ApplyVerify(apply, verify)
 	if err == nil {
 		mc.Session.Interface.HW = mac
 	}
 
+	if promiscErr == nil {
+		if promisc, err := network.GetInterfacePromisc(mc.iface); err == nil && promisc != wasPromisc {
+			log.Debug("Restoring promiscuous mode of %s to %v", mc.iface, wasPromisc)
+			if err := network.SetInterfacePromisc(mc.iface, wasPromisc); err != nil {
+				log.Warning("Could not restore promiscuous mode of %s: %s", mc.iface, err)
+			}
+		}
+	}
+
+	if err == nil {
+		mc.refreshInterface()
+		mc.Session.Events.Add("mac.changed", MacChangedEvent{mc.iface, old, mac.String()})
+	}
+
+	return err
+}
+
+// readMac reads the address the interface has now from the system.
+func (mc *MacChanger) readMac() (net.HardwareAddr, error) {
+	if os := runtime.GOOS; os == "linux" || os == "android" {
+		return network.InterfaceAddress(mc.sysfs, mc.iface)
+	}
+
+	name := mc.iface
+	if mc.adapter != nil {
+		// net.Interface has the connection name on Windows
+		name = mc.adapter.Name
+	}
+
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+	return iface.HardwareAddr, nil
+}
+
+// verifyMac catches drivers silently ignoring or reverting the address,
+// the interfaces which can't be read, like the ones inside
+// mac.changer.netns, are not verified.
+func (mc *MacChanger) verifyMac(mac net.HardwareAddr) error {
+	if mc.netns != "" {
+		return nil
+	}
+
+	current, err := mc.readMac()
+	if err != nil {
+		log.Debug("Could not verify the address of %s: %s", mc.iface, err)
+		return nil
+	} else if !bytes.Equal(current, mac) {
+		return fmt.Errorf("%s has address %s instead of %s.", mc.iface, current, mac)
+	}
+	return nil
+}
+
+// the native backends, replaced by the tests
+var (
+	nativeSetMac  = network.SetInterfaceMAC
+	nativeSetLink = network.SetInterfaceLink
+)
+
+// applyMac uses the syscalls, netlink on Linux and ioctl elsewhere, and
+// only falls back to the binaries if they're not available or the
+// interface is inside mac.changer.netns.
+func (mc *MacChanger) applyMac(mac net.HardwareAddr, ifconfigArgs []string) error {
+	if mc.netns == "" {
+		err := mc.applyNativeMac(mac)
+		if _, unavailable := err.(network.NativeMACError); !unavailable {
+			return err
+		}
+		log.Debug("%s, falling back to the binaries.", err)
+	}
+	return mc.applyExecMac(mac, ifconfigArgs)
+}
+
+func (mc *MacChanger) applyNativeMac(mac net.HardwareAddr) error {
+	if os := runtime.GOOS; os != "linux" && os != "android" {
+		err := nativeSetMac(mc.iface, mac)
+		if err == nil {
+			mc.setBackend("ioctl")
+		}
+		return err
+	}
+
+	err := nativeSetMac(mc.iface, mac)
+	if err == nil {
+		mc.setBackend("netlink")
+		log.Info("Address of %s changed keeping the link up.", mc.iface)
+		return nil
+	} else if _, unavailable := err.(network.NativeMACError); unavailable {
+		return err
+	}
+	log.Debug("Could not change the address of %s with the link up: %s", mc.iface, err)
+
+	err = mc.withLinkDown(func(up bool) error {
+		return nativeSetLink(mc.iface, up)
+	}, func() error {
+		return nativeSetMac(mc.iface, mac)
+	})
+	if err == nil {
+		mc.setBackend("netlink")
+		log.Info("Address of %s changed bringing the link down and up.", mc.iface)
+	}
+	return err
+}
+
+// applyExecMac uses ip link on Linux, which most drivers accept with the
+// link up so that the connections survive the change, and only falls back
+// to ifconfig with the link down if it fails.
+func (mc *MacChanger) applyExecMac(mac net.HardwareAddr, ifconfigArgs []string) error {
+	if os := runtime.GOOS; os != "linux" && os != "android" {
+		_, err := mc.exec("ifconfig", ifconfigArgs)
+		if err == nil {
+			mc.setBackend("ifconfig")
+		}
+		return err
+	}
+
+	_, err := core.ExecSilent(mc.command("ip", []string{"link", "set", "dev", mc.iface, "address", mac.String()}))
+	if err == nil {
+		mc.setBackend("ip")
+		log.Info("Address of %s changed keeping the link up.", mc.iface)
+		return nil
+	}
+	log.Debug("Could not change the address of %s with the link up: %s", mc.iface, err)
+
+	err = mc.withLinkDown(mc.execLink, func() error {
+		_, err := mc.exec("ifconfig", ifconfigArgs)
+		return err
+	})
+	if err == nil {
+		mc.setBackend("ifconfig")
+		log.Info("Address of %s changed bringing the link down and up.", mc.iface)
+	}
 	return err
 }
 
+// withLinkDown runs set with the link down, waiting mac.changer.settle
+// after it, and brings the link back up even if set fails.
+func (mc *MacChanger) withLinkDown(link func(up bool) error, set func() error) error {
+	snapshot := mc.snapshotConntrack()
+	if err := link(false); err != nil {
+		return err
+	}
+	defer mc.restoreConntrack(snapshot)
+
+	err := set()
+	if err == nil && mc.settle > 0 {
+		log.Debug("Waiting %s for %s to settle.", mc.settle, mc.iface)
+		time.Sleep(mc.settle)
+	}
+	if upErr := link(true); err == nil {
+		err = upErr
+	}
+	return err
+}
+
+func (mc *MacChanger) setBackend(backend string) {
+	mc.backendLock.Lock()
+	defer mc.backendLock.Unlock()
+	mc.backend = backend
+}
+
+// StatusDetail tells how the address has last been changed.
+func (mc *MacChanger) StatusDetail() string {
+	mc.backendLock.Lock()
+	defer mc.backendLock.Unlock()
+	if mc.backend == "" {
+		return ""
+	}
+	return fmt.Sprintf("(backend %s)", mc.backend)
+}
+
+// refreshInterface re-reads the session interface so that the modules
+// using it see the new hardware address and the IPv6 addresses it implies.
+func (mc *MacChanger) refreshInterface() {
+	if err := mc.Session.Interface.Refresh(); err != nil {
+		log.Debug("Could not refresh %s: %s", mc.iface, err)
+	}
+}
+
 func (mc *MacChanger) Start() error {
 	if mc.Running() {
 		return session.ErrAlreadyStarted
+	} else if err := mc.Session.RequirePrivileges(mc.Name(), session.CapNetAdmin); err != nil {
+		return err
 	} else if err := mc.Configure(); err != nil {
 		return err
+	} else if err := mc.Session.CheckSafe(mc.Name(), mc.iface); err != nil {
+		return err
 	} else if err := mc.setMac(mc.fakeMac); err != nil {
 		return err
+	} else if err := mc.applyName(); err != nil {
+		// don't leave the name nor the address changed if the module is off
+		if restoreErr := mc.restoreName(); restoreErr != nil {
+			log.Error("Error while restoring the interface name: %s", restoreErr)
+		}
+		if restoreErr := mc.setMac(mc.originalMac); restoreErr != nil {
+			log.Error("Error while restoring mac address: %s", restoreErr)
+		}
+		return err
 	}
 
-	return mc.SetRunning(true, func() {
+	mc.afterChange(true)
+
+	// expose the applied address so that caplets can
+	// reference it as {env.mac.changer.current}
+	mc.Session.Env.Set(macChangerCurrentVar, mc.fakeMac.String())
+
+	if err := mc.SetRunning(true, func() {
 		log.Info("Interface mac address set to %s", core.Bold(mc.fakeMac.String()))
+	}); err != nil {
+		return err
+	}
+
+	mc.startRotation()
+	return nil
+}
+
+// ForSSID applies the address associated to the SSID, starting the
+// module if needed so that the original address is restored when off.
+func (mc *MacChanger) ForSSID(ssid string) error {
+	mc.ssid = core.Trim(ssid)
+	if !mc.Running() {
+		return mc.Start()
+	}
+
+	mac, err := mc.addressFor(mc.ssid)
+	if err != nil {
+		return err
+	} else if err := mc.setMac(mac); err != nil {
+		return err
+	}
+	mc.afterChange(true)
+
+	mc.fakeMac = mac
+	mc.Session.Env.Set(macChangerCurrentVar, mc.fakeMac.String())
+	log.Info("Interface mac address set to %s for SSID %s", core.Bold(mc.fakeMac.String()), mc.ssid)
+	return nil
+}
+
+// BeforeAssociation applies the address of the SSID in mac.changer.per-ssid
+// before mac.changer.iface associates to it. SSIDs not in the list only get
+// mac.changer.address when leaving a listed one, so that reconnecting to
+// them doesn't change the address every time.
+func (mc *MacChanger) BeforeAssociation(iface string, ssid string) error {
+	if err, ours := mc.StringParam("mac.changer.iface"); err != nil {
+		return err
+	} else if ours != iface {
+		return nil
+	}
+
+	err, entries := mc.ListParam("mac.changer.per-ssid")
+	if err != nil {
+		return err
+	}
+	macs, err := parseSSIDMacs(entries)
+	if err != nil {
+		return err
+	}
+
+	ssid = core.Trim(ssid)
+	if _, listed := macs[ssid]; listed {
+		if mc.Running() && mc.ssid == ssid {
+			return nil
+		}
+		return mc.ForSSID(ssid)
+	} else if _, leaving := macs[mc.ssid]; leaving && mc.Running() {
+		return mc.ForSSID(ssid)
+	}
+	return nil
+}
+
+// unicast and locally administered, as the drivers refuse multicast ones
+func randomUnicastMac() (net.HardwareAddr, error) {
+	hw := make(net.HardwareAddr, 6)
+	if _, err := rand.Read(hw); err != nil {
+		return nil, err
+	}
+	hw[0] = (hw[0] &^ 0x01) | 0x02
+	return hw, nil
+}
+
+// Reassociate applies a new random address while the interface is down
+// and makes it associate again to the current SSID, so that the driver
+// can't reconnect with the old address in between.
+func (mc *MacChanger) Reassociate() error {
+	if !mc.Running() {
+		if err := mc.Configure(); err != nil {
+			return err
+		} else if err := mc.Session.CheckSafe(mc.Name(), mc.iface); err != nil {
+			return err
+		}
+	}
+
+	if mc.netns != "" {
+		return fmt.Errorf("mac.changer.reassoc is not supported inside network namespaces.")
+	} else if mc.member != "" {
+		return fmt.Errorf("mac.changer.reassoc is not supported on the master of %s.", mc.member)
+	}
+
+	ssid, err := network.GetInterfaceSSID(mc.iface)
+	if err != nil {
+		return err
+	}
+
+	mac, err := mc.distantMac(randomUnicastMac)
+	if err != nil {
+		return err
+	}
+
+	log.Info("Bringing %s down ...", mc.iface)
+	if err := network.SetInterfaceUp(mc.iface, false); err != nil {
+		return err
+	}
+
+	log.Info("Setting address of %s to %s ...", mc.iface, mac)
+	if err := mc.setMac(mac); err != nil {
+		// don't leave the interface down
+		network.SetInterfaceUp(mc.iface, true)
+		return err
+	}
+
+	log.Info("Bringing %s up ...", mc.iface)
+	if err := network.SetInterfaceUp(mc.iface, true); err != nil {
+		return err
+	}
+
+	mc.fakeMac = mac
+	mc.Session.Env.Set(macChangerCurrentVar, mc.fakeMac.String())
+	if !mc.Running() {
+		// so that the original address is restored when off
+		mc.SetRunning(true, nil)
+	}
+
+	log.Info("Reassociating %s to %s ...", mc.iface, ssid)
+	if err := network.ReassociateInterface(mc.iface, ssid); err != nil {
+		return fmt.Errorf("Could not reassociate %s to %s: %s", mc.iface, ssid, err)
+	}
+	// addresses are assigned again once the interface is back up
+	mc.refreshInterface()
+	// already bounced
+	mc.afterChange(false)
+
+	log.Info("Interface mac address set to %s and reassociated to %s", core.Bold(mc.fakeMac.String()), ssid)
+	return nil
+}
+
+// Timed starts the module if needed and schedules its stop after the
+// duration, replacing a previously scheduled one.
+func (mc *MacChanger) Timed(d time.Duration) error {
+	if !mc.Running() {
+		if err := mc.Start(); err != nil {
+			return err
+		}
+	}
+
+	mc.revertLock.Lock()
+	defer mc.revertLock.Unlock()
+
+	mc.cancelRevertUnlocked()
+
+	var timer *time.Timer
+	timer = time.AfterFunc(d, func() {
+		mc.revertLock.Lock()
+		if mc.revertTimer != timer {
+			// cancelled while firing
+			mc.revertLock.Unlock()
+			return
+		}
+		mc.revertTimer = nil
+		mc.revertLock.Unlock()
+
+		if err := mc.Stop(); err != nil {
+			log.Error("Error while stopping the timed mac address change: %s", err)
+		}
+	})
+	mc.revertTimer = timer
+
+	log.Info("Interface mac address will be restored at %s (in %s).", core.Bold(time.Now().Add(d).Format("15:04:05")), d)
+	return nil
+}
+
+func (mc *MacChanger) cancelRevertUnlocked() {
+	if mc.revertTimer != nil {
+		mc.revertTimer.Stop()
+		mc.revertTimer = nil
+	}
+}
+
+func (mc *MacChanger) cancelRevert() {
+	mc.revertLock.Lock()
+	defer mc.revertLock.Unlock()
+	mc.cancelRevertUnlocked()
+}
+
+func (mc *MacChanger) restore() error {
+	mc.stopRotation()
+	mc.ssid = ""
+	mc.Session.Env.Unset(macChangerCurrentVar)
+	// the address is restored even if the name can't be
+	nameErr := mc.restoreName()
+	// never configured, there's nothing to restore
+	mac := mc.restoreMac
+	if mac == nil {
+		return nameErr
+	} else if err := mc.setMac(mac); err != nil {
+		return err
+	}
+	mc.afterChange(true)
+	log.Info("Interface mac address restored to %s", core.Bold(mac.String()))
+	return nameErr
+}
+
+// Revert restores the original address if it has been changed.
+func (mc *MacChanger) Revert() (bool, error) {
+	if !mc.Running() {
+		return false, nil
+	}
+
+	mc.cancelRevert()
+
+	var err error
+	mc.SetRunning(false, func() {
+		err = mc.restore()
 	})
+	return true, err
 }
 
+// Stop restores the original address, it's a no-op if already restored.
 func (mc *MacChanger) Stop() error {
+	mc.cancelRevert()
+	if !mc.Running() {
+		log.Info("mac.changer is not running, the original address is already in place.")
+		return nil
+	}
 	return mc.SetRunning(false, func() {
-		if err := mc.setMac(mc.originalMac); err == nil {
-			log.Info("Interface mac address restored to %s", core.Bold(mc.originalMac.String()))
-		} else {
+		if err := mc.restore(); err != nil {
 			log.Error("Error while restoring mac address: %s", err)
 		}
 	})
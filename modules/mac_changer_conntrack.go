@@ -0,0 +1,176 @@
+package modules
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+)
+
+// conntrackTuple is one direction of a connection.
+type conntrackTuple struct {
+	Src   string
+	Dst   string
+	Sport string
+	Dport string
+}
+
+// conntrackEntry is a tcp or udp entry of conntrack -L.
+type conntrackEntry struct {
+	Proto   string
+	Timeout int
+	// empty for udp
+	State   string
+	Assured bool
+	Orig    conntrackTuple
+	Reply   conntrackTuple
+}
+
+func (e conntrackEntry) key() string {
+	return strings.Join([]string{e.Proto, e.Orig.Src, e.Orig.Sport, e.Orig.Dst, e.Orig.Dport}, " ")
+}
+
+func (e conntrackEntry) involves(ips []string) bool {
+	for _, ip := range ips {
+		if ip != "" && (e.Orig.Src == ip || e.Orig.Dst == ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// insertArgs returns the conntrack arguments to create the entry again.
+func (e conntrackEntry) insertArgs() []string {
+	args := []string{"-I", "-p", e.Proto,
+		"-s", e.Orig.Src, "-d", e.Orig.Dst, "--sport", e.Orig.Sport, "--dport", e.Orig.Dport,
+		"-r", e.Reply.Src, "-q", e.Reply.Dst, "--reply-port-src", e.Reply.Sport, "--reply-port-dst", e.Reply.Dport,
+		"-t", strconv.Itoa(e.Timeout),
+	}
+	if e.State != "" {
+		args = append(args, "--state", e.State)
+	}
+	if e.Assured {
+		args = append(args, "-u", "ASSURED")
+	}
+	return args
+}
+
+// parseConntrackEntry parses a line of conntrack -L, the first addresses
+// and ports are the original direction and the second ones the reply.
+func parseConntrackEntry(line string) (conntrackEntry, bool) {
+	e := conntrackEntry{}
+	fields := strings.Fields(line)
+	if len(fields) < 4 || (fields[0] != "tcp" && fields[0] != "udp") {
+		return e, false
+	}
+
+	timeout, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return e, false
+	}
+	e.Proto, e.Timeout = fields[0], timeout
+	if !strings.Contains(fields[3], "=") {
+		e.State = fields[3]
+	}
+
+	tuple := &e.Orig
+	seen := 0
+	for _, field := range fields[3:] {
+		parts := strings.SplitN(field, "=", 2)
+		if field == "[ASSURED]" {
+			e.Assured = true
+			continue
+		} else if len(parts) != 2 {
+			continue
+		}
+
+		switch parts[0] {
+		case "src":
+			if seen++; seen == 2 {
+				tuple = &e.Reply
+			}
+			tuple.Src = parts[1]
+		case "dst":
+			tuple.Dst = parts[1]
+		case "sport":
+			tuple.Sport = parts[1]
+		case "dport":
+			tuple.Dport = parts[1]
+		}
+	}
+
+	if e.Orig.Sport == "" || e.Reply.Src == "" {
+		return e, false
+	}
+	return e, true
+}
+
+// parseConntrack returns the entries of conntrack -L with one of the ips
+// as source or destination.
+func parseConntrack(out string, ips []string) []conntrackEntry {
+	entries := make([]conntrackEntry, 0)
+	for _, line := range strings.Split(out, "\n") {
+		if e, ok := parseConntrackEntry(line); ok && e.involves(ips) {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+func (mc *MacChanger) listConntrack() ([]conntrackEntry, error) {
+	ips := []string{mc.Session.Interface.IpAddress, mc.Session.Interface.Ip6Address}
+	out, err := core.ExecSilent(mc.command("conntrack", []string{"-L"}))
+	if err != nil {
+		return nil, err
+	}
+	return parseConntrack(out, ips), nil
+}
+
+// snapshotConntrack saves the connections of the interface addresses if
+// mac.changer.preserve-conntrack is set, nil otherwise or if it failed.
+func (mc *MacChanger) snapshotConntrack() []conntrackEntry {
+	if !mc.preserveConntrack {
+		return nil
+	}
+
+	entries, err := mc.listConntrack()
+	if err != nil {
+		log.Warning("Could not save the conntrack entries of %s: %s", mc.iface, err)
+		return nil
+	}
+	log.Debug("Saved %d conntrack entries of %s.", len(entries), mc.iface)
+	return entries
+}
+
+// restoreConntrack inserts back the saved entries which are gone, this is
+// best-effort and never fails the address change.
+func (mc *MacChanger) restoreConntrack(snapshot []conntrackEntry) {
+	if len(snapshot) == 0 {
+		return
+	}
+
+	current, err := mc.listConntrack()
+	if err != nil {
+		log.Warning("Could not read the conntrack entries of %s: %s", mc.iface, err)
+		return
+	}
+
+	alive := make(map[string]bool)
+	for _, e := range current {
+		alive[e.key()] = true
+	}
+
+	restored := 0
+	for _, e := range snapshot {
+		if alive[e.key()] {
+			continue
+		} else if _, err := core.ExecSilent(mc.command("conntrack", e.insertArgs())); err != nil {
+			log.Debug("Could not restore the conntrack entry %s: %s", e.key(), err)
+		} else {
+			restored++
+		}
+	}
+
+	log.Debug("Restored %d of %d conntrack entries of %s.", restored, len(snapshot), mc.iface)
+}
@@ -0,0 +1,91 @@
+package modules
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/bettercap/bettercap/session"
+)
+
+const testConntrack = `tcp      6 431999 ESTABLISHED src=192.168.1.2 dst=1.1.1.1 sport=51234 dport=443 src=1.1.1.1 dst=192.168.1.2 sport=443 dport=51234 [ASSURED] mark=0 use=1
+udp      17 29 src=192.168.1.2 dst=8.8.8.8 sport=40000 dport=53 [UNREPLIED] src=8.8.8.8 dst=192.168.1.2 sport=53 dport=40000 mark=0 use=1
+tcp      6 100 TIME_WAIT src=10.0.0.5 dst=10.0.0.6 sport=1000 dport=22 src=10.0.0.6 dst=10.0.0.5 sport=22 dport=1000 [ASSURED] mark=0 use=1
+icmp     1 29 src=192.168.1.2 dst=1.1.1.1 type=8 code=0 id=1 src=1.1.1.1 dst=192.168.1.2 type=0 code=0 id=1 mark=0 use=1
+conntrack v1.4.6 (conntrack-tools): 4 flow entries have been shown.
+`
+
+func TestMacChangerParseConntrack(t *testing.T) {
+	entries := parseConntrack(testConntrack, []string{session.TestInterfaceIP, ""})
+
+	exp := []conntrackEntry{
+		{"tcp", 431999, "ESTABLISHED", true,
+			conntrackTuple{"192.168.1.2", "1.1.1.1", "51234", "443"},
+			conntrackTuple{"1.1.1.1", "192.168.1.2", "443", "51234"}},
+		{"udp", 29, "", false,
+			conntrackTuple{"192.168.1.2", "8.8.8.8", "40000", "53"},
+			conntrackTuple{"8.8.8.8", "192.168.1.2", "53", "40000"}},
+	}
+	if !reflect.DeepEqual(entries, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, entries)
+	}
+
+	args := strings.Join(entries[0].insertArgs(), " ")
+	if exp := "-I -p tcp -s 192.168.1.2 -d 1.1.1.1 --sport 51234 --dport 443 -r 1.1.1.1 -q 192.168.1.2 --reply-port-src 443 --reply-port-dst 51234 -t 431999 --state ESTABLISHED -u ASSURED"; args != exp {
+		t.Fatalf("expected '%s', got '%s'", exp, args)
+	}
+}
+
+func TestMacChangerPreserveConntrack(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("conntrack is only supported on linux")
+	}
+
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	listed := 0
+	s.ExecOutput = func(executable string, args []string) (string, error) {
+		if executable == "ip" && args[len(args)-2] == "address" {
+			return "", fmt.Errorf("Device or resource busy")
+		} else if executable == "conntrack" && args[0] == "-L" {
+			if listed++; listed == 1 {
+				return testConntrack, nil
+			}
+			// the udp entry is gone after the link bounce
+			return strings.Split(testConntrack, "\n")[0], nil
+		}
+		return "", nil
+	}
+
+	mc := NewMacChanger(s.Session)
+	s.Register(mc)
+
+	if err := s.Set("mac.changer.address", "seed:lab-run-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Set("mac.changer.preserve-conntrack", "true"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	startMacChanger(t, s)
+
+	inserted := []string{}
+	for _, cmd := range s.Executed() {
+		if strings.HasPrefix(cmd, "conntrack -I") {
+			inserted = append(inserted, cmd)
+		}
+	}
+
+	exp := []string{
+		"conntrack -I -p udp -s 192.168.1.2 -d 8.8.8.8 --sport 40000 --dport 53 -r 8.8.8.8 -q 192.168.1.2 --reply-port-src 53 --reply-port-dst 40000 -t 29",
+	}
+	if !reflect.DeepEqual(inserted, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, inserted)
+	} else if listed != 2 {
+		t.Fatalf("expected the entries to be listed before and after, got %d", listed)
+	}
+}
@@ -0,0 +1,125 @@
+package modules
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/network"
+)
+
+// a DHCP client and the commands to release and renew the lease with it
+type dhcpClient [][]string
+
+// dhcpClients returns the clients to renew the lease of iface with on
+// goos, the first one which is installed is used.
+func dhcpClients(goos, iface string) []dhcpClient {
+	switch goos {
+	case "linux", "android":
+		return []dhcpClient{
+			{{"dhclient", "-r", iface}, {"dhclient", iface}},
+			{{"udhcpc", "-i", iface, "-n", "-q"}},
+		}
+	case "darwin":
+		return []dhcpClient{
+			{{"ipconfig", "set", iface, "DHCP"}},
+		}
+	case "windows":
+		return []dhcpClient{
+			{{"ipconfig", "/release", iface}, {"ipconfig", "/renew", iface}},
+		}
+	}
+	return nil
+}
+
+// execLink brings the interface up or down with the binaries.
+func (mc *MacChanger) execLink(up bool) error {
+	state := "down"
+	if up {
+		state = "up"
+	}
+
+	if os := runtime.GOOS; os != "linux" && os != "android" {
+		_, err := mc.exec("ifconfig", []string{mc.iface, state})
+		return err
+	}
+	_, err := mc.exec("ip", []string{"link", "set", "dev", mc.iface, state})
+	return err
+}
+
+// setLink brings the interface up or down, through the syscalls if
+// they're available.
+func (mc *MacChanger) setLink(up bool) error {
+	if mc.netns == "" {
+		err := nativeSetLink(mc.iface, up)
+		if _, unavailable := err.(network.NativeMACError); !unavailable {
+			return err
+		}
+	}
+	return mc.execLink(up)
+}
+
+// bounceLink makes the switches and the peers learn the new address.
+func (mc *MacChanger) bounceLink() error {
+	if runtime.GOOS == "windows" {
+		// the adapter has already been restarted to apply it
+		return nil
+	} else if err := mc.setLink(false); err != nil {
+		return err
+	}
+	return mc.setLink(true)
+}
+
+// renewLease gets a new lease for the new address, the first command of
+// each client tells whether it is installed.
+func (mc *MacChanger) renewLease() error {
+	iface := mc.iface
+	if mc.adapter != nil {
+		iface = mc.adapter.Name
+	}
+
+	clients := dhcpClients(runtime.GOOS, iface)
+	if len(clients) == 0 {
+		return fmt.Errorf("Renewing the DHCP lease is not supported on %s.", runtime.GOOS)
+	}
+
+	tried := make([]string, 0, len(clients))
+	for _, client := range clients {
+		tried = append(tried, client[0][0])
+		if _, err := mc.exec(client[0][0], client[0][1:]); err != nil {
+			log.Debug("Could not renew the lease of %s with %s: %s", iface, client[0][0], err)
+			continue
+		}
+		for _, cmd := range client[1:] {
+			if _, err := mc.exec(cmd[0], cmd[1:]); err != nil {
+				return err
+			}
+		}
+		log.Info("DHCP lease of %s renewed with %s.", iface, client[0][0])
+		return nil
+	}
+	return fmt.Errorf("Could not renew the DHCP lease of %s with any of %s.", iface, strings.Join(tried, ", "))
+}
+
+// afterChange bounces the link and renews the lease if enabled, then
+// refreshes the addresses and the gateway of the session so that the
+// modules depending on them keep working.
+func (mc *MacChanger) afterChange(bounce bool) {
+	if bounce && mc.bounceIface {
+		if err := mc.bounceLink(); err != nil {
+			log.Warning("Could not bounce %s: %s", mc.iface, err)
+		}
+	}
+
+	if mc.renewDHCP {
+		if err := mc.renewLease(); err != nil {
+			log.Warning("%s", err)
+		}
+		if mc.netns == "" {
+			if err := mc.Session.RefreshNetwork(); err != nil {
+				log.Warning("Could not refresh the addresses and the gateway of %s: %s", mc.iface, err)
+			}
+		}
+	}
+}
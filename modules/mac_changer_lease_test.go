@@ -0,0 +1,91 @@
+package modules
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/bettercap/bettercap/session"
+)
+
+func TestMacChangerDHCPClients(t *testing.T) {
+	var units = []struct {
+		goos  string
+		first []string
+	}{
+		{"linux", []string{"dhclient", "-r", "eth0"}},
+		{"android", []string{"dhclient", "-r", "eth0"}},
+		{"darwin", []string{"ipconfig", "set", "eth0", "DHCP"}},
+		{"windows", []string{"ipconfig", "/release", "eth0"}},
+		{"plan9", nil},
+	}
+
+	for _, u := range units {
+		clients := dhcpClients(u.goos, "eth0")
+		if u.first == nil {
+			if len(clients) != 0 {
+				t.Fatalf("expected no clients for '%s', got '%v'", u.goos, clients)
+			}
+		} else if len(clients) == 0 {
+			t.Fatalf("expected clients for '%s'", u.goos)
+		} else if !reflect.DeepEqual(clients[0][0], u.first) {
+			t.Fatalf("expected '%v', got '%v'", u.first, clients[0][0])
+		}
+	}
+}
+
+func TestMacChangerBounceAndRenew(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("ip link commands are only tested on linux")
+	}
+
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	s.ExecOutput = func(executable string, args []string) (string, error) {
+		if executable == "dhclient" {
+			return "", fmt.Errorf("exec: \"dhclient\": executable file not found in $PATH")
+		}
+		return "", nil
+	}
+
+	mc := NewMacChanger(s.Session)
+	s.Register(mc)
+
+	if err := s.Set("mac.changer.address", "seed:lab-run-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Set("mac.changer.bounce_iface", "true"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Set("mac.changer.renew_dhcp", "true"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	startMacChanger(t, s)
+
+	exp := []string{
+		"ip link set dev test0 address 26:19:a5:88:a7:a3",
+		"ip link set dev test0 down",
+		"ip link set dev test0 up",
+		"dhclient -r test0",
+		"udhcpc -i test0 -n -q",
+	}
+	if got := s.Executed(); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, got)
+	}
+
+	// none of the clients is installed
+	s.ExecOutput = func(executable string, args []string) (string, error) {
+		if executable == "dhclient" || executable == "udhcpc" {
+			return "", fmt.Errorf("exec: \"%s\": executable file not found in $PATH", executable)
+		}
+		return "", nil
+	}
+	if err := mc.renewLease(); err == nil {
+		t.Fatal("expected error")
+	} else if exp := "Could not renew the DHCP lease of test0 with any of dhclient, udhcpc."; err.Error() != exp {
+		t.Fatalf("expected '%s', got '%s'", exp, err)
+	}
+}
@@ -0,0 +1,100 @@
+package modules
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/bettercap/bettercap/network"
+	"github.com/bettercap/bettercap/session"
+)
+
+func nativeUnavailable() {
+	nativeSetMac = func(iface string, mac net.HardwareAddr) error {
+		return network.NativeMACError{Err: fmt.Errorf("disabled by the tests")}
+	}
+	nativeSetLink = func(iface string, up bool) error {
+		return network.NativeMACError{Err: fmt.Errorf("disabled by the tests")}
+	}
+}
+
+func init() {
+	// the other tests check the commands of the binaries, and the
+	// syscalls would change the address of the real interfaces
+	nativeUnavailable()
+}
+
+func TestMacChangerNativeBackend(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("netlink is only tested on linux")
+	}
+	defer nativeUnavailable()
+
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	calls := []string{}
+	busy := true
+	nativeSetMac = func(iface string, mac net.HardwareAddr) error {
+		calls = append(calls, fmt.Sprintf("address %s %s", iface, mac))
+		if busy {
+			busy = false
+			return fmt.Errorf("Could not set the address of %s: device or resource busy", iface)
+		}
+		return nil
+	}
+	nativeSetLink = func(iface string, up bool) error {
+		calls = append(calls, fmt.Sprintf("up %s %v", iface, up))
+		return nil
+	}
+
+	mc := NewMacChanger(s.Session)
+	s.Register(mc)
+
+	if err := s.Set("mac.changer.address", "seed:lab-run-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Handle("mac.changer", "mac.changer on"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if got := s.Interface.HW.String(); got != "26:19:a5:88:a7:a3" {
+		t.Fatalf("expected '26:19:a5:88:a7:a3', got '%s'", got)
+	} else if got := mc.StatusDetail(); got != "(backend netlink)" {
+		t.Fatalf("expected '(backend netlink)', got '%s'", got)
+	}
+
+	exp := []string{
+		"address test0 26:19:a5:88:a7:a3",
+		"up test0 false",
+		"address test0 26:19:a5:88:a7:a3",
+		"up test0 true",
+	}
+	if !reflect.DeepEqual(calls, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, calls)
+	} else if got := s.Executed(); len(got) != 0 {
+		t.Fatalf("expected no commands, got '%v'", got)
+	}
+
+	// refused by the kernel, no fallback to the binaries
+	nativeSetMac = func(iface string, mac net.HardwareAddr) error {
+		return fmt.Errorf("Could not set the address of %s: operation not permitted", iface)
+	}
+	if err := mc.setMac(mc.originalMac); err == nil {
+		t.Fatal("expected an error")
+	} else if got := s.Executed(); len(got) != 0 {
+		t.Fatalf("expected no commands, got '%v'", got)
+	}
+
+	// unavailable, the binaries are used
+	nativeUnavailable()
+	if err := mc.setMac(mc.originalMac); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if got := mc.StatusDetail(); got != "(backend ip)" {
+		t.Fatalf("expected '(backend ip)', got '%s'", got)
+	} else if got := s.Executed(); len(got) != 1 {
+		t.Fatalf("expected the ip command, got '%v'", got)
+	}
+}
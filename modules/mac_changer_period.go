@@ -0,0 +1,139 @@
+package modules
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/network"
+)
+
+// normalizeOui accepts 00:11:22, 00-11-22 or 001122, "" means no prefix.
+func normalizeOui(prefix string) (string, error) {
+	if prefix == "" {
+		return "", nil
+	}
+
+	oui := strings.ToLower(strings.NewReplacer(":", "", "-", "").Replace(prefix))
+	if raw, err := hex.DecodeString(oui); err != nil || len(raw) != 3 {
+		return "", fmt.Errorf("'%s' is not a valid OUI prefix.", prefix)
+	}
+	return oui, nil
+}
+
+// periodicMac returns the next address of the scheduled rotation, a random
+// one with the OUI if set, otherwise one from mac.changer.vendor or
+// mac.changer.address if random, otherwise a random one.
+func (mc *MacChanger) periodicMac(oui string) (net.HardwareAddr, error) {
+	if oui != "" {
+		return mc.distantMac(func() (net.HardwareAddr, error) {
+			return network.VendorMac(oui)
+		})
+	} else if mc.randomAddress() {
+		return mc.addressFor(mc.ssid)
+	}
+	return mc.distantMac(randomUnicastMac)
+}
+
+// StartPeriodicRotation starts the module if needed and applies a new
+// address every mac.changer.period seconds until mac.changer.rotate off.
+func (mc *MacChanger) StartPeriodicRotation() error {
+	err, seconds := mc.IntParam("mac.changer.period")
+	if err != nil {
+		return err
+	} else if seconds <= 0 {
+		return fmt.Errorf("mac.changer.period must be greater than 0.")
+	}
+
+	err, prefix := mc.StringParam("mac.changer.period.oui")
+	if err != nil {
+		return err
+	}
+	oui, err := normalizeOui(prefix)
+	if err != nil {
+		return err
+	}
+
+	return mc.startPeriodicRotation(time.Duration(seconds)*time.Second, oui)
+}
+
+func (mc *MacChanger) startPeriodicRotation(period time.Duration, oui string) error {
+	if !mc.Running() {
+		if err := mc.Start(); err != nil {
+			return err
+		}
+	}
+
+	mc.rotateLock.Lock()
+	defer mc.rotateLock.Unlock()
+
+	if mc.periodStop != nil {
+		return fmt.Errorf("The address is already being rotated, use mac.changer.rotate off first.")
+	}
+
+	stop := make(chan struct{})
+	mc.periodStop = stop
+	go mc.periodicWorker(period, oui, stop)
+
+	log.Info("Interface mac address will be rotated every %s.", core.Bold(period.String()))
+	return nil
+}
+
+// StopPeriodicRotation stops the scheduled rotation, keeping the address
+// applied last until the module is turned off.
+func (mc *MacChanger) StopPeriodicRotation() error {
+	mc.rotateLock.Lock()
+	defer mc.rotateLock.Unlock()
+
+	if !mc.stopPeriodicRotationUnlocked() {
+		return fmt.Errorf("The address is not being rotated.")
+	}
+	log.Info("Interface mac address rotation stopped.")
+	return nil
+}
+
+func (mc *MacChanger) stopPeriodicRotationUnlocked() bool {
+	if mc.periodStop == nil {
+		return false
+	}
+	close(mc.periodStop)
+	mc.periodStop = nil
+	return true
+}
+
+func (mc *MacChanger) periodicWorker(period time.Duration, oui string, stop chan struct{}) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := mc.rotatePeriodic(period, oui, stop); err != nil {
+				log.Error("Error while rotating the mac address: %s", err)
+			}
+		}
+	}
+}
+
+// rotatePeriodic applies a new address, unless the rotation has been
+// stopped while waiting for the lock.
+func (mc *MacChanger) rotatePeriodic(period time.Duration, oui string, stop chan struct{}) error {
+	mc.rotateLock.Lock()
+	defer mc.rotateLock.Unlock()
+
+	if mc.periodStop != stop || !mc.Running() {
+		return nil
+	}
+
+	mac, err := mc.periodicMac(oui)
+	if err != nil {
+		return err
+	}
+	return mc.rotateToUnlocked(mac, fmt.Sprintf("after %s", period))
+}
@@ -0,0 +1,105 @@
+package modules
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bettercap/bettercap/session"
+)
+
+func TestMacChangerNormalizeOui(t *testing.T) {
+	var units = []struct {
+		prefix string
+		oui    string
+		valid  bool
+	}{
+		{"", "", true},
+		{"00:11:22", "001122", true},
+		{"AA-BB-CC", "aabbcc", true},
+		{"aabbcc", "aabbcc", true},
+		{"aa:bb", "", false},
+		{"aa:bb:cc:dd", "", false},
+		{"zz:bb:cc", "", false},
+	}
+
+	for _, u := range units {
+		oui, err := normalizeOui(u.prefix)
+		if (err == nil) != u.valid {
+			t.Fatalf("expected valid=%v for '%s', got '%v'", u.valid, u.prefix, err)
+		} else if oui != u.oui {
+			t.Fatalf("expected '%s', got '%s'", u.oui, oui)
+		}
+	}
+}
+
+func TestMacChangerPeriodicRotation(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	mc := NewMacChanger(s.Session)
+	s.Register(mc)
+
+	if err := s.Set("mac.changer.period", "0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Handle("mac.changer", "mac.changer.rotate on"); err == nil {
+		t.Fatal("expected an error with mac.changer.period 0")
+	} else if mc.Running() {
+		t.Fatal("expected the module not to be started")
+	}
+
+	startMacChanger(t, s)
+	rotated, unsubscribe := s.Events.Subscribe("mac.rotated")
+	defer unsubscribe()
+
+	if err := mc.startPeriodicRotation(20*time.Millisecond, "001122"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := mc.startPeriodicRotation(20*time.Millisecond, ""); err == nil {
+		t.Fatal("expected an error while already rotating")
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case e := <-rotated:
+			changed := e.Data.(MacChangedEvent)
+			if !strings.HasPrefix(changed.New, "00:11:22:") {
+				t.Fatalf("expected the OUI prefix, got '%s'", changed.New)
+			} else if changed.Old == changed.New {
+				t.Fatalf("expected a new address, got '%s'", changed.New)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected a mac.rotated event")
+		}
+	}
+
+	if err := s.Handle("mac.changer", "mac.changer.rotate off"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Handle("mac.changer", "mac.changer.rotate off"); err == nil {
+		t.Fatal("expected an error while not rotating")
+	} else if !mc.Running() {
+		t.Fatal("expected the module to keep running")
+	}
+
+	// the last address is kept
+	current := mc.fakeMac.String()
+	if _, v := s.Env.Get(macChangerCurrentVar); v != current {
+		t.Fatalf("expected '%s', got '%s'", current, v)
+	}
+	changes := len(waitMacCommands(t, s, 1))
+	time.Sleep(60 * time.Millisecond)
+	if got := len(waitMacCommands(t, s, 1)); got != changes {
+		t.Fatalf("expected no rotations once stopped, got %d changes instead of %d", got, changes)
+	}
+
+	// turning the module off stops the rotation too
+	if err := mc.startPeriodicRotation(time.Hour, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Handle("mac.changer", "mac.changer off"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if mc.periodStop != nil {
+		t.Fatal("expected the rotation to be stopped")
+	}
+}
@@ -0,0 +1,188 @@
+package modules
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/network"
+)
+
+const (
+	// the class of the network adapters, the drivers ones have one
+	// NNNN subkey each while the network ones have one per GUID
+	ndisClassKey     = `HKLM\SYSTEM\CurrentControlSet\Control\Class\{4D36E972-E325-11CE-BFC1-08002BE10318}`
+	ndisNetworkKey   = `HKLM\SYSTEM\CurrentControlSet\Control\Network\{4D36E972-E325-11CE-BFC1-08002BE10318}`
+	ndisAddressValue = "NetworkAddress"
+)
+
+var adapterGUIDParser = regexp.MustCompile(`\{[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\}`)
+
+// a value printed by reg query
+type regValue struct {
+	Key   string
+	Name  string
+	Value string
+}
+
+// parseRegQuery parses the output of reg query, every value line follows
+// the line of its key.
+func parseRegQuery(out string) []regValue {
+	values := make([]regValue, 0)
+	key := ""
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimRight(line, "\r ")
+		if strings.HasPrefix(line, "HKEY_") {
+			key = line
+		} else if fields := strings.Fields(line); key != "" && len(fields) >= 2 && strings.HasPrefix(fields[1], "REG_") {
+			values = append(values, regValue{key, fields[0], strings.Join(fields[2:], " ")})
+		}
+	}
+	return values
+}
+
+// findRegValue returns the first value with the name and, if not empty,
+// with the data, case insensitively.
+func findRegValue(values []regValue, name, data string) (regValue, bool) {
+	for _, v := range values {
+		if strings.EqualFold(v.Name, name) && (data == "" || strings.EqualFold(v.Value, data)) {
+			return v, true
+		}
+	}
+	return regValue{}, false
+}
+
+// windowsAdapter is where the address of an adapter is overridden, Name
+// is the connection one netsh uses.
+type windowsAdapter struct {
+	GUID string
+	Name string
+	Key  string
+	// NetworkAddress before the first change, empty if not set
+	Original string
+	// NetworkAddress currently set, empty if not set
+	Current string
+}
+
+func regQuery(key string, args ...string) ([]regValue, error) {
+	out, err := core.ExecSilent("reg", append([]string{"query", key}, args...))
+	if err != nil {
+		return nil, err
+	}
+	return parseRegQuery(out), nil
+}
+
+// findAdapter resolves the registry keys of iface, either a pcap device
+// name like \Device\NPF_{GUID} or a connection name like Ethernet.
+func findAdapter(iface string) (*windowsAdapter, error) {
+	a := &windowsAdapter{GUID: adapterGUIDParser.FindString(iface)}
+
+	if a.GUID == "" {
+		values, err := regQuery(ndisNetworkKey, "/s", "/f", iface, "/d", "/e")
+		if err != nil {
+			return nil, fmt.Errorf("Could not find the connection %s: %s", iface, err)
+		}
+		for _, v := range values {
+			if strings.EqualFold(v.Name, "Name") && strings.EqualFold(v.Value, iface) && strings.HasSuffix(v.Key, `\Connection`) {
+				// the key starts with the GUID of the class
+				if guids := adapterGUIDParser.FindAllString(v.Key, -1); len(guids) == 2 {
+					a.GUID = guids[1]
+				}
+				break
+			}
+		}
+		if a.GUID == "" {
+			return nil, fmt.Errorf("Could not find the connection %s.", iface)
+		}
+		a.Name = iface
+	} else if values, err := regQuery(ndisNetworkKey+`\`+a.GUID+`\Connection`, "/v", "Name"); err != nil {
+		return nil, fmt.Errorf("Could not read the connection name of %s: %s", iface, err)
+	} else if v, found := findRegValue(values, "Name", ""); !found {
+		return nil, fmt.Errorf("Could not read the connection name of %s.", iface)
+	} else {
+		a.Name = v.Value
+	}
+
+	if values, err := regQuery(ndisClassKey, "/s", "/f", a.GUID, "/d", "/e"); err != nil {
+		return nil, fmt.Errorf("Could not find the driver key of %s: %s", a.Name, err)
+	} else if v, found := findRegValue(values, "NetCfgInstanceId", a.GUID); !found {
+		return nil, fmt.Errorf("Could not find the driver key of %s.", a.Name)
+	} else {
+		a.Key = v.Key
+	}
+
+	// exits with an error if not set
+	if values, err := regQuery(a.Key, "/v", ndisAddressValue); err == nil {
+		if v, found := findRegValue(values, ndisAddressValue, ""); found {
+			a.Original = v.Value
+		}
+	}
+	a.Current = a.Original
+
+	log.Debug("Adapter %s (%s) has driver key %s and %s '%s'.", a.Name, a.GUID, a.Key, ndisAddressValue, a.Original)
+	return a, nil
+}
+
+// setRegistryAddress sets the override of the address in the driver key,
+// or removes it if value is empty.
+func setRegistryAddress(key string, value string) (err error) {
+	if value == "" {
+		_, err = core.Exec("reg", []string{"delete", key, "/v", ndisAddressValue, "/f"})
+	} else {
+		_, err = core.Exec("reg", []string{"add", key, "/v", ndisAddressValue, "/t", "REG_SZ", "/d", value, "/f"})
+	}
+	return
+}
+
+// restartAdapter disables and enables the adapter, as the driver only
+// reads the override of the address when it starts.
+func restartAdapter(name string) error {
+	if _, err := core.Exec("netsh", []string{"interface", "set", "interface", name, "admin=disabled"}); err != nil {
+		return err
+	} else if _, err := core.Exec("netsh", []string{"interface", "set", "interface", name, "admin=enabled"}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// applyRegistryMac overrides the address in the driver key of the adapter
+// and restarts it to apply it, the original address is restored by
+// removing the override if there was none.
+func (mc *MacChanger) applyRegistryMac(mac net.HardwareAddr) error {
+	a := mc.adapter
+	if a == nil {
+		return fmt.Errorf("The adapter of %s has not been configured.", mc.iface)
+	}
+
+	if !bytes.Equal(mac, mc.originalMac) && !network.IsLocallyAdministered(mac) {
+		log.Warning("Most Windows WiFi drivers ignore addresses which are not locally administered, like %s.", mac)
+	}
+
+	value := strings.ToUpper(strings.Replace(mac.String(), ":", "", -1))
+	if bytes.Equal(mac, mc.originalMac) {
+		value = a.Original
+	}
+
+	previous := a.Current
+	if err := setRegistryAddress(a.Key, value); err != nil {
+		return err
+	} else if err := restartAdapter(a.Name); err != nil {
+		// don't leave the adapter disabled, nor with an address it couldn't start with
+		if restoreErr := setRegistryAddress(a.Key, previous); restoreErr != nil {
+			log.Error("Could not restore the %s of %s: %s", ndisAddressValue, a.Name, restoreErr)
+		}
+		if _, enableErr := core.Exec("netsh", []string{"interface", "set", "interface", a.Name, "admin=enabled"}); enableErr != nil {
+			log.Error("Could not enable %s again: %s", a.Name, enableErr)
+		}
+		return err
+	}
+	a.Current = value
+
+	mc.setBackend("registry")
+	log.Info("Address of %s changed restarting the adapter.", a.Name)
+	return nil
+}
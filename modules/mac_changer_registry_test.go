@@ -0,0 +1,157 @@
+package modules
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bettercap/bettercap/session"
+)
+
+const (
+	testAdapterGUID = "{1A2B3C4D-0000-1111-2222-333344445555}"
+	testAdapterKey  = `HKEY_LOCAL_MACHINE\SYSTEM\CurrentControlSet\Control\Class\{4d36e972-e325-11ce-bfc1-08002be10318}\0007`
+)
+
+// fakeRegistry answers the reg queries of findAdapter, with address as
+// the NetworkAddress of the adapter if not empty.
+func fakeRegistry(address string) func(string, []string) (string, error) {
+	return func(executable string, args []string) (string, error) {
+		if executable != "reg" || args[0] != "query" {
+			return "", nil
+		}
+
+		switch key := args[1]; {
+		case key == ndisNetworkKey && args[4] == "Wi-Fi 2":
+			return "\r\n" + `HKEY_LOCAL_MACHINE\SYSTEM\CurrentControlSet\Control\Network\{4D36E972-E325-11CE-BFC1-08002BE10318}\` + testAdapterGUID + `\Connection` + "\r\n" +
+				"    Name    REG_SZ    Wi-Fi 2\r\n\r\nEnd of search: 1 match(es) found.\r\n", nil
+		case strings.HasSuffix(key, testAdapterGUID+`\Connection`):
+			return "\r\n" + strings.Replace(key, "HKLM", "HKEY_LOCAL_MACHINE", 1) + "\r\n    Name    REG_SZ    Wi-Fi 2\r\n\r\n", nil
+		case key == ndisClassKey && args[4] == testAdapterGUID:
+			return "\r\n" + testAdapterKey + "\r\n    NetCfgInstanceId    REG_SZ    " + strings.ToLower(testAdapterGUID) + "\r\n\r\nEnd of search: 1 match(es) found.\r\n", nil
+		case key == testAdapterKey && address != "":
+			return "\r\n" + testAdapterKey + "\r\n    NetworkAddress    REG_SZ    " + address + "\r\n\r\n", nil
+		}
+		return "", fmt.Errorf("ERROR: The system was unable to find the specified registry key or value.")
+	}
+}
+
+func TestMacChangerParseRegQuery(t *testing.T) {
+	out := "\r\nHKEY_LOCAL_MACHINE\\A\r\n    Name    REG_SZ    Wi-Fi 2\r\n    Empty    REG_SZ\r\n\r\nHKEY_LOCAL_MACHINE\\B\r\n    Flags    REG_DWORD    0x1\r\n\r\nEnd of search: 3 match(es) found.\r\n"
+	exp := []regValue{
+		{`HKEY_LOCAL_MACHINE\A`, "Name", "Wi-Fi 2"},
+		{`HKEY_LOCAL_MACHINE\A`, "Empty", ""},
+		{`HKEY_LOCAL_MACHINE\B`, "Flags", "0x1"},
+	}
+
+	if got := parseRegQuery(out); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, got)
+	} else if v, found := findRegValue(got, "flags", ""); !found || v.Key != `HKEY_LOCAL_MACHINE\B` {
+		t.Fatalf("expected Flags, got '%v'", v)
+	} else if _, found := findRegValue(got, "name", "ethernet"); found {
+		t.Fatal("expected no value")
+	}
+}
+
+func TestMacChangerFindAdapter(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	s.ExecOutput = fakeRegistry("")
+	for _, iface := range []string{`\Device\NPF_` + testAdapterGUID, "Wi-Fi 2"} {
+		exp := &windowsAdapter{GUID: testAdapterGUID, Name: "Wi-Fi 2", Key: testAdapterKey}
+		if a, err := findAdapter(iface); err != nil {
+			t.Fatalf("unexpected error for '%s': %v", iface, err)
+		} else if !reflect.DeepEqual(a, exp) {
+			t.Fatalf("expected '%v', got '%v'", exp, a)
+		}
+	}
+
+	s.ExecOutput = fakeRegistry("0A1B2C3D4E5F")
+	if a, err := findAdapter("Wi-Fi 2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if a.Original != "0A1B2C3D4E5F" {
+		t.Fatalf("expected '0A1B2C3D4E5F', got '%s'", a.Original)
+	} else if _, err := findAdapter("Ethernet"); err == nil {
+		t.Fatal("expected error for an unknown connection")
+	}
+}
+
+func TestMacChangerRegistryMac(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	mc := NewMacChanger(s.Session)
+	mc.iface = "Wi-Fi 2"
+	mc.originalMac, _ = net.ParseMAC("0a:1b:2c:3d:4e:5f")
+	fake, _ := net.ParseMAC("26:19:a5:88:a7:a3")
+
+	if err := mc.applyRegistryMac(fake); err == nil {
+		t.Fatal("expected error without an adapter")
+	}
+
+	bounce := []string{
+		"netsh interface set interface Wi-Fi 2 admin=disabled",
+		"netsh interface set interface Wi-Fi 2 admin=enabled",
+	}
+	var units = []struct {
+		original string
+		mac      net.HardwareAddr
+		exp      string
+	}{
+		{"", fake, "reg add " + testAdapterKey + " /v NetworkAddress /t REG_SZ /d 2619A588A7A3 /f"},
+		{"", mc.originalMac, "reg delete " + testAdapterKey + " /v NetworkAddress /f"},
+		{"0a1b2c3d4e5f", mc.originalMac, "reg add " + testAdapterKey + " /v NetworkAddress /t REG_SZ /d 0a1b2c3d4e5f /f"},
+	}
+
+	for _, u := range units {
+		s.ExecOutput = func(string, []string) (string, error) {
+			return "", nil
+		}
+		mc.adapter = &windowsAdapter{GUID: testAdapterGUID, Name: "Wi-Fi 2", Key: testAdapterKey, Original: u.original}
+
+		before := len(s.Executed())
+		if err := mc.applyRegistryMac(u.mac); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if got, exp := s.Executed()[before:], append([]string{u.exp}, bounce...); !reflect.DeepEqual(got, exp) {
+			t.Fatalf("expected '%v', got '%v'", exp, got)
+		} else if got := mc.StatusDetail(); got != "(backend registry)" {
+			t.Fatalf("expected '(backend registry)', got '%s'", got)
+		}
+	}
+	// the previous address is restored and the adapter enabled again
+	enabled := 0
+	s.ExecOutput = func(executable string, args []string) (string, error) {
+		if args[len(args)-1] == "admin=enabled" {
+			if enabled++; enabled == 1 {
+				return "", fmt.Errorf("nope")
+			}
+		}
+		return "", nil
+	}
+	mc.adapter = &windowsAdapter{GUID: testAdapterGUID, Name: "Wi-Fi 2", Key: testAdapterKey, Current: "0A1B2C3D4E5F"}
+
+	before := len(s.Executed())
+	exp := []string{
+		"reg add " + testAdapterKey + " /v NetworkAddress /t REG_SZ /d 2619A588A7A3 /f",
+		bounce[0],
+		bounce[1],
+		"reg add " + testAdapterKey + " /v NetworkAddress /t REG_SZ /d 0A1B2C3D4E5F /f",
+		bounce[1],
+	}
+	if err := mc.applyRegistryMac(fake); err == nil {
+		t.Fatal("expected error")
+	} else if got := s.Executed()[before:]; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, got)
+	} else if mc.adapter.Current != "0A1B2C3D4E5F" {
+		t.Fatalf("expected the current address to be kept, got '%s'", mc.adapter.Current)
+	}
+}
@@ -0,0 +1,73 @@
+package modules
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+)
+
+// renameInterface brings the link down to rename it as Linux requires,
+// the module and the session follow the new name if it's been applied.
+func (mc *MacChanger) renameInterface(from, to string) error {
+	if mc.netns == "" {
+		if _, err := net.InterfaceByName(to); err == nil {
+			return fmt.Errorf("Can't rename %s to %s, an interface with that name already exists.", from, to)
+		}
+	}
+
+	if _, err := mc.exec("ip", []string{"link", "set", "dev", from, "down"}); err != nil {
+		return err
+	}
+
+	name := to
+	_, err := mc.exec("ip", []string{"link", "set", "dev", from, "name", to})
+	if err != nil {
+		name = from
+	}
+	// bring the link back up even if it couldn't be renamed
+	if _, upErr := mc.exec("ip", []string{"link", "set", "dev", name, "up"}); err == nil {
+		err = upErr
+	}
+
+	if name == to {
+		mc.iface = to
+		if mc.netns == "" && mc.member == "" {
+			mc.Session.Interface.Hostname = to
+			mc.Session.Env.Set("iface.name", to)
+			mc.refreshInterface()
+		}
+		log.Info("Interface %s renamed to %s.", from, core.Bold(to))
+	}
+
+	return err
+}
+
+// applyName renames the interface to mac.changer.rename, if set.
+func (mc *MacChanger) applyName() error {
+	if mc.rename == "" || mc.rename == mc.iface {
+		return nil
+	}
+
+	original := mc.iface
+	err := mc.renameInterface(original, mc.rename)
+	if mc.iface == mc.rename {
+		// renamed, even if the link couldn't be brought up again
+		mc.originalName = original
+	}
+	return err
+}
+
+// restoreName gives the interface its original name back, if renamed.
+func (mc *MacChanger) restoreName() error {
+	if mc.originalName == "" {
+		return nil
+	}
+
+	err := mc.renameInterface(mc.iface, mc.originalName)
+	if mc.iface == mc.originalName {
+		mc.originalName = ""
+	}
+	return err
+}
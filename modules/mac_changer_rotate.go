@@ -0,0 +1,118 @@
+package modules
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/session"
+)
+
+// the events fired by the rotation itself
+var macRotateOwnTags = []string{"mac.changed", "mac.rotated", "sys.log"}
+
+func checkRotateOn(tag string) error {
+	if tag == "" {
+		return nil
+	}
+	for _, own := range macRotateOwnTags {
+		if strings.HasPrefix(own, tag) {
+			return fmt.Errorf("mac.changer.rotate-on can't be %s, as the rotation fires %s events.", tag, own)
+		}
+	}
+	return nil
+}
+
+// randomAddress returns true if mac.changer.vendor or mac.changer.address
+// give a new address every time they're applied.
+func (mc *MacChanger) randomAddress() bool {
+	if _, vendor := mc.Session.Env.Get("mac.changer.vendor"); vendor != "" {
+		return true
+	}
+	_, raw := mc.Session.Env.Get("mac.changer.address")
+	return raw == session.ParamRandomMAC || strings.HasPrefix(raw, macVendorPrefix)
+}
+
+// startRotation subscribes to the events of mac.changer.rotate-on, if set.
+func (mc *MacChanger) startRotation() {
+	if mc.rotateOn == "" {
+		return
+	} else if !mc.randomAddress() {
+		log.Warning("mac.changer.rotate-on is ignored as mac.changer.address is not random.")
+		return
+	}
+
+	mc.rotateLock.Lock()
+	defer mc.rotateLock.Unlock()
+
+	events, unsubscribe := mc.Session.Events.Subscribe(mc.rotateOn)
+	mc.rotateStop = unsubscribe
+	go mc.rotationWorker(events, mc.rotateDebounce)
+
+	log.Info("Interface mac address will be rotated on %s events.", core.Bold(mc.rotateOn))
+}
+
+// stopRotation stops both the event and the scheduled rotations.
+func (mc *MacChanger) stopRotation() {
+	mc.rotateLock.Lock()
+	defer mc.rotateLock.Unlock()
+
+	if mc.rotateStop != nil {
+		mc.rotateStop()
+		mc.rotateStop = nil
+	}
+	mc.stopPeriodicRotationUnlocked()
+}
+
+// rotationWorker rotates on the first event and ignores the ones
+// following it within the debounce period.
+func (mc *MacChanger) rotationWorker(events <-chan session.Event, debounce time.Duration) {
+	last := time.Time{}
+	for e := range events {
+		if !last.IsZero() && time.Since(last) < debounce {
+			log.Debug("Ignoring %s, the address has been rotated %s ago.", e.Tag, time.Since(last).Round(time.Millisecond))
+			continue
+		}
+
+		last = time.Now()
+		if err := mc.rotate(e.Tag); err != nil {
+			log.Error("Error while rotating the mac address on %s: %s", e.Tag, err)
+		}
+	}
+}
+
+// rotate applies a new address, unless the rotation has been stopped
+// while waiting for the lock.
+func (mc *MacChanger) rotate(tag string) error {
+	mc.rotateLock.Lock()
+	defer mc.rotateLock.Unlock()
+
+	if mc.rotateStop == nil || !mc.Running() {
+		return nil
+	}
+
+	mac, err := mc.addressFor(mc.ssid)
+	if err != nil {
+		return err
+	}
+	return mc.rotateToUnlocked(mac, "on "+tag)
+}
+
+// rotateToUnlocked applies the address and fires a mac.rotated event,
+// rotateLock must be held.
+func (mc *MacChanger) rotateToUnlocked(mac net.HardwareAddr, reason string) error {
+	old := mc.fakeMac
+	if err := mc.setMac(mac); err != nil {
+		return err
+	}
+	mc.afterChange(true)
+
+	mc.fakeMac = mac
+	mc.Session.Env.Set(macChangerCurrentVar, mc.fakeMac.String())
+	mc.Session.Events.Add("mac.rotated", MacChangedEvent{mc.iface, old.String(), mac.String()})
+	log.Info("Interface mac address rotated to %s %s", core.Bold(mc.fakeMac.String()), reason)
+	return nil
+}
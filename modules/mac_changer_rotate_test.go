@@ -0,0 +1,96 @@
+package modules
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bettercap/bettercap/session"
+)
+
+// waitMacCommands waits for n address changes to be executed.
+func waitMacCommands(t *testing.T, s *session.TestSession, n int) []string {
+	deadline := time.Now().Add(time.Second)
+	for {
+		changes := []string{}
+		for _, cmd := range s.Executed() {
+			if strings.Contains(cmd, " address ") || strings.Contains(cmd, " ether ") {
+				changes = append(changes, cmd)
+			}
+		}
+
+		if len(changes) >= n {
+			return changes
+		} else if time.Now().After(deadline) {
+			t.Fatalf("expected %d address changes, got '%v'", n, changes)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMacChangerCheckRotateOn(t *testing.T) {
+	var units = []struct {
+		tag   string
+		valid bool
+	}{
+		{"", true},
+		{"wifi.client.new", true},
+		{"wifi.", true},
+		{"mac.", false},
+		{"mac.changed", false},
+		{"mac.rotated", false},
+		{"sys.log", false},
+		{"s", false},
+	}
+
+	for _, u := range units {
+		if err := checkRotateOn(u.tag); (err == nil) != u.valid {
+			t.Fatalf("expected valid=%v for '%s', got '%v'", u.valid, u.tag, err)
+		}
+	}
+}
+
+func TestMacChangerRotateOn(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	mc := NewMacChanger(s.Session)
+	s.Register(mc)
+
+	if err := s.Set("mac.changer.rotate-on", "wifi.client."); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Set("mac.changer.rotate-on.debounce", "1h"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	startMacChanger(t, s)
+
+	first := mc.fakeMac.String()
+	s.Events.Add("wifi.ap.new", nil)
+	s.Events.Add("wifi.client.new", nil)
+	waitMacCommands(t, s, 2)
+
+	// debounced
+	s.Events.Add("wifi.client.probe", nil)
+	time.Sleep(50 * time.Millisecond)
+	if changes := waitMacCommands(t, s, 2); len(changes) != 2 {
+		t.Fatalf("expected the rotation to be debounced, got '%v'", changes)
+	} else if _, v := s.Env.Get(macChangerCurrentVar); v == first || v != mc.fakeMac.String() {
+		t.Fatalf("expected a new address, got '%s' (was '%s')", v, first)
+	}
+
+	if err := s.Handle("mac.changer", "mac.changer off"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if got := s.Interface.HW.String(); got != session.TestInterfaceMAC {
+		t.Fatalf("expected '%s', got '%s'", session.TestInterfaceMAC, got)
+	}
+
+	// not rotating anymore once off
+	s.Events.Add("wifi.client.new", nil)
+	time.Sleep(50 * time.Millisecond)
+	if changes := waitMacCommands(t, s, 3); len(changes) != 3 {
+		t.Fatalf("expected no rotations when off, got '%v'", changes)
+	}
+}
@@ -0,0 +1,90 @@
+package modules
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/network"
+	"github.com/bettercap/bettercap/session"
+)
+
+// currentMac reads the address the interface has now.
+func (mc *MacChanger) currentMac() (net.HardwareAddr, error) {
+	if mc.netns != "" {
+		return mc.namespacedMac()
+	} else if mc.member != "" {
+		return network.InterfaceAddress(mc.sysfs, mc.iface)
+	}
+	return mc.Session.Interface.HW, nil
+}
+
+// timedSetMac applies the address as a self test step.
+func (mc *MacChanger) timedSetMac(name string, mac net.HardwareAddr) (readinessCheck, time.Duration) {
+	started := time.Now()
+	err := mc.setMac(mac)
+	took := time.Since(started)
+
+	if err != nil {
+		return readinessCheck{Name: name, State: checkFailed, Details: err.Error()}, took
+	}
+	return readinessCheck{Name: name, State: checkPassed, Details: mac.String()}, took
+}
+
+// SelfTest applies a random address and restores the original one right
+// away, the original address is restored even if applying the random
+// one failed half way.
+func (mc *MacChanger) SelfTest() error {
+	if mc.Running() {
+		return fmt.Errorf("mac.changer is running, turn it off before the self test.")
+	} else if err := mc.Session.RequirePrivileges(mc.Name(), session.CapNetAdmin); err != nil {
+		return err
+	} else if err := mc.Configure(); err != nil {
+		return err
+	} else if err := mc.Session.CheckSafe(mc.Name(), mc.iface); err != nil {
+		return err
+	}
+
+	original := mc.originalMac
+	random, err := randomUnicastMac()
+	if err != nil {
+		return err
+	}
+
+	log.Info("Testing %s with %s, restoring %s right after ...", mc.iface, random, original)
+
+	set, setTook := mc.timedSetMac("Set random address", random)
+	restore, restoreTook := mc.timedSetMac("Restore original address", original)
+	if restore.State == checkFailed {
+		log.Warning("Could not restore %s, retrying: %s", original, restore.Details)
+		restore, restoreTook = mc.timedSetMac(restore.Name, original)
+	}
+
+	found := readinessCheck{Name: "Interface as found", State: checkPassed, Details: original.String()}
+	if now, err := mc.currentMac(); err != nil {
+		found.State, found.Details = checkUnknown, err.Error()
+	} else if !bytes.Equal(now, original) {
+		found.State, found.Details = checkFailed, fmt.Sprintf("%s instead of %s", now, original)
+	}
+
+	rows := [][]string{
+		{set.Name, checkState(set.State), setTook.String(), set.Details},
+		{restore.Name, checkState(restore.State), restoreTook.String(), restore.Details},
+		{found.Name, checkState(found.State), "", found.Details},
+	}
+
+	fmt.Println()
+	core.AsTable(os.Stdout, []string{"Step", "Passed", "Took", "Details"}, rows)
+	fmt.Println()
+
+	if restore.State == checkFailed || found.State == checkFailed {
+		return fmt.Errorf("Self test failed, %s could not be restored on %s.", original, mc.iface)
+	} else if set.State == checkFailed {
+		return fmt.Errorf("Self test failed, the address of %s could not be changed.", mc.iface)
+	}
+	return nil
+}
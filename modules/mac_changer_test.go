@@ -0,0 +1,938 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bettercap/bettercap/network"
+	"github.com/bettercap/bettercap/session"
+)
+
+// startMacChanger turns the module on and waits for its start callback,
+// which logs asynchronously, so that it doesn't outlive the session.
+func startMacChanger(t *testing.T, s *session.TestSession) {
+	s.Events = session.NewEventPool(false, false)
+	logs, unsubscribe := s.Events.Subscribe("sys.log")
+	defer unsubscribe()
+
+	if err := s.Handle("mac.changer", "mac.changer on"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for started := false; !started; {
+		select {
+		case e := <-logs:
+			started = strings.HasPrefix(e.Data.(session.LogMessage).Message, "Interface mac address set to")
+		case <-time.After(time.Second):
+			t.Fatal("expected the module to start")
+		}
+	}
+}
+
+func TestMacChangerParseSSIDMacs(t *testing.T) {
+	macs, err := parseSSIDMacs([]string{"CorpWiFi=00:11:22:33:44:55", "a=b=aa-bb-cc-dd-ee-ff", "Guest = seed:lab-run-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var units = []struct {
+		ssid string
+		mac  string
+	}{
+		{"CorpWiFi", "00:11:22:33:44:55"},
+		{"a=b", "aa:bb:cc:dd:ee:ff"},
+		{"Guest", "26:19:a5:88:a7:a3"},
+	}
+
+	for _, u := range units {
+		if hw, found := macs[u.ssid]; !found {
+			t.Fatalf("expected SSID '%s' to be found", u.ssid)
+		} else if hw.String() != u.mac {
+			t.Fatalf("expected '%s', got '%s'", u.mac, hw.String())
+		}
+	}
+
+	for _, bad := range []string{"CorpWiFi", "=00:11:22:33:44:55", "CorpWiFi=nope"} {
+		if _, err := parseSSIDMacs([]string{bad}); err == nil {
+			t.Fatalf("expected error for '%s'", bad)
+		}
+	}
+}
+
+func TestMacChangerSetArgs(t *testing.T) {
+	mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:01")
+
+	var units = []struct {
+		goos string
+		args []string
+	}{
+		{"linux", []string{"eth0", "hw", "ether", "aa:bb:cc:dd:ee:01"}},
+		{"android", []string{"eth0", "hw", "ether", "aa:bb:cc:dd:ee:01"}},
+		{"darwin", []string{"eth0", "ether", "aa:bb:cc:dd:ee:01"}},
+		{"freebsd", []string{"eth0", "ether", "aa:bb:cc:dd:ee:01"}},
+		{"openbsd", []string{"eth0", "ether", "aa:bb:cc:dd:ee:01"}},
+		{"netbsd", []string{"eth0", "ether", "aa:bb:cc:dd:ee:01"}},
+		{"windows", nil},
+		{"plan9", nil},
+	}
+
+	for _, u := range units {
+		args, err := macSetArgs(u.goos, "eth0", mac)
+		if u.args == nil {
+			if err == nil {
+				t.Fatalf("expected error for '%s', got '%v'", u.goos, args)
+			} else if !strings.Contains(err.Error(), u.goos) {
+				t.Fatalf("expected the OS in the error, got '%s'", err)
+			}
+		} else if err != nil {
+			t.Fatalf("unexpected error for '%s': %v", u.goos, err)
+		} else if !reflect.DeepEqual(args, u.args) {
+			t.Fatalf("expected '%v', got '%v'", u.args, args)
+		}
+	}
+}
+
+func TestMacChangerVendorMac(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	hw, err := parseChangerMac("vendor:apple")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if vendor := network.OuiLookup(hw.String()); vendor != "Apple" {
+		t.Fatalf("expected 'Apple', got '%s'", vendor)
+	}
+
+	for _, bad := range []string{"vendor:", "vendor:samsung", "vendor:no such vendor"} {
+		if _, err := parseChangerMac(bad); err == nil {
+			t.Fatalf("expected error for '%s'", bad)
+		}
+	}
+
+	s.Register(NewMacChanger(s.Session))
+	if err := s.Set("mac.changer.address", "vendor:Nintendo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestMacChangerVendorParam(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	mc := NewMacChanger(s.Session)
+	s.Register(mc)
+
+	if err := s.Set("mac.changer.address", "seed:lab-run-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, vendor := range []string{"nintendo", "E0:0C:7F"} {
+		if err := s.Set("mac.changer.vendor", vendor); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if hw, err := mc.addressFor(""); err != nil {
+			t.Fatalf("unexpected error for '%s': %v", vendor, err)
+		} else if got := network.OuiLookup(hw.String()); got != "Nintendo Co." {
+			t.Fatalf("expected 'Nintendo Co.', got '%s'", got)
+		} else if !mc.randomAddress() {
+			t.Fatalf("expected '%s' to be random", vendor)
+		}
+	}
+
+	for _, bad := range []string{"samsung", "ff:ff:fe"} {
+		if err := s.Set("mac.changer.vendor", bad); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if err := s.Handle("mac.changer", "mac.changer on"); err == nil {
+			t.Fatalf("expected error for '%s'", bad)
+		}
+	}
+}
+
+func TestMacChangerOnOff(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	mc := NewMacChanger(s.Session)
+	s.Register(mc)
+
+	if err := s.Set("mac.changer.address", "seed:lab-run-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Handle("mac.changer", "mac.changer on"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, v := s.Env.Get(macChangerCurrentVar); v != "26:19:a5:88:a7:a3" {
+		t.Fatalf("expected '26:19:a5:88:a7:a3', got '%s'", v)
+	} else if got := s.Interface.HW.String(); got != "26:19:a5:88:a7:a3" {
+		t.Fatalf("expected '26:19:a5:88:a7:a3', got '%s'", got)
+	}
+
+	if err := s.Handle("mac.changer", "mac.changer off"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if got := s.Interface.HW.String(); got != session.TestInterfaceMAC {
+		t.Fatalf("expected '%s', got '%s'", session.TestInterfaceMAC, got)
+	} else if s.Env.Has(macChangerCurrentVar) {
+		t.Fatalf("expected %s to be unset", macChangerCurrentVar)
+	}
+
+	if runtime.GOOS == "linux" {
+		exp := []string{
+			"ip link set dev test0 address 26:19:a5:88:a7:a3",
+			"ip link set dev test0 address " + session.TestInterfaceMAC,
+		}
+		if got := s.Executed(); !reflect.DeepEqual(got, exp) {
+			t.Fatalf("expected '%v', got '%v'", exp, got)
+		}
+	}
+}
+
+func TestMacChangerDoubleOff(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	mc := NewMacChanger(s.Session)
+	s.Register(mc)
+
+	if err := s.Handle("mac.changer", "mac.changer off"); err != nil {
+		t.Fatalf("unexpected error turning off a module never started: %v", err)
+	} else if len(s.Executed()) != 0 {
+		t.Fatalf("expected no commands, got %v", s.Executed())
+	}
+
+	if err := s.Set("mac.changer.address", "seed:lab-run-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Handle("mac.changer", "mac.changer on"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := s.Handle("mac.changer", "mac.changer off"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if got := s.Interface.HW.String(); got != session.TestInterfaceMAC {
+			t.Fatalf("expected '%s', got '%s'", session.TestInterfaceMAC, got)
+		}
+	}
+
+	if runtime.GOOS == "linux" {
+		if got := len(s.Executed()); got != 2 {
+			t.Fatalf("expected 2 commands, got %v", s.Executed())
+		}
+	}
+}
+
+func TestMacChangerLinkFallback(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("ip link commands are only tested on linux")
+	}
+
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	s.ExecOutput = func(executable string, args []string) (string, error) {
+		if executable == "ip" && args[len(args)-2] == "address" {
+			return "", fmt.Errorf("Device or resource busy")
+		}
+		return "", nil
+	}
+
+	mc := NewMacChanger(s.Session)
+	s.Register(mc)
+
+	if err := s.Set("mac.changer.address", "seed:lab-run-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Handle("mac.changer", "mac.changer on"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if got := s.Interface.HW.String(); got != "26:19:a5:88:a7:a3" {
+		t.Fatalf("expected '26:19:a5:88:a7:a3', got '%s'", got)
+	}
+
+	exp := []string{
+		"ip link set dev test0 address 26:19:a5:88:a7:a3",
+		"ip link set dev test0 down",
+		"ifconfig test0 hw ether 26:19:a5:88:a7:a3",
+		"ip link set dev test0 up",
+	}
+	if got := s.Executed(); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, got)
+	}
+}
+
+func TestMacChangerSettle(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("ip link commands are only tested on linux")
+	}
+
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	var changedAt, upAt time.Time
+	s.ExecOutput = func(executable string, args []string) (string, error) {
+		if executable == "ip" && args[len(args)-2] == "address" {
+			return "", fmt.Errorf("Device or resource busy")
+		} else if executable == "ifconfig" {
+			changedAt = time.Now()
+		} else if args[len(args)-1] == "up" {
+			upAt = time.Now()
+		}
+		return "", nil
+	}
+
+	mc := NewMacChanger(s.Session)
+	s.Register(mc)
+
+	settle := 100 * time.Millisecond
+	if err := s.Set("mac.changer.settle", "nope"); err == nil {
+		t.Fatal("expected validation error")
+	} else if err := s.Set("mac.changer.settle", settle.String()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Handle("mac.changer", "mac.changer on"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if changedAt.IsZero() || upAt.IsZero() {
+		t.Fatalf("expected the link down and up fallback, got '%v'", s.Executed())
+	} else if waited := upAt.Sub(changedAt); waited < settle {
+		t.Fatalf("expected to wait at least %s, waited %s", settle, waited)
+	}
+}
+
+func TestMacChangerReassoc(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("reassociation commands are only tested on linux")
+	}
+
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	s.ExecOutput = func(executable string, args []string) (string, error) {
+		if executable == "iw" && args[len(args)-1] == "info" {
+			return "Interface test0\n\tifindex 3\n\tssid lab net\n\ttype managed\n", nil
+		} else if executable == "wpa_cli" {
+			return "", fmt.Errorf("wpa_supplicant not running")
+		}
+		return "", nil
+	}
+
+	mc := NewMacChanger(s.Session)
+	s.Register(mc)
+
+	if err := s.Handle("mac.changer", "mac.changer.reassoc"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !mc.Running() {
+		t.Fatal("expected module to be running")
+	}
+
+	mac := s.Interface.HW
+	if mac.String() == session.TestInterfaceMAC {
+		t.Fatal("expected a new address")
+	} else if mac[0]&0x01 != 0 || !network.IsLocallyAdministered(mac) {
+		t.Fatalf("expected a unicast locally administered address, got '%s'", mac)
+	} else if _, v := s.Env.Get(macChangerCurrentVar); v != mac.String() {
+		t.Fatalf("expected '%s', got '%s'", mac, v)
+	}
+
+	exp := []string{
+		"iw dev test0 info",
+		"ip link set dev test0 down",
+		"ip link set dev test0 address " + mac.String(),
+		"ip link set dev test0 up",
+		"wpa_cli -i test0 reassociate",
+		"iw dev test0 connect lab net",
+	}
+	if got := s.Executed(); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, got)
+	}
+}
+
+func TestMacChangerBeforeAssociation(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	mc := NewMacChanger(s.Session)
+	s.Register(mc)
+
+	if err := s.Set("mac.changer.per-ssid", "lab=00:11:22:33:44:55"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Set("mac.changer.address", "00:11:22:33:44:66"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// other interfaces and SSIDs not in the list don't start the module
+	if err := s.PrepareAssociation("wlan9", "lab"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.PrepareAssociation("test0", "cafe"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if mc.Running() {
+		t.Fatal("expected module to be stopped")
+	}
+
+	s.Events = session.NewEventPool(false, false)
+	logs, unsubscribe := s.Events.Subscribe("sys.log")
+	defer unsubscribe()
+
+	if err := s.PrepareAssociation("test0", "lab"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for started := false; !started; {
+		select {
+		case e := <-logs:
+			started = strings.HasPrefix(e.Data.(session.LogMessage).Message, "Interface mac address set to")
+		case <-time.After(time.Second):
+			t.Fatal("expected the module to start")
+		}
+	}
+
+	var steps = []struct {
+		ssid    string
+		address string
+		mac     string
+	}{
+		{"lab", "00:11:22:33:44:66", "00:11:22:33:44:55"},
+		// leaving a listed SSID
+		{"cafe", "00:11:22:33:44:66", "00:11:22:33:44:66"},
+		// reconnecting to an SSID not in the list keeps the address
+		{"cafe", "00:11:22:33:44:77", "00:11:22:33:44:66"},
+		{"lab", "00:11:22:33:44:77", "00:11:22:33:44:55"},
+		{"cafe", "00:11:22:33:44:77", "00:11:22:33:44:77"},
+	}
+
+	for _, step := range steps {
+		if err := s.Set("mac.changer.address", step.address); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if err := s.PrepareAssociation("test0", step.ssid); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if got := s.Interface.HW.String(); got != step.mac {
+			t.Fatalf("expected '%s' for %s, got '%s'", step.mac, step.ssid, got)
+		}
+	}
+}
+
+func TestMacChangerRevert(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	mc := NewMacChanger(s.Session)
+	s.Register(mc)
+
+	if changed, err := mc.Revert(); err != nil {
+		t.Fatalf("unexpected error reverting a stopped module: %v", err)
+	} else if changed {
+		t.Fatal("expected nothing to be reverted")
+	} else if len(s.Executed()) != 0 {
+		t.Fatalf("expected no commands, got %v", s.Executed())
+	}
+
+	if err := s.Set("mac.changer.address", "seed:lab-run-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Handle("mac.changer", "mac.changer on"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.RevertAll().ErrorOrNil(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if mc.Running() {
+		t.Fatal("expected module to be stopped")
+	} else if got := s.Interface.HW.String(); got != session.TestInterfaceMAC {
+		t.Fatalf("expected '%s', got '%s'", session.TestInterfaceMAC, got)
+	}
+}
+
+func TestMacChangerNetns(t *testing.T) {
+	var units = []struct {
+		netns  string
+		prefix string
+	}{
+		{"lab", "ip netns exec lab "},
+		{"/proc/1234/ns/net", "nsenter --net=/proc/1234/ns/net "},
+	}
+
+	for _, u := range units {
+		s, err := session.NewTestSession()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		s.ExecOutput = func(executable string, args []string) (string, error) {
+			if args[len(args)-1] == "/sys/class/net/test0/address" {
+				return "02:00:00:00:00:01\n", nil
+			}
+			return "", nil
+		}
+
+		mc := NewMacChanger(s.Session)
+		s.Register(mc)
+
+		if err := s.Set("mac.changer.netns", u.netns); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if err := s.Set("mac.changer.address", "seed:lab-run-1"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		err = s.Handle("mac.changer", "mac.changer on")
+		if runtime.GOOS != "linux" {
+			if err == nil {
+				t.Fatalf("expected error on %s", runtime.GOOS)
+			}
+			s.Close()
+			continue
+		} else if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if got := s.Interface.HW.String(); got != session.TestInterfaceMAC {
+			t.Fatalf("expected '%s', got '%s'", session.TestInterfaceMAC, got)
+		} else if err := s.Handle("mac.changer", "mac.changer.reassoc"); err == nil {
+			t.Fatalf("expected reassociation to be rejected inside %s", u.netns)
+		} else if err := s.Handle("mac.changer", "mac.changer off"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		exp := []string{
+			u.prefix + "cat /sys/class/net/test0/address",
+			u.prefix + "ip link set dev test0 address 26:19:a5:88:a7:a3",
+			u.prefix + "ip link set dev test0 address 02:00:00:00:00:01",
+		}
+		if got := s.Executed(); !reflect.DeepEqual(got, exp) {
+			t.Fatalf("expected '%v', got '%v'", exp, got)
+		}
+		s.Close()
+	}
+}
+
+func TestMacChangerMaster(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("bond and bridge members are only detected on linux")
+	}
+
+	root, err := ioutil.TempDir("", "sysfs")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	if err := os.MkdirAll(filepath.Join(root, "bond0", "bonding"), 0755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := os.MkdirAll(filepath.Join(root, session.TestInterfaceName), 0755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := os.Symlink("../bond0", filepath.Join(root, session.TestInterfaceName, "master")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := ioutil.WriteFile(filepath.Join(root, "bond0", "address"), []byte("02:00:00:00:00:01\n"), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	// the address is verified reading it back from sysfs
+	ignore := false
+	s.ExecOutput = func(executable string, args []string) (string, error) {
+		if executable == "ip" && args[len(args)-2] == "address" && !ignore {
+			return "", ioutil.WriteFile(filepath.Join(root, args[3], "address"), []byte(args[len(args)-1]+"\n"), 0644)
+		}
+		return "", nil
+	}
+
+	mc := NewMacChanger(s.Session)
+	mc.sysfs = root
+	s.Register(mc)
+
+	if err := s.Set("mac.changer.address", "seed:lab-run-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Handle("mac.changer", "mac.changer on"); err == nil {
+		t.Fatal("expected the bond member to be refused")
+	} else if !strings.Contains(err.Error(), "set mac.changer.iface to bond0") {
+		t.Fatalf("expected guidance, got '%s'", err)
+	} else if len(s.Executed()) != 0 {
+		t.Fatalf("expected no commands, got '%v'", s.Executed())
+	}
+
+	if err := s.Set("mac.changer.master", "true"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	startMacChanger(t, s)
+
+	if got := s.Interface.HW.String(); got != session.TestInterfaceMAC {
+		t.Fatalf("expected '%s', got '%s'", session.TestInterfaceMAC, got)
+	} else if err := s.Handle("mac.changer", "mac.changer.reassoc"); err == nil {
+		t.Fatal("expected reassociation to be rejected on the master")
+	} else if err := s.Handle("mac.changer", "mac.changer off"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exp := []string{
+		"ip link set dev bond0 address 26:19:a5:88:a7:a3",
+		"ip link set dev bond0 address 02:00:00:00:00:01",
+	}
+	if got := s.Executed(); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, got)
+	}
+
+	// a driver silently ignoring the address
+	ignore = true
+	if err := s.Handle("mac.changer", "mac.changer on"); err == nil {
+		t.Fatal("expected the address change to fail verification")
+	} else if !strings.Contains(err.Error(), "bond0 has address 02:00:00:00:00:01") {
+		t.Fatalf("expected verification error, got '%s'", err)
+	} else if mc.Running() {
+		t.Fatal("expected module to be stopped")
+	}
+}
+
+func TestMacChangerDiffersInLast(t *testing.T) {
+	prev, _ := net.ParseMAC("02:11:22:33:44:55")
+
+	var units = []struct {
+		mac      string
+		n        int
+		expected bool
+	}{
+		{"02:11:22:33:44:55", 0, true},
+		{"02:11:22:33:44:56", 1, true},
+		{"02:11:22:33:44:56", 2, false},
+		{"02:11:22:00:00:00", 3, true},
+		{"02:11:22:00:00:00", 4, false},
+		{"03:12:23:34:45:56", 6, true},
+		{"02:12:23:34:45:56", 6, false},
+	}
+
+	for _, u := range units {
+		mac, _ := net.ParseMAC(u.mac)
+		if got := differsInLast(mac, prev, u.n); got != u.expected {
+			t.Fatalf("expected '%v' for %s and %d, got '%v'", u.expected, u.mac, u.n, got)
+		}
+	}
+}
+
+func TestMacChangerRotateMinDiff(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	mc := NewMacChanger(s.Session)
+	s.Register(mc)
+	mc.originalMac, _ = net.ParseMAC("02:11:22:33:44:55")
+
+	candidates := []string{"02:11:22:33:44:66", "02:11:22:33:00:55", "02:11:22:00:00:00"}
+	attempts := 0
+	generate := func() (net.HardwareAddr, error) {
+		mac, _ := net.ParseMAC(candidates[attempts%len(candidates)])
+		attempts++
+		return mac, nil
+	}
+
+	var units = []struct {
+		minDiff  string
+		expected string
+		attempts int
+	}{
+		{"0", "02:11:22:33:44:66", 1},
+		{"1", "02:11:22:33:44:66", 1},
+		{"2", "02:11:22:00:00:00", 3},
+		// can't be satisfied, the last attempt is used
+		{"4", candidates[(macRotateRetries-1)%len(candidates)], macRotateRetries},
+	}
+
+	for _, u := range units {
+		attempts = 0
+		if err := s.Set("mac.changer.rotate.mindiff", u.minDiff); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if mac, err := mc.distantMac(generate); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if mac.String() != u.expected {
+			t.Fatalf("expected '%s', got '%s'", u.expected, mac)
+		} else if attempts != u.attempts {
+			t.Fatalf("expected %d attempts, got %d", u.attempts, attempts)
+		}
+	}
+
+	if err := s.Set("mac.changer.rotate.mindiff", "7"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if _, err := mc.distantMac(generate); err == nil {
+		t.Fatal("expected error for mac.changer.rotate.mindiff out of range")
+	}
+}
+
+func TestMacChangerPrivileges(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	mc := NewMacChanger(s.Session)
+	s.Register(mc)
+
+	s.Privileges = session.Privileges{Known: true, Capabilities: []session.Capability{}}
+	if err := s.Handle("mac.changer", "mac.changer on"); err == nil {
+		t.Fatal("expected error")
+	} else if !strings.HasPrefix(err.Error(), "Insufficient privileges") {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(s.Executed()) != 0 {
+		t.Fatalf("expected no commands, got '%v'", s.Executed())
+	}
+}
+
+func TestMacChangerTimed(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	mc := NewMacChanger(s.Session)
+	s.Register(mc)
+
+	for _, bad := range []string{"mac.changer.timed nope", "mac.changer.timed 0s"} {
+		if err := s.Run(bad); err == nil {
+			t.Fatalf("expected error for '%s'", bad)
+		}
+	}
+
+	if err := s.Run("mac.changer.timed 20ms"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !mc.Running() {
+		t.Fatal("expected module to be running")
+	}
+
+	for deadline := time.Now().Add(time.Second); mc.Running() && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if mc.Running() {
+		t.Fatal("expected the address to be restored")
+	} else if got := s.Interface.HW.String(); got != session.TestInterfaceMAC {
+		t.Fatalf("expected '%s', got '%s'", session.TestInterfaceMAC, got)
+	}
+
+	// off cancels the pending restore
+	if err := s.Run("mac.changer.timed 50ms"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Run("mac.changer off"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Run("mac.changer on"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if !mc.Running() {
+		t.Fatal("expected the cancelled restore not to stop the module")
+	}
+}
+
+func TestMacChangerSelfTest(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the commands are Linux specific")
+	}
+
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	mc := NewMacChanger(s.Session)
+	s.Register(mc)
+
+	restore := "ip link set dev test0 address " + session.TestInterfaceMAC
+	if err := s.Run("mac.changer.selftest"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if got := s.Executed(); len(got) != 2 || got[1] != restore {
+		t.Fatalf("unexpected commands '%v'", got)
+	} else if mc.Running() || s.Interface.HW.String() != session.TestInterfaceMAC {
+		t.Fatalf("expected the interface as found, got '%s'", s.Interface.HW)
+	}
+
+	// the random address can't be applied even with the link down
+	s.Commands = make([]string, 0)
+	s.ExecOutput = func(executable string, args []string) (string, error) {
+		if last := args[len(args)-1]; last != session.TestInterfaceMAC && (executable == "ifconfig" || args[len(args)-2] == "address") {
+			return "", fmt.Errorf("Cannot assign requested address")
+		}
+		return "", nil
+	}
+
+	if err := s.Run("mac.changer.selftest"); err == nil {
+		t.Fatal("expected error")
+	} else if got := s.Executed(); len(got) != 5 || got[3] != "ip link set dev test0 up" || got[4] != restore {
+		t.Fatalf("unexpected commands '%v'", got)
+	} else if s.Interface.HW.String() != session.TestInterfaceMAC {
+		t.Fatalf("expected '%s', got '%s'", session.TestInterfaceMAC, s.Interface.HW)
+	}
+
+	s.ExecOutput = nil
+	if err := s.Run("mac.changer on"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Run("mac.changer.selftest"); err == nil {
+		t.Fatal("expected error while running")
+	}
+}
+
+func TestMacChangerRename(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("renaming interfaces is Linux only")
+	}
+
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	mc := NewMacChanger(s.Session)
+	s.Register(mc)
+
+	if err := s.Set("mac.changer.rename", "not/valid"); err == nil {
+		t.Fatal("expected error for invalid name")
+	} else if err := s.Set("mac.changer.address", "seed:lab-run-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Set("mac.changer.rename", "lab0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Run("mac.changer on"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if s.Interface.Name() != "lab0" {
+		t.Fatalf("expected 'lab0', got '%s'", s.Interface.Name())
+	} else if _, name := s.Env.Get("iface.name"); name != "lab0" {
+		t.Fatalf("expected 'lab0', got '%s'", name)
+	} else if err := s.Run("mac.changer off"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if s.Interface.Name() != session.TestInterfaceName {
+		t.Fatalf("expected '%s', got '%s'", session.TestInterfaceName, s.Interface.Name())
+	}
+
+	exp := []string{
+		"ip link set dev test0 address 26:19:a5:88:a7:a3",
+		"ip link set dev test0 down",
+		"ip link set dev test0 name lab0",
+		"ip link set dev lab0 up",
+		"ip link set dev lab0 down",
+		"ip link set dev lab0 name test0",
+		"ip link set dev test0 up",
+		"ip link set dev test0 address " + session.TestInterfaceMAC,
+	}
+	if got := s.Executed(); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, got)
+	}
+
+	// the address is restored if the name is taken
+	s.Commands = make([]string, 0)
+	if err := s.Set("mac.changer.rename", "lo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Run("mac.changer on"); err == nil {
+		t.Fatal("expected error for name collision")
+	} else if mc.Running() || s.Interface.HW.String() != session.TestInterfaceMAC {
+		t.Fatalf("expected the interface as found, got '%s'", s.Interface.HW)
+	} else if got := s.Executed(); len(got) != 2 {
+		t.Fatalf("unexpected commands '%v'", got)
+	}
+
+	// renamed but the link can't be brought up, the rename is rolled back
+	s.Commands = make([]string, 0)
+	s.ExecOutput = func(executable string, args []string) (string, error) {
+		if strings.Join(args, " ") == "link set dev lab0 up" {
+			return "", fmt.Errorf("Operation not permitted")
+		}
+		return "", nil
+	}
+	if err := s.Set("mac.changer.rename", "lab0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Run("mac.changer on"); err == nil {
+		t.Fatal("expected error bringing the link up")
+	} else if mc.Running() || s.Interface.Name() != session.TestInterfaceName || mc.originalName != "" {
+		t.Fatalf("expected the interface as found, got '%s'", s.Interface.Name())
+	}
+
+	exp = []string{
+		"ip link set dev test0 address 26:19:a5:88:a7:a3",
+		"ip link set dev test0 down",
+		"ip link set dev test0 name lab0",
+		"ip link set dev lab0 up",
+		"ip link set dev lab0 down",
+		"ip link set dev lab0 name test0",
+		"ip link set dev test0 up",
+		"ip link set dev test0 address " + session.TestInterfaceMAC,
+	}
+	if got := s.Executed(); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, got)
+	}
+}
+
+func TestMacChangerHelpJSON(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	mc := NewMacChanger(s.Session)
+	s.Register(mc)
+
+	raw, err := json.Marshal(s.HelpInfo())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	info := session.HelpInfo{}
+	if err := json.Unmarshal(raw, &info); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(info.Modules) != 1 {
+		t.Fatalf("expected 1 module, got %d", len(info.Modules))
+	}
+
+	m := info.Modules[0]
+	if m.Name != mc.Name() || m.Description != mc.Description() || m.Author != mc.Author() {
+		t.Fatalf("unexpected module metadata '%+v'", m)
+	} else if len(m.Parameters) != len(mc.Parameters()) {
+		t.Fatalf("expected %d parameters, got %d", len(mc.Parameters()), len(m.Parameters))
+	} else if len(m.Handlers) != len(mc.Handlers()) {
+		t.Fatalf("expected %d handlers, got %d", len(mc.Handlers()), len(m.Handlers))
+	}
+
+	for _, p := range m.Parameters {
+		if exp := mc.Param(p.Name); exp == nil {
+			t.Fatalf("unexpected parameter '%s'", p.Name)
+		} else if p.Default != exp.Value || p.Description != exp.Description {
+			t.Fatalf("unexpected parameter '%+v'", p)
+		} else if exp.Validator != nil && p.Validator != exp.Validator.String() {
+			t.Fatalf("expected '%s', got '%s'", exp.Validator.String(), p.Validator)
+		}
+	}
+
+	for i, h := range mc.Handlers() {
+		if m.Handlers[i] != h.Info() {
+			t.Fatalf("expected '%+v', got '%+v'", h.Info(), m.Handlers[i])
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package modules
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/network"
+	"github.com/bettercap/bettercap/session"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+const (
+	macDuplicateMultipleIPs = "multiple addresses"
+	macDuplicateMismatch    = "arp sender mismatch"
+)
+
+type MacDuplicateEvent struct {
+	MAC       string   `json:"mac"`
+	Vendor    string   `json:"vendor"`
+	Addresses []string `json:"addresses"`
+	Reason    string   `json:"reason"`
+	Ours      bool     `json:"ours"`
+}
+
+// MacMonitor watches the ARP and IPv4 traffic read by the packet queue
+// for hardware addresses that are used by more than one host.
+type MacMonitor struct {
+	session.SessionModule
+	window   time.Duration
+	lock     *sync.Mutex
+	claims   map[string]map[string]time.Time
+	reported map[string]time.Time
+}
+
+func NewMacMonitor(s *session.Session) *MacMonitor {
+	m := &MacMonitor{
+		SessionModule: session.NewSessionModule("mac.monitor", s),
+		lock:          &sync.Mutex{},
+	}
+	m.reset()
+
+	m.AddParam(session.NewDurationParameter("mac.monitor.window",
+		"1m",
+		"A hardware address claiming more than one IPv4 address within this time is reported as duplicated."))
+
+	m.AddHandler(session.NewModuleHandler("mac.monitor on", "",
+		"Start monitoring the LAN for duplicated hardware addresses.",
+		func(args []string) error {
+			return m.Start()
+		}))
+
+	m.AddHandler(session.NewModuleHandler("mac.monitor off", "",
+		"Stop monitoring the LAN for duplicated hardware addresses.",
+		func(args []string) error {
+			return m.Stop()
+		}))
+
+	return m
+}
+
+func (m *MacMonitor) Name() string {
+	return "mac.monitor"
+}
+
+func (m *MacMonitor) Description() string {
+	return "Fires a mac.duplicate event when the same hardware address is used by different hosts, including clones of our own."
+}
+
+func (m *MacMonitor) Author() string {
+	return "Simone Margaritelli <evilsocket@protonmail.com>"
+}
+
+func (m *MacMonitor) Dependencies() []string {
+	return []string{"arp.spoof"}
+}
+
+func (m *MacMonitor) reset() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.claims = make(map[string]map[string]time.Time)
+	m.reported = make(map[string]time.Time)
+}
+
+func (m *MacMonitor) Configure() (err error) {
+	if m.Running() {
+		return session.ErrAlreadyStarted
+	} else if m.Session.Queue == nil {
+		return fmt.Errorf("mac.monitor needs the packet queue, which is not available on this interface.")
+	} else if err, m.window = m.DurationParam("mac.monitor.window"); err != nil {
+		return err
+	}
+	m.reset()
+	return nil
+}
+
+// our own replies claim every address we're spoofing
+func (m *MacMonitor) isSpoofing(mac net.HardwareAddr) bool {
+	return bytes.Equal(mac, m.Session.Interface.HW) && m.Session.IsOn("arp.spoof")
+}
+
+func (m *MacMonitor) isLocal(ip net.IP) bool {
+	return m.Session.Interface.Net != nil && m.Session.Interface.Net.Contains(ip)
+}
+
+// claim records that mac is being used by ip and returns every address
+// it's been used by within the time window, including ours for our mac.
+func (m *MacMonitor) claim(mac net.HardwareAddr, ip net.IP, now time.Time) []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	hw := mac.String()
+	addresses, found := m.claims[hw]
+	if !found {
+		addresses = make(map[string]time.Time)
+		m.claims[hw] = addresses
+	}
+	addresses[ip.String()] = now
+
+	if bytes.Equal(mac, m.Session.Interface.HW) {
+		addresses[m.Session.Interface.IpAddress] = now
+	}
+
+	claimed := make([]string, 0)
+	for addr, seen := range addresses {
+		if now.Sub(seen) > m.window {
+			delete(addresses, addr)
+		} else {
+			claimed = append(claimed, addr)
+		}
+	}
+	sort.Strings(claimed)
+	return claimed
+}
+
+// shouldReport makes sure the same conflict is reported at most once per window.
+func (m *MacMonitor) shouldReport(key string, now time.Time) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if last, found := m.reported[key]; found && now.Sub(last) <= m.window {
+		return false
+	}
+	m.reported[key] = now
+	return true
+}
+
+func (m *MacMonitor) report(mac net.HardwareAddr, addresses []string, reason string, now time.Time) {
+	if !m.shouldReport(mac.String()+"/"+reason, now) {
+		return
+	}
+
+	hw := network.NormalizeMac(mac.String())
+	event := MacDuplicateEvent{
+		MAC:       hw,
+		Vendor:    network.OuiLookup(hw),
+		Addresses: addresses,
+		Reason:    reason,
+		Ours:      bytes.Equal(mac, m.Session.Interface.HW),
+	}
+
+	log.Debug("mac.monitor: %s %s %v", hw, reason, addresses)
+	m.Session.Events.Add("mac.duplicate", event)
+}
+
+func (m *MacMonitor) onSender(mac net.HardwareAddr, ip net.IP, now time.Time) {
+	if ip.IsUnspecified() || !m.isLocal(ip) || m.isSpoofing(mac) {
+		return
+	} else if network.IsBroadcastMac(mac) || network.IsZeroMac(mac) {
+		return
+	}
+
+	if claimed := m.claim(mac, ip, now); len(claimed) > 1 {
+		m.report(mac, claimed, macDuplicateMultipleIPs, now)
+	}
+}
+
+func (m *MacMonitor) onPacket(pkt gopacket.Packet) {
+	leth := pkt.Layer(layers.LayerTypeEthernet)
+	if leth == nil {
+		return
+	}
+	eth := leth.(*layers.Ethernet)
+	now := time.Now()
+
+	if larp := pkt.Layer(layers.LayerTypeARP); larp != nil {
+		arp := larp.(*layers.ARP)
+		mac := net.HardwareAddr(arp.SourceHwAddress)
+		ip := net.IP(arp.SourceProtAddress)
+
+		if !bytes.Equal(eth.SrcMAC, mac) && !m.isSpoofing(eth.SrcMAC) {
+			m.report(mac, []string{ip.String()}, macDuplicateMismatch, now)
+		}
+		m.onSender(mac, ip, now)
+	} else if lip4 := pkt.Layer(layers.LayerTypeIPv4); lip4 != nil {
+		m.onSender(eth.SrcMAC, lip4.(*layers.IPv4).SrcIP, now)
+	}
+}
+
+func (m *MacMonitor) Start() error {
+	if err := m.Configure(); err != nil {
+		return err
+	}
+
+	return m.SetRunning(true, func() {
+		log.Info("mac.monitor watching for duplicated hardware addresses (window %s)", m.window)
+		m.Session.Queue.AddPacketListener(m.Name(), m.onPacket)
+	})
+}
+
+func (m *MacMonitor) Stop() error {
+	return m.SetRunning(false, func() {
+		m.Session.Queue.RemovePacketListener(m.Name())
+	})
+}
@@ -0,0 +1,121 @@
+package modules
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bettercap/bettercap/packets"
+	"github.com/bettercap/bettercap/session"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func arpClaimPacket(t *testing.T, ethSrc, sender, ip string) gopacket.Packet {
+	ethHW, _ := net.ParseMAC(ethSrc)
+	senderHW, _ := net.ParseMAC(sender)
+	gwHW, _ := net.ParseMAC(session.TestGatewayMAC)
+
+	eth, arp := packets.NewARPTo(net.ParseIP(ip), senderHW, net.ParseIP(session.TestGatewayIP), gwHW, layers.ARPReply)
+	eth.SrcMAC = ethHW
+
+	err, raw := packets.Serialize(&eth, &arp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return gopacket.NewPacket(raw, layers.LayerTypeEthernet, gopacket.Default)
+}
+
+func macDuplicates(s *session.TestSession) []MacDuplicateEvent {
+	dups := make([]MacDuplicateEvent, 0)
+	for _, e := range s.Events.Sorted() {
+		if e.Tag == "mac.duplicate" {
+			dups = append(dups, e.Data.(MacDuplicateEvent))
+		}
+	}
+	return dups
+}
+
+func TestMacMonitorDuplicates(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	m := NewMacMonitor(s.Session)
+	m.window = time.Minute
+
+	clone := "de:ad:be:ef:00:01"
+	m.onPacket(arpClaimPacket(t, clone, clone, "192.168.1.10"))
+	m.onPacket(arpClaimPacket(t, clone, clone, "192.168.1.10"))
+	// outside of the subnet
+	m.onPacket(arpClaimPacket(t, clone, clone, "10.0.0.1"))
+	if dups := macDuplicates(s); len(dups) != 0 {
+		t.Fatalf("expected no duplicates, got %v", dups)
+	}
+
+	m.onPacket(arpClaimPacket(t, clone, clone, "192.168.1.20"))
+	m.onPacket(arpClaimPacket(t, clone, clone, "192.168.1.20"))
+	m.onPacket(arpClaimPacket(t, session.TestInterfaceMAC, session.TestInterfaceMAC, "192.168.1.30"))
+	m.onPacket(arpClaimPacket(t, "de:ad:be:ef:00:02", "de:ad:be:ef:00:03", "192.168.1.40"))
+
+	exp := []MacDuplicateEvent{
+		{
+			MAC:       clone,
+			Addresses: []string{"192.168.1.10", "192.168.1.20"},
+			Reason:    macDuplicateMultipleIPs,
+		},
+		{
+			MAC:       session.TestInterfaceMAC,
+			Addresses: []string{"192.168.1.2", "192.168.1.30"},
+			Reason:    macDuplicateMultipleIPs,
+			Ours:      true,
+		},
+		{
+			MAC:       "de:ad:be:ef:00:03",
+			Addresses: []string{"192.168.1.40"},
+			Reason:    macDuplicateMismatch,
+		},
+	}
+
+	got := macDuplicates(s)
+	for i := range got {
+		got[i].Vendor = ""
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, got)
+	}
+}
+
+func TestMacMonitorWindow(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	m := NewMacMonitor(s.Session)
+	m.window = time.Minute
+
+	mac, _ := net.ParseMAC("de:ad:be:ef:00:01")
+	now := time.Now()
+	m.claim(mac, net.ParseIP("192.168.1.10"), now.Add(-2*time.Minute))
+	if got := m.claim(mac, net.ParseIP("192.168.1.20"), now); !reflect.DeepEqual(got, []string{"192.168.1.20"}) {
+		t.Fatalf("expected old claims to expire, got '%v'", got)
+	}
+
+	if !m.shouldReport("a", now) {
+		t.Fatal("expected first report")
+	} else if m.shouldReport("a", now.Add(time.Second)) {
+		t.Fatal("expected report within the window to be skipped")
+	} else if !m.shouldReport("a", now.Add(2*time.Minute)) {
+		t.Fatal("expected report after the window")
+	}
+
+	if err := m.Configure(); err == nil {
+		t.Fatal("expected error without a packet queue")
+	}
+}
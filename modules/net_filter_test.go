@@ -0,0 +1,152 @@
+package modules
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/bettercap/bettercap/session"
+)
+
+func TestNetFilter(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("filtering by hardware address is Linux only")
+	}
+
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	failOn := ""
+	s.ExecOutput = func(executable string, args []string) (string, error) {
+		if line := executable + " " + strings.Join(args, " "); failOn != "" && line == failOn {
+			return "", fmt.Errorf("nope")
+		}
+		return "", nil
+	}
+
+	f := NewNetFilter(s.Session)
+	s.Register(f)
+
+	for _, bad := range []string{
+		"net.filter nope in drop",
+		"net.filter aa:bb:cc:dd:ee in drop",
+		"net.filter.del aa:bb:cc:dd:ee:01",
+	} {
+		if err := s.Run(bad); err == nil {
+			t.Fatalf("expected error for '%s'", bad)
+		}
+	}
+
+	if err := s.Run("net.filter AA-BB-CC-DD-EE-1 in drop"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !f.Running() {
+		t.Fatal("expected module to be running with a filter installed")
+	} else if err := s.Run("net.filter aa:bb:cc:dd:ee:01 in allow"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Run("net.filter aa:bb:cc:dd:ee:02 out drop"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(f.filters) != 2 {
+		t.Fatalf("expected 2 filters, got %d", len(f.filters))
+	}
+
+	// rolled back if one of the rules can't be added
+	failOn = "ebtables -I FORWARD -d aa:bb:cc:dd:ee:03 -j DROP"
+	if err := s.Run("net.filter aa:bb:cc:dd:ee:03 both drop"); err == nil {
+		t.Fatal("expected error")
+	}
+	failOn = ""
+
+	if err := s.Run("net.filter.del aa:bb:cc:dd:ee:01"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.RevertAll().ErrorOrNil(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if f.Running() || len(f.filters) != 0 {
+		t.Fatal("expected every filter to be removed")
+	}
+
+	exp := []string{
+		"ebtables -I INPUT -s aa:bb:cc:dd:ee:01 -j DROP",
+		"ebtables -I FORWARD -s aa:bb:cc:dd:ee:01 -j DROP",
+		// replaced
+		"ebtables -D FORWARD -s aa:bb:cc:dd:ee:01 -j DROP",
+		"ebtables -D INPUT -s aa:bb:cc:dd:ee:01 -j DROP",
+		"ebtables -I INPUT -s aa:bb:cc:dd:ee:01 -j ACCEPT",
+		"ebtables -I FORWARD -s aa:bb:cc:dd:ee:01 -j ACCEPT",
+		"ebtables -I OUTPUT -d aa:bb:cc:dd:ee:02 -j DROP",
+		"ebtables -I FORWARD -d aa:bb:cc:dd:ee:02 -j DROP",
+		// rollback
+		"ebtables -I INPUT -s aa:bb:cc:dd:ee:03 -j DROP",
+		"ebtables -I FORWARD -s aa:bb:cc:dd:ee:03 -j DROP",
+		"ebtables -I OUTPUT -d aa:bb:cc:dd:ee:03 -j DROP",
+		"ebtables -I FORWARD -d aa:bb:cc:dd:ee:03 -j DROP",
+		"ebtables -D OUTPUT -d aa:bb:cc:dd:ee:03 -j DROP",
+		"ebtables -D FORWARD -s aa:bb:cc:dd:ee:03 -j DROP",
+		"ebtables -D INPUT -s aa:bb:cc:dd:ee:03 -j DROP",
+		// net.filter.del and revert
+		"ebtables -D FORWARD -s aa:bb:cc:dd:ee:01 -j ACCEPT",
+		"ebtables -D INPUT -s aa:bb:cc:dd:ee:01 -j ACCEPT",
+		"ebtables -D FORWARD -d aa:bb:cc:dd:ee:02 -j DROP",
+		"ebtables -D OUTPUT -d aa:bb:cc:dd:ee:02 -j DROP",
+	}
+	if got := s.Executed(); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, got)
+	}
+}
+
+func TestNetFilterOverlap(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("filtering by hardware address is Linux only")
+	}
+
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	f := NewNetFilter(s.Session)
+	s.Register(f)
+
+	for _, cmd := range []string{
+		"net.filter aa:bb:cc:dd:ee:01 in drop",
+		// already installed
+		"net.filter aa:bb:cc:dd:ee:01 in drop",
+		"net.filter aa:bb:cc:dd:ee:01 out drop",
+		// replaces both
+		"net.filter aa:bb:cc:dd:ee:01 both allow",
+		"net.filter aa:bb:cc:dd:ee:01 both allow",
+	} {
+		if err := s.Run(cmd); err != nil {
+			t.Fatalf("unexpected error for '%s': %v", cmd, err)
+		}
+	}
+
+	if err := s.Run("net.filter aa:bb:cc:dd:ee:01 in drop"); err == nil {
+		t.Fatal("expected error narrowing a filter in both directions")
+	} else if len(f.filters) != 1 || f.filters[0].Direction != "both" {
+		t.Fatalf("expected a single filter in both directions, got '%v'", f.filters)
+	}
+
+	exp := []string{
+		"ebtables -I INPUT -s aa:bb:cc:dd:ee:01 -j DROP",
+		"ebtables -I FORWARD -s aa:bb:cc:dd:ee:01 -j DROP",
+		"ebtables -I OUTPUT -d aa:bb:cc:dd:ee:01 -j DROP",
+		"ebtables -I FORWARD -d aa:bb:cc:dd:ee:01 -j DROP",
+		"ebtables -D FORWARD -s aa:bb:cc:dd:ee:01 -j DROP",
+		"ebtables -D INPUT -s aa:bb:cc:dd:ee:01 -j DROP",
+		"ebtables -D FORWARD -d aa:bb:cc:dd:ee:01 -j DROP",
+		"ebtables -D OUTPUT -d aa:bb:cc:dd:ee:01 -j DROP",
+		"ebtables -I INPUT -s aa:bb:cc:dd:ee:01 -j ACCEPT",
+		"ebtables -I FORWARD -s aa:bb:cc:dd:ee:01 -j ACCEPT",
+		"ebtables -I OUTPUT -d aa:bb:cc:dd:ee:01 -j ACCEPT",
+		"ebtables -I FORWARD -d aa:bb:cc:dd:ee:01 -j ACCEPT",
+	}
+	if got := s.Executed(); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, got)
+	}
+}
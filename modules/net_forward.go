@@ -0,0 +1,142 @@
+package modules
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/firewall"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/session"
+)
+
+type NetForward struct {
+	session.SessionModule
+	egress *firewall.Egress
+}
+
+func NewNetForward(s *session.Session) *NetForward {
+	f := &NetForward{
+		SessionModule: session.NewSessionModule("net.forward", s),
+	}
+
+	f.AddParam(session.NewStringParameter("net.forward.out",
+		"",
+		"",
+		"Interface the forwarded traffic of the spoofed hosts has to go out from, instead of the one of the default route."))
+
+	f.AddParam(session.NewStringParameter("net.forward.gateway",
+		"",
+		`^(|\d+\.\d+\.\d+\.\d+)$`,
+		"Next hop on net.forward.out, if empty the one of its default route is used."))
+
+	f.AddHandler(session.NewModuleHandler("net.forward on", "",
+		"Enable forwarding and route the traffic of the spoofed hosts out of net.forward.out.",
+		func(args []string) error {
+			return f.Start()
+		}))
+
+	f.AddHandler(session.NewModuleHandler("net.forward off", "",
+		"Remove the routes and rules added by net.forward on.",
+		func(args []string) error {
+			return f.Stop()
+		}))
+
+	s.RegisterCompleter("net.forward.out", func(prefix string) []string {
+		names := make([]string, 0)
+		if ifaces, err := net.Interfaces(); err == nil {
+			for _, iface := range ifaces {
+				if iface.Name != s.Interface.Name() {
+					names = append(names, iface.Name)
+				}
+			}
+		}
+		return names
+	})
+
+	return f
+}
+
+func (f *NetForward) Name() string {
+	return "net.forward"
+}
+
+func (f *NetForward) Description() string {
+	return "Makes the forwarded traffic of the spoofed hosts leave from a specific interface on multi homed hosts."
+}
+
+func (f *NetForward) Author() string {
+	return "Simone Margaritelli <evilsocket@protonmail.com>"
+}
+
+func (f *NetForward) Configure() error {
+	var err error
+	var out string
+	var gateway string
+
+	if f.Running() {
+		return session.ErrAlreadyStarted
+	} else if err, out = f.StringParam("net.forward.out"); err != nil {
+		return err
+	} else if err, gateway = f.StringParam("net.forward.gateway"); err != nil {
+		return err
+	} else if out == "" {
+		return fmt.Errorf("net.forward.out is empty, set it to the interface to forward the traffic to.")
+	} else if out == f.Session.Interface.Name() {
+		return fmt.Errorf("net.forward.out can't be %s, it's the interface the traffic is coming from.", out)
+	} else if _, err = net.InterfaceByName(out); err != nil {
+		return fmt.Errorf("Could not find interface %s: %s", out, err)
+	}
+
+	f.egress = firewall.NewEgress(f.Session.Interface.Name(), out, f.Session.Interface.CIDR(), gateway)
+	return nil
+}
+
+func (f *NetForward) Start() error {
+	if err := f.Configure(); err != nil {
+		return err
+	}
+
+	if !f.Session.Firewall.IsForwardingEnabled() {
+		log.Info("Enabling forwarding.")
+		if err := f.Session.Firewall.EnableForwarding(true); err != nil {
+			return err
+		}
+	}
+
+	if err := f.egress.Enable(); err != nil {
+		return err
+	}
+
+	return f.SetRunning(true, func() {
+		log.Info("Forwarding traffic of %s", core.Bold(f.egress.String()))
+	})
+}
+
+func (f *NetForward) restore() error {
+	if f.egress == nil || !f.egress.Enabled() {
+		return nil
+	}
+	log.Debug("Removing the egress rules of %s", f.egress)
+	return f.egress.Disable()
+}
+
+func (f *NetForward) Revert() (bool, error) {
+	if !f.Running() {
+		return false, nil
+	}
+
+	var err error
+	f.SetRunning(false, func() {
+		err = f.restore()
+	})
+	return true, err
+}
+
+func (f *NetForward) Stop() error {
+	return f.SetRunning(false, func() {
+		if err := f.restore(); err != nil {
+			log.Error("Error while removing the egress rules: %s", err)
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package modules
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/bettercap/bettercap/session"
+)
+
+func loopbackName(t *testing.T) string {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			return iface.Name
+		}
+	}
+	t.Skip("no loopback interface to run test with")
+	return ""
+}
+
+func netForwardSession(t *testing.T, failOn string) (*session.TestSession, *fakeFirewall, *NetForward) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s.ExecOutput = func(executable string, args []string) (string, error) {
+		line := executable + " " + strings.Join(args, " ")
+		if executable == "ip" && args[0] == "route" && args[1] == "show" {
+			return "default via 10.0.0.1 proto dhcp metric 600", nil
+		} else if failOn != "" && line == failOn {
+			return "", fmt.Errorf("nope")
+		}
+		return "", nil
+	}
+
+	fw := &fakeFirewall{}
+	s.Firewall = fw
+	f := NewNetForward(s.Session)
+	s.Register(f)
+
+	return s, fw, f
+}
+
+func TestNetForwardConfigure(t *testing.T) {
+	s, _, f := netForwardSession(t, "")
+	defer s.Close()
+
+	for _, out := range []string{"", session.TestInterfaceName, "nope0"} {
+		if err := s.Set("net.forward.out", out); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if err := f.Configure(); err == nil {
+			t.Fatalf("expected error for '%s'", out)
+		}
+	}
+
+	if err := s.Set("net.forward.gateway", "nope"); err == nil {
+		t.Fatal("expected error for an invalid gateway")
+	}
+}
+
+func TestNetForwardOnOff(t *testing.T) {
+	lo := loopbackName(t)
+	s, fw, _ := netForwardSession(t, "")
+	defer s.Close()
+
+	if err := s.Set("net.forward.out", lo); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := s.Handle("net.forward", "net.forward on")
+	if runtime.GOOS != "linux" {
+		if err == nil {
+			t.Fatalf("expected error on %s", runtime.GOOS)
+		}
+		return
+	} else if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !fw.forwarding {
+		t.Fatal("expected forwarding to be enabled")
+	} else if err := s.Handle("net.forward", "net.forward off"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exp := []string{
+		"ip route show default dev " + lo,
+		"ip route replace 192.168.1.0/24 dev test0 table 4747",
+		"ip route replace default via 10.0.0.1 dev " + lo + " table 4747",
+		"ip rule add iif test0 from 192.168.1.0/24 lookup 4747 priority 4747",
+		"iptables -t nat -A POSTROUTING -s 192.168.1.0/24 -o " + lo + " -j MASQUERADE",
+		"iptables -I FORWARD -i test0 -o " + lo + " -s 192.168.1.0/24 -j ACCEPT",
+		"iptables -I FORWARD -i " + lo + " -o test0 -d 192.168.1.0/24 -m conntrack --ctstate RELATED,ESTABLISHED -j ACCEPT",
+		// teardown in reverse order
+		"iptables -D FORWARD -i " + lo + " -o test0 -d 192.168.1.0/24 -m conntrack --ctstate RELATED,ESTABLISHED -j ACCEPT",
+		"iptables -D FORWARD -i test0 -o " + lo + " -s 192.168.1.0/24 -j ACCEPT",
+		"iptables -t nat -D POSTROUTING -s 192.168.1.0/24 -o " + lo + " -j MASQUERADE",
+		"ip rule del iif test0 from 192.168.1.0/24 lookup 4747 priority 4747",
+		"ip route flush table 4747",
+	}
+	if got := s.Executed(); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, got)
+	}
+}
+
+func TestNetForwardRollback(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("egress rules are only tested on linux")
+	}
+
+	lo := loopbackName(t)
+	s, _, f := netForwardSession(t, "iptables -t nat -A POSTROUTING -s 192.168.1.0/24 -o "+lo+" -j MASQUERADE")
+	defer s.Close()
+
+	if err := s.Set("net.forward.out", lo); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Set("net.forward.gateway", "10.0.0.254"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Handle("net.forward", "net.forward on"); err == nil {
+		t.Fatal("expected error")
+	} else if f.Running() {
+		t.Fatal("expected module not to be running")
+	}
+
+	exp := []string{
+		"ip route replace 192.168.1.0/24 dev test0 table 4747",
+		"ip route replace default via 10.0.0.254 dev " + lo + " table 4747",
+		"ip rule add iif test0 from 192.168.1.0/24 lookup 4747 priority 4747",
+		"iptables -t nat -A POSTROUTING -s 192.168.1.0/24 -o " + lo + " -j MASQUERADE",
+		"ip rule del iif test0 from 192.168.1.0/24 lookup 4747 priority 4747",
+		"ip route flush table 4747",
+	}
+	if got := s.Executed(); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, got)
+	}
+}
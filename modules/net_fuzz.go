@@ -0,0 +1,433 @@
+package modules
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/network"
+	"github.com/bettercap/bettercap/session"
+)
+
+const (
+	// how long to wait for the services which greet the client
+	fuzzGreetingTimeout = time.Second
+	fuzzReadSize        = 4096
+)
+
+type fuzzTarget struct {
+	Address string
+	// nil if the address is not in the LAN
+	Host  *network.Endpoint
+	Ports []int
+}
+
+type NetFuzzer struct {
+	session.SessionModule
+	targets     []*fuzzTarget
+	protocols   map[string]bool
+	mutations   int
+	timeout     time.Duration
+	concurrency int
+	pool        *core.WorkerPool
+	waitGroup   *sync.WaitGroup
+	// number of services probed so far
+	probed int32
+	total  int
+}
+
+func NewNetFuzzer(s *session.Session) *NetFuzzer {
+	f := &NetFuzzer{
+		SessionModule: session.NewSessionModule("net.fuzz", s),
+		targets:       make([]*fuzzTarget, 0),
+		protocols:     make(map[string]bool),
+		waitGroup:     &sync.WaitGroup{},
+	}
+
+	f.AddParam(session.NewStringParameter("net.fuzz.targets",
+		"",
+		"",
+		"Comma separated list of IP addresses, MAC addresses or aliases to probe, every host of the LAN if empty."))
+
+	f.AddParam(session.NewStringParameter("net.fuzz.ports",
+		"21,23,25,80,8080",
+		"",
+		"Comma separated list of ports to probe on the targets without open ports found by syn.scan."))
+
+	f.AddParam(session.NewStringParameter("net.fuzz.protocols",
+		"ftp,http,smtp,telnet",
+		"",
+		"Comma separated list of protocols to fuzz once detected."))
+
+	f.AddParam(session.NewIntParameter("net.fuzz.mutations",
+		"0",
+		"Number of malformed requests sent to each detected service, 0 to only grab the banners."))
+
+	f.AddParam(session.NewDurationParameter("net.fuzz.timeout",
+		"3s",
+		"Timeout of the connections and of the responses."))
+
+	f.AddParam(session.NewIntParameter("net.fuzz.concurrency",
+		"8",
+		"Maximum number of services to probe concurrently."))
+
+	f.AddHandler(session.NewDangerousModuleHandler("net.fuzz on", "",
+		"Probe the services of the targets once, tagging the hosts with the detected ones.",
+		func(args []string) error {
+			return f.Start()
+		}))
+
+	f.AddHandler(session.NewModuleHandler("net.fuzz off", "",
+		"Stop probing.",
+		func(args []string) error {
+			return f.Stop()
+		}))
+
+	return f
+}
+
+func (f *NetFuzzer) Name() string {
+	return "net.fuzz"
+}
+
+func (f *NetFuzzer) Description() string {
+	return "Grab the banners of the services found by net.recon and syn.scan and optionally fuzz them with malformed requests."
+}
+
+func (f *NetFuzzer) Author() string {
+	return "Simone Margaritelli <evilsocket@protonmail.com>"
+}
+
+// fuzzHostPorts returns the open ports found by syn.scan.
+func fuzzHostPorts(e *network.Endpoint) []int {
+	list, _ := e.Meta.Get("tcp-ports").(string)
+	ports, err := parseFuzzPorts(list)
+	if err != nil {
+		return nil
+	}
+	return ports
+}
+
+func (f *NetFuzzer) hostFor(address string) *network.Endpoint {
+	if address == f.Session.Interface.IpAddress {
+		return f.Session.Interface
+	} else if address == f.Session.Gateway.IpAddress {
+		return f.Session.Gateway
+	}
+	return f.Session.Lan.GetByIp(address)
+}
+
+func (f *NetFuzzer) parseTargets(list string, ports []int) ([]*fuzzTarget, error) {
+	hosts := make([]*network.Endpoint, 0)
+	addresses := make([]string, 0)
+
+	if list == "" {
+		hosts = f.Session.Lan.List()
+		sort.Sort(ByAddressSorter(hosts))
+	} else {
+		ips, macs, err := network.ParseTargets(list, f.Session.Lan.Aliases())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ip := range ips {
+			if host := f.hostFor(ip.String()); host != nil {
+				hosts = append(hosts, host)
+			} else {
+				addresses = append(addresses, ip.String())
+			}
+		}
+		for _, mac := range macs {
+			if host, found := f.Session.Lan.Get(mac.String()); found {
+				hosts = append(hosts, host)
+			} else {
+				log.Warning("[%s] Could not find %s in the LAN, skipping it.", core.Green("net.fuzz"), mac)
+			}
+		}
+	}
+
+	targets := make([]*fuzzTarget, 0)
+	for _, host := range hosts {
+		target := &fuzzTarget{Address: host.IpAddress, Host: host, Ports: fuzzHostPorts(host)}
+		if len(target.Ports) == 0 {
+			target.Ports = ports
+		}
+		targets = append(targets, target)
+	}
+	for _, address := range addresses {
+		targets = append(targets, &fuzzTarget{Address: address, Ports: ports})
+	}
+	return targets, nil
+}
+
+func (f *NetFuzzer) Configure() error {
+	var err error
+	var targets string
+	var ports string
+	var protocols string
+	var defaults []int
+
+	if f.Running() {
+		return session.ErrAlreadyStarted
+	} else if err, targets = f.StringParam("net.fuzz.targets"); err != nil {
+		return err
+	} else if err, ports = f.StringParam("net.fuzz.ports"); err != nil {
+		return err
+	} else if err, protocols = f.StringParam("net.fuzz.protocols"); err != nil {
+		return err
+	} else if err, f.mutations = f.IntParam("net.fuzz.mutations"); err != nil {
+		return err
+	} else if err, f.timeout = f.DurationParam("net.fuzz.timeout"); err != nil {
+		return err
+	} else if err, f.concurrency = f.IntParam("net.fuzz.concurrency"); err != nil {
+		return err
+	} else if f.mutations < 0 {
+		return fmt.Errorf("net.fuzz.mutations can't be negative.")
+	} else if f.timeout <= 0 {
+		return fmt.Errorf("net.fuzz.timeout must be greater than 0.")
+	} else if defaults, err = parseFuzzPorts(ports); err != nil {
+		return err
+	}
+
+	f.protocols = make(map[string]bool)
+	for _, name := range core.CommaSplit(protocols) {
+		if fuzzProtocolByName(name) == nil {
+			return fmt.Errorf("Unknown protocol '%s'.", name)
+		}
+		f.protocols[name] = true
+	}
+
+	if f.targets, err = f.parseTargets(targets, defaults); err != nil {
+		return err
+	} else if len(f.targets) == 0 {
+		return fmt.Errorf("No targets to probe.")
+	}
+	return nil
+}
+
+func (f *NetFuzzer) trackProgress() {
+	done := atomic.AddInt32(&f.probed, 1)
+	f.Progress("Probing services", int(done), f.total)
+}
+
+func (f *NetFuzzer) Start() error {
+	if err := f.Configure(); err != nil {
+		return err
+	}
+
+	f.pool = core.NewWorkerPool(f.concurrency)
+	f.probed = 0
+	f.total = 0
+	for _, t := range f.targets {
+		f.total += len(t.Ports)
+	}
+	f.StartProgress("Probing services", f.total)
+
+	return f.SetRunning(true, func() {
+		defer f.SetRunning(false, nil)
+
+		f.waitGroup.Add(1)
+		defer f.waitGroup.Done()
+
+		log.Info("[%s] Probing %d services of %d hosts ...", core.Green("net.fuzz"), f.total, len(f.targets))
+
+		for _, t := range f.targets {
+			for _, port := range t.Ports {
+				if !f.Running() {
+					break
+				}
+
+				t, port := t, port
+				if !f.pool.Submit(func() error {
+					f.probe(t, port)
+					f.trackProgress()
+					return nil
+				}) {
+					break
+				}
+			}
+		}
+
+		if err := f.pool.Wait(); err != nil {
+			log.Error("Error while probing: %s", err)
+		}
+		log.Info("[%s] Probed %d services.", core.Green("net.fuzz"), atomic.LoadInt32(&f.probed))
+	})
+}
+
+// fuzzRead returns what the server sends within the timeout.
+func fuzzRead(conn net.Conn, timeout time.Duration) ([]byte, error) {
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	buf := make([]byte, fuzzReadSize)
+	n, err := conn.Read(buf)
+	return buf[:n], err
+}
+
+func (f *NetFuzzer) read(conn net.Conn, timeout time.Duration) ([]byte, error) {
+	return fuzzRead(conn, timeout)
+}
+
+// grabService returns the greeting of the service or, if it doesn't
+// greet the client, its response to an HTTP request.
+func grabService(address string, port int, timeout time.Duration) (greeting []byte, response []byte, err error) {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(address, strconv.Itoa(port)), timeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer conn.Close()
+
+	wait := timeout
+	if wait > fuzzGreetingTimeout {
+		wait = fuzzGreetingTimeout
+	}
+	if greeting, _ = fuzzRead(conn, wait); len(greeting) > 0 {
+		return greeting, nil, nil
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(timeout))
+	if _, err = io.WriteString(conn, strings.Replace(fuzzHTTPProbe, "{HOST}", address, -1)); err != nil {
+		return nil, nil, err
+	}
+
+	response, _ = fuzzRead(conn, timeout)
+	return nil, response, nil
+}
+
+func (f *NetFuzzer) tagHost(t *fuzzTarget, port int, service string, version string) {
+	if t.Host == nil {
+		return
+	}
+
+	desc := service
+	if version != "" {
+		desc = fmt.Sprintf("%s (%s)", service, version)
+	}
+	t.Host.Meta.Set(fmt.Sprintf("tcp-%d", port), desc)
+
+	if service != "unknown" {
+		f.Session.Lan.Tag(t.Host.HwAddress, service)
+	}
+}
+
+func (f *NetFuzzer) probe(t *fuzzTarget, port int) {
+	address := net.JoinHostPort(t.Address, strconv.Itoa(port))
+	greeting, response, err := grabService(t.Address, port, f.timeout)
+	if err != nil {
+		log.Debug("Could not probe %s: %s", address, err)
+		return
+	}
+
+	proto, banner, version := fuzzIdentify(port, greeting, response)
+	service := "unknown"
+	if proto != nil {
+		service = proto.Name
+	} else if banner == "" {
+		log.Debug("No banner from %s.", address)
+		return
+	}
+
+	f.tagHost(t, port, service, version)
+	NetFuzzEvent{
+		Address: t.Address,
+		Host:    t.Host,
+		Port:    port,
+		Service: service,
+		Banner:  banner,
+		Version: version,
+	}.Push()
+
+	if proto != nil && f.mutations > 0 && f.protocols[proto.Name] {
+		f.fuzz(t, port, proto)
+	}
+}
+
+func (f *NetFuzzer) alive(address string) bool {
+	conn, err := net.DialTimeout("tcp", address, f.timeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// try sends the malformed request and returns the anomaly it caused,
+// if any.
+func (f *NetFuzzer) try(address string, proto *fuzzProtocol, c fuzzCase) string {
+	conn, err := net.DialTimeout("tcp", address, f.timeout)
+	if err != nil {
+		return fmt.Sprintf("could not connect: %s", err)
+	}
+	defer conn.Close()
+
+	if proto.Greets {
+		f.read(conn, f.timeout)
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(f.timeout))
+	if _, err = conn.Write(c.Payload); err != nil {
+		return fmt.Sprintf("connection lost while sending the request: %s", err)
+	}
+
+	data, err := f.read(conn, f.timeout)
+	if len(data) > 0 {
+		if status := fuzzBanner(data); proto.Name == "http" && strings.HasPrefix(status, "HTTP/") {
+			if parts := strings.Fields(status); len(parts) > 1 && strings.HasPrefix(parts[1], "5") {
+				return fmt.Sprintf("server error '%s'", status)
+			}
+		}
+		return ""
+	} else if err == io.EOF {
+		return "connection closed without a response"
+	} else if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return ""
+	}
+	return fmt.Sprintf("connection lost: %s", err)
+}
+
+func (f *NetFuzzer) fuzz(t *fuzzTarget, port int, proto *fuzzProtocol) {
+	address := net.JoinHostPort(t.Address, strconv.Itoa(port))
+	for _, c := range proto.Cases(t.Address, f.mutations) {
+		if !f.Running() {
+			return
+		}
+
+		anomaly := f.try(address, proto, c)
+		alive := f.alive(address)
+		if !alive {
+			if anomaly != "" {
+				anomaly += ", then "
+			}
+			anomaly += "the service stopped accepting connections"
+		}
+
+		if anomaly != "" {
+			NetFuzzEvent{
+				Address: t.Address,
+				Host:    t.Host,
+				Port:    port,
+				Service: proto.Name,
+				Case:    c.Name,
+				Anomaly: anomaly,
+			}.Push()
+		}
+
+		if !alive {
+			return
+		}
+	}
+}
+
+func (f *NetFuzzer) Stop() error {
+	return f.SetRunning(false, func() {
+		f.pool.Cancel()
+		f.waitGroup.Wait()
+	})
+}
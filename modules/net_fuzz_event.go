@@ -0,0 +1,26 @@
+package modules
+
+import (
+	"github.com/bettercap/bettercap/network"
+	"github.com/bettercap/bettercap/session"
+)
+
+type NetFuzzEvent struct {
+	Address string
+	Host    *network.Endpoint
+	Port    int
+	Service string
+	Banner  string
+	Version string
+	// the request which caused the anomaly, if any
+	Case    string
+	Anomaly string
+}
+
+func (e NetFuzzEvent) Push() {
+	if e.Anomaly != "" {
+		session.I.Events.Add("net.fuzz.anomaly", e)
+	} else {
+		session.I.Events.Add("net.fuzz.service", e)
+	}
+}
@@ -0,0 +1,217 @@
+package modules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/bettercap/bettercap/core"
+)
+
+// fuzzProtocol describes how to recognize a service and the requests
+// used to fuzz it, {FILL} in a template is replaced by the payload and
+// {HOST} by the address of the target.
+type fuzzProtocol struct {
+	Name  string
+	Ports []int
+	// true if the server greets the client before any request
+	Greets    bool
+	Templates []string
+}
+
+var fuzzProtocols = []*fuzzProtocol{
+	{
+		Name:   "ftp",
+		Ports:  []int{21, 2121},
+		Greets: true,
+		Templates: []string{
+			"USER {FILL}\r\n",
+			"USER anonymous\r\nPASS {FILL}\r\n",
+			"CWD {FILL}\r\n",
+			"SITE {FILL}\r\n",
+		},
+	},
+	{
+		Name:   "smtp",
+		Ports:  []int{25, 587, 2525},
+		Greets: true,
+		Templates: []string{
+			"HELO {FILL}\r\n",
+			"EHLO {HOST}\r\nMAIL FROM:<{FILL}>\r\n",
+			"VRFY {FILL}\r\n",
+			"EXPN {FILL}\r\n",
+		},
+	},
+	{
+		Name:   "telnet",
+		Ports:  []int{23, 2323},
+		Greets: true,
+		Templates: []string{
+			"{FILL}\r\n",
+			// subnegotiation of the terminal type option
+			"\xff\xfa\x18\x00{FILL}\xff\xf0",
+		},
+	},
+	{
+		Name:  "http",
+		Ports: []int{80, 8000, 8008, 8080, 8081, 8888},
+		Templates: []string{
+			"GET /{FILL} HTTP/1.1\r\nHost: {HOST}\r\n\r\n",
+			"{FILL} / HTTP/1.1\r\nHost: {HOST}\r\n\r\n",
+			"GET / HTTP/1.1\r\nHost: {HOST}\r\nX-{FILL}: 1\r\n\r\n",
+			"GET / HTTP/{FILL}\r\nHost: {HOST}\r\n\r\n",
+		},
+	},
+}
+
+var (
+	// sent to the services which don't greet the client
+	fuzzHTTPProbe = "HEAD / HTTP/1.0\r\nHost: {HOST}\r\n\r\n"
+	fuzzSizes     = []int{256, 4096, 65536}
+	fuzzFillers   = []string{"A", "%n%s", "../"}
+)
+
+func fuzzProtocolByName(name string) *fuzzProtocol {
+	for _, proto := range fuzzProtocols {
+		if proto.Name == name {
+			return proto
+		}
+	}
+	return nil
+}
+
+func (p *fuzzProtocol) HasPort(port int) bool {
+	for _, n := range p.Ports {
+		if n == port {
+			return true
+		}
+	}
+	return false
+}
+
+// fuzzCase is a single malformed request.
+type fuzzCase struct {
+	Name    string
+	Payload []byte
+}
+
+// Cases returns at most max requests, the shortest payloads first.
+func (p *fuzzProtocol) Cases(host string, max int) []fuzzCase {
+	cases := make([]fuzzCase, 0)
+	for _, size := range fuzzSizes {
+		for _, filler := range fuzzFillers {
+			fill := strings.Repeat(filler, size/len(filler))
+			for _, tpl := range p.Templates {
+				if len(cases) >= max {
+					return cases
+				}
+
+				payload := strings.Replace(tpl, "{HOST}", host, -1)
+				name := fuzzPrintable([]byte(strings.SplitN(payload, "{FILL}", 2)[0]))
+				cases = append(cases, fuzzCase{
+					Name:    strings.TrimSpace(fmt.Sprintf("%s '%s'x%d", name, filler, size/len(filler))),
+					Payload: []byte(strings.Replace(payload, "{FILL}", fill, -1)),
+				})
+			}
+		}
+	}
+	return cases
+}
+
+// parseFuzzPorts parses a comma separated list of ports.
+func parseFuzzPorts(list string) ([]int, error) {
+	ports := make([]int, 0)
+	for _, s := range core.CommaSplit(list) {
+		port, err := strconv.Atoi(s)
+		if err != nil || port < 1 || port > 65535 {
+			return nil, fmt.Errorf("'%s' is not a valid port.", s)
+		}
+		ports = append(ports, port)
+	}
+	return core.UniqueInts(ports, true), nil
+}
+
+// fuzzPrintable returns the text of a banner without the telnet option
+// negotiations and the control characters.
+func fuzzPrintable(raw []byte) string {
+	text := make([]rune, 0, len(raw))
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		if c == 0xff && i+1 < len(raw) {
+			switch op := raw[i+1]; {
+			case op == 0xfa:
+				// skip up to the end of the subnegotiation
+				for i += 2; i+1 < len(raw) && !(raw[i] == 0xff && raw[i+1] == 0xf0); i++ {
+				}
+				i++
+			case op >= 0xfb && op <= 0xfe:
+				// WILL, WONT, DO, DONT and the option
+				i += 2
+			default:
+				i++
+			}
+			continue
+		}
+
+		if r := rune(c); c < 0x80 && unicode.IsPrint(r) {
+			text = append(text, r)
+		} else if unicode.IsSpace(r) {
+			text = append(text, ' ')
+		}
+	}
+	return strings.Join(strings.Fields(string(text)), " ")
+}
+
+// fuzzBanner returns the first line of a banner.
+func fuzzBanner(raw []byte) string {
+	line := string(raw)
+	if idx := strings.IndexAny(line, "\r\n"); idx > 0 {
+		line = line[:idx]
+	}
+	if line = fuzzPrintable([]byte(line)); len(line) > 128 {
+		line = line[:128]
+	}
+	return line
+}
+
+// fuzzIdentify returns the protocol of a service given its greeting or,
+// if it doesn't greet, its response to an HTTP request, the banner and
+// the version of the software if found.
+func fuzzIdentify(port int, greeting []byte, response []byte) (proto *fuzzProtocol, banner string, version string) {
+	if len(greeting) > 0 {
+		banner = fuzzBanner(greeting)
+		lower := strings.ToLower(banner)
+
+		switch {
+		case greeting[0] == 0xff:
+			proto, version = fuzzProtocolByName("telnet"), banner
+		case strings.HasPrefix(banner, "220"):
+			if strings.Contains(lower, "smtp") || strings.Contains(lower, "mail") || fuzzProtocolByName("smtp").HasPort(port) {
+				proto = fuzzProtocolByName("smtp")
+			} else {
+				proto = fuzzProtocolByName("ftp")
+			}
+			version = strings.Trim(strings.TrimSpace(strings.TrimLeft(banner[3:], "- ")), "()")
+		case fuzzProtocolByName("telnet").HasPort(port):
+			proto, version = fuzzProtocolByName("telnet"), banner
+		}
+		return
+	}
+
+	if len(response) == 0 {
+		return
+	}
+
+	banner = fuzzBanner(response)
+	if strings.HasPrefix(banner, "HTTP/") {
+		proto = fuzzProtocolByName("http")
+		for _, line := range strings.Split(string(response), "\n") {
+			if parts := strings.SplitN(line, ":", 2); len(parts) == 2 && strings.EqualFold(strings.TrimSpace(parts[0]), "server") {
+				version = fuzzPrintable([]byte(parts[1]))
+				break
+			}
+		}
+	}
+	return
+}
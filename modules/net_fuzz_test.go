@@ -0,0 +1,177 @@
+package modules
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bettercap/bettercap/session"
+)
+
+func TestNetFuzzIdentify(t *testing.T) {
+	var units = []struct {
+		port     int
+		greeting string
+		response string
+		proto    string
+		banner   string
+		version  string
+	}{
+		{21, "220 (vsFTPd 3.0.3)\r\n", "", "ftp", "220 (vsFTPd 3.0.3)", "vsFTPd 3.0.3"},
+		{2100, "220-ProFTPD Server\r\n220 ready\r\n", "", "ftp", "220-ProFTPD Server", "ProFTPD Server"},
+		{2525, "220 mx.example.com ESMTP Postfix\r\n", "", "smtp", "220 mx.example.com ESMTP Postfix", "mx.example.com ESMTP Postfix"},
+		{23, "\xff\xfd\x18\xff\xfb\x01Ubuntu 18.04 LTS\r\nlogin: ", "", "telnet", "Ubuntu 18.04 LTS", "Ubuntu 18.04 LTS"},
+		{8000, "", "HTTP/1.1 200 OK\r\nServer: nginx/1.14.0\r\n\r\n", "http", "HTTP/1.1 200 OK", "nginx/1.14.0"},
+		{9999, "SSH-2.0-OpenSSH_7.6\r\n", "", "", "SSH-2.0-OpenSSH_7.6", ""},
+		{9999, "", "", "", "", ""},
+	}
+
+	for _, u := range units {
+		proto, banner, version := fuzzIdentify(u.port, []byte(u.greeting), []byte(u.response))
+		name := ""
+		if proto != nil {
+			name = proto.Name
+		}
+
+		if name != u.proto {
+			t.Fatalf("expected '%s', got '%s'", u.proto, name)
+		} else if banner != u.banner {
+			t.Fatalf("expected '%s', got '%s'", u.banner, banner)
+		} else if version != u.version {
+			t.Fatalf("expected '%s', got '%s'", u.version, version)
+		}
+	}
+}
+
+func TestNetFuzzCases(t *testing.T) {
+	http := fuzzProtocolByName("http")
+	cases := http.Cases("192.168.1.10", 5)
+	if len(cases) != 5 {
+		t.Fatalf("expected 5 cases, got %d", len(cases))
+	} else if cases[0].Name != "GET / 'A'x256" {
+		t.Fatalf("expected '%s', got '%s'", "GET / 'A'x256", cases[0].Name)
+	} else if exp := "GET /" + strings.Repeat("A", 256) + " HTTP/1.1\r\nHost: 192.168.1.10\r\n\r\n"; string(cases[0].Payload) != exp {
+		t.Fatalf("unexpected payload '%s'", cases[0].Payload)
+	} else if !strings.HasPrefix(string(cases[4].Payload), "GET /%n%s%n%s") {
+		t.Fatalf("unexpected payload '%s'", cases[4].Payload)
+	}
+
+	all := len(http.Templates) * len(fuzzSizes) * len(fuzzFillers)
+	if got := len(http.Cases("192.168.1.10", 1000)); got != all {
+		t.Fatalf("expected %d cases, got %d", all, got)
+	}
+
+	if ports, err := parseFuzzPorts("80, 21,80"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(ports) != 2 || ports[0] != 21 || ports[1] != 80 {
+		t.Fatalf("unexpected ports %v", ports)
+	} else if _, err := parseFuzzPorts("80,http"); err == nil {
+		t.Fatal("expected error for 'http'")
+	}
+}
+
+// an FTP server which stops accepting connections after a long command
+func fuzzTestServer(t *testing.T) net.Listener {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			conn.Write([]byte("220 (vsFTPd 3.0.3)\r\n"))
+			line, err := bufio.NewReaderSize(conn, 8192).ReadString('\n')
+			if err == nil && len(line) > 1024 {
+				ln.Close()
+			} else if err == nil {
+				conn.Write([]byte("530 Please login with USER and PASS.\r\n"))
+			}
+			conn.Close()
+		}
+	}()
+
+	return ln
+}
+
+func TestNetFuzzProbe(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	ln := fuzzTestServer(t)
+	defer ln.Close()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	f := NewNetFuzzer(s.Session)
+	f.timeout = 500 * time.Millisecond
+	f.mutations = 1000
+	f.protocols = map[string]bool{"ftp": true}
+	if err := f.SetRunning(true, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	f.probe(&fuzzTarget{Address: "127.0.0.1", Ports: []int{port}}, port)
+
+	events := make([]NetFuzzEvent, 0)
+	for _, e := range s.Events.Sorted() {
+		if strings.HasPrefix(e.Tag, "net.fuzz.") {
+			events = append(events, e.Data.(NetFuzzEvent))
+		}
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	} else if e := events[0]; e.Service != "ftp" || e.Version != "vsFTPd 3.0.3" || e.Port != port {
+		t.Fatalf("unexpected service event %+v", e)
+	} else if e := events[1]; e.Case != "USER 'A'x4096" || !strings.HasSuffix(e.Anomaly, "the service stopped accepting connections") {
+		t.Fatalf("unexpected anomaly event %+v", e)
+	}
+}
+
+func TestNetFuzzTargets(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	s.Lan.AddIfNew("192.168.1.20", "aa:00:00:00:00:20")
+	s.Lan.AddIfNew("192.168.1.10", "aa:00:00:00:00:10")
+	host, _ := s.Lan.Get("aa:00:00:00:00:20")
+	host.Meta.SetInts("tcp-ports", []int{22, 21})
+
+	f := NewNetFuzzer(s.Session)
+	targets, err := f.parseTargets("", []int{80})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(targets) != 2 {
+		t.Fatalf("expected 2 targets, got %d", len(targets))
+	} else if targets[0].Address != "192.168.1.10" || len(targets[0].Ports) != 1 || targets[0].Ports[0] != 80 {
+		t.Fatalf("unexpected target %+v", targets[0])
+	} else if targets[1].Address != "192.168.1.20" || len(targets[1].Ports) != 2 || targets[1].Ports[0] != 21 {
+		t.Fatalf("unexpected target %+v", targets[1])
+	}
+
+	if targets, err = f.parseTargets("192.168.1.1, 10.0.0.1", []int{80}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(targets) != 2 || targets[0].Host != s.Gateway || targets[1].Host != nil {
+		t.Fatalf("unexpected targets %+v", targets)
+	}
+
+	f.tagHost(targets[1], 21, "ftp", "vsFTPd")
+	f.tagHost(&fuzzTarget{Address: host.IpAddress, Host: host}, 21, "ftp", "vsFTPd 3.0.3")
+	if !host.HasTag("ftp") {
+		t.Fatalf("expected host to be tagged, got %v", host.Tags)
+	} else if desc := host.Meta.Get("tcp-21"); desc != "ftp (vsFTPd 3.0.3)" {
+		t.Fatalf("expected '%s', got '%v'", "ftp (vsFTPd 3.0.3)", desc)
+	}
+}
@@ -0,0 +1,94 @@
+package modules
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/session"
+)
+
+// destination mac + source mac + ethertype
+const minInjectSize = 14
+
+type PacketInjector struct {
+	session.SessionModule
+}
+
+func NewPacketInjector(s *session.Session) *PacketInjector {
+	inj := &PacketInjector{
+		SessionModule: session.NewSessionModule("net.inject", s),
+	}
+
+	inj.AddParam(session.NewStringParameter(session.InjectIfaceVariable,
+		"",
+		"",
+		"Interface to send the packets of net.inject and arp.spoof on, if empty the capture one is used."))
+
+	inj.AddHandler(session.NewModuleHandler("net.inject HEX", `net\.inject\s+(.+)`,
+		"Write the raw frame HEX (optionally separated by spaces or colons) on the wire as is.",
+		func(args []string) error {
+			if raw, err := parseFrame(args[0]); err != nil {
+				return err
+			} else {
+				return inj.inject(raw)
+			}
+		}))
+
+	return inj
+}
+
+func (inj *PacketInjector) Name() string {
+	return "net.inject"
+}
+
+func (inj *PacketInjector) Description() string {
+	return "A module to inject raw crafted frames on the network."
+}
+
+func (inj *PacketInjector) Author() string {
+	return "Simone Margaritelli <evilsocket@protonmail.com>"
+}
+
+func (inj *PacketInjector) Configure() error {
+	return nil
+}
+
+func (inj *PacketInjector) Start() error {
+	return nil
+}
+
+func (inj *PacketInjector) Stop() error {
+	return nil
+}
+
+func parseFrame(data string) ([]byte, error) {
+	data = strings.TrimPrefix(strings.ToLower(core.Trim(data)), "0x")
+	data = strings.NewReplacer(" ", "", ":", "", "\t", "").Replace(data)
+
+	raw, err := hex.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid hex frame: %s.", err)
+	} else if len(raw) < minInjectSize {
+		return nil, fmt.Errorf("Frame is %d bytes long, at least %d bytes are required.", len(raw), minInjectSize)
+	}
+	return raw, nil
+}
+
+func (inj *PacketInjector) inject(raw []byte) error {
+	if err := inj.Session.UpdateInjection(); err != nil {
+		return err
+	}
+
+	inj.SetRunning(true, nil)
+	defer inj.SetRunning(false, nil)
+
+	log.Info("Injecting %d bytes frame from %s to %s.", len(raw),
+		core.Bold(net.HardwareAddr(raw[6:12]).String()),
+		core.Bold(net.HardwareAddr(raw[0:6]).String()))
+
+	return inj.Session.Inject(raw)
+}
@@ -0,0 +1,32 @@
+package modules
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNetInjectParseFrame(t *testing.T) {
+	exp := []byte{
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xde, 0xad, 0xbe, 0xef, 0x00, 0x01,
+		0x08, 0x06,
+	}
+
+	for _, data := range []string{
+		"ffffffffffffdeadbeef00010806",
+		"0xFFFFFFFFFFFFDEADBEEF00010806",
+		"ff:ff:ff:ff:ff:ff de:ad:be:ef:00:01 08 06",
+	} {
+		if raw, err := parseFrame(data); err != nil {
+			t.Fatalf("unexpected error for '%s': %v", data, err)
+		} else if !bytes.Equal(raw, exp) {
+			t.Fatalf("expected '%x', got '%x'", exp, raw)
+		}
+	}
+
+	for _, bad := range []string{"ffff", "zz", "fffffffffffffffffffffffffff"} {
+		if _, err := parseFrame(bad); err == nil {
+			t.Fatalf("expected error for '%s'", bad)
+		}
+	}
+}
@@ -15,6 +15,7 @@ import (
 type Prober struct {
 	session.SessionModule
 	throttle  int
+	ipv6      bool
 	waitGroup *sync.WaitGroup
 }
 
@@ -28,6 +29,10 @@ func NewProber(s *session.Session) *Prober {
 		"10",
 		"If greater than 0, probe packets will be throttled by this value in milliseconds."))
 
+	p.AddParam(session.NewBoolParameter("net.probe.ipv6",
+		"true",
+		"If true and the interface has an IPv6 address, every round also sends an ICMPv6 echo request, an mDNS and an LLMNR query to the IPv6 multicast groups of the link."))
+
 	p.AddHandler(session.NewModuleHandler("net.probe on", "",
 		"Start network hosts probing in background.",
 		func(args []string) error {
@@ -48,7 +53,7 @@ func (p Prober) Name() string {
 }
 
 func (p Prober) Description() string {
-	return "Keep probing for new hosts on the network by sending dummy UDP packets to every possible IP on the subnet."
+	return "Keep probing for new hosts on the network by sending dummy UDP packets to every possible IP on the subnet and IPv6 multicast probes."
 }
 
 func (p Prober) Author() string {
@@ -71,6 +76,8 @@ func (p *Prober) Configure() error {
 	var err error
 	if err, p.throttle = p.IntParam("net.probe.throttle"); err != nil {
 		return err
+	} else if err, p.ipv6 = p.BoolParam("net.probe.ipv6"); err != nil {
+		return err
 	} else {
 		log.Debug("Throttling packets of %d ms.", p.throttle)
 	}
@@ -102,6 +109,10 @@ func (p *Prober) Start() error {
 		throttle := time.Duration(p.throttle) * time.Millisecond
 
 		for p.Running() {
+			if p.ipv6 && p.Session.Interface.IPv6 != nil {
+				p.sendProbeIPv6()
+			}
+
 			for _, ip := range addresses {
 				if !p.Running() {
 					return
@@ -0,0 +1,93 @@
+package modules
+
+import (
+	"net"
+	"os"
+
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/packets"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+const (
+	probeMDNSService = "_services._dns-sd._udp.local"
+	probeLLMNRName   = "wpad"
+	// the mDNS responders answer with unicast packets to the questions
+	// with this bit set in the class (RFC 6762)
+	probeMDNSUnicastResponse = 0x8000
+)
+
+var (
+	probeMDNSGroup  = &net.UDPAddr{IP: net.ParseIP("ff02::fb"), Port: 5353}
+	probeLLMNRGroup = &net.UDPAddr{IP: net.ParseIP("ff02::1:3"), Port: 5355}
+)
+
+func newProbeDNSQuery(name string, qtype layers.DNSType, class layers.DNSClass) ([]byte, error) {
+	// multicast queries are sent with a zero id (RFC 6762)
+	dns := layers.DNS{
+		QDCount: 1,
+		Questions: []layers.DNSQuestion{
+			{
+				Name:  []byte(name),
+				Type:  qtype,
+				Class: class,
+			},
+		},
+	}
+
+	buf := gopacket.NewSerializeBuffer()
+	if err := dns.SerializeTo(buf, packets.SerializationOptions); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (p *Prober) sendProbeMulticastUDP(group *net.UDPAddr, payload []byte) {
+	to := *group
+	to.Zone = p.Session.Interface.Name()
+
+	if con, err := net.DialUDP("udp6", nil, &to); err != nil {
+		log.Debug("Could not dial %s: %s", to.String(), err)
+	} else {
+		defer con.Close()
+		if wrote, _ := con.Write(payload); wrote > 0 {
+			p.Session.Queue.TrackSent(uint64(wrote))
+		} else {
+			p.Session.Queue.TrackError()
+		}
+	}
+}
+
+// sendProbeIPv6 sends an echo request to every node of the link and the
+// mDNS and LLMNR queries to their responders, the hosts replying to us
+// solicit our address and so end up in the IPv6 neighbor cache.
+func (p *Prober) sendProbeIPv6() {
+	iface := p.Session.Interface
+
+	src := iface.IPv6.String()
+	if iface.IPv6.IsLinkLocalUnicast() {
+		src += "%" + iface.Name()
+	}
+
+	if conn, err := net.ListenPacket("ip6:ipv6-icmp", src); err != nil {
+		log.Debug("Could not send the ICMPv6 probe: %s", err)
+	} else {
+		echo := newICMPEcho(true, uint16(os.Getpid()&0xffff), 0, nil)
+		if wrote, err := conn.WriteTo(echo, &net.IPAddr{IP: packets.IPv6AllNodes, Zone: iface.Name()}); err == nil {
+			p.Session.Queue.TrackSent(uint64(wrote))
+		} else {
+			p.Session.Queue.TrackError()
+		}
+		conn.Close()
+	}
+
+	if query, err := newProbeDNSQuery(probeMDNSService, layers.DNSTypePTR, layers.DNSClassIN|probeMDNSUnicastResponse); err == nil {
+		p.sendProbeMulticastUDP(probeMDNSGroup, query)
+	}
+
+	if query, err := newProbeDNSQuery(probeLLMNRName, layers.DNSTypeAAAA, layers.DNSClassIN); err == nil {
+		p.sendProbeMulticastUDP(probeLLMNRGroup, query)
+	}
+}
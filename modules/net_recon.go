@@ -1,6 +1,8 @@
 package modules
 
 import (
+	"net"
+	"strconv"
 	"time"
 
 	"github.com/bettercap/bettercap/log"
@@ -10,13 +12,40 @@ import (
 
 type Discovery struct {
 	session.SessionModule
+	aging time.Duration
+	// hosts removed by aging which are still in the ARP cache
+	aged map[string]bool
+	// secondary addresses added to the interface
+	secondary map[string]*net.IPNet
+	// fingerprint the hosts from the packets they send
+	fingerprint bool
 }
 
 func NewDiscovery(s *session.Session) *Discovery {
 	d := &Discovery{
 		SessionModule: session.NewSessionModule("net.recon", s),
+		aged:          make(map[string]bool),
+		secondary:     make(map[string]*net.IPNet),
 	}
 
+	d.AddParam(session.NewDurationParameter("net.recon.aging",
+		"0",
+		"If greater than 0, hosts not seen for this amount of time (for instance 90s or 5m) will be removed."))
+
+	d.AddParam(session.NewStringParameter("net.recon.sort",
+		"address",
+		`^(address|seen|sent|rcvd|traffic)$`,
+		"Default sorting of net.show, one of address, seen, sent, rcvd or traffic (sent + received bytes)."))
+
+	d.AddParam(session.NewStringParameter("net.recon.show.tags",
+		"",
+		"",
+		"If not empty, comma separated list of tags net.show will only include the hosts tagged with (any of them)."))
+
+	d.AddParam(session.NewBoolParameter("net.recon.fingerprint",
+		"true",
+		"If true, infer the operating system, device type and model of the hosts from the DHCP, TCP SYN, mDNS, SSDP and HTTP packets they send."))
+
 	d.AddHandler(session.NewModuleHandler("net.recon on", "",
 		"Start network hosts discovery.",
 		func(args []string) error {
@@ -29,10 +58,50 @@ func NewDiscovery(s *session.Session) *Discovery {
 			return d.Stop()
 		}))
 
+	d.AddHandler(session.NewModuleHandler("net.recon.addip ADDRESS", `net\.recon\.addip\s+([^\s]+)`,
+		"Add the secondary ADDRESS (IP or IP/CIDR) to the interface, it'll be removed when the session is closed.",
+		func(args []string) error {
+			return d.AddIP(args[0])
+		}))
+
+	d.AddHandler(session.NewModuleHandler("net.recon.delip ADDRESS", `net\.recon\.delip\s+([^\s]+)`,
+		"Remove a secondary ADDRESS added with net.recon.addip.",
+		func(args []string) error {
+			return d.DelIP(args[0])
+		}))
+
+	d.AddHandler(session.NewModuleHandler("net.recon.tag ADDRESS TAGS", `net\.recon\.tag\s+([^\s]+)\s+(.+)`,
+		"Tag the host with the given IP or MAC ADDRESS with TAGS, separated by spaces or commas.",
+		func(args []string) error {
+			return d.Tag(args[0], args[1])
+		}))
+
+	d.AddHandler(session.NewModuleHandler("net.recon.untag ADDRESS TAGS", `net\.recon\.untag\s+([^\s]+)\s+(.+)`,
+		"Remove TAGS from the host with the given IP or MAC ADDRESS.",
+		func(args []string) error {
+			return d.Untag(args[0], args[1])
+		}))
+
+	d.AddHandler(session.NewModuleHandler("net.recon.note ADDRESS TEXT", `net\.recon\.note\s+([^\s]+)\s*(.*)`,
+		"Attach a note to the host with the given IP or MAC ADDRESS, an empty TEXT removes it.",
+		func(args []string) error {
+			return d.Note(args[0], args[1])
+		}))
+
+	d.AddHandler(session.NewModuleHandler("net.recon.flush-cache IFACE", `net\.recon\.flush-cache\s*([^\s]*)`,
+		"Flush the ARP/neighbor cache of the operating system for IFACE, or for every interface if empty.",
+		func(args []string) error {
+			return d.FlushCache(args[0])
+		}))
+
 	d.AddHandler(session.NewModuleHandler("net.show", "",
-		"Show cache hosts list (default sorting by ip).",
+		"Show cache hosts list (sorted by net.recon.sort).",
 		func(args []string) error {
-			return d.Show("address")
+			if err, by := d.StringParam("net.recon.sort"); err != nil {
+				return err
+			} else {
+				return d.Show(by)
+			}
 		}))
 
 	d.AddHandler(session.NewModuleHandler("net.show by seen", "",
@@ -42,17 +111,54 @@ func NewDiscovery(s *session.Session) *Discovery {
 		}))
 
 	d.AddHandler(session.NewModuleHandler("net.show by sent", "",
-		"Show cache hosts list (sort by sent packets).",
+		"Show cache hosts list (sort by sent bytes).",
 		func(args []string) error {
 			return d.Show("sent")
 		}))
 
 	d.AddHandler(session.NewModuleHandler("net.show by rcvd", "",
-		"Show cache hosts list (sort by received packets).",
+		"Show cache hosts list (sort by received bytes).",
 		func(args []string) error {
 			return d.Show("rcvd")
 		}))
 
+	d.AddHandler(session.NewModuleHandler("net.show by traffic", "",
+		"Show cache hosts list (sort by sent and received bytes).",
+		func(args []string) error {
+			return d.Show("traffic")
+		}))
+
+	d.AddHandler(session.NewModuleHandler("net.recon.routes", "",
+		"Show the routing table and check whether this computer is ready to forward the traffic of the spoofed hosts.",
+		func(args []string) error {
+			return d.ShowRoutes()
+		}))
+
+	d.AddHandler(session.NewModuleHandler("net.ping ADDRESS COUNT", `net\.ping\s+([^\s]+)\s*(\d*)`,
+		"Send COUNT (4 if empty) ICMP echo requests to the IPv4 or IPv6 ADDRESS from the current interface and show the round trip times and the packet loss.",
+		func(args []string) error {
+			count := pingDefaultCount
+			if args[1] != "" {
+				var err error
+				if count, err = strconv.Atoi(args[1]); err != nil {
+					return err
+				}
+			}
+			return d.Ping(args[0], count)
+		}))
+
+	d.AddHandler(session.NewModuleHandler("net.show.interfaces", "",
+		"Show the network interfaces of this computer and their promiscuous mode state.",
+		func(args []string) error {
+			return d.ShowInterfaces()
+		}))
+
+	d.AddHandler(session.NewModuleHandler("net.show.caps IFACE", `net\.show\.caps\s*([^\s]*)`,
+		"Show what the interface IFACE (or the current one if empty) and its driver support: promiscuous and monitor mode, injection and WiFi channels.",
+		func(args []string) error {
+			return d.ShowCaps(args[0])
+		}))
+
 	return d
 }
 
@@ -61,13 +167,17 @@ func (d Discovery) Name() string {
 }
 
 func (d Discovery) Description() string {
-	return "Read periodically the ARP cache in order to monitor for new hosts on the network."
+	return "Read periodically the ARP and IPv6 neighbor caches in order to monitor for new hosts on the network."
 }
 
 func (d Discovery) Author() string {
 	return "Simone Margaritelli <evilsocket@protonmail.com>"
 }
 
+func (d Discovery) Dependencies() []string {
+	return []string{"mac.changer"}
+}
+
 func (d *Discovery) runDiff(cache network.ArpTable) {
 	// check for endpoints who disappeared
 	var rem network.ArpTable = make(network.ArpTable)
@@ -83,13 +193,66 @@ func (d *Discovery) runDiff(cache network.ArpTable) {
 	}
 
 	// now check for new friends ^_^
+	cached := make(map[string]bool)
 	for ip, mac := range cache {
+		mac = network.NormalizeMac(mac)
+		cached[mac] = true
+		// aged hosts lingering in the ARP cache are not new, they will
+		// be added back only if seen again on the wire
+		if d.aged[mac] {
+			if _, found := d.Session.Lan.Get(mac); !found {
+				continue
+			}
+			delete(d.aged, mac)
+		}
 		d.Session.Lan.AddIfNew(ip, mac)
 	}
+
+	for mac := range d.aged {
+		if !cached[mac] {
+			delete(d.aged, mac)
+		}
+	}
 }
 
-func (d *Discovery) Configure() error {
-	return nil
+// updateIPv6 sets the IPv6 addresses of the known hosts from the IPv6
+// neighbor cache, filled by net.probe.
+func (d *Discovery) updateIPv6(iface string) {
+	table, err := network.NdpUpdate(iface)
+	if err != nil {
+		log.Debug("Could not read the IPv6 neighbor cache: %s", err)
+		return
+	}
+
+	for ip, mac := range table {
+		d.Session.Lan.SetIPv6For(mac, ip)
+	}
+}
+
+func (d *Discovery) prune() {
+	for _, e := range d.Session.Lan.Prune(d.aging) {
+		log.Debug("Endpoint %s not seen in %s, removing.", e.String(), time.Since(e.LastSeen))
+		d.aged[e.HwAddress] = true
+	}
+}
+
+func (d *Discovery) Configure() (err error) {
+	if err, d.aging = d.DurationParam("net.recon.aging"); err != nil {
+		return
+	} else if err, d.fingerprint = d.BoolParam("net.recon.fingerprint"); err != nil {
+		return
+	}
+	d.aged = make(map[string]bool)
+	return
+}
+
+// resetTraffic clears the traffic accounting so that every
+// run of the module starts counting from zero.
+func (d *Discovery) resetTraffic() {
+	d.Session.Gateway.Traffic.Reset()
+	d.Session.Lan.EachHost(func(mac string, e *network.Endpoint) {
+		e.Traffic.Reset()
+	})
 }
 
 func (d *Discovery) Start() error {
@@ -97,7 +260,13 @@ func (d *Discovery) Start() error {
 		return err
 	}
 
+	d.resetTraffic()
+
 	return d.SetRunning(true, func() {
+		if d.fingerprint && d.Session.Queue != nil {
+			d.Session.Queue.AddPacketListener(d.Name(), d.onPacket)
+		}
+
 		every := time.Duration(1) * time.Second
 		iface := d.Session.Interface.Name()
 		for d.Running() {
@@ -106,11 +275,44 @@ func (d *Discovery) Start() error {
 			} else {
 				d.runDiff(table)
 			}
+
+			d.updateIPv6(iface)
+
+			// the sweep runs at the polling pace whatever the aging timeout
+			if d.aging > 0 {
+				d.prune()
+			}
+
 			time.Sleep(every)
 		}
 	})
 }
 
 func (d *Discovery) Stop() error {
-	return d.SetRunning(false, nil)
+	return d.SetRunning(false, func() {
+		if d.Session.Queue != nil {
+			d.Session.Queue.RemovePacketListener(d.Name())
+		}
+	})
+}
+
+// FlushCache empties the neighbor cache so that the mappings changed by
+// a spoofing attack are learned again from the network.
+func (d *Discovery) FlushCache(iface string) error {
+	if iface != "" {
+		if _, err := net.InterfaceByName(iface); err != nil {
+			return err
+		}
+	}
+
+	if err := network.ArpFlush(iface); err != nil {
+		return err
+	}
+
+	if iface == "" {
+		log.Info("Neighbor cache flushed.")
+	} else {
+		log.Info("Neighbor cache of %s flushed.", iface)
+	}
+	return nil
 }
@@ -0,0 +1,100 @@
+package modules
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/network"
+)
+
+func capabilityState(c network.Capability) int {
+	switch c {
+	case network.CapabilitySupported:
+		return checkPassed
+	case network.CapabilityMissing:
+		return checkFailed
+	}
+	return checkUnknown
+}
+
+func channelsList(channels []int) string {
+	list := make([]string, len(channels))
+	for i, ch := range channels {
+		list[i] = fmt.Sprintf("%d", ch)
+	}
+	return strings.Join(list, ", ")
+}
+
+// capabilities returns the checks telling which modules can work on
+// the interface.
+func capabilities(caps *network.InterfaceCaps) []readinessCheck {
+	checks := make([]readinessCheck, 0)
+
+	driver := readinessCheck{Name: "Driver", State: checkUnknown, Details: "unknown"}
+	if caps.Driver != "" {
+		driver.State, driver.Details = checkPassed, caps.Driver
+	}
+	checks = append(checks, driver)
+
+	wireless := readinessCheck{Name: "WiFi", State: checkFailed, Details: "not a wireless interface"}
+	if caps.Wireless {
+		wireless.State, wireless.Details = checkPassed, "wireless interface"
+	}
+	checks = append(checks, wireless)
+
+	checks = append(checks, readinessCheck{
+		Name:    "Promiscuous mode",
+		State:   capabilityState(caps.Promisc),
+		Details: "needed by net.sniff to capture the traffic of other hosts",
+	})
+
+	checks = append(checks, readinessCheck{
+		Name:    "Monitor mode",
+		State:   capabilityState(caps.Monitor),
+		Details: "needed by the wifi module",
+	})
+
+	injection := readinessCheck{
+		Name:    "Injection",
+		State:   capabilityState(caps.Injection),
+		Details: "needed by wifi.deauth and the wifi.ap beacons",
+	}
+	if caps.Monitor == network.CapabilitySupported && injection.State == checkUnknown {
+		injection.Details = "monitor mode is supported but the driver doesn't tell if it can send frames"
+	}
+	checks = append(checks, injection)
+
+	channels := readinessCheck{Name: "Channels", State: checkUnknown, Details: "unknown"}
+	if len(caps.Channels) > 0 {
+		channels.State, channels.Details = checkPassed, channelsList(caps.Channels)
+	} else if !caps.Wireless {
+		channels.State, channels.Details = checkFailed, "none"
+	}
+	checks = append(checks, channels)
+
+	return checks
+}
+
+func (d *Discovery) ShowCaps(iface string) error {
+	if iface == "" {
+		iface = d.Session.Interface.Name()
+	}
+
+	caps, err := network.GetInterfaceCaps(iface)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0)
+	for _, check := range capabilities(caps) {
+		rows = append(rows, []string{check.Name, checkState(check.State), check.Details})
+	}
+
+	fmt.Printf("\n%s\n\n", core.Bold(iface))
+	core.AsTable(os.Stdout, []string{"Capability", "Supported", "Details"}, rows)
+	fmt.Println()
+
+	return nil
+}
@@ -0,0 +1,522 @@
+package modules
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/textproto"
+	"regexp"
+	"strings"
+
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/network"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// tcpSignature is a p0f like signature of the SYN packets an operating
+// system sends: initial TTL, TCP options layout and window size, if
+// it's a fixed one.
+type tcpSignature struct {
+	ttl     uint8
+	layout  string
+	windows []uint16
+	os      string
+}
+
+var tcpSignatures = []tcpSignature{
+	{64, "M,S,T,N,W", nil, "Linux"},
+	{64, "M,N,W,S,T", []uint16{65535}, "FreeBSD"},
+	{64, "M,N,W,N,N,T,S,E", []uint16{65535}, "macOS/iOS"},
+	{128, "M,N,W,N,N,S", []uint16{8192, 64240, 65535}, "Windows"},
+	{128, "M,N,W,S,T", nil, "Windows"},
+}
+
+// dhcpSignature is the order of the options a DHCP client requests.
+type dhcpSignature struct {
+	options string
+	os      string
+	device  string
+}
+
+var dhcpSignatures = []dhcpSignature{
+	{"1,3,6,15,31,33,43,44,46,47,119,121,249,252", "Windows", "computer"},
+	{"1,15,3,6,44,46,47,31,33,121,249,43", "Windows", "computer"},
+	{"1,15,3,6,44,46,47,31,33,121,249,43,252", "Windows", "computer"},
+	{"1,121,3,6,15,119,252,95,44,46", "macOS", "computer"},
+	{"1,121,3,6,15,114,119,252,95,44,46", "macOS", "computer"},
+	{"1,121,3,6,15,119,252", "iOS", "phone"},
+	{"1,121,3,6,15,108,114,119,252", "iOS", "phone"},
+	{"1,3,6,15,26,28,51,58,59,43", "Android", "phone"},
+	{"1,3,6,15,26,28,51,58,59,43,114", "Android", "phone"},
+	{"1,33,3,6,15,28,51,58,59", "Android", "phone"},
+	{"1,28,2,3,15,6,119,12,44,47,26,121,42", "Linux", "computer"},
+	{"1,2,6,12,15,26,28,121,3,33,40,41,42,119,249,252,17", "Linux", "computer"},
+	{"1,3,6,12,15,28,42", "Linux", "embedded"},
+}
+
+// dhcpVendors are the prefixes of the vendor class identifier of the
+// DHCP clients.
+var dhcpVendors = []struct {
+	prefix string
+	os     string
+	device string
+}{
+	{"MSFT", "Windows", "computer"},
+	{"android-dhcp", "Android", "phone"},
+	{"dhcpcd", "Linux", ""},
+	{"udhcp", "Linux", "embedded"},
+}
+
+// mdnsServices are the kinds of devices announcing a service.
+var mdnsServices = map[string]string{
+	"_googlecast._tcp": "media player",
+	"_airplay._tcp":    "media player",
+	"_raop._tcp":       "media player",
+	"_spotify-connect": "media player",
+	"_ipp._tcp":        "printer",
+	"_ipps._tcp":       "printer",
+	"_printer._tcp":    "printer",
+	"_pdl-datastream":  "printer",
+	"_scanner._tcp":    "printer",
+	"_hap._tcp":        "smart home",
+	"_homekit._tcp":    "smart home",
+}
+
+// appleModels are the prefixes of the model identifiers of the Apple
+// devices.
+var appleModels = []struct {
+	prefix string
+	os     string
+	device string
+}{
+	{"iPhone", "iOS", "phone"},
+	{"iPad", "iPadOS", "tablet"},
+	{"iPod", "iOS", "media player"},
+	{"AppleTV", "tvOS", "media player"},
+	{"Watch", "watchOS", "watch"},
+	{"AudioAccessory", "", "smart speaker"},
+	{"Mac", "macOS", "computer"},
+	{"iMac", "macOS", "computer"},
+}
+
+var (
+	reUAWindowsPhone = regexp.MustCompile(`Windows Phone(?: OS)? ([\d.]+)`)
+	reUAAndroid      = regexp.MustCompile(`Android ([\d.]+)(?:; ([^;)]+?))?(?: Build/[^;)]*)?\)`)
+	reUAiPhone       = regexp.MustCompile(`iPhone(?:;.*?)? OS ([\d_]+)`)
+	reUAiPad         = regexp.MustCompile(`iPad;.*? OS ([\d_]+)`)
+	reUAPlayStation  = regexp.MustCompile(`PlayStation ?(\w+)`)
+	reUAMac          = regexp.MustCompile(`Mac OS X ([\d_.]+)`)
+	reUAWindows      = regexp.MustCompile(`Windows NT ([\d.]+)`)
+	reSSDPOS         = regexp.MustCompile(`^([^/\s]+)(?:/([^\s,]+))?`)
+	reSSDPProduct    = regexp.MustCompile(`UPnP/[\d.]+[,\s]+(.+)$`)
+)
+
+var windowsVersions = map[string]string{
+	"10.0": "10",
+	"6.3":  "8.1",
+	"6.2":  "8",
+	"6.1":  "7",
+	"6.0":  "Vista",
+	"5.1":  "XP",
+}
+
+// initialTTL rounds the TTL of a packet up to the one the host sent it
+// with.
+func initialTTL(ttl uint8) uint8 {
+	for _, initial := range []uint8{32, 64, 128} {
+		if ttl <= initial {
+			return initial
+		}
+	}
+	return 255
+}
+
+// tcpOptionsLayout returns the kinds of the options of a packet, as
+// p0f shows them.
+func tcpOptionsLayout(tcp *layers.TCP) string {
+	layout := make([]string, 0, len(tcp.Options))
+	for _, opt := range tcp.Options {
+		switch opt.OptionType {
+		case layers.TCPOptionKindMSS:
+			layout = append(layout, "M")
+		case layers.TCPOptionKindNop:
+			layout = append(layout, "N")
+		case layers.TCPOptionKindWindowScale:
+			layout = append(layout, "W")
+		case layers.TCPOptionKindSACKPermitted:
+			layout = append(layout, "S")
+		case layers.TCPOptionKindTimestamps:
+			layout = append(layout, "T")
+		case layers.TCPOptionKindEndList:
+			// the padding after the end of the options is decoded as more of them
+			return strings.Join(append(layout, "E"), ",")
+		default:
+			layout = append(layout, fmt.Sprintf("?%d", opt.OptionType))
+		}
+	}
+	return strings.Join(layout, ",")
+}
+
+// fingerprintTCP guesses the operating system that sent a SYN packet.
+func fingerprintTCP(ip *layers.IPv4, tcp *layers.TCP) (network.FingerprintGuess, bool) {
+	guess := network.FingerprintGuess{Source: network.FingerprintTCP}
+	if !tcp.SYN || tcp.ACK {
+		return guess, false
+	}
+
+	ttl := initialTTL(ip.TTL)
+	layout := tcpOptionsLayout(tcp)
+	guess.Signature = fmt.Sprintf("%d:%d:%s", ttl, tcp.Window, layout)
+
+	for _, sig := range tcpSignatures {
+		if sig.ttl != ttl || sig.layout != layout {
+			continue
+		}
+		if sig.windows != nil {
+			fixed := false
+			for _, window := range sig.windows {
+				if window == tcp.Window {
+					fixed = true
+					break
+				}
+			}
+			if !fixed {
+				continue
+			}
+		}
+		guess.OS = sig.os
+		break
+	}
+
+	return guess, true
+}
+
+// fingerprintDHCP guesses the operating system of a DHCP client from
+// the options it requests and its vendor class.
+func fingerprintDHCP(dhcp *layers.DHCPv4) (network.FingerprintGuess, bool) {
+	guess := network.FingerprintGuess{Source: network.FingerprintDHCP}
+	if dhcp.Operation != layers.DHCPOpRequest {
+		return guess, false
+	}
+
+	vendor := ""
+	for _, opt := range dhcp.Options {
+		if opt.Type == layers.DHCPOptParamsRequest {
+			options := make([]string, len(opt.Data))
+			for i, code := range opt.Data {
+				options[i] = fmt.Sprintf("%d", code)
+			}
+			guess.Signature = strings.Join(options, ",")
+		} else if opt.Type == layers.DHCPOptClassID {
+			vendor = string(opt.Data)
+		}
+	}
+
+	if guess.Signature == "" && vendor == "" {
+		return guess, false
+	}
+
+	for _, sig := range dhcpSignatures {
+		if sig.options == guess.Signature {
+			guess.OS, guess.Device = sig.os, sig.device
+			break
+		}
+	}
+
+	// the vendor class is more specific than the options
+	for _, v := range dhcpVendors {
+		if strings.HasPrefix(vendor, v.prefix) {
+			guess.OS = v.os
+			if v.device != "" {
+				guess.Device = v.device
+			}
+			break
+		}
+	}
+
+	if vendor != "" {
+		guess.Signature = fmt.Sprintf("%s:%s", guess.Signature, vendor)
+	}
+
+	return guess, true
+}
+
+// appleModel fills the guess with what the model identifier of an Apple
+// device, such as MacBookPro18,1, tells.
+func appleModel(guess *network.FingerprintGuess, model string) {
+	for _, m := range appleModels {
+		if strings.HasPrefix(model, m.prefix) {
+			guess.OS, guess.Device = m.os, m.device
+			guess.Model = model
+			return
+		}
+	}
+}
+
+// fingerprintMDNS guesses the device announcing its services with a
+// multicast DNS response.
+func fingerprintMDNS(payload []byte) (network.FingerprintGuess, bool) {
+	guess := network.FingerprintGuess{Source: network.FingerprintMDNS}
+
+	dns := &layers.DNS{}
+	if err := dns.DecodeFromBytes(payload, gopacket.NilDecodeFeedback); err != nil || !dns.QR {
+		return guess, false
+	}
+
+	services := make([]string, 0)
+	records := append(dns.Answers, dns.Additionals...)
+	for _, rr := range records {
+		name := string(rr.Name)
+		switch rr.Type {
+		case layers.DNSTypePTR:
+			for service, device := range mdnsServices {
+				if strings.Contains(name, service) {
+					services = append(services, service)
+					if guess.Device == "" {
+						guess.Device = device
+					}
+				}
+			}
+		case layers.DNSTypeTXT:
+			for _, txt := range rr.TXTs {
+				parts := strings.SplitN(string(txt), "=", 2)
+				if len(parts) != 2 || parts[1] == "" {
+					continue
+				}
+				switch strings.ToLower(parts[0]) {
+				case "model":
+					if strings.Contains(name, "_device-info") {
+						appleModel(&guess, parts[1])
+					}
+					if guess.Model == "" {
+						guess.Model = parts[1]
+					}
+				case "md", "ty", "usb_mdl":
+					guess.Model = parts[1]
+				}
+			}
+		}
+	}
+
+	if guess.Device == "" && guess.Model == "" && guess.OS == "" {
+		return guess, false
+	}
+
+	guess.Signature = strings.Join(services, ",")
+	if guess.Model != "" {
+		guess.Signature = strings.TrimPrefix(guess.Signature+","+guess.Model, ",")
+	}
+	return guess, true
+}
+
+// ssdpDevice returns the device type of a UPnP notification type.
+func ssdpDevice(nt string) string {
+	switch {
+	case strings.Contains(nt, "InternetGatewayDevice"), strings.Contains(nt, "WANDevice"):
+		return "router"
+	case strings.Contains(nt, "MediaRenderer"), strings.Contains(nt, "dial-multiscreen"):
+		return "media player"
+	case strings.Contains(nt, "MediaServer"):
+		return "media server"
+	case strings.Contains(nt, "Printer"):
+		return "printer"
+	}
+	return ""
+}
+
+// fingerprintSSDP guesses the operating system and device type from the
+// SERVER and NT headers of an SSDP announcement or search response.
+func fingerprintSSDP(payload []byte) (network.FingerprintGuess, bool) {
+	guess := network.FingerprintGuess{Source: network.FingerprintSSDP}
+
+	reader := textproto.NewReader(bufio.NewReader(bytes.NewReader(payload)))
+	line, err := reader.ReadLine()
+	if err != nil || !(strings.HasPrefix(line, "NOTIFY ") || strings.HasPrefix(line, "HTTP/1.")) {
+		return guess, false
+	}
+
+	headers, _ := reader.ReadMIMEHeader()
+	server := headers.Get("Server")
+	nt := headers.Get("NT")
+	if nt == "" {
+		nt = headers.Get("ST")
+	}
+	if server == "" && nt == "" {
+		return guess, false
+	}
+
+	if m := reSSDPOS.FindStringSubmatch(server); m != nil {
+		switch os := strings.ToLower(m[1]); {
+		case os == "linux":
+			guess.OS = "Linux"
+		case strings.Contains(os, "windows"):
+			guess.OS = "Windows"
+		case os == "darwin" || os == "macos":
+			guess.OS = "macOS"
+		}
+	}
+	if m := reSSDPProduct.FindStringSubmatch(server); m != nil {
+		guess.Model = strings.TrimSpace(m[1])
+	}
+	guess.Device = ssdpDevice(nt)
+
+	guess.Signature = server
+	if nt != "" {
+		guess.Signature = strings.TrimPrefix(server+"|"+nt, "|")
+	}
+	return guess, true
+}
+
+// httpUserAgent returns the User-Agent of a plaintext HTTP request.
+func httpUserAgent(payload []byte) (string, bool) {
+	if !isHTTPRequest(payload) {
+		return "", false
+	}
+
+	reader := textproto.NewReader(bufio.NewReader(bytes.NewReader(payload)))
+	if _, err := reader.ReadLine(); err != nil {
+		return "", false
+	}
+	headers, _ := reader.ReadMIMEHeader()
+	ua := headers.Get("User-Agent")
+	return ua, ua != ""
+}
+
+func isHTTPRequest(payload []byte) bool {
+	for _, method := range []string{"GET ", "POST ", "HEAD ", "PUT ", "OPTIONS ", "DELETE "} {
+		if bytes.HasPrefix(payload, []byte(method)) {
+			return true
+		}
+	}
+	return false
+}
+
+// fingerprintUserAgent guesses the operating system, the device and its
+// model from the User-Agent of a browser.
+func fingerprintUserAgent(ua string) (network.FingerprintGuess, bool) {
+	guess := network.FingerprintGuess{Source: network.FingerprintHTTP, Signature: ua}
+
+	if m := reUAWindowsPhone.FindStringSubmatch(ua); m != nil {
+		guess.OS, guess.Device = "Windows Phone "+m[1], "phone"
+	} else if m := reUAAndroid.FindStringSubmatch(ua); m != nil {
+		guess.OS, guess.Device = "Android "+m[1], "tablet"
+		if strings.Contains(ua, "Mobile") {
+			guess.Device = "phone"
+		}
+		if model := strings.TrimSpace(m[2]); model != "" && model != "K" && !strings.HasPrefix(model, "wv") {
+			guess.Model = model
+		}
+	} else if m := reUAiPhone.FindStringSubmatch(ua); m != nil && strings.Contains(ua, "iPhone") {
+		guess.OS, guess.Device, guess.Model = "iOS "+strings.Replace(m[1], "_", ".", -1), "phone", "iPhone"
+	} else if m := reUAiPad.FindStringSubmatch(ua); m != nil {
+		guess.OS, guess.Device, guess.Model = "iPadOS "+strings.Replace(m[1], "_", ".", -1), "tablet", "iPad"
+	} else if m := reUAPlayStation.FindStringSubmatch(ua); m != nil {
+		guess.Device, guess.Model = "game console", "PlayStation "+m[1]
+	} else if strings.Contains(ua, "Tizen") || strings.Contains(ua, "SMART-TV") {
+		guess.OS, guess.Device = "Tizen", "smart tv"
+	} else if strings.Contains(ua, "Web0S") {
+		guess.OS, guess.Device = "webOS", "smart tv"
+	} else if strings.Contains(ua, "CrOS") {
+		guess.OS, guess.Device = "ChromeOS", "computer"
+	} else if m := reUAMac.FindStringSubmatch(ua); m != nil && strings.Contains(ua, "Macintosh") {
+		guess.OS, guess.Device = "macOS "+strings.Replace(m[1], "_", ".", -1), "computer"
+	} else if m := reUAWindows.FindStringSubmatch(ua); m != nil {
+		guess.OS, guess.Device = "Windows", "computer"
+		if version, found := windowsVersions[m[1]]; found {
+			guess.OS = "Windows " + version
+		}
+	} else if strings.Contains(ua, "Linux") && strings.Contains(ua, "X11") {
+		guess.OS, guess.Device = "Linux", "computer"
+	} else {
+		return guess, false
+	}
+
+	return guess, true
+}
+
+// fingerprintPacket returns the guesses a packet allows about its sender.
+func fingerprintPacket(pkt gopacket.Packet) []network.FingerprintGuess {
+	guesses := make([]network.FingerprintGuess, 0)
+
+	if ldhcp := pkt.Layer(layers.LayerTypeDHCPv4); ldhcp != nil {
+		if guess, ok := fingerprintDHCP(ldhcp.(*layers.DHCPv4)); ok {
+			guesses = append(guesses, guess)
+		}
+		return guesses
+	}
+
+	lip4 := pkt.Layer(layers.LayerTypeIPv4)
+	if lip4 == nil {
+		return guesses
+	}
+	ip := lip4.(*layers.IPv4)
+
+	if ltcp := pkt.Layer(layers.LayerTypeTCP); ltcp != nil {
+		tcp := ltcp.(*layers.TCP)
+		if guess, ok := fingerprintTCP(ip, tcp); ok {
+			guesses = append(guesses, guess)
+		} else if ua, ok := httpUserAgent(tcp.Payload); ok {
+			if guess, ok := fingerprintUserAgent(ua); ok {
+				guesses = append(guesses, guess)
+			}
+		}
+	} else if ludp := pkt.Layer(layers.LayerTypeUDP); ludp != nil {
+		udp := ludp.(*layers.UDP)
+		if udp.SrcPort == 5353 {
+			if guess, ok := fingerprintMDNS(udp.Payload); ok {
+				guesses = append(guesses, guess)
+			}
+		} else if udp.SrcPort == 1900 || udp.DstPort == 1900 {
+			if guess, ok := fingerprintSSDP(udp.Payload); ok {
+				guesses = append(guesses, guess)
+			}
+		}
+	}
+
+	return guesses
+}
+
+// fingerprintEndpoint returns the known host with the given hardware
+// address.
+func (d *Discovery) fingerprintEndpoint(mac string) *network.Endpoint {
+	if mac == d.Session.Interface.HwAddress {
+		return nil
+	} else if mac == d.Session.Gateway.HwAddress {
+		return d.Session.Gateway
+	} else if e, found := d.Session.Lan.Get(mac); found {
+		return e
+	}
+	return nil
+}
+
+// onPacket attaches what the packets sent by the known hosts tell about
+// them to their fingerprint.
+func (d *Discovery) onPacket(pkt gopacket.Packet) {
+	leth := pkt.Layer(layers.LayerTypeEthernet)
+	if leth == nil {
+		return
+	}
+
+	guesses := fingerprintPacket(pkt)
+	if len(guesses) == 0 {
+		return
+	}
+
+	e := d.fingerprintEndpoint(network.NormalizeMac(leth.(*layers.Ethernet).SrcMAC.String()))
+	if e == nil {
+		return
+	}
+
+	before := e.Fingerprint.String()
+	for _, guess := range guesses {
+		e.Fingerprint.Update(guess)
+	}
+
+	// new signatures alone are not worth an event
+	if after := e.Fingerprint.String(); after != before {
+		log.Debug("Endpoint %s fingerprinted as %s.", e.IpAddress, after)
+		d.Session.Events.Add("endpoint.fingerprint", e)
+	}
+}
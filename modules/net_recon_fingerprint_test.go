@@ -0,0 +1,208 @@
+package modules
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/bettercap/bettercap/session"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func TestFingerprintUserAgent(t *testing.T) {
+	var units = []struct {
+		ua  string
+		exp string
+	}{
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36", "Windows 10 computer"},
+		{"Mozilla/5.0 (Linux; Android 13; Pixel 7 Build/TQ3A.230805.001) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/116.0 Mobile Safari/537.36", "Android 13 phone (Pixel 7)"},
+		{"Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36", "Android 10 tablet"},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1", "iOS 17.1 phone (iPhone)"},
+		{"Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1", "iPadOS 16.6 tablet (iPad)"},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Safari/605.1.15", "macOS 10.15.7 computer"},
+		{"Mozilla/5.0 (X11; Linux x86_64; rv:109.0) Gecko/20100101 Firefox/119.0", "Linux computer"},
+		{"Mozilla/5.0 (X11; CrOS x86_64 14541.0.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36", "ChromeOS computer"},
+		{"Mozilla/5.0 (SMART-TV; Linux; Tizen 6.0) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/4.0 TV Safari/537.36", "Tizen smart tv"},
+		{"Mozilla/5.0 (PlayStation 4 9.00) AppleWebKit/605.1.15 (KHTML, like Gecko)", "game console (PlayStation 4)"},
+		{"curl/8.4.0", ""},
+	}
+
+	for _, u := range units {
+		guess, ok := fingerprintUserAgent(u.ua)
+		if ok != (u.exp != "") {
+			t.Fatalf("expected %v for '%s', got %v", u.exp != "", u.ua, ok)
+		} else if !ok {
+			continue
+		}
+
+		got := guess.OS
+		if guess.Device != "" {
+			got += " " + guess.Device
+		}
+		if guess.Model != "" {
+			got += " (" + guess.Model + ")"
+		}
+		if got = strings.TrimSpace(got); got != u.exp {
+			t.Fatalf("expected '%s', got '%s'", u.exp, got)
+		}
+	}
+}
+
+func TestFingerprintTCP(t *testing.T) {
+	options := func(kinds ...layers.TCPOptionKind) []layers.TCPOption {
+		list := make([]layers.TCPOption, 0, len(kinds))
+		for _, kind := range kinds {
+			list = append(list, layers.TCPOption{OptionType: kind})
+		}
+		return list
+	}
+
+	var M, N, W, S, T, E layers.TCPOptionKind = layers.TCPOptionKindMSS, layers.TCPOptionKindNop, layers.TCPOptionKindWindowScale,
+		layers.TCPOptionKindSACKPermitted, layers.TCPOptionKindTimestamps, layers.TCPOptionKindEndList
+
+	var units = []struct {
+		ttl    uint8
+		window uint16
+		opts   []layers.TCPOption
+		sig    string
+		os     string
+	}{
+		{61, 29200, options(M, S, T, N, W), "64:29200:M,S,T,N,W", "Linux"},
+		{127, 64240, options(M, N, W, N, N, S), "128:64240:M,N,W,N,N,S", "Windows"},
+		{50, 65535, options(M, N, W, N, N, T, S, E, E, E), "64:65535:M,N,W,N,N,T,S,E", "macOS/iOS"},
+		{64, 1024, options(M, N, W, N, N, T, S, E), "64:1024:M,N,W,N,N,T,S,E", ""},
+	}
+
+	for _, u := range units {
+		ip := &layers.IPv4{TTL: u.ttl}
+		tcp := &layers.TCP{SYN: true, Window: u.window, Options: u.opts}
+		if guess, ok := fingerprintTCP(ip, tcp); !ok {
+			t.Fatalf("expected a guess for %s", u.sig)
+		} else if guess.Signature != u.sig {
+			t.Fatalf("expected '%s', got '%s'", u.sig, guess.Signature)
+		} else if guess.OS != u.os {
+			t.Fatalf("expected '%s', got '%s'", u.os, guess.OS)
+		}
+	}
+
+	if _, ok := fingerprintTCP(&layers.IPv4{TTL: 64}, &layers.TCP{SYN: true, ACK: true}); ok {
+		t.Fatal("expected SYN+ACK packets to be ignored")
+	}
+}
+
+func TestFingerprintDHCP(t *testing.T) {
+	var units = []struct {
+		params []byte
+		vendor string
+		sig    string
+		os     string
+		device string
+	}{
+		{[]byte{1, 121, 3, 6, 15, 119, 252}, "", "1,121,3,6,15,119,252", "iOS", "phone"},
+		{[]byte{1, 3, 6, 15, 31, 33, 43, 44, 46, 47, 119, 121, 249, 252}, "MSFT 5.0", "1,3,6,15,31,33,43,44,46,47,119,121,249,252:MSFT 5.0", "Windows", "computer"},
+		{[]byte{1, 3, 6, 28}, "android-dhcp-13", "1,3,6,28:android-dhcp-13", "Android", "phone"},
+		{[]byte{1, 3, 6, 28}, "", "1,3,6,28", "", ""},
+	}
+
+	for _, u := range units {
+		dhcp := &layers.DHCPv4{Operation: layers.DHCPOpRequest}
+		dhcp.Options = append(dhcp.Options, layers.NewDHCPOption(layers.DHCPOptParamsRequest, u.params))
+		if u.vendor != "" {
+			dhcp.Options = append(dhcp.Options, layers.NewDHCPOption(layers.DHCPOptClassID, []byte(u.vendor)))
+		}
+
+		if guess, ok := fingerprintDHCP(dhcp); !ok {
+			t.Fatalf("expected a guess for %s", u.sig)
+		} else if guess.Signature != u.sig {
+			t.Fatalf("expected '%s', got '%s'", u.sig, guess.Signature)
+		} else if guess.OS != u.os || guess.Device != u.device {
+			t.Fatalf("expected '%s %s', got '%s %s'", u.os, u.device, guess.OS, guess.Device)
+		}
+	}
+}
+
+func TestFingerprintSSDP(t *testing.T) {
+	notify := "NOTIFY * HTTP/1.1\r\n" +
+		"HOST: 239.255.255.250:1900\r\n" +
+		"NT: urn:schemas-upnp-org:device:MediaRenderer:1\r\n" +
+		"NTS: ssdp:alive\r\n" +
+		"SERVER: Linux/4.9 UPnP/1.0 Roku/9.4.0\r\n\r\n"
+
+	guess, ok := fingerprintSSDP([]byte(notify))
+	if !ok {
+		t.Fatal("expected a guess")
+	} else if guess.OS != "Linux" || guess.Device != "media player" || guess.Model != "Roku/9.4.0" {
+		t.Fatalf("expected 'Linux media player (Roku/9.4.0)', got '%s %s (%s)'", guess.OS, guess.Device, guess.Model)
+	}
+
+	if _, ok := fingerprintSSDP([]byte("M-SEARCH * HTTP/1.1\r\nST: ssdp:all\r\n\r\n")); ok {
+		t.Fatal("expected searches to be ignored")
+	}
+}
+
+func mdnsPacket(t *testing.T, records ...layers.DNSResourceRecord) []byte {
+	dns := &layers.DNS{QR: true, AA: true, Answers: records, ANCount: uint16(len(records))}
+	buf := gopacket.NewSerializeBuffer()
+	if err := dns.SerializeTo(buf, gopacket.SerializeOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestFingerprintMDNS(t *testing.T) {
+	payload := mdnsPacket(t,
+		layers.DNSResourceRecord{Name: []byte("_googlecast._tcp.local"), Type: layers.DNSTypePTR, Class: layers.DNSClassIN,
+			PTR: []byte("Living-Room._googlecast._tcp.local")},
+		layers.DNSResourceRecord{Name: []byte("Living-Room._googlecast._tcp.local"), Type: layers.DNSTypeTXT, Class: layers.DNSClassIN,
+			TXTs: [][]byte{[]byte("id=1234"), []byte("md=Chromecast Ultra")}})
+
+	if guess, ok := fingerprintMDNS(payload); !ok {
+		t.Fatal("expected a guess")
+	} else if guess.Device != "media player" || guess.Model != "Chromecast Ultra" {
+		t.Fatalf("expected 'media player (Chromecast Ultra)', got '%s (%s)'", guess.Device, guess.Model)
+	}
+
+	payload = mdnsPacket(t,
+		layers.DNSResourceRecord{Name: []byte("MacBook._device-info._tcp.local"), Type: layers.DNSTypeTXT, Class: layers.DNSClassIN,
+			TXTs: [][]byte{[]byte("model=MacBookPro18,1")}})
+
+	if guess, ok := fingerprintMDNS(payload); !ok {
+		t.Fatal("expected a guess")
+	} else if guess.OS != "macOS" || guess.Device != "computer" || guess.Model != "MacBookPro18,1" {
+		t.Fatalf("expected 'macOS computer (MacBookPro18,1)', got '%s %s (%s)'", guess.OS, guess.Device, guess.Model)
+	}
+}
+
+func TestDiscoveryFingerprintPacket(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	d := NewDiscovery(s.Session)
+	s.Lan.AddIfNew("192.168.1.10", "aa:00:00:00:00:10")
+	host, _ := s.Lan.Get("aa:00:00:00:00:10")
+
+	mac, _ := net.ParseMAC("aa:00:00:00:00:10")
+	eth := &layers.Ethernet{SrcMAC: mac, DstMAC: s.Interface.HW, EthernetType: layers.EthernetTypeIPv4}
+	ip := &layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolTCP,
+		SrcIP: net.ParseIP("192.168.1.10").To4(), DstIP: net.ParseIP("93.184.216.34").To4()}
+	tcp := &layers.TCP{SrcPort: 51000, DstPort: 80, PSH: true, ACK: true}
+	tcp.SetNetworkLayerForChecksum(ip)
+	request := "GET / HTTP/1.1\r\nHost: example.com\r\n" +
+		"User-Agent: Mozilla/5.0 (Linux; Android 13; Pixel 7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/116.0 Mobile Safari/537.36\r\n\r\n"
+
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buf, opts, eth, ip, tcp, gopacket.Payload(request)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	d.onPacket(gopacket.NewPacket(buf.Bytes(), layers.LayerTypeEthernet, gopacket.Default))
+	if got := host.Fingerprint.String(); got != "Android 13 phone (Pixel 7)" {
+		t.Fatalf("expected 'Android 13 phone (Pixel 7)', got '%s'", got)
+	}
+}
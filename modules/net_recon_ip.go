@@ -0,0 +1,137 @@
+package modules
+
+import (
+	"fmt"
+	"net"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+)
+
+// interface meta holding the secondary addresses added by net.recon.addip
+const secondaryAddressesMeta = "secondary"
+
+func parseSecondaryAddress(address string) (net.IP, *net.IPNet, error) {
+	if !strings.Contains(address, "/") {
+		address += "/32"
+	}
+
+	ip, subnet, err := net.ParseCIDR(address)
+	if err != nil {
+		return nil, nil, err
+	} else if ip.To4() == nil {
+		return nil, nil, fmt.Errorf("Only IPv4 addresses are supported.")
+	}
+	return ip.To4(), subnet, nil
+}
+
+func secondaryAddressCmd(add bool, iface string, ip net.IP, subnet *net.IPNet) (string, []string, error) {
+	bits, _ := subnet.Mask.Size()
+	cidr := fmt.Sprintf("%s/%d", ip, bits)
+
+	os := runtime.GOOS
+	if strings.Contains(os, "bsd") || os == "darwin" {
+		if add {
+			return "ifconfig", []string{iface, "alias", ip.String(), "netmask", net.IP(subnet.Mask).String()}, nil
+		}
+		return "ifconfig", []string{iface, "-alias", ip.String()}, nil
+	} else if os == "linux" || os == "android" {
+		action := "del"
+		if add {
+			action = "add"
+		}
+		return "ip", []string{"addr", action, cidr, "dev", iface}, nil
+	}
+	return "", nil, fmt.Errorf("OS %s is not supported by net.recon.addip.", os)
+}
+
+func (d *Discovery) isAssigned(ip net.IP) bool {
+	if ip.Equal(d.Session.Interface.IP) {
+		return true
+	} else if _, found := d.secondary[ip.String()]; found {
+		return true
+	}
+
+	if iface, err := net.InterfaceByName(d.Session.Interface.Name()); err == nil {
+		if addrs, err := iface.Addrs(); err == nil {
+			for _, addr := range addrs {
+				if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.Equal(ip) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (d *Discovery) updateSecondaryMeta() {
+	addresses := make([]string, 0, len(d.secondary))
+	for ip := range d.secondary {
+		addresses = append(addresses, ip)
+	}
+	sort.Strings(addresses)
+
+	d.Session.Interface.Meta.Set(secondaryAddressesMeta, strings.Join(addresses, ", "))
+}
+
+// AddIP assigns a secondary address to the interface, it'll be removed
+// when the session is closed.
+func (d *Discovery) AddIP(address string) error {
+	ip, subnet, err := parseSecondaryAddress(core.Trim(address))
+	if err != nil {
+		return err
+	} else if d.isAssigned(ip) {
+		return fmt.Errorf("Address %s is already assigned to %s.", ip, d.Session.Interface.Name())
+	}
+
+	cmd, args, err := secondaryAddressCmd(true, d.Session.Interface.Name(), ip, subnet)
+	if err != nil {
+		return err
+	} else if _, err = core.Exec(cmd, args); err != nil {
+		return err
+	}
+
+	d.secondary[ip.String()] = subnet
+	d.updateSecondaryMeta()
+
+	log.Info("Address %s added to %s.", core.Bold(ip.String()), d.Session.Interface.Name())
+	return nil
+}
+
+// DelIP removes a secondary address added with AddIP.
+func (d *Discovery) DelIP(address string) error {
+	ip := net.ParseIP(core.Trim(address))
+	if ip == nil {
+		return fmt.Errorf("'%s' is not a valid IP address.", address)
+	}
+
+	subnet, found := d.secondary[ip.String()]
+	if !found {
+		return fmt.Errorf("Address %s was not added by net.recon.addip.", ip)
+	}
+
+	cmd, args, err := secondaryAddressCmd(false, d.Session.Interface.Name(), ip, subnet)
+	if err != nil {
+		return err
+	} else if _, err = core.Exec(cmd, args); err != nil {
+		return err
+	}
+
+	delete(d.secondary, ip.String())
+	d.updateSecondaryMeta()
+
+	log.Info("Address %s removed from %s.", core.Bold(ip.String()), d.Session.Interface.Name())
+	return nil
+}
+
+// Revert removes every secondary address added with AddIP.
+func (d *Discovery) Revert() (bool, error) {
+	result := core.NewMultiError()
+	for ip := range d.secondary {
+		result.Add(ip, d.DelIP(ip))
+	}
+	return result.Succeeded > 0, result.ErrorOrNil()
+}
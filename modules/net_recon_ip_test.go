@@ -0,0 +1,65 @@
+package modules
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/bettercap/bettercap/session"
+)
+
+func TestDiscoverySecondaryAddresses(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("address commands are only tested on linux")
+	}
+
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	d := NewDiscovery(s.Session)
+	s.Register(d)
+
+	var units = []struct {
+		handler string
+		arg     string
+		valid   bool
+	}{
+		{"net.recon.addip ADDRESS", "192.168.1.50/24", true},
+		{"net.recon.addip ADDRESS", "192.168.1.50", false},
+		{"net.recon.addip ADDRESS", session.TestInterfaceIP, false},
+		{"net.recon.addip ADDRESS", "fe80::1/64", false},
+		{"net.recon.addip ADDRESS", "10.0.0.1", true},
+		{"net.recon.delip ADDRESS", "10.0.0.1", true},
+		{"net.recon.delip ADDRESS", "10.0.0.1", false},
+		{"net.recon.delip ADDRESS", "nope", false},
+	}
+
+	for _, u := range units {
+		if err := s.Handle("net.recon", u.handler, u.arg); (err == nil) != u.valid {
+			t.Fatalf("expected '%s %s' valid=%v, got error '%v'", u.handler, u.arg, u.valid, err)
+		}
+	}
+
+	if v := s.Interface.Meta.Get(secondaryAddressesMeta); v != "192.168.1.50" {
+		t.Fatalf("expected '192.168.1.50', got '%v'", v)
+	} else if result := s.RevertAll(); result.ErrorOrNil() != nil {
+		t.Fatalf("unexpected error: %v", result.ErrorOrNil())
+	} else if result.Succeeded != 1 {
+		t.Fatalf("expected 1 success, got %d", result.Succeeded)
+	} else if v := s.Interface.Meta.Get(secondaryAddressesMeta); v != "" {
+		t.Fatalf("expected no secondary addresses, got '%v'", v)
+	}
+
+	exp := []string{
+		"ip addr add 192.168.1.50/24 dev test0",
+		"ip addr add 10.0.0.1/32 dev test0",
+		"ip addr del 10.0.0.1/32 dev test0",
+		"ip addr del 192.168.1.50/24 dev test0",
+	}
+	if got := s.Executed(); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, got)
+	}
+}
@@ -0,0 +1,212 @@
+package modules
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/session"
+)
+
+const (
+	pingDefaultCount = 4
+	pingTimeout      = 1 * time.Second
+	pingPayloadSize  = 56
+
+	icmpv4EchoRequest = 8
+	icmpv4EchoReply   = 0
+	icmpv6EchoRequest = 128
+	icmpv6EchoReply   = 129
+)
+
+type pingStats struct {
+	Sent     int
+	Received int
+	Min      time.Duration
+	Max      time.Duration
+	total    time.Duration
+}
+
+func (s *pingStats) Add(rtt time.Duration) {
+	if s.Received == 0 || rtt < s.Min {
+		s.Min = rtt
+	}
+	if rtt > s.Max {
+		s.Max = rtt
+	}
+	s.Received++
+	s.total += rtt
+}
+
+func (s *pingStats) Avg() time.Duration {
+	if s.Received == 0 {
+		return 0
+	}
+	return s.total / time.Duration(s.Received)
+}
+
+// Loss is the percentage of echo requests with no reply.
+func (s *pingStats) Loss() float64 {
+	if s.Sent == 0 {
+		return 0
+	}
+	return float64(s.Sent-s.Received) * 100.0 / float64(s.Sent)
+}
+
+func icmpChecksum(b []byte) uint16 {
+	sum := uint32(0)
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(b[i:]))
+	}
+	if len(b)%2 == 1 {
+		sum += uint32(b[len(b)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = (sum & 0xffff) + (sum >> 16)
+	}
+	return ^uint16(sum)
+}
+
+// newICMPEcho builds an echo request, the checksum of the ICMPv6 ones
+// is computed by the kernel as it depends on the source address.
+func newICMPEcho(ipv6 bool, id, seq uint16, payload []byte) []byte {
+	msg := make([]byte, 8+len(payload))
+	msg[0] = icmpv4EchoRequest
+	if ipv6 {
+		msg[0] = icmpv6EchoRequest
+	}
+	binary.BigEndian.PutUint16(msg[4:6], id)
+	binary.BigEndian.PutUint16(msg[6:8], seq)
+	copy(msg[8:], payload)
+
+	if !ipv6 {
+		binary.BigEndian.PutUint16(msg[2:4], icmpChecksum(msg))
+	}
+	return msg
+}
+
+// parseICMPEchoReply returns the identifier and the sequence number of
+// an echo reply, ok is false for any other message.
+func parseICMPEchoReply(ipv6 bool, msg []byte) (id uint16, seq uint16, ok bool) {
+	reply := byte(icmpv4EchoReply)
+	if ipv6 {
+		reply = icmpv6EchoReply
+	}
+
+	if len(msg) < 8 || msg[0] != reply || msg[1] != 0 {
+		return 0, 0, false
+	}
+	return binary.BigEndian.Uint16(msg[4:6]), binary.BigEndian.Uint16(msg[6:8]), true
+}
+
+// pingConn opens the raw ICMP socket on the address of the interface
+// of the same family of the target.
+func (d *Discovery) pingConn(ip net.IP) (net.PacketConn, *net.IPAddr, error) {
+	iface := d.Session.Interface
+	dst := &net.IPAddr{IP: ip}
+
+	if ip.To4() != nil {
+		if iface.IP == nil || iface.IP.To4() == nil {
+			return nil, nil, fmt.Errorf("%s has no IPv4 address.", iface.Name())
+		}
+		conn, err := net.ListenPacket("ip4:icmp", iface.IP.String())
+		return conn, dst, err
+	}
+
+	if ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+		dst.Zone = iface.Name()
+	}
+
+	src := "::"
+	if iface.IPv6 != nil {
+		src = iface.IPv6.String()
+		if iface.IPv6.IsLinkLocalUnicast() {
+			src += "%" + iface.Name()
+		}
+	}
+	conn, err := net.ListenPacket("ip6:ipv6-icmp", src)
+	return conn, dst, err
+}
+
+func (d *Discovery) waitEchoReply(conn net.PacketConn, dst *net.IPAddr, id, seq uint16, deadline time.Time) (int, bool) {
+	ipv6 := dst.IP.To4() == nil
+	buf := make([]byte, 1500)
+
+	conn.SetReadDeadline(deadline)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			return 0, false
+		} else if addr, ok := from.(*net.IPAddr); !ok || !addr.IP.Equal(dst.IP) {
+			continue
+		} else if rid, rseq, ok := parseICMPEchoReply(ipv6, buf[:n]); ok && rid == id && rseq == seq {
+			return n, true
+		}
+	}
+}
+
+// Ping sends count ICMP echo requests to the address, one per second,
+// and prints the round trip time of each reply.
+func (d *Discovery) Ping(address string, count int) error {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return fmt.Errorf("'%s' is not a valid IPv4 or IPv6 address.", address)
+	} else if count <= 0 {
+		return fmt.Errorf("The number of echo requests must be greater than 0.")
+	} else if err := d.Session.RequirePrivileges("net.ping", session.CapNetRaw); err != nil {
+		return err
+	}
+
+	conn, dst, err := d.pingConn(ip)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	id := uint16(os.Getpid() & 0xffff)
+	payload := make([]byte, pingPayloadSize)
+	for i := range payload {
+		payload[i] = byte(i)
+	}
+
+	fmt.Printf("\nPING %s from %s: %d data bytes\n", dst, d.Session.Interface.Name(), len(payload))
+
+	stats := pingStats{}
+	for seq := 1; seq <= count; seq++ {
+		msg := newICMPEcho(ip.To4() == nil, id, uint16(seq), payload)
+
+		sent := time.Now()
+		if _, err := conn.WriteTo(msg, dst); err != nil {
+			return fmt.Errorf("Could not send echo request to %s: %s", dst, err)
+		}
+		stats.Sent++
+
+		if n, ok := d.waitEchoReply(conn, dst, id, uint16(seq), sent.Add(pingTimeout)); ok {
+			rtt := time.Since(sent)
+			stats.Add(rtt)
+			fmt.Printf("%d bytes from %s: seq=%d time=%s\n", n, dst, seq, rtt.Round(time.Microsecond))
+		} else {
+			fmt.Printf("Request timeout for seq=%d\n", seq)
+		}
+
+		if wait := pingTimeout - time.Since(sent); wait > 0 && seq < count {
+			time.Sleep(wait)
+		}
+	}
+
+	fmt.Printf("\n%d packets transmitted, %d received, %.1f%% packet loss\n", stats.Sent, stats.Received, stats.Loss())
+	if stats.Received > 0 {
+		fmt.Printf("rtt min/avg/max = %s/%s/%s\n",
+			stats.Min.Round(time.Microsecond),
+			stats.Avg().Round(time.Microsecond),
+			stats.Max.Round(time.Microsecond))
+	} else {
+		fmt.Println(core.Red(fmt.Sprintf("%s is not reachable.", dst)))
+	}
+	fmt.Println()
+
+	return nil
+}
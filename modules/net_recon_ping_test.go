@@ -0,0 +1,55 @@
+package modules
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNetReconPingEcho(t *testing.T) {
+	payload := []byte{0x01, 0x02, 0x03}
+
+	msg := newICMPEcho(false, 0x1234, 7, payload)
+	if len(msg) != 11 || msg[0] != icmpv4EchoRequest {
+		t.Fatalf("unexpected echo request '%x'", msg)
+	} else if sum := icmpChecksum(msg); sum != 0 {
+		t.Fatalf("expected a valid checksum, got %x", sum)
+	}
+
+	// the kernel fills the ICMPv6 checksum
+	if msg6 := newICMPEcho(true, 0x1234, 7, payload); msg6[0] != icmpv6EchoRequest || msg6[2] != 0 || msg6[3] != 0 {
+		t.Fatalf("unexpected echo request '%x'", msg6)
+	}
+
+	var units = []struct {
+		ipv6 bool
+		msg  []byte
+		id   uint16
+		seq  uint16
+		ok   bool
+	}{
+		{false, []byte{icmpv4EchoReply, 0, 0, 0, 0x12, 0x34, 0, 7}, 0x1234, 7, true},
+		{true, []byte{icmpv6EchoReply, 0, 0, 0, 0x12, 0x34, 0, 8}, 0x1234, 8, true},
+		{false, []byte{icmpv4EchoRequest, 0, 0, 0, 0x12, 0x34, 0, 7}, 0, 0, false},
+		{true, []byte{icmpv4EchoReply, 0, 0, 0, 0x12, 0x34, 0, 7}, 0, 0, false},
+		{false, []byte{icmpv4EchoReply, 0, 0}, 0, 0, false},
+	}
+
+	for _, u := range units {
+		if id, seq, ok := parseICMPEchoReply(u.ipv6, u.msg); ok != u.ok || id != u.id || seq != u.seq {
+			t.Fatalf("expected %x/%d/%v for '%x', got %x/%d/%v", u.id, u.seq, u.ok, u.msg, id, seq, ok)
+		}
+	}
+}
+
+func TestNetReconPingStats(t *testing.T) {
+	stats := pingStats{Sent: 4}
+	for _, rtt := range []time.Duration{3 * time.Millisecond, 1 * time.Millisecond, 2 * time.Millisecond} {
+		stats.Add(rtt)
+	}
+
+	if stats.Min != time.Millisecond || stats.Max != 3*time.Millisecond || stats.Avg() != 2*time.Millisecond {
+		t.Fatalf("unexpected rtt %s/%s/%s", stats.Min, stats.Avg(), stats.Max)
+	} else if loss := stats.Loss(); loss != 25.0 {
+		t.Fatalf("expected 25%% loss, got %.1f%%", loss)
+	}
+}
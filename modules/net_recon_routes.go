@@ -0,0 +1,102 @@
+package modules
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/firewall"
+	"github.com/bettercap/bettercap/network"
+)
+
+const (
+	checkFailed = iota
+	checkPassed
+	checkUnknown
+)
+
+type readinessCheck struct {
+	Name    string
+	State   int
+	Details string
+}
+
+func checkState(state int) string {
+	switch state {
+	case checkFailed:
+		return no()
+	case checkPassed:
+		return yes()
+	}
+	return core.Dim("?")
+}
+
+// readiness verifies the conditions needed to forward the traffic
+// of the spoofed hosts, the usual suspects when they lose connectivity.
+func (d *Discovery) readiness() []readinessCheck {
+	checks := make([]readinessCheck, 0)
+
+	fwd := readinessCheck{Name: "IP forwarding", State: checkFailed, Details: "disabled"}
+	if d.Session.Firewall == nil {
+		fwd.State, fwd.Details = checkUnknown, "no firewall manager"
+	} else if d.Session.Firewall.IsForwardingEnabled() {
+		fwd.State, fwd.Details = checkPassed, "enabled"
+	}
+	checks = append(checks, fwd)
+
+	gw := readinessCheck{Name: "Gateway", State: checkFailed, Details: "not found"}
+	if g := d.Session.Gateway; g != nil && g != d.Session.Interface && g.IpAddress != d.Session.Interface.IpAddress {
+		if g.HW == nil || g.HwAddress == "" || g.HwAddress == "00:00:00:00:00:00" {
+			gw.Details = fmt.Sprintf("%s has no hardware address", g.IpAddress)
+		} else {
+			gw.State, gw.Details = checkPassed, fmt.Sprintf("%s is at %s", g.IpAddress, g.HwAddress)
+		}
+	}
+	checks = append(checks, gw)
+
+	up := readinessCheck{Name: "Interface", State: checkFailed}
+	name := d.Session.Interface.Name()
+	if iface, err := net.InterfaceByName(name); err != nil {
+		up.Details = err.Error()
+	} else if iface.Flags&net.FlagUp == 0 {
+		up.Details = fmt.Sprintf("%s is down", name)
+	} else {
+		up.State, up.Details = checkPassed, fmt.Sprintf("%s is up", name)
+	}
+	checks = append(checks, up)
+
+	rules := readinessCheck{Name: "Forwarded packets", State: checkFailed}
+	if dropping, err := firewall.ForwardDropRules(); err != nil {
+		rules.State, rules.Details = checkUnknown, err.Error()
+	} else if len(dropping) > 0 {
+		rules.Details = "dropped by " + strings.Join(dropping, "\n")
+	} else {
+		rules.State, rules.Details = checkPassed, "not dropped"
+	}
+	checks = append(checks, rules)
+
+	return checks
+}
+
+func (d *Discovery) ShowRoutes() error {
+	out, err := core.ExecSilent(network.IPv4RouteCmd, network.IPv4RouteCmdOpts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%s\n\n", out)
+
+	rows := make([][]string, 0)
+	for _, check := range d.readiness() {
+		rows = append(rows, []string{check.Name, checkState(check.State), check.Details})
+	}
+
+	core.AsTable(os.Stdout, []string{"Check", "Ready", "Details"}, rows)
+	fmt.Println()
+
+	d.Session.Refresh()
+
+	return nil
+}
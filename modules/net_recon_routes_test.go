@@ -0,0 +1,81 @@
+package modules
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/bettercap/bettercap/firewall"
+	"github.com/bettercap/bettercap/session"
+)
+
+type fakeFirewall struct {
+	forwarding bool
+}
+
+func (f *fakeFirewall) IsForwardingEnabled() bool                                     { return f.forwarding }
+func (f *fakeFirewall) EnableForwarding(enabled bool) error                           { f.forwarding = enabled; return nil }
+func (f *fakeFirewall) EnableRedirection(r *firewall.Redirection, enabled bool) error { return nil }
+func (f *fakeFirewall) Restore()                                                      {}
+
+func TestDiscoveryReadiness(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	iptables := "-P FORWARD ACCEPT\n-A FORWARD -i eth1 -j ACCEPT"
+	s.ExecOutput = func(executable string, args []string) (string, error) {
+		if executable == "iptables" {
+			return iptables, nil
+		}
+		return "", nil
+	}
+
+	fw := &fakeFirewall{}
+	s.Firewall = fw
+	d := NewDiscovery(s.Session)
+
+	states := func() map[string]readinessCheck {
+		checks := make(map[string]readinessCheck)
+		for _, c := range d.readiness() {
+			checks[c.Name] = c
+		}
+		return checks
+	}
+
+	checks := states()
+	if c := checks["IP forwarding"]; c.State != checkFailed {
+		t.Fatalf("expected forwarding check to fail, got %v", c)
+	} else if c := checks["Gateway"]; c.State != checkPassed || c.Details != session.TestGatewayIP+" is at "+session.TestGatewayMAC {
+		t.Fatalf("expected gateway check to pass, got %v", c)
+	} else if c := checks["Interface"]; c.State != checkFailed {
+		// there's no test0 interface on the test host
+		t.Fatalf("expected interface check to fail, got %v", c)
+	}
+
+	if runtime.GOOS != "linux" {
+		if c := checks["Forwarded packets"]; c.State != checkUnknown {
+			t.Fatalf("expected forwarded packets check to be unknown, got %v", c)
+		}
+		return
+	} else if c := checks["Forwarded packets"]; c.State != checkPassed {
+		t.Fatalf("expected forwarded packets check to pass, got %v", c)
+	}
+
+	fw.forwarding = true
+	iptables = "-P FORWARD DROP\n-A FORWARD -i eth1 -j ACCEPT\n-A FORWARD -s 10.0.0.0/8 -j REJECT --reject-with icmp-port-unreachable"
+	s.Gateway = s.Interface
+
+	checks = states()
+	if c := checks["IP forwarding"]; c.State != checkPassed {
+		t.Fatalf("expected forwarding check to pass, got %v", c)
+	} else if c := checks["Gateway"]; c.State != checkFailed {
+		t.Fatalf("expected gateway check to fail, got %v", c)
+	} else if c := checks["Forwarded packets"]; c.State != checkFailed {
+		t.Fatalf("expected forwarded packets check to fail, got %v", c)
+	} else if strings.Count(c.Details, "\n") != 1 || !strings.Contains(c.Details, "-P FORWARD DROP") {
+		t.Fatalf("unexpected details '%s'", c.Details)
+	}
+}
@@ -2,6 +2,7 @@ package modules
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"sort"
 	"strings"
@@ -9,7 +10,6 @@ import (
 
 	"github.com/bettercap/bettercap/core"
 	"github.com/bettercap/bettercap/network"
-	"github.com/bettercap/bettercap/packets"
 
 	"github.com/dustin/go-humanize"
 )
@@ -31,7 +31,7 @@ func (p ProtoPairList) Len() int           { return len(p) }
 func (p ProtoPairList) Less(i, j int) bool { return p[i].Hits < p[j].Hits }
 func (p ProtoPairList) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
 
-func (d *Discovery) getRow(e *network.Endpoint, withMeta bool) []string {
+func (d *Discovery) getRow(e *network.Endpoint, withIPv6 bool, withOS bool, withTags bool, withMeta bool) []string {
 	sinceStarted := time.Since(d.Session.StartedAt)
 	sinceFirstSeen := time.Since(e.FirstSeen)
 
@@ -58,11 +58,8 @@ func (d *Discovery) getRow(e *network.Endpoint, withMeta bool) []string {
 		name = core.Yellow(e.Hostname)
 	}
 
-	var traffic *packets.Traffic
-	var found bool
-	if traffic, found = d.Session.Queue.Traffic[e.IpAddress]; !found {
-		traffic = &packets.Traffic{}
-	}
+	sent, rcvd := e.Traffic.Bytes()
+	pktSent, pktRcvd := e.Traffic.Packets()
 
 	seen := e.LastSeen.Format("15:04:05")
 	sinceLastSeen := time.Since(e.LastSeen)
@@ -76,14 +73,26 @@ func (d *Discovery) getRow(e *network.Endpoint, withMeta bool) []string {
 		seen = core.Dim(seen)
 	}
 
-	row := []string{
-		addr,
+	row := []string{addr}
+	if withIPv6 {
+		row = append(row, e.Ip6Address)
+	}
+	row = append(row,
 		mac,
 		name,
 		e.Vendor,
-		humanize.Bytes(traffic.Sent),
-		humanize.Bytes(traffic.Received),
+	)
+	if withOS {
+		row = append(row, e.Fingerprint.String())
+	}
+	row = append(row,
+		fmt.Sprintf("%s (%d pkts)", humanize.Bytes(sent), pktSent),
+		fmt.Sprintf("%s (%d pkts)", humanize.Bytes(rcvd), pktRcvd),
 		seen,
+	)
+
+	if withTags {
+		row = append(row, core.Yellow(strings.Join(e.Tags, ", ")), e.Note)
 	}
 
 	if withMeta {
@@ -106,10 +115,18 @@ func (d *Discovery) Show(by string) error {
 		sort.Sort(BySentSorter(targets))
 	} else if by == "rcvd" {
 		sort.Sort(ByRcvdSorter(targets))
+	} else if by == "traffic" {
+		sort.Sort(ByTrafficSorter(targets))
 	} else {
 		sort.Sort(ByAddressSorter(targets))
 	}
 
+	if err, tags := d.ListParam("net.recon.show.tags"); err != nil {
+		return err
+	} else {
+		targets = filterByTags(targets, tags)
+	}
+
 	pad := 1
 	if d.Session.Interface.HwAddress == d.Session.Gateway.HwAddress {
 		pad = 0
@@ -118,16 +135,41 @@ func (d *Discovery) Show(by string) error {
 		targets = append([]*network.Endpoint{d.Session.Interface, d.Session.Gateway}, targets...)
 	}
 
+	hasIPv6 := false
+	hasOS := false
+	hasTags := false
 	hasMeta := false
 	for _, t := range targets {
+		if t.Ip6Address != "" {
+			hasIPv6 = true
+		}
+		if !t.Fingerprint.Empty() {
+			hasOS = true
+		}
+		if len(t.Tags) > 0 || t.Note != "" {
+			hasTags = true
+		}
 		if !t.Meta.Empty() {
 			hasMeta = true
-			break
 		}
 	}
 
 	padCols := []string{"", "", "", "", "", "", ""}
 	colNames := []string{"IP", "MAC", "Name", "Vendor", "Sent", "Recvd", "Last Seen"}
+	if hasIPv6 {
+		padCols = append(padCols, "")
+		colNames = append([]string{"IP", "IPv6"}, colNames[1:]...)
+	}
+	if hasOS {
+		// right after the vendor
+		at := len(colNames) - 3
+		padCols = append(padCols, "")
+		colNames = append(colNames[:at], append([]string{"OS"}, colNames[at:]...)...)
+	}
+	if hasTags {
+		padCols = append(padCols, "", "")
+		colNames = append(colNames, "Tags", "Note")
+	}
 	if hasMeta {
 		padCols = append(padCols, "")
 		colNames = append(colNames, "Meta")
@@ -135,7 +177,7 @@ func (d *Discovery) Show(by string) error {
 
 	rows := make([][]string, 0)
 	for i, t := range targets {
-		rows = append(rows, d.getRow(t, hasMeta))
+		rows = append(rows, d.getRow(t, hasIPv6, hasOS, hasTags, hasMeta))
 		if i == pad {
 			rows = append(rows, padCols)
 		}
@@ -157,3 +199,46 @@ func (d *Discovery) Show(by string) error {
 
 	return nil
 }
+
+func (d *Discovery) ShowInterfaces() error {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return err
+	}
+
+	colNames := []string{"Name", "MAC", "Addresses", "Flags", "Promisc"}
+	rows := make([][]string, 0)
+	for _, iface := range ifaces {
+		name := iface.Name
+		if name == d.Session.Interface.Name() {
+			name = core.Bold(name)
+		}
+
+		addrs := []string{}
+		if list, err := iface.Addrs(); err == nil {
+			for _, addr := range list {
+				addrs = append(addrs, addr.String())
+			}
+		}
+
+		promisc := core.Dim("?")
+		if on, err := network.GetInterfacePromisc(iface.Name); err == nil {
+			promisc = yn(on)
+		}
+
+		rows = append(rows, []string{
+			name,
+			iface.HardwareAddr.String(),
+			strings.Join(addrs, "\n"),
+			iface.Flags.String(),
+			promisc,
+		})
+	}
+
+	core.AsTable(os.Stdout, colNames, rows)
+	fmt.Println()
+
+	d.Session.Refresh()
+
+	return nil
+}
@@ -2,8 +2,6 @@ package modules
 
 import (
 	"github.com/bettercap/bettercap/network"
-	"github.com/bettercap/bettercap/packets"
-	"github.com/bettercap/bettercap/session"
 )
 
 type ByAddressSorter []*network.Endpoint
@@ -28,22 +26,9 @@ type BySentSorter []*network.Endpoint
 func (a BySentSorter) Len() int      { return len(a) }
 func (a BySentSorter) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
 func (a BySentSorter) Less(i, j int) bool {
-	session.I.Queue.Lock()
-	defer session.I.Queue.Unlock()
-
-	var found bool = false
-	var aTraffic *packets.Traffic = nil
-	var bTraffic *packets.Traffic = nil
-
-	if aTraffic, found = session.I.Queue.Traffic[a[i].IpAddress]; !found {
-		aTraffic = &packets.Traffic{}
-	}
-
-	if bTraffic, found = session.I.Queue.Traffic[a[j].IpAddress]; !found {
-		bTraffic = &packets.Traffic{}
-	}
-
-	return bTraffic.Sent < aTraffic.Sent
+	aSent, _ := a[i].Traffic.Bytes()
+	bSent, _ := a[j].Traffic.Bytes()
+	return bSent < aSent
 }
 
 type ByRcvdSorter []*network.Endpoint
@@ -51,20 +36,15 @@ type ByRcvdSorter []*network.Endpoint
 func (a ByRcvdSorter) Len() int      { return len(a) }
 func (a ByRcvdSorter) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
 func (a ByRcvdSorter) Less(i, j int) bool {
-	session.I.Queue.Lock()
-	defer session.I.Queue.Unlock()
-
-	var found bool = false
-	var aTraffic *packets.Traffic = nil
-	var bTraffic *packets.Traffic = nil
-
-	if aTraffic, found = session.I.Queue.Traffic[a[i].IpAddress]; !found {
-		aTraffic = &packets.Traffic{}
-	}
+	_, aRcvd := a[i].Traffic.Bytes()
+	_, bRcvd := a[j].Traffic.Bytes()
+	return bRcvd < aRcvd
+}
 
-	if bTraffic, found = session.I.Queue.Traffic[a[j].IpAddress]; !found {
-		bTraffic = &packets.Traffic{}
-	}
+type ByTrafficSorter []*network.Endpoint
 
-	return bTraffic.Received < aTraffic.Received
+func (a ByTrafficSorter) Len() int      { return len(a) }
+func (a ByTrafficSorter) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a ByTrafficSorter) Less(i, j int) bool {
+	return a[j].Traffic.Total() < a[i].Traffic.Total()
 }
@@ -0,0 +1,75 @@
+package modules
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/bettercap/bettercap/network"
+	"github.com/bettercap/bettercap/session"
+)
+
+func TestDiscoveryTrafficSorting(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	d := NewDiscovery(s.Session)
+	s.Register(d)
+
+	var hosts = []struct {
+		ip   string
+		mac  string
+		sent uint64
+		rcvd uint64
+	}{
+		{"192.168.1.10", "aa:00:00:00:00:10", 500, 100},
+		{"192.168.1.11", "aa:00:00:00:00:11", 10, 2000},
+		{"192.168.1.12", "aa:00:00:00:00:12", 900, 0},
+	}
+
+	for _, h := range hosts {
+		s.Lan.AddIfNew(h.ip, h.mac)
+		e, found := s.Lan.Get(h.mac)
+		if !found {
+			t.Fatalf("expected %s to be found", h.mac)
+		}
+		e.Traffic.Track(h.sent, true)
+		e.Traffic.Track(h.rcvd, false)
+	}
+
+	var units = []struct {
+		sorter func([]*network.Endpoint) sort.Interface
+		exp    []string
+	}{
+		{func(l []*network.Endpoint) sort.Interface { return BySentSorter(l) }, []string{"192.168.1.12", "192.168.1.10", "192.168.1.11"}},
+		{func(l []*network.Endpoint) sort.Interface { return ByRcvdSorter(l) }, []string{"192.168.1.11", "192.168.1.10", "192.168.1.12"}},
+		{func(l []*network.Endpoint) sort.Interface { return ByTrafficSorter(l) }, []string{"192.168.1.11", "192.168.1.12", "192.168.1.10"}},
+	}
+
+	for _, u := range units {
+		list := s.Lan.List()
+		sort.Sort(u.sorter(list))
+		for i, e := range list {
+			if e.IpAddress != u.exp[i] {
+				t.Fatalf("expected '%s' at %d, got '%s'", u.exp[i], i, e.IpAddress)
+			}
+		}
+	}
+
+	if err := s.Set("net.recon.sort", "bandwidth"); err == nil {
+		t.Fatalf("expected error for invalid sorting")
+	}
+
+	s.Gateway.Traffic.Track(42, true)
+	d.resetTraffic()
+	if total := s.Gateway.Traffic.Total(); total != 0 {
+		t.Fatalf("expected 0, got %d", total)
+	}
+	for _, e := range s.Lan.List() {
+		if total := e.Traffic.Total(); total != 0 {
+			t.Fatalf("expected 0 for %s, got %d", e.IpAddress, total)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package modules
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/bettercap/bettercap/network"
+)
+
+// tags can be separated by spaces or commas
+func parseTags(list string) []string {
+	return strings.FieldsFunc(list, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+}
+
+// hostMac returns the mac of the LAN host with the given ip or mac address.
+func (d *Discovery) hostMac(address string) (string, error) {
+	if hw, err := net.ParseMAC(network.NormalizeMac(address)); err == nil {
+		mac := hw.String()
+		if _, found := d.Session.Lan.Get(mac); found {
+			return mac, nil
+		}
+	} else if e := d.Session.Lan.GetByIp(address); e != nil {
+		return e.HwAddress, nil
+	}
+	return "", fmt.Errorf("Could not find endpoint %s", address)
+}
+
+func (d *Discovery) Tag(address, tags string) error {
+	if mac, err := d.hostMac(address); err != nil {
+		return err
+	} else if list := parseTags(tags); len(list) == 0 {
+		return fmt.Errorf("No tags to add to %s.", address)
+	} else {
+		d.Session.Lan.Tag(mac, list...)
+	}
+	return nil
+}
+
+func (d *Discovery) Untag(address, tags string) error {
+	if mac, err := d.hostMac(address); err != nil {
+		return err
+	} else {
+		d.Session.Lan.Untag(mac, parseTags(tags)...)
+	}
+	return nil
+}
+
+func (d *Discovery) Note(address, note string) error {
+	if mac, err := d.hostMac(address); err != nil {
+		return err
+	} else {
+		d.Session.Lan.SetNoteFor(mac, strings.TrimSpace(note))
+	}
+	return nil
+}
+
+// filterByTags returns the endpoints having at least one of the tags,
+// or all of them if there are no tags.
+func filterByTags(targets []*network.Endpoint, tags []string) []*network.Endpoint {
+	if len(tags) == 0 {
+		return targets
+	}
+
+	filtered := make([]*network.Endpoint, 0)
+	for _, t := range targets {
+		for _, tag := range tags {
+			if t.HasTag(tag) {
+				filtered = append(filtered, t)
+				break
+			}
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,62 @@
+package modules
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bettercap/bettercap/session"
+)
+
+func TestDiscoveryTags(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	d := NewDiscovery(s.Session)
+	s.Register(d)
+
+	s.Lan.AddIfNew("192.168.1.10", "de:ad:be:ef:00:01")
+	s.Lan.AddIfNew("192.168.1.20", "de:ad:be:ef:00:02")
+
+	if err := s.Run("net.recon.tag 192.168.1.10 DC, target"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Run("net.recon.tag DE-AD-BE-EF-00-02 printer"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Run("net.recon.untag 192.168.1.10 target"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Run("net.recon.note 192.168.1.10   main domain controller "); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Run("net.recon.tag 192.168.1.30 DC"); err == nil {
+		t.Fatal("expected error for an unknown host")
+	}
+
+	dc := s.Lan.GetByIp("192.168.1.10")
+	if exp := []string{"DC"}; !reflect.DeepEqual(dc.Tags, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, dc.Tags)
+	} else if dc.Note != "main domain controller" {
+		t.Fatalf("expected 'main domain controller', got '%s'", dc.Note)
+	}
+
+	targets := s.Lan.List()
+	var units = []struct {
+		tags []string
+		exp  int
+	}{
+		{nil, 2},
+		{[]string{"dc"}, 1},
+		{[]string{"DC", "printer"}, 2},
+		{[]string{"nope"}, 0},
+	}
+
+	for _, u := range units {
+		if got := filterByTags(targets, u.tags); len(got) != u.exp {
+			t.Fatalf("expected %d hosts for '%v', got '%v'", u.exp, u.tags, got)
+		}
+	}
+
+	if got := filterByTags(targets, []string{"printer"}); got[0] != s.Lan.GetByIp("192.168.1.20") {
+		t.Fatalf("expected printer host, got '%v'", got[0])
+	}
+}
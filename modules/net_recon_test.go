@@ -0,0 +1,47 @@
+package modules
+
+import (
+	"net"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/bettercap/bettercap/session"
+)
+
+func TestDiscoveryFlushCache(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("expected commands are Linux specific")
+	}
+
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	ifaces, err := net.Interfaces()
+	if err != nil || len(ifaces) == 0 {
+		t.Skip("no interfaces to flush")
+	}
+	iface := ifaces[0].Name
+
+	d := NewDiscovery(s.Session)
+	s.Register(d)
+
+	if err := s.Run("net.recon.flush-cache"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Run("net.recon.flush-cache " + iface); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Run("net.recon.flush-cache nosuchiface0"); err == nil {
+		t.Fatal("expected error for unknown interface")
+	}
+
+	exp := []string{
+		"ip neigh flush all",
+		"ip neigh flush dev " + iface,
+	}
+	if got := s.Executed(); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, got)
+	}
+}
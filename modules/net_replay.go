@@ -0,0 +1,238 @@
+package modules
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/session"
+
+	"github.com/google/gopacket/pcap"
+)
+
+type NetReplay struct {
+	session.SessionModule
+	file      string
+	rate      int
+	rewrite   *replayRewrite
+	total     int
+	quit      chan bool
+	waitGroup *sync.WaitGroup
+}
+
+func NewNetReplay(s *session.Session) *NetReplay {
+	p := &NetReplay{
+		SessionModule: session.NewSessionModule("net.replay", s),
+		waitGroup:     &sync.WaitGroup{},
+	}
+
+	p.AddParam(session.NewStringParameter("net.replay.file", "", "",
+		"PCAP or PCAPNG file to replay."))
+
+	p.AddParam(session.NewIntParameter("net.replay.rate", "0",
+		"Packets to send per second, 0 to replay them with their original timing."))
+
+	p.AddParam(session.NewBoolParameter("net.replay.rewrite.src",
+		"false",
+		"If true, the source MAC and IPv4 addresses of the packets are replaced with the ones of this computer."))
+
+	p.AddParam(session.NewStringParameter("net.replay.rewrite.dst", "", "",
+		"IPv4 address of the host to send the unicast packets to instead of their original destination, its MAC address replaces theirs."))
+
+	p.AddHandler(session.NewDangerousModuleHandler("net.replay on", "",
+		"Start injecting the packets of net.replay.file.",
+		func(args []string) error {
+			return p.Start()
+		}))
+
+	p.AddHandler(session.NewModuleHandler("net.replay off", "",
+		"Stop the replay.",
+		func(args []string) error {
+			return p.Stop()
+		}))
+
+	return p
+}
+
+func (p NetReplay) Name() string {
+	return "net.replay"
+}
+
+func (p NetReplay) Description() string {
+	return "Inject the packets of a capture file on the network, optionally rewriting their addresses for the current one."
+}
+
+func (p NetReplay) Author() string {
+	return "Simone Margaritelli <evilsocket@protonmail.com>"
+}
+
+// countReplayPackets returns how many packets the file has, to report
+// the progress of the replay.
+func countReplayPackets(file string) (int, error) {
+	handle, err := pcap.OpenOffline(file)
+	if err != nil {
+		return 0, err
+	}
+	defer handle.Close()
+
+	count := 0
+	for {
+		if _, _, err := handle.ReadPacketData(); err == io.EOF {
+			return count, nil
+		} else if err != nil {
+			return count, err
+		}
+		count++
+	}
+}
+
+func (p *NetReplay) setRewrite(src bool, dst string) error {
+	p.rewrite = &replayRewrite{}
+	if src {
+		p.rewrite.srcHW = p.Session.Interface.HW
+		p.rewrite.srcIP = p.Session.Interface.IP.To4()
+	}
+
+	if dst == "" {
+		return nil
+	} else if p.rewrite.dstIP = net.ParseIP(dst).To4(); p.rewrite.dstIP == nil {
+		return fmt.Errorf("'%s' is not a valid IPv4 address.", dst)
+	}
+
+	var err error
+	if p.rewrite.dstIP.Equal(p.Session.Gateway.IP) {
+		p.rewrite.dstHW = p.Session.Gateway.HW
+	} else if e := p.Session.Lan.GetByIp(dst); e != nil {
+		p.rewrite.dstHW = e.HW
+	} else if p.rewrite.dstHW, err = findMAC(p.Session, p.rewrite.dstIP, true); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (p *NetReplay) Configure() error {
+	var err error
+	var src bool
+	var dst string
+
+	if p.Running() {
+		return session.ErrAlreadyStarted
+	} else if err, p.file = p.StringParam("net.replay.file"); err != nil {
+		return err
+	} else if err, p.rate = p.IntParam("net.replay.rate"); err != nil {
+		return err
+	} else if err, src = p.BoolParam("net.replay.rewrite.src"); err != nil {
+		return err
+	} else if err, dst = p.StringParam("net.replay.rewrite.dst"); err != nil {
+		return err
+	} else if p.file == "" {
+		return fmt.Errorf("No file to replay, set %s first.", core.Bold("net.replay.file"))
+	} else if p.rate < 0 {
+		return fmt.Errorf("%s can't be negative.", core.Bold("net.replay.rate"))
+	} else if p.file, err = core.ExpandPath(p.file); err != nil {
+		return err
+	} else if p.total, err = countReplayPackets(p.file); err != nil {
+		return err
+	} else if err = p.setRewrite(src, dst); err != nil {
+		return err
+	}
+
+	return p.Session.UpdateInjection()
+}
+
+// wait sleeps for d unless the replay is stopped first.
+func (p *NetReplay) wait(d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+
+	select {
+	case <-time.After(d):
+		return true
+	case <-p.quit:
+		return false
+	}
+}
+
+func (p *NetReplay) replay() {
+	handle, err := pcap.OpenOffline(p.file)
+	if err != nil {
+		log.Error("Error opening %s: %s", p.file, err)
+		return
+	}
+	defer handle.Close()
+
+	label := "Replaying " + filepath.Base(p.file)
+	sent, errors := 0, 0
+	var last time.Time
+
+	p.StartProgress(label, p.total)
+	for p.Running() {
+		data, info, err := handle.ReadPacketData()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			log.Error("Error reading %s: %s", p.file, err)
+			break
+		}
+
+		var delay time.Duration
+		if p.rate > 0 {
+			delay = time.Second / time.Duration(p.rate)
+		} else if !last.IsZero() {
+			delay = info.Timestamp.Sub(last)
+		}
+		last = info.Timestamp
+
+		if (sent > 0 || errors > 0) && !p.wait(delay) {
+			break
+		}
+
+		if raw, err := p.rewrite.rewrite(data); err != nil {
+			log.Debug("Error rewriting packet %d: %s", sent+errors+1, err)
+			errors++
+		} else if err := p.Session.Inject(raw); err != nil {
+			log.Debug("Error sending packet %d: %s", sent+errors+1, err)
+			errors++
+		} else {
+			sent++
+		}
+
+		p.Progress(label, sent+errors, p.total)
+	}
+
+	log.Info("Replayed %d of %d packets of %s (%d errors).", sent, p.total, p.file, errors)
+}
+
+func (p *NetReplay) Start() error {
+	if err := p.Session.RequirePrivileges(p.Name(), session.CapNetRaw); err != nil {
+		return err
+	} else if err := p.Session.CheckSafe(p.Name(), p.Session.Interface.Name()); err != nil {
+		return err
+	} else if err := p.Configure(); err != nil {
+		return err
+	}
+
+	p.quit = make(chan bool)
+	return p.SetRunning(true, func() {
+		defer p.SetRunning(false, nil)
+
+		p.waitGroup.Add(1)
+		defer p.waitGroup.Done()
+
+		log.Info("Replaying %d packets of %s ...", p.total, p.file)
+		p.replay()
+	})
+}
+
+func (p *NetReplay) Stop() error {
+	return p.SetRunning(false, func() {
+		close(p.quit)
+		p.waitGroup.Wait()
+	})
+}
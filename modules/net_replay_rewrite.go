@@ -0,0 +1,102 @@
+package modules
+
+import (
+	"net"
+
+	"github.com/bettercap/bettercap/packets"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// replayRewrite are the addresses the replayed packets are rewritten
+// with, the nil ones are kept.
+type replayRewrite struct {
+	srcHW net.HardwareAddr
+	srcIP net.IP
+	dstHW net.HardwareAddr
+	dstIP net.IP
+}
+
+func (rw *replayRewrite) empty() bool {
+	return rw.srcHW == nil && rw.srcIP == nil && rw.dstHW == nil && rw.dstIP == nil
+}
+
+func isUnicastHW(hw net.HardwareAddr) bool {
+	return len(hw) > 0 && hw[0]&1 == 0
+}
+
+func isUnicastIP(ip net.IP) bool {
+	return !ip.Equal(net.IPv4bcast) && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+func isZeroHW(hw []byte) bool {
+	for _, b := range hw {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// rewrite returns the packet with its ethernet, ARP and IPv4 addresses
+// rewritten and its checksums fixed, the destination ones of broadcast
+// and multicast packets are kept, and the packet itself if it is not an
+// ethernet one.
+func (rw *replayRewrite) rewrite(raw []byte) ([]byte, error) {
+	pkt := gopacket.NewPacket(raw, layers.LayerTypeEthernet, gopacket.NoCopy)
+	eth, ok := pkt.Layer(layers.LayerTypeEthernet).(*layers.Ethernet)
+	if !ok || rw.empty() {
+		return raw, nil
+	}
+
+	if rw.srcHW != nil {
+		eth.SrcMAC = rw.srcHW
+	}
+	if rw.dstHW != nil && isUnicastHW(eth.DstMAC) {
+		eth.DstMAC = rw.dstHW
+	}
+
+	if arp, ok := pkt.Layer(layers.LayerTypeARP).(*layers.ARP); ok {
+		if rw.srcHW != nil {
+			arp.SourceHwAddress = rw.srcHW
+		}
+		if rw.srcIP != nil {
+			arp.SourceProtAddress = rw.srcIP.To4()
+		}
+		if rw.dstHW != nil && !isZeroHW(arp.DstHwAddress) && isUnicastHW(arp.DstHwAddress) {
+			arp.DstHwAddress = rw.dstHW
+		}
+		if rw.dstIP != nil {
+			arp.DstProtAddress = rw.dstIP.To4()
+		}
+		return serializeReplay(eth, arp)
+	}
+
+	ip4, ok := pkt.Layer(layers.LayerTypeIPv4).(*layers.IPv4)
+	if !ok {
+		return serializeReplay(eth, gopacket.Payload(eth.LayerPayload()))
+	}
+
+	if rw.srcIP != nil {
+		ip4.SrcIP = rw.srcIP.To4()
+	}
+	if rw.dstIP != nil && isUnicastIP(ip4.DstIP) {
+		ip4.DstIP = rw.dstIP.To4()
+	}
+
+	// the transport checksums cover the addresses
+	if tcp, ok := pkt.Layer(layers.LayerTypeTCP).(*layers.TCP); ok {
+		tcp.SetNetworkLayerForChecksum(ip4)
+		return serializeReplay(eth, ip4, tcp, gopacket.Payload(tcp.LayerPayload()))
+	} else if udp, ok := pkt.Layer(layers.LayerTypeUDP).(*layers.UDP); ok {
+		udp.SetNetworkLayerForChecksum(ip4)
+		return serializeReplay(eth, ip4, udp, gopacket.Payload(udp.LayerPayload()))
+	}
+	return serializeReplay(eth, ip4, gopacket.Payload(ip4.LayerPayload()))
+}
+
+func serializeReplay(stack ...gopacket.SerializableLayer) ([]byte, error) {
+	err, raw := packets.Serialize(stack...)
+	return raw, err
+}
@@ -0,0 +1,72 @@
+package modules
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/bettercap/bettercap/packets"
+
+	"github.com/google/gopacket/layers"
+)
+
+func newReplayUDP(from net.IP, fromHW net.HardwareAddr, to net.IP, toHW net.HardwareAddr) []byte {
+	eth := layers.Ethernet{
+		SrcMAC:       fromHW,
+		DstMAC:       toHW,
+		EthernetType: layers.EthernetTypeIPv4,
+	}
+	ip4 := layers.IPv4{
+		Version:  4,
+		TTL:      64,
+		Protocol: layers.IPProtocolUDP,
+		SrcIP:    from.To4(),
+		DstIP:    to.To4(),
+	}
+	udp := layers.UDP{
+		SrcPort: 5353,
+		DstPort: 53,
+	}
+	udp.Payload = []byte("replayed")
+	udp.SetNetworkLayerForChecksum(&ip4)
+
+	_, raw := packets.Serialize(&eth, &ip4, &udp)
+	return raw
+}
+
+func TestReplayRewrite(t *testing.T) {
+	oldHW, _ := net.ParseMAC("00:00:00:00:00:01")
+	oldDstHW, _ := net.ParseMAC("00:00:00:00:00:02")
+	ourHW, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	dstHW, _ := net.ParseMAC("66:77:88:99:aa:bb")
+	oldIP, oldDst := net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")
+	ourIP, dstIP := net.ParseIP("192.168.1.2"), net.ParseIP("192.168.1.10")
+
+	rw := &replayRewrite{srcHW: ourHW, srcIP: ourIP, dstHW: dstHW, dstIP: dstIP}
+
+	// checksums are updated with the addresses
+	got, err := rw.rewrite(newReplayUDP(oldIP, oldHW, oldDst, oldDstHW))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if expected := newReplayUDP(ourIP, ourHW, dstIP, dstHW); !bytes.Equal(got, expected) {
+		t.Fatalf("expected '%x', got '%x'", expected, got)
+	}
+
+	// broadcast destinations are kept
+	got, _ = rw.rewrite(newReplayUDP(oldIP, oldHW, net.IPv4bcast, layers.EthernetBroadcast))
+	if expected := newReplayUDP(ourIP, ourHW, net.IPv4bcast, layers.EthernetBroadcast); !bytes.Equal(got, expected) {
+		t.Fatalf("expected '%x', got '%x'", expected, got)
+	}
+
+	_, reply := packets.NewARPReply(oldIP, oldHW, oldDst, oldDstHW)
+	got, _ = rw.rewrite(reply)
+	if _, expected := packets.NewARPReply(ourIP, ourHW, dstIP, dstHW); !bytes.Equal(got, expected) {
+		t.Fatalf("expected '%x', got '%x'", expected, got)
+	}
+
+	// without addresses to rewrite packets are sent as they are
+	raw := newReplayUDP(oldIP, oldHW, oldDst, oldDstHW)
+	if got, _ := (&replayRewrite{}).rewrite(raw); !bytes.Equal(got, raw) {
+		t.Fatalf("expected '%x', got '%x'", raw, got)
+	}
+}
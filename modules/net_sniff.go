@@ -2,8 +2,11 @@ package modules
 
 import (
 	"fmt"
+	"os"
 	"time"
 
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
 	"github.com/bettercap/bettercap/session"
 
 	"github.com/google/gopacket"
@@ -31,6 +34,15 @@ func NewSniffer(s *session.Session) *Sniffer {
 		"false",
 		"If true it will consider packets from/to this computer, otherwise it will skip them."))
 
+	sniff.AddParam(session.NewBoolParameter("net.sniff.creds.only",
+		"false",
+		"If true, only the net.sniff.creds events with the credentials found by the parsers will be sent to the events.stream."))
+
+	sniff.AddParam(session.NewStringParameter("net.sniff.parsers",
+		"*",
+		"",
+		"Comma separated list of the application layer parsers to run, or * for all of them, see net.sniff.parsers.list for the available ones."))
+
 	sniff.AddParam(session.NewStringParameter("net.sniff.filter",
 		"not arp",
 		"",
@@ -44,13 +56,30 @@ func NewSniffer(s *session.Session) *Sniffer {
 	sniff.AddParam(session.NewStringParameter("net.sniff.output",
 		"",
 		"",
-		"If set, the sniffer will write captured packets to this file."))
+		"If set, the sniffer will write captured packets to this file, as pcapng with the interface details, the mac.changed events and the summary of the parsed protocols of each packet if its extension is .pcapng."))
+
+	sniff.AddParam(session.NewDurationParameter("net.sniff.output.rotate",
+		"0s",
+		"If greater than 0, net.sniff.output is rotated after this amount of time, the next files have a counter before the extension."))
+
+	sniff.AddParam(session.NewStringParameter("net.sniff.output.max_size",
+		"0",
+		"",
+		"If not 0, net.sniff.output is rotated once it grows over this size, for instance 100MB."))
 
 	sniff.AddParam(session.NewStringParameter("net.sniff.source",
 		"",
 		"",
 		"If set, the sniffer will read from this pcap file instead of the current interface."))
 
+	sniff.AddParam(session.NewIntParameter("net.sniff.snaplen",
+		"65536",
+		"Maximum number of bytes captured for each packet."))
+
+	sniff.AddParam(session.NewIntParameter("net.sniff.buffer",
+		"0",
+		"Size in bytes of the kernel capture buffer, increase it if the stats report dropped packets (0 for the system default)."))
+
 	sniff.AddHandler(session.NewModuleHandler("net.sniff stats", "",
 		"Print sniffer session configuration and statistics.",
 		func(args []string) error {
@@ -59,10 +88,19 @@ func NewSniffer(s *session.Session) *Sniffer {
 			}
 
 			sniff.Ctx.Log(sniff.Session)
+			if sniff.Ctx.Handle != nil {
+				sniff.Stats.UpdateKernel(sniff.Ctx.Handle)
+			}
 
 			return sniff.Stats.Print()
 		}))
 
+	sniff.AddHandler(session.NewModuleHandler("net.sniff.parsers.list", "",
+		"List the available application layer parsers and whether net.sniff.parsers enables them.",
+		func(args []string) error {
+			return sniff.showParsers()
+		}))
+
 	sniff.AddHandler(session.NewModuleHandler("net.sniff on", "",
 		"Start network sniffer in background.",
 		func(args []string) error {
@@ -75,6 +113,18 @@ func NewSniffer(s *session.Session) *Sniffer {
 			return sniff.Stop()
 		}))
 
+	sniff.AddHandler(session.NewModuleHandler("net.sniff.pause", "",
+		"Stop processing packets while keeping the capture open.",
+		func(args []string) error {
+			return sniff.Pause()
+		}))
+
+	sniff.AddHandler(session.NewModuleHandler("net.sniff.resume", "",
+		"Resume processing packets after net.sniff.pause.",
+		func(args []string) error {
+			return sniff.Resume()
+		}))
+
 	return sniff
 }
 
@@ -101,8 +151,37 @@ func (s Sniffer) isLocalPacket(packet gopacket.Packet) bool {
 	return false
 }
 
+func (s *Sniffer) showParsers() error {
+	err, value := s.StringParam("net.sniff.parsers")
+	if err != nil {
+		return err
+	}
+
+	enabled, err := parseSnifferParsers(value)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(snifferParsers))
+	for _, p := range snifferParsers {
+		on := false
+		for _, e := range enabled {
+			if e == p {
+				on = true
+				break
+			}
+		}
+		rows = append(rows, []string{p.Name, p.Protocol(), yn(on), p.Description})
+	}
+
+	fmt.Println()
+	core.AsTable(os.Stdout, []string{"Name", "Protocol", "Enabled", "Description"}, rows)
+	fmt.Println()
+	return nil
+}
+
 func (s *Sniffer) onPacketMatched(pkt gopacket.Packet) {
-	if mainParser(pkt, s.Ctx.Verbose) {
+	if mainParser(pkt, s.Ctx.Verbose, s.Ctx.Parsers) {
 		s.Stats.NumDumped++
 	}
 }
@@ -120,6 +199,8 @@ func (s *Sniffer) Configure() error {
 		return err
 	}
 
+	sniffCredsOnly = s.Ctx.CredsOnly
+
 	return nil
 }
 
@@ -136,6 +217,9 @@ func (s *Sniffer) Start() error {
 		for packet := range s.pktSourceChan {
 			if !s.Running() {
 				break
+			} else if s.Paused() {
+				// drained so that the kernel buffer doesn't fill up
+				continue
 			}
 
 			now := time.Now()
@@ -154,10 +238,15 @@ func (s *Sniffer) Start() error {
 				if s.Ctx.Compiled == nil || s.Ctx.Compiled.Match(data) {
 					s.Stats.NumMatched++
 
+					if s.Ctx.OutputWriter != nil && s.Ctx.OutputWriter.Comments() {
+						sniffSummaries.Start()
+					}
+
 					s.onPacketMatched(packet)
 
 					if s.Ctx.OutputWriter != nil {
-						s.Ctx.OutputWriter.WritePacket(packet.Metadata().CaptureInfo, data)
+						// commented with the parsed protocols if pcapng
+						s.Ctx.OutputWriter.WritePacketComment(packet.Metadata().CaptureInfo, data, sniffSummaries.Stop())
 						s.Stats.NumWrote++
 					}
 				}
@@ -168,11 +257,41 @@ func (s *Sniffer) Start() error {
 	})
 }
 
+// Pause stops processing packets, the capture handle stays open.
+func (s *Sniffer) Pause() error {
+	if err := s.SetPaused(true); err != nil {
+		return err
+	}
+	log.Info("Sniffer paused.")
+	return nil
+}
+
+func (s *Sniffer) Resume() error {
+	if err := s.SetPaused(false); err != nil {
+		return err
+	}
+	log.Info("Sniffer resumed.")
+	return nil
+}
+
 func (s *Sniffer) Stop() error {
 	return s.SetRunning(false, func() {
 		if s.pktSourceChan != nil {
 			s.pktSourceChan <- nil
 		}
+		// keep the last kernel counters for net.sniff stats
+		if s.Stats != nil && s.Ctx.Handle != nil {
+			s.Stats.UpdateKernel(s.Ctx.Handle)
+		}
 		s.Ctx.Close()
 	})
 }
+
+// Revert restores the promiscuous mode of the interface if the sniffer
+// is running.
+func (s *Sniffer) Revert() (bool, error) {
+	if !s.Running() {
+		return false, nil
+	}
+	return true, s.Stop()
+}
@@ -1,13 +1,18 @@
 package modules
 
 import (
-	"os"
+	"fmt"
+	"io"
+	"path/filepath"
 	"regexp"
+	"strings"
 
 	"github.com/bettercap/bettercap/core"
 	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/network"
 	"github.com/bettercap/bettercap/session"
 
+	"github.com/dustin/go-humanize"
 	"github.com/google/gopacket/pcap"
 	"github.com/google/gopacket/pcapgo"
 )
@@ -15,14 +20,21 @@ import (
 type SnifferContext struct {
 	Handle       *pcap.Handle
 	Source       string
+	Interface    string
+	SnapLen      int
+	BufferSize   int
+	WasPromisc   bool
 	DumpLocal    bool
 	Verbose      bool
+	CredsOnly    bool
+	Parsers      []*snifferParser
 	Filter       string
 	Expression   string
 	Compiled     *regexp.Regexp
 	Output       string
-	OutputFile   *os.File
-	OutputWriter *pcapgo.Writer
+	OutputWriter *snifferOutput
+	events       *session.EventPool
+	listener     <-chan session.Event
 }
 
 func (s *Sniffer) GetContext() (error, *SnifferContext) {
@@ -32,10 +44,36 @@ func (s *Sniffer) GetContext() (error, *SnifferContext) {
 
 	if err, ctx.Source = s.StringParam("net.sniff.source"); err != nil {
 		return err, ctx
+	} else if err, ctx.SnapLen = s.IntParam("net.sniff.snaplen"); err != nil {
+		return err, ctx
+	} else if ctx.SnapLen <= 0 {
+		return fmt.Errorf("net.sniff.snaplen must be greater than 0."), ctx
+	} else if err, ctx.BufferSize = s.IntParam("net.sniff.buffer"); err != nil {
+		return err, ctx
+	} else if ctx.BufferSize < 0 {
+		return fmt.Errorf("net.sniff.buffer can't be negative."), ctx
+	}
+
+	if err, parsers := s.StringParam("net.sniff.parsers"); err != nil {
+		return err, ctx
+	} else if ctx.Parsers, err = parseSnifferParsers(parsers); err != nil {
+		return err, ctx
 	}
 
 	if ctx.Source == "" {
-		if ctx.Handle, err = pcap.OpenLive(s.Session.Interface.Name(), 65536, true, pcap.BlockForever); err != nil {
+		if err = s.Session.RequirePrivileges(s.Name(), session.CapNetRaw); err != nil {
+			return err, ctx
+		}
+
+		// save the promiscuous mode flag in order to restore it
+		// once done, since not every driver restores it properly
+		ctx.Interface = s.Session.Interface.Name()
+		if ctx.WasPromisc, err = network.GetInterfacePromisc(ctx.Interface); err != nil {
+			log.Debug("Could not read promiscuous mode of %s: %s", ctx.Interface, err)
+			ctx.Interface = ""
+		}
+
+		if ctx.Handle, err = openSnifferHandle(s.Session.Interface.Name(), ctx.SnapLen, ctx.BufferSize); err != nil {
 			return err, ctx
 		}
 	} else {
@@ -48,6 +86,10 @@ func (s *Sniffer) GetContext() (error, *SnifferContext) {
 		return err, ctx
 	}
 
+	if err, ctx.CredsOnly = s.BoolParam("net.sniff.creds.only"); err != nil {
+		return err, ctx
+	}
+
 	if err, ctx.DumpLocal = s.BoolParam("net.sniff.local"); err != nil {
 		return err, ctx
 	}
@@ -72,46 +114,153 @@ func (s *Sniffer) GetContext() (error, *SnifferContext) {
 	if err, ctx.Output = s.StringParam("net.sniff.output"); err != nil {
 		return err, ctx
 	} else if ctx.Output != "" {
-		if ctx.OutputFile, err = os.Create(ctx.Output); err != nil {
+		if err = ctx.openOutput(s); err != nil {
 			return err, ctx
 		}
-
-		ctx.OutputWriter = pcapgo.NewWriter(ctx.OutputFile)
-		ctx.OutputWriter.WriteFileHeader(65536, ctx.Handle.LinkType())
 	}
 
 	return nil, ctx
 }
 
+// openOutput writes pcapng if the extension of the output is .pcapng or
+// classic pcap otherwise, rotating it if configured to.
+func (c *SnifferContext) openOutput(s *Sniffer) error {
+	err, rotate := s.DurationParam("net.sniff.output.rotate")
+	if err != nil {
+		return err
+	} else if rotate < 0 {
+		return fmt.Errorf("net.sniff.output.rotate can't be negative.")
+	}
+
+	err, size := s.StringParam("net.sniff.output.max_size")
+	if err != nil {
+		return err
+	}
+	maxSize, err := humanize.ParseBytes(size)
+	if err != nil {
+		return fmt.Errorf("'%s' is not a valid net.sniff.output.max_size: %s", size, err)
+	}
+
+	open := func(w io.Writer) (packetWriter, error) {
+		writer := pcapgo.NewWriter(w)
+		if err := writer.WriteFileHeader(uint32(c.SnapLen), c.Handle.LinkType()); err != nil {
+			return nil, err
+		}
+		return writer, nil
+	}
+
+	pcapng := strings.ToLower(filepath.Ext(c.Output)) == ".pcapng"
+	if pcapng {
+		open = c.pcapngOpener(s.Session)
+	}
+
+	if c.OutputWriter, err = newSnifferOutput(c.Output, maxSize, rotate, open); err != nil {
+		return err
+	}
+
+	if pcapng {
+		c.events = s.Session.Events
+		c.listener = s.Session.Events.Listen()
+		go trackMacChanges(c.OutputWriter, c.listener)
+	}
+	return nil
+}
+
+// pcapngOpener describes the capture interface with its address at the
+// start of every file, the mac.changed events are commented as well.
+func (c *SnifferContext) pcapngOpener(sess *session.Session) func(w io.Writer) (packetWriter, error) {
+	return func(w io.Writer) (packetWriter, error) {
+		var mac []byte
+		iface, description := "", ""
+		if c.Source != "" {
+			description = "read from " + c.Source
+		} else {
+			iface = sess.Interface.Name()
+			mac = sess.Interface.HW
+		}
+		return newPcapngWriter(w, uint32(c.SnapLen), c.Handle.LinkType(), iface, mac, description)
+	}
+}
+
+// the buffer size can only be set before the handle is activated
+func openSnifferHandle(iface string, snapLen int, bufferSize int) (*pcap.Handle, error) {
+	ihandle, err := pcap.NewInactiveHandle(iface)
+	if err != nil {
+		return nil, err
+	}
+	defer ihandle.CleanUp()
+
+	if err = ihandle.SetSnapLen(snapLen); err != nil {
+		return nil, err
+	} else if err = ihandle.SetPromisc(true); err != nil {
+		return nil, err
+	} else if err = ihandle.SetTimeout(pcap.BlockForever); err != nil {
+		return nil, err
+	} else if bufferSize > 0 {
+		if err = ihandle.SetBufferSize(bufferSize); err != nil {
+			return nil, err
+		}
+	}
+
+	return ihandle.Activate()
+}
+
 func NewSnifferContext() *SnifferContext {
 	return &SnifferContext{
 		Handle:       nil,
+		Interface:    "",
+		SnapLen:      65536,
+		BufferSize:   0,
+		WasPromisc:   false,
 		DumpLocal:    false,
 		Verbose:      true,
+		CredsOnly:    false,
+		Parsers:      newSnifferParsers(snifferParsers),
 		Filter:       "",
 		Expression:   "",
 		Compiled:     nil,
 		Output:       "",
-		OutputFile:   nil,
 		OutputWriter: nil,
 	}
 }
 
-var (
-	no  = core.Red("no")
-	yes = core.Green("yes")
-	yn  = map[bool]string{
-		true:  yes,
-		false: no,
+// built on every call so that they follow the current theme
+func yes() string { return core.Green("yes") }
+func no() string  { return core.Red("no") }
+
+func yn(b bool) string {
+	if b {
+		return yes()
 	}
-)
+	return no()
+}
 
 func (c *SnifferContext) Log(sess *session.Session) {
-	log.Info("Skip local packets : %s", yn[c.DumpLocal])
-	log.Info("Verbose            : %s", yn[c.Verbose])
+	log.Info("Skip local packets : %s", yn(c.DumpLocal))
+	log.Info("Verbose            : %s", yn(c.Verbose))
+	log.Info("Credentials only   : %s", yn(c.CredsOnly))
+	log.Info("Parsers            : %s", core.Yellow(strings.Join(c.parserNames(), ", ")))
 	log.Info("BPF Filter         : '%s'", core.Yellow(c.Filter))
 	log.Info("Regular expression : '%s'", core.Yellow(c.Expression))
-	log.Info("File output        : '%s'", core.Yellow(c.Output))
+	if c.OutputWriter != nil && c.OutputWriter.Files() > 1 {
+		log.Info("File output        : '%s' (rotated %d times)", core.Yellow(c.Output), c.OutputWriter.Files()-1)
+	} else {
+		log.Info("File output        : '%s'", core.Yellow(c.Output))
+	}
+	log.Info("Snapshot length    : %d", c.SnapLen)
+	if c.BufferSize > 0 {
+		log.Info("Buffer size        : %d", c.BufferSize)
+	} else {
+		log.Info("Buffer size        : system default")
+	}
+}
+
+func (c *SnifferContext) parserNames() []string {
+	names := make([]string, len(c.Parsers))
+	for i, p := range c.Parsers {
+		names[i] = p.Name
+	}
+	return names
 }
 
 func (c *SnifferContext) Close() {
@@ -120,8 +269,23 @@ func (c *SnifferContext) Close() {
 		c.Handle = nil
 	}
 
-	if c.OutputFile != nil {
-		c.OutputFile.Close()
-		c.OutputFile = nil
+	if c.Interface != "" {
+		if promisc, err := network.GetInterfacePromisc(c.Interface); err == nil && promisc != c.WasPromisc {
+			log.Debug("Restoring promiscuous mode of %s to %v", c.Interface, c.WasPromisc)
+			if err := network.SetInterfacePromisc(c.Interface, c.WasPromisc); err != nil {
+				log.Warning("Could not restore promiscuous mode of %s: %s", c.Interface, err)
+			}
+		}
+		c.Interface = ""
+	}
+
+	if c.listener != nil {
+		c.events.Unlisten(c.listener)
+		c.listener = nil
+	}
+
+	if c.OutputWriter != nil {
+		c.OutputWriter.Close()
+		c.OutputWriter = nil
 	}
 }
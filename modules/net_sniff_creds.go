@@ -0,0 +1,35 @@
+package modules
+
+import (
+	"time"
+
+	"github.com/bettercap/bettercap/session"
+)
+
+// if true, only credentials events will be pushed by the sniffer
+var sniffCredsOnly = false
+
+type SnifferCredentials struct {
+	PacketTime  time.Time
+	Protocol    string
+	Source      string
+	Destination string
+	Username    string
+	Password    string
+}
+
+func NewSnifferCredentials(t time.Time, proto string, src string, dst string, username string, password string) SnifferCredentials {
+	return SnifferCredentials{
+		PacketTime:  t,
+		Protocol:    proto,
+		Source:      src,
+		Destination: dst,
+		Username:    username,
+		Password:    password,
+	}
+}
+
+func (c SnifferCredentials) Push() {
+	session.I.Events.Add("net.sniff.creds", c)
+	session.I.Refresh()
+}
@@ -30,6 +30,10 @@ func NewSnifferEvent(t time.Time, proto string, src string, dst string, data int
 }
 
 func (e SnifferEvent) Push() {
+	sniffSummaries.Add(e.Message)
+	if sniffCredsOnly {
+		return
+	}
 	session.I.Events.Add("net.sniff.leak."+e.Protocol, e)
 	session.I.Refresh()
 }
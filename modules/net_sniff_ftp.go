@@ -0,0 +1,69 @@
+package modules
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bettercap/bettercap/core"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// usernames sent with USER and waiting for the PASS
+// command, indexed by connection
+var (
+	ftpUsers     = make(map[string]string)
+	ftpUsersLock = &sync.Mutex{}
+)
+
+func ftpParser(ip *layers.IPv4, pkt gopacket.Packet, tcp *layers.TCP) bool {
+	if tcp.DstPort != 21 {
+		return false
+	}
+
+	src := fmt.Sprintf("%s:%d", ip.SrcIP, tcp.SrcPort)
+	dst := fmt.Sprintf("%s:%d", ip.DstIP, tcp.DstPort)
+	conn := src + ">" + dst
+	ok := false
+
+	for _, line := range strings.Split(string(tcp.Payload), "\r\n") {
+		parts := strings.SplitN(core.Trim(line), " ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		cmd := strings.ToUpper(parts[0])
+		arg := core.Trim(parts[1])
+		if cmd == "USER" {
+			ok = true
+			ftpUsersLock.Lock()
+			ftpUsers[conn] = arg
+			ftpUsersLock.Unlock()
+
+			NewSnifferEvent(
+				pkt.Metadata().Timestamp,
+				"ftp",
+				src,
+				dst,
+				nil,
+				"%s %s > %s | USER %s",
+				core.W(core.BG_RED+core.FG_BLACK, "ftp"),
+				vIP(ip.SrcIP),
+				vIP(ip.DstIP),
+				core.Yellow(arg),
+			).Push()
+		} else if cmd == "PASS" {
+			ok = true
+			ftpUsersLock.Lock()
+			user := ftpUsers[conn]
+			delete(ftpUsers, conn)
+			ftpUsersLock.Unlock()
+
+			NewSnifferCredentials(pkt.Metadata().Timestamp, "ftp", src, dst, user, arg).Push()
+		}
+	}
+
+	return ok
+}
@@ -3,6 +3,7 @@ package modules
 import (
 	"bufio"
 	"bytes"
+	"fmt"
 	"net/http"
 
 	"github.com/bettercap/bettercap/core"
@@ -17,6 +18,17 @@ func httpParser(ip *layers.IPv4, pkt gopacket.Packet, tcp *layers.TCP) bool {
 	req, err := http.ReadRequest(reader)
 
 	if err == nil {
+		if user, pass, ok := req.BasicAuth(); ok {
+			NewSnifferCredentials(
+				pkt.Metadata().Timestamp,
+				"http.basic",
+				fmt.Sprintf("%s:%d", ip.SrcIP, tcp.SrcPort),
+				fmt.Sprintf("%s:%d", ip.DstIP, tcp.DstPort),
+				user,
+				pass,
+			).Push()
+		}
+
 		NewSnifferEvent(
 			pkt.Metadata().Timestamp,
 			"http",
@@ -0,0 +1,94 @@
+package modules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/network"
+	"github.com/bettercap/bettercap/packets"
+	"github.com/bettercap/bettercap/session"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// tlsFingerprintHost returns the endpoint with the address, nil if it is
+// not in the LAN.
+func tlsFingerprintHost(s *session.Session, address string) *network.Endpoint {
+	if address == s.Interface.IpAddress {
+		return s.Interface
+	} else if address == s.Gateway.IpAddress {
+		return s.Gateway
+	}
+	return s.Lan.GetByIp(address)
+}
+
+// addTLSFingerprint adds the hash to the comma separated list of the
+// fingerprints of the host, it returns true if the host wasn't using it
+// yet.
+func addTLSFingerprint(host *network.Endpoint, name string, hash string) bool {
+	hashes := make([]string, 0)
+	if list, _ := host.Meta.Get(name).(string); list != "" {
+		hashes = strings.Split(list, ",")
+	}
+	for _, h := range hashes {
+		if h == hash {
+			return false
+		}
+	}
+
+	host.Meta.Set(name, strings.Join(append(hashes, hash), ","))
+	return true
+}
+
+// ja3Parser fingerprints the TLS client and server hellos, the client ones
+// are never claimed so that the sni parser still reports them.
+func ja3Parser(ip *layers.IPv4, pkt gopacket.Packet, tcp *layers.TCP) bool {
+	data := tcp.Payload
+	if len(data) < 2 || data[0] != packets.TLSHandshakeRecord || data[1] != 0x03 {
+		return false
+	}
+
+	hello, err := packets.ParseTLSHello(data)
+	if err != nil {
+		return false
+	}
+
+	proto, what := "ja3", "client"
+	if hello.Type == packets.TLSServerHelloType {
+		proto, what = "ja3s", "server"
+	}
+
+	hash := hello.JA3()
+	fingerprint := core.Dim(hash)
+	// the hello is always sent by the endpoint it fingerprints
+	if host := tlsFingerprintHost(session.I, ip.SrcIP.String()); host != nil && addTLSFingerprint(host, proto, hash) {
+		fingerprint = core.Bold(hash) + " " + core.Yellow("new")
+	}
+
+	target := vIP(ip.DstIP)
+	if hello.ServerName != "" {
+		target = core.Yellow(hello.ServerName)
+	}
+
+	NewSnifferEvent(
+		pkt.Metadata().Timestamp,
+		proto,
+		fmt.Sprintf("%s:%d", ip.SrcIP, tcp.SrcPort),
+		fmt.Sprintf("%s:%d", ip.DstIP, tcp.DstPort),
+		SniffData{
+			"JA3":        hello.JA3String(),
+			"Hash":       hash,
+			"ServerName": hello.ServerName,
+		},
+		"%s %s %s > %s %s",
+		core.W(core.BG_DGRAY+core.FG_WHITE, proto),
+		what,
+		vIP(ip.SrcIP),
+		target,
+		fingerprint,
+	).Push()
+
+	return hello.Type == packets.TLSServerHelloType
+}
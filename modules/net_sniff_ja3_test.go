@@ -0,0 +1,48 @@
+package modules
+
+import (
+	"testing"
+
+	"github.com/bettercap/bettercap/session"
+)
+
+func TestSnifferTLSFingerprints(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	s.Lan.AddIfNew("192.168.1.10", "aa:00:00:00:00:10")
+
+	if host := tlsFingerprintHost(s.Session, "10.0.0.1"); host != nil {
+		t.Fatalf("expected no host, got %s", host)
+	} else if host := tlsFingerprintHost(s.Session, s.Gateway.IpAddress); host != s.Gateway {
+		t.Fatalf("expected the gateway, got %v", host)
+	}
+
+	host := tlsFingerprintHost(s.Session, "192.168.1.10")
+	if host == nil {
+		t.Fatal("expected the host to be found")
+	}
+
+	var units = []struct {
+		name string
+		hash string
+		new  bool
+		exp  string
+	}{
+		{"ja3", "d53fc7724d7df23b15a2b621393f85bb", true, "d53fc7724d7df23b15a2b621393f85bb"},
+		{"ja3", "d53fc7724d7df23b15a2b621393f85bb", false, "d53fc7724d7df23b15a2b621393f85bb"},
+		{"ja3", "e7d705a3286e19ea42f587b344ee6865", true, "d53fc7724d7df23b15a2b621393f85bb,e7d705a3286e19ea42f587b344ee6865"},
+		{"ja3s", "f9a66afdd1f499d415ca470974ec00c8", true, "f9a66afdd1f499d415ca470974ec00c8"},
+	}
+
+	for _, u := range units {
+		if got := addTLSFingerprint(host, u.name, u.hash); got != u.new {
+			t.Fatalf("expected %v for %s %s, got %v", u.new, u.name, u.hash, got)
+		} else if got := host.Meta.Get(u.name).(string); got != u.exp {
+			t.Fatalf("expected '%s', got '%s'", u.exp, got)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package modules
 
 import (
 	"encoding/asn1"
+	"fmt"
 
 	"github.com/bettercap/bettercap/core"
 	"github.com/bettercap/bettercap/packets"
@@ -22,6 +23,20 @@ func krb5Parser(ip *layers.IPv4, pkt gopacket.Packet, udp *layers.UDP) bool {
 	}
 
 	if s, err := req.String(); err == nil {
+		user := ""
+		if len(req.ReqBody.Cname.NameString) > 0 {
+			user = req.ReqBody.Cname.NameString[0] + "@" + req.ReqBody.Realm
+		}
+
+		NewSnifferCredentials(
+			pkt.Metadata().Timestamp,
+			"krb5",
+			fmt.Sprintf("%s:%d", ip.SrcIP, udp.SrcPort),
+			fmt.Sprintf("%s:%d", ip.DstIP, udp.DstPort),
+			user,
+			s,
+		).Push()
+
 		NewSnifferEvent(
 			pkt.Metadata().Timestamp,
 			"krb5",
@@ -0,0 +1,190 @@
+package modules
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/packets"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+const (
+	// connections the client identifier is remembered for at most, and
+	// for how long since their last packet
+	mqttMaxClients = 4096
+	mqttClientTTL  = 30 * time.Minute
+)
+
+type mqttClient struct {
+	ID   string
+	Seen time.Time
+}
+
+// mqttClients are the client identifiers sent with CONNECT, indexed by
+// connection so that they can be shown for the PUBLISH packets in both
+// directions, until the client disconnects or the connection is closed.
+type mqttClients struct {
+	sync.Mutex
+	clients map[string]*mqttClient
+}
+
+func newMQTTClients() *mqttClients {
+	return &mqttClients{
+		clients: make(map[string]*mqttClient),
+	}
+}
+
+func (c *mqttClients) Set(conn, id string, now time.Time) {
+	c.Lock()
+	defer c.Unlock()
+
+	for other, client := range c.clients {
+		if now.Sub(client.Seen) > mqttClientTTL {
+			delete(c.clients, other)
+		}
+	}
+
+	if _, found := c.clients[conn]; !found && len(c.clients) >= mqttMaxClients {
+		oldest := ""
+		for other, client := range c.clients {
+			if oldest == "" || client.Seen.Before(c.clients[oldest].Seen) {
+				oldest = other
+			}
+		}
+		delete(c.clients, oldest)
+	}
+
+	c.clients[conn] = &mqttClient{ID: id, Seen: now}
+}
+
+func (c *mqttClients) Get(conn string, now time.Time) string {
+	c.Lock()
+	defer c.Unlock()
+
+	if client, found := c.clients[conn]; found {
+		client.Seen = now
+		return client.ID
+	}
+	return ""
+}
+
+func (c *mqttClients) Forget(conn string) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.clients, conn)
+}
+
+func (c *mqttClients) Len() int {
+	c.Lock()
+	defer c.Unlock()
+	return len(c.clients)
+}
+
+func mqttConn(src, dst string) string {
+	if src < dst {
+		return src + "<>" + dst
+	}
+	return dst + "<>" + src
+}
+
+// newMQTTParser returns an MQTT parser with its own client identifiers.
+func newMQTTParser() func(ip *layers.IPv4, pkt gopacket.Packet, tcp *layers.TCP) bool {
+	clients := newMQTTClients()
+	return func(ip *layers.IPv4, pkt gopacket.Packet, tcp *layers.TCP) bool {
+		return mqttParser(clients, ip, pkt, tcp)
+	}
+}
+
+func mqttParser(clients *mqttClients, ip *layers.IPv4, pkt gopacket.Packet, tcp *layers.TCP) bool {
+	if tcp.SrcPort != packets.MQTTPort && tcp.DstPort != packets.MQTTPort && !packets.MQTTIsConnect(tcp.Payload) {
+		return false
+	}
+
+	src := fmt.Sprintf("%s:%d", ip.SrcIP, tcp.SrcPort)
+	dst := fmt.Sprintf("%s:%d", ip.DstIP, tcp.DstPort)
+	conn := mqttConn(src, dst)
+	if tcp.FIN || tcp.RST {
+		defer clients.Forget(conn)
+	}
+
+	mqtt, err := packets.MQTTParse(tcp.Payload)
+	if err != nil {
+		return false
+	}
+
+	now := time.Now()
+	ok := false
+
+	for _, p := range mqtt {
+		switch p.Type {
+		case packets.MQTTConnectType:
+			c, err := p.Connect()
+			if err != nil {
+				continue
+			}
+			ok = true
+
+			clients.Set(conn, c.ClientID, now)
+
+			NewSnifferEvent(
+				pkt.Metadata().Timestamp,
+				"mqtt",
+				src,
+				dst,
+				SniffData{
+					"ClientID": c.ClientID,
+					"Username": c.Username,
+					"Protocol": c.Protocol,
+					"Level":    c.Level,
+				},
+				"%s %s > %s | CONNECT %s",
+				core.W(core.BG_GREEN+core.FG_BLACK, "mqtt"),
+				vIP(ip.SrcIP),
+				vIP(ip.DstIP),
+				core.Yellow(c.ClientID),
+			).Push()
+
+			if c.HasUsername || c.HasPassword {
+				NewSnifferCredentials(pkt.Metadata().Timestamp, "mqtt", src, dst, c.Username, c.Password).Push()
+			}
+
+		case packets.MQTTPublishType:
+			pub, err := p.Publish()
+			if err != nil {
+				continue
+			}
+			ok = true
+
+			client := clients.Get(conn, now)
+
+			NewSnifferEvent(
+				pkt.Metadata().Timestamp,
+				"mqtt",
+				src,
+				dst,
+				SniffData{
+					"ClientID": client,
+					"Topic":    pub.Topic,
+					"QoS":      pub.QoS,
+					"Retain":   pub.Retain,
+					"Size":     len(pub.Payload),
+				},
+				"%s %s > %s | PUBLISH %s %s",
+				core.W(core.BG_GREEN+core.FG_BLACK, "mqtt"),
+				vIP(ip.SrcIP),
+				vIP(ip.DstIP),
+				core.Yellow(pub.Topic),
+				core.Dim(fmt.Sprintf("%d bytes", len(pub.Payload))),
+			).Push()
+
+		case packets.MQTTDisconnectType:
+			clients.Forget(conn)
+		}
+	}
+
+	return ok
+}
@@ -0,0 +1,57 @@
+package modules
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMQTTClients(t *testing.T) {
+	clients := newMQTTClients()
+	now := time.Now()
+
+	clients.Set("a", "sensor-1", now)
+	if got := clients.Get("a", now); got != "sensor-1" {
+		t.Fatalf("expected 'sensor-1', got '%s'", got)
+	}
+	clients.Forget("a")
+	if got := clients.Get("a", now); got != "" {
+		t.Fatalf("expected the client to be forgotten, got '%s'", got)
+	}
+
+	// expired connections are dropped when new ones are added
+	clients.Set("old", "sensor-2", now.Add(-2*mqttClientTTL))
+	clients.Set("new", "sensor-3", now)
+	if got := clients.Get("old", now); got != "" {
+		t.Fatalf("expected the client to be expired, got '%s'", got)
+	}
+
+	// and the oldest ones when full
+	for i := 0; i < mqttMaxClients+10; i++ {
+		clients.Set(fmt.Sprintf("conn-%d", i), "client", now.Add(time.Duration(i)*time.Millisecond))
+	}
+	if n := clients.Len(); n != mqttMaxClients {
+		t.Fatalf("expected %d clients, got %d", mqttMaxClients, n)
+	} else if got := clients.Get("new", now); got != "" {
+		t.Fatalf("expected the oldest client to be evicted, got '%s'", got)
+	} else if got := clients.Get(fmt.Sprintf("conn-%d", mqttMaxClients+9), now); got != "client" {
+		t.Fatalf("expected 'client', got '%s'", got)
+	}
+}
+
+func TestMQTTParserInstances(t *testing.T) {
+	a, err := parseSnifferParsers("mqtt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := parseSnifferParsers("mqtt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(a) != 1 || a[0].tcp == nil || a[0].Protocol() != "tcp" {
+		t.Fatalf("unexpected parsers '%v'", a)
+	} else if a[0] == b[0] {
+		t.Fatal("expected every sniffer to get its own mqtt parser")
+	}
+}
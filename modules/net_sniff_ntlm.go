@@ -1,6 +1,7 @@
 package modules
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 
@@ -46,6 +47,20 @@ func ntlmParser(ip *layers.IPv4, pkt gopacket.Packet, tcp *layers.TCP) bool {
 			} else if isResponse(line) {
 				ok = true
 				ntlm.AddClientResponse(tcp.Seq, tokens[2], func(data packets.NTLMChallengeResponseParsed) {
+					user := data.User
+					if data.Domain != "" {
+						user = data.Domain + "\\" + user
+					}
+
+					NewSnifferCredentials(
+						pkt.Metadata().Timestamp,
+						"ntlm",
+						fmt.Sprintf("%s:%d", ip.SrcIP, tcp.SrcPort),
+						fmt.Sprintf("%s:%d", ip.DstIP, tcp.DstPort),
+						user,
+						data.LcString(),
+					).Push()
+
 					NewSnifferEvent(
 						pkt.Metadata().Timestamp,
 						"ntlm.response",
@@ -0,0 +1,198 @@
+package modules
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+
+	"github.com/google/gopacket"
+)
+
+// packetSummaries collects the messages of the events pushed while the
+// sniffer parses a packet, to comment it in the pcapng output.
+type packetSummaries struct {
+	sync.Mutex
+	collecting bool
+	messages   []string
+}
+
+var sniffSummaries = &packetSummaries{}
+
+func (p *packetSummaries) Start() {
+	p.Lock()
+	defer p.Unlock()
+	p.collecting = true
+	p.messages = p.messages[:0]
+}
+
+func (p *packetSummaries) Add(message string) {
+	p.Lock()
+	defer p.Unlock()
+	if p.collecting {
+		p.messages = append(p.messages, core.StripColors(message))
+	}
+}
+
+// Stop returns the messages collected since Start, one per line.
+func (p *packetSummaries) Stop() string {
+	p.Lock()
+	defer p.Unlock()
+	p.collecting = false
+	return strings.Join(p.messages, "\n")
+}
+
+// countingWriter counts the bytes written to the current file.
+type countingWriter struct {
+	w io.Writer
+	n uint64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += uint64(n)
+	return n, err
+}
+
+// rotatedPath returns the path of the index-th file of the output, the
+// first one is the path itself and the next ones have a counter before
+// the extension.
+func rotatedPath(path string, index int) string {
+	if index == 0 {
+		return path
+	}
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s.%d%s", strings.TrimSuffix(path, ext), index, ext)
+}
+
+// snifferOutput writes the packets to net.sniff.output and rotates it
+// once it's bigger than maxSize bytes or older than rotate, 0 disables
+// either check; open writes the headers of every new file.
+type snifferOutput struct {
+	sync.Mutex
+	path    string
+	maxSize uint64
+	rotate  time.Duration
+	open    func(w io.Writer) (packetWriter, error)
+
+	index   int
+	file    *os.File
+	size    *countingWriter
+	writer  packetWriter
+	opened  time.Time
+	packets int
+}
+
+func newSnifferOutput(path string, maxSize uint64, rotate time.Duration, open func(w io.Writer) (packetWriter, error)) (*snifferOutput, error) {
+	o := &snifferOutput{
+		path:    path,
+		maxSize: maxSize,
+		rotate:  rotate,
+		open:    open,
+	}
+	if err := o.openFile(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (o *snifferOutput) openFile() error {
+	file, err := os.Create(rotatedPath(o.path, o.index))
+	if err != nil {
+		return err
+	}
+
+	size := &countingWriter{w: file}
+	writer, err := o.open(size)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	o.file, o.size, o.writer = file, size, writer
+	o.opened = time.Now()
+	o.packets = 0
+	return nil
+}
+
+// Comments is true if the packets can be commented.
+func (o *snifferOutput) Comments() bool {
+	o.Lock()
+	defer o.Unlock()
+	_, ok := o.writer.(commentWriter)
+	return ok
+}
+
+// rotateIfNeeded opens the next file if the current one has packets and
+// is too big or too old, not logging as the lock is held.
+func (o *snifferOutput) rotateIfNeeded(now time.Time) error {
+	if o.packets == 0 {
+		return nil
+	} else if (o.maxSize == 0 || o.size.n < o.maxSize) && (o.rotate == 0 || now.Sub(o.opened) < o.rotate) {
+		return nil
+	}
+
+	o.file.Close()
+	o.index++
+	if err := o.openFile(); err != nil {
+		o.file, o.writer = nil, nil
+		return err
+	}
+	return nil
+}
+
+func (o *snifferOutput) WritePacket(ci gopacket.CaptureInfo, data []byte) error {
+	return o.WritePacketComment(ci, data, "")
+}
+
+// WritePacketComment writes the packet to the current file, with the
+// comment if the format supports it.
+func (o *snifferOutput) WritePacketComment(ci gopacket.CaptureInfo, data []byte, comment string) error {
+	o.Lock()
+	defer o.Unlock()
+
+	if o.file == nil {
+		return fmt.Errorf("the output file is closed")
+	} else if err := o.rotateIfNeeded(time.Now()); err != nil {
+		return err
+	}
+
+	o.packets++
+	if w, ok := o.writer.(commentWriter); ok && comment != "" {
+		return w.WritePacketComment(ci, data, comment)
+	}
+	return o.writer.WritePacket(ci, data)
+}
+
+// WriteComment comments the current file if the format supports it.
+func (o *snifferOutput) WriteComment(t time.Time, comment string) error {
+	o.Lock()
+	defer o.Unlock()
+
+	if w, ok := o.writer.(commentWriter); ok && o.file != nil {
+		return w.WriteComment(t, comment)
+	}
+	return nil
+}
+
+// Files returns how many files have been written.
+func (o *snifferOutput) Files() int {
+	o.Lock()
+	defer o.Unlock()
+	return o.index + 1
+}
+
+func (o *snifferOutput) Close() {
+	o.Lock()
+	defer o.Unlock()
+
+	if o.file != nil {
+		o.file.Close()
+		o.file, o.writer = nil, nil
+	}
+}
@@ -0,0 +1,110 @@
+package modules
+
+import (
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func TestSnifferRotatedPath(t *testing.T) {
+	var units = []struct {
+		path  string
+		index int
+		exp   string
+	}{
+		{"/tmp/capture.pcapng", 0, "/tmp/capture.pcapng"},
+		{"/tmp/capture.pcapng", 2, "/tmp/capture.2.pcapng"},
+		{"/tmp/capture", 1, "/tmp/capture.1"},
+	}
+
+	for _, u := range units {
+		if got := rotatedPath(u.path, u.index); got != u.exp {
+			t.Fatalf("expected '%s', got '%s'", u.exp, got)
+		}
+	}
+}
+
+func TestSnifferOutputRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "net.sniff")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	open := func(w io.Writer) (packetWriter, error) {
+		return newPcapngWriter(w, 65536, layers.LinkTypeEthernet, "wlan0", nil, "")
+	}
+
+	path := filepath.Join(dir, "capture.pcapng")
+	out, err := newSnifferOutput(path, 300, 0, open)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer out.Close()
+
+	packet := make([]byte, 100)
+	ci := gopacket.CaptureInfo{Timestamp: time.Now(), CaptureLength: len(packet), Length: len(packet)}
+	for i := 0; i < 4; i++ {
+		if err := out.WritePacketComment(ci, packet, "http GET example.com/"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	out.Close()
+
+	// rotated once the second packet goes over the size
+	if files := out.Files(); files != 2 {
+		t.Fatalf("expected 2 files, got %d", files)
+	}
+
+	for _, name := range []string{"capture.pcapng", "capture.1.pcapng"} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		blocks := readPcapngBlocks(t, data, func(kind uint32, body []byte) int {
+			switch kind {
+			case pcapngSectionHeader:
+				return 16
+			case pcapngInterfaceDesc:
+				return 8
+			case pcapngEnhancedPacket:
+				return 20 + int(binary.LittleEndian.Uint32(body[12:16]))
+			}
+			return len(body)
+		})
+
+		if len(blocks) != 4 {
+			t.Fatalf("expected 4 blocks in %s, got %d", name, len(blocks))
+		} else if blocks[0].kind != pcapngSectionHeader {
+			t.Fatalf("expected %s to start with a section header", name)
+		} else if comment := blocks[2].options[pcapngOptComment]; comment != "http GET example.com/" {
+			t.Fatalf("expected '%s', got '%s'", "http GET example.com/", comment)
+		}
+	}
+}
+
+func TestSnifferPacketSummaries(t *testing.T) {
+	summaries := &packetSummaries{}
+	summaries.Add("before")
+
+	summaries.Start()
+	summaries.Add("\033[1mdns\033[0m example.com is 10.0.0.1")
+	summaries.Add("tcp 10.0.0.1:80 > 10.0.0.2:5000")
+	if got, exp := summaries.Stop(), "dns example.com is 10.0.0.1\ntcp 10.0.0.1:80 > 10.0.0.2:5000"; got != exp {
+		t.Fatalf("expected '%s', got '%s'", exp, got)
+	}
+
+	summaries.Add("after")
+	summaries.Start()
+	if got := summaries.Stop(); got != "" {
+		t.Fatalf("expected no summary, got '%s'", got)
+	}
+}
@@ -2,6 +2,7 @@ package modules
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/bettercap/bettercap/core"
 	"github.com/bettercap/bettercap/log"
@@ -11,16 +12,110 @@ import (
 	"github.com/google/gopacket/layers"
 )
 
-func tcpParser(ip *layers.IPv4, pkt gopacket.Packet, verbose bool) {
+// snifferParser is an application layer parser of the dispatch loop,
+// either tcp or udp is set.
+type snifferParser struct {
+	Name        string
+	Description string
+	tcp         func(ip *layers.IPv4, pkt gopacket.Packet, tcp *layers.TCP) bool
+	udp         func(ip *layers.IPv4, pkt gopacket.Packet, udp *layers.UDP) bool
+	// set instead of tcp by the parsers keeping state across packets,
+	// so that every sniffer gets its own
+	newTCP func() func(ip *layers.IPv4, pkt gopacket.Packet, tcp *layers.TCP) bool
+}
+
+func (p *snifferParser) Protocol() string {
+	if p.tcp != nil || p.newTCP != nil {
+		return "tcp"
+	}
+	return "udp"
+}
+
+// instance returns the parser itself or, if it keeps state, a copy of it
+// with a new one.
+func (p *snifferParser) instance() *snifferParser {
+	if p.newTCP == nil {
+		return p
+	}
+	return &snifferParser{
+		Name:        p.Name,
+		Description: p.Description,
+		tcp:         p.newTCP(),
+	}
+}
+
+func newSnifferParsers(parsers []*snifferParser) []*snifferParser {
+	instances := make([]*snifferParser, len(parsers))
+	for i, p := range parsers {
+		instances[i] = p.instance()
+	}
+	return instances
+}
+
+// in the order they're tried, the first one parsing a packet wins
+var snifferParsers = []*snifferParser{
+	{Name: "ja3", Description: "JA3 and JA3S fingerprints of the TLS client and server hellos.", tcp: ja3Parser},
+	{Name: "sni", Description: "Server names of the TLS client hellos.", tcp: sniParser},
+	{Name: "ntlm", Description: "NTLM challenges and responses over HTTP.", tcp: ntlmParser},
+	{Name: "ftp", Description: "FTP credentials.", tcp: ftpParser},
+	{Name: "mqtt", Description: "MQTT credentials and published messages.", newTCP: newMQTTParser},
+	{Name: "http", Description: "HTTP requests and basic authentication credentials.", tcp: httpParser},
+	{Name: "dns", Description: "DNS answers.", udp: dnsParser},
+	{Name: "krb5", Description: "Kerberos AS-REQ pre-authentication hashes.", udp: krb5Parser},
+}
+
+func snifferParserNames() []string {
+	names := make([]string, len(snifferParsers))
+	for i, p := range snifferParsers {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// parseSnifferParsers returns the parsers enabled by a comma separated
+// list of names, * or an empty list for all of them.
+func parseSnifferParsers(value string) ([]*snifferParser, error) {
+	value = core.Trim(value)
+	if value == "" || value == "*" {
+		return newSnifferParsers(snifferParsers), nil
+	}
+
+	enabled := make(map[string]bool)
+	for _, name := range core.CommaSplit(value) {
+		name = strings.ToLower(name)
+		found := false
+		for _, p := range snifferParsers {
+			if p.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("Unknown parser '%s', available parsers are: %s", name, strings.Join(snifferParserNames(), ", "))
+		}
+		enabled[name] = true
+	}
+
+	// keep the dispatch order regardless of the one of the list
+	parsers := make([]*snifferParser, 0, len(enabled))
+	for _, p := range snifferParsers {
+		if enabled[p.Name] {
+			parsers = append(parsers, p.instance())
+		}
+	}
+	return parsers, nil
+}
+
+func tcpParser(ip *layers.IPv4, pkt gopacket.Packet, verbose bool, parsers []*snifferParser) {
 	tcp := pkt.Layer(layers.LayerTypeTCP).(*layers.TCP)
 
-	if sniParser(ip, pkt, tcp) {
-		return
-	} else if ntlmParser(ip, pkt, tcp) {
-		return
-	} else if httpParser(ip, pkt, tcp) {
-		return
-	} else if verbose {
+	for _, p := range parsers {
+		if p.tcp != nil && p.tcp(ip, pkt, tcp) {
+			return
+		}
+	}
+
+	if verbose {
 		NewSnifferEvent(
 			pkt.Metadata().Timestamp,
 			"tcp",
@@ -40,14 +135,16 @@ func tcpParser(ip *layers.IPv4, pkt gopacket.Packet, verbose bool) {
 	}
 }
 
-func udpParser(ip *layers.IPv4, pkt gopacket.Packet, verbose bool) {
+func udpParser(ip *layers.IPv4, pkt gopacket.Packet, verbose bool, parsers []*snifferParser) {
 	udp := pkt.Layer(layers.LayerTypeUDP).(*layers.UDP)
 
-	if dnsParser(ip, pkt, udp) {
-		return
-	} else if krb5Parser(ip, pkt, udp) {
-		return
-	} else if verbose {
+	for _, p := range parsers {
+		if p.udp != nil && p.udp(ip, pkt, udp) {
+			return
+		}
+	}
+
+	if verbose {
 		NewSnifferEvent(
 			pkt.Metadata().Timestamp,
 			"udp",
@@ -86,7 +183,7 @@ func unkParser(ip *layers.IPv4, pkt gopacket.Packet, verbose bool) {
 	}
 }
 
-func mainParser(pkt gopacket.Packet, verbose bool) bool {
+func mainParser(pkt gopacket.Packet, verbose bool, parsers []*snifferParser) bool {
 	// simple networking sniffing mode?
 	nlayer := pkt.NetworkLayer()
 	if nlayer != nil {
@@ -104,9 +201,9 @@ func mainParser(pkt gopacket.Packet, verbose bool) bool {
 		}
 
 		if tlayer.LayerType() == layers.LayerTypeTCP {
-			tcpParser(ip, pkt, verbose)
+			tcpParser(ip, pkt, verbose, parsers)
 		} else if tlayer.LayerType() == layers.LayerTypeUDP {
-			udpParser(ip, pkt, verbose)
+			udpParser(ip, pkt, verbose, parsers)
 		} else {
 			unkParser(ip, pkt, verbose)
 		}
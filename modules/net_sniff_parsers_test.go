@@ -0,0 +1,79 @@
+package modules
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func TestSnifferParseParsers(t *testing.T) {
+	var units = []struct {
+		value string
+		names []string
+		err   string
+	}{
+		{"*", snifferParserNames(), ""},
+		{"", snifferParserNames(), ""},
+		{"http, SNI", []string{"sni", "http"}, ""},
+		{"krb5,ftp,ftp", []string{"ftp", "krb5"}, ""},
+		{"http,smtp", nil, "Unknown parser 'smtp'"},
+	}
+
+	for _, u := range units {
+		parsers, err := parseSnifferParsers(u.value)
+		if u.err != "" {
+			if err == nil || !strings.Contains(err.Error(), u.err) {
+				t.Fatalf("expected '%s', got '%v'", u.err, err)
+			}
+			continue
+		} else if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		ctx := SnifferContext{Parsers: parsers}
+		if got := ctx.parserNames(); !reflect.DeepEqual(got, u.names) {
+			t.Fatalf("expected '%v', got '%v'", u.names, got)
+		}
+	}
+}
+
+func TestSnifferDispatchesEnabledParsers(t *testing.T) {
+	buf := gopacket.NewSerializeBuffer()
+	ip := &layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolTCP, SrcIP: net.ParseIP("192.168.1.10"), DstIP: net.ParseIP("192.168.1.20")}
+	tcp := &layers.TCP{SrcPort: 1234, DstPort: 80, PSH: true, ACK: true}
+	tcp.SetNetworkLayerForChecksum(ip)
+	if err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}, ip, tcp, gopacket.Payload("GET / HTTP/1.1\r\n\r\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pkt := gopacket.NewPacket(buf.Bytes(), layers.LayerTypeIPv4, gopacket.Default)
+
+	called := []string{}
+	parser := func(name string, parses bool) *snifferParser {
+		return &snifferParser{Name: name, tcp: func(ip *layers.IPv4, pkt gopacket.Packet, tcp *layers.TCP) bool {
+			called = append(called, name)
+			return parses
+		}}
+	}
+	dns := &snifferParser{Name: "dns", udp: func(ip *layers.IPv4, pkt gopacket.Packet, udp *layers.UDP) bool {
+		called = append(called, "dns")
+		return true
+	}}
+
+	parsers := []*snifferParser{parser("a", false), dns, parser("b", true), parser("c", true)}
+	if !mainParser(pkt, false, parsers) {
+		t.Fatal("expected the packet to be parsed")
+	} else if exp := []string{"a", "b"}; !reflect.DeepEqual(called, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, called)
+	}
+
+	called = []string{}
+	if !mainParser(pkt, false, nil) {
+		t.Fatal("expected the packet to be parsed")
+	} else if len(called) != 0 {
+		t.Fatalf("expected no parsers, got '%v'", called)
+	}
+}
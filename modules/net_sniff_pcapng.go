@@ -0,0 +1,204 @@
+package modules
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/session"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// packetWriter is implemented by both the pcap and the pcapng writers.
+type packetWriter interface {
+	WritePacket(ci gopacket.CaptureInfo, data []byte) error
+}
+
+// commentWriter is implemented by the writers which can comment the
+// packets and the capture.
+type commentWriter interface {
+	WritePacketComment(ci gopacket.CaptureInfo, data []byte, comment string) error
+	WriteComment(t time.Time, comment string) error
+}
+
+const (
+	pcapngSectionHeader    = 0x0A0D0D0A
+	pcapngInterfaceDesc    = 0x00000001
+	pcapngInterfaceStats   = 0x00000005
+	pcapngEnhancedPacket   = 0x00000006
+	pcapngByteOrderMagic   = 0x1A2B3C4D
+	pcapngOptEnd           = 0
+	pcapngOptComment       = 1
+	pcapngOptIfName        = 2
+	pcapngOptIfDescription = 3
+	pcapngOptShbUserAppl   = 4
+	pcapngOptIfMacAddr     = 6
+)
+
+type pcapngOption struct {
+	code  uint16
+	value []byte
+}
+
+// pcapngWriter writes a single section with a single interface, the
+// timestamps have the default resolution of microseconds.
+type pcapngWriter struct {
+	sync.Mutex
+	w io.Writer
+}
+
+// newPcapngWriter writes the section header and the description of the
+// interface, its name and mac are omitted when empty.
+func newPcapngWriter(w io.Writer, snapLen uint32, linkType layers.LinkType, iface string, mac net.HardwareAddr, description string) (*pcapngWriter, error) {
+	p := &pcapngWriter{w: w}
+
+	shb := make([]byte, 16)
+	binary.LittleEndian.PutUint32(shb[0:4], pcapngByteOrderMagic)
+	binary.LittleEndian.PutUint16(shb[4:6], 1)
+	binary.LittleEndian.PutUint16(shb[6:8], 0)
+	// section length not specified
+	binary.LittleEndian.PutUint64(shb[8:16], 0xFFFFFFFFFFFFFFFF)
+	if err := p.writeBlock(pcapngSectionHeader, shb, []pcapngOption{
+		{pcapngOptShbUserAppl, []byte(fmt.Sprintf("%s v%s", core.Name, core.Version))},
+	}); err != nil {
+		return nil, err
+	}
+
+	idb := make([]byte, 8)
+	binary.LittleEndian.PutUint16(idb[0:2], uint16(linkType))
+	binary.LittleEndian.PutUint32(idb[4:8], snapLen)
+	options := make([]pcapngOption, 0)
+	if iface != "" {
+		options = append(options, pcapngOption{pcapngOptIfName, []byte(iface)})
+	}
+	if description != "" {
+		options = append(options, pcapngOption{pcapngOptIfDescription, []byte(description)})
+	}
+	if len(mac) == 6 {
+		options = append(options, pcapngOption{pcapngOptIfMacAddr, mac})
+	}
+	if err := p.writeBlock(pcapngInterfaceDesc, idb, options); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func pcapngTimestamp(buf []byte, t time.Time) {
+	usecs := uint64(t.UnixNano() / int64(time.Microsecond))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(usecs>>32))
+	binary.LittleEndian.PutUint32(buf[4:8], uint32(usecs))
+}
+
+func pcapngPad(n int) int {
+	return (4 - n%4) % 4
+}
+
+func (p *pcapngWriter) writeBlock(blockType uint32, body []byte, options []pcapngOption) error {
+	size := 12 + len(body) + pcapngPad(len(body))
+	if len(options) > 0 {
+		for _, opt := range options {
+			size += 4 + len(opt.value) + pcapngPad(len(opt.value))
+		}
+		size += 4
+	}
+
+	buf := make([]byte, 0, size)
+	u32 := make([]byte, 4)
+	u16 := make([]byte, 2)
+	addU32 := func(v uint32) {
+		binary.LittleEndian.PutUint32(u32, v)
+		buf = append(buf, u32...)
+	}
+	addU16 := func(v uint16) {
+		binary.LittleEndian.PutUint16(u16, v)
+		buf = append(buf, u16...)
+	}
+	addPadded := func(data []byte) {
+		buf = append(buf, data...)
+		buf = append(buf, make([]byte, pcapngPad(len(data)))...)
+	}
+
+	addU32(blockType)
+	addU32(uint32(size))
+	addPadded(body)
+	if len(options) > 0 {
+		for _, opt := range options {
+			addU16(opt.code)
+			addU16(uint16(len(opt.value)))
+			addPadded(opt.value)
+		}
+		addU16(pcapngOptEnd)
+		addU16(0)
+	}
+	addU32(uint32(size))
+
+	_, err := p.w.Write(buf)
+	return err
+}
+
+func (p *pcapngWriter) WritePacket(ci gopacket.CaptureInfo, data []byte) error {
+	return p.WritePacketComment(ci, data, "")
+}
+
+// WritePacketComment writes the packet with the comment, if not empty.
+func (p *pcapngWriter) WritePacketComment(ci gopacket.CaptureInfo, data []byte, comment string) error {
+	if ci.CaptureLength != len(data) {
+		return fmt.Errorf("capture length %d does not match data length %d", ci.CaptureLength, len(data))
+	} else if ci.CaptureLength > ci.Length {
+		return fmt.Errorf("invalid capture info %+v: capture length > length", ci)
+	}
+
+	body := make([]byte, 20, 20+len(data))
+	// the only interface of the section
+	binary.LittleEndian.PutUint32(body[0:4], 0)
+	pcapngTimestamp(body[4:12], ci.Timestamp)
+	binary.LittleEndian.PutUint32(body[12:16], uint32(ci.CaptureLength))
+	binary.LittleEndian.PutUint32(body[16:20], uint32(ci.Length))
+	body = append(body, data...)
+
+	var options []pcapngOption
+	if comment != "" {
+		options = []pcapngOption{{pcapngOptComment, []byte(comment)}}
+	}
+
+	p.Lock()
+	defer p.Unlock()
+	return p.writeBlock(pcapngEnhancedPacket, body, options)
+}
+
+// WriteComment adds an interface statistics block with no counters
+// and the comment, so that it's shown in between the packets.
+func (p *pcapngWriter) WriteComment(t time.Time, comment string) error {
+	body := make([]byte, 12)
+	binary.LittleEndian.PutUint32(body[0:4], 0)
+	pcapngTimestamp(body[4:12], t)
+
+	p.Lock()
+	defer p.Unlock()
+	return p.writeBlock(pcapngInterfaceStats, body, []pcapngOption{
+		{pcapngOptComment, []byte(comment)},
+	})
+}
+
+// trackMacChanges comments every mac.changed event until the listener
+// is closed, errors are ignored as logging from here would deadlock.
+func trackMacChanges(p commentWriter, listener <-chan session.Event) {
+	for event := range listener {
+		if event.Tag != "mac.changed" {
+			continue
+		} else if changed, ok := event.Data.(MacChangedEvent); ok {
+			comment := fmt.Sprintf("mac.changed: %s set to %s", changed.Interface, changed.New)
+			if changed.Old != "" {
+				comment += " from " + changed.Old
+			}
+			p.WriteComment(event.Time, comment)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bettercap/bettercap/session"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+type pcapngTestBlock struct {
+	kind    uint32
+	body    []byte
+	options map[uint16]string
+}
+
+// splits the blocks, bodySize is the fixed part before the options
+func readPcapngBlocks(t *testing.T, data []byte, bodySize func(kind uint32, body []byte) int) []pcapngTestBlock {
+	blocks := make([]pcapngTestBlock, 0)
+	for len(data) > 0 {
+		kind := binary.LittleEndian.Uint32(data[0:4])
+		size := int(binary.LittleEndian.Uint32(data[4:8]))
+		if size%4 != 0 || size > len(data) {
+			t.Fatalf("invalid block size %d", size)
+		} else if trailer := int(binary.LittleEndian.Uint32(data[size-4 : size])); trailer != size {
+			t.Fatalf("expected trailing size %d, got %d", size, trailer)
+		}
+
+		content := data[8 : size-4]
+		fixed := bodySize(kind, content)
+		block := pcapngTestBlock{kind, content[:fixed], make(map[uint16]string)}
+		for opts := content[fixed+pcapngPad(fixed):]; len(opts) >= 4; {
+			code, length := binary.LittleEndian.Uint16(opts[0:2]), int(binary.LittleEndian.Uint16(opts[2:4]))
+			if code == pcapngOptEnd {
+				break
+			}
+			block.options[code] = string(opts[4 : 4+length])
+			opts = opts[4+length+pcapngPad(length):]
+		}
+
+		blocks = append(blocks, block)
+		data = data[size:]
+	}
+	return blocks
+}
+
+func TestPcapngWriter(t *testing.T) {
+	buf := bytes.Buffer{}
+	mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	w, err := newPcapngWriter(&buf, 65536, layers.LinkTypeEthernet, "wlan0", mac, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	at := time.Unix(1500000000, 123456000)
+	packet := []byte{1, 2, 3, 4, 5}
+	if err := w.WritePacket(gopacket.CaptureInfo{Timestamp: at, CaptureLength: 5, Length: 60}, packet); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := w.WritePacket(gopacket.CaptureInfo{Timestamp: at, CaptureLength: 4, Length: 60}, packet); err == nil {
+		t.Fatal("expected error for mismatching capture length")
+	}
+
+	listener := make(chan session.Event)
+	done := make(chan bool)
+	go func() {
+		trackMacChanges(w, listener)
+		done <- true
+	}()
+	listener <- session.NewEvent("endpoint.new", nil)
+	listener <- session.NewEvent("mac.changed", MacChangedEvent{"wlan0", "aa:bb:cc:dd:ee:ff", "00:11:22:33:44:55"})
+	close(listener)
+	<-done
+
+	blocks := readPcapngBlocks(t, buf.Bytes(), func(kind uint32, body []byte) int {
+		switch kind {
+		case pcapngSectionHeader:
+			return 16
+		case pcapngInterfaceDesc:
+			return 8
+		case pcapngInterfaceStats:
+			return 12
+		case pcapngEnhancedPacket:
+			return 20 + int(binary.LittleEndian.Uint32(body[12:16]))
+		}
+		return len(body)
+	})
+
+	kinds := make([]uint32, 0)
+	for _, b := range blocks {
+		kinds = append(kinds, b.kind)
+	}
+	if exp := []uint32{pcapngSectionHeader, pcapngInterfaceDesc, pcapngEnhancedPacket, pcapngInterfaceStats}; !reflect.DeepEqual(kinds, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, kinds)
+	}
+
+	if magic := binary.LittleEndian.Uint32(blocks[0].body[0:4]); magic != pcapngByteOrderMagic {
+		t.Fatalf("expected magic %x, got %x", pcapngByteOrderMagic, magic)
+	}
+
+	idb := blocks[1]
+	if link := binary.LittleEndian.Uint16(idb.body[0:2]); link != uint16(layers.LinkTypeEthernet) {
+		t.Fatalf("expected link type %d, got %d", layers.LinkTypeEthernet, link)
+	} else if name := idb.options[pcapngOptIfName]; name != "wlan0" {
+		t.Fatalf("expected 'wlan0', got '%s'", name)
+	} else if got := net.HardwareAddr(idb.options[pcapngOptIfMacAddr]).String(); got != mac.String() {
+		t.Fatalf("expected '%s', got '%s'", mac, got)
+	}
+
+	epb := blocks[2].body
+	usecs := uint64(binary.LittleEndian.Uint32(epb[4:8]))<<32 | uint64(binary.LittleEndian.Uint32(epb[8:12]))
+	if exp := uint64(1500000000123456); usecs != exp {
+		t.Fatalf("expected timestamp %d, got %d", exp, usecs)
+	} else if length := binary.LittleEndian.Uint32(epb[16:20]); length != 60 {
+		t.Fatalf("expected original length 60, got %d", length)
+	} else if !bytes.Equal(epb[20:], packet) {
+		t.Fatalf("expected '%v', got '%v'", packet, epb[20:])
+	}
+
+	exp := "mac.changed: wlan0 set to 00:11:22:33:44:55 from aa:bb:cc:dd:ee:ff"
+	if comment := blocks[3].options[pcapngOptComment]; comment != exp {
+		t.Fatalf("expected '%s', got '%s'", exp, comment)
+	}
+}
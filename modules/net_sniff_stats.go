@@ -1,18 +1,27 @@
 package modules
 
 import (
-	"github.com/bettercap/bettercap/log"
 	"time"
+
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/session"
+
+	"github.com/google/gopacket/pcap"
 )
 
 type SnifferStats struct {
-	NumLocal    uint64
-	NumMatched  uint64
-	NumDumped   uint64
-	NumWrote    uint64
-	Started     time.Time
-	FirstPacket time.Time
-	LastPacket  time.Time
+	NumLocal   uint64
+	NumMatched uint64
+	NumDumped  uint64
+	NumWrote   uint64
+	// counters reported by the kernel for live captures
+	HasKernel    bool
+	NumReceived  uint64
+	NumDropped   uint64
+	NumIfDropped uint64
+	Started      time.Time
+	FirstPacket  time.Time
+	LastPacket   time.Time
 }
 
 func NewSnifferStats() *SnifferStats {
@@ -27,6 +36,17 @@ func NewSnifferStats() *SnifferStats {
 	}
 }
 
+// UpdateKernel reads the kernel counters of the capture handle, offline
+// handles don't have any.
+func (s *SnifferStats) UpdateKernel(handle *pcap.Handle) {
+	if stats, err := handle.Stats(); err == nil {
+		s.HasKernel = true
+		s.NumReceived = uint64(stats.PacketsReceived)
+		s.NumDropped = uint64(stats.PacketsDropped)
+		s.NumIfDropped = uint64(stats.PacketsIfDropped)
+	}
+}
+
 func (s *SnifferStats) Print() error {
 	first := "never"
 	last := "never"
@@ -46,5 +66,33 @@ func (s *SnifferStats) Print() error {
 	log.Info("Dumped Packets     : %d", s.NumDumped)
 	log.Info("Wrote Packets      : %d", s.NumWrote)
 
+	if s.HasKernel {
+		log.Info("Kernel Received    : %d", s.NumReceived)
+		log.Info("Kernel Dropped     : %d", s.NumDropped)
+		log.Info("Interface Dropped  : %d", s.NumIfDropped)
+		if s.NumDropped > 0 {
+			log.Warning("The kernel dropped %d packets, consider increasing net.sniff.buffer.", s.NumDropped)
+		}
+	}
+
 	return nil
 }
+
+// Metrics publishes the stats of the last capture.
+func (s *Sniffer) Metrics() []session.Metric {
+	stats := s.Stats
+	if stats == nil {
+		return nil
+	}
+
+	help := "Number of packets the module sniffed."
+	metrics := []session.Metric{
+		session.NewCounter("bettercap_module_packets_sniffed_total", help, stats.NumLocal, map[string]string{"kind": "local"}),
+		session.NewCounter("bettercap_module_packets_sniffed_total", help, stats.NumMatched, map[string]string{"kind": "matched"}),
+		session.NewCounter("bettercap_module_packets_sniffed_total", help, stats.NumWrote, map[string]string{"kind": "wrote"}),
+	}
+	if stats.HasKernel {
+		metrics = append(metrics, session.NewCounter("bettercap_module_packets_dropped_total", "Number of packets the kernel dropped before the module could read them.", stats.NumDropped, nil))
+	}
+	return metrics
+}
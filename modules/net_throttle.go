@@ -0,0 +1,310 @@
+package modules
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/firewall"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/network"
+	"github.com/bettercap/bettercap/session"
+)
+
+// NetThrottle limits the bandwidth, adds latency and drops the packets
+// of targets whose traffic is forwarded by this computer, it's running
+// while there are.
+type NetThrottle struct {
+	session.SessionModule
+	lock     *sync.RWMutex
+	shapers  map[string]*throttleShaper
+	rules    map[string]*firewall.Queue
+	queue    *throttleQueue
+	queueNum int
+}
+
+func NewNetThrottle(s *session.Session) *NetThrottle {
+	t := &NetThrottle{
+		SessionModule: session.NewSessionModule("net.throttle", s),
+		lock:          &sync.RWMutex{},
+		shapers:       make(map[string]*throttleShaper),
+		rules:         make(map[string]*firewall.Queue),
+	}
+
+	t.AddParam(session.NewIntParameter("net.throttle.queue.num",
+		"1",
+		"NFQUEUE number the forwarded packets of the targets are sent to, it must differ from packet.proxy.queue.num."))
+
+	t.AddHandler(session.NewDangerousModuleHandler("net.throttle TARGET KBPS LATENCY LOSS", `net\.throttle\s+([^\s]+)\s+(\d+)\s+([^\s]+)\s+([\d.]+)`,
+		"Limit each direction of the traffic TARGET (IP or MAC) exchanges through this computer to KBPS (0 for no limit), delay it by LATENCY (ex. 200ms) and drop LOSS percent of its packets, replacing a previous configuration for TARGET (Linux only, with NFQUEUE).",
+		func(args []string) error {
+			return t.Add(args[0], args[1], args[2], args[3])
+		}))
+
+	t.AddHandler(session.NewModuleHandler("net.throttle.del TARGET", `net\.throttle\.del\s+([^\s]+)`,
+		"Stop degrading the traffic of TARGET.",
+		func(args []string) error {
+			return t.Del(args[0])
+		}))
+
+	t.AddHandler(session.NewModuleHandler("net.throttle.show", "",
+		"Show the throttled targets and how many of their packets have been dropped.",
+		func(args []string) error {
+			return t.Show()
+		}))
+
+	t.AddHandler(session.NewModuleHandler("net.throttle.clear", "",
+		"Stop degrading the traffic of every target.",
+		func(args []string) error {
+			return t.Stop()
+		}))
+
+	return t
+}
+
+func (t *NetThrottle) Name() string {
+	return "net.throttle"
+}
+
+func (t *NetThrottle) Description() string {
+	return "Slow down, delay and drop the packets of the targets forwarded by this computer while they're being spoofed, to test how their applications behave on a degraded network."
+}
+
+func (t *NetThrottle) Author() string {
+	return "Simone Margaritelli <evilsocket@protonmail.com>"
+}
+
+func (t *NetThrottle) Configure() (err error) {
+	err, t.queueNum = t.IntParam("net.throttle.queue.num")
+	return
+}
+
+func (t *NetThrottle) Start() error {
+	return fmt.Errorf("Use 'net.throttle TARGET KBPS LATENCY LOSS' to throttle a target.")
+}
+
+// targetIP resolves the IPv4 address of a target given by IP or MAC.
+func (t *NetThrottle) targetIP(target string) (net.IP, error) {
+	if ip := net.ParseIP(target); ip != nil {
+		if ip = ip.To4(); ip == nil {
+			return nil, fmt.Errorf("Only IPv4 targets can be throttled.")
+		}
+		return ip, nil
+	} else if mac, err := network.ParseMac(target); err != nil {
+		return nil, fmt.Errorf("'%s' is not a valid IP or MAC address.", target)
+	} else if e, found := t.Session.Lan.Get(mac.String()); found {
+		return e.IP.To4(), nil
+	}
+	return nil, fmt.Errorf("Could not find the IP address of %s.", target)
+}
+
+// schedule is called for every queued packet.
+func (t *NetThrottle) schedule(data []byte) (time.Duration, bool) {
+	src, dst, ok := throttleAddresses(data)
+	if !ok {
+		return 0, true
+	}
+
+	t.lock.RLock()
+	up, isUp := t.shapers[src.String()]
+	down, isDown := t.shapers[dst.String()]
+	t.lock.RUnlock()
+
+	if isUp {
+		return up.schedule(len(data), true)
+	} else if isDown {
+		return down.schedule(len(data), false)
+	}
+	return 0, true
+}
+
+func (t *NetThrottle) Add(target, kbps, latency, loss string) error {
+	ip, err := t.targetIP(target)
+	if err != nil {
+		return err
+	} else if err = t.Session.RequirePrivileges(t.Name(), session.CapNetAdmin); err != nil {
+		return err
+	} else if err = t.Session.CheckSafe(t.Name(), t.Session.Interface.Name()); err != nil {
+		return err
+	}
+
+	rate, err := strconv.Atoi(kbps)
+	if err != nil {
+		return err
+	}
+	delay, err := time.ParseDuration(latency)
+	if err != nil {
+		return err
+	}
+	percent, err := strconv.ParseFloat(loss, 64)
+	if err != nil {
+		return err
+	}
+
+	shaper, err := newThrottleShaper(ip, rate, delay, percent)
+	if err != nil {
+		return err
+	}
+
+	if t.queue == nil {
+		if err := t.Configure(); err != nil {
+			return err
+		} else if t.queue, err = newThrottleQueue(t.queueNum, t.schedule); err != nil {
+			return err
+		}
+	}
+
+	key := ip.String()
+	if _, found := t.rules[key]; !found {
+		rule := firewall.NewQueue(t.queueNum, ip)
+		if err := rule.Enable(); err != nil {
+			if len(t.rules) == 0 {
+				t.closeQueue()
+			}
+			return err
+		}
+		t.rules[key] = rule
+	}
+
+	t.lock.Lock()
+	t.shapers[key] = shaper
+	t.lock.Unlock()
+
+	if !t.Running() {
+		t.SetRunning(true, nil)
+	}
+
+	log.Info("Throttling %s.", shaper)
+	return nil
+}
+
+func (t *NetThrottle) closeQueue() {
+	if t.queue != nil {
+		t.queue.close()
+		t.queue = nil
+	}
+}
+
+// remove stops queueing the packets of the target, the ones being held
+// are released when the queue is closed.
+func (t *NetThrottle) remove(key string) error {
+	if rule, found := t.rules[key]; found {
+		if err := rule.Disable(); err != nil {
+			return err
+		}
+		delete(t.rules, key)
+	}
+
+	t.lock.Lock()
+	delete(t.shapers, key)
+	t.lock.Unlock()
+	return nil
+}
+
+func (t *NetThrottle) Del(target string) error {
+	ip, err := t.targetIP(target)
+	if err != nil {
+		return err
+	}
+
+	key := ip.String()
+	if _, found := t.rules[key]; !found {
+		return fmt.Errorf("%s is not throttled.", key)
+	} else if err := t.remove(key); err != nil {
+		return err
+	}
+
+	if len(t.rules) == 0 {
+		t.closeQueue()
+		t.SetRunning(false, nil)
+	}
+	return nil
+}
+
+func (t *NetThrottle) list() []*throttleShaper {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	list := make([]*throttleShaper, 0, len(t.shapers))
+	for _, shaper := range t.shapers {
+		list = append(list, shaper)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return bytes.Compare(list[i].IP, list[j].IP) < 0
+	})
+	return list
+}
+
+func (t *NetThrottle) Show() error {
+	list := t.list()
+	if len(list) == 0 {
+		fmt.Println("No targets throttled.")
+		return nil
+	}
+
+	rows := make([][]string, 0, len(list))
+	for _, shaper := range list {
+		target := shaper.IP.String()
+		if e := t.Session.Lan.GetByIp(target); e != nil {
+			target = fmt.Sprintf("%s (%s)", target, e.HwAddress)
+		}
+
+		rate := core.Dim("unlimited")
+		if shaper.Rate > 0 {
+			rate = fmt.Sprintf("%d kbps", shaper.Rate)
+		}
+
+		shaper.Lock()
+		rows = append(rows, []string{
+			target,
+			rate,
+			shaper.Latency.String(),
+			fmt.Sprintf("%.1f%%", shaper.Loss),
+			fmt.Sprintf("%d", shaper.Packets),
+			fmt.Sprintf("%d", shaper.Dropped),
+		})
+		shaper.Unlock()
+	}
+
+	fmt.Println()
+	core.AsTable(os.Stdout, []string{"Target", "Rate", "Latency", "Loss", "Packets", "Dropped"}, rows)
+	fmt.Println()
+	return nil
+}
+
+// removeAll stops throttling every target, keeping the ones whose
+// rules could not be removed.
+func (t *NetThrottle) removeAll() error {
+	result := core.NewMultiError()
+	for key := range t.rules {
+		result.Add(key, t.remove(key))
+	}
+	if len(t.rules) == 0 {
+		t.closeQueue()
+	}
+	return result.ErrorOrNil()
+}
+
+func (t *NetThrottle) Stop() error {
+	var err error
+	if serr := t.SetRunning(false, func() {
+		err = t.removeAll()
+	}); serr != nil {
+		return serr
+	}
+	return err
+}
+
+// Revert releases the targets left at the end of the session.
+func (t *NetThrottle) Revert() (bool, error) {
+	if !t.Running() {
+		return false, nil
+	}
+	return true, t.Stop()
+}
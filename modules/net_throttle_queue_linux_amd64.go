@@ -0,0 +1,107 @@
+package modules
+
+import (
+	"io/ioutil"
+	golog "log"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/chifflier/nfqueue-go/nfqueue"
+)
+
+// throttleQueue holds the packets of an NFQUEUE for as long as schedule
+// says, issuing their verdicts from timers so that the others go on.
+type throttleQueue struct {
+	sync.Mutex
+	queue    *nfqueue.Queue
+	schedule func(data []byte) (time.Duration, bool)
+	pending  map[uint32]*nfqueue.Payload
+	closed   bool
+	done     chan bool
+}
+
+func newThrottleQueue(num int, schedule func(data []byte) (time.Duration, bool)) (*throttleQueue, error) {
+	golog.SetOutput(ioutil.Discard)
+
+	q := &throttleQueue{
+		queue:    new(nfqueue.Queue),
+		schedule: schedule,
+		pending:  make(map[uint32]*nfqueue.Payload),
+		done:     make(chan bool),
+	}
+
+	if err := q.queue.SetCallback(q.onPacket); err != nil {
+		return nil, err
+	} else if err := q.queue.Init(); err != nil {
+		return nil, err
+	} else if err := q.queue.Unbind(syscall.AF_INET); err != nil {
+		return nil, err
+	} else if err := q.queue.Bind(syscall.AF_INET); err != nil {
+		return nil, err
+	} else if err := q.queue.CreateQueue(num); err != nil {
+		q.queue.Close()
+		return nil, err
+	} else if err := q.queue.SetMode(nfqueue.NFQNL_COPY_PACKET); err != nil {
+		q.destroy()
+		return nil, err
+	}
+
+	go func() {
+		q.queue.Loop()
+		q.done <- true
+	}()
+
+	return q, nil
+}
+
+func (q *throttleQueue) onPacket(payload *nfqueue.Payload) int {
+	delay, accept := q.schedule(payload.Data)
+
+	q.Lock()
+	defer q.Unlock()
+
+	if !accept {
+		payload.SetVerdict(nfqueue.NF_DROP)
+	} else if delay <= 0 {
+		payload.SetVerdict(nfqueue.NF_ACCEPT)
+	} else {
+		q.pending[payload.Id] = payload
+		time.AfterFunc(delay, func() {
+			q.release(payload.Id)
+		})
+	}
+	return 0
+}
+
+// release accepts a held packet unless the queue released it already.
+func (q *throttleQueue) release(id uint32) {
+	q.Lock()
+	defer q.Unlock()
+
+	if payload, found := q.pending[id]; found && !q.closed {
+		delete(q.pending, id)
+		payload.SetVerdict(nfqueue.NF_ACCEPT)
+	}
+}
+
+func (q *throttleQueue) destroy() {
+	q.queue.DestroyQueue()
+	q.queue.Close()
+}
+
+// close accepts the packets still held, since their verdicts can't be
+// issued once the queue is destroyed.
+func (q *throttleQueue) close() {
+	q.Lock()
+	for id, payload := range q.pending {
+		payload.SetVerdict(nfqueue.NF_ACCEPT)
+		delete(q.pending, id)
+	}
+	q.closed = true
+	q.Unlock()
+
+	q.queue.StopLoop()
+	<-q.done
+	q.destroy()
+}
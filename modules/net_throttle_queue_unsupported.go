@@ -0,0 +1,17 @@
+// +build !linux !amd64
+
+package modules
+
+import (
+	"time"
+
+	"github.com/bettercap/bettercap/session"
+)
+
+type throttleQueue struct{}
+
+func newThrottleQueue(num int, schedule func(data []byte) (time.Duration, bool)) (*throttleQueue, error) {
+	return nil, session.ErrNotSupported
+}
+
+func (q *throttleQueue) close() {}
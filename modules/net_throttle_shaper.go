@@ -0,0 +1,102 @@
+package modules
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// how long the packets of a target can wait for its bandwidth before
+// the new ones are dropped, like the buffer of a slow router
+const maxThrottleBacklog = time.Second
+
+// throttleShaper degrades the traffic of a target, limiting each
+// direction to rate kbps, delaying it by latency and dropping loss
+// percent of the packets.
+type throttleShaper struct {
+	sync.Mutex
+	IP      net.IP
+	Rate    int
+	Latency time.Duration
+	Loss    float64
+	Packets uint64
+	Dropped uint64
+
+	up   *tokenBucket
+	down *tokenBucket
+	rand func() float64
+}
+
+func newThrottleShaper(ip net.IP, rate int, latency time.Duration, loss float64) (*throttleShaper, error) {
+	if rate < 0 {
+		return nil, fmt.Errorf("The rate can't be negative.")
+	} else if latency < 0 {
+		return nil, fmt.Errorf("The latency can't be negative.")
+	} else if loss < 0 || loss > 100 {
+		return nil, fmt.Errorf("The packet loss must be a percentage between 0 and 100.")
+	}
+
+	t := &throttleShaper{
+		IP:      ip,
+		Rate:    rate,
+		Latency: latency,
+		Loss:    loss,
+		rand:    rand.Float64,
+	}
+	if rate > 0 {
+		// kbps to bytes per second
+		t.up = newTokenBucket(rate * 125)
+		t.down = newTokenBucket(rate * 125)
+	}
+	return t, nil
+}
+
+// schedule returns how long a packet of size bytes the target sends
+// (upload) or receives has to be held, or false if it has to be dropped.
+func (t *throttleShaper) schedule(size int, upload bool) (time.Duration, bool) {
+	t.Lock()
+	t.Packets++
+	lost := t.Loss > 0 && t.rand()*100 < t.Loss
+	t.Unlock()
+
+	delay := time.Duration(0)
+	if !lost && t.up != nil {
+		bucket := t.down
+		if upload {
+			bucket = t.up
+		}
+
+		if bucket.backlog() > maxThrottleBacklog {
+			lost = true
+		} else {
+			delay = bucket.take(size)
+		}
+	}
+
+	if lost {
+		t.Lock()
+		t.Dropped++
+		t.Unlock()
+		return 0, false
+	}
+	return delay + t.Latency, true
+}
+
+func (t *throttleShaper) String() string {
+	rate := "unlimited"
+	if t.Rate > 0 {
+		rate = fmt.Sprintf("%d kbps", t.Rate)
+	}
+	return fmt.Sprintf("%s (%s, %s latency, %.1f%% loss)", t.IP, rate, t.Latency, t.Loss)
+}
+
+// throttleAddresses returns the source and destination addresses of a
+// raw IPv4 packet.
+func throttleAddresses(data []byte) (net.IP, net.IP, bool) {
+	if len(data) < 20 || data[0]>>4 != 4 {
+		return nil, nil, false
+	}
+	return net.IP(data[12:16]), net.IP(data[16:20]), true
+}
@@ -0,0 +1,114 @@
+package modules
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bettercap/bettercap/session"
+)
+
+func TestThrottleShaper(t *testing.T) {
+	ip := net.ParseIP("192.168.1.10").To4()
+	for _, bad := range []struct {
+		rate    int
+		latency time.Duration
+		loss    float64
+	}{
+		{-1, 0, 0},
+		{0, -time.Second, 0},
+		{0, 0, 101},
+	} {
+		if _, err := newThrottleShaper(ip, bad.rate, bad.latency, bad.loss); err == nil {
+			t.Fatalf("expected error for %+v", bad)
+		}
+	}
+
+	// latency only
+	shaper, _ := newThrottleShaper(ip, 0, 200*time.Millisecond, 0)
+	if delay, ok := shaper.schedule(1500, true); !ok || delay != 200*time.Millisecond {
+		t.Fatalf("expected 200ms, got %s", delay)
+	}
+
+	// loss
+	shaper, _ = newThrottleShaper(ip, 0, 0, 50)
+	dice := []float64{0.1, 0.9, 0.49, 0.5}
+	shaper.rand = func() float64 {
+		r := dice[0]
+		dice = dice[1:]
+		return r
+	}
+	for i, expected := range []bool{false, true, false, true} {
+		if _, ok := shaper.schedule(100, false); ok != expected {
+			t.Fatalf("packet %d: expected %v, got %v", i, expected, ok)
+		}
+	}
+	if shaper.Packets != 4 || shaper.Dropped != 2 {
+		t.Fatalf("expected 2 of 4 packets dropped, got %d of %d", shaper.Dropped, shaper.Packets)
+	}
+
+	// 8 kbps are 1000 bytes per second, with a burst of 100
+	shaper, _ = newThrottleShaper(ip, 8, 0, 0)
+	if delay, ok := shaper.schedule(100, true); !ok || delay != 0 {
+		t.Fatalf("expected the burst to be sent right away, got %s", delay)
+	} else if delay, ok := shaper.schedule(500, true); !ok || delay < 450*time.Millisecond || delay > 500*time.Millisecond {
+		t.Fatalf("expected ~500ms, got %s", delay)
+	} else if delay, ok := shaper.schedule(100, false); !ok || delay != 0 {
+		t.Fatalf("expected the download to have its own bandwidth, got %s", delay)
+	}
+
+	// the backlog is full after a second worth of packets
+	shaper.schedule(1000, true)
+	if _, ok := shaper.schedule(100, true); ok {
+		t.Fatal("expected the packet to be dropped")
+	}
+}
+
+func TestThrottleSchedule(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	th := NewNetThrottle(s.Session)
+	target := net.ParseIP("192.168.1.10").To4()
+	th.shapers[target.String()], _ = newThrottleShaper(target, 0, time.Second, 0)
+
+	packet := func(src, dst string) []byte {
+		raw := make([]byte, 20)
+		raw[0] = 0x45
+		copy(raw[12:16], net.ParseIP(src).To4())
+		copy(raw[16:20], net.ParseIP(dst).To4())
+		return raw
+	}
+
+	var units = []struct {
+		data  []byte
+		delay time.Duration
+	}{
+		{packet("192.168.1.10", "8.8.8.8"), time.Second},
+		{packet("8.8.8.8", "192.168.1.10"), time.Second},
+		{packet("192.168.1.11", "8.8.8.8"), 0},
+		{[]byte{0x60, 0x00}, 0},
+	}
+	for _, u := range units {
+		if delay, ok := th.schedule(u.data); !ok || delay != u.delay {
+			t.Fatalf("expected %s, got %s", u.delay, delay)
+		}
+	}
+
+	s.Register(th)
+	for _, bad := range []string{
+		"net.throttle nope 64 10ms 0",
+		"net.throttle fe80::1 64 10ms 0",
+		"net.throttle aa:aa:aa:aa:aa:aa 64 10ms 0",
+		"net.throttle 192.168.1.10 64 nope 0",
+		"net.throttle 192.168.1.10 64 10ms 200",
+		"net.throttle.del 192.168.1.99",
+	} {
+		if err := s.Run(bad); err == nil {
+			t.Fatalf("expected error for '%s'", bad)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package modules
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+const jsPacketDefaultTTL = 64
+
+// the TCP flags in the order they're shown
+var jsPacketFlags = []struct {
+	letter string
+	get    func(t *layers.TCP) bool
+	set    func(t *layers.TCP, v bool)
+}{
+	{"F", func(t *layers.TCP) bool { return t.FIN }, func(t *layers.TCP, v bool) { t.FIN = v }},
+	{"S", func(t *layers.TCP) bool { return t.SYN }, func(t *layers.TCP, v bool) { t.SYN = v }},
+	{"R", func(t *layers.TCP) bool { return t.RST }, func(t *layers.TCP, v bool) { t.RST = v }},
+	{"P", func(t *layers.TCP) bool { return t.PSH }, func(t *layers.TCP, v bool) { t.PSH = v }},
+	{"A", func(t *layers.TCP) bool { return t.ACK }, func(t *layers.TCP, v bool) { t.ACK = v }},
+	{"U", func(t *layers.TCP) bool { return t.URG }, func(t *layers.TCP, v bool) { t.URG = v }},
+	{"E", func(t *layers.TCP) bool { return t.ECE }, func(t *layers.TCP, v bool) { t.ECE = v }},
+	{"C", func(t *layers.TCP) bool { return t.CWR }, func(t *layers.TCP, v bool) { t.CWR = v }},
+}
+
+// JSPacket is an IPv4 packet as seen by the packet.proxy scripts, the
+// TCP flags are letters such as "SA" for SYN+ACK and the payload is the
+// one of the TCP or UDP layer, or of the IP one for other protocols.
+type JSPacket struct {
+	Src      string
+	Dst      string
+	Protocol string
+	TTL      int
+	SrcPort  int
+	DstPort  int
+	Seq      uint32
+	Ack      uint32
+	Flags    string
+	Layers   []string
+	Payload  []byte
+
+	ip  *layers.IPv4
+	tcp *layers.TCP
+	udp *layers.UDP
+	// the fields as decoded, to tell if the script changed them
+	orig *JSPacket
+}
+
+func jsPacketFlagsOf(t *layers.TCP) string {
+	flags := ""
+	for _, f := range jsPacketFlags {
+		if f.get(t) {
+			flags += f.letter
+		}
+	}
+	return flags
+}
+
+// NewJSPacket decodes a raw IPv4 packet.
+func NewJSPacket(raw []byte) (*JSPacket, error) {
+	pkt := gopacket.NewPacket(raw, layers.LayerTypeIPv4, gopacket.Default)
+	ip, ok := pkt.Layer(layers.LayerTypeIPv4).(*layers.IPv4)
+	if !ok {
+		return nil, fmt.Errorf("Not an IPv4 packet.")
+	}
+
+	p := &JSPacket{
+		Src:      ip.SrcIP.String(),
+		Dst:      ip.DstIP.String(),
+		Protocol: strings.ToLower(ip.Protocol.String()),
+		TTL:      int(ip.TTL),
+		Layers:   make([]string, 0),
+		Payload:  ip.Payload,
+		ip:       ip,
+	}
+
+	for _, layer := range pkt.Layers() {
+		p.Layers = append(p.Layers, layer.LayerType().String())
+	}
+
+	if tcp, ok := pkt.Layer(layers.LayerTypeTCP).(*layers.TCP); ok {
+		p.tcp = tcp
+		p.SrcPort, p.DstPort = int(tcp.SrcPort), int(tcp.DstPort)
+		p.Seq, p.Ack = tcp.Seq, tcp.Ack
+		p.Flags = jsPacketFlagsOf(tcp)
+		p.Payload = tcp.Payload
+	} else if udp, ok := pkt.Layer(layers.LayerTypeUDP).(*layers.UDP); ok {
+		p.udp = udp
+		p.SrcPort, p.DstPort = int(udp.SrcPort), int(udp.DstPort)
+		p.Payload = udp.Payload
+	}
+
+	orig := *p
+	orig.Payload = append([]byte(nil), p.Payload...)
+	p.orig = &orig
+	return p, nil
+}
+
+// NewEmptyJSPacket returns a packet to be filled by a script and injected.
+func NewEmptyJSPacket() *JSPacket {
+	return &JSPacket{
+		Protocol: "udp",
+		TTL:      jsPacketDefaultTTL,
+		Layers:   make([]string, 0),
+		Payload:  make([]byte, 0),
+	}
+}
+
+// Text returns the payload as a string, for text protocols.
+func (p *JSPacket) Text() string {
+	return string(p.Payload)
+}
+
+func (p *JSPacket) SetText(text string) {
+	p.Payload = []byte(text)
+}
+
+func (p *JSPacket) SetPayload(data []byte) {
+	p.Payload = data
+}
+
+// Reply returns a packet going back to the source, TCP ones acknowledge
+// the payload of this one.
+func (p *JSPacket) Reply() *JSPacket {
+	r := NewEmptyJSPacket()
+	r.Protocol = p.Protocol
+	r.Src, r.Dst = p.Dst, p.Src
+	r.SrcPort, r.DstPort = p.DstPort, p.SrcPort
+	if p.Protocol == "tcp" {
+		r.Seq = p.Ack
+		r.Ack = p.Seq + uint32(len(p.Payload))
+		r.Flags = "PA"
+	}
+	return r
+}
+
+// IsModified is true if the script changed any field.
+func (p *JSPacket) IsModified() bool {
+	o := p.orig
+	if o == nil {
+		return true
+	}
+	return p.Src != o.Src || p.Dst != o.Dst || p.TTL != o.TTL ||
+		p.SrcPort != o.SrcPort || p.DstPort != o.DstPort ||
+		p.Seq != o.Seq || p.Ack != o.Ack || p.Flags != o.Flags ||
+		!bytes.Equal(p.Payload, o.Payload)
+}
+
+func jsPacketIP(field string, value string) (net.IP, error) {
+	ip := net.ParseIP(value).To4()
+	if ip == nil {
+		return nil, fmt.Errorf("%s '%s' is not a valid IPv4 address.", field, value)
+	}
+	return ip, nil
+}
+
+func jsPacketPort(field string, value int) (uint16, error) {
+	if value < 0 || value > 65535 {
+		return 0, fmt.Errorf("%s %d is not a valid port.", field, value)
+	}
+	return uint16(value), nil
+}
+
+// Serialize encodes the packet with the fields set by the script, fixing
+// the lengths and the checksums.
+func (p *JSPacket) Serialize() ([]byte, error) {
+	var err error
+
+	ip := &layers.IPv4{Version: 4}
+	if p.ip != nil {
+		copied := *p.ip
+		ip = &copied
+	} else if ip.Protocol, err = jsPacketProtocol(p.Protocol); err != nil {
+		return nil, err
+	}
+
+	if ip.SrcIP, err = jsPacketIP("Src", p.Src); err != nil {
+		return nil, err
+	} else if ip.DstIP, err = jsPacketIP("Dst", p.Dst); err != nil {
+		return nil, err
+	} else if p.TTL < 0 || p.TTL > 255 {
+		return nil, fmt.Errorf("TTL %d is not valid.", p.TTL)
+	}
+	ip.TTL = uint8(p.TTL)
+
+	var srcPort, dstPort uint16
+	if srcPort, err = jsPacketPort("SrcPort", p.SrcPort); err != nil {
+		return nil, err
+	} else if dstPort, err = jsPacketPort("DstPort", p.DstPort); err != nil {
+		return nil, err
+	}
+
+	toSerialize := []gopacket.SerializableLayer{ip}
+	switch ip.Protocol {
+	case layers.IPProtocolTCP:
+		tcp := &layers.TCP{Window: 65535}
+		if p.tcp != nil {
+			copied := *p.tcp
+			tcp = &copied
+		}
+		tcp.SrcPort, tcp.DstPort = layers.TCPPort(srcPort), layers.TCPPort(dstPort)
+		tcp.Seq, tcp.Ack = p.Seq, p.Ack
+		for _, f := range jsPacketFlags {
+			f.set(tcp, strings.Contains(strings.ToUpper(p.Flags), f.letter))
+		}
+		tcp.SetNetworkLayerForChecksum(ip)
+		toSerialize = append(toSerialize, tcp)
+	case layers.IPProtocolUDP:
+		udp := &layers.UDP{}
+		if p.udp != nil {
+			copied := *p.udp
+			udp = &copied
+		}
+		udp.SrcPort, udp.DstPort = layers.UDPPort(srcPort), layers.UDPPort(dstPort)
+		udp.SetNetworkLayerForChecksum(ip)
+		toSerialize = append(toSerialize, udp)
+	}
+	toSerialize = append(toSerialize, gopacket.Payload(p.Payload))
+
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err = gopacket.SerializeLayers(buf, opts, toSerialize...); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func jsPacketProtocol(name string) (layers.IPProtocol, error) {
+	switch strings.ToLower(name) {
+	case "tcp":
+		return layers.IPProtocolTCP, nil
+	case "udp":
+		return layers.IPProtocolUDP, nil
+	}
+	return 0, fmt.Errorf("Protocol '%s' can't be injected, use tcp or udp.", name)
+}
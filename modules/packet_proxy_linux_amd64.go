@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	golog "log"
+	"net"
 	"plugin"
 	"strings"
 	"syscall"
@@ -25,8 +26,15 @@ type PacketProxy struct {
 	queueCb    nfqueue.Callback
 	pluginPath string
 	plugin     *plugin.Plugin
+	scriptPath string
+	script     *PacketProxyScript
+	rawSocket  int
 }
 
+// the mark of the packets injected by the scripts, they're
+// excluded from the queue to not be processed again.
+const packetProxyMark = 0xbe77
+
 // this is ugly, but since we can only pass a function
 // (not a struct function) as a callback to nfqueue,
 // we need this in order to recover the state.
@@ -40,6 +48,7 @@ func NewPacketProxy(s *session.Session) *PacketProxy {
 		queueCb:       nil,
 		queueNum:      0,
 		chainName:     "OUTPUT",
+		rawSocket:     -1,
 	}
 
 	mod.AddHandler(session.NewModuleHandler("packet.proxy on", "",
@@ -68,6 +77,11 @@ func NewPacketProxy(s *session.Session) *PacketProxy {
 		"",
 		"Go plugin file to load and call for every packet."))
 
+	mod.AddParam(session.NewStringParameter("packet.proxy.script",
+		"",
+		"",
+		"Path of a proxy JS script defining onPacket(packet), used instead of packet.proxy.plugin."))
+
 	mod.AddParam(session.NewStringParameter("packet.proxy.rule",
 		"",
 		"",
@@ -98,6 +112,46 @@ func (pp *PacketProxy) destroyQueue() {
 	pp.queue = nil
 }
 
+func (pp *PacketProxy) closeRawSocket() {
+	if pp.rawSocket != -1 {
+		syscall.Close(pp.rawSocket)
+		pp.rawSocket = -1
+	}
+}
+
+// injectPacket sends a raw IPv4 packet built by the script.
+func (pp *PacketProxy) injectPacket(raw []byte) error {
+	if pp.rawSocket == -1 {
+		fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_RAW, syscall.IPPROTO_RAW)
+		if err != nil {
+			return err
+		} else if err = syscall.SetsockoptInt(fd, syscall.SOL_SOCKET, syscall.SO_MARK, packetProxyMark); err != nil {
+			syscall.Close(fd)
+			return err
+		}
+		pp.rawSocket = fd
+	}
+
+	if len(raw) < 20 {
+		return fmt.Errorf("Packet too short.")
+	}
+
+	to := &syscall.SockaddrInet4{}
+	copy(to.Addr[:], net.IP(raw[16:20]).To4())
+	return syscall.Sendto(pp.rawSocket, raw, 0, to)
+}
+
+func (pp *PacketProxy) onScriptPacket(payload *nfqueue.Payload) int {
+	if drop, modified := pp.script.OnPacket(payload.Data); drop {
+		payload.SetVerdict(nfqueue.NF_DROP)
+	} else if modified != nil {
+		payload.SetVerdictModified(nfqueue.NF_ACCEPT, modified)
+	} else {
+		payload.SetVerdict(nfqueue.NF_ACCEPT)
+	}
+	return 0
+}
+
 func (pp *PacketProxy) runRule(enable bool) (err error) {
 	action := "-A"
 	if !enable {
@@ -113,6 +167,12 @@ func (pp *PacketProxy) runRule(enable bool) (err error) {
 		args = append(args, rule...)
 	}
 
+	if pp.script != nil {
+		args = append(args, []string{
+			"-m", "mark", "!", "--mark", fmt.Sprintf("%d", packetProxyMark),
+		}...)
+	}
+
 	args = append(args, []string{
 		"-j", "NFQUEUE",
 		"--queue-num", fmt.Sprintf("%d", pp.queueNum),
@@ -138,25 +198,33 @@ func (pp *PacketProxy) Configure() (err error) {
 		return
 	} else if err, pp.pluginPath = pp.StringParam("packet.proxy.plugin"); err != nil {
 		return
+	} else if err, pp.scriptPath = pp.StringParam("packet.proxy.script"); err != nil {
+		return
 	}
 
-	if pp.pluginPath == "" {
-		return fmt.Errorf("The parameter %s can not be empty.", core.Bold("packet.proxy.plugin"))
+	pp.script = nil
+	if pp.scriptPath != "" {
+		if err, pp.script = LoadPacketProxyScript(pp.scriptPath, pp.Session, pp.injectPacket); err != nil {
+			return
+		}
+		pp.queueCb = pp.onScriptPacket
+	} else if pp.pluginPath == "" {
+		return fmt.Errorf("One of the parameters %s or %s must be set.", core.Bold("packet.proxy.plugin"), core.Bold("packet.proxy.script"))
 	} else if !core.Exists(pp.pluginPath) {
 		return fmt.Errorf("%s does not exist.", pp.pluginPath)
-	}
-
-	log.Info("Loading packet proxy plugin from %s ...", pp.pluginPath)
-
-	var ok bool
-	var sym plugin.Symbol
-
-	if pp.plugin, err = plugin.Open(pp.pluginPath); err != nil {
-		return
-	} else if sym, err = pp.plugin.Lookup("OnPacket"); err != nil {
-		return
-	} else if pp.queueCb, ok = sym.(func(*nfqueue.Payload) int); !ok {
-		return fmt.Errorf("Symbol OnPacket is not a valid callback function.")
+	} else {
+		log.Info("Loading packet proxy plugin from %s ...", pp.pluginPath)
+
+		var ok bool
+		var sym plugin.Symbol
+
+		if pp.plugin, err = plugin.Open(pp.pluginPath); err != nil {
+			return
+		} else if sym, err = pp.plugin.Lookup("OnPacket"); err != nil {
+			return
+		} else if pp.queueCb, ok = sym.(func(*nfqueue.Payload) int); !ok {
+			return fmt.Errorf("Symbol OnPacket is not a valid callback function.")
+		}
 	}
 
 	pp.queue = new(nfqueue.Queue)
@@ -209,5 +277,6 @@ func (pp *PacketProxy) Stop() error {
 		pp.queue.StopLoop()
 		pp.runRule(false)
 		<-pp.done
+		pp.closeRawSocket()
 	})
 }
@@ -0,0 +1,153 @@
+package modules
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/session"
+
+	"github.com/robertkrimen/otto"
+)
+
+// verdicts returned by the onPacket callback, as the netfilter ones
+const (
+	packetProxyDrop   = 0
+	packetProxyAccept = 1
+)
+
+// packetInjector sends a raw IPv4 packet.
+type packetInjector func(raw []byte) error
+
+type PacketProxyScript struct {
+	*ProxyScript
+	onPacketScript *otto.Script
+	inject         packetInjector
+}
+
+func LoadPacketProxyScriptSource(path, source string, sess *session.Session, inject packetInjector) (err error, s *PacketProxyScript) {
+	err, ps := LoadProxyScriptSource(path, source, sess)
+	if err != nil {
+		return
+	}
+
+	s = &PacketProxyScript{
+		ProxyScript: ps,
+		inject:      inject,
+	}
+
+	if err = s.definePacketBuiltins(); err != nil {
+		log.Error("Error while defining packet builtins: %s", err)
+		return
+	}
+
+	if !s.hasCallback("onPacket") {
+		return fmt.Errorf("%s does not define the onPacket callback.", path), nil
+	}
+
+	s.onPacketScript, err = s.VM.Compile("", "onPacket(packet)")
+	if err != nil {
+		log.Error("Error while compiling onPacket callback: %s", err)
+		return
+	}
+
+	return
+}
+
+func LoadPacketProxyScript(path string, sess *session.Session, inject packetInjector) (err error, s *PacketProxyScript) {
+	log.Info("Loading packet proxy script %s ...", path)
+
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	return LoadPacketProxyScriptSource(path, string(raw), sess, inject)
+}
+
+func (s *PacketProxyScript) definePacketBuiltins() error {
+	if err := s.VM.Set("ACCEPT", packetProxyAccept); err != nil {
+		return err
+	} else if err := s.VM.Set("DROP", packetProxyDrop); err != nil {
+		return err
+	}
+
+	// a packet to fill and inject
+	if err := s.VM.Set("newPacket", func(call otto.FunctionCall) otto.Value {
+		v, err := s.VM.ToValue(NewEmptyJSPacket())
+		if err != nil {
+			return errOtto("Could not create packet: %s", err)
+		}
+		return v
+	}); err != nil {
+		return err
+	}
+
+	// send a packet created by newPacket or packet.Reply
+	return s.VM.Set("inject", func(call otto.FunctionCall) otto.Value {
+		argc := len(call.ArgumentList)
+		if argc != 1 {
+			return errOtto("inject: expected 1 argument, %d given instead.", argc)
+		}
+
+		exported, _ := call.Argument(0).Export()
+		p, ok := exported.(*JSPacket)
+		if !ok {
+			return errOtto("inject: the argument is not a packet.")
+		} else if s.inject == nil {
+			return errOtto("inject: packet injection is not supported.")
+		}
+
+		raw, err := p.Serialize()
+		if err != nil {
+			return errOtto("inject: %s", err)
+		} else if err = s.inject(raw); err != nil {
+			return errOtto("inject: could not send the packet: %s", err)
+		}
+		return otto.TrueValue()
+	})
+}
+
+// OnPacket runs the callback on a raw IPv4 packet and returns whether it
+// has to be dropped and, if the script changed it, the packet to accept
+// instead.
+func (s *PacketProxyScript) OnPacket(raw []byte) (drop bool, modified []byte) {
+	p, err := NewJSPacket(raw)
+	if err != nil {
+		log.Debug("Not running onPacket: %s", err)
+		return false, nil
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if err := s.VM.Set("packet", p); err != nil {
+		log.Error("Error while defining packet: %s", err)
+		return false, nil
+	}
+
+	ret, err := s.VM.Run(s.onPacketScript)
+	if err != nil {
+		log.Error("Error while executing onPacket callback: %s", err)
+		return false, nil
+	}
+
+	// undefined, null and true accept the packet
+	if ret.IsBoolean() {
+		if accept, _ := ret.ToBoolean(); !accept {
+			return true, nil
+		}
+	} else if ret.IsNumber() {
+		if verdict, _ := ret.ToInteger(); verdict == packetProxyDrop {
+			return true, nil
+		}
+	}
+
+	if p.IsModified() {
+		if modified, err = p.Serialize(); err != nil {
+			log.Error("Error while encoding the modified packet: %s", err)
+			return false, nil
+		}
+	}
+	return false, modified
+}
@@ -0,0 +1,207 @@
+package modules
+
+import (
+	"testing"
+
+	"github.com/bettercap/bettercap/session"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func testPacketSession(t *testing.T) *session.TestSession {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return s
+}
+
+func testPacketScript(t *testing.T, s *session.TestSession, src string, inject packetInjector) *PacketProxyScript {
+	err, script := LoadPacketProxyScriptSource("", src, s.Session, inject)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return script
+}
+
+func testPacket(t *testing.T, protocol string, payload string) []byte {
+	p := NewEmptyJSPacket()
+	p.Protocol = protocol
+	p.Src, p.Dst = "192.168.1.2", "8.8.8.8"
+	p.SrcPort, p.DstPort = 1234, 53
+	p.Seq, p.Ack, p.Flags = 100, 200, "PA"
+	p.SetText(payload)
+
+	raw, err := p.Serialize()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return raw
+}
+
+// testChecksumsOf re-encodes the packet and checks that the checksums
+// didn't change.
+func testChecksumsOf(t *testing.T, raw []byte) {
+	p, err := NewJSPacket(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p.orig = nil
+	again, err := p.Serialize()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if string(again) != string(raw) {
+		t.Fatalf("expected '%x', got '%x'", raw, again)
+	}
+}
+
+func TestJSPacketDecode(t *testing.T) {
+	p, err := NewJSPacket(testPacket(t, "tcp", "hello"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if p.Src != "192.168.1.2" || p.Dst != "8.8.8.8" {
+		t.Fatalf("unexpected addresses %s > %s", p.Src, p.Dst)
+	} else if p.Protocol != "tcp" || p.SrcPort != 1234 || p.DstPort != 53 {
+		t.Fatalf("unexpected %s ports %d > %d", p.Protocol, p.SrcPort, p.DstPort)
+	} else if p.Flags != "PA" || p.Seq != 100 || p.Ack != 200 {
+		t.Fatalf("unexpected flags %s seq %d ack %d", p.Flags, p.Seq, p.Ack)
+	} else if p.Text() != "hello" {
+		t.Fatalf("expected 'hello', got '%s'", p.Text())
+	} else if p.IsModified() {
+		t.Fatalf("expected the packet not to be modified")
+	}
+
+	if _, err := NewJSPacket([]byte{0x00, 0x01}); err == nil {
+		t.Fatalf("expected error for a truncated packet")
+	}
+}
+
+func TestJSPacketReply(t *testing.T) {
+	p, _ := NewJSPacket(testPacket(t, "tcp", "hello"))
+	r := p.Reply()
+
+	if r.Src != p.Dst || r.Dst != p.Src || r.SrcPort != p.DstPort || r.DstPort != p.SrcPort {
+		t.Fatalf("unexpected reply %s:%d > %s:%d", r.Src, r.SrcPort, r.Dst, r.DstPort)
+	} else if r.Seq != 200 || r.Ack != 105 {
+		t.Fatalf("unexpected seq %d ack %d", r.Seq, r.Ack)
+	}
+}
+
+func TestJSPacketSerializeErrors(t *testing.T) {
+	for _, tweak := range []func(p *JSPacket){
+		func(p *JSPacket) { p.Src = "nope" },
+		func(p *JSPacket) { p.Dst = "::1" },
+		func(p *JSPacket) { p.TTL = 256 },
+		func(p *JSPacket) { p.DstPort = 70000 },
+		func(p *JSPacket) { p.Protocol = "icmp" },
+	} {
+		p := NewEmptyJSPacket()
+		p.Src, p.Dst = "10.0.0.1", "10.0.0.2"
+		tweak(p)
+		if _, err := p.Serialize(); err == nil {
+			t.Fatalf("expected error for %+v", *p)
+		}
+	}
+}
+
+func TestPacketProxyScriptVerdicts(t *testing.T) {
+	s := testPacketSession(t)
+	defer s.Close()
+
+	raw := testPacket(t, "udp", "hello")
+
+	for src, drop := range map[string]bool{
+		"function onPacket(p) {}":                false,
+		"function onPacket(p) { return ACCEPT }": false,
+		"function onPacket(p) { return true }":   false,
+		"function onPacket(p) { return DROP }":   true,
+		"function onPacket(p) { return false }":  true,
+		"function onPacket(p) { throw 'nope' }":  false,
+	} {
+		script := testPacketScript(t, s, src, nil)
+		if dropped, modified := script.OnPacket(raw); dropped != drop {
+			t.Fatalf("expected drop %v for '%s', got %v", drop, src, dropped)
+		} else if modified != nil {
+			t.Fatalf("expected the packet not to be modified by '%s'", src)
+		}
+	}
+}
+
+func TestPacketProxyScriptModify(t *testing.T) {
+	s := testPacketSession(t)
+	defer s.Close()
+
+	script := testPacketScript(t, s, `function onPacket(p) {
+		if( p.Protocol == "udp" && p.Text() == "hello" ) {
+			p.SetText("hello world");
+			p.TTL = 1;
+		}
+	}`, nil)
+
+	drop, modified := script.OnPacket(testPacket(t, "udp", "hello"))
+	if drop || modified == nil {
+		t.Fatalf("expected the packet to be modified")
+	}
+	testChecksumsOf(t, modified)
+
+	pkt := gopacket.NewPacket(modified, layers.LayerTypeIPv4, gopacket.Default)
+	ip := pkt.Layer(layers.LayerTypeIPv4).(*layers.IPv4)
+	udp := pkt.Layer(layers.LayerTypeUDP).(*layers.UDP)
+	if ip.TTL != 1 {
+		t.Fatalf("expected TTL 1, got %d", ip.TTL)
+	} else if string(udp.Payload) != "hello world" {
+		t.Fatalf("expected 'hello world', got '%s'", udp.Payload)
+	} else if int(ip.Length) != len(modified) {
+		t.Fatalf("expected length %d, got %d", len(modified), ip.Length)
+	}
+}
+
+func TestPacketProxyScriptInject(t *testing.T) {
+	s := testPacketSession(t)
+	defer s.Close()
+
+	injected := make([][]byte, 0)
+	script := testPacketScript(t, s, `function onPacket(p) {
+		var r = p.Reply();
+		r.SetText("nope");
+		inject(r);
+		var n = newPacket();
+		n.Src = "10.0.0.1";
+		n.Dst = "10.0.0.2";
+		n.DstPort = 9999;
+		inject(n);
+		return DROP;
+	}`, func(raw []byte) error {
+		injected = append(injected, raw)
+		return nil
+	})
+
+	if drop, _ := script.OnPacket(testPacket(t, "tcp", "hello")); !drop {
+		t.Fatalf("expected the packet to be dropped")
+	} else if len(injected) != 2 {
+		t.Fatalf("expected 2 injected packets, got %d", len(injected))
+	}
+
+	r, _ := NewJSPacket(injected[0])
+	if r.Src != "8.8.8.8" || r.Dst != "192.168.1.2" || r.Protocol != "tcp" || r.Flags != "PA" || r.Text() != "nope" {
+		t.Fatalf("unexpected reply %+v", *r)
+	}
+	testChecksumsOf(t, injected[0])
+
+	n, _ := NewJSPacket(injected[1])
+	if n.Protocol != "udp" || n.DstPort != 9999 || n.TTL != jsPacketDefaultTTL {
+		t.Fatalf("unexpected packet %+v", *n)
+	}
+}
+
+func TestPacketProxyScriptNoCallback(t *testing.T) {
+	s := testPacketSession(t)
+	defer s.Close()
+
+	if err, _ := LoadPacketProxyScriptSource("", "function onData() {}", s.Session, nil); err == nil {
+		t.Fatalf("expected error for a script without onPacket")
+	}
+}
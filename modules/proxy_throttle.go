@@ -0,0 +1,157 @@
+package modules
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+const maxThrottleBurst = 0xffff
+
+// tokenBucket limits a stream to rate bytes per second, allowing
+// bursts of a tenth of a second worth of data.
+type tokenBucket struct {
+	sync.Mutex
+	rate   int
+	burst  int
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate int) *tokenBucket {
+	burst := rate / 10
+	if burst < 1 {
+		burst = 1
+	} else if burst > maxThrottleBurst {
+		burst = maxThrottleBurst
+	}
+
+	return &tokenBucket{
+		rate:   rate,
+		burst:  burst,
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// take consumes n tokens and returns how long the caller has to wait
+// before using them, so that the lock is never held while sleeping.
+func (b *tokenBucket) take(n int) time.Duration {
+	b.Lock()
+	defer b.Unlock()
+
+	now := time.Now()
+	if now.After(b.last) {
+		b.tokens += now.Sub(b.last).Seconds() * float64(b.rate)
+		if b.tokens > float64(b.burst) {
+			b.tokens = float64(b.burst)
+		}
+		b.last = now
+	}
+
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	// last moves to when the debt, including the one of
+	// the callers still sleeping, will be paid
+	b.last = b.last.Add(time.Duration(-b.tokens / float64(b.rate) * float64(time.Second)))
+	b.tokens = 0
+	return b.last.Sub(now)
+}
+
+// backlog returns how long the data already taken has to wait.
+func (b *tokenBucket) backlog() time.Duration {
+	b.Lock()
+	defer b.Unlock()
+
+	if d := time.Until(b.last); d > 0 {
+		return d
+	}
+	return 0
+}
+
+func (b *tokenBucket) wait(n int) {
+	if d := b.take(n); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// throttledConn limits the bandwidth of each direction of a
+// connection independently.
+type throttledConn struct {
+	net.Conn
+	in  *tokenBucket
+	out *tokenBucket
+}
+
+// newThrottledConn returns the connection unchanged if the rate is not positive.
+func newThrottledConn(c net.Conn, rate int) net.Conn {
+	if rate <= 0 {
+		return c
+	}
+	return &throttledConn{
+		Conn: c,
+		in:   newTokenBucket(rate),
+		out:  newTokenBucket(rate),
+	}
+}
+
+func (c *throttledConn) Read(b []byte) (int, error) {
+	if len(b) > c.in.burst {
+		b = b[:c.in.burst]
+	}
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.in.wait(n)
+	}
+	return n, err
+}
+
+func (c *throttledConn) Write(b []byte) (written int, err error) {
+	for len(b) > 0 {
+		chunk := b
+		if len(chunk) > c.out.burst {
+			chunk = chunk[:c.out.burst]
+		}
+
+		c.out.wait(len(chunk))
+		n, err := c.Conn.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		b = b[n:]
+	}
+	return written, nil
+}
+
+// CloseWrite propagates half-closes to the wrapped connection.
+func (c *throttledConn) CloseWrite() error {
+	if cw, ok := c.Conn.(interface{ CloseWrite() error }); ok {
+		return cw.CloseWrite()
+	}
+	return nil
+}
+
+// throttledListener throttles every accepted connection on its own.
+type throttledListener struct {
+	net.Listener
+	rate int
+}
+
+func newThrottledListener(l net.Listener, rate int) net.Listener {
+	if rate <= 0 {
+		return l
+	}
+	return &throttledListener{l, rate}
+}
+
+func (l *throttledListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return newThrottledConn(c, l.rate), nil
+}
@@ -0,0 +1,110 @@
+package modules
+
+import (
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bettercap/bettercap/session"
+)
+
+func TestTokenBucket(t *testing.T) {
+	b := newTokenBucket(1000)
+	if b.burst != 100 {
+		t.Fatalf("expected burst of 100, got %d", b.burst)
+	} else if d := b.take(100); d != 0 {
+		t.Fatalf("expected no wait for the first burst, got %s", d)
+	} else if d := b.take(100); d < 90*time.Millisecond || d > 110*time.Millisecond {
+		t.Fatalf("expected to wait ~100ms, got %s", d)
+	} else if d := b.take(50); d < 140*time.Millisecond || d > 160*time.Millisecond {
+		t.Fatalf("expected the previous debt to be accounted, got %s", d)
+	}
+
+	if b := newTokenBucket(5); b.burst != 1 {
+		t.Fatalf("expected burst of 1, got %d", b.burst)
+	} else if b := newTokenBucket(100 * 1024 * 1024); b.burst != maxThrottleBurst {
+		t.Fatalf("expected burst of %d, got %d", maxThrottleBurst, b.burst)
+	}
+}
+
+func TestNewThrottledConnUnlimited(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	if c := newThrottledConn(a, 0); c != a {
+		t.Fatal("expected the connection not to be wrapped")
+	}
+}
+
+func TestTcpProxyThrottleHalfClose(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	// replies only once the client is done sending
+	origin, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer origin.Close()
+
+	go func() {
+		c, err := origin.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		if data, err := ioutil.ReadAll(c); err == nil {
+			c.Write([]byte(strings.ToUpper(string(data))))
+		}
+	}()
+
+	front, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer front.Close()
+
+	p := NewTcpProxy(s.Session)
+	p.remoteAddr = origin.Addr().(*net.TCPAddr)
+	p.tunnelAddr = &net.TCPAddr{}
+	p.bandwidth = 1000
+
+	go func() {
+		if c, err := front.Accept(); err == nil {
+			p.handleConnection(c.(*net.TCPConn))
+		}
+	}()
+
+	conn, err := net.Dial("tcp", front.Addr().String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	started := time.Now()
+	sent := strings.Repeat("slow link ", 30)
+	if _, err := conn.Write([]byte(sent)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := conn.(*net.TCPConn).CloseWrite(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := ioutil.ReadAll(conn)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if exp := strings.ToUpper(sent); string(got) != exp {
+		t.Fatalf("expected '%s', got '%s'", exp, got)
+	}
+
+	// 300 bytes each way, the first 100 are free
+	if elapsed := time.Since(started); elapsed < 400*time.Millisecond {
+		t.Fatalf("expected the connection to be throttled, took %s", elapsed)
+	}
+}
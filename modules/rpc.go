@@ -0,0 +1,406 @@
+package modules
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/session"
+)
+
+const (
+	rpcVersion = "2.0"
+
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcCommandError   = -32000
+)
+
+type rpcRequest struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcNotification struct {
+	Version string        `json:"jsonrpc"`
+	Method  string        `json:"method"`
+	Params  session.Event `json:"params"`
+}
+
+type rpcModuleState struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Running     bool              `json:"running"`
+	Paused      bool              `json:"paused"`
+	Parameters  map[string]string `json:"parameters"`
+}
+
+// one client connected to the socket, responses and events are
+// written by different goroutines
+type rpcClient struct {
+	sync.Mutex
+	conn    net.Conn
+	encoder *json.Encoder
+	tags    []string
+	events  <-chan session.Event
+	// stops the delivery of the events
+	unsubscribe func()
+	// events dropped because the client was too slow
+	dropped uint64
+}
+
+func (c *rpcClient) send(o interface{}) error {
+	c.Lock()
+	defer c.Unlock()
+	return c.encoder.Encode(o)
+}
+
+func (c *rpcClient) wants(tag string) bool {
+	if len(c.tags) == 0 {
+		return true
+	}
+	for _, prefix := range c.tags {
+		if strings.HasPrefix(tag, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// RPCServer accepts JSON-RPC 2.0 requests, one per line, on a unix socket
+// only accessible by the user running bettercap.
+type RPCServer struct {
+	session.SessionModule
+	path     string
+	listener net.Listener
+	clients  map[*rpcClient]bool
+	lock     *sync.Mutex
+	wg       *sync.WaitGroup
+}
+
+func NewRPCServer(s *session.Session) *RPCServer {
+	rpc := &RPCServer{
+		SessionModule: session.NewSessionModule("rpc", s),
+		clients:       make(map[*rpcClient]bool),
+		lock:          &sync.Mutex{},
+		wg:            &sync.WaitGroup{},
+	}
+
+	rpc.AddParam(session.NewStringParameter("rpc.socket",
+		"",
+		"",
+		"Path of the unix socket to accept JSON-RPC requests on, created with 0600 permissions."))
+
+	rpc.AddHandler(session.NewModuleHandler("rpc on", "",
+		"Start the JSON-RPC server on rpc.socket.",
+		func(args []string) error {
+			return rpc.Start()
+		}))
+
+	rpc.AddHandler(session.NewModuleHandler("rpc off", "",
+		"Stop the JSON-RPC server and disconnect its clients.",
+		func(args []string) error {
+			return rpc.Stop()
+		}))
+
+	return rpc
+}
+
+func (rpc *RPCServer) Name() string {
+	return "rpc"
+}
+
+func (rpc *RPCServer) Description() string {
+	return "A JSON-RPC server on a unix socket exposing the command.run, module.state and events.subscribe methods."
+}
+
+func (rpc *RPCServer) Author() string {
+	return "Simone Margaritelli <evilsocket@protonmail.com>"
+}
+
+func (rpc *RPCServer) Configure() (err error) {
+	if rpc.Running() {
+		return session.ErrAlreadyStarted
+	} else if err, rpc.path = rpc.StringParam("rpc.socket"); err != nil {
+		return err
+	} else if rpc.path == "" {
+		return fmt.Errorf("rpc.socket is empty, set it to the path of the socket to create.")
+	} else if rpc.path, err = core.ExpandPath(rpc.path); err != nil {
+		return err
+	}
+
+	// only replace leftovers of a previous session
+	if info, err := os.Lstat(rpc.path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return fmt.Errorf("%s exists and is not a socket.", rpc.path)
+		} else if err := os.Remove(rpc.path); err != nil {
+			return err
+		}
+	}
+
+	if rpc.listener, err = net.Listen("unix", rpc.path); err != nil {
+		return err
+	} else if err = os.Chmod(rpc.path, 0600); err != nil {
+		rpc.listener.Close()
+		return err
+	}
+
+	return nil
+}
+
+func (rpc *RPCServer) Start() error {
+	if err := rpc.Configure(); err != nil {
+		return err
+	}
+
+	return rpc.SetRunning(true, func() {
+		log.Info("JSON-RPC server listening on %s", core.Bold(rpc.path))
+		for {
+			conn, err := rpc.listener.Accept()
+			if err != nil {
+				if rpc.Running() {
+					log.Error("Error while accepting JSON-RPC connection: %s", err)
+				}
+				return
+			}
+
+			rpc.wg.Add(1)
+			go rpc.serve(conn)
+		}
+	})
+}
+
+func (rpc *RPCServer) Stop() error {
+	return rpc.SetRunning(false, func() {
+		rpc.listener.Close()
+
+		rpc.lock.Lock()
+		for client := range rpc.clients {
+			client.conn.Close()
+		}
+		rpc.lock.Unlock()
+
+		rpc.wg.Wait()
+	})
+}
+
+func (rpc *RPCServer) serve(conn net.Conn) {
+	defer rpc.wg.Done()
+
+	client := &rpcClient{
+		conn:    conn,
+		encoder: json.NewEncoder(conn),
+	}
+
+	rpc.lock.Lock()
+	rpc.clients[client] = true
+	rpc.lock.Unlock()
+
+	log.Debug("JSON-RPC client connected.")
+
+	defer func() {
+		rpc.lock.Lock()
+		delete(rpc.clients, client)
+		rpc.lock.Unlock()
+
+		client.Lock()
+		if client.unsubscribe != nil {
+			client.dropped = rpc.Session.Events.Dropped(client.events)
+			client.unsubscribe()
+		}
+		client.Unlock()
+		conn.Close()
+		log.Debug("JSON-RPC client disconnected (%d events dropped).", client.dropped)
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 4096), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		resp := rpc.handle(client, []byte(line))
+		if resp != nil {
+			if err := client.send(resp); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func rpcFail(id json.RawMessage, code int, format string, args ...interface{}) *rpcResponse {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &rpcResponse{
+		Version: rpcVersion,
+		ID:      id,
+		Error: &rpcError{
+			Code:    code,
+			Message: fmt.Sprintf(format, args...),
+		},
+	}
+}
+
+// handle runs a single request and returns its response, or nil if
+// the request is a notification.
+func (rpc *RPCServer) handle(client *rpcClient, raw []byte) *rpcResponse {
+	var req rpcRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return rpcFail(nil, rpcParseError, "Parse error: %s", err)
+	} else if req.Version != rpcVersion || req.Method == "" {
+		return rpcFail(req.ID, rpcInvalidRequest, "Invalid request.")
+	}
+
+	var result interface{}
+	var fail *rpcResponse
+
+	switch req.Method {
+	case "command.run":
+		result, fail = rpc.runCommand(req)
+	case "module.state":
+		result, fail = rpc.moduleState(req)
+	case "events.subscribe":
+		result, fail = rpc.subscribe(client, req)
+	default:
+		fail = rpcFail(req.ID, rpcMethodNotFound, "Method %s not found.", req.Method)
+	}
+
+	if req.ID == nil {
+		return nil
+	} else if fail != nil {
+		return fail
+	}
+
+	return &rpcResponse{
+		Version: rpcVersion,
+		ID:      req.ID,
+		Result:  result,
+	}
+}
+
+func (rpc *RPCServer) runCommand(req rpcRequest) (interface{}, *rpcResponse) {
+	var params struct {
+		Command string `json:"cmd"`
+	}
+
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.Command == "" {
+		return nil, rpcFail(req.ID, rpcInvalidParams, "command.run expects {\"cmd\": \"COMMAND\"}.")
+	} else if err := rpc.Session.RunFrom(session.OriginRPC, params.Command); err != nil {
+		return nil, rpcFail(req.ID, rpcCommandError, "%s", err)
+	}
+
+	return true, nil
+}
+
+func (rpc *RPCServer) stateOf(m session.Module) rpcModuleState {
+	params := make(map[string]string)
+	for name := range m.Parameters() {
+		_, params[name] = rpc.Session.Env.Get(name)
+	}
+
+	return rpcModuleState{
+		Name:        m.Name(),
+		Description: m.Description(),
+		Running:     m.Running(),
+		Paused:      m.Paused(),
+		Parameters:  params,
+	}
+}
+
+func (rpc *RPCServer) moduleState(req rpcRequest) (interface{}, *rpcResponse) {
+	var params struct {
+		Name string `json:"name"`
+	}
+
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, rpcFail(req.ID, rpcInvalidParams, "module.state expects {\"name\": \"MODULE\"}.")
+		}
+	}
+
+	if params.Name == "" {
+		states := make([]rpcModuleState, 0, len(rpc.Session.Modules))
+		for _, m := range rpc.Session.Modules {
+			states = append(states, rpc.stateOf(m))
+		}
+		return states, nil
+	}
+
+	err, m := rpc.Session.Module(params.Name)
+	if err != nil {
+		return nil, rpcFail(req.ID, rpcInvalidParams, "%s", err)
+	}
+	return rpc.stateOf(m), nil
+}
+
+// subscribe streams the events whose tag starts with any of the given
+// ones, or every event if none, as event notifications.
+func (rpc *RPCServer) subscribe(client *rpcClient, req rpcRequest) (interface{}, *rpcResponse) {
+	var params struct {
+		Tags []string `json:"tags"`
+	}
+
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, rpcFail(req.ID, rpcInvalidParams, "events.subscribe expects {\"tags\": [\"PREFIX\", ...]}.")
+		}
+	}
+
+	client.Lock()
+	client.tags = params.Tags
+	subscribed := client.events != nil
+	if !subscribed {
+		// buffered and never blocking the event pool, the oldest events
+		// are dropped if the client can't keep up
+		client.events, client.unsubscribe = rpc.Session.Events.Subscribe("")
+	}
+	events := client.events
+	client.Unlock()
+
+	if subscribed {
+		return true, nil
+	}
+
+	go func() {
+		for event := range events {
+			client.Lock()
+			wanted := client.wants(event.Tag)
+			client.Unlock()
+
+			if wanted {
+				client.send(rpcNotification{
+					Version: rpcVersion,
+					Method:  "event",
+					Params:  event,
+				})
+			}
+		}
+	}()
+
+	return true, nil
+}
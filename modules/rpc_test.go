@@ -0,0 +1,141 @@
+package modules
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bettercap/bettercap/session"
+)
+
+type rpcTestClient struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func (c *rpcTestClient) call(line string) map[string]interface{} {
+	if _, err := c.conn.Write([]byte(line + "\n")); err != nil {
+		c.t.Fatalf("unexpected error: %v", err)
+	}
+	return c.read()
+}
+
+func (c *rpcTestClient) read() map[string]interface{} {
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	raw, err := c.reader.ReadBytes('\n')
+	if err != nil {
+		c.t.Fatalf("unexpected error: %v", err)
+	}
+
+	msg := make(map[string]interface{})
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		c.t.Fatalf("unexpected error: %v", err)
+	}
+	return msg
+}
+
+func rpcErrorCode(msg map[string]interface{}) int {
+	if e, ok := msg["error"].(map[string]interface{}); ok {
+		return int(e["code"].(float64))
+	}
+	return 0
+}
+
+func TestRPCServer(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	dir, err := ioutil.TempDir("", "rpc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	echoed := ""
+	s.CoreHandlers = append(s.CoreHandlers, session.NewCommandHandler("echo MSG", `^echo\s+(.+)$`, "Echo.",
+		func(args []string, sess *session.Session) error {
+			echoed = args[0]
+			return nil
+		}))
+
+	rpc := NewRPCServer(s.Session)
+	s.Register(rpc)
+
+	path := filepath.Join(dir, "rpc.sock")
+	if err := s.Handle("rpc", "rpc on"); err == nil {
+		t.Fatal("expected error without rpc.socket")
+	} else if err := s.Set("rpc.socket", path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Handle("rpc", "rpc on"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer rpc.Stop()
+
+	if info, err := os.Stat(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if perm := info.Mode().Perm(); perm != 0600 {
+		t.Fatalf("expected '%o', got '%o'", 0600, perm)
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer conn.Close()
+	c := &rpcTestClient{t: t, conn: conn, reader: bufio.NewReader(conn)}
+
+	var units = []struct {
+		req  string
+		code int
+	}{
+		{`nope`, rpcParseError},
+		{`{"id": 1, "method": "command.run"}`, rpcInvalidRequest},
+		{`{"jsonrpc": "2.0", "id": 1, "method": "nope"}`, rpcMethodNotFound},
+		{`{"jsonrpc": "2.0", "id": 1, "method": "command.run", "params": {}}`, rpcInvalidParams},
+		{`{"jsonrpc": "2.0", "id": 1, "method": "command.run", "params": {"cmd": "nope"}}`, rpcCommandError},
+		{`{"jsonrpc": "2.0", "id": 1, "method": "module.state", "params": {"name": "nope"}}`, rpcInvalidParams},
+		{`{"jsonrpc": "2.0", "id": 1, "method": "command.run", "params": {"cmd": "echo hello"}}`, 0},
+	}
+
+	for _, u := range units {
+		if got := rpcErrorCode(c.call(u.req)); got != u.code {
+			t.Fatalf("expected '%d' for '%s', got '%d'", u.code, u.req, got)
+		}
+	}
+
+	if echoed != "hello" {
+		t.Fatalf("expected 'hello', got '%s'", echoed)
+	}
+
+	state := c.call(`{"jsonrpc": "2.0", "id": "state", "method": "module.state", "params": {"name": "rpc"}}`)
+	if state["id"] != "state" {
+		t.Fatalf("expected 'state', got '%v'", state["id"])
+	} else if result := state["result"].(map[string]interface{}); result["running"] != true {
+		t.Fatalf("expected module to be running, got '%v'", result)
+	} else if params := result["parameters"].(map[string]interface{}); params["rpc.socket"] != path {
+		t.Fatalf("expected '%s', got '%v'", path, params["rpc.socket"])
+	}
+
+	if resp := c.call(`{"jsonrpc": "2.0", "id": 2, "method": "events.subscribe", "params": {"tags": ["test."]}}`); resp["result"] != true {
+		t.Fatalf("expected subscription, got '%v'", resp)
+	}
+
+	s.Events.Add("other.event", nil)
+	s.Events.Add("test.event", "data")
+
+	event := c.read()
+	if event["method"] != "event" {
+		t.Fatalf("expected 'event', got '%v'", event["method"])
+	} else if params := event["params"].(map[string]interface{}); params["tag"] != "test.event" || params["data"] != "data" {
+		t.Fatalf("expected test.event, got '%v'", params)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"net"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bettercap/bettercap/core"
@@ -21,12 +22,33 @@ import (
 
 const synSourcePort = 666
 
+// synScanTarget is an address to scan and the hardware address the
+// probes are sent to, the gateway one if it's not in our subnet.
+type synScanTarget struct {
+	IP string
+	to net.IP
+	hw net.HardwareAddr
+}
+
 type SynScanner struct {
 	session.SessionModule
-	addresses []net.IP
-	startPort int
-	endPort   int
-	waitGroup *sync.WaitGroup
+	addresses   []net.IP
+	startPort   int
+	endPort     int
+	concurrency int
+	rate        int
+	udp         bool
+	banners     bool
+	timeout     time.Duration
+	targets     map[string]*synScanTarget
+	results     *synScanResults
+	lock        *sync.Mutex
+	pool        *core.WorkerPool
+	limiter     *time.Ticker
+	quit        chan struct{}
+	waitGroup   *sync.WaitGroup
+	// number of probes sent (or skipped) so far
+	probed int32
 }
 
 func NewSynScanner(s *session.Session) *SynScanner {
@@ -35,9 +57,32 @@ func NewSynScanner(s *session.Session) *SynScanner {
 		addresses:     make([]net.IP, 0),
 		startPort:     0,
 		endPort:       0,
+		targets:       make(map[string]*synScanTarget),
+		results:       newSynScanResults(),
+		lock:          &sync.Mutex{},
 		waitGroup:     &sync.WaitGroup{},
 	}
 
+	ss.AddParam(session.NewIntParameter("syn.scan.concurrency",
+		"16",
+		"Number of workers resolving the addresses and sending the probes concurrently."))
+
+	ss.AddParam(session.NewIntParameter("syn.scan.rate",
+		"0",
+		"Maximum number of probes sent per second, 0 for no limit."))
+
+	ss.AddParam(session.NewBoolParameter("syn.scan.udp",
+		"false",
+		"If true, the common UDP services within the ports range are probed as well."))
+
+	ss.AddParam(session.NewBoolParameter("syn.scan.banners",
+		"false",
+		"If true, the banners of the open TCP ports are grabbed to detect their services once every probe has been sent."))
+
+	ss.AddParam(session.NewDurationParameter("syn.scan.timeout",
+		"2s",
+		"How long to wait for the late responses once every probe has been sent, and for each banner."))
+
 	ss.AddHandler(session.NewModuleHandler("syn.scan IP-RANGE [START-PORT] [END-PORT]", "syn.scan ([^\\s]+) ?(\\d+)?([\\s\\d]*)?",
 		"Perform a syn port scanning against an IP address within the provided ports range.",
 		func(args []string) error {
@@ -79,6 +124,12 @@ func NewSynScanner(s *session.Session) *SynScanner {
 			return ss.synScan()
 		}))
 
+	ss.AddHandler(session.NewModuleHandler("syn.scan.export FILE", `syn\.scan\.export\s+(.+)`,
+		"Export the open ports found by the last scan to FILE as JSON, grouped by host.",
+		func(args []string) error {
+			return ss.results.Export(core.Trim(args[0]), ss.startPort, ss.endPort)
+		}))
+
 	return ss
 }
 
@@ -87,14 +138,29 @@ func (s *SynScanner) Name() string {
 }
 
 func (s *SynScanner) Description() string {
-	return "A module to perform SYN port scanning."
+	return "A module to perform SYN port scanning, UDP probing and service detection."
 }
 
 func (s *SynScanner) Author() string {
 	return "Simone Margaritelli <evilsocket@protonmail.com>"
 }
 
-func (s *SynScanner) Configure() error {
+func (s *SynScanner) Configure() (err error) {
+	if err, s.concurrency = s.IntParam("syn.scan.concurrency"); err != nil {
+		return err
+	} else if err, s.rate = s.IntParam("syn.scan.rate"); err != nil {
+		return err
+	} else if err, s.udp = s.BoolParam("syn.scan.udp"); err != nil {
+		return err
+	} else if err, s.banners = s.BoolParam("syn.scan.banners"); err != nil {
+		return err
+	} else if err, s.timeout = s.DurationParam("syn.scan.timeout"); err != nil {
+		return err
+	} else if s.rate < 0 {
+		return fmt.Errorf("syn.scan.rate can't be negative.")
+	} else if s.timeout <= 0 {
+		return fmt.Errorf("syn.scan.timeout must be greater than 0.")
+	}
 	return nil
 }
 
@@ -102,19 +168,44 @@ func (s *SynScanner) Start() error {
 	return nil
 }
 
-func (s *SynScanner) inRange(ip net.IP) bool {
-	for _, a := range s.addresses {
-		if a.Equal(ip) {
-			return true
-		}
+func (s *SynScanner) hostFor(ip net.IP) *network.Endpoint {
+	if ip.Equal(s.Session.Interface.IP) {
+		return s.Session.Interface
+	} else if ip.Equal(s.Session.Gateway.IP) {
+		return s.Session.Gateway
+	}
+	return s.Session.Lan.GetByIp(ip.String())
+}
+
+// onOpen records an open port, the first time it's found.
+func (s *SynScanner) onOpen(ip net.IP, proto string, port int, service string, banner string) {
+	from := ip.String()
+	if !s.results.Add(from, proto, port) {
+		return
 	}
-	return false
+
+	host := s.hostFor(ip)
+	if host != nil {
+		ports := host.Meta.GetIntsWith(proto+"-ports", port, true)
+		host.Meta.SetInts(proto+"-ports", ports)
+		s.results.SetHost(from, host.HwAddress, host.Hostname, host.Vendor)
+	}
+
+	event := NewSynScanEvent(from, host, port)
+	event.Protocol = proto
+	if service != "" {
+		event.Service = service
+		event.Banner = banner
+		s.results.SetService(from, proto, port, service, banner, "")
+	}
+	event.Push()
 }
 
 func (s *SynScanner) onPacket(pkt gopacket.Packet) {
 	var eth layers.Ethernet
 	var ip layers.IPv4
 	var tcp layers.TCP
+	var udp layers.UDP
 	foundLayerTypes := []gopacket.LayerType{}
 
 	parser := gopacket.NewDecodingLayerParser(
@@ -122,42 +213,266 @@ func (s *SynScanner) onPacket(pkt gopacket.Packet) {
 		&eth,
 		&ip,
 		&tcp,
+		&udp,
 	)
 
+	// the payloads of the UDP responses can't be decoded by the parser
 	err := parser.DecodeLayers(pkt.Data(), &foundLayerTypes)
-	if err != nil {
+	if _, unsupported := err.(gopacket.UnsupportedLayerType); err != nil && !unsupported {
+		return
+	} else if _, found := s.targets[ip.SrcIP.String()]; !found {
 		return
 	}
 
-	if s.inRange(ip.SrcIP) && tcp.DstPort == synSourcePort && tcp.SYN && tcp.ACK {
-		from := ip.SrcIP.String()
-		port := int(tcp.SrcPort)
+	for _, layer := range foundLayerTypes {
+		switch layer {
+		case layers.LayerTypeTCP:
+			if tcp.DstPort == synSourcePort && tcp.SYN && tcp.ACK {
+				s.onOpen(ip.SrcIP, "tcp", int(tcp.SrcPort), "", "")
+			}
+		case layers.LayerTypeUDP:
+			if udp.DstPort != synSourcePort {
+				continue
+			} else if probe := udpProbeByPort(int(udp.SrcPort)); probe != nil {
+				s.onOpen(ip.SrcIP, "udp", probe.Port, probe.Service, fuzzBanner(udp.Payload))
+			}
+		}
+	}
+}
+
+func (s *SynScanner) udpProbes() []*udpProbe {
+	if !s.udp {
+		return nil
+	}
+	return udpProbesIn(s.startPort, s.endPort)
+}
 
-		var host *network.Endpoint
-		if ip.SrcIP.Equal(s.Session.Interface.IP) {
-			host = s.Session.Interface
-		} else if ip.SrcIP.Equal(s.Session.Gateway.IP) {
-			host = s.Session.Gateway
-		} else {
-			host = s.Session.Lan.GetByIp(from)
+func (s *SynScanner) probesPerAddress() int {
+	return s.endPort - s.startPort + 1 + len(s.udpProbes())
+}
+
+func (s *SynScanner) totalProbes() int {
+	return len(s.addresses) * s.probesPerAddress()
+}
+
+func (s *SynScanner) trackProgress(probes int) {
+	done := atomic.AddInt32(&s.probed, int32(probes))
+	s.Progress("SYN scanning", int(done), s.totalProbes())
+}
+
+func (s *SynScanner) stopped() bool {
+	select {
+	case <-s.quit:
+		return true
+	default:
+		return !s.Running()
+	}
+}
+
+// pace blocks until the next probe can be sent, it returns false if the
+// scan has been stopped meanwhile.
+func (s *SynScanner) pace() bool {
+	if s.limiter == nil {
+		return !s.stopped()
+	}
+
+	select {
+	case <-s.limiter.C:
+		return !s.stopped()
+	case <-s.quit:
+		return false
+	}
+}
+
+// run executes the jobs submitted by submit on a new worker pool, which
+// gets canceled by Stop.
+func (s *SynScanner) run(submit func(pool *core.WorkerPool)) error {
+	pool := core.NewWorkerPool(s.concurrency)
+
+	s.lock.Lock()
+	s.pool = pool
+	s.lock.Unlock()
+
+	if s.stopped() {
+		pool.Cancel()
+	} else {
+		submit(pool)
+	}
+	return pool.Wait()
+}
+
+// resolve finds the hardware addresses the probes are sent to.
+func (s *SynScanner) resolve(address net.IP) *synScanTarget {
+	target := &synScanTarget{IP: address.String(), to: address}
+	if s.Session.Interface.Net != nil && !s.Session.Interface.Net.Contains(address) {
+		target.hw = s.Session.Gateway.HW
+	} else if mac, err := findMAC(s.Session, address, true); err != nil {
+		log.Debug("Could not get MAC for %s: %s", address.String(), err)
+		return nil
+	} else {
+		target.hw = mac
+	}
+	return target
+}
+
+func (s *SynScanner) resolveTargets() error {
+	targets := make(map[string]*synScanTarget)
+	lock := &sync.Mutex{}
+
+	err := s.run(func(pool *core.WorkerPool) {
+		for _, address := range s.addresses {
+			address := address
+			if !pool.Submit(func() error {
+				if target := s.resolve(address); target != nil {
+					lock.Lock()
+					targets[target.IP] = target
+					lock.Unlock()
+				} else {
+					s.trackProgress(s.probesPerAddress())
+				}
+				return nil
+			}) {
+				break
+			}
 		}
+	})
+
+	s.targets = targets
+	return err
+}
 
-		if host != nil {
-			ports := host.Meta.GetIntsWith("tcp-ports", port, true)
-			host.Meta.SetInts("tcp-ports", ports)
+func (s *SynScanner) send(raw []byte, err error, what string, target *synScanTarget, port int) {
+	if err != nil {
+		log.Error("Error creating %s packet: %s", what, err)
+	} else if err := s.Session.Queue.Send(raw); err != nil {
+		log.Error("Error sending %s packet: %s", what, err)
+	} else {
+		log.Debug("Sent %d bytes of %s packet to %s for port %d", len(raw), what, target.IP, port)
+	}
+}
+
+func (s *SynScanner) probeTCP(target *synScanTarget, port int) error {
+	if s.pace() {
+		err, raw := packets.NewTCPSyn(s.Session.Interface.IP, s.Session.Interface.HW, target.to, target.hw, synSourcePort, port)
+		s.send(raw, err, "SYN", target, port)
+	}
+	s.trackProgress(1)
+	return nil
+}
+
+func (s *SynScanner) probeUDP(target *synScanTarget, probe *udpProbe) error {
+	if s.pace() {
+		err, raw := packets.NewUDPTo(s.Session.Interface.IP, s.Session.Interface.HW, target.to, target.hw, synSourcePort, probe.Port, probe.Payload)
+		s.send(raw, err, "UDP", target, probe.Port)
+	}
+	s.trackProgress(1)
+	return nil
+}
+
+// sendProbes sends every probe, ports first so that the load is spread
+// across the targets.
+func (s *SynScanner) sendProbes() error {
+	targets := make([]*synScanTarget, 0, len(s.targets))
+	for _, address := range s.addresses {
+		if target, found := s.targets[address.String()]; found {
+			targets = append(targets, target)
 		}
+	}
 
-		NewSynScanEvent(from, host, port).Push()
+	return s.run(func(pool *core.WorkerPool) {
+		for dstPort := s.startPort; dstPort < s.endPort+1; dstPort++ {
+			for _, target := range targets {
+				target, port := target, dstPort
+				if !pool.Submit(func() error { return s.probeTCP(target, port) }) {
+					return
+				}
+			}
+		}
+
+		for _, probe := range s.udpProbes() {
+			for _, target := range targets {
+				target, probe := target, probe
+				if !pool.Submit(func() error { return s.probeUDP(target, probe) }) {
+					return
+				}
+			}
+		}
+	})
+}
+
+func (s *SynScanner) grabBanner(address string, port int) error {
+	if s.stopped() {
+		return nil
+	}
+
+	greeting, response, err := grabService(address, port, s.timeout)
+	if err != nil {
+		log.Debug("Could not grab the banner of %s:%d: %s", address, port, err)
+		return nil
+	}
+
+	service := "unknown"
+	proto, banner, version := fuzzIdentify(port, greeting, response)
+	if proto != nil {
+		service = proto.Name
+	}
+	s.results.SetService(address, "tcp", port, service, banner, version)
+
+	host := s.hostFor(net.ParseIP(address))
+	if host != nil {
+		desc := service
+		if version != "" {
+			desc = fmt.Sprintf("%s (%s)", service, version)
+		}
+		host.Meta.Set(fmt.Sprintf("tcp-%d", port), desc)
 	}
+
+	event := NewSynScanEvent(address, host, port)
+	event.Service = service
+	event.Banner = banner
+	event.Version = version
+	event.Push()
+	return nil
+}
+
+func (s *SynScanner) grabBanners() error {
+	return s.run(func(pool *core.WorkerPool) {
+		for _, host := range s.results.List() {
+			for _, port := range s.results.Open(host.Address, "tcp") {
+				address, port := host.Address, port
+				if !pool.Submit(func() error { return s.grabBanner(address, port) }) {
+					return
+				}
+			}
+		}
+	})
 }
 
 func (s *SynScanner) synScan() error {
+	if err := s.Configure(); err != nil {
+		return err
+	}
+
+	s.probed = 0
+	s.results = newSynScanResults()
+	s.targets = make(map[string]*synScanTarget)
+	s.quit = make(chan struct{})
+	s.limiter = nil
+	if s.rate > 0 {
+		s.limiter = time.NewTicker(time.Second / time.Duration(s.rate))
+	}
+	s.StartProgress("SYN scanning", s.totalProbes())
+
 	s.SetRunning(true, func() {
 		defer s.SetRunning(false, nil)
 
 		s.waitGroup.Add(1)
 		defer s.waitGroup.Done()
 
+		if s.limiter != nil {
+			defer s.limiter.Stop()
+		}
+
 		naddrs := len(s.addresses)
 		plural := "es"
 		if naddrs == 1 {
@@ -170,42 +485,40 @@ func (s *SynScanner) synScan() error {
 			log.Info("SYN scanning %d address%s on port %d ...", naddrs, plural, s.startPort)
 		}
 
+		started := time.Now()
+		if err := s.resolveTargets(); err != nil {
+			log.Error("Error while resolving the addresses: %s", err)
+			return
+		}
+
 		// set the collector
 		s.Session.Queue.OnPacket(s.onPacket)
 		defer s.Session.Queue.OnPacket(nil)
 
-		// start sending SYN packets and wait
-		for _, address := range s.addresses {
-			if !s.Running() {
-				break
-			}
-			mac, err := findMAC(s.Session, address, true)
-			if err != nil {
-				log.Debug("Could not get MAC for %s: %s", address.String(), err)
-				continue
-			}
-
-			for dstPort := s.startPort; dstPort < s.endPort+1; dstPort++ {
-				if !s.Running() {
-					break
-				}
+		if err := s.sendProbes(); err != nil {
+			log.Error("Error while scanning: %s", err)
+			return
+		}
 
-				err, raw := packets.NewTCPSyn(s.Session.Interface.IP, s.Session.Interface.HW, address, mac, synSourcePort, dstPort)
-				if err != nil {
-					log.Error("Error creating SYN packet: %s", err)
-					continue
-				}
+		// and wait for the responses
+		select {
+		case <-time.After(s.timeout):
+		case <-s.quit:
+			return
+		}
 
-				if err := s.Session.Queue.Send(raw); err != nil {
-					log.Error("Error sending SYN packet: %s", err)
-				} else {
-					log.Debug("Sent %d bytes of SYN packet to %s for port %d", len(raw), address.String(), dstPort)
-				}
+		if s.banners {
+			if err := s.grabBanners(); err != nil {
+				log.Error("Error while grabbing the banners: %s", err)
 			}
 		}
 
-		nports := s.endPort - s.startPort + 1
-		time.Sleep(time.Duration(nports*500) * time.Millisecond)
+		hosts := s.results.List()
+		nports := 0
+		for _, host := range hosts {
+			nports += len(host.Ports)
+		}
+		log.Info("SYN scan found %d open ports on %d hosts in %s.", nports, len(hosts), time.Since(started).Round(time.Millisecond))
 	})
 
 	return nil
@@ -213,6 +526,12 @@ func (s *SynScanner) synScan() error {
 
 func (s *SynScanner) Stop() error {
 	return s.SetRunning(false, func() {
+		s.lock.Lock()
+		close(s.quit)
+		if s.pool != nil {
+			s.pool.Cancel()
+		}
+		s.lock.Unlock()
 		s.waitGroup.Wait()
 	})
 }
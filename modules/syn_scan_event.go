@@ -6,20 +6,29 @@ import (
 )
 
 type SynScanEvent struct {
-	Address string
-	Host    *network.Endpoint
-	Port    int
+	Address  string
+	Host     *network.Endpoint
+	Port     int
+	Protocol string
+	// set by the banner grabbing or the UDP probes
+	Service string
+	Banner  string
+	Version string
 }
 
 func NewSynScanEvent(address string, h *network.Endpoint, port int) SynScanEvent {
 	return SynScanEvent{
-		Address: address,
-		Host:    h,
-		Port:    port,
+		Address:  address,
+		Host:     h,
+		Port:     port,
+		Protocol: "tcp",
 	}
 }
 
 func (e SynScanEvent) Push() {
-	session.I.Events.Add("syn.scan", e)
-	session.I.Refresh()
+	if e.Service != "" && e.Protocol == "tcp" {
+		session.I.Events.Add("syn.scan.service", e)
+	} else {
+		session.I.Events.Add("syn.scan", e)
+	}
 }
@@ -0,0 +1,178 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+)
+
+// SynScanPort is an open port found by syn.scan.
+type SynScanPort struct {
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"`
+	Service  string `json:"service,omitempty"`
+	Banner   string `json:"banner,omitempty"`
+	Version  string `json:"version,omitempty"`
+}
+
+// SynScanHost is a scanned host with at least an open port.
+type SynScanHost struct {
+	Address  string        `json:"address"`
+	MAC      string        `json:"mac,omitempty"`
+	Hostname string        `json:"hostname,omitempty"`
+	Vendor   string        `json:"vendor,omitempty"`
+	Ports    []SynScanPort `json:"ports"`
+}
+
+type synScanExport struct {
+	Started   time.Time     `json:"started"`
+	StartPort int           `json:"start_port"`
+	EndPort   int           `json:"end_port"`
+	Hosts     []SynScanHost `json:"hosts"`
+}
+
+// synScanResults are the open ports found by the last scan.
+type synScanResults struct {
+	sync.Mutex
+	started time.Time
+	hosts   map[string]*SynScanHost
+}
+
+func newSynScanResults() *synScanResults {
+	return &synScanResults{
+		started: time.Now(),
+		hosts:   make(map[string]*SynScanHost),
+	}
+}
+
+func (r *synScanResults) find(host *SynScanHost, proto string, port int) *SynScanPort {
+	for i := range host.Ports {
+		if host.Ports[i].Port == port && host.Ports[i].Protocol == proto {
+			return &host.Ports[i]
+		}
+	}
+	return nil
+}
+
+// Add records an open port and returns false if it was known already.
+func (r *synScanResults) Add(address string, proto string, port int) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	host, found := r.hosts[address]
+	if !found {
+		host = &SynScanHost{Address: address, Ports: make([]SynScanPort, 0)}
+		r.hosts[address] = host
+	} else if r.find(host, proto, port) != nil {
+		return false
+	}
+
+	host.Ports = append(host.Ports, SynScanPort{Port: port, Protocol: proto})
+	sort.Slice(host.Ports, func(i, j int) bool {
+		if host.Ports[i].Protocol != host.Ports[j].Protocol {
+			return host.Ports[i].Protocol < host.Ports[j].Protocol
+		}
+		return host.Ports[i].Port < host.Ports[j].Port
+	})
+	return true
+}
+
+// SetHost sets the details of the endpoint of address.
+func (r *synScanResults) SetHost(address string, mac string, hostname string, vendor string) {
+	r.Lock()
+	defer r.Unlock()
+
+	if host, found := r.hosts[address]; found {
+		host.MAC = mac
+		host.Hostname = hostname
+		host.Vendor = vendor
+	}
+}
+
+// SetService sets the service detected on an open port.
+func (r *synScanResults) SetService(address string, proto string, port int, service string, banner string, version string) {
+	r.Lock()
+	defer r.Unlock()
+
+	if host, found := r.hosts[address]; found {
+		if p := r.find(host, proto, port); p != nil {
+			p.Service = service
+			p.Banner = banner
+			p.Version = version
+		}
+	}
+}
+
+// Open returns the open ports of address using protocol proto.
+func (r *synScanResults) Open(address string, proto string) []int {
+	r.Lock()
+	defer r.Unlock()
+
+	ports := make([]int, 0)
+	if host, found := r.hosts[address]; found {
+		for _, p := range host.Ports {
+			if p.Protocol == proto {
+				ports = append(ports, p.Port)
+			}
+		}
+	}
+	return ports
+}
+
+// List returns a copy of the hosts sorted by address.
+func (r *synScanResults) List() []SynScanHost {
+	r.Lock()
+	defer r.Unlock()
+
+	list := make([]SynScanHost, 0, len(r.hosts))
+	for _, host := range r.hosts {
+		cp := *host
+		cp.Ports = append([]SynScanPort(nil), host.Ports...)
+		list = append(list, cp)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		a, b := net.ParseIP(list[i].Address).To4(), net.ParseIP(list[j].Address).To4()
+		if a == nil || b == nil {
+			return list[i].Address < list[j].Address
+		}
+		return bytes.Compare(a, b) < 0
+	})
+	return list
+}
+
+// Export writes the results as JSON to fileName.
+func (r *synScanResults) Export(fileName string, startPort int, endPort int) error {
+	fileName, err := core.ExpandPath(fileName)
+	if err != nil {
+		return err
+	}
+
+	r.Lock()
+	started := r.started
+	r.Unlock()
+
+	hosts := r.List()
+	data, err := json.MarshalIndent(synScanExport{
+		Started:   started,
+		StartPort: startPort,
+		EndPort:   endPort,
+		Hosts:     hosts,
+	}, "", "  ")
+	if err != nil {
+		return err
+	} else if err = ioutil.WriteFile(fileName, data, 0644); err != nil {
+		return fmt.Errorf("Error while writing %s: %s", fileName, err)
+	}
+
+	log.Info("Exported the open ports of %d hosts to %s.", len(hosts), fileName)
+	return nil
+}
@@ -0,0 +1,236 @@
+package modules
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bettercap/bettercap/packets"
+	"github.com/bettercap/bettercap/session"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func synScanResponse(t *testing.T, from string, l4 gopacket.SerializableLayer, payload []byte) gopacket.Packet {
+	eth := layers.Ethernet{
+		SrcMAC:       net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0x00, 0x01},
+		DstMAC:       net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+		EthernetType: layers.EthernetTypeIPv4,
+	}
+	ip4 := layers.IPv4{
+		Version: 4,
+		TTL:     64,
+		SrcIP:   net.ParseIP(from),
+		DstIP:   net.ParseIP(session.TestInterfaceIP),
+	}
+
+	var err error
+	var raw []byte
+	switch l := l4.(type) {
+	case *layers.TCP:
+		ip4.Protocol = layers.IPProtocolTCP
+		l.SetNetworkLayerForChecksum(&ip4)
+		err, raw = packets.Serialize(&eth, &ip4, l)
+	case *layers.UDP:
+		ip4.Protocol = layers.IPProtocolUDP
+		l.SetNetworkLayerForChecksum(&ip4)
+		err, raw = packets.Serialize(&eth, &ip4, l, gopacket.Payload(payload))
+	}
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return gopacket.NewPacket(raw, layers.LayerTypeEthernet, gopacket.Default)
+}
+
+func synScanEvents(s *session.TestSession, tag string) []SynScanEvent {
+	events := make([]SynScanEvent, 0)
+	for _, e := range s.Events.Sorted() {
+		if e.Tag == tag {
+			events = append(events, e.Data.(SynScanEvent))
+		}
+	}
+	return events
+}
+
+func newTestSynScanner(s *session.Session, addresses ...string) *SynScanner {
+	ss := NewSynScanner(s)
+	ss.startPort = 1
+	ss.endPort = 1024
+	for _, address := range addresses {
+		ss.addresses = append(ss.addresses, net.ParseIP(address))
+		ss.targets[address] = &synScanTarget{IP: address, to: net.ParseIP(address)}
+	}
+	return ss
+}
+
+func TestSynScanOnPacket(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	ss := newTestSynScanner(s.Session, session.TestGatewayIP, "192.168.1.10")
+	synAck := func(port int) *layers.TCP {
+		return &layers.TCP{SrcPort: layers.TCPPort(port), DstPort: synSourcePort, SYN: true, ACK: true}
+	}
+
+	// the retransmissions are reported once
+	ss.onPacket(synScanResponse(t, session.TestGatewayIP, synAck(80), nil))
+	ss.onPacket(synScanResponse(t, session.TestGatewayIP, synAck(80), nil))
+	ss.onPacket(synScanResponse(t, session.TestGatewayIP, synAck(22), nil))
+	// a reset, another source port and a host which is not scanned
+	ss.onPacket(synScanResponse(t, session.TestGatewayIP, &layers.TCP{SrcPort: 443, DstPort: synSourcePort, RST: true, ACK: true}, nil))
+	ss.onPacket(synScanResponse(t, session.TestGatewayIP, &layers.TCP{SrcPort: 8080, DstPort: 1234, SYN: true, ACK: true}, nil))
+	ss.onPacket(synScanResponse(t, "192.168.1.99", synAck(80), nil))
+
+	ss.onPacket(synScanResponse(t, "192.168.1.10", &layers.UDP{SrcPort: 1900, DstPort: synSourcePort}, []byte("HTTP/1.1 200 OK\r\nSERVER: UPnP/1.0\r\n\r\n")))
+	// not one of the probed services
+	ss.onPacket(synScanResponse(t, "192.168.1.10", &layers.UDP{SrcPort: 9999, DstPort: synSourcePort}, []byte("nope")))
+
+	events := synScanEvents(s, "syn.scan")
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %v", events)
+	} else if events[2].Protocol != "udp" || events[2].Service != "ssdp" || events[2].Banner != "HTTP/1.1 200 OK" {
+		t.Fatalf("unexpected udp event %+v", events[2])
+	}
+
+	if got := ss.results.Open(session.TestGatewayIP, "tcp"); !reflect.DeepEqual(got, []int{22, 80}) {
+		t.Fatalf("expected [22 80], got %v", got)
+	} else if got := ss.results.Open("192.168.1.10", "udp"); !reflect.DeepEqual(got, []int{1900}) {
+		t.Fatalf("expected [1900], got %v", got)
+	} else if got := s.Gateway.Meta.Get("tcp-ports"); got != "22,80" {
+		t.Fatalf("expected '22,80', got '%v'", got)
+	}
+}
+
+func TestSynScanUDPProbes(t *testing.T) {
+	var units = []struct {
+		start int
+		end   int
+		exp   []string
+	}{
+		{1, 65535, []string{"dns", "ntp", "netbios-ns", "snmp", "ssdp", "mdns"}},
+		{100, 200, []string{"ntp", "netbios-ns", "snmp"}},
+		{22, 22, []string{}},
+	}
+
+	for _, u := range units {
+		got := make([]string, 0)
+		for _, probe := range udpProbesIn(u.start, u.end) {
+			got = append(got, probe.Service)
+		}
+		if !reflect.DeepEqual(got, u.exp) {
+			t.Fatalf("expected %v, got %v", u.exp, got)
+		}
+	}
+
+	if probe := udpProbeByPort(161); probe == nil || probe.Service != "snmp" {
+		t.Fatalf("expected the snmp probe, got %v", probe)
+	} else if probe := udpProbeByPort(22); probe != nil {
+		t.Fatalf("unexpected probe %v", probe)
+	}
+}
+
+func TestSynScanExport(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	dir, err := ioutil.TempDir("", "bettercap-syn-scan")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	results := newSynScanResults()
+	results.Add("192.168.1.10", "tcp", 80)
+	results.Add("192.168.1.2", "tcp", 443)
+	results.Add("192.168.1.2", "udp", 53)
+	results.Add("192.168.1.2", "tcp", 22)
+	results.SetHost("192.168.1.2", "aa:bb:cc:dd:ee:ff", "box", "")
+	results.SetService("192.168.1.2", "tcp", 22, "ssh", "SSH-2.0-OpenSSH_8.9", "")
+
+	fileName := filepath.Join(dir, "scan.json")
+	if err := results.Export(fileName, 1, 1024); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	raw, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var export synScanExport
+	if err := json.Unmarshal(raw, &export); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if export.StartPort != 1 || export.EndPort != 1024 || len(export.Hosts) != 2 {
+		t.Fatalf("unexpected export %s", raw)
+	}
+
+	host := export.Hosts[0]
+	exp := []SynScanPort{
+		{Port: 22, Protocol: "tcp", Service: "ssh", Banner: "SSH-2.0-OpenSSH_8.9"},
+		{Port: 443, Protocol: "tcp"},
+		{Port: 53, Protocol: "udp"},
+	}
+	if host.Address != "192.168.1.2" || host.MAC != "aa:bb:cc:dd:ee:ff" || host.Hostname != "box" {
+		t.Fatalf("unexpected host %+v", host)
+	} else if !reflect.DeepEqual(host.Ports, exp) {
+		t.Fatalf("expected %v, got %v", exp, host.Ports)
+	} else if export.Hosts[1].Address != "192.168.1.10" {
+		t.Fatalf("expected '192.168.1.10', got '%s'", export.Hosts[1].Address)
+	}
+}
+
+func TestSynScanGrabBanner(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer listener.Close()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte("220 ProFTPD 1.3.5 Server (Debian)\r\n"))
+			conn.Close()
+		}
+	}()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	ss := newTestSynScanner(s.Session, "127.0.0.1")
+	ss.timeout = time.Second
+	ss.quit = make(chan struct{})
+	ss.SetRunning(true, nil)
+	defer ss.SetRunning(false, nil)
+	ss.results.Add("127.0.0.1", "tcp", port)
+
+	if err := ss.grabBanner("127.0.0.1", port); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	p := ss.results.List()[0].Ports[0]
+	if p.Service != "ftp" || p.Banner != "220 ProFTPD 1.3.5 Server (Debian)" {
+		t.Fatalf("unexpected port %+v", p)
+	} else if events := synScanEvents(s, "syn.scan.service"); len(events) != 1 || events[0].Service != "ftp" {
+		t.Fatalf("unexpected events %v", events)
+	}
+}
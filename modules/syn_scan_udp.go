@@ -0,0 +1,75 @@
+package modules
+
+import (
+	"strings"
+)
+
+// udpProbe is a request a UDP service answers to, telling that its port
+// is open.
+type udpProbe struct {
+	Service string
+	Port    int
+	Payload []byte
+}
+
+var udpProbes = []*udpProbe{
+	{
+		Service: "dns",
+		Port:    53,
+		// version.bind CH TXT
+		Payload: []byte("\x13\x37\x01\x00\x00\x01\x00\x00\x00\x00\x00\x00\x07version\x04bind\x00\x00\x10\x00\x03"),
+	},
+	{
+		Service: "ntp",
+		Port:    123,
+		// version 4 client request
+		Payload: append([]byte{0xe3}, make([]byte, 47)...),
+	},
+	{
+		Service: "netbios-ns",
+		Port:    137,
+		// node status request for the * name
+		Payload: []byte("\x13\x37\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\x20CK" + strings.Repeat("A", 30) + "\x00\x00\x21\x00\x01"),
+	},
+	{
+		Service: "snmp",
+		Port:    161,
+		// v1 get-request of sysDescr.0 with the public community
+		Payload: []byte{
+			0x30, 0x29, 0x02, 0x01, 0x00, 0x04, 0x06, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0xa0, 0x1c, 0x02,
+			0x04, 0x42, 0x42, 0x42, 0x42, 0x02, 0x01, 0x00, 0x02, 0x01, 0x00, 0x30, 0x0e, 0x30, 0x0c, 0x06,
+			0x08, 0x2b, 0x06, 0x01, 0x02, 0x01, 0x01, 0x01, 0x00, 0x05, 0x00,
+		},
+	},
+	{
+		Service: "ssdp",
+		Port:    1900,
+		Payload: []byte("M-SEARCH * HTTP/1.1\r\nHOST: 239.255.255.250:1900\r\nMAN: \"ssdp:discover\"\r\nMX: 1\r\nST: ssdp:all\r\n\r\n"),
+	},
+	{
+		Service: "mdns",
+		Port:    5353,
+		// unicast PTR query for _services._dns-sd._udp.local
+		Payload: []byte("\x00\x00\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\x09_services\x07_dns-sd\x04_udp\x05local\x00\x00\x0c\x80\x01"),
+	},
+}
+
+// udpProbesIn returns the probes of the services within the ports range.
+func udpProbesIn(start int, end int) []*udpProbe {
+	probes := make([]*udpProbe, 0)
+	for _, probe := range udpProbes {
+		if probe.Port >= start && probe.Port <= end {
+			probes = append(probes, probe)
+		}
+	}
+	return probes
+}
+
+func udpProbeByPort(port int) *udpProbe {
+	for _, probe := range udpProbes {
+		if probe.Port == port {
+			return probe
+		}
+	}
+	return nil
+}
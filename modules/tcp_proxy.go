@@ -19,6 +19,7 @@ type TcpProxy struct {
 	tunnelAddr  *net.TCPAddr
 	listener    *net.TCPListener
 	script      *TcpProxyScript
+	bandwidth   int
 }
 
 func NewTcpProxy(s *session.Session) *TcpProxy {
@@ -49,6 +50,10 @@ func NewTcpProxy(s *session.Session) *TcpProxy {
 		"",
 		"Path of a TCP proxy JS script."))
 
+	p.AddParam(session.NewIntParameter("tcp.proxy.bandwidth",
+		"0",
+		"Maximum bytes per second copied in each direction of every proxied connection, 0 for unlimited."))
+
 	p.AddParam(session.NewStringParameter("tcp.tunnel.address",
 		"",
 		"",
@@ -111,6 +116,10 @@ func (p *TcpProxy) Configure() error {
 		return err
 	} else if err, scriptPath = p.StringParam("tcp.proxy.script"); err != nil {
 		return err
+	} else if err, p.bandwidth = p.IntParam("tcp.proxy.bandwidth"); err != nil {
+		return err
+	} else if p.bandwidth < 0 {
+		return fmt.Errorf("tcp.proxy.bandwidth can't be negative.")
 	} else if p.localAddr, err = net.ResolveTCPAddr("tcp", fmt.Sprintf("%s:%d", proxyAddress, proxyPort)); err != nil {
 		return err
 	} else if p.remoteAddr, err = net.ResolveTCPAddr("tcp", fmt.Sprintf("%s:%d", address, port)); err != nil {
@@ -160,6 +169,10 @@ func (p *TcpProxy) doPipe(from, to net.Addr, src, dst io.ReadWriter, wg *sync.Wa
 		if err != nil {
 			if err.Error() != "EOF" {
 				log.Warning("Read failed: %s", err)
+			} else if cw, ok := dst.(interface{ CloseWrite() error }); ok {
+				// let the other side know, it might be waiting
+				// for the end of the stream before replying
+				cw.CloseWrite()
 			}
 			return
 		}
@@ -205,12 +218,15 @@ func (p *TcpProxy) handleConnection(c *net.TCPConn) {
 	}
 	defer remote.Close()
 
+	// throttling the client side limits both directions
+	client := newThrottledConn(c, p.bandwidth)
+
 	wg := sync.WaitGroup{}
 	wg.Add(2)
 
 	// start pipeing
-	go p.doPipe(c.RemoteAddr(), p.remoteAddr, c, remote, &wg)
-	go p.doPipe(p.remoteAddr, c.RemoteAddr(), remote, c, &wg)
+	go p.doPipe(c.RemoteAddr(), p.remoteAddr, client, remote, &wg)
+	go p.doPipe(p.remoteAddr, c.RemoteAddr(), remote, client, &wg)
 
 	wg.Wait()
 }
@@ -249,3 +265,12 @@ func (p *TcpProxy) Stop() error {
 		p.listener.Close()
 	})
 }
+
+// Revert stops the proxy if it's running, so that its redirection is removed
+// before the firewall is restored.
+func (p *TcpProxy) Revert() (bool, error) {
+	if !p.Running() {
+		return false, nil
+	}
+	return true, p.Stop()
+}
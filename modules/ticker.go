@@ -87,7 +87,7 @@ func (t *Ticker) Start() error {
 			}
 
 			for _, cmd := range t.Commands {
-				if err := t.Session.Run(cmd); err != nil {
+				if err := t.Session.RunFrom(session.OriginTicker, cmd); err != nil {
 					log.Error("%s", err)
 				}
 			}
@@ -0,0 +1,213 @@
+package modules
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/session"
+
+	"github.com/mattn/go-isatty"
+)
+
+const (
+	// rows left to the prompt and to the output of the commands
+	dashboardMinCommandRows = 5
+	dashboardMinRows        = 8
+	dashboardMinWidth       = 40
+)
+
+// UIDashboard keeps the top rows of the terminal updated with the LAN
+// hosts, the WiFi stations, the running modules and the last events,
+// while the prompt keeps working in the scrolling region below them.
+type UIDashboard struct {
+	session.SessionModule
+	refresh   time.Duration
+	height    int
+	events    int
+	out       io.Writer
+	quit      chan bool
+	waitGroup *sync.WaitGroup
+	// size of the terminal and of the dashboard rows last drawn
+	width int
+	rows  int
+	drawn int
+}
+
+func NewUIDashboard(s *session.Session) *UIDashboard {
+	d := &UIDashboard{
+		SessionModule: session.NewSessionModule("ui.dashboard", s),
+		out:           os.Stdout,
+		waitGroup:     &sync.WaitGroup{},
+	}
+
+	d.AddParam(session.NewDurationParameter("ui.dashboard.refresh",
+		"1s",
+		"How often the dashboard is redrawn."))
+
+	d.AddParam(session.NewIntParameter("ui.dashboard.height",
+		"0",
+		fmt.Sprintf("Rows of the dashboard, 0 to use two thirds of the terminal, at least %d rows are left to the command pane.", dashboardMinCommandRows)))
+
+	d.AddParam(session.NewIntParameter("ui.dashboard.events",
+		"20",
+		"Number of recent events the events pane picks from."))
+
+	d.AddHandler(session.NewModuleHandler("ui.dashboard on", "",
+		"Start drawing the dashboard above the prompt.",
+		func(args []string) error {
+			return d.Start()
+		}))
+
+	d.AddHandler(session.NewModuleHandler("ui.dashboard off", "",
+		"Stop drawing the dashboard and give the whole terminal back to the prompt.",
+		func(args []string) error {
+			return d.Stop()
+		}))
+
+	return d
+}
+
+func (d *UIDashboard) Name() string {
+	return "ui.dashboard"
+}
+
+func (d *UIDashboard) Description() string {
+	return "Live terminal dashboard of the LAN hosts, WiFi access points and clients, running modules and events, drawn above the prompt."
+}
+
+func (d *UIDashboard) Author() string {
+	return "Simone Margaritelli <evilsocket@protonmail.com>"
+}
+
+// dashboardHeight returns the rows of the dashboard on a terminal with
+// the given rows, or 0 if it doesn't fit.
+func dashboardHeight(rows int, height int) int {
+	if height <= 0 {
+		height = rows * 2 / 3
+	}
+	if max := rows - dashboardMinCommandRows; height > max {
+		height = max
+	}
+	if height < dashboardMinRows {
+		return 0
+	}
+	return height
+}
+
+func (d *UIDashboard) Configure() (err error) {
+	if d.Running() {
+		return session.ErrAlreadyStarted
+	} else if !isatty.IsTerminal(os.Stdout.Fd()) {
+		return fmt.Errorf("ui.dashboard needs the standard output to be a terminal.")
+	} else if err, d.refresh = d.DurationParam("ui.dashboard.refresh"); err != nil {
+		return err
+	} else if err, d.height = d.IntParam("ui.dashboard.height"); err != nil {
+		return err
+	} else if err, d.events = d.IntParam("ui.dashboard.events"); err != nil {
+		return err
+	} else if d.refresh <= 0 {
+		return fmt.Errorf("ui.dashboard.refresh must be greater than zero.")
+	}
+
+	width, rows, err := terminalSize(os.Stdout.Fd())
+	if err != nil {
+		return fmt.Errorf("can't read the size of the terminal: %s", err)
+	} else if width < dashboardMinWidth || dashboardHeight(rows, d.height) == 0 {
+		return fmt.Errorf("the terminal is too small for the dashboard (%dx%d).", width, rows)
+	}
+
+	d.width, d.rows, d.drawn = 0, 0, 0
+	d.quit = make(chan bool)
+	return nil
+}
+
+// layout moves the scrolling region below the dashboard if the size of
+// the terminal changed, the cursor is left on its last row.
+func (d *UIDashboard) layout(buf *bytes.Buffer, width int, rows int) int {
+	height := dashboardHeight(rows, d.height)
+	if width < dashboardMinWidth {
+		height = 0
+	}
+
+	if width != d.width || rows != d.rows || height != d.drawn {
+		buf.WriteString("\033[r\033[2J")
+		if height > 0 {
+			fmt.Fprintf(buf, "\033[%d;%dr", height+1, rows)
+		}
+		fmt.Fprintf(buf, "\033[%d;1H", rows)
+		d.width, d.rows, d.drawn = width, rows, height
+	}
+	return height
+}
+
+func (d *UIDashboard) draw() {
+	width, rows, err := terminalSize(os.Stdout.Fd())
+	if err != nil {
+		log.Debug("ui.dashboard: can't read the size of the terminal: %s", err)
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	resized := width != d.width || rows != d.rows
+	if height := d.layout(buf, width, rows); height > 0 {
+		buf.WriteString("\0337")
+		for i, line := range renderDashboard(d.Session, width, height, d.events) {
+			fmt.Fprintf(buf, "\033[%d;1H\033[2K%s", i+1, line)
+		}
+		buf.WriteString("\0338")
+	}
+
+	d.out.Write(buf.Bytes())
+	if resized {
+		d.Session.Refresh()
+	}
+}
+
+// clear gives the whole terminal back to the prompt.
+func (d *UIDashboard) clear() {
+	buf := &bytes.Buffer{}
+	buf.WriteString("\0337")
+	for i := 0; i < d.drawn; i++ {
+		fmt.Fprintf(buf, "\033[%d;1H\033[2K", i+1)
+	}
+	buf.WriteString("\033[r\0338")
+	d.out.Write(buf.Bytes())
+}
+
+func (d *UIDashboard) Start() error {
+	if err := d.Configure(); err != nil {
+		return err
+	}
+
+	return d.SetRunning(true, func() {
+		d.waitGroup.Add(1)
+		defer d.waitGroup.Done()
+
+		log.Debug("ui.dashboard redrawing every %s", d.refresh)
+
+		tick := time.NewTicker(d.refresh)
+		defer tick.Stop()
+
+		for {
+			d.draw()
+			select {
+			case <-d.quit:
+				d.clear()
+				return
+			case <-tick.C:
+			}
+		}
+	})
+}
+
+func (d *UIDashboard) Stop() error {
+	return d.SetRunning(false, func() {
+		close(d.quit)
+		d.waitGroup.Wait()
+	})
+}
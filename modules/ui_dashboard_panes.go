@@ -0,0 +1,237 @@
+package modules
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/session"
+)
+
+const dashboardSeparator = " | "
+
+// dashboardFit pads or truncates line to exactly width visible columns,
+// the colors are dropped from the lines which don't fit.
+func dashboardFit(line string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+
+	line = strings.Replace(line, "\t", "  ", -1)
+	plain := core.StripColors(line)
+	size := utf8.RuneCountInString(plain)
+	if size <= width {
+		return line + strings.Repeat(" ", width-size)
+	}
+
+	runes := []rune(plain)
+	if width == 1 {
+		return string(runes[:1])
+	}
+	return string(runes[:width-1]) + "~"
+}
+
+// dashboardPane returns exactly height lines of width columns, the title
+// and as many lines as they fit, followed by how many were left out.
+func dashboardPane(title string, lines []string, width int, height int) []string {
+	pane := make([]string, 0, height)
+	if height <= 0 {
+		return pane
+	}
+
+	pane = append(pane, dashboardFit(core.Bold(title), width))
+	if room := height - 1; len(lines) > room && room > 0 {
+		more := len(lines) - room + 1
+		lines = append(lines[:room-1:room-1], core.Dim(fmt.Sprintf("... %d more", more)))
+	}
+	for _, line := range lines {
+		if len(pane) == height {
+			break
+		}
+		pane = append(pane, dashboardFit(line, width))
+	}
+	for len(pane) < height {
+		pane = append(pane, strings.Repeat(" ", width))
+	}
+	return pane
+}
+
+// dashboardColumns joins two panes of the same height side by side.
+func dashboardColumns(left []string, right []string) []string {
+	lines := make([]string, len(left))
+	for i := range left {
+		lines[i] = left[i] + core.Dim(dashboardSeparator) + right[i]
+	}
+	return lines
+}
+
+func dashboardHeader(s *session.Session, width int) string {
+	gateway := core.Dim("no gateway")
+	if s.Gateway != nil && s.Gateway != s.Interface {
+		gateway = s.Gateway.IpAddress
+	}
+
+	aps := 0
+	if s.WiFi != nil {
+		aps = len(s.WiFi.List())
+	}
+
+	header := fmt.Sprintf("%s %s  gateway %s  %d hosts  %d access points  %d events  %s",
+		core.Bold(s.Interface.Name()),
+		s.Interface.IpAddress,
+		gateway,
+		len(s.Lan.List()),
+		aps,
+		s.Events.Emitted(),
+		core.Dim(time.Now().Format(eventTimeFormat)))
+
+	return dashboardFit(header, width)
+}
+
+func dashboardHosts(s *session.Session) (string, []string) {
+	hosts := s.Lan.List()
+	sort.Sort(ByAddressSorter(hosts))
+
+	lines := make([]string, 0, len(hosts))
+	for _, e := range hosts {
+		name := e.Hostname
+		if e.Alias != "" {
+			name = core.Green(e.Alias)
+		}
+		lines = append(lines, fmt.Sprintf("%-15s %s %s %s", e.IpAddress, core.Dim(e.HwAddress), name, core.Dim(e.Vendor)))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, core.Dim("No hosts discovered yet."))
+	}
+
+	return fmt.Sprintf("LAN hosts (%d)", len(hosts)), lines
+}
+
+func dashboardWiFi(s *session.Session) (string, []string) {
+	if s.WiFi == nil {
+		return "WiFi", []string{core.Dim("Start wifi.recon to see the access points.")}
+	}
+
+	aps := s.WiFi.List()
+	sort.Slice(aps, func(i, j int) bool {
+		return aps[i].RSSI > aps[j].RSSI
+	})
+
+	clients := 0
+	lines := make([]string, 0)
+	for _, ap := range aps {
+		essid := ap.ESSID()
+		if essid == "" {
+			essid = core.Dim("<hidden>")
+		}
+		lines = append(lines, fmt.Sprintf("%4d %s %s %s", ap.RSSI, ap.BSSID(), core.Bold(essid), core.Dim(fmt.Sprintf("ch %d %s", ap.Channel(), ap.Encryption))))
+
+		list := ap.Clients()
+		sort.Sort(ByRSSISorter(list))
+		for _, c := range list {
+			lines = append(lines, fmt.Sprintf("  %4d %s %s", c.RSSI, c.BSSID(), core.Dim(c.Vendor)))
+		}
+		clients += len(list)
+	}
+	if len(lines) == 0 {
+		lines = append(lines, core.Dim("No access points detected yet."))
+	}
+
+	return fmt.Sprintf("WiFi (%d access points, %d clients)", len(aps), clients), lines
+}
+
+func dashboardModules(s *session.Session) (string, []string) {
+	lines := make([]string, 0)
+	for _, m := range s.Modules {
+		if !m.Running() {
+			continue
+		}
+
+		line := core.Green(m.Name())
+		if m.Paused() {
+			line += core.Yellow(" (paused)")
+		}
+		if detailer, ok := m.(session.StatusDetailer); ok {
+			if detail := detailer.StatusDetail(); detail != "" {
+				line += " " + core.Dim(detail)
+			}
+		}
+		lines = append(lines, line)
+	}
+	running := len(lines)
+	if running == 0 {
+		lines = append(lines, core.Dim("No modules running."))
+	}
+
+	return fmt.Sprintf("Modules (%d running)", running), lines
+}
+
+// dashboardEvents returns the views events.stream would print for the
+// last events, skipping the ones it's ignoring.
+func dashboardEvents(s *session.Session, last int) (string, []string) {
+	buf := &bytes.Buffer{}
+	viewer := &EventsStream{
+		SessionModule: session.NewSessionModule("events.stream", s),
+		output:        &eventsOutputs{text: []io.Writer{buf}},
+	}
+
+	var ignored *IgnoreList
+	if err, mod := s.Module("events.stream"); err == nil {
+		if stream, ok := mod.(*EventsStream); ok {
+			ignored = stream.ignoreList
+		}
+	}
+
+	events := s.Events.Sorted()
+	if last > 0 && len(events) > last {
+		events = events[len(events)-last:]
+	}
+	for _, e := range events {
+		if ignored == nil || !ignored.Ignored(e) {
+			viewer.View(e, false)
+		}
+	}
+
+	lines := make([]string, 0)
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.TrimSpace(core.StripColors(line)) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return "Events", lines
+}
+
+// renderDashboard returns the height lines of width columns of the
+// dashboard: the header, the hosts and the WiFi stations on top, the
+// running modules and the last events at the bottom.
+func renderDashboard(s *session.Session, width int, height int, events int) []string {
+	lines := []string{dashboardHeader(s, width)}
+
+	leftWidth := (width - len(dashboardSeparator)) / 2
+	rightWidth := width - len(dashboardSeparator) - leftWidth
+	bottom := (height - 1) / 2
+	top := height - 1 - bottom
+
+	title, hosts := dashboardHosts(s)
+	left := dashboardPane(title, hosts, leftWidth, top)
+	title, wifi := dashboardWiFi(s)
+	right := dashboardPane(title, wifi, rightWidth, top)
+	lines = append(lines, dashboardColumns(left, right)...)
+
+	title, mods := dashboardModules(s)
+	left = dashboardPane(title, mods, leftWidth, bottom)
+	title, views := dashboardEvents(s, events)
+	// the most recent events are the ones shown
+	if room := bottom - 1; room >= 0 && len(views) > room {
+		views = views[len(views)-room:]
+	}
+	right = dashboardPane(title, views, rightWidth, bottom)
+	lines = append(lines, dashboardColumns(left, right)...)
+
+	return lines
+}
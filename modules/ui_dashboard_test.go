@@ -0,0 +1,134 @@
+package modules
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/network"
+	"github.com/bettercap/bettercap/session"
+)
+
+func TestDashboardHeight(t *testing.T) {
+	cases := []struct {
+		rows   int
+		height int
+		expect int
+	}{
+		{48, 0, 32},
+		{48, 10, 10},
+		{48, 46, 43},
+		{12, 0, 0},
+		{13, 0, 8},
+		{24, 4, 0},
+	}
+
+	for _, c := range cases {
+		if got := dashboardHeight(c.rows, c.height); got != c.expect {
+			t.Fatalf("expected %d rows for (%d, %d), got %d", c.expect, c.rows, c.height, got)
+		}
+	}
+}
+
+func TestDashboardFit(t *testing.T) {
+	cases := []struct {
+		line   string
+		width  int
+		expect string
+	}{
+		{"abc", 5, "abc  "},
+		{"abcdef", 4, "abc~"},
+		{"abc", 1, "a"},
+		{"a\tb", 4, "a  b"},
+		{core.Green("abcdef"), 3, "ab~"},
+	}
+
+	for _, c := range cases {
+		if got := dashboardFit(c.line, c.width); got != c.expect {
+			t.Fatalf("expected '%s', got '%s'", c.expect, got)
+		}
+	}
+
+	if got := dashboardFit(core.Green("ab"), 3); got != core.Green("ab")+" " {
+		t.Fatalf("expected the colors of a fitting line to be kept, got '%s'", got)
+	}
+}
+
+func TestDashboardPane(t *testing.T) {
+	lines := []string{"1", "2", "3", "4", "5"}
+
+	pane := dashboardPane("title", lines, 10, 4)
+	if len(pane) != 4 {
+		t.Fatalf("expected 4 lines, got %d", len(pane))
+	} else if got := core.StripColors(pane[3]); got != "... 3 more" {
+		t.Fatalf("expected '... 3 more', got '%s'", got)
+	} else if lines[2] != "3" {
+		t.Fatalf("expected the lines not to be modified, got '%s'", lines[2])
+	}
+
+	pane = dashboardPane("title", lines[:1], 10, 4)
+	if len(pane) != 4 {
+		t.Fatalf("expected 4 lines, got %d", len(pane))
+	} else if pane[3] != strings.Repeat(" ", 10) {
+		t.Fatalf("expected a blank line, got '%s'", pane[3])
+	}
+}
+
+func TestRenderDashboard(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	s.Lan.AddIfNew("192.168.1.10", "aa:00:00:00:00:10")
+	s.WiFi = network.NewWiFi(s.Interface, nil, nil)
+	s.WiFi.AddIfNew("home", "00:de:ad:be:ef:00", 2437, -40)
+	s.Events.Add("dashboard.test", nil)
+
+	width, height := 100, 20
+	lines := renderDashboard(s.Session, width, height, 10)
+	if len(lines) != height {
+		t.Fatalf("expected %d lines, got %d", height, len(lines))
+	}
+
+	for i, line := range lines {
+		if size := utf8.RuneCountInString(core.StripColors(line)); size != width {
+			t.Fatalf("expected line %d to be %d columns, got %d: '%s'", i, width, size, line)
+		}
+	}
+
+	screen := core.StripColors(strings.Join(lines, "\n"))
+	for _, expected := range []string{"192.168.1.10", "home", "00:de:ad:be:ef:00", "dashboard.test", "No modules running."} {
+		if !strings.Contains(screen, expected) {
+			t.Fatalf("expected '%s' on the dashboard:\n%s", expected, screen)
+		}
+	}
+}
+
+func TestDashboardLayout(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	d := NewUIDashboard(s.Session)
+	buf := &bytes.Buffer{}
+	if height := d.layout(buf, 80, 24); height != 16 {
+		t.Fatalf("expected 16 rows, got %d", height)
+	} else if !strings.Contains(buf.String(), "\033[17;24r") {
+		t.Fatalf("expected the scrolling region to be set, got %q", buf.String())
+	}
+
+	buf.Reset()
+	if d.layout(buf, 80, 24); buf.Len() != 0 {
+		t.Fatalf("expected no changes of the layout, got %q", buf.String())
+	}
+
+	if d.layout(buf, 30, 24); !strings.Contains(buf.String(), "\033[r") || strings.Contains(buf.String(), ";24r") {
+		t.Fatalf("expected the scrolling region to be reset, got %q", buf.String())
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+// +build !windows
+
+package modules
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// terminalSize returns the columns and the rows of the terminal fd refers to.
+func terminalSize(fd uintptr) (int, int, error) {
+	ws, err := unix.IoctlGetWinsize(int(fd), unix.TIOCGWINSZ)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(ws.Col), int(ws.Row), nil
+}
@@ -0,0 +1,21 @@
+package modules
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// terminalSize returns the columns and the rows of the terminal as set
+// in the COLUMNS and LINES environment variables.
+func terminalSize(fd uintptr) (int, int, error) {
+	width, err := strconv.Atoi(os.Getenv("COLUMNS"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("COLUMNS is not set")
+	}
+	rows, err := strconv.Atoi(os.Getenv("LINES"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("LINES is not set")
+	}
+	return width, rows, nil
+}
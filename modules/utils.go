@@ -3,6 +3,7 @@ package modules
 import (
 	"fmt"
 	"net"
+	"strings"
 	"time"
 
 	"github.com/bettercap/bettercap/log"
@@ -45,3 +46,10 @@ func findMAC(s *session.Session, ip net.IP, probe bool) (net.HardwareAddr, error
 
 	return hw, nil
 }
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return strings.Repeat("*", 8)
+}
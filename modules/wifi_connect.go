@@ -0,0 +1,30 @@
+package modules
+
+import (
+	"fmt"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/network"
+)
+
+// connect associates the interface to the SSID, after every module which
+// needs to change the interface first (like mac.changer.per-ssid) did it.
+func (w *WiFiModule) connect(ssid string) error {
+	if w.Running() {
+		return fmt.Errorf("wifi.connect can't be used while wifi.recon is running, the interface is in monitor mode.")
+	}
+
+	iface := w.Session.Interface.Name()
+	if err := w.Session.PrepareAssociation(iface, ssid); err != nil {
+		return err
+	}
+
+	log.Info("Connecting %s to %s ...", iface, ssid)
+	if err := network.ConnectInterface(iface, ssid); err != nil {
+		return fmt.Errorf("Could not connect %s to %s: %s", iface, ssid, err)
+	}
+
+	log.Info("%s connected to %s", iface, core.Bold(ssid))
+	return nil
+}
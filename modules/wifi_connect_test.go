@@ -0,0 +1,45 @@
+package modules
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/bettercap/bettercap/session"
+)
+
+func TestWiFiConnect(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("connection commands are only tested on linux")
+	}
+
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	w := NewWiFiModule(s.Session)
+	s.Register(w)
+	mc := NewMacChanger(s.Session)
+	s.Register(mc)
+
+	if err := s.Set("mac.changer.per-ssid", "lab net=00:11:22:33:44:55"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	startMacChanger(t, s)
+
+	if err := s.Handle("wifi", "wifi.connect SSID", "lab net"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the address is changed before associating
+	got := s.Executed()
+	exp := []string{
+		"ip link set dev test0 address 00:11:22:33:44:55",
+		"nmcli device wifi connect lab net ifname test0",
+	}
+	if len(got) < 2 || !reflect.DeepEqual(got[len(got)-2:], exp) {
+		t.Fatalf("expected '%v' at the end, got '%v'", exp, got)
+	}
+}
@@ -42,6 +42,10 @@ func (w *WiFiModule) sendDeauthPacket(ap net.HardwareAddr, client net.HardwareAd
 }
 
 func (w *WiFiModule) startDeauth(to net.HardwareAddr) error {
+	if err := w.Session.CheckSafe("wifi.deauth", w.Session.Interface.Name()); err != nil {
+		return err
+	}
+
 	// if not already running, temporarily enable the pcap handle
 	// for packet injection
 	if !w.Running() {
@@ -0,0 +1,254 @@
+package modules
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/packets"
+	"github.com/bettercap/bettercap/session"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// hashcat 22000 hash types
+const (
+	hashcatPMKID = "01"
+	hashcatEAPOL = "02"
+)
+
+// hashcat 22000 message pairs, the EAPOL frame is always the one of M2
+const (
+	hashcatPairM1M2 = 0x00
+	hashcatPairM2M3 = 0x02
+)
+
+type WiFiHandshakeEvent struct {
+	AP      string
+	Station string
+	ESSID   string
+	PMKID   bool
+	File    string
+}
+
+type wifiHash struct {
+	Type    string
+	AP      net.HardwareAddr
+	Station net.HardwareAddr
+	Line    string
+}
+
+// the EAPOL key frames seen so far between an access point and a station
+type wifiHandshake struct {
+	m1 *packets.EAPOLKey
+	m2 *packets.EAPOLKey
+	m3 *packets.EAPOLKey
+}
+
+// wifiHandshakes pairs the EAPOL key frames into crackable hashes and
+// keeps track of the access points already saved.
+type wifiHandshakes struct {
+	sync.Mutex
+	pending map[string]*wifiHandshake
+	saved   map[string]bool
+	// hashes captured during this session
+	captured []wifiHash
+}
+
+func newWiFiHandshakes() *wifiHandshakes {
+	return &wifiHandshakes{
+		pending:  make(map[string]*wifiHandshake),
+		saved:    make(map[string]bool),
+		captured: make([]wifiHash, 0),
+	}
+}
+
+func hashcatMac(hw net.HardwareAddr) string {
+	return fmt.Sprintf("%x", []byte(hw))
+}
+
+func hashcatKey(hashType string, ap string) string {
+	return hashType + "*" + ap
+}
+
+func hashcatPMKIDLine(pmkid []byte, ap, station net.HardwareAddr, essid string) string {
+	return fmt.Sprintf("WPA*%s*%x*%s*%s*%x***", hashcatPMKID, pmkid, hashcatMac(ap), hashcatMac(station), essid)
+}
+
+func hashcatEAPOLLine(m2 *packets.EAPOLKey, anonce []byte, essid string, pair int) string {
+	return fmt.Sprintf("WPA*%s*%x*%s*%s*%x*%x*%x*%02x", hashcatEAPOL, m2.MIC, hashcatMac(m2.AP), hashcatMac(m2.Station), essid, anonce, m2.FrameWithoutMIC(), pair)
+}
+
+// Load marks as saved every access point found in an existing
+// hashcat file so that they're not saved twice across sessions.
+func (h *wifiHandshakes) Load(fileName string) error {
+	file, err := os.Open(fileName)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	h.Lock()
+	defer h.Unlock()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 4096), 1024*1024)
+	for scanner.Scan() {
+		if fields := strings.Split(core.Trim(scanner.Text()), "*"); len(fields) >= 4 && fields[0] == "WPA" {
+			h.saved[hashcatKey(fields[1], fields[3])] = true
+		}
+	}
+	return scanner.Err()
+}
+
+func (h *wifiHandshakes) MarkSaved(hash wifiHash) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.saved[hashcatKey(hash.Type, hashcatMac(hash.AP))] = true
+	h.captured = append(h.captured, hash)
+	if hash.Type == hashcatEAPOL {
+		delete(h.pending, hash.AP.String()+hash.Station.String())
+	}
+}
+
+// Captured returns the hashes of the given type captured so far.
+func (h *wifiHandshakes) Captured(hashType string) []wifiHash {
+	h.Lock()
+	defer h.Unlock()
+
+	hashes := make([]wifiHash, 0)
+	for _, hash := range h.captured {
+		if hash.Type == hashType {
+			hashes = append(hashes, hash)
+		}
+	}
+	return hashes
+}
+
+// Metrics publishes the number of handshakes and PMKIDs captured.
+func (w *WiFiModule) Metrics() []session.Metric {
+	help := "Number of WPA hashes captured during the session."
+	return []session.Metric{
+		session.NewCounter("bettercap_wifi_handshakes_captured_total", help, uint64(len(w.handshakes.Captured(hashcatEAPOL))), map[string]string{"type": "eapol"}),
+		session.NewCounter("bettercap_wifi_handshakes_captured_total", help, uint64(len(w.handshakes.Captured(hashcatPMKID))), map[string]string{"type": "pmkid"}),
+	}
+}
+
+// Track adds the key frame to its handshake and returns the hashes not
+// saved yet that can be built with it, partial handshakes and access
+// points with an unknown ESSID return nothing.
+func (h *wifiHandshakes) Track(key *packets.EAPOLKey, essid string) []wifiHash {
+	h.Lock()
+	defer h.Unlock()
+
+	id := key.AP.String() + key.Station.String()
+	hs, found := h.pending[id]
+	if !found {
+		hs = &wifiHandshake{}
+		h.pending[id] = hs
+	}
+
+	switch key.Message {
+	case 1:
+		hs.m1 = key
+	case 2:
+		// the fourth message can be flagged as the second one by some
+		// implementations, but it has no nonce
+		if !bytes.Equal(key.Nonce, make([]byte, len(key.Nonce))) {
+			hs.m2 = key
+		}
+	case 3:
+		hs.m3 = key
+	}
+
+	hashes := make([]wifiHash, 0)
+	if essid == "" {
+		return hashes
+	}
+
+	ap := hashcatMac(key.AP)
+	if key.PMKID != nil && !h.saved[hashcatKey(hashcatPMKID, ap)] {
+		hashes = append(hashes, wifiHash{
+			Type:    hashcatPMKID,
+			AP:      key.AP,
+			Station: key.Station,
+			Line:    hashcatPMKIDLine(key.PMKID, key.AP, key.Station, essid),
+		})
+	}
+
+	if hs.m2 != nil && !h.saved[hashcatKey(hashcatEAPOL, ap)] {
+		line := ""
+		// the replay counter tells which frames belong together
+		if hs.m1 != nil && hs.m1.Replay == hs.m2.Replay {
+			line = hashcatEAPOLLine(hs.m2, hs.m1.Nonce, essid, hashcatPairM1M2)
+		} else if hs.m3 != nil && hs.m3.Replay == hs.m2.Replay+1 {
+			line = hashcatEAPOLLine(hs.m2, hs.m3.Nonce, essid, hashcatPairM2M3)
+		}
+
+		if line != "" {
+			hashes = append(hashes, wifiHash{
+				Type:    hashcatEAPOL,
+				AP:      key.AP,
+				Station: key.Station,
+				Line:    line,
+			})
+		}
+	}
+
+	return hashes
+}
+
+func (w *WiFiModule) saveHash(hash wifiHash) error {
+	file, err := os.OpenFile(w.handshakesFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(hash.Line + "\n")
+	return err
+}
+
+func (w *WiFiModule) discoverHandshakes(dot11 *layers.Dot11, packet gopacket.Packet) {
+	ok, key := packets.Dot11ParseEAPOLKey(packet, dot11)
+	if !ok {
+		return
+	} else if w.handshakesFile == "" && !w.assoc.Has(key.AP) {
+		// the frames of the associated access points are kept in memory
+		// for wifi.assoc export
+		return
+	}
+
+	essid := ""
+	if ap, found := w.Session.WiFi.Get(key.AP.String()); found && ap.ESSID() != "<hidden>" {
+		essid = ap.ESSID()
+	}
+
+	for _, hash := range w.handshakes.Track(key, essid) {
+		if w.handshakesFile != "" {
+			if err := w.saveHash(hash); err != nil {
+				log.Error("Error while saving handshake of %s to %s: %s", hash.AP, w.handshakesFile, err)
+				continue
+			}
+		}
+		w.handshakes.MarkSaved(hash)
+
+		w.Session.Events.Add("wifi.handshake", WiFiHandshakeEvent{
+			AP:      hash.AP.String(),
+			Station: hash.Station.String(),
+			ESSID:   essid,
+			PMKID:   hash.Type == hashcatPMKID,
+			File:    w.handshakesFile,
+		})
+	}
+}
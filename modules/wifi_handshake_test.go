@@ -0,0 +1,136 @@
+package modules
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bettercap/bettercap/packets"
+)
+
+var (
+	testHsAP, _      = net.ParseMAC("00:11:22:33:44:55")
+	testHsStation, _ = net.ParseMAC("aa:bb:cc:dd:ee:ff")
+)
+
+func testEAPOLKey(message int, replay uint64, nonce byte, pmkid []byte) *packets.EAPOLKey {
+	frame := make([]byte, 99)
+	mic := frame[81:97]
+	if message != 1 {
+		copy(mic, bytes.Repeat([]byte{0x33}, 16))
+	}
+	return &packets.EAPOLKey{
+		Message: message,
+		AP:      testHsAP,
+		Station: testHsStation,
+		Replay:  replay,
+		Nonce:   bytes.Repeat([]byte{nonce}, 32),
+		MIC:     mic,
+		PMKID:   pmkid,
+		Frame:   frame,
+	}
+}
+
+func TestWiFiHandshakesPMKID(t *testing.T) {
+	h := newWiFiHandshakes()
+	pmkid := bytes.Repeat([]byte{0x44}, 16)
+
+	if hashes := h.Track(testEAPOLKey(1, 1, 0x11, pmkid), ""); len(hashes) != 0 {
+		t.Fatalf("expected no hashes without ESSID, got %d", len(hashes))
+	}
+
+	hashes := h.Track(testEAPOLKey(1, 1, 0x11, pmkid), "lab")
+	if len(hashes) != 1 {
+		t.Fatalf("expected 1 hash, got %d", len(hashes))
+	}
+
+	exp := "WPA*01*44444444444444444444444444444444*001122334455*aabbccddeeff*6c6162***"
+	if hashes[0].Line != exp {
+		t.Fatalf("expected '%s', got '%s'", exp, hashes[0].Line)
+	}
+
+	h.MarkSaved(hashes[0])
+	if hashes := h.Track(testEAPOLKey(1, 2, 0x11, pmkid), "lab"); len(hashes) != 0 {
+		t.Fatalf("expected PMKID not to be saved twice, got %d hashes", len(hashes))
+	}
+}
+
+func TestWiFiHandshakesEAPOL(t *testing.T) {
+	var units = []struct {
+		frames []*packets.EAPOLKey
+		pair   string
+	}{
+		// M1 + M2
+		{[]*packets.EAPOLKey{testEAPOLKey(1, 1, 0x11, nil), testEAPOLKey(2, 1, 0x22, nil)}, "00"},
+		// M2 + M3
+		{[]*packets.EAPOLKey{testEAPOLKey(2, 1, 0x22, nil), testEAPOLKey(3, 2, 0x11, nil)}, "02"},
+		// M2 only
+		{[]*packets.EAPOLKey{testEAPOLKey(2, 1, 0x22, nil)}, ""},
+		// M1 of a different exchange
+		{[]*packets.EAPOLKey{testEAPOLKey(1, 5, 0x11, nil), testEAPOLKey(2, 1, 0x22, nil)}, ""},
+		// M4 flagged as M2, without nonce
+		{[]*packets.EAPOLKey{testEAPOLKey(1, 1, 0x11, nil), testEAPOLKey(2, 1, 0x00, nil)}, ""},
+	}
+
+	for i, u := range units {
+		h := newWiFiHandshakes()
+		var hashes []wifiHash
+		for _, frame := range u.frames {
+			hashes = append(hashes, h.Track(frame, "lab")...)
+		}
+
+		if u.pair == "" {
+			if len(hashes) != 0 {
+				t.Fatalf("expected no hashes for unit %d, got %d", i, len(hashes))
+			}
+			continue
+		} else if len(hashes) != 1 {
+			t.Fatalf("expected 1 hash for unit %d, got %d", i, len(hashes))
+		}
+
+		fields := strings.Split(hashes[0].Line, "*")
+		if len(fields) != 9 {
+			t.Fatalf("expected 9 fields, got %d", len(fields))
+		} else if fields[1] != hashcatEAPOL {
+			t.Fatalf("expected '%s', got '%s'", hashcatEAPOL, fields[1])
+		} else if fields[2] != strings.Repeat("33", 16) {
+			t.Fatalf("unexpected MIC '%s'", fields[2])
+		} else if fields[6] != strings.Repeat("11", 32) {
+			t.Fatalf("unexpected ANonce '%s'", fields[6])
+		} else if fields[7] != strings.Repeat("00", 99) {
+			t.Fatalf("expected the MIC to be zeroed in '%s'", fields[7])
+		} else if fields[8] != u.pair {
+			t.Fatalf("expected '%s', got '%s'", u.pair, fields[8])
+		}
+	}
+}
+
+func TestWiFiHandshakesLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "handshakes")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	h := newWiFiHandshakes()
+	fileName := filepath.Join(dir, "hashes.22000")
+	if err := h.Load(fileName); err != nil {
+		t.Fatalf("unexpected error for a missing file: %v", err)
+	}
+
+	data := "WPA*02*33*001122334455*aabbccddeeff*6c6162*11*00*00\n"
+	if err := ioutil.WriteFile(fileName, []byte(data), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := h.Load(fileName); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	h.Track(testEAPOLKey(1, 1, 0x11, nil), "lab")
+	if hashes := h.Track(testEAPOLKey(2, 1, 0x22, nil), "lab"); len(hashes) != 0 {
+		t.Fatalf("expected the saved handshake to be skipped, got %d hashes", len(hashes))
+	}
+}
@@ -1,12 +1,65 @@
 package modules
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/bettercap/bettercap/log"
 	"github.com/bettercap/bettercap/network"
 )
 
+// supportedFrequencies returns the frequencies of the channels supported
+// by the adapter in the wifi.hop.bands, without the DFS ones if
+// wifi.hop.dfs is false.
+func (w *WiFiModule) supportedFrequencies() ([]int, error) {
+	err, bands := w.StringParam("wifi.hop.bands")
+	if err != nil {
+		return nil, err
+	}
+	err, dfs := w.BoolParam("wifi.hop.dfs")
+	if err != nil {
+		return nil, err
+	}
+
+	channels, err := network.GetSupportedChannels(w.Session.Interface.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	frequencies := make([]int, 0)
+	for _, c := range network.FilterWiFiChannels(channels, bands, dfs) {
+		frequencies = append(frequencies, c.Frequency)
+	}
+
+	if len(frequencies) == 0 {
+		return nil, fmt.Errorf("Interface %s doesn't support any channel of the %s band(s).", w.Session.Interface.Name(), bands)
+	}
+	return frequencies, nil
+}
+
+func isDualBand(frequencies []int) bool {
+	bands := make(map[string]bool)
+	for _, frequency := range frequencies {
+		bands[network.Dot11FreqBand(frequency)] = true
+	}
+	return bands[network.WiFiBand2GHz] && bands[network.WiFiBand5GHz]
+}
+
+// hopDelay returns how long the hopper stays on a frequency, the dwell
+// time of its band if set or the hop period.
+func (w *WiFiModule) hopDelay(frequency int, dualBand bool) time.Duration {
+	if delay := w.hopDwell[network.Dot11FreqBand(frequency)]; delay > 0 {
+		return delay
+	}
+	// if we have both 2.4 and 5ghz capabilities, we have
+	// more channels, therefore we need to increase the time
+	// we hop on each one otherwise me lose information
+	if dualBand {
+		return w.hopPeriod * 2
+	}
+	return w.hopPeriod
+}
+
 func (w *WiFiModule) onChannel(channel int, cb func()) {
 	w.chanLock.Lock()
 	defer w.chanLock.Unlock()
@@ -32,15 +85,8 @@ func (w *WiFiModule) channelHopper() {
 	log.Info("Channel hopper started.")
 
 	for w.Running() {
-		delay := w.hopPeriod
-		// if we have both 2.4 and 5ghz capabilities, we have
-		// more channels, therefore we need to increase the time
-		// we hop on each one otherwise me lose information
-		if len(w.frequencies) > 14 {
-			delay = delay * 2
-		}
-
 		frequencies := w.frequencies
+		dualBand := isDualBand(frequencies)
 		for _, frequency := range frequencies {
 			channel := network.Dot11Freq2Chan(frequency)
 			// stick to the access point channel as long as it's selected
@@ -57,7 +103,7 @@ func (w *WiFiModule) channelHopper() {
 			}
 			w.chanLock.Unlock()
 
-			time.Sleep(delay)
+			time.Sleep(w.hopDelay(frequency, dualBand))
 			if !w.Running() {
 				return
 			}
@@ -0,0 +1,44 @@
+package modules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bettercap/bettercap/network"
+	"github.com/bettercap/bettercap/session"
+)
+
+func TestWiFiHopDelay(t *testing.T) {
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	w := NewWiFiModule(s.Session)
+	w.hopPeriod = 250 * time.Millisecond
+
+	if isDualBand([]int{2412, 2437}) || !isDualBand([]int{2412, 5180}) {
+		t.Fatal("unexpected dual band detection")
+	}
+
+	var units = []struct {
+		dwell    map[string]time.Duration
+		freq     int
+		dualBand bool
+		exp      time.Duration
+	}{
+		{nil, 2412, false, 250 * time.Millisecond},
+		{nil, 5180, true, 500 * time.Millisecond},
+		{map[string]time.Duration{network.WiFiBand5GHz: 100 * time.Millisecond}, 5180, true, 100 * time.Millisecond},
+		{map[string]time.Duration{network.WiFiBand5GHz: 100 * time.Millisecond}, 2412, true, 500 * time.Millisecond},
+		{map[string]time.Duration{network.WiFiBand2GHz: time.Second}, 2412, false, time.Second},
+	}
+
+	for _, u := range units {
+		w.hopDwell = u.dwell
+		if got := w.hopDelay(u.freq, u.dualBand); got != u.exp {
+			t.Fatalf("expected '%s', got '%s'", u.exp, got)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package modules
 
 import (
 	"bytes"
+	"math"
 	"net"
 	"time"
 
@@ -13,8 +14,6 @@ import (
 	"github.com/google/gopacket/layers"
 )
 
-var maxStationTTL = 5 * time.Minute
-
 type WiFiProbe struct {
 	FromAddr   net.HardwareAddr
 	FromVendor string
@@ -29,27 +28,50 @@ func (w *WiFiModule) stationPruner() {
 
 	log.Debug("WiFi stations pruner started.")
 	for w.Running() {
-		// loop every AP
-		for _, ap := range w.Session.WiFi.List() {
-			sinceLastSeen := time.Since(ap.LastSeen)
-			if sinceLastSeen > maxStationTTL {
-				log.Debug("Station %s not seen in %s, removing.", ap.BSSID(), sinceLastSeen)
-				w.Session.WiFi.Remove(ap.BSSID())
-				continue
-			}
-			// loop every AP client
-			for _, c := range ap.Clients() {
-				sinceLastSeen := time.Since(c.LastSeen)
-				if sinceLastSeen > maxStationTTL {
-					log.Debug("Client %s of station %s not seen in %s, removing.", c.String(), ap.BSSID(), sinceLastSeen)
-					ap.RemoveClient(c.BSSID())
-				}
-			}
+		// the sweep interval doesn't depend on the aging timeout
+		if w.aging > 0 {
+			w.pruneStations()
+		}
+		// wifi.rssi.min can be changed while running
+		if err := w.updateMinRSSI(); err != nil {
+			log.Debug("%s", err)
 		}
 		time.Sleep(1 * time.Second)
 	}
 }
 
+// updateMinRSSI applies wifi.rssi.min to the stations list.
+func (w *WiFiModule) updateMinRSSI() error {
+	err, rssi := w.IntParam("wifi.rssi.min")
+	if err != nil {
+		return err
+	} else if rssi < math.MinInt8 {
+		rssi = math.MinInt8
+	}
+	w.Session.WiFi.SetMinRSSI(int8(rssi))
+	return nil
+}
+
+func (w *WiFiModule) pruneStations() {
+	// loop every AP
+	for _, ap := range w.Session.WiFi.List() {
+		sinceLastSeen := time.Since(ap.LastSeen)
+		if sinceLastSeen > w.aging {
+			log.Debug("Station %s not seen in %s, removing.", ap.BSSID(), sinceLastSeen)
+			w.Session.WiFi.Remove(ap.BSSID())
+			continue
+		}
+		// loop every AP client
+		for _, c := range ap.Clients() {
+			sinceLastSeen := time.Since(c.LastSeen)
+			if sinceLastSeen > w.aging {
+				log.Debug("Client %s of station %s not seen in %s, removing.", c.String(), ap.BSSID(), sinceLastSeen)
+				ap.RemoveClient(c.BSSID())
+			}
+		}
+	}
+}
+
 func (w *WiFiModule) discoverAccessPoints(radiotap *layers.RadioTap, dot11 *layers.Dot11, packet gopacket.Packet) {
 	// search for Dot11InformationElementIDSSID
 	if ok, ssid := packets.Dot11ParseIDSSID(packet); ok {
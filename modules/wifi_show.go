@@ -123,6 +123,10 @@ func (w *WiFiModule) Show(by string) error {
 		stations = w.Session.WiFi.Stations()
 	}
 
+	if err := w.updateMinRSSI(); err != nil {
+		return err
+	}
+
 	if by == "seen" {
 		sort.Sort(ByWiFiSeenSorter(stations))
 	} else if by == "essid" {
@@ -135,7 +139,9 @@ func (w *WiFiModule) Show(by string) error {
 
 	rows := make([][]string, 0)
 	for _, s := range stations {
-		if row, include := w.getRow(s); include {
+		if !w.Session.WiFi.Visible(s) {
+			continue
+		} else if row, include := w.getRow(s); include {
 			rows = append(rows, row)
 		}
 	}
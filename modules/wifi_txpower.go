@@ -0,0 +1,102 @@
+package modules
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/log"
+	"github.com/bettercap/bettercap/network"
+)
+
+// wifiTxPower is the transmit power the interface had before wifi.txpower,
+// restored when the module stops or the session exits.
+type wifiTxPower struct {
+	sync.Mutex
+	iface    string
+	original float64
+	changed  bool
+}
+
+func checkTxPower(dbm, min, max float64) error {
+	if dbm < min || dbm > max {
+		return fmt.Errorf("%.2f dBm is out of the range supported by the adapter, allowed values are from %.2f to %.2f dBm.", dbm, min, max)
+	}
+	return nil
+}
+
+func (w *WiFiModule) showTxPower() error {
+	iface := w.Session.Interface.Name()
+	power, err := network.GetInterfaceTxPower(iface)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Printf("  Interface : %s\n", iface)
+	fmt.Printf("  TX power  : %s\n", core.Bold(fmt.Sprintf("%.2f dBm", power)))
+	if min, max, err := network.GetInterfaceTxPowerRange(iface); err == nil {
+		fmt.Printf("  Range     : %.2f to %.2f dBm\n", min, max)
+	}
+
+	w.txPower.Lock()
+	if w.txPower.changed {
+		fmt.Printf("  Original  : %.2f dBm (restored when off)\n", w.txPower.original)
+	}
+	w.txPower.Unlock()
+	fmt.Println()
+
+	return nil
+}
+
+// setTxPower validates the power against the limits of the adapter and
+// applies it, the first time saving the one to restore.
+func (w *WiFiModule) setTxPower(dbm float64) error {
+	iface := w.Session.Interface.Name()
+	if min, max, err := network.GetInterfaceTxPowerRange(iface); err != nil {
+		return err
+	} else if err := checkTxPower(dbm, min, max); err != nil {
+		return err
+	}
+
+	w.txPower.Lock()
+	defer w.txPower.Unlock()
+
+	if !w.txPower.changed {
+		original, err := network.GetInterfaceTxPower(iface)
+		if err != nil {
+			return err
+		}
+		w.txPower.iface, w.txPower.original = iface, original
+	}
+
+	if err := network.SetInterfaceTxPower(iface, dbm); err != nil {
+		return err
+	}
+	w.txPower.changed = true
+
+	log.Info("Transmit power of %s set to %.2f dBm.", iface, dbm)
+	return nil
+}
+
+// restoreTxPower returns true if the original transmit power had to be
+// restored.
+func (w *WiFiModule) restoreTxPower() (bool, error) {
+	w.txPower.Lock()
+	defer w.txPower.Unlock()
+
+	if !w.txPower.changed {
+		return false, nil
+	} else if err := network.SetInterfaceTxPower(w.txPower.iface, w.txPower.original); err != nil {
+		return true, err
+	}
+	w.txPower.changed = false
+
+	log.Info("Transmit power of %s restored to %.2f dBm.", w.txPower.iface, w.txPower.original)
+	return true, nil
+}
+
+// Revert restores the original transmit power if it has been changed.
+func (w *WiFiModule) Revert() (bool, error) {
+	return w.restoreTxPower()
+}
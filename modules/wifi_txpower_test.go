@@ -0,0 +1,98 @@
+package modules
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/bettercap/bettercap/session"
+)
+
+const testIwPhyInfo = `Wiphy phy1
+	Band 1:
+		Frequencies:
+			* 2412 MHz [1] (20.0 dBm)
+			* 2467 MHz [12] (disabled)
+`
+
+func TestWiFiCheckTxPower(t *testing.T) {
+	var units = []struct {
+		dbm   float64
+		valid bool
+	}{
+		{0, true},
+		{15.5, true},
+		{20, true},
+		{20.5, false},
+		{-1, false},
+	}
+
+	for _, u := range units {
+		if err := checkTxPower(u.dbm, 0, 20); (err == nil) != u.valid {
+			t.Fatalf("expected valid=%v for %.2f, got '%v'", u.valid, u.dbm, err)
+		}
+	}
+}
+
+func TestWiFiTxPower(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("iw commands are only tested on linux")
+	}
+
+	s, err := session.NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	s.ExecOutput = func(executable string, args []string) (string, error) {
+		if args[0] == "dev" && args[len(args)-1] == "info" {
+			return "Interface test0\n\ttype managed\n\twiphy 1\n\ttxpower 18.00 dBm\n", nil
+		} else if args[0] == "phy#1" {
+			return testIwPhyInfo, nil
+		}
+		return "", nil
+	}
+
+	w := NewWiFiModule(s.Session)
+	s.Register(w)
+
+	if err := s.Run("wifi.txpower 30"); err == nil {
+		t.Fatal("expected out of range error")
+	} else if !strings.Contains(err.Error(), "from 0.00 to 20.00 dBm") {
+		t.Fatalf("expected the allowed range, got '%s'", err)
+	} else if err := s.Run("wifi.txpower 10"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Run("wifi.txpower 5.5"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the module is not running, but the power is restored
+	if result := s.RevertAll(); result.ErrorOrNil() != nil {
+		t.Fatalf("unexpected error: %v", result.ErrorOrNil())
+	} else if result.Succeeded != 1 {
+		t.Fatalf("expected 1 success, got %d", result.Succeeded)
+	} else if changed, err := w.Revert(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if changed {
+		t.Fatal("expected the power to be already restored")
+	}
+
+	sets := []string{}
+	for _, cmd := range s.Executed() {
+		if strings.Contains(cmd, " set ") {
+			sets = append(sets, cmd)
+		}
+	}
+
+	// the original power is restored only once
+	exp := []string{
+		"iw dev test0 set txpower fixed 1000",
+		"iw dev test0 set txpower fixed 550",
+		"iw dev test0 set txpower fixed 1800",
+	}
+	if !reflect.DeepEqual(sets, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, sets)
+	}
+}
@@ -2,6 +2,7 @@ package network
 
 import (
 	"fmt"
+	"runtime"
 	"strings"
 	"sync"
 
@@ -99,3 +100,42 @@ func ArpParsed() bool {
 	defer arpLock.RUnlock()
 	return arpWasParsed
 }
+
+// ArpFlush empties the neighbor cache of the operating system for the
+// given interface, or for every interface if empty.
+func ArpFlush(iface string) error {
+	var cmd string
+	var args []string
+
+	os := runtime.GOOS
+	if os == "linux" || os == "android" {
+		cmd, args = "ip", []string{"neigh", "flush", "all"}
+		if iface != "" {
+			args = []string{"neigh", "flush", "dev", iface}
+		}
+	} else if strings.Contains(os, "bsd") || os == "darwin" {
+		cmd, args = "arp", []string{"-d", "-a"}
+		if iface != "" {
+			args = append(args, "-i", iface)
+		}
+	} else if os == "windows" {
+		cmd, args = "netsh", []string{"interface", "ip", "delete", "arpcache"}
+		if iface != "" {
+			args = append(args, iface)
+		}
+	} else {
+		return fmt.Errorf("OS %s is not supported by ArpFlush.", os)
+	}
+
+	if _, err := core.Exec(cmd, args); err != nil {
+		return err
+	}
+
+	// the cached table is stale now
+	arpLock.Lock()
+	defer arpLock.Unlock()
+	arpTable = make(ArpTable)
+	arpWasParsed = false
+
+	return nil
+}
@@ -57,6 +57,9 @@ func (b *BLE) AddIfNew(id string, p gatt.Peripheral, a *gatt.Advertisement, rssi
 		dev.LastSeen = time.Now()
 		dev.RSSI = rssi
 		dev.Advertisement = a
+		if dev.Restored() {
+			dev.Device = p
+		}
 		return dev
 	}
 
@@ -70,6 +73,23 @@ func (b *BLE) AddIfNew(id string, p gatt.Peripheral, a *gatt.Advertisement, rssi
 	return nil
 }
 
+// Restore adds the devices of a saved session which are not known yet
+// and returns how many have been added.
+func (b *BLE) Restore(devices []*BLEDevice) int {
+	b.Lock()
+	defer b.Unlock()
+
+	added := 0
+	for _, dev := range devices {
+		id := NormalizeMac(dev.Device.ID())
+		if _, found := b.devices[id]; !found {
+			b.devices[id] = dev
+			added++
+		}
+	}
+	return added
+}
+
 func (b *BLE) Remove(id string) {
 	b.Lock()
 	defer b.Unlock()
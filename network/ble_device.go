@@ -1,10 +1,11 @@
-// +build !windows
-// +build !darwin
+//go:build !windows && !darwin
+// +build !windows,!darwin
 
 package network
 
 import (
 	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/bettercap/gatt"
@@ -26,6 +27,66 @@ type bleDeviceJSON struct {
 	RSSI     int       `json:"rssi"`
 }
 
+var errBLERestored = errors.New("the device has been restored from a saved session and has not been discovered again yet")
+
+// restoredPeripheral stands for a device of a saved session until it is
+// discovered again, it can't be connected to.
+type restoredPeripheral struct {
+	id   string
+	name string
+}
+
+func (p *restoredPeripheral) Device() gatt.Device       { return nil }
+func (p *restoredPeripheral) ID() string                { return p.id }
+func (p *restoredPeripheral) Name() string              { return p.name }
+func (p *restoredPeripheral) Services() []*gatt.Service { return nil }
+func (p *restoredPeripheral) ReadRSSI() int             { return 0 }
+func (p *restoredPeripheral) SetMTU(mtu uint16) error   { return errBLERestored }
+
+func (p *restoredPeripheral) DiscoverServices(s []gatt.UUID) ([]*gatt.Service, error) {
+	return nil, errBLERestored
+}
+
+func (p *restoredPeripheral) DiscoverIncludedServices(ss []gatt.UUID, s *gatt.Service) ([]*gatt.Service, error) {
+	return nil, errBLERestored
+}
+
+func (p *restoredPeripheral) DiscoverCharacteristics(c []gatt.UUID, s *gatt.Service) ([]*gatt.Characteristic, error) {
+	return nil, errBLERestored
+}
+
+func (p *restoredPeripheral) DiscoverDescriptors(d []gatt.UUID, c *gatt.Characteristic) ([]*gatt.Descriptor, error) {
+	return nil, errBLERestored
+}
+
+func (p *restoredPeripheral) ReadCharacteristic(c *gatt.Characteristic) ([]byte, error) {
+	return nil, errBLERestored
+}
+
+func (p *restoredPeripheral) ReadLongCharacteristic(c *gatt.Characteristic) ([]byte, error) {
+	return nil, errBLERestored
+}
+
+func (p *restoredPeripheral) WriteCharacteristic(c *gatt.Characteristic, b []byte, noRsp bool) error {
+	return errBLERestored
+}
+
+func (p *restoredPeripheral) ReadDescriptor(d *gatt.Descriptor) ([]byte, error) {
+	return nil, errBLERestored
+}
+
+func (p *restoredPeripheral) WriteDescriptor(d *gatt.Descriptor, b []byte) error {
+	return errBLERestored
+}
+
+func (p *restoredPeripheral) SetNotifyValue(c *gatt.Characteristic, f func(*gatt.Characteristic, []byte, error)) error {
+	return errBLERestored
+}
+
+func (p *restoredPeripheral) SetIndicateValue(c *gatt.Characteristic, f func(*gatt.Characteristic, []byte, error)) error {
+	return errBLERestored
+}
+
 func NewBLEDevice(p gatt.Peripheral, a *gatt.Advertisement, rssi int) *BLEDevice {
 	return &BLEDevice{
 		LastSeen:      time.Now(),
@@ -47,3 +108,27 @@ func (d *BLEDevice) MarshalJSON() ([]byte, error) {
 
 	return json.Marshal(doc)
 }
+
+func (d *BLEDevice) UnmarshalJSON(raw []byte) error {
+	var doc bleDeviceJSON
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return err
+	}
+
+	d.LastSeen = doc.LastSeen
+	d.Vendor = doc.Vendor
+	d.RSSI = doc.RSSI
+	d.Device = &restoredPeripheral{
+		id:   NormalizeMac(doc.MAC),
+		name: doc.Name,
+	}
+	d.Advertisement = &gatt.Advertisement{LocalName: doc.Name}
+	return nil
+}
+
+// Restored is true for the devices of a saved session which have not
+// been discovered again.
+func (d *BLEDevice) Restored() bool {
+	_, restored := d.Device.(*restoredPeripheral)
+	return restored
+}
@@ -48,3 +48,11 @@ func (b *BLE) MarshalJSON() ([]byte, error) {
 	}
 	return json.Marshal(doc)
 }
+
+func (b *BLE) Restore(devices []*BLEDevice) int {
+	return 0
+}
+
+func (b *BLE) Devices() (devices []*BLEDevice) {
+	return make([]*BLEDevice, 0)
+}
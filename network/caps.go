@@ -0,0 +1,168 @@
+package network
+
+import (
+	"bufio"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bettercap/bettercap/core"
+)
+
+type Capability int
+
+const (
+	CapabilityUnknown Capability = iota
+	CapabilityMissing
+	CapabilitySupported
+)
+
+// InterfaceCaps is what an interface and its driver support.
+type InterfaceCaps struct {
+	Name      string
+	Driver    string
+	Wireless  bool
+	Promisc   Capability
+	Monitor   Capability
+	Injection Capability
+	// WiFi channels the interface can be tuned to
+	Channels []int
+}
+
+func NewInterfaceCaps(name string) *InterfaceCaps {
+	return &InterfaceCaps{
+		Name:     name,
+		Channels: make([]int, 0),
+	}
+}
+
+var (
+	iwSectionParser = regexp.MustCompile(`^\s*([^*].*):\s*$`)
+	iwItemParser    = regexp.MustCompile(`^\s*\*\s+(.+)$`)
+	iwFreqParser    = regexp.MustCompile(`^([0-9\.]+)\s+MHz\s+\[(\d+)\](.*)$`)
+	iwPowerParser   = regexp.MustCompile(`\((-?[0-9\.]+) dBm\)`)
+	iwWiphyParser   = regexp.MustCompile(`^\s*wiphy\s+(\d+)\s*$`)
+	iwTxPowerParser = regexp.MustCompile(`^\s*txpower\s+(-?[0-9\.]+)\s+dBm`)
+)
+
+// iwPhyInfo is the relevant part of the output of 'iw phy PHY info'.
+type iwPhyInfo struct {
+	// supported interface modes
+	Modes []string
+	// interface modes frames can be sent from
+	TxModes  []string
+	Channels []int
+	// enabled channels with their frequency and DFS flag
+	WiFiChannels []WiFiChannel
+	// highest transmit power in dBm of the enabled channels
+	MaxTxPower float64
+}
+
+func (i iwPhyInfo) HasMode(mode string) bool {
+	for _, m := range i.Modes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+func (i iwPhyInfo) CanTransmitFrom(mode string) bool {
+	for _, m := range i.TxModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+func parseIwPhyInfo(out string) iwPhyInfo {
+	info := iwPhyInfo{
+		Modes:        make([]string, 0),
+		TxModes:      make([]string, 0),
+		Channels:     make([]int, 0),
+		WiFiChannels: make([]WiFiChannel, 0),
+	}
+
+	section := ""
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := iwSectionParser.FindStringSubmatch(line); m != nil {
+			section = core.Trim(m[1])
+			continue
+		}
+
+		m := iwItemParser.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		item := core.Trim(m[1])
+
+		switch section {
+		case "Supported interface modes":
+			info.Modes = append(info.Modes, item)
+		case "Supported TX frame types":
+			if parts := strings.SplitN(item, ":", 2); len(parts) == 2 {
+				info.TxModes = append(info.TxModes, core.Trim(parts[0]))
+			}
+		case "Frequencies":
+			if f := iwFreqParser.FindStringSubmatch(item); f != nil && !strings.Contains(f[3], "disabled") {
+				if channel, err := strconv.Atoi(f[2]); err == nil {
+					info.Channels = append(info.Channels, channel)
+					if freq, err := strconv.ParseFloat(f[1], 64); err == nil {
+						info.WiFiChannels = append(info.WiFiChannels, WiFiChannel{
+							Number:    channel,
+							Frequency: int(freq),
+							DFS:       strings.Contains(f[3], "radar detection"),
+						})
+					}
+				}
+				if p := iwPowerParser.FindStringSubmatch(f[3]); p != nil {
+					if power, err := strconv.ParseFloat(p[1], 64); err == nil && power > info.MaxTxPower {
+						info.MaxTxPower = power
+					}
+				}
+			}
+		}
+	}
+
+	return info
+}
+
+// iwDevInfo is the relevant part of the output of 'iw dev IFACE info'.
+type iwDevInfo struct {
+	// index of the phy, -1 if not a WiFi interface
+	Wiphy int
+	// transmit power in dBm, if reported
+	TxPower    float64
+	HasTxPower bool
+}
+
+func parseIwDevInfo(out string) iwDevInfo {
+	info := iwDevInfo{Wiphy: -1}
+
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := iwWiphyParser.FindStringSubmatch(line); m != nil {
+			info.Wiphy, _ = strconv.Atoi(m[1])
+		} else if m := iwTxPowerParser.FindStringSubmatch(line); m != nil {
+			if power, err := strconv.ParseFloat(m[1], 64); err == nil {
+				info.TxPower, info.HasTxPower = power, true
+			}
+		}
+	}
+
+	return info
+}
+
+// parses the driver name out of 'ethtool -i IFACE'
+func parseEthtoolDriver(out string) string {
+	for _, line := range strings.Split(out, "\n") {
+		if parts := strings.SplitN(line, ":", 2); len(parts) == 2 && core.Trim(parts[0]) == "driver" {
+			return core.Trim(parts[1])
+		}
+	}
+	return ""
+}
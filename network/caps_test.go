@@ -0,0 +1,88 @@
+package network
+
+import (
+	"reflect"
+	"testing"
+)
+
+const exampleIwPhyInfo = `Wiphy phy0
+	max # scan SSIDs: 4
+	Supported Ciphers:
+		* WEP40 (00-0f-ac:1)
+		* CCMP-128 (00-0f-ac:4)
+	Supported interface modes:
+		 * IBSS
+		 * managed
+		 * AP
+		 * monitor
+	Band 1:
+		Capabilities: 0x1862
+		Frequencies:
+			* 2412 MHz [1] (20.0 dBm)
+			* 2417.0 MHz [2] (20.0 dBm)
+			* 2467 MHz [12] (disabled)
+	Band 2:
+		Frequencies:
+			* 5180 MHz [36] (23.0 dBm)
+			* 5260 MHz [52] (20.0 dBm) (radar detection)
+	Supported TX frame types:
+		 * IBSS: 0x00 0x10 0x20
+		 * managed: 0x00 0x10 0x20
+		 * monitor: 0x00 0x10 0x20
+	software interface modes (can always be added):
+		 * AP/VLAN
+`
+
+func TestParseIwPhyInfo(t *testing.T) {
+	info := parseIwPhyInfo(exampleIwPhyInfo)
+
+	if exp := []string{"IBSS", "managed", "AP", "monitor"}; !reflect.DeepEqual(info.Modes, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, info.Modes)
+	} else if exp := []string{"IBSS", "managed", "monitor"}; !reflect.DeepEqual(info.TxModes, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, info.TxModes)
+	} else if exp := []int{1, 2, 36, 52}; !reflect.DeepEqual(info.Channels, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, info.Channels)
+	} else if !info.HasMode("monitor") || info.HasMode("mesh point") {
+		t.Fatalf("unexpected modes '%v'", info.Modes)
+	} else if !info.CanTransmitFrom("monitor") || info.CanTransmitFrom("AP") {
+		t.Fatalf("unexpected tx modes '%v'", info.TxModes)
+	} else if exp := []WiFiChannel{{1, 2412, false}, {2, 2417, false}, {36, 5180, false}, {52, 5260, true}}; !reflect.DeepEqual(info.WiFiChannels, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, info.WiFiChannels)
+	} else if info.MaxTxPower != 23.0 {
+		t.Fatalf("expected '23.0', got '%.1f'", info.MaxTxPower)
+	}
+}
+
+func TestParseIwDevInfo(t *testing.T) {
+	var units = []struct {
+		out string
+		exp iwDevInfo
+	}{
+		{"Interface wlan0\n\tifindex 3\n\taddr 02:00:00:00:00:01\n\ttype managed\n\twiphy 1\n\ttxpower 20.00 dBm\n", iwDevInfo{1, 20.0, true}},
+		{"Interface wlan0\n\ttype monitor\n\twiphy 0\n", iwDevInfo{0, 0, false}},
+		{"command failed: No such device (-19)\n", iwDevInfo{-1, 0, false}},
+	}
+
+	for _, u := range units {
+		if got := parseIwDevInfo(u.out); got != u.exp {
+			t.Fatalf("expected '%v', got '%v'", u.exp, got)
+		}
+	}
+}
+
+func TestParseEthtoolDriver(t *testing.T) {
+	var units = []struct {
+		out      string
+		expected string
+	}{
+		{"driver: e1000e\nversion: 3.2.6-k\nfirmware-version: 0.13-3\n", "e1000e"},
+		{"driver:   iwlwifi  \nbus-info: 0000:03:00.0\n", "iwlwifi"},
+		{"Cannot get driver information: Operation not supported\n", ""},
+	}
+
+	for _, u := range units {
+		if got := parseEthtoolDriver(u.out); got != u.expected {
+			t.Fatalf("expected '%s', got '%s'", u.expected, got)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package network
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+// the sources of the guesses, the more reliable ones replace what was
+// guessed from the others
+const (
+	FingerprintTCP  = "tcp"
+	FingerprintDHCP = "dhcp"
+	FingerprintSSDP = "ssdp"
+	FingerprintMDNS = "mdns"
+	FingerprintHTTP = "http"
+)
+
+var fingerprintWeights = map[string]int{
+	FingerprintTCP:  1,
+	FingerprintDHCP: 2,
+	FingerprintSSDP: 3,
+	FingerprintMDNS: 4,
+	FingerprintHTTP: 4,
+}
+
+// FingerprintGuess is what a packet of a host tells about it, the
+// empty fields are unknown.
+type FingerprintGuess struct {
+	Source    string
+	Signature string
+	OS        string
+	Device    string
+	Model     string
+}
+
+// Fingerprint is the operating system, the kind of device and the model
+// of a host inferred passively from its traffic, with the signatures
+// they have been inferred from by source.
+type Fingerprint struct {
+	sync.Mutex
+	os      string
+	device  string
+	model   string
+	sources map[string]string
+	// weight of the source of os, device and model
+	weights [3]int
+}
+
+type fingerprintJSON struct {
+	OS      string            `json:"os"`
+	Device  string            `json:"device"`
+	Model   string            `json:"model"`
+	Sources map[string]string `json:"sources"`
+}
+
+func NewFingerprint() *Fingerprint {
+	return &Fingerprint{
+		sources: make(map[string]string),
+	}
+}
+
+func (f *Fingerprint) MarshalJSON() ([]byte, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	return json.Marshal(fingerprintJSON{
+		OS:      f.os,
+		Device:  f.device,
+		Model:   f.model,
+		Sources: f.sources,
+	})
+}
+
+func (f *Fingerprint) UnmarshalJSON(raw []byte) error {
+	var doc fingerprintJSON
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return err
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	f.os, f.device, f.model = doc.OS, doc.Device, doc.Model
+	f.sources = doc.Sources
+	if f.sources == nil {
+		f.sources = make(map[string]string)
+	}
+	return nil
+}
+
+func (f *Fingerprint) update(field *string, idx int, value string, weight int) bool {
+	if value == "" || weight < f.weights[idx] || *field == value {
+		return false
+	}
+	*field = value
+	f.weights[idx] = weight
+	return true
+}
+
+// Update applies a guess, replacing what was guessed from less reliable
+// sources, and returns true if the fingerprint changed.
+func (f *Fingerprint) Update(g FingerprintGuess) bool {
+	f.Lock()
+	defer f.Unlock()
+
+	weight := fingerprintWeights[g.Source]
+	changed := false
+	if g.Signature != "" && f.sources[g.Source] != g.Signature {
+		f.sources[g.Source] = g.Signature
+		changed = true
+	}
+	if f.update(&f.os, 0, g.OS, weight) {
+		changed = true
+	}
+	if f.update(&f.device, 1, g.Device, weight) {
+		changed = true
+	}
+	if f.update(&f.model, 2, g.Model, weight) {
+		changed = true
+	}
+	return changed
+}
+
+// Merge completes the fingerprint with another one without overwriting
+// anything.
+func (f *Fingerprint) Merge(other *Fingerprint) {
+	if other == nil || other == f {
+		return
+	}
+
+	other.Lock()
+	doc := fingerprintJSON{OS: other.os, Device: other.device, Model: other.model, Sources: make(map[string]string)}
+	for source, sig := range other.sources {
+		doc.Sources[source] = sig
+	}
+	other.Unlock()
+
+	f.Lock()
+	defer f.Unlock()
+
+	if f.os == "" {
+		f.os = doc.OS
+	}
+	if f.device == "" {
+		f.device = doc.Device
+	}
+	if f.model == "" {
+		f.model = doc.Model
+	}
+	for source, sig := range doc.Sources {
+		if _, found := f.sources[source]; !found {
+			f.sources[source] = sig
+		}
+	}
+}
+
+func (f *Fingerprint) OS() string {
+	f.Lock()
+	defer f.Unlock()
+	return f.os
+}
+
+func (f *Fingerprint) Device() string {
+	f.Lock()
+	defer f.Unlock()
+	return f.device
+}
+
+func (f *Fingerprint) Model() string {
+	f.Lock()
+	defer f.Unlock()
+	return f.model
+}
+
+func (f *Fingerprint) Empty() bool {
+	f.Lock()
+	defer f.Unlock()
+	return f.os == "" && f.device == "" && f.model == ""
+}
+
+// String returns something like "Android phone (Pixel 6)".
+func (f *Fingerprint) String() string {
+	f.Lock()
+	defer f.Unlock()
+
+	parts := make([]string, 0, 3)
+	if f.os != "" {
+		parts = append(parts, f.os)
+	}
+	if f.device != "" {
+		parts = append(parts, f.device)
+	}
+	if f.model != "" {
+		parts = append(parts, "("+f.model+")")
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,73 @@
+package network
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFingerprintUpdate(t *testing.T) {
+	f := NewFingerprint()
+	if !f.Empty() {
+		t.Fatal("expected a new fingerprint to be empty")
+	}
+
+	var units = []struct {
+		guess   FingerprintGuess
+		changed bool
+		exp     string
+	}{
+		{FingerprintGuess{Source: FingerprintTCP, Signature: "64:29200:M,S,T,N,W", OS: "Linux"}, true, "Linux"},
+		{FingerprintGuess{Source: FingerprintTCP, Signature: "64:29200:M,S,T,N,W", OS: "Linux"}, false, "Linux"},
+		// more reliable sources replace the guesses of the others
+		{FingerprintGuess{Source: FingerprintDHCP, Signature: "1,3,6", OS: "Android", Device: "phone"}, true, "Android phone"},
+		{FingerprintGuess{Source: FingerprintHTTP, Signature: "ua", OS: "Android 13", Model: "Pixel 7"}, true, "Android 13 phone (Pixel 7)"},
+		// but not the other way around, even if the signature is stored
+		{FingerprintGuess{Source: FingerprintTCP, Signature: "64:65535:M,N,W,S,T", OS: "FreeBSD"}, true, "Android 13 phone (Pixel 7)"},
+	}
+
+	for _, u := range units {
+		if got := f.Update(u.guess); got != u.changed {
+			t.Fatalf("expected %v for %v, got %v", u.changed, u.guess, got)
+		} else if got := f.String(); got != u.exp {
+			t.Fatalf("expected '%s', got '%s'", u.exp, got)
+		}
+	}
+
+	if got := f.sources[FingerprintTCP]; got != "64:65535:M,N,W,S,T" {
+		t.Fatalf("expected '64:65535:M,N,W,S,T', got '%s'", got)
+	}
+}
+
+func TestFingerprintJSON(t *testing.T) {
+	f := NewFingerprint()
+	f.Update(FingerprintGuess{Source: FingerprintMDNS, Signature: "_googlecast._tcp", Device: "media player", Model: "Chromecast"})
+
+	raw, err := json.Marshal(f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	restored := &Fingerprint{}
+	if err := json.Unmarshal(raw, restored); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if got := restored.String(); got != "media player (Chromecast)" {
+		t.Fatalf("expected 'media player (Chromecast)', got '%s'", got)
+	} else if got := restored.sources[FingerprintMDNS]; got != "_googlecast._tcp" {
+		t.Fatalf("expected '_googlecast._tcp', got '%s'", got)
+	}
+}
+
+func TestFingerprintMerge(t *testing.T) {
+	e := NewEndpointNoResolve("192.168.1.10", "aa:00:00:00:00:10", "", 24)
+	e.Fingerprint.Update(FingerprintGuess{Source: FingerprintTCP, OS: "Windows"})
+
+	saved := NewEndpointNoResolve("192.168.1.10", "aa:00:00:00:00:10", "", 24)
+	saved.Fingerprint.Update(FingerprintGuess{Source: FingerprintHTTP, Signature: "ua", OS: "Windows 10", Device: "computer"})
+
+	e.Merge(saved)
+	if got := e.Fingerprint.String(); got != "Windows computer" {
+		t.Fatalf("expected 'Windows computer', got '%s'", got)
+	} else if got := e.Fingerprint.sources[FingerprintHTTP]; got != "ua" {
+		t.Fatalf("expected 'ua', got '%s'", got)
+	}
+}
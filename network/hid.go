@@ -0,0 +1,94 @@
+package network
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+type HIDDevNewCallback func(dev *HIDDevice)
+type HIDDevLostCallback func(dev *HIDDevice)
+
+type HID struct {
+	sync.RWMutex
+	devices map[string]*HIDDevice
+	newCb   HIDDevNewCallback
+	lostCb  HIDDevLostCallback
+}
+
+type hidJSON struct {
+	Devices []*HIDDevice `json:"devices"`
+}
+
+func NewHID(newcb HIDDevNewCallback, lostcb HIDDevLostCallback) *HID {
+	return &HID{
+		devices: make(map[string]*HIDDevice),
+		newCb:   newcb,
+		lostCb:  lostcb,
+	}
+}
+
+func (h *HID) MarshalJSON() ([]byte, error) {
+	doc := hidJSON{
+		Devices: h.Devices(),
+	}
+	return json.Marshal(doc)
+}
+
+func (h *HID) Get(address string) (dev *HIDDevice, found bool) {
+	h.RLock()
+	defer h.RUnlock()
+
+	if raw, err := ParseHIDAddress(address); err == nil {
+		dev, found = h.devices[HIDAddress(raw)]
+	}
+	return
+}
+
+// AddIfNew tracks the payload sent by the device with the address on the
+// channel, it returns the device if it was already known.
+func (h *HID) AddIfNew(address []byte, channel int, payload []byte) *HIDDevice {
+	h.Lock()
+	defer h.Unlock()
+
+	id := HIDAddress(address)
+	if dev, found := h.devices[id]; found {
+		dev.AddPayload(channel, payload)
+		return dev
+	}
+
+	newDev := NewHIDDevice(address)
+	newDev.AddPayload(channel, payload)
+	h.devices[id] = newDev
+
+	if h.newCb != nil {
+		h.newCb(newDev)
+	}
+
+	return nil
+}
+
+func (h *HID) Remove(address string) {
+	h.Lock()
+	defer h.Unlock()
+
+	if raw, err := ParseHIDAddress(address); err == nil {
+		id := HIDAddress(raw)
+		if dev, found := h.devices[id]; found {
+			delete(h.devices, id)
+			if h.lostCb != nil {
+				h.lostCb(dev)
+			}
+		}
+	}
+}
+
+func (h *HID) Devices() (devices []*HIDDevice) {
+	h.RLock()
+	defer h.RUnlock()
+
+	devices = make([]*HIDDevice, 0)
+	for _, dev := range h.devices {
+		devices = append(devices, dev)
+	}
+	return
+}
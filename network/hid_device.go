@@ -0,0 +1,175 @@
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type HIDType int
+
+const (
+	HIDTypeUnknown HIDType = iota
+	HIDTypeLogitech
+	HIDTypeAmazon
+	HIDTypeMicrosoft
+)
+
+func (t HIDType) String() string {
+	switch t {
+	case HIDTypeLogitech:
+		return "logitech"
+	case HIDTypeAmazon:
+		return "amazon"
+	case HIDTypeMicrosoft:
+		return "microsoft"
+	}
+	return ""
+}
+
+// ParseHIDType returns the type of a name as returned by HIDType.String.
+func ParseHIDType(name string) (HIDType, error) {
+	for _, t := range []HIDType{HIDTypeLogitech, HIDTypeAmazon, HIDTypeMicrosoft} {
+		if strings.EqualFold(name, t.String()) {
+			return t, nil
+		}
+	}
+	return HIDTypeUnknown, fmt.Errorf("'%s' is not a valid HID device type, use logitech, amazon or microsoft.", name)
+}
+
+// HIDDevice is a wireless keyboard or mouse dongle seen on the air.
+type HIDDevice struct {
+	sync.Mutex
+	Address []byte
+	Type    HIDType
+	// true if the payloads are XOR'ed with the address (Microsoft)
+	Encrypted bool
+	LastSeen  time.Time
+	channels  map[int]bool
+	payloads  uint64
+}
+
+type hidDeviceJSON struct {
+	LastSeen  time.Time `json:"last_seen"`
+	Address   string    `json:"address"`
+	Type      string    `json:"type"`
+	Encrypted bool      `json:"encrypted"`
+	Channels  []int     `json:"channels"`
+	Payloads  uint64    `json:"payloads"`
+}
+
+func NewHIDDevice(address []byte) *HIDDevice {
+	return &HIDDevice{
+		Address:  address,
+		LastSeen: time.Now(),
+		channels: make(map[int]bool),
+	}
+}
+
+// HIDAddress formats an address as AA:BB:CC:DD:EE.
+func HIDAddress(address []byte) string {
+	parts := make([]string, len(address))
+	for i, b := range address {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+// ParseHIDAddress parses an AA:BB:CC:DD:EE address.
+func ParseHIDAddress(address string) ([]byte, error) {
+	parts := strings.Split(address, ":")
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("'%s' is not a valid HID device address.", address)
+	}
+
+	raw := make([]byte, len(parts))
+	for i, part := range parts {
+		b, err := strconv.ParseUint(part, 16, 8)
+		if err != nil || len(part) != 2 {
+			return nil, fmt.Errorf("'%s' is not a valid HID device address.", address)
+		}
+		raw[i] = byte(b)
+	}
+	return raw, nil
+}
+
+func (d *HIDDevice) String() string {
+	return HIDAddress(d.Address)
+}
+
+// Channels returns the sorted channels the device has been seen on.
+func (d *HIDDevice) Channels() []int {
+	d.Lock()
+	defer d.Unlock()
+
+	channels := make([]int, 0, len(d.channels))
+	for ch := range d.channels {
+		channels = append(channels, ch)
+	}
+	sort.Ints(channels)
+	return channels
+}
+
+func (d *HIDDevice) Payloads() uint64 {
+	d.Lock()
+	defer d.Unlock()
+	return d.payloads
+}
+
+// AddPayload tracks a payload received on the channel and detects the
+// type of the device from it if still unknown.
+func (d *HIDDevice) AddPayload(channel int, payload []byte) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.LastSeen = time.Now()
+	d.channels[channel] = true
+	d.payloads++
+
+	if d.Type == HIDTypeUnknown {
+		d.Type, d.Encrypted = DetectHIDType(payload)
+	}
+}
+
+// DetectHIDType returns the type of the device given one of its payloads,
+// as documented by the MouseJack research.
+func DetectHIDType(p []byte) (HIDType, bool) {
+	sz := len(p)
+	switch {
+	case sz == 6:
+		// mouse movement
+		return HIDTypeAmazon, false
+	case sz == 10 && p[0] == 0x00 && (p[1] == 0xc2 || p[1] == 0x4f):
+		// mouse movement or keepalive timeout
+		return HIDTypeLogitech, false
+	case sz == 22 && p[0] == 0x00 && p[1] == 0xd3:
+		// encrypted keystroke
+		return HIDTypeLogitech, false
+	case sz == 5 && p[0] == 0x00 && p[1] == 0x40:
+		// keepalive
+		return HIDTypeLogitech, false
+	case sz == 19 && (p[0] == 0x08 || p[0] == 0x0c) && p[6] == 0x40:
+		// mouse movement
+		return HIDTypeMicrosoft, false
+	case sz == 19 && p[0] == 0x0a:
+		// mouse movement XOR'ed with the address
+		return HIDTypeMicrosoft, true
+	}
+	return HIDTypeUnknown, false
+}
+
+func (d *HIDDevice) MarshalJSON() ([]byte, error) {
+	doc := hidDeviceJSON{
+		LastSeen:  d.LastSeen,
+		Address:   d.String(),
+		Type:      d.Type.String(),
+		Encrypted: d.Encrypted,
+		Channels:  d.Channels(),
+		Payloads:  d.Payloads(),
+	}
+	return json.Marshal(doc)
+}
@@ -0,0 +1,80 @@
+package network
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestHIDAddress(t *testing.T) {
+	raw, err := ParseHIDAddress("aa:0B:cc:1D:ee")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !bytes.Equal(raw, []byte{0xaa, 0x0b, 0xcc, 0x1d, 0xee}) {
+		t.Fatalf("unexpected address %x", raw)
+	} else if got := HIDAddress(raw); got != "AA:0B:CC:1D:EE" {
+		t.Fatalf("expected 'AA:0B:CC:1D:EE', got '%s'", got)
+	}
+
+	for _, bad := range []string{"", "aa:bb:cc:dd", "aa:bb:cc:dd:ee:ff", "aa:bb:cc:dd:gg", "aa:bb:cc:dd:e"} {
+		if _, err := ParseHIDAddress(bad); err == nil {
+			t.Fatalf("expected error for '%s'", bad)
+		}
+	}
+}
+
+func TestDetectHIDType(t *testing.T) {
+	var units = []struct {
+		payload   []byte
+		exp       HIDType
+		encrypted bool
+	}{
+		{[]byte{0x00, 0xc2, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x3d}, HIDTypeLogitech, false},
+		{[]byte{0x00, 0x40, 0x04, 0xb0, 0x0c}, HIDTypeLogitech, false},
+		{append([]byte{0x00, 0xd3}, make([]byte, 20)...), HIDTypeLogitech, false},
+		{[]byte{0x00, 0x00, 0x01, 0x00, 0x00, 0x00}, HIDTypeAmazon, false},
+		{[]byte{0x08, 0x90, 0x01, 0x01, 0x00, 0x00, 0x40, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, HIDTypeMicrosoft, false},
+		{append([]byte{0x0a}, make([]byte, 18)...), HIDTypeMicrosoft, true},
+		{[]byte{0x01, 0x02, 0x03}, HIDTypeUnknown, false},
+		{[]byte{}, HIDTypeUnknown, false},
+	}
+
+	for _, u := range units {
+		if got, encrypted := DetectHIDType(u.payload); got != u.exp || encrypted != u.encrypted {
+			t.Fatalf("expected '%s' (%v) for %x, got '%s' (%v)", u.exp, u.encrypted, u.payload, got, encrypted)
+		}
+	}
+}
+
+func TestHIDAddIfNew(t *testing.T) {
+	added := make([]*HIDDevice, 0)
+	lost := make([]*HIDDevice, 0)
+	h := NewHID(func(dev *HIDDevice) {
+		added = append(added, dev)
+	}, func(dev *HIDDevice) {
+		lost = append(lost, dev)
+	})
+
+	address := []byte{0xaa, 0xbb, 0xcc, 0xdd, 0xee}
+	if dev := h.AddIfNew(address, 5, []byte{0x01}); dev != nil {
+		t.Fatal("expected a new device")
+	} else if dev := h.AddIfNew(address, 62, []byte{0x00, 0x40, 0x04, 0xb0, 0x0c}); dev == nil {
+		t.Fatal("expected a known device")
+	}
+
+	dev, found := h.Get("aa:bb:cc:dd:ee")
+	if !found || len(added) != 1 || added[0] != dev {
+		t.Fatal("expected the device to be found")
+	} else if dev.Type != HIDTypeLogitech {
+		t.Fatalf("expected 'logitech', got '%s'", dev.Type)
+	} else if exp := []int{5, 62}; !reflect.DeepEqual(dev.Channels(), exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, dev.Channels())
+	} else if dev.Payloads() != 2 {
+		t.Fatalf("expected 2 payloads, got %d", dev.Payloads())
+	}
+
+	h.Remove("AA:BB:CC:DD:EE")
+	if len(h.Devices()) != 0 || len(lost) != 1 {
+		t.Fatal("expected the device to be removed")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net"
 	"strings"
 	"sync"
+	"time"
 )
 
 const LANDefaultttl = 10
@@ -21,8 +22,15 @@ type LAN struct {
 	gateway *Endpoint
 	ttl     map[string]uint
 	aliases *Aliases
-	newCb   EndpointNewCallback
-	lostCb  EndpointLostCallback
+	// annotations by mac, so that they survive the host being lost
+	tags  map[string][]string
+	notes map[string]string
+	// IPv6 addresses by mac, they can be learned before the host
+	ipv6 map[string]net.IP
+	// hosts found by remote instances, they're not in our ARP cache
+	remote map[string]bool
+	newCb  EndpointNewCallback
+	lostCb EndpointLostCallback
 }
 
 type lanJSON struct {
@@ -41,6 +49,10 @@ func NewLAN(iface, gateway *Endpoint, newcb EndpointNewCallback, lostcb Endpoint
 		hosts:   make(map[string]*Endpoint),
 		ttl:     make(map[string]uint),
 		aliases: aliases,
+		tags:    make(map[string][]string),
+		notes:   make(map[string]string),
+		ipv6:    make(map[string]net.IP),
+		remote:  make(map[string]bool),
 		newCb:   newcb,
 		lostCb:  lostcb,
 	}
@@ -71,6 +83,65 @@ func (lan *LAN) SetAliasFor(mac, alias string) bool {
 	return false
 }
 
+// Tag adds the tags the host with the given mac doesn't have yet.
+func (lan *LAN) Tag(mac string, tags ...string) bool {
+	lan.Lock()
+	defer lan.Unlock()
+
+	mac = NormalizeMac(mac)
+	if e, found := lan.hosts[mac]; found {
+		for _, tag := range tags {
+			if tag != "" && !e.HasTag(tag) {
+				e.Tags = append(e.Tags, tag)
+			}
+		}
+		lan.tags[mac] = append([]string{}, e.Tags...)
+		return true
+	}
+	return false
+}
+
+// Untag removes the tags from the host with the given mac.
+func (lan *LAN) Untag(mac string, tags ...string) bool {
+	lan.Lock()
+	defer lan.Unlock()
+
+	mac = NormalizeMac(mac)
+	if e, found := lan.hosts[mac]; found {
+		kept := make([]string, 0)
+		for _, have := range e.Tags {
+			remove := false
+			for _, tag := range tags {
+				if strings.EqualFold(have, tag) {
+					remove = true
+					break
+				}
+			}
+			if !remove {
+				kept = append(kept, have)
+			}
+		}
+		e.Tags = kept
+		lan.tags[mac] = append([]string{}, kept...)
+		return true
+	}
+	return false
+}
+
+// SetNoteFor sets the free form note of the host, an empty one removes it.
+func (lan *LAN) SetNoteFor(mac, note string) bool {
+	lan.Lock()
+	defer lan.Unlock()
+
+	mac = NormalizeMac(mac)
+	if e, found := lan.hosts[mac]; found {
+		e.Note = note
+		lan.notes[mac] = note
+		return true
+	}
+	return false
+}
+
 func (lan *LAN) Get(mac string) (*Endpoint, bool) {
 	lan.Lock()
 	defer lan.Unlock()
@@ -114,7 +185,7 @@ func (lan *LAN) Remove(ip, mac string) {
 	lan.Lock()
 	defer lan.Unlock()
 
-	if e, found := lan.hosts[mac]; found {
+	if e, found := lan.hosts[mac]; found && !lan.remote[mac] {
 		lan.ttl[mac]--
 		if lan.ttl[mac] == 0 {
 			delete(lan.hosts, mac)
@@ -125,6 +196,25 @@ func (lan *LAN) Remove(ip, mac string) {
 	}
 }
 
+// Prune removes every host which has not been seen for longer than maxAge
+// and returns the removed endpoints.
+func (lan *LAN) Prune(maxAge time.Duration) []*Endpoint {
+	lan.Lock()
+	defer lan.Unlock()
+
+	pruned := make([]*Endpoint, 0)
+	for mac, e := range lan.hosts {
+		if time.Since(e.LastSeen) > maxAge {
+			delete(lan.hosts, mac)
+			delete(lan.ttl, mac)
+			delete(lan.remote, mac)
+			lan.lostCb(e)
+			pruned = append(pruned, e)
+		}
+	}
+	return pruned
+}
+
 func (lan *LAN) shouldIgnore(ip, mac string) bool {
 	// skip our own address
 	if ip == lan.iface.IpAddress || mac == lan.iface.HwAddress {
@@ -198,6 +288,13 @@ func (lan *LAN) AddIfNew(ip, mac string) *Endpoint {
 	}
 
 	e := NewEndpointWithAlias(ip, mac, lan.aliases.Get(mac))
+	if tags, found := lan.tags[mac]; found {
+		e.Tags = append(e.Tags, tags...)
+	}
+	e.Note = lan.notes[mac]
+	if ip6, found := lan.ipv6[mac]; found {
+		e.UpdateIPv6(ip6)
+	}
 
 	lan.hosts[mac] = e
 	lan.ttl[mac] = LANDefaultttl
@@ -207,6 +304,122 @@ func (lan *LAN) AddIfNew(ip, mac string) *Endpoint {
 	return nil
 }
 
+// Restore adds the hosts of a saved session which are not known yet and
+// completes the known ones and the gateway with what was saved, it
+// returns how many hosts have been added.
+func (lan *LAN) Restore(hosts []*Endpoint) int {
+	return lan.merge(hosts, false)
+}
+
+// AddRemote adds the hosts found by a remote instance, even the ones
+// outside of our subnet which are never removed for missing from our ARP
+// cache, and returns how many are new.
+func (lan *LAN) AddRemote(hosts []*Endpoint) int {
+	return lan.merge(hosts, true)
+}
+
+func (lan *LAN) merge(hosts []*Endpoint, remote bool) int {
+	lan.Lock()
+	defer lan.Unlock()
+
+	added := 0
+	for _, h := range hosts {
+		// saved as null or by an older version
+		if h.Traffic == nil {
+			h.Traffic = NewEndpointTraffic()
+		}
+		if h.Meta == nil {
+			h.Meta = NewMeta()
+		}
+		if h.Fingerprint == nil {
+			h.Fingerprint = NewFingerprint()
+		}
+
+		mac := h.HwAddress
+		if mac == lan.gateway.HwAddress {
+			lan.gateway.Merge(h)
+			continue
+		} else if remote && (mac == "" || mac == lan.iface.HwAddress || mac == BroadcastMac) {
+			continue
+		} else if !remote && lan.shouldIgnore(h.IpAddress, mac) {
+			continue
+		} else if e, found := lan.hosts[mac]; found {
+			e.Merge(h)
+			continue
+		}
+
+		if alias := lan.aliases.Get(mac); alias != "" {
+			h.Alias = alias
+		}
+		if ip6, found := lan.ipv6[mac]; found {
+			h.UpdateIPv6(ip6)
+		}
+		if len(h.Tags) > 0 {
+			lan.tags[mac] = append([]string{}, h.Tags...)
+		}
+		if h.Note != "" {
+			lan.notes[mac] = h.Note
+		}
+
+		lan.hosts[mac] = h
+		lan.ttl[mac] = LANDefaultttl
+		if remote {
+			lan.remote[mac] = true
+			lan.newCb(h)
+		}
+		added++
+	}
+
+	return added
+}
+
+// SetIPv6For sets the IPv6 address of the host or gateway with the given
+// mac, returning true if it changed.
+func (lan *LAN) SetIPv6For(mac, address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil || ip.To4() != nil {
+		return false
+	}
+
+	lan.Lock()
+	defer lan.Unlock()
+
+	mac = NormalizeMac(mac)
+	if mac == lan.iface.HwAddress {
+		return false
+	} else if mac == lan.gateway.HwAddress {
+		return lan.gateway.UpdateIPv6(ip)
+	}
+
+	if have, found := lan.ipv6[mac]; !found || ipv6Rank(ip) > ipv6Rank(have) {
+		lan.ipv6[mac] = ip
+	}
+
+	if e, found := lan.hosts[mac]; found {
+		return e.UpdateIPv6(ip)
+	}
+	return false
+}
+
+// GetByIp6 returns the host with the IPv6 address.
+func (lan *LAN) GetByIp6(address string) *Endpoint {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return nil
+	}
+
+	lan.Lock()
+	defer lan.Unlock()
+
+	for _, e := range lan.hosts {
+		if ip.Equal(e.IPv6) {
+			return e
+		}
+	}
+
+	return nil
+}
+
 func (lan *LAN) GetAlias(mac string) string {
 	return lan.aliases.Get(mac)
 }
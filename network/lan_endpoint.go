@@ -2,6 +2,7 @@ package network
 
 import (
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"net"
 	"strconv"
@@ -19,6 +20,7 @@ type Endpoint struct {
 	Net              *net.IPNet             `json:"-"`
 	IPv6             net.IP                 `json:"-"`
 	HW               net.HardwareAddr       `json:"-"`
+	Flags            net.Flags              `json:"-"`
 	IpAddress        string                 `json:"ipv4"`
 	Ip6Address       string                 `json:"ipv6"`
 	SubnetBits       uint32                 `json:"-"`
@@ -27,10 +29,32 @@ type Endpoint struct {
 	Hostname         string                 `json:"hostname"`
 	Alias            string                 `json:"alias"`
 	Vendor           string                 `json:"vendor"`
+	Tags             []string               `json:"tags"`
+	Note             string                 `json:"note"`
 	ResolvedCallback OnHostResolvedCallback `json:"-"`
 	FirstSeen        time.Time              `json:"first_seen"`
 	LastSeen         time.Time              `json:"last_seen"`
 	Meta             *Meta                  `json:"meta"`
+	Traffic          *EndpointTraffic       `json:"traffic"`
+	Fingerprint      *Fingerprint           `json:"fingerprint"`
+}
+
+// endpointJSON are the fields an endpoint is rebuilt from when a saved
+// session is restored.
+type endpointJSON struct {
+	IpAddress   string           `json:"ipv4"`
+	Ip6Address  string           `json:"ipv6"`
+	HwAddress   string           `json:"mac"`
+	Hostname    string           `json:"hostname"`
+	Alias       string           `json:"alias"`
+	Vendor      string           `json:"vendor"`
+	Tags        []string         `json:"tags"`
+	Note        string           `json:"note"`
+	FirstSeen   time.Time        `json:"first_seen"`
+	LastSeen    time.Time        `json:"last_seen"`
+	Meta        *Meta            `json:"meta"`
+	Traffic     *EndpointTraffic `json:"traffic"`
+	Fingerprint *Fingerprint     `json:"fingerprint"`
 }
 
 func NewEndpointNoResolve(ip, mac, name string, bits uint32) *Endpoint {
@@ -48,10 +72,13 @@ func NewEndpointNoResolve(ip, mac, name string, bits uint32) *Endpoint {
 		HwAddress:        mac,
 		Hostname:         name,
 		Vendor:           OuiLookup(mac),
+		Tags:             make([]string, 0),
 		ResolvedCallback: nil,
 		FirstSeen:        now,
 		LastSeen:         now,
 		Meta:             NewMeta(),
+		Traffic:          NewEndpointTraffic(),
+		Fingerprint:      NewFingerprint(),
 	}
 
 	e.SetIP(ip)
@@ -81,6 +108,75 @@ func NewEndpointWithAlias(ip, mac, alias string) *Endpoint {
 	return e
 }
 
+func (t *Endpoint) UnmarshalJSON(raw []byte) error {
+	var doc endpointJSON
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return err
+	}
+
+	*t = *NewEndpointNoResolve(doc.IpAddress, doc.HwAddress, doc.Hostname, 0)
+	if ip := net.ParseIP(doc.Ip6Address); ip != nil {
+		t.UpdateIPv6(ip)
+	}
+	if t.Vendor == "" {
+		t.Vendor = doc.Vendor
+	}
+	if doc.Tags != nil {
+		t.Tags = doc.Tags
+	}
+	if doc.Meta != nil {
+		t.Meta = doc.Meta
+	}
+	if doc.Traffic != nil {
+		t.Traffic = doc.Traffic
+	}
+	if doc.Fingerprint != nil {
+		t.Fingerprint = doc.Fingerprint
+	}
+	t.Alias = doc.Alias
+	t.Note = doc.Note
+	t.FirstSeen = doc.FirstSeen
+	t.LastSeen = doc.LastSeen
+
+	return nil
+}
+
+// Merge completes what is known about the endpoint with another copy of
+// it, such as a saved one, without overwriting anything.
+func (t *Endpoint) Merge(other *Endpoint) {
+	if t.Hostname == "" {
+		t.Hostname = other.Hostname
+	}
+	if t.Alias == "" {
+		t.Alias = other.Alias
+	}
+	if t.Note == "" {
+		t.Note = other.Note
+	}
+	for _, tag := range other.Tags {
+		if !t.HasTag(tag) {
+			t.Tags = append(t.Tags, tag)
+		}
+	}
+	if other.IPv6 != nil {
+		t.UpdateIPv6(other.IPv6)
+	}
+	if other.FirstSeen.Before(t.FirstSeen) {
+		t.FirstSeen = other.FirstSeen
+	}
+	if other.LastSeen.After(t.LastSeen) {
+		t.LastSeen = other.LastSeen
+	}
+
+	other.Meta.Each(func(name string, value interface{}) {
+		if t.Meta.GetOr(name, nil) == nil {
+			t.Meta.Set(name, value)
+		}
+	})
+
+	t.Fingerprint.Merge(other.Fingerprint)
+}
+
 func ip2int(ip net.IP) uint32 {
 	if len(ip) == 16 {
 		return binary.BigEndian.Uint32(ip[12:16])
@@ -108,6 +204,28 @@ func (t *Endpoint) SetIPv6(netw string) {
 	}
 }
 
+// ipv6Rank is how much an address identifies a host, global ones more
+// than link-local ones.
+func ipv6Rank(ip net.IP) int {
+	if ip.IsLinkLocalUnicast() {
+		return 0
+	}
+	return 1
+}
+
+// UpdateIPv6 sets the IPv6 address of the endpoint if it has none yet or
+// only a link-local one the address replaces, either way it returns true
+// if it changed.
+func (t *Endpoint) UpdateIPv6(ip net.IP) bool {
+	if t.IPv6 != nil && ipv6Rank(ip) <= ipv6Rank(t.IPv6) {
+		return false
+	}
+
+	t.IPv6 = ip
+	t.Ip6Address = ip.String()
+	return true
+}
+
 func (t *Endpoint) SetIP(ip string) {
 	addr := net.ParseIP(ip)
 	t.IP = addr
@@ -135,6 +253,17 @@ func (t *Endpoint) CIDR() string {
 	return fmt.Sprintf("%s/%d", ip.String(), t.SubnetBits)
 }
 
+// HasTag returns true if the endpoint has been tagged with tag, tags
+// are case insensitive.
+func (t *Endpoint) HasTag(tag string) bool {
+	for _, have := range t.Tags {
+		if strings.EqualFold(have, tag) {
+			return true
+		}
+	}
+	return false
+}
+
 func (t *Endpoint) IsMonitor() bool {
 	return t.IpAddress == MonitorModeAddress
 }
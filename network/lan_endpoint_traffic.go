@@ -0,0 +1,91 @@
+package network
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// EndpointTraffic accounts the bytes and packets sent and
+// received by an endpoint while net.recon is running.
+type EndpointTraffic struct {
+	sync.Mutex
+	sent        uint64
+	received    uint64
+	pktSent     uint64
+	pktReceived uint64
+}
+
+type endpointTrafficJSON struct {
+	Sent        uint64 `json:"sent"`
+	Received    uint64 `json:"received"`
+	PktSent     uint64 `json:"pkt_sent"`
+	PktReceived uint64 `json:"pkt_received"`
+}
+
+func NewEndpointTraffic() *EndpointTraffic {
+	return &EndpointTraffic{}
+}
+
+func (t *EndpointTraffic) Track(size uint64, sent bool) {
+	t.Lock()
+	defer t.Unlock()
+
+	if sent {
+		t.sent += size
+		t.pktSent++
+	} else {
+		t.received += size
+		t.pktReceived++
+	}
+}
+
+func (t *EndpointTraffic) Reset() {
+	t.Lock()
+	defer t.Unlock()
+
+	t.sent, t.received, t.pktSent, t.pktReceived = 0, 0, 0, 0
+}
+
+func (t *EndpointTraffic) Bytes() (sent uint64, received uint64) {
+	t.Lock()
+	defer t.Unlock()
+	return t.sent, t.received
+}
+
+func (t *EndpointTraffic) Packets() (sent uint64, received uint64) {
+	t.Lock()
+	defer t.Unlock()
+	return t.pktSent, t.pktReceived
+}
+
+func (t *EndpointTraffic) Total() uint64 {
+	t.Lock()
+	defer t.Unlock()
+	return t.sent + t.received
+}
+
+func (t *EndpointTraffic) MarshalJSON() ([]byte, error) {
+	t.Lock()
+	defer t.Unlock()
+
+	return json.Marshal(endpointTrafficJSON{
+		Sent:        t.sent,
+		Received:    t.received,
+		PktSent:     t.pktSent,
+		PktReceived: t.pktReceived,
+	})
+}
+
+func (t *EndpointTraffic) UnmarshalJSON(raw []byte) error {
+	var doc endpointTrafficJSON
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return err
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	t.sent, t.received = doc.Sent, doc.Received
+	t.pktSent, t.pktReceived = doc.PktSent, doc.PktReceived
+	return nil
+}
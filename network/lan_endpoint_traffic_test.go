@@ -0,0 +1,35 @@
+package network
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEndpointTraffic(t *testing.T) {
+	traffic := NewEndpointTraffic()
+	traffic.Track(100, true)
+	traffic.Track(50, true)
+	traffic.Track(1000, false)
+
+	if sent, received := traffic.Bytes(); sent != 150 || received != 1000 {
+		t.Fatalf("expected 150/1000 bytes, got %d/%d", sent, received)
+	} else if sent, received := traffic.Packets(); sent != 2 || received != 1 {
+		t.Fatalf("expected 2/1 packets, got %d/%d", sent, received)
+	} else if total := traffic.Total(); total != 1150 {
+		t.Fatalf("expected 1150, got %d", total)
+	}
+
+	raw, err := json.Marshal(traffic)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if exp := `{"sent":150,"received":1000,"pkt_sent":2,"pkt_received":1}`; string(raw) != exp {
+		t.Fatalf("expected '%s', got '%s'", exp, raw)
+	}
+
+	traffic.Reset()
+	if total := traffic.Total(); total != 0 {
+		t.Fatalf("expected 0, got %d", total)
+	} else if sent, received := traffic.Packets(); sent != 0 || received != 0 {
+		t.Fatalf("expected 0/0 packets, got %d/%d", sent, received)
+	}
+}
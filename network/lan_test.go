@@ -1,7 +1,10 @@
 package network
 
 import (
+	"encoding/json"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func buildExampleLAN() *LAN {
@@ -126,6 +129,28 @@ func TestWasMissed(t *testing.T) {
 // func TestRemove(t *testing.T) {
 // }
 
+func TestPrune(t *testing.T) {
+	lost := 0
+	iface, _ := FindInterface("")
+	gateway, _ := FindGateway(iface)
+	exampleLAN := NewLAN(iface, gateway, func(e *Endpoint) {}, func(e *Endpoint) { lost++ })
+
+	fresh := NewEndpointNoResolve("10.0.0.1", "aa:bb:cc:dd:ee:01", "", 0)
+	stale := NewEndpointNoResolve("10.0.0.2", "aa:bb:cc:dd:ee:02", "", 0)
+	stale.LastSeen = time.Now().Add(-time.Minute)
+	exampleLAN.hosts[fresh.HwAddress] = fresh
+	exampleLAN.hosts[stale.HwAddress] = stale
+
+	pruned := exampleLAN.Prune(30 * time.Second)
+	if len(pruned) != 1 || pruned[0] != stale {
+		t.Fatalf("expected only the stale endpoint to be pruned, got %v", pruned)
+	} else if lost != 1 {
+		t.Fatalf("expected 1 lost callback, got %d", lost)
+	} else if _, found := exampleLAN.Get(fresh.HwAddress); !found {
+		t.Fatal("expected the fresh endpoint to be kept")
+	}
+}
+
 func TestHas(t *testing.T) {
 	exampleLAN := buildExampleLAN()
 	exampleEndpoint := buildExampleEndpoint()
@@ -198,3 +223,134 @@ func TestShouldIgnore(t *testing.T) {
 		t.Fatalf("expected '%v', got '%v'", exp, got)
 	}
 }
+
+func TestLANTags(t *testing.T) {
+	iface := NewEndpointNoResolve("192.168.1.2", "aa:bb:cc:dd:ee:ff", "test0", 24)
+	gateway := NewEndpointNoResolve("192.168.1.1", "00:11:22:33:44:55", "gateway", 24)
+	lan := NewLAN(iface, gateway, func(e *Endpoint) {}, func(e *Endpoint) {})
+
+	mac := "de:ad:be:ef:00:01"
+	lan.AddIfNew("192.168.1.10", mac)
+
+	if lan.Tag("de:ad:be:ef:00:02", "DC") {
+		t.Fatal("expected unknown host not to be tagged")
+	} else if !lan.Tag(mac, "DC", "dc", "target", "printer") || !lan.Untag(mac, "PRINTER") {
+		t.Fatal("expected host to be tagged")
+	} else if !lan.SetNoteFor(mac, "domain controller") {
+		t.Fatal("expected note to be set")
+	}
+
+	check := func() {
+		e, found := lan.Get(mac)
+		if !found {
+			t.Fatalf("expected %s to be found", mac)
+		} else if exp := []string{"DC", "target"}; !reflect.DeepEqual(e.Tags, exp) {
+			t.Fatalf("expected '%v', got '%v'", exp, e.Tags)
+		} else if !e.HasTag("dc") || e.HasTag("printer") {
+			t.Fatalf("unexpected tags '%v'", e.Tags)
+		} else if e.Note != "domain controller" {
+			t.Fatalf("expected 'domain controller', got '%s'", e.Note)
+		}
+	}
+
+	check()
+
+	// annotations are restored when the host is found again
+	for i := 0; i < LANDefaultttl; i++ {
+		lan.Remove("192.168.1.10", mac)
+	}
+	if _, found := lan.Get(mac); found {
+		t.Fatalf("expected %s to be removed", mac)
+	}
+	lan.AddIfNew("192.168.1.10", mac)
+	check()
+}
+
+func TestLANIPv6(t *testing.T) {
+	iface := NewEndpointNoResolve("192.168.1.2", "aa:bb:cc:dd:ee:ff", "test0", 24)
+	gateway := NewEndpointNoResolve("192.168.1.1", "00:11:22:33:44:55", "gateway", 24)
+	lan := NewLAN(iface, gateway, func(e *Endpoint) {}, func(e *Endpoint) {})
+
+	mac := "de:ad:be:ef:00:01"
+	// learned before the host is found
+	if lan.SetIPv6For(mac, "fe80::10") {
+		t.Fatal("expected unknown host not to be updated")
+	}
+	lan.AddIfNew("192.168.1.10", mac)
+
+	e, _ := lan.Get(mac)
+	if e.Ip6Address != "fe80::10" {
+		t.Fatalf("expected 'fe80::10', got '%s'", e.Ip6Address)
+	}
+
+	// global addresses replace link-local ones, not the other way around
+	if !lan.SetIPv6For(mac, "2001:db8::10") || e.Ip6Address != "2001:db8::10" {
+		t.Fatalf("expected '2001:db8::10', got '%s'", e.Ip6Address)
+	} else if lan.SetIPv6For(mac, "fe80::11") || e.Ip6Address != "2001:db8::10" {
+		t.Fatalf("expected '2001:db8::10', got '%s'", e.Ip6Address)
+	} else if found := lan.GetByIp6("2001:db8:0::10"); found != e {
+		t.Fatalf("expected '%v', got '%v'", e, found)
+	}
+
+	if !lan.SetIPv6For("00:11:22:33:44:55", "fe80::1") || gateway.Ip6Address != "fe80::1" {
+		t.Fatalf("expected 'fe80::1', got '%s'", gateway.Ip6Address)
+	} else if lan.SetIPv6For(mac, "10.0.0.1") {
+		t.Fatal("expected IPv4 address to be ignored")
+	}
+}
+
+func TestLANAddRemote(t *testing.T) {
+	found := 0
+	iface := NewEndpointNoResolve("192.168.1.2", "aa:bb:cc:dd:ee:ff", "test0", 24)
+	gateway := NewEndpointNoResolve("192.168.1.1", "00:11:22:33:44:55", "gateway", 24)
+	lan := NewLAN(iface, gateway, func(e *Endpoint) { found++ }, func(e *Endpoint) {})
+
+	remote := NewEndpointNoResolve("10.0.0.10", "de:ad:be:ef:00:01", "", 0)
+	remote.Tags = []string{"agent"}
+	hosts := []*Endpoint{
+		remote,
+		NewEndpointNoResolve("10.0.0.2", "aa:bb:cc:dd:ee:ff", "", 0),
+		NewEndpointNoResolve("10.0.0.255", BroadcastMac, "", 0),
+	}
+
+	// hosts out of our subnet are added, we and broadcast are not
+	if added := lan.AddRemote(hosts); added != 1 {
+		t.Fatalf("expected 1 host added, got %d", added)
+	} else if found != 1 {
+		t.Fatalf("expected 1 new callback, got %d", found)
+	} else if e, _ := lan.Get(remote.HwAddress); e != remote || !e.HasTag("agent") {
+		t.Fatalf("expected '%v', got '%v'", remote, e)
+	} else if added := lan.AddRemote(hosts); added != 0 || found != 1 {
+		t.Fatalf("expected no hosts added, got %d", added)
+	}
+
+	// they're not in our ARP cache, so they're not removed for that
+	for i := 0; i < LANDefaultttl; i++ {
+		lan.Remove(remote.IpAddress, remote.HwAddress)
+	}
+	if !lan.Has(remote.IpAddress) {
+		t.Fatal("expected the remote host to be kept")
+	}
+}
+
+func TestLANRestoreNullTraffic(t *testing.T) {
+	iface := NewEndpointNoResolve("192.168.1.2", "aa:bb:cc:dd:ee:ff", "test0", 24)
+	gateway := NewEndpointNoResolve("192.168.1.1", "00:11:22:33:44:55", "gateway", 24)
+	lan := NewLAN(iface, gateway, func(e *Endpoint) {}, func(e *Endpoint) {})
+
+	saved := &Endpoint{}
+	if err := json.Unmarshal([]byte(`{"ipv4":"192.168.1.10","mac":"de:ad:be:ef:00:01","traffic":null,"meta":null}`), saved); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	older := &Endpoint{IpAddress: "192.168.1.11", HwAddress: "de:ad:be:ef:00:02"}
+
+	if added := lan.Restore([]*Endpoint{saved, older}); added != 2 {
+		t.Fatalf("expected 2 hosts restored, got %d", added)
+	}
+	for _, e := range lan.List() {
+		if e.Traffic == nil || e.Meta == nil || e.Fingerprint == nil {
+			t.Fatalf("expected %s to be initialized, got '%v'", e.HwAddress, e)
+		}
+		e.Traffic.Track(10, true)
+	}
+}
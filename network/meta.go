@@ -32,6 +32,22 @@ func (m *Meta) MarshalJSON() ([]byte, error) {
 	return json.Marshal(metaJSON{Values: m.m})
 }
 
+func (m *Meta) UnmarshalJSON(raw []byte) error {
+	var doc metaJSON
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	m.m = doc.Values
+	if m.m == nil {
+		m.m = make(map[string]interface{})
+	}
+	return nil
+}
+
 func (m *Meta) Set(name string, value interface{}) {
 	m.Lock()
 	defer m.Unlock()
@@ -0,0 +1,82 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/bettercap/bettercap/core"
+)
+
+// NdpTable maps the IPv6 addresses of the IPv6 neighbor cache to their
+// hardware address.
+type NdpTable map[string]string
+
+var (
+	ndpLock  = &sync.RWMutex{}
+	ndpTable = make(NdpTable)
+)
+
+func parseNdpTable(output string, iface string) NdpTable {
+	table := make(NdpTable)
+	for _, line := range strings.Split(output, "\n") {
+		m := NdpTableParser.FindStringSubmatch(core.Trim(line))
+		if len(m) != NdpTableTokens {
+			continue
+		}
+
+		address := m[NdpTableTokenIndex[0]]
+		mac := m[NdpTableTokenIndex[1]]
+		ifname := iface
+		if ifIndex := NdpTableTokenIndex[2]; ifIndex != -1 {
+			ifname = m[ifIndex]
+		}
+
+		// link-local addresses can be scoped with the interface
+		address = strings.SplitN(address, "%", 2)[0]
+		mac = NormalizeMac(mac)
+		if ip := net.ParseIP(address); ip == nil || ip.To4() != nil || ip.IsMulticast() {
+			continue
+		} else if mac == "00:00:00:00:00:00" {
+			// unreachable neighbors
+			continue
+		} else if ifname == iface {
+			table[ip.String()] = mac
+		}
+	}
+	return table
+}
+
+// NdpUpdate reads the IPv6 neighbor cache of the interface.
+func NdpUpdate(iface string) (NdpTable, error) {
+	ndpLock.Lock()
+	defer ndpLock.Unlock()
+
+	output, err := core.ExecSilent(NdpCmd, NdpCmdOpts)
+	if err != nil {
+		return ndpTable, err
+	}
+
+	ndpTable = parseNdpTable(output, iface)
+	return ndpTable, nil
+}
+
+func NdpLookup(iface string, address string, refresh bool) (string, error) {
+	if refresh {
+		if _, err := NdpUpdate(iface); err != nil {
+			return "", err
+		}
+	}
+
+	ndpLock.RLock()
+	defer ndpLock.RUnlock()
+
+	if ip := net.ParseIP(address); ip != nil {
+		if mac, found := ndpTable[ip.String()]; found {
+			return mac, nil
+		}
+	}
+
+	return "", fmt.Errorf("Could not find mac for %s", address)
+}
@@ -0,0 +1,9 @@
+package network
+
+import "regexp"
+
+var NdpTableParser = regexp.MustCompile(`^([a-f0-9:]+(?:%[^\s]+)?)\s+([a-f0-9:]{11,17})\s+([^\s]+)\s+.+$`)
+var NdpTableTokens = 4
+var NdpTableTokenIndex = []int{1, 2, 3}
+var NdpCmd = "ndp"
+var NdpCmdOpts = []string{"-a", "-n"}
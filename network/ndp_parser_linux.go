@@ -0,0 +1,9 @@
+package network
+
+import "regexp"
+
+var NdpTableParser = regexp.MustCompile(`^([a-f0-9:]+)\s+dev\s+([^\s]+)\s+lladdr\s+([a-f0-9:]{17})\s+.+$`)
+var NdpTableTokens = 4
+var NdpTableTokenIndex = []int{1, 3, 2}
+var NdpCmd = "ip"
+var NdpCmdOpts = []string{"-6", "neigh"}
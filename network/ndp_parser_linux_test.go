@@ -0,0 +1,23 @@
+package network
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseNdpTable(t *testing.T) {
+	output := `fe80::1 dev eth0 lladdr 00:11:22:33:44:55 router REACHABLE
+2001:db8::10 dev eth0 lladdr 66:77:88:99:aa:bb STALE
+fe80::20 dev eth0 FAILED
+fe80::30 dev wlan0 lladdr 00:00:00:00:00:01 DELAY
+ff02::fb dev eth0 lladdr 33:33:00:00:00:fb NOARP`
+
+	exp := NdpTable{
+		"fe80::1":      "00:11:22:33:44:55",
+		"2001:db8::10": "66:77:88:99:aa:bb",
+	}
+
+	if table := parseNdpTable(output, "eth0"); !reflect.DeepEqual(table, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, table)
+	}
+}
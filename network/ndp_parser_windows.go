@@ -0,0 +1,9 @@
+package network
+
+import "regexp"
+
+var NdpTableParser = regexp.MustCompile(`^([a-f0-9:]+(?:%\d+)?)\s+([a-f0-9\-]{17})\s+.+$`)
+var NdpTableTokens = 3
+var NdpTableTokenIndex = []int{1, 2, -1}
+var NdpCmd = "netsh"
+var NdpCmdOpts = []string{"interface", "ipv6", "show", "neighbors"}
@@ -1,6 +1,9 @@
 package network
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"net"
@@ -15,6 +18,17 @@ import (
 var ErrNoIfaces = errors.New("No active interfaces found.")
 var ErrNoGateway = errors.New("Could not detect gateway.")
 
+// NativeMACError is returned by SetInterfaceMAC and SetInterfaceLink when
+// the syscalls they use are not available, as opposed to the kernel
+// refusing the change, so that the caller can fall back to the binaries.
+type NativeMACError struct {
+	Err error
+}
+
+func (e NativeMACError) Error() string {
+	return fmt.Sprintf("Native interface configuration is not available: %s", e.Err)
+}
+
 const (
 	MonitorModeAddress = "0.0.0.0"
 	BroadcastSuffix    = ".255"
@@ -51,6 +65,39 @@ func IsBroadcastMac(mac net.HardwareAddr) bool {
 	return true
 }
 
+// SeededMac derives a locally administered unicast hardware
+// address from the SHA-256 digest of the given seed.
+func SeededMac(seed string) net.HardwareAddr {
+	digest := sha256.Sum256([]byte(seed))
+	hw := make(net.HardwareAddr, 6)
+	copy(hw, digest[:6])
+	// set the locally administered bit and clear the multicast one
+	hw[0] = (hw[0] | 0x02) & 0xfe
+	return hw
+}
+
+// VendorMac returns a random address starting with the given OUI, as six
+// hexadecimal digits like the ones returned by OuiVendorLookup.
+func VendorMac(prefix string) (net.HardwareAddr, error) {
+	raw, err := hex.DecodeString(prefix)
+	if err != nil || len(raw) != 3 {
+		return nil, fmt.Errorf("'%s' is not a valid OUI.", prefix)
+	}
+
+	hw := make(net.HardwareAddr, 6)
+	copy(hw, raw)
+	if _, err := rand.Read(hw[3:]); err != nil {
+		return nil, err
+	}
+	return hw, nil
+}
+
+// IsLocallyAdministered returns true if the address has the locally
+// administered bit set, as it happens with randomized addresses.
+func IsLocallyAdministered(mac net.HardwareAddr) bool {
+	return len(mac) > 0 && mac[0]&0x02 != 0
+}
+
 func NormalizeMac(mac string) string {
 	var parts []string
 	if strings.ContainsRune(mac, '-') {
@@ -67,6 +114,12 @@ func NormalizeMac(mac string) string {
 	return strings.ToLower(strings.Join(parts, ":"))
 }
 
+// ParseMac parses a hardware address with either ':' or '-' separators,
+// tolerating octets without the leading zero.
+func ParseMac(address string) (net.HardwareAddr, error) {
+	return net.ParseMAC(NormalizeMac(address))
+}
+
 func ParseTargets(targets string, aliasMap *Aliases) (ips []net.IP, macs []net.HardwareAddr, err error) {
 	ips = make([]net.IP, 0)
 	macs = make([]net.HardwareAddr, 0)
@@ -129,24 +182,59 @@ func buildEndpointFromInterface(iface net.Interface) (*Endpoint, error) {
 	e := NewEndpointNoResolve(MonitorModeAddress, iface.HardwareAddr.String(), ifName, 0)
 
 	e.Index = iface.Index
+	e.Flags = iface.Flags
+	e.setAddresses(addrs)
 
+	return e, nil
+}
+
+func (t *Endpoint) setAddresses(addrs []net.Addr) {
 	for _, a := range addrs {
 		address := a.String()
 		if IPv4Validator.MatchString(address) {
 			if !strings.ContainsRune(address, '/') {
 				// plain ip
-				e.SetIP(address)
+				t.SetIP(address)
 			} else {
 				// ip/bits
-				e.SetNetwork(address)
+				t.SetNetwork(address)
 			}
 		} else {
 			// ipv6/xxx
-			e.SetIPv6(address)
+			t.SetIPv6(address)
 		}
 	}
+}
 
-	return e, nil
+// Refresh re-reads the hardware address, flags and addresses of the
+// interface, the IPv6 ones usually change together with the MAC. The
+// IPv4 address is kept if the interface has none at the moment, as
+// it happens while it's brought down and up.
+func (t *Endpoint) Refresh() error {
+	iface, err := net.InterfaceByName(t.Name())
+	if err != nil && t.Index > 0 {
+		// on windows the endpoint is named after the pcap device
+		iface, err = net.InterfaceByIndex(t.Index)
+	}
+	if err != nil {
+		return err
+	}
+
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return err
+	}
+
+	t.Index = iface.Index
+	t.Flags = iface.Flags
+	t.HW = iface.HardwareAddr
+	t.HwAddress = NormalizeMac(iface.HardwareAddr.String())
+	t.Vendor = OuiLookup(t.HwAddress)
+	t.IPv6 = nil
+	t.Ip6Address = ""
+	t.setAddresses(addrs)
+
+	return nil
 }
 
 func matchByAddress(iface net.Interface, name string) bool {
@@ -209,3 +297,37 @@ func FindInterface(name string) (*Endpoint, error) {
 
 	return nil, ErrNoIfaces
 }
+
+// FindInterfaceForAddress returns the interface the system would use
+// to route packets to the given address.
+func FindInterfaceForAddress(ip net.IP) (*net.Interface, error) {
+	// no packets are sent, this only asks the kernel for a route
+	conn, err := net.Dial("udp", net.JoinHostPort(ip.String(), "9"))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	local := conn.LocalAddr().(*net.UDPAddr).IP
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.Equal(local) {
+				found := iface
+				return &found, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("No interface found routing to %s.", ip)
+}
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"net"
 	"regexp"
+	"strings"
+	"syscall"
+	"unsafe"
 
 	"github.com/bettercap/bettercap/core"
 )
@@ -42,3 +45,163 @@ func GetSupportedFrequencies(iface string) ([]int, error) {
 	freqs := []int{2412, 2417, 2422, 2427, 2432, 2437, 2442, 2447, 2452, 2457, 2462, 2467, 2472, 2484}
 	return freqs, nil
 }
+
+func GetSupportedChannels(iface string) ([]WiFiChannel, error) {
+	freqs, err := GetSupportedFrequencies(iface)
+	if err != nil {
+		return nil, err
+	}
+	return WiFiChannelsOf(freqs), nil
+}
+
+func GetInterfacePromisc(iface string) (bool, error) {
+	out, err := core.ExecSilent("ifconfig", []string{iface})
+	if err != nil {
+		return false, err
+	}
+	return strings.Contains(out, "PROMISC"), nil
+}
+
+func SetInterfacePromisc(iface string, enabled bool) error {
+	mode := "-promisc"
+	if enabled {
+		mode = "promisc"
+	}
+
+	_, err := core.Exec("ifconfig", []string{iface, mode})
+	return err
+}
+
+func PermanentMAC(iface string) (net.HardwareAddr, error) {
+	return nil, fmt.Errorf("macOS does not support reading the permanent hardware address.")
+}
+
+// struct ifreq with the ifr_addr member of the union
+type ifreqAddr struct {
+	name [syscall.IFNAMSIZ]byte
+	addr syscall.RawSockaddr
+}
+
+// SetInterfaceMAC changes the hardware address of the interface with the
+// SIOCSIFLLADDR ioctl, as ifconfig ether does.
+func SetInterfaceMAC(iface string, mac net.HardwareAddr) error {
+	if len(iface) >= syscall.IFNAMSIZ {
+		return fmt.Errorf("Interface name %s is too long.", iface)
+	} else if len(mac) > len(syscall.RawSockaddr{}.Data) {
+		return fmt.Errorf("Unexpected address size %d for %s.", len(mac), iface)
+	}
+
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM, 0)
+	if err != nil {
+		return NativeMACError{err}
+	}
+	defer syscall.Close(fd)
+
+	req := ifreqAddr{}
+	copy(req.name[:], iface)
+	req.addr.Len = uint8(len(mac))
+	req.addr.Family = syscall.AF_LINK
+	for i, b := range mac {
+		req.addr.Data[i] = int8(b)
+	}
+
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.SIOCSIFLLADDR, uintptr(unsafe.Pointer(&req))); errno != 0 {
+		return fmt.Errorf("Could not set the address of %s: %s", iface, errno)
+	}
+	return nil
+}
+
+func SetInterfaceLink(iface string, up bool) error {
+	return NativeMACError{fmt.Errorf("macOS interfaces are brought up and down with ifconfig.")}
+}
+
+func GetInterfaceSSID(iface string) (string, error) {
+	out, err := core.ExecSilent(airPortPath, []string{"-I"})
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		line = core.Trim(line)
+		if strings.HasPrefix(line, "SSID: ") {
+			return strings.TrimPrefix(line, "SSID: "), nil
+		}
+	}
+	return "", fmt.Errorf("Interface %s is not associated to any network.", iface)
+}
+
+func SetInterfaceUp(iface string, up bool) error {
+	state := "down"
+	if up {
+		state = "up"
+	}
+
+	_, err := core.Exec("ifconfig", []string{iface, state})
+	return err
+}
+
+func GetInterfaceTxPower(iface string) (float64, error) {
+	return 0, fmt.Errorf("macOS does not support reading the WiFi transmit power.")
+}
+
+func GetInterfaceTxPowerRange(iface string) (float64, float64, error) {
+	return 0, 0, fmt.Errorf("macOS does not support reading the WiFi transmit power limits.")
+}
+
+func SetInterfaceTxPower(iface string, dbm float64) error {
+	return fmt.Errorf("macOS does not support setting the WiFi transmit power.")
+}
+
+func ReassociateInterface(iface string, ssid string) error {
+	_, err := core.Exec("networksetup", []string{"-setairportnetwork", iface, ssid})
+	return err
+}
+
+func ConnectInterface(iface string, ssid string) error {
+	return ReassociateInterface(iface, ssid)
+}
+
+// Wi-Fi devices are listed by networksetup as "Hardware Port: Wi-Fi"
+// followed by their "Device: enX" line.
+func isAirPortDevice(iface string) bool {
+	out, err := core.ExecSilent("networksetup", []string{"-listallhardwareports"})
+	if err != nil {
+		return false
+	}
+
+	port := ""
+	for _, line := range strings.Split(out, "\n") {
+		line = core.Trim(line)
+		if strings.HasPrefix(line, "Hardware Port: ") {
+			port = strings.TrimPrefix(line, "Hardware Port: ")
+		} else if line == "Device: "+iface {
+			return port == "Wi-Fi" || port == "AirPort"
+		}
+	}
+	return false
+}
+
+// GetInterfaceCaps returns the capabilities of the interface, the
+// AirPort driver can sniff in monitor mode but not inject.
+func GetInterfaceCaps(iface string) (*InterfaceCaps, error) {
+	if _, err := net.InterfaceByName(iface); err != nil {
+		return nil, err
+	}
+
+	caps := NewInterfaceCaps(iface)
+	caps.Promisc = CapabilitySupported
+	caps.Monitor = CapabilityMissing
+	caps.Injection = CapabilityMissing
+
+	if caps.Wireless = isAirPortDevice(iface); caps.Wireless {
+		caps.Driver = "AirPort"
+		caps.Monitor = CapabilitySupported
+		if freqs, err := GetSupportedFrequencies(iface); err == nil {
+			for _, freq := range freqs {
+				caps.Channels = append(caps.Channels, Dot11Freq2Chan(freq))
+			}
+		}
+	}
+
+	return caps, nil
+}
@@ -3,11 +3,17 @@ package network
 import (
 	"bufio"
 	"fmt"
+	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
 	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
+	"unsafe"
 
 	"github.com/bettercap/bettercap/core"
 )
@@ -78,3 +84,281 @@ func GetSupportedFrequencies(iface string) ([]int, error) {
 	}
 	return freqs, nil
 }
+
+// GetSupportedChannels returns the enabled channels of the adapter as
+// reported by its phy, or the frequencies listed by iwlist otherwise.
+func GetSupportedChannels(iface string) ([]WiFiChannel, error) {
+	if raw, err := ioutil.ReadFile(fmt.Sprintf("/sys/class/net/%s/phy80211/name", iface)); err == nil {
+		if out, err := core.ExecSilent("iw", []string{"phy", core.Trim(string(raw)), "info"}); err == nil {
+			if info := parseIwPhyInfo(out); len(info.WiFiChannels) > 0 {
+				return info.WiFiChannels, nil
+			}
+		}
+	}
+
+	freqs, err := GetSupportedFrequencies(iface)
+	if err != nil {
+		return nil, err
+	}
+	return WiFiChannelsOf(freqs), nil
+}
+
+// IFF_PROMISC as defined in linux/if.h
+const iffPromisc = 0x100
+
+func GetInterfacePromisc(iface string) (bool, error) {
+	raw, err := ioutil.ReadFile(fmt.Sprintf("/sys/class/net/%s/flags", iface))
+	if err != nil {
+		return false, err
+	}
+
+	flags, err := strconv.ParseUint(strings.TrimPrefix(core.Trim(string(raw)), "0x"), 16, 32)
+	if err != nil {
+		return false, fmt.Errorf("Could not parse flags of interface %s: %s", iface, err)
+	}
+
+	return flags&iffPromisc != 0, nil
+}
+
+func SetInterfacePromisc(iface string, enabled bool) error {
+	mode := "off"
+	if enabled {
+		mode = "on"
+	}
+
+	_, err := core.Exec("ip", []string{"link", "set", "dev", iface, "promisc", mode})
+	return err
+}
+
+const (
+	siocEthtool      = 0x8946
+	ethtoolGPermAddr = 0x00000020
+	// MAX_ADDR_LEN as defined in linux/netdevice.h
+	maxAddrLen = 32
+)
+
+// struct ethtool_perm_addr
+type ethtoolPermAddr struct {
+	cmd  uint32
+	size uint32
+	data [maxAddrLen]byte
+}
+
+// struct ifreq with the ifr_data member of the union
+type ifreqData struct {
+	name [syscall.IFNAMSIZ]byte
+	data uintptr
+	_    [24 - unsafe.Sizeof(uintptr(0))]byte
+}
+
+// PermanentMAC returns the burned-in hardware address of the
+// interface using the ETHTOOL_GPERMADDR ioctl.
+func PermanentMAC(iface string) (net.HardwareAddr, error) {
+	if len(iface) >= syscall.IFNAMSIZ {
+		return nil, fmt.Errorf("Interface name %s is too long.", iface)
+	}
+
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.Close(fd)
+
+	perm := ethtoolPermAddr{cmd: ethtoolGPermAddr, size: maxAddrLen}
+	req := ifreqData{data: uintptr(unsafe.Pointer(&perm))}
+	copy(req.name[:], iface)
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), siocEthtool, uintptr(unsafe.Pointer(&req)))
+	runtime.KeepAlive(&perm)
+	if errno != 0 {
+		return nil, fmt.Errorf("Could not read the permanent address of %s: %s", iface, errno)
+	}
+
+	if perm.size != 6 {
+		return nil, fmt.Errorf("Unexpected permanent address size %d for %s.", perm.size, iface)
+	}
+
+	hw := make(net.HardwareAddr, 6)
+	copy(hw, perm.data[:6])
+	if IsZeroMac(hw) {
+		return nil, fmt.Errorf("Interface %s has no permanent hardware address.", iface)
+	}
+	return hw, nil
+}
+
+var wifiInfoParser = regexp.MustCompile(`^\s+(ssid|type)\s+(.+)$`)
+
+// GetInterfaceSSID returns the SSID the interface is associated to, the
+// interface must be a WiFi one in managed mode.
+func GetInterfaceSSID(iface string) (string, error) {
+	out, err := core.ExecSilent("iw", []string{"dev", iface, "info"})
+	if err != nil {
+		return "", err
+	}
+
+	ssid, mode := "", ""
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		if m := wifiInfoParser.FindStringSubmatch(scanner.Text()); len(m) == 3 {
+			if m[1] == "ssid" {
+				ssid = m[2]
+			} else {
+				mode = core.Trim(m[2])
+			}
+		}
+	}
+
+	if mode != "managed" {
+		return "", fmt.Errorf("Interface %s is not a WiFi interface in managed mode.", iface)
+	} else if ssid == "" {
+		return "", fmt.Errorf("Interface %s is not associated to any network.", iface)
+	}
+	return ssid, nil
+}
+
+func SetInterfaceUp(iface string, up bool) error {
+	state := "down"
+	if up {
+		state = "up"
+	}
+
+	_, err := core.Exec("ip", []string{"link", "set", "dev", iface, state})
+	return err
+}
+
+// ReassociateInterface makes the interface associate again to the SSID,
+// through wpa_supplicant if it's managing the interface or iw otherwise
+// (which only works for open networks).
+func ReassociateInterface(iface string, ssid string) error {
+	if out, err := core.ExecSilent("wpa_cli", []string{"-i", iface, "reassociate"}); err == nil && core.Trim(out) == "OK" {
+		return nil
+	}
+
+	_, err := core.Exec("iw", []string{"dev", iface, "connect", ssid})
+	return err
+}
+
+// ConnectInterface associates the interface to the SSID, through
+// NetworkManager if it's running, which knows the saved passwords, or
+// iw otherwise (which only works for open networks).
+func ConnectInterface(iface string, ssid string) error {
+	if _, err := core.ExecSilent("nmcli", []string{"device", "wifi", "connect", ssid, "ifname", iface}); err == nil {
+		return nil
+	}
+
+	_, err := core.Exec("iw", []string{"dev", iface, "connect", ssid})
+	return err
+}
+
+func getIwDevInfo(iface string) (iwDevInfo, error) {
+	out, err := core.ExecSilent("iw", []string{"dev", iface, "info"})
+	if err != nil {
+		return iwDevInfo{Wiphy: -1}, err
+	}
+	return parseIwDevInfo(out), nil
+}
+
+// GetInterfaceTxPower returns the transmit power of the WiFi interface in dBm.
+func GetInterfaceTxPower(iface string) (float64, error) {
+	info, err := getIwDevInfo(iface)
+	if err != nil {
+		return 0, err
+	} else if !info.HasTxPower {
+		return 0, fmt.Errorf("Could not read the transmit power of %s.", iface)
+	}
+	return info.TxPower, nil
+}
+
+// GetInterfaceTxPowerRange returns the transmit power range in dBm the
+// adapter reports for its enabled channels.
+func GetInterfaceTxPowerRange(iface string) (float64, float64, error) {
+	dev, err := getIwDevInfo(iface)
+	if err != nil {
+		return 0, 0, err
+	} else if dev.Wiphy < 0 {
+		return 0, 0, fmt.Errorf("Interface %s is not a WiFi interface.", iface)
+	}
+
+	out, err := core.ExecSilent("iw", []string{fmt.Sprintf("phy#%d", dev.Wiphy), "info"})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	info := parseIwPhyInfo(out)
+	if info.MaxTxPower <= 0 {
+		return 0, 0, fmt.Errorf("Could not read the transmit power limits of %s.", iface)
+	}
+	return 0, info.MaxTxPower, nil
+}
+
+// dbmToMbm rounds the power to the closest mBm, math.Round is not
+// available on Go 1.9.
+func dbmToMbm(dbm float64) int {
+	if dbm < 0 {
+		return -int(-dbm*100 + 0.5)
+	}
+	return int(dbm*100 + 0.5)
+}
+
+// SetInterfaceTxPower sets a fixed transmit power, iw takes it in mBm.
+func SetInterfaceTxPower(iface string, dbm float64) error {
+	mbm := dbmToMbm(dbm)
+	_, err := core.Exec("iw", []string{"dev", iface, "set", "txpower", "fixed", strconv.Itoa(mbm)})
+	return err
+}
+
+// interfaceDriver returns the name of the kernel module driving the
+// interface, from sysfs or ethtool.
+func interfaceDriver(iface string) string {
+	if link, err := os.Readlink(fmt.Sprintf("/sys/class/net/%s/device/driver", iface)); err == nil {
+		return filepath.Base(link)
+	} else if out, err := core.ExecSilent("ethtool", []string{"-i", iface}); err == nil {
+		return parseEthtoolDriver(out)
+	}
+	return ""
+}
+
+// GetInterfaceCaps returns the capabilities of the interface, WiFi ones
+// are read from its phy with iw.
+func GetInterfaceCaps(iface string) (*InterfaceCaps, error) {
+	if _, err := net.InterfaceByName(iface); err != nil {
+		return nil, err
+	}
+
+	caps := NewInterfaceCaps(iface)
+	caps.Driver = interfaceDriver(iface)
+	if _, err := GetInterfacePromisc(iface); err == nil {
+		caps.Promisc = CapabilitySupported
+	}
+
+	raw, err := ioutil.ReadFile(fmt.Sprintf("/sys/class/net/%s/phy80211/name", iface))
+	if err != nil {
+		// no mac80211 phy, it could still be an old wireless extensions driver
+		caps.Wireless = core.Exists(fmt.Sprintf("/sys/class/net/%s/wireless", iface))
+		if !caps.Wireless {
+			caps.Monitor = CapabilityMissing
+			caps.Injection = CapabilityMissing
+		}
+		return caps, nil
+	}
+
+	caps.Wireless = true
+	out, err := core.ExecSilent("iw", []string{"phy", core.Trim(string(raw)), "info"})
+	if err != nil {
+		return caps, nil
+	}
+
+	info := parseIwPhyInfo(out)
+	caps.Channels = info.Channels
+	if !info.HasMode("monitor") {
+		caps.Monitor = CapabilityMissing
+		caps.Injection = CapabilityMissing
+	} else {
+		caps.Monitor = CapabilitySupported
+		if info.CanTransmitFrom("monitor") {
+			caps.Injection = CapabilitySupported
+		}
+	}
+
+	return caps, nil
+}
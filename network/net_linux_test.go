@@ -0,0 +1,26 @@
+package network
+
+import (
+	"testing"
+)
+
+func TestDbmToMbm(t *testing.T) {
+	cases := []struct {
+		dbm    float64
+		expect int
+	}{
+		{20, 2000},
+		{17.5, 1750},
+		{0.004, 0},
+		{0.005, 1},
+		{12.345, 1235},
+		{-3.5, -350},
+		{-0.006, -1},
+	}
+
+	for _, c := range cases {
+		if got := dbmToMbm(c.dbm); got != c.expect {
+			t.Fatalf("expected %d mBm for %v dBm, got %d", c.expect, c.dbm, got)
+		}
+	}
+}
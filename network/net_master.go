@@ -0,0 +1,65 @@
+package network
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SysClassNet is where Linux exposes the network interfaces.
+const SysClassNet = "/sys/class/net"
+
+const (
+	MasterBond   = "bond"
+	MasterBridge = "bridge"
+)
+
+// LinkMaster is the bond, bridge or other master an interface is
+// enslaved to, Kind is empty for the ones which are neither.
+type LinkMaster struct {
+	Name string
+	Kind string
+}
+
+func (m LinkMaster) String() string {
+	if m.Kind == "" {
+		return fmt.Sprintf("master %s", m.Name)
+	}
+	return fmt.Sprintf("%s %s", m.Kind, m.Name)
+}
+
+func sysfsExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// InterfaceMaster reads the master of iface from the sysfs root, usually
+// SysClassNet, it returns nil if the interface is not enslaved.
+func InterfaceMaster(root, iface string) (*LinkMaster, error) {
+	link, err := os.Readlink(filepath.Join(root, iface, "master"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	master := &LinkMaster{Name: filepath.Base(link)}
+	if sysfsExists(filepath.Join(root, master.Name, "bonding")) || sysfsExists(filepath.Join(root, iface, "bonding_slave")) {
+		master.Kind = MasterBond
+	} else if sysfsExists(filepath.Join(root, master.Name, "bridge")) || sysfsExists(filepath.Join(root, iface, "brport")) {
+		master.Kind = MasterBridge
+	}
+	return master, nil
+}
+
+// InterfaceAddress reads the hardware address of iface from the sysfs root.
+func InterfaceAddress(root, iface string) (net.HardwareAddr, error) {
+	raw, err := ioutil.ReadFile(filepath.Join(root, iface, "address"))
+	if err != nil {
+		return nil, err
+	}
+	return net.ParseMAC(strings.TrimSpace(string(raw)))
+}
@@ -0,0 +1,87 @@
+package network
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeSysfs builds a /sys/class/net like tree with eth0 enslaved to bond0,
+// eth1 to br0 as the bridge port, eth2 to team0 and eth3 standalone.
+func fakeSysfs(t *testing.T) string {
+	root, err := ioutil.TempDir("", "sysfs")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	dirs := []string{
+		"eth0", "eth1/brport", "eth2", "eth3",
+		"bond0/bonding", "br0/bridge", "team0",
+	}
+	for _, dir := range dirs {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	links := map[string]string{
+		"eth0": "../../../devices/virtual/net/bond0",
+		"eth1": "../../../devices/virtual/net/br0",
+		"eth2": "../team0",
+	}
+	for iface, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, iface, "master")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(root, "bond0", "address"), []byte("02:00:00:00:00:01\n"), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return root
+}
+
+func TestInterfaceMaster(t *testing.T) {
+	root := fakeSysfs(t)
+	defer os.RemoveAll(root)
+
+	var units = []struct {
+		iface  string
+		master string
+	}{
+		{"eth0", "bond bond0"},
+		{"eth1", "bridge br0"},
+		{"eth2", "master team0"},
+		{"eth3", ""},
+		{"eth4", ""},
+	}
+
+	for _, u := range units {
+		master, err := InterfaceMaster(root, u.iface)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		got := ""
+		if master != nil {
+			got = master.String()
+		}
+		if got != u.master {
+			t.Fatalf("expected '%s', got '%s'", u.master, got)
+		}
+	}
+}
+
+func TestInterfaceAddress(t *testing.T) {
+	root := fakeSysfs(t)
+	defer os.RemoveAll(root)
+
+	if hw, err := InterfaceAddress(root, "bond0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if exp := "02:00:00:00:00:01"; hw.String() != exp {
+		t.Fatalf("expected '%s', got '%s'", exp, hw)
+	} else if _, err := InterfaceAddress(root, "eth3"); err == nil {
+		t.Fatal("expected error for an interface with no address")
+	}
+}
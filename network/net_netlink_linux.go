@@ -0,0 +1,119 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func rtaAlign(n int) int {
+	return (n + syscall.RTA_ALIGNTO - 1) &^ (syscall.RTA_ALIGNTO - 1)
+}
+
+// newSetLinkRequest builds a RTM_SETLINK message changing the flags in
+// change to flags and, if not nil, the hardware address of the link.
+func newSetLinkRequest(seq uint32, index int, flags, change uint32, mac net.HardwareAddr) []byte {
+	size := syscall.SizeofNlMsghdr + syscall.SizeofIfInfomsg
+	if mac != nil {
+		size += rtaAlign(syscall.SizeofRtAttr + len(mac))
+	}
+
+	b := make([]byte, size)
+	*(*syscall.NlMsghdr)(unsafe.Pointer(&b[0])) = syscall.NlMsghdr{
+		Len:   uint32(size),
+		Type:  syscall.RTM_SETLINK,
+		Flags: syscall.NLM_F_REQUEST | syscall.NLM_F_ACK,
+		Seq:   seq,
+	}
+	*(*syscall.IfInfomsg)(unsafe.Pointer(&b[syscall.SizeofNlMsghdr])) = syscall.IfInfomsg{
+		Family: syscall.AF_UNSPEC,
+		Index:  int32(index),
+		Flags:  flags,
+		Change: change,
+	}
+
+	if mac != nil {
+		off := syscall.SizeofNlMsghdr + syscall.SizeofIfInfomsg
+		*(*syscall.RtAttr)(unsafe.Pointer(&b[off])) = syscall.RtAttr{
+			Len:  uint16(syscall.SizeofRtAttr + len(mac)),
+			Type: syscall.IFLA_ADDRESS,
+		}
+		copy(b[off+syscall.SizeofRtAttr:], mac)
+	}
+	return b
+}
+
+// parseNetlinkAck returns the error of the acknowledgement of seq, done
+// is false if the messages don't include it.
+func parseNetlinkAck(b []byte, seq uint32) (done bool, err error) {
+	msgs, err := syscall.ParseNetlinkMessage(b)
+	if err != nil {
+		return true, err
+	}
+
+	for _, m := range msgs {
+		if m.Header.Seq != seq || m.Header.Type != syscall.NLMSG_ERROR {
+			continue
+		} else if len(m.Data) < 4 {
+			return true, fmt.Errorf("Short netlink acknowledgement.")
+		} else if errno := *(*int32)(unsafe.Pointer(&m.Data[0])); errno != 0 {
+			return true, syscall.Errno(-errno)
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// setLink sends a RTM_SETLINK request for the interface and waits for
+// the kernel to acknowledge it, action describes it for the errors.
+func setLink(iface string, action string, flags, change uint32, mac net.HardwareAddr) error {
+	link, err := net.InterfaceByName(iface)
+	if err != nil {
+		return fmt.Errorf("Could not %s: %s", action, err)
+	}
+
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_ROUTE)
+	if err != nil {
+		return NativeMACError{os.NewSyscallError("socket", err)}
+	}
+	defer syscall.Close(fd)
+
+	kernel := &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return NativeMACError{os.NewSyscallError("bind", err)}
+	}
+
+	seq := uint32(os.Getpid())
+	if err := syscall.Sendto(fd, newSetLinkRequest(seq, link.Index, flags, change, mac), 0, kernel); err != nil {
+		return NativeMACError{os.NewSyscallError("sendto", err)}
+	}
+
+	buf := make([]byte, os.Getpagesize())
+	for {
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return fmt.Errorf("Could not %s: %s", action, os.NewSyscallError("recvfrom", err))
+		} else if done, err := parseNetlinkAck(buf[:n], seq); !done {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("Could not %s: %s", action, err)
+		}
+		return nil
+	}
+}
+
+// SetInterfaceMAC changes the hardware address of the interface through
+// netlink, some drivers only accept it with the link down.
+func SetInterfaceMAC(iface string, mac net.HardwareAddr) error {
+	return setLink(iface, fmt.Sprintf("set the address of %s", iface), 0, 0, mac)
+}
+
+// SetInterfaceLink brings the interface up or down through netlink.
+func SetInterfaceLink(iface string, up bool) error {
+	if up {
+		return setLink(iface, fmt.Sprintf("bring %s up", iface), syscall.IFF_UP, syscall.IFF_UP, nil)
+	}
+	return setLink(iface, fmt.Sprintf("bring %s down", iface), 0, syscall.IFF_UP, nil)
+}
@@ -0,0 +1,67 @@
+package network
+
+import (
+	"net"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+func TestNewSetLinkRequest(t *testing.T) {
+	mac, _ := net.ParseMAC("26:19:a5:88:a7:a3")
+	b := newSetLinkRequest(42, 3, 0, 0, mac)
+
+	msgs, err := syscall.ParseNetlinkMessage(b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(msgs) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(msgs))
+	} else if m := msgs[0]; m.Header.Type != syscall.RTM_SETLINK || m.Header.Seq != 42 {
+		t.Fatalf("expected RTM_SETLINK 42, got %d %d", m.Header.Type, m.Header.Seq)
+	}
+
+	info := (*syscall.IfInfomsg)(unsafe.Pointer(&msgs[0].Data[0]))
+	if info.Index != 3 {
+		t.Fatalf("expected index 3, got %d", info.Index)
+	}
+
+	// only the messages sent by the kernel are parsed
+	m := msgs[0]
+	m.Header.Type = syscall.RTM_NEWLINK
+	attrs, err := syscall.ParseNetlinkRouteAttr(&m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(attrs) != 1 || attrs[0].Attr.Type != syscall.IFLA_ADDRESS {
+		t.Fatalf("expected IFLA_ADDRESS, got '%v'", attrs)
+	} else if got := net.HardwareAddr(attrs[0].Value).String(); got != mac.String() {
+		t.Fatalf("expected '%s', got '%s'", mac, got)
+	}
+
+	if attrs, _ := syscall.ParseNetlinkRouteAttr(&syscall.NetlinkMessage{
+		Header: syscall.NlMsghdr{Type: syscall.RTM_NEWLINK},
+		Data:   newSetLinkRequest(1, 3, syscall.IFF_UP, syscall.IFF_UP, nil)[syscall.SizeofNlMsghdr:],
+	}); len(attrs) != 0 {
+		t.Fatalf("expected no attributes, got '%v'", attrs)
+	}
+}
+
+func netlinkAck(seq uint32, errno int32) []byte {
+	b := make([]byte, syscall.SizeofNlMsghdr+4+syscall.SizeofNlMsghdr)
+	*(*syscall.NlMsghdr)(unsafe.Pointer(&b[0])) = syscall.NlMsghdr{
+		Len:  uint32(len(b)),
+		Type: syscall.NLMSG_ERROR,
+		Seq:  seq,
+	}
+	*(*int32)(unsafe.Pointer(&b[syscall.SizeofNlMsghdr])) = errno
+	return b
+}
+
+func TestParseNetlinkAck(t *testing.T) {
+	if done, err := parseNetlinkAck(netlinkAck(1, 0), 1); !done || err != nil {
+		t.Fatalf("expected an acknowledgement, got %v '%v'", done, err)
+	} else if done, err := parseNetlinkAck(netlinkAck(1, -int32(syscall.EBUSY)), 1); !done || err != syscall.EBUSY {
+		t.Fatalf("expected '%v', got %v '%v'", syscall.EBUSY, done, err)
+	} else if done, _ := parseNetlinkAck(netlinkAck(2, 0), 1); done {
+		t.Fatal("expected the acknowledgement of another request to be skipped")
+	}
+}
@@ -2,6 +2,7 @@ package network
 
 import (
 	"net"
+	"strings"
 	"testing"
 )
 
@@ -33,7 +34,44 @@ func TestNormalizeMac(t *testing.T) {
 	}
 }
 
+func TestSeededMac(t *testing.T) {
+	a := SeededMac("lab-run-1")
+	if exp := "26:19:a5:88:a7:a3"; a.String() != exp {
+		t.Fatalf("expected '%s', got '%s'", exp, a)
+	} else if b := SeededMac("lab-run-1"); a.String() != b.String() {
+		t.Fatalf("expected '%s', got '%s'", a, b)
+	} else if c := SeededMac("lab-run-2"); a.String() == c.String() {
+		t.Fatalf("expected different addresses for different seeds, got '%s'", c)
+	}
+
+	for _, seed := range []string{"", "a", "lab-run-1", "lab-run-2", "bettercap"} {
+		hw := SeededMac(seed)
+		if hw[0]&0x02 == 0 {
+			t.Fatalf("expected locally administered address for '%s', got '%s'", seed, hw)
+		} else if hw[0]&0x01 != 0 {
+			t.Fatalf("expected unicast address for '%s', got '%s'", seed, hw)
+		}
+	}
+}
+
 // TODO: refactor to parse targets with an actual alias map
+func TestVendorMac(t *testing.T) {
+	a, err := VendorMac("403cfc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !strings.HasPrefix(a.String(), "40:3c:fc:") {
+		t.Fatalf("expected '40:3c:fc:...', got '%s'", a)
+	} else if b, _ := VendorMac("403cfc"); a.String() == b.String() {
+		t.Fatalf("expected random addresses, got '%s' twice", a)
+	}
+
+	for _, bad := range []string{"", "403c", "403cfc00", "zzzzzz"} {
+		if _, err := VendorMac(bad); err == nil {
+			t.Fatalf("expected error for '%s'", bad)
+		}
+	}
+}
+
 func TestParseTargets(t *testing.T) {
 	ips, macs, err := ParseTargets("192.168.1.2, 192.168.1.3", &Aliases{})
 	if err != nil {
@@ -112,3 +150,73 @@ func TestFindInterface(t *testing.T) {
 		t.Error("unable to find a given interface by name to build endpoint")
 	}
 }
+
+func TestFindInterfaceForAddress(t *testing.T) {
+	iface, err := FindInterfaceForAddress(net.ParseIP("127.0.0.1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if iface.Flags&net.FlagLoopback == 0 {
+		t.Fatalf("expected loopback interface, got %s", iface.Name)
+	}
+}
+
+func TestIsLocallyAdministered(t *testing.T) {
+	var units = []struct {
+		mac string
+		exp bool
+	}{
+		{"00:11:22:33:44:55", false},
+		{"02:11:22:33:44:55", true},
+		{"da:a1:19:00:00:01", true},
+		{"f4:5c:89:00:00:01", false},
+	}
+
+	for _, u := range units {
+		hw, _ := net.ParseMAC(u.mac)
+		if got := IsLocallyAdministered(hw); got != u.exp {
+			t.Fatalf("expected '%v' for %s, got '%v'", u.exp, u.mac, got)
+		}
+	}
+}
+
+func TestEndpointRefresh(t *testing.T) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var lo *net.Interface
+	for i := range ifaces {
+		if ifaces[i].Flags&net.FlagLoopback != 0 {
+			lo = &ifaces[i]
+			break
+		}
+	}
+	if lo == nil {
+		t.Skip("no loopback interface to run test with")
+	}
+
+	e := NewEndpointNoResolve("10.0.0.1", "aa:bb:cc:dd:ee:ff", lo.Name, 24)
+	e.SetIPv6("fe80::1/64")
+	if err := e.Refresh(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if e.Index != lo.Index {
+		t.Fatalf("expected index %d, got %d", lo.Index, e.Index)
+	} else if e.Flags != lo.Flags {
+		t.Fatalf("expected flags '%v', got '%v'", lo.Flags, e.Flags)
+	} else if e.HW.String() != lo.HardwareAddr.String() {
+		t.Fatalf("expected '%s', got '%s'", lo.HardwareAddr, e.HW)
+	} else if e.Ip6Address == "fe80::1" {
+		t.Fatal("expected stale IPv6 address to be replaced")
+	}
+
+	if addrs, _ := lo.Addrs(); len(addrs) > 0 {
+		if e.IpAddress != "127.0.0.1" && e.Ip6Address != "::1" {
+			t.Fatalf("expected loopback addresses, got '%s' and '%s'", e.IpAddress, e.Ip6Address)
+		}
+	}
+
+	if err := NewEndpointNoResolve("10.0.0.1", "", "nope0", 24).Refresh(); err == nil {
+		t.Fatal("expected error for a missing interface")
+	}
+}
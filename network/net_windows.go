@@ -6,6 +6,8 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/bettercap/bettercap/core"
+
 	"github.com/google/gopacket/pcap"
 )
 
@@ -67,3 +69,69 @@ func GetSupportedFrequencies(iface string) ([]int, error) {
 	freqs := make([]int, 0)
 	return freqs, fmt.Errorf("Windows does not support WiFi channel hopping.")
 }
+
+func GetSupportedChannels(iface string) ([]WiFiChannel, error) {
+	freqs, err := GetSupportedFrequencies(iface)
+	if err != nil {
+		return nil, err
+	}
+	return WiFiChannelsOf(freqs), nil
+}
+
+func GetInterfacePromisc(iface string) (bool, error) {
+	return false, fmt.Errorf("Windows does not support reading the promiscuous mode flag.")
+}
+
+func SetInterfacePromisc(iface string, enabled bool) error {
+	return fmt.Errorf("Windows does not support setting the promiscuous mode flag.")
+}
+
+func PermanentMAC(iface string) (net.HardwareAddr, error) {
+	return nil, fmt.Errorf("Windows does not support reading the permanent hardware address.")
+}
+
+func SetInterfaceMAC(iface string, mac net.HardwareAddr) error {
+	return NativeMACError{fmt.Errorf("Windows does not support changing the hardware address.")}
+}
+
+func SetInterfaceLink(iface string, up bool) error {
+	return NativeMACError{fmt.Errorf("Windows does not support changing the interface state.")}
+}
+
+func GetInterfaceSSID(iface string) (string, error) {
+	return "", fmt.Errorf("Windows does not support reading the associated SSID.")
+}
+
+func SetInterfaceUp(iface string, up bool) error {
+	return fmt.Errorf("Windows does not support changing the interface state.")
+}
+
+func GetInterfaceTxPower(iface string) (float64, error) {
+	return 0, fmt.Errorf("Windows does not support reading the WiFi transmit power.")
+}
+
+func GetInterfaceTxPowerRange(iface string) (float64, float64, error) {
+	return 0, 0, fmt.Errorf("Windows does not support reading the WiFi transmit power limits.")
+}
+
+func SetInterfaceTxPower(iface string, dbm float64) error {
+	return fmt.Errorf("Windows does not support setting the WiFi transmit power.")
+}
+
+func ReassociateInterface(iface string, ssid string) error {
+	return fmt.Errorf("Windows does not support WiFi reassociation.")
+}
+
+// ConnectInterface associates the interface to the SSID, which needs a
+// saved profile with the same name.
+func ConnectInterface(iface string, ssid string) error {
+	_, err := core.Exec("netsh", []string{"wlan", "connect", "name=" + ssid, "ssid=" + ssid, "interface=" + iface})
+	return err
+}
+
+func GetInterfaceCaps(iface string) (*InterfaceCaps, error) {
+	// capturing in promiscuous mode is up to the pcap driver
+	caps := NewInterfaceCaps(iface)
+	caps.Promisc = CapabilitySupported
+	return caps, nil
+}
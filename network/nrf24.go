@@ -0,0 +1,168 @@
+package network
+
+import (
+	"fmt"
+	"sync"
+)
+
+// USB identifiers of a CrazyRadio PA flashed with the nRF24 research
+// firmware from the MouseJack project.
+const (
+	NRF24VendorID  = 0x1915
+	NRF24ProductID = 0x0102
+)
+
+// commands of the research firmware
+const (
+	nrf24TransmitPayload       = 0x04
+	nrf24EnterSnifferMode      = 0x05
+	nrf24EnterPromiscuousMode  = 0x06
+	nrf24SetChannel            = 0x09
+	nrf24EnableLNA             = 0x0b
+	nrf24ReceivePayload        = 0x12
+	nrf24MaxChannel            = 125
+	nrf24MaxPayload            = 64
+	nrf24DefaultACKTimeout     = 4
+	nrf24DefaultRetransmits    = 15
+	nrf24PingACKTimeout        = 0
+	nrf24PingRetransmits       = 1
+	nrf24AddressLength         = 5
+	nrf24PromiscuousMinPayload = nrf24AddressLength
+)
+
+// the payload used to check if a device is listening on a channel
+var nrf24PingPayload = []byte{0x0f, 0x0f, 0x0f, 0x0f}
+
+// NRF24Channels are the channels used by the Logitech Unifying,
+// Amazon and Microsoft dongles.
+var NRF24Channels = func() []int {
+	channels := make([]int, 0)
+	for ch := 2; ch <= 83; ch++ {
+		channels = append(channels, ch)
+	}
+	return channels
+}()
+
+// nrf24Transport is the USB link to the dongle, commands are written to
+// the bulk OUT endpoint and answered on the bulk IN one.
+type nrf24Transport interface {
+	Write(data []byte) error
+	Read(buf []byte) (int, error)
+	Close() error
+}
+
+// NRF24 is a nRF24LU1+ based dongle running the research firmware.
+type NRF24 struct {
+	sync.Mutex
+	link    nrf24Transport
+	channel int
+}
+
+// OpenNRF24 opens the first dongle connected.
+func OpenNRF24() (*NRF24, error) {
+	link, err := openNRF24Transport()
+	if err != nil {
+		return nil, err
+	}
+	return &NRF24{link: link}, nil
+}
+
+func (r *NRF24) Close() error {
+	r.Lock()
+	defer r.Unlock()
+	return r.link.Close()
+}
+
+// command sends a command to the dongle and returns its response.
+func (r *NRF24) command(cmd byte, data ...byte) ([]byte, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if err := r.link.Write(append([]byte{cmd}, data...)); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, nrf24MaxPayload)
+	n, err := r.link.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+func (r *NRF24) Channel() int {
+	r.Lock()
+	defer r.Unlock()
+	return r.channel
+}
+
+func (r *NRF24) SetChannel(channel int) error {
+	if channel < 0 || channel > nrf24MaxChannel {
+		return fmt.Errorf("Invalid nRF24 channel %d.", channel)
+	} else if _, err := r.command(nrf24SetChannel, byte(channel)); err != nil {
+		return err
+	}
+
+	r.Lock()
+	r.channel = channel
+	r.Unlock()
+	return nil
+}
+
+// EnableLNA enables the low noise amplifier of the CrazyRadio PA.
+func (r *NRF24) EnableLNA() error {
+	_, err := r.command(nrf24EnableLNA)
+	return err
+}
+
+// EnterPromiscuousMode makes the dongle receive the payloads sent to any
+// address starting with the prefix.
+func (r *NRF24) EnterPromiscuousMode(prefix []byte) error {
+	_, err := r.command(nrf24EnterPromiscuousMode, append([]byte{byte(len(prefix))}, prefix...)...)
+	return err
+}
+
+// EnterSnifferMode makes the dongle receive and transmit the payloads of
+// a single address.
+func (r *NRF24) EnterSnifferMode(address []byte) error {
+	// the firmware wants the address in little endian
+	reversed := make([]byte, len(address))
+	for i, b := range address {
+		reversed[len(address)-1-i] = b
+	}
+	_, err := r.command(nrf24EnterSnifferMode, append([]byte{byte(len(reversed))}, reversed...)...)
+	return err
+}
+
+// ReceivePromiscuous returns the address and the payload of a packet
+// received in promiscuous mode, or nil if there wasn't any.
+func (r *NRF24) ReceivePromiscuous() (address []byte, payload []byte, err error) {
+	value, err := r.command(nrf24ReceivePayload)
+	if err != nil || len(value) < nrf24PromiscuousMinPayload {
+		return nil, nil, err
+	}
+	return value[:nrf24AddressLength], value[nrf24AddressLength:], nil
+}
+
+// TransmitPayload sends a payload in sniffer mode and returns true if it
+// has been acknowledged, the ACK timeout is in steps of 250us.
+func (r *NRF24) TransmitPayload(payload []byte, timeout byte, retransmits byte) (bool, error) {
+	data := append([]byte{byte(len(payload)), timeout, retransmits}, payload...)
+	value, err := r.command(nrf24TransmitPayload, data...)
+	if err != nil {
+		return false, err
+	}
+	return len(value) > 0 && value[0] > 0, nil
+}
+
+// Transmit sends a payload with the default ACK timeout and retransmits.
+func (r *NRF24) Transmit(payload []byte) (bool, error) {
+	return r.TransmitPayload(payload, nrf24DefaultACKTimeout, nrf24DefaultRetransmits)
+}
+
+// Ping is true if the device of the sniffer mode address is listening on
+// the current channel.
+func (r *NRF24) Ping() bool {
+	acked, err := r.TransmitPayload(nrf24PingPayload, nrf24PingACKTimeout, nrf24PingRetransmits)
+	return err == nil && acked
+}
@@ -0,0 +1,139 @@
+package network
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"syscall"
+	"unsafe"
+
+	"github.com/bettercap/bettercap/core"
+)
+
+const (
+	usbSysPath       = "/sys/bus/usb/devices"
+	usbDevPath       = "/dev/bus/usb"
+	nrf24EndpointOut = 0x01
+	nrf24EndpointIn  = 0x81
+	// milliseconds
+	nrf24USBTimeout = 2500
+)
+
+// struct usbdevfs_bulktransfer as defined in linux/usbdevice_fs.h
+type usbdevfsBulkTransfer struct {
+	ep      uint32
+	len     uint32
+	timeout uint32
+	data    uintptr
+}
+
+func usbdevfsIoctl(dir uintptr, nr uintptr, size uintptr) uintptr {
+	return dir<<30 | size<<16 | 'U'<<8 | nr
+}
+
+var (
+	usbdevfsBulk             = usbdevfsIoctl(3, 2, unsafe.Sizeof(usbdevfsBulkTransfer{}))
+	usbdevfsClaimInterface   = usbdevfsIoctl(2, 15, 4)
+	usbdevfsReleaseInterface = usbdevfsIoctl(2, 16, 4)
+)
+
+// usbfsLink talks to the dongle through the usbfs device node.
+type usbfsLink struct {
+	fd    int
+	iface uint32
+}
+
+// findUSBDevice returns the usbfs node of the first device with the
+// given identifiers in the sysfs root.
+func findUSBDevice(root string, vendor, product uint16) (string, error) {
+	entries, err := ioutil.ReadDir(root)
+	if err != nil {
+		return "", err
+	}
+
+	read := func(dir, name string) string {
+		raw, _ := ioutil.ReadFile(filepath.Join(root, dir, name))
+		return core.Trim(string(raw))
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if read(name, "idVendor") != fmt.Sprintf("%04x", vendor) || read(name, "idProduct") != fmt.Sprintf("%04x", product) {
+			continue
+		}
+
+		bus, err := strconv.Atoi(read(name, "busnum"))
+		if err != nil {
+			continue
+		}
+		dev, err := strconv.Atoi(read(name, "devnum"))
+		if err != nil {
+			continue
+		}
+		return filepath.Join(usbDevPath, fmt.Sprintf("%03d", bus), fmt.Sprintf("%03d", dev)), nil
+	}
+
+	return "", fmt.Errorf("No nRF24 dongle (%04x:%04x) found, make sure it's connected and running the research firmware.", vendor, product)
+}
+
+func openNRF24Transport() (nrf24Transport, error) {
+	path, err := findUSBDevice(usbSysPath, NRF24VendorID, NRF24ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	fd, err := syscall.Open(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("Could not open %s: %s", path, err)
+	}
+
+	link := &usbfsLink{fd: fd}
+	if err := link.ioctl(usbdevfsClaimInterface, unsafe.Pointer(&link.iface)); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("Could not claim the interface of %s: %s", path, err)
+	}
+	return link, nil
+}
+
+func (u *usbfsLink) ioctl(req uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(u.fd), req, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func (u *usbfsLink) bulk(ep uint32, buf []byte) (int, error) {
+	if len(buf) == 0 {
+		return 0, nil
+	}
+
+	xfer := usbdevfsBulkTransfer{
+		ep:      ep,
+		len:     uint32(len(buf)),
+		timeout: nrf24USBTimeout,
+		data:    uintptr(unsafe.Pointer(&buf[0])),
+	}
+	n, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(u.fd), usbdevfsBulk, uintptr(unsafe.Pointer(&xfer)))
+	runtime.KeepAlive(buf)
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(n), nil
+}
+
+func (u *usbfsLink) Write(data []byte) error {
+	_, err := u.bulk(nrf24EndpointOut, data)
+	return err
+}
+
+func (u *usbfsLink) Read(buf []byte) (int, error) {
+	return u.bulk(nrf24EndpointIn, buf)
+}
+
+func (u *usbfsLink) Close() error {
+	u.ioctl(usbdevfsReleaseInterface, unsafe.Pointer(&u.iface))
+	return syscall.Close(u.fd)
+}
@@ -0,0 +1,40 @@
+package network
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindUSBDevice(t *testing.T) {
+	root, err := ioutil.TempDir("", "bcap-usb-")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	for name, attrs := range map[string]map[string]string{
+		"1-1":  {"idVendor": "046d\n", "idProduct": "c52b\n", "busnum": "1\n", "devnum": "4\n"},
+		"2-3":  {"idVendor": "1915\n", "idProduct": "0102\n", "busnum": "2\n", "devnum": "17\n"},
+		"usb1": {},
+	} {
+		dir := filepath.Join(root, name)
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for attr, value := range attrs {
+			if err := ioutil.WriteFile(filepath.Join(dir, attr), []byte(value), 0644); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+
+	if path, err := findUSBDevice(root, NRF24VendorID, NRF24ProductID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if exp := "/dev/bus/usb/002/017"; path != exp {
+		t.Fatalf("expected '%s', got '%s'", exp, path)
+	} else if _, err := findUSBDevice(root, 0x1234, 0x5678); err == nil {
+		t.Fatal("expected error for a missing device")
+	}
+}
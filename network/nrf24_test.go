@@ -0,0 +1,83 @@
+package network
+
+import (
+	"bytes"
+	"testing"
+)
+
+// fakeNRF24 records the commands and answers with the queued responses.
+type fakeNRF24 struct {
+	written   [][]byte
+	responses [][]byte
+}
+
+func (f *fakeNRF24) Write(data []byte) error {
+	f.written = append(f.written, append([]byte{}, data...))
+	return nil
+}
+
+func (f *fakeNRF24) Read(buf []byte) (int, error) {
+	if len(f.responses) == 0 {
+		return copy(buf, []byte{0x00}), nil
+	}
+	n := copy(buf, f.responses[0])
+	f.responses = f.responses[1:]
+	return n, nil
+}
+
+func (f *fakeNRF24) Close() error {
+	return nil
+}
+
+func TestNRF24Commands(t *testing.T) {
+	link := &fakeNRF24{
+		responses: [][]byte{
+			// set channel, sniffer mode, ping
+			{0x00}, {0x00}, {0x01},
+			// promiscuous mode, no packet, packet
+			{0x00}, {0xff}, {0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0x00, 0x40},
+		},
+	}
+	r := &NRF24{link: link}
+
+	if err := r.SetChannel(62); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if r.Channel() != 62 {
+		t.Fatalf("expected channel 62, got %d", r.Channel())
+	} else if err := r.EnterSnifferMode([]byte{0xaa, 0xbb, 0xcc, 0xdd, 0xee}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !r.Ping() {
+		t.Fatal("expected the ping to be acknowledged")
+	} else if err := r.EnterPromiscuousMode(nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if address, payload, err := r.ReceivePromiscuous(); err != nil || address != nil || payload != nil {
+		t.Fatalf("expected no packet, got %x %x %v", address, payload, err)
+	} else if address, payload, err := r.ReceivePromiscuous(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !bytes.Equal(address, []byte{0xaa, 0xbb, 0xcc, 0xdd, 0xee}) || !bytes.Equal(payload, []byte{0x00, 0x40}) {
+		t.Fatalf("unexpected packet %x %x", address, payload)
+	}
+
+	expected := [][]byte{
+		{nrf24SetChannel, 62},
+		{nrf24EnterSnifferMode, 5, 0xee, 0xdd, 0xcc, 0xbb, 0xaa},
+		{nrf24TransmitPayload, 4, nrf24PingACKTimeout, nrf24PingRetransmits, 0x0f, 0x0f, 0x0f, 0x0f},
+		{nrf24EnterPromiscuousMode, 0},
+		{nrf24ReceivePayload},
+		{nrf24ReceivePayload},
+	}
+	if len(link.written) != len(expected) {
+		t.Fatalf("expected %d commands, got %d", len(expected), len(link.written))
+	}
+	for i, cmd := range expected {
+		if !bytes.Equal(link.written[i], cmd) {
+			t.Fatalf("expected command %x, got %x", cmd, link.written[i])
+		}
+	}
+
+	if err := r.SetChannel(126); err == nil {
+		t.Fatal("expected error for channel 126")
+	}
+}
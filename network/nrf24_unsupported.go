@@ -0,0 +1,12 @@
+// +build !linux
+
+package network
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func openNRF24Transport() (nrf24Transport, error) {
+	return nil, fmt.Errorf("nRF24 dongles are not supported on %s.", runtime.GOOS)
+}
@@ -1,6 +1,8 @@
 package network
 
 import (
+	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -22936,9 +22938,85 @@ func OuiLookup(mac string) string {
 	octects := strings.Split(mac, ":")
 	if len(octects) > 3 {
 		prefix := octects[0] + octects[1] + octects[2]
-		if vendor, found := oui[prefix]; found {
+		if vendor, found := ouiVendor(prefix); found {
 			return vendor
 		}
 	}
 	return ""
 }
+
+// how many candidates are listed when a vendor name is ambiguous
+const ouiMaxMatches = 10
+
+// OuiVendorLookup returns the vendor matching name and its OUIs, the name
+// is case insensitive and can be part of the vendor one as long as it
+// only matches a single vendor.
+func OuiVendorLookup(name string) (string, []string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return "", nil, fmt.Errorf("Empty vendor name.")
+	}
+
+	exact := ""
+	prefixes := make(map[string][]string)
+	forEachOui(func(prefix, vendor string) {
+		lower := strings.ToLower(vendor)
+		if lower == name {
+			exact = vendor
+		}
+		if strings.Contains(lower, name) {
+			prefixes[vendor] = append(prefixes[vendor], prefix)
+		}
+	})
+
+	vendors := make([]string, 0, len(prefixes))
+	for vendor := range prefixes {
+		vendors = append(vendors, vendor)
+	}
+	sort.Strings(vendors)
+
+	if exact == "" {
+		if len(vendors) == 0 {
+			return "", nil, fmt.Errorf("No vendor matching '%s'.", name)
+		} else if len(vendors) > 1 {
+			if len(vendors) > ouiMaxMatches {
+				vendors = append(vendors[:ouiMaxMatches], "...")
+			}
+			return "", nil, fmt.Errorf("'%s' matches more than one vendor: %s", name, strings.Join(vendors, ", "))
+		}
+		exact = vendors[0]
+	}
+
+	sort.Strings(prefixes[exact])
+	return exact, prefixes[exact], nil
+}
+
+// OuiPool returns the vendor and the OUIs to pick an address from for
+// query, either a vendor name as accepted by OuiVendorLookup or an OUI
+// prefix like 00:1A:2B, which gives every OUI of its vendor.
+func OuiPool(query string) (string, []string, error) {
+	query = strings.TrimSpace(query)
+	if !strings.ContainsAny(query, ":-") && len(query) != 6 {
+		return OuiVendorLookup(query)
+	}
+
+	prefix, err := normalizeOuiPrefix(query)
+	if err != nil {
+		// a six characters vendor name
+		return OuiVendorLookup(query)
+	}
+
+	vendor, found := ouiVendor(prefix)
+	if !found {
+		return "", nil, fmt.Errorf("Unknown OUI %s.", query)
+	}
+
+	prefixes := make([]string, 0)
+	forEachOui(func(p, v string) {
+		if v == vendor {
+			prefixes = append(prefixes, p)
+		}
+	})
+	sort.Strings(prefixes)
+	return vendor, prefixes, nil
+}
@@ -0,0 +1,102 @@
+package network
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// OuiFileName is looked up in the data paths to override the vendors
+// of the builtin database, one "PREFIX VENDOR" entry per line.
+const OuiFileName = "oui.txt"
+
+var (
+	ouiLock      = sync.RWMutex{}
+	ouiOverrides = map[string]string{}
+)
+
+func normalizeOuiPrefix(prefix string) (string, error) {
+	prefix = strings.ToLower(strings.NewReplacer(":", "", "-", "", ".", "").Replace(prefix))
+	if len(prefix) != 6 {
+		return "", fmt.Errorf("'%s' is not a valid OUI.", prefix)
+	}
+	for _, c := range prefix {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return "", fmt.Errorf("'%s' is not a valid OUI.", prefix)
+		}
+	}
+	return prefix, nil
+}
+
+// LoadOuiOverrides replaces the overrides of the builtin vendors with the
+// entries of fileName, empty lines and the ones starting with # are skipped.
+func LoadOuiOverrides(fileName string) (int, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	overrides := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		parts := strings.Fields(line)
+		if len(parts) < 2 {
+			return 0, fmt.Errorf("%s:%d: expected PREFIX VENDOR.", fileName, lineno)
+		}
+
+		prefix, err := normalizeOuiPrefix(parts[0])
+		if err != nil {
+			return 0, fmt.Errorf("%s:%d: %s", fileName, lineno, err)
+		}
+		overrides[prefix] = strings.Join(parts[1:], " ")
+	}
+
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	ouiLock.Lock()
+	defer ouiLock.Unlock()
+	ouiOverrides = overrides
+	return len(overrides), nil
+}
+
+// ResetOuiOverrides goes back to the builtin vendors only.
+func ResetOuiOverrides() {
+	ouiLock.Lock()
+	defer ouiLock.Unlock()
+	ouiOverrides = map[string]string{}
+}
+
+func ouiVendor(prefix string) (string, bool) {
+	ouiLock.RLock()
+	defer ouiLock.RUnlock()
+	if vendor, found := ouiOverrides[prefix]; found {
+		return vendor, true
+	}
+	vendor, found := oui[prefix]
+	return vendor, found
+}
+
+// forEachOui calls cb for every prefix, the overrides taking precedence
+// over the builtin vendors.
+func forEachOui(cb func(prefix, vendor string)) {
+	ouiLock.RLock()
+	defer ouiLock.RUnlock()
+	for prefix, vendor := range ouiOverrides {
+		cb(prefix, vendor)
+	}
+	for prefix, vendor := range oui {
+		if _, found := ouiOverrides[prefix]; !found {
+			cb(prefix, vendor)
+		}
+	}
+}
@@ -1,6 +1,11 @@
 package network
 
-import "testing"
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
 
 func TestOuiVar(t *testing.T) {
 	if len(oui) <= 0 {
@@ -16,3 +21,112 @@ func TestOuiLookup(t *testing.T) {
 		t.Fatalf("expected '%s', got '%s'", exp, got)
 	}
 }
+
+func TestOuiVendorLookup(t *testing.T) {
+	var units = []struct {
+		name   string
+		vendor string
+	}{
+		{"Apple", "Apple"},
+		{"  apple ", "Apple"},
+		{"nintendo", "Nintendo Co."},
+	}
+
+	for _, u := range units {
+		vendor, prefixes, err := OuiVendorLookup(u.name)
+		if err != nil {
+			t.Fatalf("unexpected error for '%s': %v", u.name, err)
+		} else if vendor != u.vendor {
+			t.Fatalf("expected '%s', got '%s'", u.vendor, vendor)
+		} else if len(prefixes) == 0 {
+			t.Fatalf("expected OUIs for '%s'", u.name)
+		}
+
+		for _, prefix := range prefixes {
+			if got := oui[prefix]; got != u.vendor {
+				t.Fatalf("expected '%s' for %s, got '%s'", u.vendor, prefix, got)
+			}
+		}
+	}
+
+	for _, bad := range []string{"", "samsung", "no such vendor"} {
+		if _, _, err := OuiVendorLookup(bad); err == nil {
+			t.Fatalf("expected error for '%s'", bad)
+		}
+	}
+
+	if _, _, err := OuiVendorLookup("samsung"); !strings.Contains(err.Error(), "Samsung Electronics Co.") {
+		t.Fatalf("expected the matching vendors to be listed, got '%s'", err)
+	}
+}
+
+func TestOuiOverrides(t *testing.T) {
+	defer ResetOuiOverrides()
+
+	fp, err := ioutil.TempFile("", "oui")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(fp.Name())
+
+	fp.WriteString("# lab devices\n\nE0-0C-7F Lab Switches\n0a1b2c Lab Phones\n")
+	fp.Close()
+
+	if n, err := LoadOuiOverrides(fp.Name()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if n != 2 {
+		t.Fatalf("expected 2 overrides, got %d", n)
+	} else if got := OuiLookup("e0:0c:7f:00:00:01"); got != "Lab Switches" {
+		t.Fatalf("expected 'Lab Switches', got '%s'", got)
+	} else if vendor, prefixes, err := OuiVendorLookup("lab phones"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if vendor != "Lab Phones" || len(prefixes) != 1 || prefixes[0] != "0a1b2c" {
+		t.Fatalf("expected 'Lab Phones' with '0a1b2c', got '%s' with '%v'", vendor, prefixes)
+	} else if got := OuiLookup("00:00:00:00:00:01"); got != oui["000000"] {
+		t.Fatalf("expected '%s', got '%s'", oui["000000"], got)
+	}
+
+	ioutil.WriteFile(fp.Name(), []byte("nope Lab\n"), 0644)
+	if _, err := LoadOuiOverrides(fp.Name()); err == nil {
+		t.Fatal("expected error for an invalid prefix")
+	} else if got := OuiLookup("e0:0c:7f:00:00:01"); got != "Lab Switches" {
+		t.Fatalf("expected the previous overrides to be kept, got '%s'", got)
+	}
+
+	ResetOuiOverrides()
+	if got := OuiLookup("e0:0c:7f:00:00:01"); got != "Nintendo Co." {
+		t.Fatalf("expected 'Nintendo Co.', got '%s'", got)
+	}
+}
+
+func TestOuiPool(t *testing.T) {
+	var units = []struct {
+		query  string
+		vendor string
+	}{
+		{"apple", "Apple"},
+		{"e0:0c:7f", "Nintendo Co."},
+		{"E0-0C-7F", "Nintendo Co."},
+		{"e00c7f", "Nintendo Co."},
+	}
+
+	for _, u := range units {
+		vendor, prefixes, err := OuiPool(u.query)
+		if err != nil {
+			t.Fatalf("unexpected error for '%s': %v", u.query, err)
+		} else if vendor != u.vendor {
+			t.Fatalf("expected '%s', got '%s'", u.vendor, vendor)
+		}
+
+		_, expected, _ := OuiVendorLookup(u.vendor)
+		if len(prefixes) != len(expected) {
+			t.Fatalf("expected %d OUIs for '%s', got %d", len(expected), u.query, len(prefixes))
+		}
+	}
+
+	for _, bad := range []string{"", "samsung", "aa:bb", "ff:ff:fe"} {
+		if _, _, err := OuiPool(bad); err == nil {
+			t.Fatalf("expected error for '%s'", bad)
+		}
+	}
+}
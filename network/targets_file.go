@@ -0,0 +1,92 @@
+package network
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+)
+
+// TargetsFilePrefix makes a targets parameter read the list from a file.
+const TargetsFilePrefix = "@"
+
+// TargetsFile is a list of targets kept in a file updated by another
+// process, with any of the ParseTargets forms on each line.
+type TargetsFile struct {
+	Path  string
+	mtime time.Time
+}
+
+// NewTargetsFile returns nil if targets is not in the @path form.
+func NewTargetsFile(targets string) (*TargetsFile, error) {
+	if targets = core.Trim(targets); !strings.HasPrefix(targets, TargetsFilePrefix) {
+		return nil, nil
+	}
+
+	path, err := core.ExpandPath(core.Trim(strings.TrimPrefix(targets, TargetsFilePrefix)))
+	if err != nil {
+		return nil, err
+	} else if path == "" {
+		return nil, fmt.Errorf("No targets file specified after %s.", TargetsFilePrefix)
+	}
+
+	return &TargetsFile{Path: path}, nil
+}
+
+// Changed returns true if the file has been modified since the last Parse.
+func (f *TargetsFile) Changed() (bool, error) {
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		return false, err
+	}
+	return !info.ModTime().Equal(f.mtime), nil
+}
+
+// Parse reads the targets, empty lines and the ones starting with #
+// are ignored while the malformed ones are returned as skipped.
+func (f *TargetsFile) Parse(aliasMap *Aliases) (ips []net.IP, macs []net.HardwareAddr, skipped []error, err error) {
+	fp, err := os.Open(f.Path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer fp.Close()
+
+	info, err := fp.Stat()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	ips = make([]net.IP, 0)
+	macs = make([]net.HardwareAddr, 0)
+	skipped = make([]error, 0)
+
+	lineno := 0
+	scanner := bufio.NewScanner(fp)
+	for scanner.Scan() {
+		lineno++
+		line := core.Trim(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		lineIPs, lineMacs, lineErr := ParseTargets(line, aliasMap)
+		if lineErr != nil {
+			skipped = append(skipped, fmt.Errorf("%s:%d: %s", f.Path, lineno, lineErr))
+			continue
+		}
+
+		ips = append(ips, lineIPs...)
+		macs = append(macs, lineMacs...)
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	f.mtime = info.ModTime()
+	return ips, macs, skipped, nil
+}
@@ -0,0 +1,65 @@
+package network
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewTargetsFile(t *testing.T) {
+	if f, err := NewTargetsFile("192.168.1.1, 192.168.1.2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if f != nil {
+		t.Fatalf("expected no targets file, got '%s'", f.Path)
+	} else if _, err := NewTargetsFile("@ "); err == nil {
+		t.Fatal("expected error for missing path")
+	} else if f, err := NewTargetsFile(" @/tmp/targets "); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if f.Path != "/tmp/targets" {
+		t.Fatalf("expected '/tmp/targets', got '%s'", f.Path)
+	}
+}
+
+func TestTargetsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "targets")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "targets")
+	data := "# campaign targets\n192.168.1.10\n\n192.168.1.20-21, aa:bb:cc:dd:ee:ff\nnot a target\n"
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	f, _ := NewTargetsFile("@" + path)
+	if changed, err := f.Changed(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !changed {
+		t.Fatal("expected the file to be changed before the first read")
+	}
+
+	ips, macs, skipped, err := f.Parse(&Aliases{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if got := fmt.Sprintf("%v", ips); got != "[192.168.1.10 192.168.1.20 192.168.1.21]" {
+		t.Fatalf("expected '[192.168.1.10 192.168.1.20 192.168.1.21]', got '%s'", got)
+	} else if len(macs) != 1 || macs[0].String() != "aa:bb:cc:dd:ee:ff" {
+		t.Fatalf("expected '[aa:bb:cc:dd:ee:ff]', got '%v'", macs)
+	} else if len(skipped) != 1 {
+		t.Fatalf("expected 1 skipped line, got '%v'", skipped)
+	} else if changed, _ := f.Changed(); changed {
+		t.Fatal("expected the file not to be changed")
+	}
+
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if changed, _ := f.Changed(); !changed {
+		t.Fatal("expected the file to be changed")
+	}
+}
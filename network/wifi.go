@@ -12,7 +12,10 @@ func Dot11Freq2Chan(freq int) int {
 		return ((freq - 2412) / 5) + 1
 	} else if freq == 2484 {
 		return 14
-	} else if freq >= 5035 && freq <= 5865 {
+	} else if freq >= 4910 && freq <= 4980 {
+		// 4.9GHz channels used in Japan
+		return ((freq - 4910) / 5) + 182
+	} else if freq >= 5035 && freq <= 5885 {
 		return ((freq - 5035) / 5) + 7
 	}
 	return 0
@@ -23,8 +26,10 @@ func Dot11Chan2Freq(channel int) int {
 		return ((channel - 1) * 5) + 2412
 	} else if channel == 14 {
 		return 2484
-	} else if channel <= 173 {
+	} else if channel <= 177 {
 		return ((channel - 7) * 5) + 5035
+	} else if channel >= 182 && channel <= 196 {
+		return ((channel - 182) * 5) + 4910
 	}
 
 	return 0
@@ -40,6 +45,10 @@ type WiFi struct {
 	iface  *Endpoint
 	newCb  APNewCallback
 	lostCb APLostCallback
+	// access points weaker than minRSSI are tracked without being
+	// announced until they get stronger, 0 to announce every one
+	minRSSI int8
+	hidden  map[string]bool
 }
 
 type wifiJSON struct {
@@ -52,6 +61,7 @@ func NewWiFi(iface *Endpoint, newcb APNewCallback, lostcb APLostCallback) *WiFi
 		iface:  iface,
 		newCb:  newcb,
 		lostCb: lostcb,
+		hidden: make(map[string]bool),
 	}
 }
 
@@ -98,13 +108,48 @@ func (w *WiFi) List() (list []*AccessPoint) {
 	return
 }
 
+func (w *WiFi) isVisible(s *Station) bool {
+	return w.minRSSI == 0 || s.RSSI >= w.minRSSI
+}
+
+// Visible returns false for the stations weaker than the threshold.
+func (w *WiFi) Visible(s *Station) bool {
+	w.Lock()
+	defer w.Unlock()
+	return w.isVisible(s)
+}
+
+// SetMinRSSI changes the threshold, announcing the access points which
+// were hidden by the previous one.
+func (w *WiFi) SetMinRSSI(rssi int8) {
+	w.Lock()
+	defer w.Unlock()
+
+	w.minRSSI = rssi
+	for mac := range w.hidden {
+		w.announce(mac)
+	}
+}
+
+func (w *WiFi) announce(mac string) {
+	if ap, found := w.aps[mac]; found && w.isVisible(ap.Station) {
+		delete(w.hidden, mac)
+		if w.newCb != nil {
+			w.newCb(ap)
+		}
+	}
+}
+
 func (w *WiFi) Remove(mac string) {
 	w.Lock()
 	defer w.Unlock()
 
 	if ap, found := w.aps[mac]; found {
 		delete(w.aps, mac)
-		if w.lostCb != nil {
+		if w.hidden[mac] {
+			// never announced
+			delete(w.hidden, mac)
+		} else if w.lostCb != nil {
 			w.lostCb(ap)
 		}
 	}
@@ -134,19 +179,65 @@ func (w *WiFi) AddIfNew(ssid, mac string, frequency int, rssi int8) *AccessPoint
 		if !isBogusMacESSID(ssid) {
 			ap.Hostname = ssid
 		}
+		if w.hidden[mac] {
+			w.announce(mac)
+		}
 		return ap
 	}
 
 	newAp := NewAccessPoint(ssid, mac, frequency, rssi)
 	w.aps[mac] = newAp
 
-	if w.newCb != nil {
+	if !w.isVisible(newAp.Station) {
+		w.hidden[mac] = true
+	} else if w.newCb != nil {
 		w.newCb(newAp)
 	}
 
 	return nil
 }
 
+// Restore adds the access points of a saved session which are not known
+// yet and the clients of the known ones, it returns how many access
+// points have been added.
+func (w *WiFi) Restore(aps []*AccessPoint) int {
+	return w.merge(aps, false)
+}
+
+// AddRemote adds the access points found by a remote instance, announcing
+// the new ones, and updates the known ones, it returns how many are new.
+func (w *WiFi) AddRemote(aps []*AccessPoint) int {
+	return w.merge(aps, true)
+}
+
+func (w *WiFi) merge(aps []*AccessPoint, remote bool) int {
+	w.Lock()
+	defer w.Unlock()
+
+	added := 0
+	for _, ap := range aps {
+		mac := ap.HwAddress
+		if known, found := w.aps[mac]; found {
+			known.restoreClients(ap)
+			if remote && ap.LastSeen.After(known.LastSeen) {
+				known.LastSeen = ap.LastSeen
+				known.RSSI = ap.RSSI
+			}
+			continue
+		}
+
+		w.aps[mac] = ap
+		if !w.isVisible(ap.Station) {
+			w.hidden[mac] = true
+		} else if remote && w.newCb != nil {
+			w.newCb(ap)
+		}
+		added++
+	}
+
+	return added
+}
+
 func (w *WiFi) Get(mac string) (*AccessPoint, bool) {
 	w.Lock()
 	defer w.Unlock()
@@ -172,5 +263,6 @@ func (w *WiFi) GetClient(mac string) (*Station, bool) {
 
 func (w *WiFi) Clear() error {
 	w.aps = make(map[string]*AccessPoint)
+	w.hidden = make(map[string]bool)
 	return nil
 }
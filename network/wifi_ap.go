@@ -38,6 +38,27 @@ func (ap *AccessPoint) MarshalJSON() ([]byte, error) {
 	return json.Marshal(doc)
 }
 
+func (ap *AccessPoint) UnmarshalJSON(raw []byte) error {
+	// the embedded station would decode the whole document
+	var doc struct {
+		Clients []*Station `json:"clients"`
+	}
+
+	station := &Station{}
+	if err := json.Unmarshal(raw, station); err != nil {
+		return err
+	} else if err := json.Unmarshal(raw, &doc); err != nil {
+		return err
+	}
+
+	ap.Station = station
+	ap.clients = make(map[string]*Station)
+	for _, c := range doc.Clients {
+		ap.clients[c.HwAddress] = c
+	}
+	return nil
+}
+
 func (ap *AccessPoint) Get(bssid string) (*Station, bool) {
 	ap.Lock()
 	defer ap.Unlock()
@@ -80,6 +101,19 @@ func (ap *AccessPoint) AddClient(bssid string, frequency int, rssi int8) *Statio
 	return s
 }
 
+// restoreClients adds the clients of a saved copy of the access point
+// which are not known yet.
+func (ap *AccessPoint) restoreClients(saved *AccessPoint) {
+	ap.Lock()
+	defer ap.Unlock()
+
+	for mac, c := range saved.clients {
+		if _, found := ap.clients[mac]; !found {
+			ap.clients[mac] = c
+		}
+	}
+}
+
 func (ap *AccessPoint) NumClients() int {
 	ap.Lock()
 	defer ap.Unlock()
@@ -0,0 +1,67 @@
+package network
+
+const (
+	WiFiBand2GHz = "2.4"
+	WiFiBand5GHz = "5"
+	WiFiBandBoth = "both"
+)
+
+// WiFiChannel is a channel the adapter can be tuned to.
+type WiFiChannel struct {
+	Number    int
+	Frequency int
+	// true if the channel requires radar detection (DFS)
+	DFS bool
+}
+
+func (c WiFiChannel) Band() string {
+	return Dot11FreqBand(c.Frequency)
+}
+
+// Dot11FreqBand returns the band of a frequency in MHz, 2.4 or 5.
+func Dot11FreqBand(freq int) string {
+	if freq >= 2412 && freq <= 2484 {
+		return WiFiBand2GHz
+	} else if freq >= 4910 && freq <= 5885 {
+		return WiFiBand5GHz
+	}
+	return ""
+}
+
+// IsDFSFrequency is true for the 5GHz channels from 52 to 144, which
+// require radar detection in most regulatory domains.
+func IsDFSFrequency(freq int) bool {
+	return freq >= 5260 && freq <= 5720
+}
+
+// WiFiChannelsOf returns the channels of a list of frequencies, used when
+// the adapter doesn't report which ones require radar detection.
+func WiFiChannelsOf(freqs []int) []WiFiChannel {
+	channels := make([]WiFiChannel, 0, len(freqs))
+	for _, freq := range freqs {
+		channels = append(channels, WiFiChannel{
+			Number:    Dot11Freq2Chan(freq),
+			Frequency: freq,
+			DFS:       IsDFSFrequency(freq),
+		})
+	}
+	return channels
+}
+
+// FilterWiFiChannels returns the channels of the selected bands, without
+// the DFS ones unless dfs is true, nor the frequencies that can't be
+// hopped on as they have no channel number.
+func FilterWiFiChannels(channels []WiFiChannel, bands string, dfs bool) []WiFiChannel {
+	filtered := make([]WiFiChannel, 0, len(channels))
+	for _, c := range channels {
+		if band := c.Band(); band == "" || (bands != WiFiBandBoth && band != bands) {
+			continue
+		} else if Dot11Freq2Chan(c.Frequency) == 0 {
+			continue
+		} else if c.DFS && !dfs {
+			continue
+		}
+		filtered = append(filtered, c)
+	}
+	return filtered
+}
@@ -0,0 +1,68 @@
+package network
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDot11FreqBand(t *testing.T) {
+	var units = []struct {
+		freq int
+		exp  string
+	}{
+		{2412, WiFiBand2GHz},
+		{2484, WiFiBand2GHz},
+		{5180, WiFiBand5GHz},
+		{5885, WiFiBand5GHz},
+		{5955, ""},
+		{0, ""},
+	}
+
+	for _, u := range units {
+		if got := Dot11FreqBand(u.freq); got != u.exp {
+			t.Fatalf("expected '%s', got '%s'", u.exp, got)
+		}
+	}
+}
+
+func TestDot11Chan2Freq5GHz(t *testing.T) {
+	for channel, freq := range map[int]int{36: 5180, 52: 5260, 100: 5500, 144: 5720, 165: 5825, 177: 5885, 182: 4910, 196: 4980} {
+		if got := Dot11Chan2Freq(channel); got != freq {
+			t.Fatalf("expected '%v', got '%v'", freq, got)
+		} else if got := Dot11Freq2Chan(freq); got != channel {
+			t.Fatalf("expected '%v', got '%v'", channel, got)
+		}
+	}
+}
+
+func TestWiFiChannelsOf(t *testing.T) {
+	exp := []WiFiChannel{{6, 2437, false}, {48, 5240, false}, {100, 5500, true}, {149, 5745, false}}
+	if got := WiFiChannelsOf([]int{2437, 5240, 5500, 5745}); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, got)
+	}
+}
+
+func TestFilterWiFiChannels(t *testing.T) {
+	channels := WiFiChannelsOf([]int{2412, 2484, 4920, 5010, 5180, 5260, 5825, 5955})
+
+	var units = []struct {
+		bands string
+		dfs   bool
+		exp   []int
+	}{
+		{WiFiBandBoth, true, []int{1, 14, 184, 36, 52, 165}},
+		{WiFiBandBoth, false, []int{1, 14, 184, 36, 165}},
+		{WiFiBand2GHz, true, []int{1, 14}},
+		{WiFiBand5GHz, false, []int{184, 36, 165}},
+	}
+
+	for _, u := range units {
+		got := make([]int, 0)
+		for _, c := range FilterWiFiChannels(channels, u.bands, u.dfs) {
+			got = append(got, c.Number)
+		}
+		if !reflect.DeepEqual(got, u.exp) {
+			t.Fatalf("expected '%v', got '%v'", u.exp, got)
+		}
+	}
+}
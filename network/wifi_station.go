@@ -1,6 +1,7 @@
 package network
 
 import (
+	"encoding/json"
 	"strconv"
 )
 
@@ -15,6 +16,18 @@ type Station struct {
 	Authentication string `json:"authentication"`
 }
 
+// stationJSON are the fields of a station besides the ones of its
+// endpoint.
+type stationJSON struct {
+	Frequency      int    `json:"frequency"`
+	RSSI           int8   `json:"rssi"`
+	Sent           uint64 `json:"sent"`
+	Received       uint64 `json:"received"`
+	Encryption     string `json:"encryption"`
+	Cipher         string `json:"cipher"`
+	Authentication string `json:"authentication"`
+}
+
 func cleanESSID(essid string) string {
 	res := ""
 
@@ -36,6 +49,29 @@ func NewStation(essid, bssid string, frequency int, rssi int8) *Station {
 	}
 }
 
+func (s *Station) UnmarshalJSON(raw []byte) error {
+	var doc stationJSON
+
+	e := &Endpoint{}
+	if err := json.Unmarshal(raw, e); err != nil {
+		return err
+	} else if err := json.Unmarshal(raw, &doc); err != nil {
+		return err
+	}
+
+	*s = Station{
+		Endpoint:       e,
+		Frequency:      doc.Frequency,
+		RSSI:           doc.RSSI,
+		Sent:           doc.Sent,
+		Received:       doc.Received,
+		Encryption:     doc.Encryption,
+		Cipher:         doc.Cipher,
+		Authentication: doc.Authentication,
+	}
+	return nil
+}
+
 func (s Station) BSSID() string {
 	return s.HwAddress
 }
@@ -138,3 +138,49 @@ func TestWiFiClear(t *testing.T) {
 		t.Error("unable to clear known access point for wifi struct")
 	}
 }
+
+func TestWiFiMinRSSI(t *testing.T) {
+	announced := make([]string, 0)
+	lost := make([]string, 0)
+	w := NewWiFi(buildExampleEndpoint(), func(ap *AccessPoint) {
+		announced = append(announced, ap.BSSID())
+	}, func(ap *AccessPoint) {
+		lost = append(lost, ap.BSSID())
+	})
+
+	w.SetMinRSSI(-70)
+	w.AddIfNew("near", "aa:00:00:00:00:01", 2412, -40)
+	w.AddIfNew("far", "aa:00:00:00:00:02", 2412, -90)
+	w.AddIfNew("farther", "aa:00:00:00:00:03", 2412, -95)
+
+	if len(w.List()) != 3 {
+		t.Fatalf("expected 3 tracked access points, got %d", len(w.List()))
+	} else if len(announced) != 1 {
+		t.Fatalf("expected 1 announced access point, got '%v'", announced)
+	}
+
+	far, _ := w.Get("aa:00:00:00:00:02")
+	if w.Visible(far.Station) {
+		t.Fatal("expected weak access point to be hidden")
+	}
+
+	// it gets stronger
+	w.AddIfNew("far", "aa:00:00:00:00:02", 2412, -60)
+	if !w.Visible(far.Station) {
+		t.Fatal("expected access point to be visible")
+	} else if len(announced) != 2 || announced[1] != "aa:00:00:00:00:02" {
+		t.Fatalf("expected 'aa:00:00:00:00:02' to be announced, got '%v'", announced)
+	}
+
+	// never announced, never lost
+	w.Remove("aa:00:00:00:00:03")
+	if len(lost) != 0 {
+		t.Fatalf("expected no lost access points, got '%v'", lost)
+	}
+
+	w.AddIfNew("farther", "aa:00:00:00:00:03", 2412, -95)
+	w.SetMinRSSI(0)
+	if len(announced) != 3 || announced[2] != "aa:00:00:00:00:03" {
+		t.Fatalf("expected 'aa:00:00:00:00:03' to be announced, got '%v'", announced)
+	}
+}
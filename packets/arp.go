@@ -35,6 +35,14 @@ func NewARPRequest(from net.IP, from_hw net.HardwareAddr, to net.IP) (error, []b
 	return Serialize(&eth, &arp)
 }
 
+// NewARPBroadcastRequest is like NewARPRequest but the packet is sent
+// to the broadcast ethernet address, so it reaches the owner of the address.
+func NewARPBroadcastRequest(from net.IP, from_hw net.HardwareAddr, to net.IP) (error, []byte) {
+	eth, arp := NewARP(from, from_hw, to, layers.ARPRequest)
+	eth.DstMAC = layers.EthernetBroadcast
+	return Serialize(&eth, &arp)
+}
+
 func NewARPReply(from net.IP, from_hw net.HardwareAddr, to net.IP, to_hw net.HardwareAddr) (error, []byte) {
 	eth, arp := NewARPTo(from, from_hw, to, to_hw, layers.ARPReply)
 	return Serialize(&eth, &arp)
@@ -4,6 +4,9 @@ import (
 	"net"
 	"reflect"
 	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
 )
 
 func TestNewARPTo(t *testing.T) {
@@ -81,6 +84,31 @@ func TestNewARPRequest(t *testing.T) {
 	}
 }
 
+func TestNewARPBroadcastRequest(t *testing.T) {
+	from := net.IP{192, 168, 1, 2}
+	from_hw, _ := net.ParseMAC("01:23:45:67:89:ab")
+	to := net.IP{192, 168, 1, 3}
+
+	err, raw := NewARPBroadcastRequest(from, from_hw, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkt := gopacket.NewPacket(raw, layers.LayerTypeEthernet, gopacket.Default)
+	eth := pkt.Layer(layers.LayerTypeEthernet).(*layers.Ethernet)
+	arp := pkt.Layer(layers.LayerTypeARP).(*layers.ARP)
+
+	if !reflect.DeepEqual(eth.DstMAC, layers.EthernetBroadcast) {
+		t.Fatalf("expected '%v', got '%v'", layers.EthernetBroadcast, eth.DstMAC)
+	} else if arp.Operation != layers.ARPRequest {
+		t.Fatalf("expected '%v', got '%v'", layers.ARPRequest, arp.Operation)
+	} else if !reflect.DeepEqual(arp.DstHwAddress, []byte{0, 0, 0, 0, 0, 0}) {
+		t.Fatalf("expected '%v', got '%v'", []byte{0, 0, 0, 0, 0, 0}, arp.DstHwAddress)
+	} else if !net.IP(arp.DstProtAddress).Equal(to) {
+		t.Fatalf("expected '%v', got '%v'", to, net.IP(arp.DstProtAddress))
+	}
+}
+
 func TestNewARPReply(t *testing.T) {
 	from := net.IP{0, 0, 0, 0}
 	from_hw, _ := net.ParseMAC("01:23:45:67:89:ab")
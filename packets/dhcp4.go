@@ -0,0 +1,94 @@
+package packets
+
+import (
+	"encoding/binary"
+	"net"
+	"time"
+
+	"github.com/google/gopacket/layers"
+)
+
+const (
+	DHCPv4ServerPort = 67
+	DHCPv4ClientPort = 68
+	// the clients asking for the replies to be broadcast set this flag
+	DHCPv4FlagBroadcast = 0x8000
+	// some clients drop the replies shorter than a BOOTP message
+	dhcpv4MinLength = 300
+)
+
+// DHCPv4Option returns the data of the first option of the type.
+func DHCPv4Option(dhcp *layers.DHCPv4, opt layers.DHCPOpt) ([]byte, bool) {
+	for _, o := range dhcp.Options {
+		if o.Type == opt {
+			return o.Data, true
+		}
+	}
+	return nil, false
+}
+
+// DHCPv4MsgType returns the type of the message, unspecified if missing.
+func DHCPv4MsgType(dhcp *layers.DHCPv4) layers.DHCPMsgType {
+	if data, found := DHCPv4Option(dhcp, layers.DHCPOptMessageType); found && len(data) == 1 {
+		return layers.DHCPMsgType(data[0])
+	}
+	return layers.DHCPMsgTypeUnspecified
+}
+
+// DHCPv4IPOption returns the address of an option such as the requested
+// or the server one, nil if missing.
+func DHCPv4IPOption(dhcp *layers.DHCPv4, opt layers.DHCPOpt) net.IP {
+	if data, found := DHCPv4Option(dhcp, opt); found && len(data) == net.IPv4len {
+		return net.IP(data)
+	}
+	return nil
+}
+
+func DHCPv4IPsOption(opt layers.DHCPOpt, ips ...net.IP) layers.DHCPOption {
+	data := make([]byte, 0, len(ips)*net.IPv4len)
+	for _, ip := range ips {
+		data = append(data, ip.To4()...)
+	}
+	return layers.NewDHCPOption(opt, data)
+}
+
+func DHCPv4DurationOption(opt layers.DHCPOpt, d time.Duration) layers.DHCPOption {
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, uint32(d/time.Second))
+	return layers.NewDHCPOption(opt, data)
+}
+
+// NewDHCPv4Reply creates the reply of the server to the client, it is
+// broadcast if to is nil.
+func NewDHCPv4Reply(from net.IP, fromHW net.HardwareAddr, to net.IP, toHW net.HardwareAddr, reply *layers.DHCPv4) (error, []byte) {
+	if to == nil {
+		to = net.IPv4bcast
+		toHW = layers.EthernetBroadcast
+	}
+
+	for reply.Len() < dhcpv4MinLength {
+		reply.Options = append(reply.Options, layers.NewDHCPOption(layers.DHCPOptPad, nil))
+	}
+
+	eth := layers.Ethernet{
+		SrcMAC:       fromHW,
+		DstMAC:       toHW,
+		EthernetType: layers.EthernetTypeIPv4,
+	}
+
+	ip4 := layers.IPv4{
+		Protocol: layers.IPProtocolUDP,
+		Version:  4,
+		TTL:      64,
+		SrcIP:    from,
+		DstIP:    to,
+	}
+
+	udp := layers.UDP{
+		SrcPort: DHCPv4ServerPort,
+		DstPort: DHCPv4ClientPort,
+	}
+	udp.SetNetworkLayerForChecksum(&ip4)
+
+	return Serialize(&eth, &ip4, &udp, reply)
+}
@@ -0,0 +1,59 @@
+package packets
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func TestNewDHCPv4Reply(t *testing.T) {
+	hw, _ := net.ParseMAC("00:11:22:33:44:55")
+	toHW, _ := net.ParseMAC("66:77:88:99:aa:bb")
+	from := net.ParseIP("192.168.1.2").To4()
+
+	reply := &layers.DHCPv4{
+		Operation:    layers.DHCPOpReply,
+		HardwareType: layers.LinkTypeEthernet,
+		HardwareLen:  6,
+		Xid:          0x1234,
+		ClientIP:     net.IPv4zero.To4(),
+		YourClientIP: net.ParseIP("192.168.1.10").To4(),
+		NextServerIP: net.IPv4zero.To4(),
+		RelayAgentIP: net.IPv4zero.To4(),
+		ClientHWAddr: toHW,
+		Options: layers.DHCPOptions{
+			layers.NewDHCPOption(layers.DHCPOptMessageType, []byte{byte(layers.DHCPMsgTypeOffer)}),
+			DHCPv4IPsOption(layers.DHCPOptDNS, from, net.ParseIP("8.8.8.8")),
+		},
+	}
+
+	err, raw := NewDHCPv4Reply(from, hw, nil, nil, reply)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pkt := gopacket.NewPacket(raw, layers.LayerTypeEthernet, gopacket.Default)
+	eth := pkt.Layer(layers.LayerTypeEthernet).(*layers.Ethernet)
+	ip4 := pkt.Layer(layers.LayerTypeIPv4).(*layers.IPv4)
+	udp := pkt.Layer(layers.LayerTypeUDP).(*layers.UDP)
+	dhcp, ok := pkt.Layer(layers.LayerTypeDHCPv4).(*layers.DHCPv4)
+	if !ok {
+		t.Fatalf("expected a DHCPv4 packet")
+	} else if eth.DstMAC.String() != "ff:ff:ff:ff:ff:ff" || !ip4.DstIP.Equal(net.IPv4bcast) {
+		t.Fatalf("expected a broadcast, got '%s' '%s'", eth.DstMAC, ip4.DstIP)
+	} else if len(udp.Payload) < dhcpv4MinLength {
+		t.Fatalf("expected at least %d bytes, got %d", dhcpv4MinLength, len(udp.Payload))
+	} else if DHCPv4MsgType(dhcp) != layers.DHCPMsgTypeOffer {
+		t.Fatalf("expected an offer, got %s", DHCPv4MsgType(dhcp))
+	} else if data, _ := DHCPv4Option(dhcp, layers.DHCPOptDNS); len(data) != 8 {
+		t.Fatalf("expected two DNS servers, got %x", data)
+	}
+
+	_, raw = NewDHCPv4Reply(from, hw, reply.YourClientIP, toHW, reply)
+	pkt = gopacket.NewPacket(raw, layers.LayerTypeEthernet, gopacket.Default)
+	if eth = pkt.Layer(layers.LayerTypeEthernet).(*layers.Ethernet); eth.DstMAC.String() != toHW.String() {
+		t.Fatalf("expected '%s', got '%s'", toHW, eth.DstMAC)
+	}
+}
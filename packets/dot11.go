@@ -26,6 +26,17 @@ var (
 		0x00, 0x00,
 	}
 	wpaSignatureBytes = []byte{0, 0x50, 0xf2, 1}
+	// ESS, privacy, short preamble and short slot time
+	stationFlags = 0x0431
+	stationRSN   = []byte{
+		0x01, 0x00, // RSN Version 1
+		0x00, 0x0f, 0xac, 0x04, // Group Cipher Suite : 00-0f-ac CCMP
+		0x01, 0x00, // 1 Pairwise Cipher Suite (next line)
+		0x00, 0x0f, 0xac, 0x04, // AES Cipher / CCMP
+		0x01, 0x00, // 1 Authentication Key Management Suite (line below)
+		0x00, 0x0f, 0xac, 0x02, // Pre-Shared Key
+		0x00, 0x00,
+	}
 )
 
 type Dot11ApConfig struct {
@@ -97,6 +108,49 @@ func NewDot11Deauth(a1 net.HardwareAddr, a2 net.HardwareAddr, a3 net.HardwareAdd
 	)
 }
 
+// NewDot11Auth returns an open system authentication request from the
+// station to the access point.
+func NewDot11Auth(station net.HardwareAddr, ap net.HardwareAddr, seq uint16) (error, []byte) {
+	return Serialize(
+		&layers.RadioTap{},
+		&layers.Dot11{
+			Address1:       ap,
+			Address2:       station,
+			Address3:       ap,
+			Type:           layers.Dot11TypeMgmtAuthentication,
+			SequenceNumber: seq,
+		},
+		&layers.Dot11MgmtAuthentication{
+			Algorithm: layers.Dot11AlgorithmOpen,
+			Sequence:  1,
+			Status:    layers.Dot11StatusSuccess,
+		},
+	)
+}
+
+// NewDot11AssociationRequest returns an association request to a WPA2
+// PSK access point, which answers with the first EAPOL frame of the
+// handshake.
+func NewDot11AssociationRequest(station net.HardwareAddr, ap net.HardwareAddr, essid string, seq uint16) (error, []byte) {
+	return Serialize(
+		&layers.RadioTap{},
+		&layers.Dot11{
+			Address1:       ap,
+			Address2:       station,
+			Address3:       ap,
+			Type:           layers.Dot11TypeMgmtAssociationReq,
+			SequenceNumber: seq,
+		},
+		&layers.Dot11MgmtAssociationReq{
+			CapabilityInfo: uint16(stationFlags),
+			ListenInterval: 10,
+		},
+		Dot11Info(layers.Dot11InformationElementIDSSID, []byte(essid)),
+		Dot11Info(layers.Dot11InformationElementIDRates, supportedRates),
+		Dot11Info(layers.Dot11InformationElementIDRSNInfo, stationRSN),
+	)
+}
+
 func Dot11Parse(packet gopacket.Packet) (ok bool, radiotap *layers.RadioTap, dot11 *layers.Dot11) {
 	ok = false
 	radiotap = nil
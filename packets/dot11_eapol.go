@@ -0,0 +1,138 @@
+package packets
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// key information flags of an EAPOL key frame
+const (
+	eapolKeyPairwise = 0x0008
+	eapolKeyInstall  = 0x0040
+	eapolKeyAck      = 0x0080
+	eapolKeyMIC      = 0x0100
+	eapolKeySecure   = 0x0200
+)
+
+const (
+	eapolHeaderSize = 4
+	// fixed part of the key descriptor, up to the key data length
+	eapolKeySize      = 95
+	eapolKeyReplayOff = 5
+	eapolKeyNonceOff  = 13
+	eapolKeyMICOff    = 77
+	eapolKeyDataOff   = 95
+	eapolKeyLengthOff = 93
+	eapolNonceSize    = 32
+	eapolMICSize      = 16
+	pmkidSize         = 16
+)
+
+// PMKID KDE as found in the key data of the first message
+var pmkidKDEPrefix = []byte{0xdd, 0x14, 0x00, 0x0f, 0xac, 0x04}
+
+// EAPOLKey is an EAPOL key frame of a 4-way handshake.
+type EAPOLKey struct {
+	// the 1 to 4 message number of the handshake
+	Message int
+	AP      net.HardwareAddr
+	Station net.HardwareAddr
+	Replay  uint64
+	Nonce   []byte
+	MIC     []byte
+	PMKID   []byte
+	// the whole EAPOL frame
+	Frame []byte
+}
+
+func eapolKeyMessage(info uint16) int {
+	if info&eapolKeyPairwise == 0 {
+		return 0
+	} else if info&eapolKeyAck != 0 {
+		if info&eapolKeyMIC == 0 {
+			return 1
+		} else if info&eapolKeyInstall != 0 {
+			return 3
+		}
+	} else if info&eapolKeyMIC != 0 {
+		if info&eapolKeySecure == 0 {
+			return 2
+		}
+		return 4
+	}
+	return 0
+}
+
+func eapolParsePMKID(data []byte) []byte {
+	if idx := bytes.Index(data, pmkidKDEPrefix); idx >= 0 && len(data) >= idx+len(pmkidKDEPrefix)+pmkidSize {
+		start := idx + len(pmkidKDEPrefix)
+		pmkid := data[start : start+pmkidSize]
+		if !bytes.Equal(pmkid, make([]byte, pmkidSize)) {
+			return pmkid
+		}
+	}
+	return nil
+}
+
+// Dot11ParseEAPOLKey returns the pairwise EAPOL key frame carried by
+// the 802.11 data packet, if any.
+func Dot11ParseEAPOLKey(packet gopacket.Packet, dot11 *layers.Dot11) (bool, *EAPOLKey) {
+	eapolLayer := packet.Layer(layers.LayerTypeEAPOL)
+	if eapolLayer == nil {
+		return false, nil
+	}
+
+	eapol, ok := eapolLayer.(*layers.EAPOL)
+	if !ok || eapol.Type != layers.EAPOLTypeKey {
+		return false, nil
+	}
+
+	frame := append(append([]byte{}, eapol.Contents...), eapol.Payload...)
+	size := eapolHeaderSize + int(eapol.Length)
+	if len(frame) < size || size < eapolHeaderSize+eapolKeySize {
+		return false, nil
+	}
+	frame = frame[:size]
+
+	body := frame[eapolHeaderSize:]
+	info := binary.BigEndian.Uint16(body[1:3])
+	key := &EAPOLKey{
+		Message: eapolKeyMessage(info),
+		Replay:  binary.BigEndian.Uint64(body[eapolKeyReplayOff:eapolKeyNonceOff]),
+		Nonce:   body[eapolKeyNonceOff : eapolKeyNonceOff+eapolNonceSize],
+		MIC:     body[eapolKeyMICOff : eapolKeyMICOff+eapolMICSize],
+		Frame:   frame,
+	}
+	if key.Message == 0 {
+		return false, nil
+	}
+
+	// messages 1 and 3 are sent by the access point
+	if key.Message == 1 || key.Message == 3 {
+		key.AP, key.Station = dot11.Address2, dot11.Address1
+	} else {
+		key.AP, key.Station = dot11.Address1, dot11.Address2
+	}
+
+	if key.Message == 1 {
+		dataSize := int(binary.BigEndian.Uint16(body[eapolKeyLengthOff:eapolKeyDataOff]))
+		if len(body) >= eapolKeyDataOff+dataSize {
+			key.PMKID = eapolParsePMKID(body[eapolKeyDataOff : eapolKeyDataOff+dataSize])
+		}
+	}
+
+	return true, key
+}
+
+// FrameWithoutMIC returns a copy of the frame with the MIC zeroed, as
+// it was when the MIC was computed.
+func (k *EAPOLKey) FrameWithoutMIC() []byte {
+	frame := append([]byte{}, k.Frame...)
+	off := eapolHeaderSize + eapolKeyMICOff
+	copy(frame[off:off+eapolMICSize], make([]byte, eapolMICSize))
+	return frame
+}
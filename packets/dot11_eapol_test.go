@@ -0,0 +1,119 @@
+package packets
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+var (
+	testEAPOLAP, _      = net.ParseMAC("00:11:22:33:44:55")
+	testEAPOLStation, _ = net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	testPMKID           = bytes.Repeat([]byte{0x44}, 16)
+)
+
+func buildEAPOLKeyPacket(fromAP bool, info uint16, replay uint64, nonce, mic, data []byte) gopacket.Packet {
+	raw := []byte{0x08, 0x01, 0x00, 0x00}
+	if fromAP {
+		raw[1] = 0x02
+		raw = append(raw, testEAPOLStation...)
+		raw = append(raw, testEAPOLAP...)
+	} else {
+		raw = append(raw, testEAPOLAP...)
+		raw = append(raw, testEAPOLStation...)
+	}
+	raw = append(raw, testEAPOLAP...)
+	raw = append(raw, 0x00, 0x00)
+	// LLC + SNAP
+	raw = append(raw, 0xaa, 0xaa, 0x03, 0x00, 0x00, 0x00, 0x88, 0x8e)
+
+	body := make([]byte, eapolKeySize)
+	body[0] = 2
+	binary.BigEndian.PutUint16(body[1:3], info)
+	binary.BigEndian.PutUint64(body[eapolKeyReplayOff:], replay)
+	copy(body[eapolKeyNonceOff:], nonce)
+	copy(body[eapolKeyMICOff:], mic)
+	binary.BigEndian.PutUint16(body[eapolKeyLengthOff:], uint16(len(data)))
+	body = append(body, data...)
+
+	header := []byte{0x02, 0x03, 0x00, 0x00}
+	binary.BigEndian.PutUint16(header[2:], uint16(len(body)))
+	raw = append(raw, header...)
+	raw = append(raw, body...)
+	// FCS
+	raw = append(raw, 0x00, 0x00, 0x00, 0x00)
+
+	return gopacket.NewPacket(raw, layers.LayerTypeDot11, gopacket.Default)
+}
+
+func parseTestEAPOLKey(t *testing.T, packet gopacket.Packet) (bool, *EAPOLKey) {
+	dot11, ok := packet.Layer(layers.LayerTypeDot11).(*layers.Dot11)
+	if !ok {
+		t.Fatal("expected a dot11 layer")
+	}
+	return Dot11ParseEAPOLKey(packet, dot11)
+}
+
+func TestDot11ParseEAPOLKeyMessages(t *testing.T) {
+	anonce := bytes.Repeat([]byte{0x11}, 32)
+	snonce := bytes.Repeat([]byte{0x22}, 32)
+	mic := bytes.Repeat([]byte{0x33}, 16)
+	kde := append(append([]byte{}, pmkidKDEPrefix...), testPMKID...)
+
+	var units = []struct {
+		packet  gopacket.Packet
+		message int
+		fromAP  bool
+		pmkid   []byte
+	}{
+		{buildEAPOLKeyPacket(true, 0x008a, 1, anonce, nil, kde), 1, true, testPMKID},
+		{buildEAPOLKeyPacket(false, 0x010a, 1, snonce, mic, nil), 2, false, nil},
+		{buildEAPOLKeyPacket(true, 0x13ca, 2, anonce, mic, nil), 3, true, nil},
+		{buildEAPOLKeyPacket(false, 0x030a, 2, nil, mic, nil), 4, false, nil},
+	}
+
+	for _, u := range units {
+		ok, key := parseTestEAPOLKey(t, u.packet)
+		if !ok {
+			t.Fatalf("expected message %d to be parsed", u.message)
+		} else if key.Message != u.message {
+			t.Fatalf("expected message %d, got %d", u.message, key.Message)
+		} else if !bytes.Equal(key.AP, testEAPOLAP) || !bytes.Equal(key.Station, testEAPOLStation) {
+			t.Fatalf("unexpected addresses %s / %s for message %d", key.AP, key.Station, u.message)
+		} else if !bytes.Equal(key.PMKID, u.pmkid) {
+			t.Fatalf("expected '%x', got '%x'", u.pmkid, key.PMKID)
+		}
+	}
+}
+
+func TestDot11ParseEAPOLKeyFrame(t *testing.T) {
+	mic := bytes.Repeat([]byte{0x33}, 16)
+	ok, key := parseTestEAPOLKey(t, buildEAPOLKeyPacket(false, 0x010a, 7, bytes.Repeat([]byte{0x22}, 32), mic, nil))
+	if !ok {
+		t.Fatal("expected the key to be parsed")
+	} else if key.Replay != 7 {
+		t.Fatalf("expected replay counter 7, got %d", key.Replay)
+	} else if len(key.Frame) != eapolHeaderSize+eapolKeySize {
+		t.Fatalf("expected frame size %d, got %d", eapolHeaderSize+eapolKeySize, len(key.Frame))
+	} else if !bytes.Equal(key.MIC, mic) {
+		t.Fatalf("expected '%x', got '%x'", mic, key.MIC)
+	}
+
+	zeroed := key.FrameWithoutMIC()
+	if bytes.Contains(zeroed, mic) {
+		t.Fatal("expected the MIC to be zeroed")
+	} else if !bytes.Equal(key.MIC, mic) {
+		t.Fatal("expected the original frame to be untouched")
+	}
+}
+
+func TestDot11ParseEAPOLKeyInvalid(t *testing.T) {
+	// group key message
+	if ok, _ := parseTestEAPOLKey(t, buildEAPOLKeyPacket(true, 0x0382, 1, nil, nil, nil)); ok {
+		t.Fatal("group key messages should not be parsed")
+	}
+}
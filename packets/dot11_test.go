@@ -133,6 +133,61 @@ func TestNewDot11Deauth(t *testing.T) {
 	}
 }
 
+func TestNewDot11Auth(t *testing.T) {
+	station, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	ap, _ := net.ParseMAC("00:11:22:33:44:55")
+
+	err, raw := NewDot11Auth(station, ap, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	packet := gopacket.NewPacket(raw, layers.LayerTypeRadioTap, gopacket.Default)
+	if ok, _, dot11 := Dot11Parse(packet); !ok {
+		t.Fatal("unable to parse authentication packet")
+	} else if dot11.Type != layers.Dot11TypeMgmtAuthentication || dot11.Address1.String() != ap.String() || dot11.Address2.String() != station.String() {
+		t.Fatalf("unexpected authentication header %v", dot11)
+	} else if auth, ok := packet.Layer(layers.LayerTypeDot11MgmtAuthentication).(*layers.Dot11MgmtAuthentication); !ok {
+		t.Fatal("unable to find the authentication layer")
+	} else if auth.Algorithm != layers.Dot11AlgorithmOpen || auth.Sequence != 1 {
+		t.Fatalf("unexpected authentication %v", auth)
+	}
+}
+
+func TestNewDot11AssociationRequest(t *testing.T) {
+	station, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	ap, _ := net.ParseMAC("00:11:22:33:44:55")
+
+	err, raw := NewDot11AssociationRequest(station, ap, "lab", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	packet := gopacket.NewPacket(raw, layers.LayerTypeRadioTap, gopacket.Default)
+	if ok, _, dot11 := Dot11Parse(packet); !ok {
+		t.Fatal("unable to parse association request packet")
+	} else if dot11.Type != layers.Dot11TypeMgmtAssociationReq || dot11.SequenceNumber != 2 {
+		t.Fatalf("unexpected association request header %v", dot11)
+	} else if ok, ssid := Dot11ParseIDSSID(packet); !ok || ssid != "lab" {
+		t.Fatalf("expected 'lab', got '%s'", ssid)
+	}
+
+	found := false
+	for _, layer := range packet.Layers() {
+		if info, ok := layer.(*layers.Dot11InformationElement); ok && info.ID == layers.Dot11InformationElementIDRSNInfo {
+			if rsn, err := Dot11InformationElementRSNInfoDecode(info.Info); err != nil {
+				t.Fatal(err)
+			} else if rsn.Pairwise.Count != 1 || rsn.AuthKey.Count != 1 {
+				t.Fatalf("unexpected RSN info %v", rsn)
+			}
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("expected a RSN information element")
+	}
+}
+
 func BuildDot11Packet() gopacket.Packet {
 	mac, _ := net.ParseMAC("00:00:00:00:00:00")
 	seq := uint16(0)
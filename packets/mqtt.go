@@ -0,0 +1,262 @@
+package packets
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	MQTTPort = 1883
+	MQTTv5   = 5
+
+	MQTTConnectType    = 1
+	MQTTPublishType    = 3
+	MQTTDisconnectType = 14
+)
+
+const (
+	mqttConnectUsername = 0x80
+	mqttConnectPassword = 0x40
+	mqttConnectWill     = 0x04
+)
+
+// MQTTPacket is a control packet split by its fixed header.
+type MQTTPacket struct {
+	Type  byte
+	Flags byte
+	Body  []byte
+}
+
+type MQTTConnect struct {
+	Protocol    string
+	Level       byte
+	KeepAlive   uint16
+	ClientID    string
+	WillTopic   string
+	HasUsername bool
+	Username    string
+	HasPassword bool
+	Password    string
+}
+
+type MQTTPublish struct {
+	Topic    string
+	QoS      byte
+	Retain   bool
+	Dup      bool
+	PacketID uint16
+	Payload  []byte
+}
+
+type mqttReader struct {
+	buf  []byte
+	what string
+}
+
+func (r *mqttReader) need(n int) error {
+	if n > len(r.buf) {
+		return fmt.Errorf("Malformed MQTT packet, could not parse %s: needed %d bytes but only %d are available.", r.what, n, len(r.buf))
+	}
+	return nil
+}
+
+func (r *mqttReader) byte() (byte, error) {
+	if err := r.need(1); err != nil {
+		return 0, err
+	}
+	b := r.buf[0]
+	r.buf = r.buf[1:]
+	return b, nil
+}
+
+func (r *mqttReader) uint16() (uint16, error) {
+	if err := r.need(2); err != nil {
+		return 0, err
+	}
+	v := binary.BigEndian.Uint16(r.buf)
+	r.buf = r.buf[2:]
+	return v, nil
+}
+
+// binary data and strings are both prefixed by their length
+func (r *mqttReader) bytes() ([]byte, error) {
+	size, err := r.uint16()
+	if err != nil {
+		return nil, err
+	} else if err = r.need(int(size)); err != nil {
+		return nil, err
+	}
+	data := r.buf[:size]
+	r.buf = r.buf[size:]
+	return data, nil
+}
+
+func (r *mqttReader) string() (string, error) {
+	s, err := r.bytes()
+	return string(s), err
+}
+
+// skips the properties of MQTT 5 packets
+func (r *mqttReader) properties() error {
+	size, n, err := mqttVarInt(r.buf)
+	if err != nil {
+		return err
+	}
+	r.buf = r.buf[n:]
+	if err = r.need(size); err != nil {
+		return err
+	}
+	r.buf = r.buf[size:]
+	return nil
+}
+
+func mqttVarInt(buf []byte) (value int, n int, err error) {
+	multiplier := 1
+	for n < 4 {
+		if n >= len(buf) {
+			return 0, 0, fmt.Errorf("Malformed MQTT packet, truncated variable length integer.")
+		}
+		b := buf[n]
+		n++
+		value += int(b&0x7f) * multiplier
+		if b&0x80 == 0 {
+			return value, n, nil
+		}
+		multiplier *= 128
+	}
+	return 0, 0, fmt.Errorf("Malformed MQTT packet, variable length integer longer than 4 bytes.")
+}
+
+// MQTTIsConnect checks for the signature of a CONNECT packet,
+// so that brokers listening on non standard ports are detected.
+func MQTTIsConnect(data []byte) bool {
+	if len(data) < 2 || data[0] != MQTTConnectType<<4 {
+		return false
+	}
+
+	_, n, err := mqttVarInt(data[1:])
+	if err != nil {
+		return false
+	}
+
+	header := data[1+n:]
+	return bytes.HasPrefix(header, []byte("\x00\x04MQTT")) || bytes.HasPrefix(header, []byte("\x00\x06MQIsdp"))
+}
+
+// MQTTParse splits a TCP payload into the MQTT packets it contains,
+// failing if it doesn't look like MQTT at all or it's truncated.
+func MQTTParse(data []byte) (packets []MQTTPacket, err error) {
+	for len(data) > 0 {
+		if len(data) < 2 {
+			return nil, fmt.Errorf("Malformed MQTT packet, truncated fixed header.")
+		}
+
+		ptype := data[0] >> 4
+		if ptype == 0 {
+			return nil, fmt.Errorf("Malformed MQTT packet, reserved packet type.")
+		}
+
+		size, n, err := mqttVarInt(data[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		start := 1 + n
+		if start+size > len(data) {
+			return nil, fmt.Errorf("Malformed MQTT packet, needed %d bytes but only %d are available.", size, len(data)-start)
+		}
+
+		packets = append(packets, MQTTPacket{
+			Type:  ptype,
+			Flags: data[0] & 0x0f,
+			Body:  data[start : start+size],
+		})
+		data = data[start+size:]
+	}
+	return packets, nil
+}
+
+func (p MQTTPacket) Connect() (c MQTTConnect, err error) {
+	if p.Type != MQTTConnectType {
+		return c, fmt.Errorf("Not an MQTT CONNECT packet.")
+	}
+
+	r := &mqttReader{buf: p.Body, what: "CONNECT"}
+	if c.Protocol, err = r.string(); err != nil {
+		return
+	} else if c.Protocol != "MQTT" && c.Protocol != "MQIsdp" {
+		return c, fmt.Errorf("Unknown MQTT protocol name '%s'.", c.Protocol)
+	} else if c.Level, err = r.byte(); err != nil {
+		return
+	}
+
+	flags, err := r.byte()
+	if err != nil {
+		return
+	} else if c.KeepAlive, err = r.uint16(); err != nil {
+		return
+	} else if c.Level == MQTTv5 {
+		if err = r.properties(); err != nil {
+			return
+		}
+	}
+
+	if c.ClientID, err = r.string(); err != nil {
+		return
+	}
+
+	if flags&mqttConnectWill != 0 {
+		if c.Level == MQTTv5 {
+			if err = r.properties(); err != nil {
+				return
+			}
+		}
+		if c.WillTopic, err = r.string(); err != nil {
+			return
+		} else if _, err = r.bytes(); err != nil {
+			return
+		}
+	}
+
+	if c.HasUsername = flags&mqttConnectUsername != 0; c.HasUsername {
+		if c.Username, err = r.string(); err != nil {
+			return
+		}
+	}
+
+	if c.HasPassword = flags&mqttConnectPassword != 0; c.HasPassword {
+		if c.Password, err = r.string(); err != nil {
+			return
+		}
+	}
+
+	return c, nil
+}
+
+// Publish decodes a PUBLISH packet, since the protocol level is only
+// known to the CONNECT, MQTT 5 properties end up in the payload.
+func (p MQTTPacket) Publish() (pub MQTTPublish, err error) {
+	if p.Type != MQTTPublishType {
+		return pub, fmt.Errorf("Not an MQTT PUBLISH packet.")
+	}
+
+	pub.Dup = p.Flags&0x08 != 0
+	pub.QoS = (p.Flags >> 1) & 0x03
+	pub.Retain = p.Flags&0x01 != 0
+	if pub.QoS > 2 {
+		return pub, fmt.Errorf("Invalid MQTT PUBLISH QoS %d.", pub.QoS)
+	}
+
+	r := &mqttReader{buf: p.Body, what: "PUBLISH"}
+	if pub.Topic, err = r.string(); err != nil {
+		return
+	} else if pub.QoS > 0 {
+		if pub.PacketID, err = r.uint16(); err != nil {
+			return
+		}
+	}
+
+	pub.Payload = r.buf
+	return pub, nil
+}
@@ -0,0 +1,134 @@
+package packets
+
+import (
+	"reflect"
+	"testing"
+)
+
+// mosquitto_pub -i mosq-thermostat-07 -u iot -P 's3cr3t!' (MQTT 3.1.1)
+var mqttConnectV311 = []byte{
+	0x10, 0x2c, 0x00, 0x04, 0x4d, 0x51, 0x54, 0x54, 0x04, 0xc2, 0x00, 0x3c,
+	0x00, 0x12, 0x6d, 0x6f, 0x73, 0x71, 0x2d, 0x74, 0x68, 0x65, 0x72, 0x6d,
+	0x6f, 0x73, 0x74, 0x61, 0x74, 0x2d, 0x30, 0x37, 0x00, 0x03, 0x69, 0x6f,
+	0x74, 0x00, 0x07, 0x73, 0x33, 0x63, 0x72, 0x33, 0x74, 0x21,
+}
+
+// MQTT 5 with session expiry property and a will
+var mqttConnectV5 = []byte{
+	0x10, 0x3c, 0x00, 0x04, 0x4d, 0x51, 0x54, 0x54, 0x05, 0xc6, 0x00, 0x3c,
+	0x05, 0x11, 0x00, 0x00, 0x00, 0x0a, 0x00, 0x08, 0x73, 0x65, 0x6e, 0x73,
+	0x6f, 0x72, 0x2d, 0x31, 0x00, 0x00, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x00, 0x07, 0x6f, 0x66, 0x66, 0x6c, 0x69, 0x6e, 0x65, 0x00, 0x05,
+	0x61, 0x64, 0x6d, 0x69, 0x6e, 0x00, 0x07, 0x68, 0x75, 0x6e, 0x74, 0x65,
+	0x72, 0x32,
+}
+
+// PUBLISH QoS 1 to home/temp followed by a DISCONNECT
+var mqttPublish = []byte{
+	0x33, 0x11, 0x00, 0x09, 0x68, 0x6f, 0x6d, 0x65, 0x2f, 0x74, 0x65, 0x6d,
+	0x70, 0x00, 0x0a, 0x32, 0x31, 0x2e, 0x35, 0xe0, 0x00,
+}
+
+func TestMQTTConnect(t *testing.T) {
+	var units = []struct {
+		data []byte
+		exp  MQTTConnect
+	}{
+		{mqttConnectV311, MQTTConnect{
+			Protocol:    "MQTT",
+			Level:       4,
+			KeepAlive:   60,
+			ClientID:    "mosq-thermostat-07",
+			HasUsername: true,
+			Username:    "iot",
+			HasPassword: true,
+			Password:    "s3cr3t!",
+		}},
+		{mqttConnectV5, MQTTConnect{
+			Protocol:    "MQTT",
+			Level:       5,
+			KeepAlive:   60,
+			ClientID:    "sensor-1",
+			WillTopic:   "status",
+			HasUsername: true,
+			Username:    "admin",
+			HasPassword: true,
+			Password:    "hunter2",
+		}},
+	}
+
+	for _, u := range units {
+		if !MQTTIsConnect(u.data) {
+			t.Fatalf("expected '%x' to be detected as CONNECT", u.data)
+		}
+
+		pkts, err := MQTTParse(u.data)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if len(pkts) != 1 {
+			t.Fatalf("expected 1 packet, got %d", len(pkts))
+		}
+
+		got, err := pkts[0].Connect()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if !reflect.DeepEqual(got, u.exp) {
+			t.Fatalf("expected '%+v', got '%+v'", u.exp, got)
+		}
+	}
+}
+
+func TestMQTTPublish(t *testing.T) {
+	if MQTTIsConnect(mqttPublish) {
+		t.Fatal("expected PUBLISH not to be detected as CONNECT")
+	}
+
+	pkts, err := MQTTParse(mqttPublish)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(pkts) != 2 {
+		t.Fatalf("expected 2 packets, got %d", len(pkts))
+	} else if pkts[1].Type != MQTTDisconnectType {
+		t.Fatalf("expected DISCONNECT, got type %d", pkts[1].Type)
+	} else if _, err := pkts[0].Connect(); err == nil {
+		t.Fatal("expected error decoding PUBLISH as CONNECT")
+	}
+
+	pub, err := pkts[0].Publish()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exp := MQTTPublish{
+		Topic:    "home/temp",
+		QoS:      1,
+		Retain:   true,
+		PacketID: 10,
+		Payload:  []byte("21.5"),
+	}
+	if !reflect.DeepEqual(pub, exp) {
+		t.Fatalf("expected '%+v', got '%+v'", exp, pub)
+	}
+}
+
+func TestMQTTParseMalformed(t *testing.T) {
+	var units = [][]byte{
+		{0x10},
+		{0x00, 0x00},
+		{0x10, 0xff, 0xff, 0xff, 0xff, 0x01},
+		mqttConnectV311[:len(mqttConnectV311)-1],
+		[]byte("GET / HTTP/1.1\r\n\r\n"),
+	}
+
+	for _, u := range units {
+		if _, err := MQTTParse(u); err == nil {
+			t.Fatalf("expected error for '%x'", u)
+		}
+	}
+
+	// valid framing, invalid body
+	bad := MQTTPacket{Type: MQTTConnectType, Body: []byte{0x00, 0x04, 'H', 'T', 'T', 'P', 0x04}}
+	if _, err := bad.Connect(); err == nil {
+		t.Fatal("expected error for unknown protocol name")
+	}
+}
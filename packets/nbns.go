@@ -0,0 +1,115 @@
+package packets
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+)
+
+const (
+	NBNSPort = 137
+
+	NBNSTypeNB  = 0x0020
+	NBNSClassIN = 0x0001
+
+	// workstation and file server name suffixes
+	NBNSSuffixWorkstation = 0x00
+	NBNSSuffixServer      = 0x20
+
+	nbnsHeaderSize  = 12
+	nbnsEncodedSize = 34
+	nbnsResponse    = 0x8000
+	nbnsOpCodeMask  = 0x7800
+	nbnsBroadcast   = 0x0010
+)
+
+// NBNSQuery is a NetBIOS name service query for a single name.
+type NBNSQuery struct {
+	ID        uint16
+	Name      string
+	Suffix    byte
+	Broadcast bool
+}
+
+// NBNSEncodeName returns the first level encoding of a NetBIOS name,
+// length byte and root label included.
+func NBNSEncodeName(name string, suffix byte) []byte {
+	padded := fmt.Sprintf("%-15s", strings.ToUpper(name))
+	if len(padded) > 15 {
+		padded = padded[:15]
+	}
+	raw := append([]byte(padded), suffix)
+
+	encoded := []byte{0x20}
+	for _, c := range raw {
+		encoded = append(encoded, 'A'+(c>>4), 'A'+(c&0x0f))
+	}
+	return append(encoded, 0x00)
+}
+
+// NBNSDecodeName returns the name and the suffix of an encoded NetBIOS
+// name.
+func NBNSDecodeName(encoded []byte) (string, byte, error) {
+	if len(encoded) < nbnsEncodedSize || encoded[0] != 0x20 || encoded[nbnsEncodedSize-1] != 0x00 {
+		return "", 0, fmt.Errorf("Malformed NetBIOS name.")
+	}
+
+	raw := make([]byte, 16)
+	for i := range raw {
+		hi, lo := encoded[1+i*2]-'A', encoded[2+i*2]-'A'
+		if hi > 0x0f || lo > 0x0f {
+			return "", 0, fmt.Errorf("Malformed NetBIOS name.")
+		}
+		raw[i] = hi<<4 | lo
+	}
+	return strings.TrimRight(string(raw[:15]), " "), raw[15], nil
+}
+
+// ParseNBNSQuery parses a name query request, the other requests and the
+// responses are errors.
+func ParseNBNSQuery(data []byte) (*NBNSQuery, error) {
+	if len(data) < nbnsHeaderSize+nbnsEncodedSize+4 {
+		return nil, fmt.Errorf("NBNS packet too short.")
+	}
+
+	flags := binary.BigEndian.Uint16(data[2:4])
+	if flags&nbnsResponse != 0 || flags&nbnsOpCodeMask != 0 {
+		return nil, fmt.Errorf("Not a NBNS name query.")
+	} else if qd := binary.BigEndian.Uint16(data[4:6]); qd != 1 {
+		return nil, fmt.Errorf("Unexpected number of NBNS questions: %d.", qd)
+	}
+
+	name, suffix, err := NBNSDecodeName(data[nbnsHeaderSize:])
+	if err != nil {
+		return nil, err
+	}
+
+	qtype := binary.BigEndian.Uint16(data[nbnsHeaderSize+nbnsEncodedSize:])
+	if qtype != NBNSTypeNB {
+		return nil, fmt.Errorf("Unexpected NBNS question type 0x%04x.", qtype)
+	}
+
+	return &NBNSQuery{
+		ID:        binary.BigEndian.Uint16(data[0:2]),
+		Name:      name,
+		Suffix:    suffix,
+		Broadcast: flags&nbnsBroadcast != 0,
+	}, nil
+}
+
+// NewNBNSResponse returns a positive name query response mapping the
+// name of the query to address.
+func NewNBNSResponse(q *NBNSQuery, address net.IP, ttl uint32) []byte {
+	buf := &bytes.Buffer{}
+	// response, authoritative answer, recursion desired
+	binary.Write(buf, binary.BigEndian, []uint16{q.ID, 0x8500, 0, 1, 0, 0})
+	buf.Write(NBNSEncodeName(q.Name, q.Suffix))
+	binary.Write(buf, binary.BigEndian, []uint16{NBNSTypeNB, NBNSClassIN})
+	binary.Write(buf, binary.BigEndian, ttl)
+	// rdlength, then the flags of a unique B-node name
+	binary.Write(buf, binary.BigEndian, []uint16{6, 0})
+	buf.Write(address.To4())
+	return buf.Bytes()
+}
@@ -0,0 +1,94 @@
+package packets
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+)
+
+func nbnsQuery(id uint16, flags uint16, name string, suffix byte) []byte {
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.BigEndian, []uint16{id, flags, 1, 0, 0, 0})
+	buf.Write(NBNSEncodeName(name, suffix))
+	binary.Write(buf, binary.BigEndian, []uint16{NBNSTypeNB, NBNSClassIN})
+	return buf.Bytes()
+}
+
+func TestNBNSEncodeName(t *testing.T) {
+	encoded := NBNSEncodeName("wpad", NBNSSuffixWorkstation)
+	exp := append(append([]byte{0x20}, []byte("FHFAEBEECACACACACACACACACACACAAA")...), 0x00)
+	if !bytes.Equal(encoded, exp) {
+		t.Fatalf("expected '%s', got '%s'", exp, encoded)
+	}
+
+	var units = []struct {
+		name   string
+		suffix byte
+		exp    string
+	}{
+		{"wpad", NBNSSuffixWorkstation, "WPAD"},
+		{"FILESRV", NBNSSuffixServer, "FILESRV"},
+		{"averyveryverylongname", NBNSSuffixServer, "AVERYVERYVERYLO"},
+	}
+
+	for _, u := range units {
+		name, suffix, err := NBNSDecodeName(NBNSEncodeName(u.name, u.suffix))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if name != u.exp {
+			t.Fatalf("expected '%s', got '%s'", u.exp, name)
+		} else if suffix != u.suffix {
+			t.Fatalf("expected suffix 0x%02x, got 0x%02x", u.suffix, suffix)
+		}
+	}
+
+	if _, _, err := NBNSDecodeName([]byte{0x20, 'Z'}); err == nil {
+		t.Fatalf("expected error for a truncated name")
+	}
+}
+
+func TestParseNBNSQuery(t *testing.T) {
+	q, err := ParseNBNSQuery(nbnsQuery(0xbeef, 0x0110, "fileserver", NBNSSuffixServer))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if q.ID != 0xbeef {
+		t.Fatalf("expected id 0xbeef, got 0x%04x", q.ID)
+	} else if q.Name != "FILESERVER" {
+		t.Fatalf("expected 'FILESERVER', got '%s'", q.Name)
+	} else if q.Suffix != NBNSSuffixServer {
+		t.Fatalf("expected suffix 0x20, got 0x%02x", q.Suffix)
+	} else if !q.Broadcast {
+		t.Fatalf("expected a broadcast query")
+	}
+
+	// a response and a registration
+	for _, flags := range []uint16{0x8500, 0x2910} {
+		if _, err := ParseNBNSQuery(nbnsQuery(1, flags, "wpad", NBNSSuffixWorkstation)); err == nil {
+			t.Fatalf("expected error for flags 0x%04x", flags)
+		}
+	}
+
+	if _, err := ParseNBNSQuery([]byte{0x00, 0x01}); err == nil {
+		t.Fatalf("expected error for a truncated packet")
+	}
+}
+
+func TestNewNBNSResponse(t *testing.T) {
+	q := &NBNSQuery{ID: 0x1234, Name: "WPAD", Suffix: NBNSSuffixWorkstation}
+	resp := NewNBNSResponse(q, net.ParseIP("192.168.1.2"), 30)
+
+	if size := nbnsHeaderSize + nbnsEncodedSize + 4 + 4 + 2 + 6; len(resp) != size {
+		t.Fatalf("expected %d bytes, got %d", size, len(resp))
+	} else if id := binary.BigEndian.Uint16(resp[0:]); id != 0x1234 {
+		t.Fatalf("expected id 0x1234, got 0x%04x", id)
+	} else if flags := binary.BigEndian.Uint16(resp[2:]); flags&nbnsResponse == 0 {
+		t.Fatalf("expected a response, got flags 0x%04x", flags)
+	} else if an := binary.BigEndian.Uint16(resp[6:]); an != 1 {
+		t.Fatalf("expected 1 answer, got %d", an)
+	} else if name, _, _ := NBNSDecodeName(resp[nbnsHeaderSize:]); name != "WPAD" {
+		t.Fatalf("expected 'WPAD', got '%s'", name)
+	} else if ip := net.IP(resp[len(resp)-4:]); ip.String() != "192.168.1.2" {
+		t.Fatalf("expected '192.168.1.2', got '%s'", ip)
+	}
+}
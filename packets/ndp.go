@@ -0,0 +1,135 @@
+package packets
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+const (
+	// NDP messages are only accepted with the maximum hop limit,
+	// which proves they come from the link (RFC 4861)
+	NDPHopLimit = 255
+
+	NDPFlagRouter    = 0x80
+	NDPFlagSolicited = 0x40
+	NDPFlagOverride  = 0x20
+
+	ndpOptSourceLinkAddress = 1
+	ndpOptTargetLinkAddress = 2
+	ndpOptPrefixInformation = 3
+
+	ndpPrefixOnLink     = 0x80
+	ndpPrefixAutonomous = 0x40
+)
+
+var (
+	IPv6AllNodes    = net.ParseIP("ff02::1")
+	IPv6AllNodesMAC = net.HardwareAddr{0x33, 0x33, 0x00, 0x00, 0x00, 0x01}
+)
+
+// IPv6MulticastMAC returns the ethernet address of an IPv6 multicast
+// group (RFC 2464).
+func IPv6MulticastMAC(ip net.IP) net.HardwareAddr {
+	ip = ip.To16()
+	return net.HardwareAddr{0x33, 0x33, ip[12], ip[13], ip[14], ip[15]}
+}
+
+// IPv6LinkLocal returns the link-local address derived from the
+// hardware address with the modified EUI-64 format (RFC 4291).
+func IPv6LinkLocal(hw net.HardwareAddr) net.IP {
+	ip := make(net.IP, net.IPv6len)
+	ip[0], ip[1] = 0xfe, 0x80
+	ip[8] = hw[0] ^ 0x02
+	ip[9], ip[10] = hw[1], hw[2]
+	ip[11], ip[12] = 0xff, 0xfe
+	ip[13], ip[14], ip[15] = hw[3], hw[4], hw[5]
+	return ip
+}
+
+// ndpLinkAddress encodes a source or target link-layer address option.
+func ndpLinkAddress(opt byte, hw net.HardwareAddr) []byte {
+	return append([]byte{opt, 1}, hw...)
+}
+
+func newNDP(from net.IP, fromHW net.HardwareAddr, to net.IP, toHW net.HardwareAddr, what uint8, typeBytes []byte, body []byte) (error, []byte) {
+	if from.To4() != nil || from.To16() == nil {
+		return fmt.Errorf("%s is not a valid IPv6 address.", from), nil
+	} else if to.To4() != nil || to.To16() == nil {
+		return fmt.Errorf("%s is not a valid IPv6 address.", to), nil
+	}
+
+	eth := layers.Ethernet{
+		SrcMAC:       fromHW,
+		DstMAC:       toHW,
+		EthernetType: layers.EthernetTypeIPv6,
+	}
+
+	ip6 := layers.IPv6{
+		Version:    6,
+		NextHeader: layers.IPProtocolICMPv6,
+		HopLimit:   NDPHopLimit,
+		SrcIP:      from,
+		DstIP:      to,
+	}
+
+	icmp6 := layers.ICMPv6{
+		TypeCode:  layers.CreateICMPv6TypeCode(what, 0),
+		TypeBytes: typeBytes,
+	}
+	icmp6.SetNetworkLayerForChecksum(&ip6)
+
+	return Serialize(&eth, &ip6, &icmp6, gopacket.Payload(body))
+}
+
+// NewNDPNeighborAdvertisement creates a neighbor advertisement sent to
+// the address to, telling that target is at the hardware address fromHW,
+// router has to be set if target is a router or the hosts will stop
+// using it as such.
+func NewNDPNeighborAdvertisement(from net.IP, fromHW net.HardwareAddr, to net.IP, toHW net.HardwareAddr, target net.IP, router bool) (error, []byte) {
+	flags := byte(NDPFlagOverride)
+	if router {
+		flags |= NDPFlagRouter
+	}
+	// unicast advertisements pass as replies to a solicitation
+	if !to.IsMulticast() {
+		flags |= NDPFlagSolicited
+	}
+
+	body := append([]byte(nil), target.To16()...)
+	body = append(body, ndpLinkAddress(ndpOptTargetLinkAddress, fromHW)...)
+
+	return newNDP(from, fromHW, to, toHW, layers.ICMPv6TypeNeighborAdvertisement, []byte{flags, 0, 0, 0}, body)
+}
+
+// NewNDPRouterAdvertisement creates a router advertisement to every node
+// of the link for our address, announcing the prefix for the hosts to
+// configure an address from with SLAAC, a lifetime of 0 withdraws both.
+func NewNDPRouterAdvertisement(from net.IP, fromHW net.HardwareAddr, prefix *net.IPNet, lifetime uint16) (error, []byte) {
+	// current hop limit, no managed nor other flags, the router lifetime
+	// and no reachable time nor retransmission timer
+	typeBytes := []byte{64, 0, 0, 0}
+	binary.BigEndian.PutUint16(typeBytes[2:], lifetime)
+	body := make([]byte, 8)
+
+	body = append(body, ndpLinkAddress(ndpOptSourceLinkAddress, fromHW)...)
+
+	if prefix != nil {
+		bits, _ := prefix.Mask.Size()
+		validity := uint32(lifetime)
+
+		opt := make([]byte, 32)
+		opt[0], opt[1] = ndpOptPrefixInformation, 4
+		opt[2] = byte(bits)
+		opt[3] = ndpPrefixOnLink | ndpPrefixAutonomous
+		binary.BigEndian.PutUint32(opt[4:], validity)
+		binary.BigEndian.PutUint32(opt[8:], validity)
+		copy(opt[16:], prefix.IP.To16())
+		body = append(body, opt...)
+	}
+
+	return newNDP(from, fromHW, IPv6AllNodes, IPv6AllNodesMAC, layers.ICMPv6TypeRouterAdvertisement, typeBytes, body)
+}
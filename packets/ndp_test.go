@@ -0,0 +1,103 @@
+package packets
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func decodeNDP(t *testing.T, raw []byte) (*layers.IPv6, *layers.ICMPv6) {
+	pkt := gopacket.NewPacket(raw, layers.LayerTypeEthernet, gopacket.Default)
+	ip6, ok := pkt.Layer(layers.LayerTypeIPv6).(*layers.IPv6)
+	if !ok {
+		t.Fatalf("expected an IPv6 packet")
+	}
+	icmp6, ok := pkt.Layer(layers.LayerTypeICMPv6).(*layers.ICMPv6)
+	if !ok {
+		t.Fatalf("expected an ICMPv6 packet")
+	}
+	return ip6, icmp6
+}
+
+func TestIPv6LinkLocal(t *testing.T) {
+	hw, _ := net.ParseMAC("00:11:22:33:44:55")
+	if ip := IPv6LinkLocal(hw).String(); ip != "fe80::211:22ff:fe33:4455" {
+		t.Fatalf("expected 'fe80::211:22ff:fe33:4455', got '%s'", ip)
+	}
+
+	if mac := IPv6MulticastMAC(net.ParseIP("ff02::1:ff33:4455")).String(); mac != "33:33:ff:33:44:55" {
+		t.Fatalf("expected '33:33:ff:33:44:55', got '%s'", mac)
+	}
+}
+
+func TestNewNDPNeighborAdvertisement(t *testing.T) {
+	hw, _ := net.ParseMAC("00:11:22:33:44:55")
+	toHW, _ := net.ParseMAC("66:77:88:99:aa:bb")
+	from := net.ParseIP("fe80::1")
+	to := net.ParseIP("fe80::2")
+
+	err, raw := NewNDPNeighborAdvertisement(from, hw, to, toHW, from, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ip6, icmp6 := decodeNDP(t, raw)
+	if ip6.HopLimit != NDPHopLimit {
+		t.Fatalf("expected hop limit %d, got %d", NDPHopLimit, ip6.HopLimit)
+	} else if icmp6.TypeCode.Type() != layers.ICMPv6TypeNeighborAdvertisement {
+		t.Fatalf("unexpected type %s", icmp6.TypeCode)
+	} else if flags := icmp6.TypeBytes[0]; flags != NDPFlagRouter|NDPFlagSolicited|NDPFlagOverride {
+		t.Fatalf("unexpected flags %x", flags)
+	} else if !net.IP(icmp6.Payload[:16]).Equal(from) {
+		t.Fatalf("expected target '%s', got '%s'", from, net.IP(icmp6.Payload[:16]))
+	} else if !bytes.Equal(icmp6.Payload[16:], append([]byte{ndpOptTargetLinkAddress, 1}, hw...)) {
+		t.Fatalf("unexpected option %x", icmp6.Payload[16:])
+	}
+
+	// multicast ones are unsolicited
+	_, raw = NewNDPNeighborAdvertisement(from, hw, IPv6AllNodes, IPv6AllNodesMAC, from, false)
+	if _, icmp6 = decodeNDP(t, raw); icmp6.TypeBytes[0] != NDPFlagOverride {
+		t.Fatalf("unexpected flags %x", icmp6.TypeBytes[0])
+	}
+
+	if err, _ := NewNDPNeighborAdvertisement(net.ParseIP("10.0.0.1"), hw, to, toHW, from, false); err == nil {
+		t.Fatalf("expected error for an IPv4 source")
+	}
+}
+
+func TestNewNDPRouterAdvertisement(t *testing.T) {
+	hw, _ := net.ParseMAC("00:11:22:33:44:55")
+	_, prefix, _ := net.ParseCIDR("d00d::/64")
+
+	err, raw := NewNDPRouterAdvertisement(IPv6LinkLocal(hw), hw, prefix, 1800)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ip6, icmp6 := decodeNDP(t, raw)
+	if !ip6.DstIP.Equal(IPv6AllNodes) {
+		t.Fatalf("expected '%s', got '%s'", IPv6AllNodes, ip6.DstIP)
+	} else if icmp6.TypeCode.Type() != layers.ICMPv6TypeRouterAdvertisement {
+		t.Fatalf("unexpected type %s", icmp6.TypeCode)
+	} else if lifetime := int(icmp6.TypeBytes[2])<<8 | int(icmp6.TypeBytes[3]); lifetime != 1800 {
+		t.Fatalf("expected lifetime 1800, got %d", lifetime)
+	}
+
+	// reachable time and retransmission timer, then the options
+	opts := icmp6.Payload[8:]
+	if len(opts) != 8+32 {
+		t.Fatalf("expected 40 bytes of options, got %d", len(opts))
+	} else if !bytes.Equal(opts[:8], append([]byte{ndpOptSourceLinkAddress, 1}, hw...)) {
+		t.Fatalf("unexpected option %x", opts[:8])
+	}
+
+	info := opts[8:]
+	if info[0] != ndpOptPrefixInformation || info[2] != 64 || info[3] != ndpPrefixOnLink|ndpPrefixAutonomous {
+		t.Fatalf("unexpected prefix information %x", info)
+	} else if !net.IP(info[16:]).Equal(prefix.IP) {
+		t.Fatalf("expected '%s', got '%s'", prefix.IP, net.IP(info[16:]))
+	}
+}
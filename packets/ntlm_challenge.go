@@ -0,0 +1,107 @@
+package packets
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	NTLMNegotiate    = 1
+	NTLMChallenge    = 2
+	NTLMAuthenticate = 3
+
+	// unicode, request target, sign, NTLM, always sign, domain target,
+	// extended session security, target info, version, 128, key exchange
+	// and 56 bits, what Windows servers offer
+	NTLMChallengeFlags = 0xe2898215
+
+	ntlmAvEOL             = 0
+	ntlmAvNbComputerName  = 1
+	ntlmAvNbDomainName    = 2
+	ntlmAvDnsComputerName = 3
+	ntlmAvDnsDomainName   = 4
+)
+
+var ntlmSignature = []byte("NTLMSSP\x00")
+
+// Windows 10.0 build 19041, NTLM revision 15
+var ntlmVersion = []byte{0x0a, 0x00, 0x61, 0x4a, 0x00, 0x00, 0x00, 0x0f}
+
+func ntlmUnicode(s string) []byte {
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.LittleEndian, utf16.Encode([]rune(s)))
+	return buf.Bytes()
+}
+
+func ntlmAvPair(id uint16, value []byte) []byte {
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.LittleEndian, []uint16{id, uint16(len(value))})
+	buf.Write(value)
+	return buf.Bytes()
+}
+
+// NTLMMessageType returns the type of an NTLMSSP message, or 0 if data
+// is not one.
+func NTLMMessageType(data []byte) int {
+	if len(data) < 12 || !bytes.HasPrefix(data, ntlmSignature) {
+		return 0
+	}
+	return int(binary.LittleEndian.Uint32(data[NTLM_TYPE_OFFSET:]))
+}
+
+// NewNTLMChallenge returns the CHALLENGE message of a server of the
+// given NetBIOS domain and computer name.
+func NewNTLMChallenge(challenge []byte, domain string, computer string) []byte {
+	target := ntlmUnicode(strings.ToUpper(domain))
+	info := bytes.Join([][]byte{
+		ntlmAvPair(ntlmAvNbDomainName, ntlmUnicode(strings.ToUpper(domain))),
+		ntlmAvPair(ntlmAvNbComputerName, ntlmUnicode(strings.ToUpper(computer))),
+		ntlmAvPair(ntlmAvDnsDomainName, ntlmUnicode(strings.ToLower(domain))),
+		ntlmAvPair(ntlmAvDnsComputerName, ntlmUnicode(strings.ToLower(computer))),
+		ntlmAvPair(ntlmAvEOL, nil),
+	}, nil)
+
+	// the payload follows the header and the version
+	offset := uint32(NTLM_TYPE2_DATA_OFFSET + len(ntlmVersion))
+
+	buf := &bytes.Buffer{}
+	buf.Write(ntlmSignature)
+	binary.Write(buf, binary.LittleEndian, uint32(NTLMChallenge))
+	binary.Write(buf, binary.LittleEndian, []uint16{uint16(len(target)), uint16(len(target))})
+	binary.Write(buf, binary.LittleEndian, offset)
+	binary.Write(buf, binary.LittleEndian, uint32(NTLMChallengeFlags))
+	buf.Write(challenge[:8])
+	buf.Write(make([]byte, 8))
+	binary.Write(buf, binary.LittleEndian, []uint16{uint16(len(info)), uint16(len(info))})
+	binary.Write(buf, binary.LittleEndian, offset+uint32(len(target)))
+	buf.Write(ntlmVersion)
+	buf.Write(target)
+	buf.Write(info)
+	return buf.Bytes()
+}
+
+// CheckNTLMAuthenticate makes sure that an AUTHENTICATE message can be
+// parsed, its security buffers within the message.
+func CheckNTLMAuthenticate(data []byte) error {
+	if NTLMMessageType(data) != NTLMAuthenticate {
+		return errors.New("Not an NTLM AUTHENTICATE message.")
+	} else if len(data) < NTLM_TYPE3_DATA_OFFSET {
+		return errors.New("NTLM AUTHENTICATE message too short.")
+	}
+
+	for _, at := range []int{NTLM_TYPE3_LMRESP_OFFSET, NTLM_TYPE3_NTRESP_OFFSET, NTLM_TYPE3_DOMAIN_OFFSET, NTLM_TYPE3_USER_OFFSET, NTLM_TYPE3_WORKSTN_OFFSET} {
+		size := int(binary.LittleEndian.Uint16(data[at+NTLM_BUFFER_LEN_OFFSET:]))
+		offset := int(binary.LittleEndian.Uint32(data[at+NTLM_BUFFER_OFFSET_OFFSET:]))
+		if offset+size > len(data) || offset > 0xffff {
+			return errors.New("Malformed NTLM AUTHENTICATE message.")
+		}
+	}
+
+	if size := binary.LittleEndian.Uint16(data[NTLM_TYPE3_NTRESP_OFFSET:]); size < 24 {
+		return errors.New("NTLM AUTHENTICATE message without a NT response.")
+	}
+	return nil
+}
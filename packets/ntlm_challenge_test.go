@@ -0,0 +1,93 @@
+package packets
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// ntlmAuthenticateMessage returns an AUTHENTICATE message with the given
+// credentials and NT response.
+func ntlmAuthenticateMessage(domain string, user string, nt []byte) []byte {
+	lm := make([]byte, 24)
+	fields := [][]byte{lm, nt, ntlmUnicode(domain), ntlmUnicode(user), ntlmUnicode("WKS")}
+
+	header := &bytes.Buffer{}
+	header.Write(ntlmSignature)
+	binary.Write(header, binary.LittleEndian, uint32(NTLMAuthenticate))
+
+	payload := &bytes.Buffer{}
+	offset := NTLM_TYPE3_DATA_OFFSET
+	for _, field := range fields {
+		binary.Write(header, binary.LittleEndian, []uint16{uint16(len(field)), uint16(len(field))})
+		binary.Write(header, binary.LittleEndian, uint32(offset+payload.Len()))
+		payload.Write(field)
+	}
+	// session key and flags
+	header.Write(make([]byte, NTLM_TYPE3_DATA_OFFSET-header.Len()))
+
+	return append(header.Bytes(), payload.Bytes()...)
+}
+
+func TestNewNTLMChallenge(t *testing.T) {
+	challenge := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	msg := NewNTLMChallenge(challenge, "workgroup", "filesrv")
+
+	if NTLMMessageType(msg) != NTLMChallenge {
+		t.Fatalf("expected a CHALLENGE message, got type %d", NTLMMessageType(msg))
+	} else if got := msg[NTLM_TYPE2_CHALLENGE_OFFSET : NTLM_TYPE2_CHALLENGE_OFFSET+8]; !bytes.Equal(got, challenge) {
+		t.Fatalf("expected challenge %x, got %x", challenge, got)
+	} else if flags := binary.LittleEndian.Uint32(msg[NTLM_TYPE2_FLAGS_OFFSET:]); flags != NTLMChallengeFlags {
+		t.Fatalf("expected flags 0x%08x, got 0x%08x", NTLMChallengeFlags, flags)
+	}
+
+	size := int(binary.LittleEndian.Uint16(msg[NTLM_TYPE2_TARGET_OFFSET:]))
+	offset := int(binary.LittleEndian.Uint32(msg[NTLM_TYPE2_TARGET_OFFSET+4:]))
+	if target := msg[offset : offset+size]; !bytes.Equal(target, ntlmUnicode("WORKGROUP")) {
+		t.Fatalf("expected target 'WORKGROUP', got %x", target)
+	}
+
+	size = int(binary.LittleEndian.Uint16(msg[NTLM_TYPE2_TARGETINFO_OFFSET:]))
+	offset = int(binary.LittleEndian.Uint32(msg[NTLM_TYPE2_TARGETINFO_OFFSET+4:]))
+	if offset+size != len(msg) {
+		t.Fatalf("expected the target info to end the message")
+	} else if info := msg[offset : offset+size]; !bytes.Contains(info, ntlmUnicode("FILESRV")) {
+		t.Fatalf("expected the computer name in the target info")
+	} else if !bytes.HasSuffix(info, []byte{0, 0, 0, 0}) {
+		t.Fatalf("expected the target info to end with MsvAvEOL")
+	}
+}
+
+func TestNTLMMessageType(t *testing.T) {
+	var units = []struct {
+		data []byte
+		exp  int
+	}{
+		{nil, 0},
+		{[]byte("NTLMSSP\x00"), 0},
+		{[]byte("NOTNTLM\x00\x01\x00\x00\x00"), 0},
+		{[]byte("NTLMSSP\x00\x01\x00\x00\x00"), NTLMNegotiate},
+		{ntlmAuthenticateMessage("CORP", "alice", make([]byte, 40)), NTLMAuthenticate},
+	}
+
+	for _, u := range units {
+		if got := NTLMMessageType(u.data); got != u.exp {
+			t.Fatalf("expected %d, got %d", u.exp, got)
+		}
+	}
+}
+
+func TestCheckNTLMAuthenticate(t *testing.T) {
+	valid := ntlmAuthenticateMessage("CORP", "alice", make([]byte, 40))
+	if err := CheckNTLMAuthenticate(valid); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	truncated := valid[:len(valid)-4]
+	noNT := ntlmAuthenticateMessage("CORP", "alice", nil)
+	for _, bad := range [][]byte{nil, valid[:40], truncated, noNT, NewNTLMChallenge(make([]byte, 8), "a", "b")} {
+		if err := CheckNTLMAuthenticate(bad); err == nil {
+			t.Fatalf("expected error for %x", bad)
+		}
+	}
+}
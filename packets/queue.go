@@ -16,6 +16,7 @@ type Activity struct {
 	IP     net.IP
 	MAC    net.HardwareAddr
 	Source bool
+	Size   uint64
 }
 
 type Traffic struct {
@@ -43,12 +44,16 @@ type Queue struct {
 	Protos  map[string]uint64
 	Traffic map[string]*Traffic
 
-	iface      *network.Endpoint
-	handle     *pcap.Handle
+	iface  *network.Endpoint
+	handle *pcap.Handle
+	// if set, packets are sent on this interface instead
+	sendIface  string
+	sendHandle *pcap.Handle
 	source     *gopacket.PacketSource
 	srcChannel chan gopacket.Packet
 	writes     *sync.WaitGroup
 	pktCb      PacketCallback
+	listeners  map[string]PacketCallback
 	active     bool
 }
 
@@ -83,6 +88,23 @@ func (q *Queue) OnPacket(cb PacketCallback) {
 	q.pktCb = cb
 }
 
+// AddPacketListener registers a named callback for every packet read,
+// unlike OnPacket it can be used by more than one module at a time.
+func (q *Queue) AddPacketListener(name string, cb PacketCallback) {
+	q.Lock()
+	defer q.Unlock()
+	if q.listeners == nil {
+		q.listeners = make(map[string]PacketCallback)
+	}
+	q.listeners[name] = cb
+}
+
+func (q *Queue) RemovePacketListener(name string) {
+	q.Lock()
+	defer q.Unlock()
+	delete(q.listeners, name)
+}
+
 func (q *Queue) onPacketCallback(pkt gopacket.Packet) {
 	q.RLock()
 	defer q.RUnlock()
@@ -90,6 +112,10 @@ func (q *Queue) onPacketCallback(pkt gopacket.Packet) {
 	if q.pktCb != nil {
 		q.pktCb(pkt)
 	}
+
+	for _, cb := range q.listeners {
+		cb(pkt)
+	}
 }
 
 func (q *Queue) trackProtocols(pkt gopacket.Packet) {
@@ -114,10 +140,16 @@ func (q *Queue) trackProtocols(pkt gopacket.Packet) {
 
 func (q *Queue) trackActivity(eth *layers.Ethernet, ip4 *layers.IPv4, address net.IP, pktSize uint64, isSent bool) {
 	// push to activity channel
+	mac := eth.SrcMAC
+	if !isSent {
+		mac = eth.DstMAC
+	}
+
 	q.Activities <- Activity{
 		IP:     address,
-		MAC:    eth.SrcMAC,
+		MAC:    mac,
 		Source: isSent,
+		Size:   pktSize,
 	}
 
 	q.Lock()
@@ -203,18 +235,52 @@ func (q *Queue) worker() {
 	}
 }
 
+// SetSendInterface makes Send write packets on the given interface, which
+// also works while capturing in monitor mode, an empty name restores the
+// capture interface.
+func (q *Queue) SetSendInterface(name string) (err error) {
+	q.Lock()
+	defer q.Unlock()
+
+	if q.sendHandle != nil {
+		q.sendHandle.Close()
+		q.sendHandle = nil
+		q.sendIface = ""
+	}
+
+	if name != "" {
+		if q.sendHandle, err = pcap.OpenLive(name, 1024, false, pcap.BlockForever); err != nil {
+			q.sendHandle = nil
+			return fmt.Errorf("Could not open %s to send packets: %s", name, err)
+		}
+		q.sendIface = name
+	}
+
+	return nil
+}
+
+// SendInterface returns the interface set by SetSendInterface, if any.
+func (q *Queue) SendInterface() string {
+	q.RLock()
+	defer q.RUnlock()
+	return q.sendIface
+}
+
 func (q *Queue) Send(raw []byte) error {
 	q.Lock()
 	defer q.Unlock()
 
-	if !q.active {
+	handle := q.handle
+	if q.sendHandle != nil {
+		handle = q.sendHandle
+	} else if !q.active {
 		return fmt.Errorf("Packet queue is not active.")
 	}
 
 	q.writes.Add(1)
 	defer q.writes.Done()
 
-	if err := q.handle.WritePacketData(raw); err != nil {
+	if err := handle.WritePacketData(raw); err != nil {
 		q.TrackError()
 		return err
 	} else {
@@ -236,4 +302,9 @@ func (q *Queue) Stop() {
 		q.srcChannel <- nil
 		q.handle.Close()
 	}
+
+	if q.sendHandle != nil {
+		q.sendHandle.Close()
+		q.sendHandle = nil
+	}
 }
@@ -4,6 +4,8 @@ import (
 	"net"
 	"reflect"
 	"testing"
+
+	"github.com/google/gopacket"
 )
 
 func TestQueueActivity(t *testing.T) {
@@ -63,3 +65,38 @@ func TestQueueStats(t *testing.T) {
 }
 
 // TODO: add tests for the rest of queue.go
+
+func TestQueuePacketListeners(t *testing.T) {
+	q := &Queue{}
+	got := []string{}
+
+	q.OnPacket(func(pkt gopacket.Packet) { got = append(got, "legacy") })
+	q.AddPacketListener("a", func(pkt gopacket.Packet) { got = append(got, "a") })
+	q.AddPacketListener("a", func(pkt gopacket.Packet) { got = append(got, "a2") })
+	q.onPacketCallback(nil)
+
+	if exp := []string{"legacy", "a2"}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected '%v', got '%v'", exp, got)
+	}
+
+	got = []string{}
+	q.OnPacket(nil)
+	q.RemovePacketListener("a")
+	q.onPacketCallback(nil)
+	if len(got) != 0 {
+		t.Fatalf("expected no callbacks, got '%v'", got)
+	}
+}
+
+func TestQueueSendInterface(t *testing.T) {
+	q := &Queue{}
+	if err := q.Send([]byte{0x00}); err == nil {
+		t.Fatal("expected error sending on an inactive queue")
+	} else if err := q.SetSendInterface("nope0"); err == nil {
+		t.Fatal("expected error for an unknown interface")
+	} else if got := q.SendInterface(); got != "" {
+		t.Fatalf("expected no send interface, got '%s'", got)
+	} else if err := q.SetSendInterface(""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package packets
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+const (
+	SMB2HeaderSize = 64
+
+	SMB2Negotiate    = 0x0000
+	SMB2SessionSetup = 0x0001
+
+	SMB2StatusOK                       = 0x00000000
+	SMB2StatusMoreProcessingRequired   = 0xc0000016
+	SMB2StatusAccessDenied             = 0xc0000022
+	SMB2DialectWildcard                = 0x02ff
+	smb2FlagResponse                   = 0x00000001
+	smb2SecurityModeSigningEnabled     = 0x0001
+	smb2NegotiateResponseStructureSize = 65
+
+	// intervals of 100 nanoseconds between 1601 and 1970
+	fileTimeEpoch = 116444736000000000
+)
+
+var (
+	smb1Signature = []byte{0xff, 'S', 'M', 'B'}
+	smb2Signature = []byte{0xfe, 'S', 'M', 'B'}
+
+	spnegoOID = []byte{0x06, 0x06, 0x2b, 0x06, 0x01, 0x05, 0x05, 0x02}
+	ntlmOID   = []byte{0x06, 0x0a, 0x2b, 0x06, 0x01, 0x04, 0x01, 0x82, 0x37, 0x02, 0x02, 0x0a}
+)
+
+// SMB2Header is the header of an SMB2 request.
+type SMB2Header struct {
+	Status    uint32
+	Command   uint16
+	Credits   uint16
+	MessageID uint64
+	SessionID uint64
+}
+
+// IsSMB1 returns true if data is an SMB1 message.
+func IsSMB1(data []byte) bool {
+	return bytes.HasPrefix(data, smb1Signature)
+}
+
+// ParseSMB2Header parses the header of an SMB2 message.
+func ParseSMB2Header(data []byte) (*SMB2Header, error) {
+	if len(data) < SMB2HeaderSize || !bytes.HasPrefix(data, smb2Signature) {
+		return nil, errors.New("Not an SMB2 message.")
+	}
+
+	return &SMB2Header{
+		Status:    binary.LittleEndian.Uint32(data[8:]),
+		Command:   binary.LittleEndian.Uint16(data[12:]),
+		Credits:   binary.LittleEndian.Uint16(data[14:]),
+		MessageID: binary.LittleEndian.Uint64(data[24:]),
+		SessionID: binary.LittleEndian.Uint64(data[40:]),
+	}, nil
+}
+
+// SMB2Dialects returns the dialects offered by an SMB2 NEGOTIATE request.
+func SMB2Dialects(data []byte) ([]uint16, error) {
+	if len(data) < SMB2HeaderSize+36 {
+		return nil, errors.New("SMB2 NEGOTIATE request too short.")
+	}
+
+	body := data[SMB2HeaderSize:]
+	count := int(binary.LittleEndian.Uint16(body[2:]))
+	if len(body) < 36+count*2 {
+		return nil, errors.New("Malformed SMB2 NEGOTIATE request.")
+	}
+
+	dialects := make([]uint16, count)
+	for i := range dialects {
+		dialects[i] = binary.LittleEndian.Uint16(body[36+i*2:])
+	}
+	return dialects, nil
+}
+
+// SMB2SecurityBuffer returns the security buffer of an SMB2 SESSION_SETUP
+// request.
+func SMB2SecurityBuffer(data []byte) ([]byte, error) {
+	if len(data) < SMB2HeaderSize+24 {
+		return nil, errors.New("SMB2 SESSION_SETUP request too short.")
+	}
+
+	body := data[SMB2HeaderSize:]
+	offset := int(binary.LittleEndian.Uint16(body[12:]))
+	size := int(binary.LittleEndian.Uint16(body[14:]))
+	if offset < SMB2HeaderSize || offset+size > len(data) {
+		return nil, errors.New("Malformed SMB2 SESSION_SETUP request.")
+	}
+	return data[offset : offset+size], nil
+}
+
+func smb2Response(req *SMB2Header, command uint16, status uint32, body []byte) []byte {
+	buf := &bytes.Buffer{}
+	buf.Write(smb2Signature)
+	// structure size and credit charge
+	binary.Write(buf, binary.LittleEndian, []uint16{SMB2HeaderSize, 0})
+	binary.Write(buf, binary.LittleEndian, status)
+	binary.Write(buf, binary.LittleEndian, []uint16{command, 1})
+	// flags and next command
+	binary.Write(buf, binary.LittleEndian, []uint32{smb2FlagResponse, 0})
+	binary.Write(buf, binary.LittleEndian, req.MessageID)
+	// process and tree ids
+	binary.Write(buf, binary.LittleEndian, []uint32{0, 0})
+	binary.Write(buf, binary.LittleEndian, req.SessionID)
+	buf.Write(make([]byte, 16))
+	buf.Write(body)
+	return buf.Bytes()
+}
+
+func fileTime(t time.Time) uint64 {
+	return uint64(t.UnixNano()/100) + fileTimeEpoch
+}
+
+// NewSMB2NegotiateResponse returns the NEGOTIATE response selecting
+// dialect and offering NTLM authentication.
+func NewSMB2NegotiateResponse(req *SMB2Header, dialect uint16, guid []byte) []byte {
+	token := NewSPNEGOInit()
+	now := fileTime(time.Now())
+
+	body := &bytes.Buffer{}
+	binary.Write(body, binary.LittleEndian, []uint16{smb2NegotiateResponseStructureSize, smb2SecurityModeSigningEnabled, dialect, 0})
+	body.Write(guid[:16])
+	// capabilities, max transact, read and write sizes
+	binary.Write(body, binary.LittleEndian, []uint32{0, 65536, 65536, 65536})
+	binary.Write(body, binary.LittleEndian, []uint64{now, now})
+	binary.Write(body, binary.LittleEndian, []uint16{SMB2HeaderSize + 64, uint16(len(token))})
+	binary.Write(body, binary.LittleEndian, uint32(0))
+	body.Write(token)
+
+	return smb2Response(req, SMB2Negotiate, SMB2StatusOK, body.Bytes())
+}
+
+// NewSMB2SessionSetupResponse returns the SESSION_SETUP response carrying
+// the security token of the server.
+func NewSMB2SessionSetupResponse(req *SMB2Header, status uint32, token []byte) []byte {
+	body := &bytes.Buffer{}
+	// structure size, session flags, security buffer offset and length
+	binary.Write(body, binary.LittleEndian, []uint16{9, 0, SMB2HeaderSize + 8, uint16(len(token))})
+	body.Write(token)
+	return smb2Response(req, SMB2SessionSetup, status, body.Bytes())
+}
+
+// NewSMB2ErrorResponse returns the error response to the command of req.
+func NewSMB2ErrorResponse(req *SMB2Header, status uint32) []byte {
+	// structure size, error context count, reserved, byte count and data
+	body := []byte{9, 0, 0, 0, 0, 0, 0, 0, 0}
+	return smb2Response(req, req.Command, status, body)
+}
+
+// derWrap returns the DER encoding of the content with the given tag.
+func derWrap(tag byte, content ...[]byte) []byte {
+	data := bytes.Join(content, nil)
+	size := len(data)
+
+	encoded := []byte{tag}
+	switch {
+	case size < 0x80:
+		encoded = append(encoded, byte(size))
+	case size < 0x100:
+		encoded = append(encoded, 0x81, byte(size))
+	default:
+		encoded = append(encoded, 0x82, byte(size>>8), byte(size))
+	}
+	return append(encoded, data...)
+}
+
+// NewSPNEGOInit returns the SPNEGO negTokenInit offering NTLMSSP as the
+// only authentication mechanism.
+func NewSPNEGOInit() []byte {
+	return derWrap(0x60, spnegoOID,
+		derWrap(0xa0,
+			derWrap(0x30,
+				derWrap(0xa0,
+					derWrap(0x30, ntlmOID)))))
+}
+
+// NewSPNEGOChallenge returns the SPNEGO negTokenResp carrying an NTLM
+// CHALLENGE message.
+func NewSPNEGOChallenge(challenge []byte) []byte {
+	return derWrap(0xa1,
+		derWrap(0x30,
+			// accept-incomplete
+			derWrap(0xa0, []byte{0x0a, 0x01, 0x01}),
+			derWrap(0xa1, ntlmOID),
+			derWrap(0xa2, derWrap(0x04, challenge))))
+}
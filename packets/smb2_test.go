@@ -0,0 +1,130 @@
+package packets
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func smb2Request(command uint16, messageID uint64, body []byte) []byte {
+	buf := &bytes.Buffer{}
+	buf.Write(smb2Signature)
+	binary.Write(buf, binary.LittleEndian, []uint16{SMB2HeaderSize, 0, 0, 0, command, 1})
+	binary.Write(buf, binary.LittleEndian, []uint32{0, 0})
+	binary.Write(buf, binary.LittleEndian, messageID)
+	buf.Write(make([]byte, 32))
+	buf.Write(body)
+	return buf.Bytes()
+}
+
+func TestParseSMB2Header(t *testing.T) {
+	h, err := ParseSMB2Header(smb2Request(SMB2SessionSetup, 3, nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if h.Command != SMB2SessionSetup {
+		t.Fatalf("expected command %d, got %d", SMB2SessionSetup, h.Command)
+	} else if h.MessageID != 3 {
+		t.Fatalf("expected message id 3, got %d", h.MessageID)
+	}
+
+	if _, err := ParseSMB2Header([]byte{0xff, 'S', 'M', 'B'}); err == nil {
+		t.Fatalf("expected error for an SMB1 message")
+	}
+}
+
+func TestSMB2Dialects(t *testing.T) {
+	body := make([]byte, 36)
+	binary.LittleEndian.PutUint16(body[0:], 36)
+	binary.LittleEndian.PutUint16(body[2:], 3)
+	for _, dialect := range []uint16{0x0202, 0x0210, 0x0311} {
+		body = append(body, byte(dialect), byte(dialect>>8))
+	}
+
+	dialects, err := SMB2Dialects(smb2Request(SMB2Negotiate, 0, body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(dialects) != 3 || dialects[0] != 0x0202 || dialects[2] != 0x0311 {
+		t.Fatalf("unexpected dialects %x", dialects)
+	}
+
+	if _, err := SMB2Dialects(smb2Request(SMB2Negotiate, 0, body[:38])); err == nil {
+		t.Fatalf("expected error for a truncated request")
+	}
+}
+
+func TestSMB2SecurityBuffer(t *testing.T) {
+	body := make([]byte, 24)
+	binary.LittleEndian.PutUint16(body[12:], SMB2HeaderSize+24)
+	binary.LittleEndian.PutUint16(body[14:], 4)
+	body = append(body, []byte("NTLM")...)
+
+	buffer, err := SMB2SecurityBuffer(smb2Request(SMB2SessionSetup, 1, body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if string(buffer) != "NTLM" {
+		t.Fatalf("expected 'NTLM', got '%s'", buffer)
+	}
+
+	binary.LittleEndian.PutUint16(body[14:], 40)
+	if _, err := SMB2SecurityBuffer(smb2Request(SMB2SessionSetup, 1, body)); err == nil {
+		t.Fatalf("expected error for an out of bounds buffer")
+	}
+}
+
+func TestNewSMB2NegotiateResponse(t *testing.T) {
+	req := &SMB2Header{MessageID: 7}
+	resp := NewSMB2NegotiateResponse(req, 0x0210, make([]byte, 16))
+
+	h, err := ParseSMB2Header(resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if h.MessageID != 7 || h.Command != SMB2Negotiate || h.Status != SMB2StatusOK {
+		t.Fatalf("unexpected header %+v", h)
+	}
+
+	body := resp[SMB2HeaderSize:]
+	offset := int(binary.LittleEndian.Uint16(body[56:]))
+	size := int(binary.LittleEndian.Uint16(body[58:]))
+	if dialect := binary.LittleEndian.Uint16(body[4:]); dialect != 0x0210 {
+		t.Fatalf("expected dialect 0x0210, got 0x%04x", dialect)
+	} else if offset+size != len(resp) {
+		t.Fatalf("expected the security buffer to end the message")
+	} else if token := resp[offset:]; !bytes.Equal(token, NewSPNEGOInit()) {
+		t.Fatalf("unexpected security buffer %x", token)
+	}
+}
+
+func TestNewSMB2SessionSetupResponse(t *testing.T) {
+	req := &SMB2Header{MessageID: 2, SessionID: 42}
+	resp := NewSMB2SessionSetupResponse(req, SMB2StatusMoreProcessingRequired, []byte("token"))
+
+	h, err := ParseSMB2Header(resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if h.Status != SMB2StatusMoreProcessingRequired || h.SessionID != 42 {
+		t.Fatalf("unexpected header %+v", h)
+	}
+
+	offset := int(binary.LittleEndian.Uint16(resp[SMB2HeaderSize+4:]))
+	if token := string(resp[offset:]); token != "token" {
+		t.Fatalf("expected 'token', got '%s'", token)
+	}
+}
+
+func TestSPNEGO(t *testing.T) {
+	init := NewSPNEGOInit()
+	if init[0] != 0x60 || int(init[1]) != len(init)-2 {
+		t.Fatalf("unexpected negTokenInit %x", init)
+	} else if !bytes.Contains(init, ntlmOID) {
+		t.Fatalf("expected the NTLMSSP mechanism in %x", init)
+	}
+
+	// long enough to need a two bytes length
+	challenge := NewNTLMChallenge(make([]byte, 8), "WORKGROUP", "FILESRV")
+	resp := NewSPNEGOChallenge(challenge)
+	if resp[0] != 0xa1 || resp[1] != 0x81 || int(resp[2]) != len(resp)-3 {
+		t.Fatalf("unexpected negTokenResp %x", resp)
+	} else if !bytes.HasSuffix(resp, challenge) {
+		t.Fatalf("expected the CHALLENGE message at the end of the negTokenResp")
+	}
+}
@@ -0,0 +1,281 @@
+package packets
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	TLSHandshakeRecord = 0x16
+	TLSClientHelloType = 1
+	TLSServerHelloType = 2
+
+	tlsServerNameExtension   = 0x0000
+	tlsSupportedGroups       = 0x000a
+	tlsECPointFormats        = 0x000b
+	tlsServerNameHostType    = 0
+	tlsRecordHeaderLength    = 5
+	tlsHandshakeHeaderLength = 4
+	tlsRandomLength          = 32
+)
+
+// TLSHello is a client or server hello with the fields used by the JA3
+// and JA3S fingerprints, a server hello has a single cipher suite and no
+// groups nor point formats.
+type TLSHello struct {
+	Type         byte
+	Version      uint16
+	Ciphers      []uint16
+	Extensions   []uint16
+	Groups       []uint16
+	PointFormats []uint8
+	ServerName   string
+}
+
+type tlsReader struct {
+	buf  []byte
+	what string
+}
+
+func (r *tlsReader) need(n int) error {
+	if n > len(r.buf) {
+		return fmt.Errorf("Malformed TLS hello, could not parse %s: needed %d bytes but only %d are available.", r.what, n, len(r.buf))
+	}
+	return nil
+}
+
+func (r *tlsReader) bytes(n int) ([]byte, error) {
+	if err := r.need(n); err != nil {
+		return nil, err
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b, nil
+}
+
+func (r *tlsReader) uint8() (uint8, error) {
+	b, err := r.bytes(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (r *tlsReader) uint16() (uint16, error) {
+	b, err := r.bytes(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(b), nil
+}
+
+// vector reads a block prefixed by its length on size bytes.
+func (r *tlsReader) vector(size int, what string) (*tlsReader, error) {
+	r.what = what + " length"
+	raw, err := r.bytes(size)
+	if err != nil {
+		return nil, err
+	}
+
+	n := 0
+	for _, b := range raw {
+		n = n<<8 | int(b)
+	}
+
+	r.what = what
+	data, err := r.bytes(n)
+	if err != nil {
+		return nil, err
+	}
+	return &tlsReader{buf: data, what: what}, nil
+}
+
+func (r *tlsReader) uint16s() ([]uint16, error) {
+	if len(r.buf)%2 != 0 {
+		return nil, fmt.Errorf("Malformed TLS hello, %s has an odd length.", r.what)
+	}
+	list := make([]uint16, 0, len(r.buf)/2)
+	for len(r.buf) > 0 {
+		v, _ := r.uint16()
+		list = append(list, v)
+	}
+	return list, nil
+}
+
+// IsTLSGREASE is true for the reserved values clients advertise to keep
+// the servers tolerant to unknown ones (RFC 8701).
+func IsTLSGREASE(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
+
+// ParseTLSHello parses the client or server hello at the beginning of a
+// TLS record, the hello has to be in a single record.
+func ParseTLSHello(data []byte) (*TLSHello, error) {
+	r := &tlsReader{buf: data, what: "record header"}
+	if err := r.need(tlsRecordHeaderLength); err != nil {
+		return nil, err
+	} else if data[0] != TLSHandshakeRecord {
+		return nil, fmt.Errorf("Not a TLS handshake record.")
+	}
+	r.bytes(3)
+
+	record, err := r.vector(2, "record")
+	if err != nil {
+		return nil, err
+	}
+
+	record.what = "handshake header"
+	if err := record.need(tlsHandshakeHeaderLength); err != nil {
+		return nil, err
+	}
+
+	hello := &TLSHello{}
+	hello.Type, _ = record.uint8()
+	if hello.Type != TLSClientHelloType && hello.Type != TLSServerHelloType {
+		return nil, fmt.Errorf("Unexpected TLS handshake type %d.", hello.Type)
+	}
+
+	body, err := record.vector(3, "handshake")
+	if err != nil {
+		return nil, err
+	}
+
+	body.what = "version"
+	if hello.Version, err = body.uint16(); err != nil {
+		return nil, err
+	}
+	body.what = "random"
+	if _, err = body.bytes(tlsRandomLength); err != nil {
+		return nil, err
+	} else if _, err = body.vector(1, "session id"); err != nil {
+		return nil, err
+	}
+
+	if hello.Type == TLSClientHelloType {
+		ciphers, err := body.vector(2, "cipher suites")
+		if err != nil {
+			return nil, err
+		} else if hello.Ciphers, err = ciphers.uint16s(); err != nil {
+			return nil, err
+		} else if _, err = body.vector(1, "compression methods"); err != nil {
+			return nil, err
+		}
+	} else {
+		body.what = "cipher suite"
+		cipher, err := body.uint16()
+		if err != nil {
+			return nil, err
+		}
+		hello.Ciphers = []uint16{cipher}
+
+		body.what = "compression method"
+		if _, err = body.uint8(); err != nil {
+			return nil, err
+		}
+	}
+
+	hello.Extensions = make([]uint16, 0)
+	// the extensions are optional
+	if len(body.buf) == 0 {
+		return hello, nil
+	}
+
+	extensions, err := body.vector(2, "extensions")
+	if err != nil {
+		return nil, err
+	}
+
+	for len(extensions.buf) > 0 {
+		extensions.what = "extension type"
+		extType, err := extensions.uint16()
+		if err != nil {
+			return nil, err
+		}
+
+		ext, err := extensions.vector(2, "extension "+strconv.Itoa(int(extType)))
+		if err != nil {
+			return nil, err
+		}
+		hello.Extensions = append(hello.Extensions, extType)
+
+		if hello.Type != TLSClientHelloType {
+			continue
+		}
+
+		switch extType {
+		case tlsSupportedGroups:
+			groups, err := ext.vector(2, "supported groups")
+			if err != nil {
+				return nil, err
+			} else if hello.Groups, err = groups.uint16s(); err != nil {
+				return nil, err
+			}
+		case tlsECPointFormats:
+			formats, err := ext.vector(1, "point formats")
+			if err != nil {
+				return nil, err
+			}
+			hello.PointFormats = formats.buf
+		case tlsServerNameExtension:
+			names, err := ext.vector(2, "server names")
+			if err != nil {
+				return nil, err
+			}
+			for len(names.buf) > 0 {
+				names.what = "server name type"
+				nameType, err := names.uint8()
+				if err != nil {
+					return nil, err
+				}
+				name, err := names.vector(2, "server name")
+				if err != nil {
+					return nil, err
+				} else if nameType == tlsServerNameHostType {
+					hello.ServerName = string(name.buf)
+				}
+			}
+		}
+	}
+
+	return hello, nil
+}
+
+func tlsJoin(values []uint16) string {
+	list := make([]string, 0, len(values))
+	for _, v := range values {
+		if !IsTLSGREASE(v) {
+			list = append(list, strconv.Itoa(int(v)))
+		}
+	}
+	return strings.Join(list, "-")
+}
+
+// JA3String returns the JA3 string of a client hello or the JA3S one of a
+// server hello, without the GREASE values.
+func (h *TLSHello) JA3String() string {
+	fields := []string{
+		strconv.Itoa(int(h.Version)),
+		tlsJoin(h.Ciphers),
+		tlsJoin(h.Extensions),
+	}
+
+	if h.Type == TLSClientHelloType {
+		formats := make([]uint16, len(h.PointFormats))
+		for i, f := range h.PointFormats {
+			formats[i] = uint16(f)
+		}
+		fields = append(fields, tlsJoin(h.Groups), tlsJoin(formats))
+	}
+
+	return strings.Join(fields, ",")
+}
+
+// JA3 returns the MD5 hash of the JA3 or JA3S string.
+func (h *TLSHello) JA3() string {
+	hash := md5.Sum([]byte(h.JA3String()))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,101 @@
+package packets
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// client hello for example.com with GREASE cipher, extension and group
+var tlsClientHello = []byte{
+	0x16, 0x03, 0x01, 0x00, 0x68, 0x01, 0x00, 0x00, 0x64, 0x03, 0x03, 0x00,
+	0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c,
+	0x0d, 0x0e, 0x0f, 0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18,
+	0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f, 0x00, 0x00, 0x08, 0x0a, 0x0a,
+	0x13, 0x01, 0xc0, 0x2b, 0x00, 0x2f, 0x01, 0x00, 0x00, 0x33, 0x2a, 0x2a,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x0e, 0x00, 0x00, 0x0b, 0x65,
+	0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x2e, 0x63, 0x6f, 0x6d, 0x00, 0x0a,
+	0x00, 0x08, 0x00, 0x06, 0x1a, 0x1a, 0x00, 0x1d, 0x00, 0x17, 0x00, 0x0b,
+	0x00, 0x02, 0x01, 0x00, 0x00, 0x10, 0x00, 0x05, 0x00, 0x03, 0x02, 0x68,
+	0x32,
+}
+
+// server hello with renegotiation info and point formats
+var tlsServerHello = []byte{
+	0x16, 0x03, 0x03, 0x00, 0x37, 0x02, 0x00, 0x00, 0x33, 0x03, 0x03, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xc0, 0x2b, 0x00, 0x00,
+	0x0b, 0xff, 0x01, 0x00, 0x01, 0x00, 0x00, 0x0b, 0x00, 0x02, 0x01, 0x00,
+}
+
+func TestTLSHello(t *testing.T) {
+	var units = []struct {
+		data []byte
+		exp  TLSHello
+		ja3  string
+		hash string
+	}{
+		{tlsClientHello, TLSHello{
+			Type:         TLSClientHelloType,
+			Version:      0x0303,
+			Ciphers:      []uint16{0x0a0a, 0x1301, 0xc02b, 0x002f},
+			Extensions:   []uint16{0x2a2a, 0, 10, 11, 16},
+			Groups:       []uint16{0x1a1a, 29, 23},
+			PointFormats: []uint8{0},
+			ServerName:   "example.com",
+		}, "771,4865-49195-47,0-10-11-16,29-23,0", "d53fc7724d7df23b15a2b621393f85bb"},
+		{tlsServerHello, TLSHello{
+			Type:       TLSServerHelloType,
+			Version:    0x0303,
+			Ciphers:    []uint16{0xc02b},
+			Extensions: []uint16{0xff01, 11},
+		}, "771,49195,65281-11", "f9a66afdd1f499d415ca470974ec00c8"},
+	}
+
+	for _, u := range units {
+		hello, err := ParseTLSHello(u.data)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if !reflect.DeepEqual(*hello, u.exp) {
+			t.Fatalf("expected '%+v', got '%+v'", u.exp, *hello)
+		} else if got := hello.JA3String(); got != u.ja3 {
+			t.Fatalf("expected '%s', got '%s'", u.ja3, got)
+		} else if got := hello.JA3(); got != u.hash {
+			t.Fatalf("expected '%s', got '%s'", u.hash, got)
+		}
+	}
+}
+
+func TestTLSHelloErrors(t *testing.T) {
+	var units = []struct {
+		data []byte
+		err  string
+	}{
+		{[]byte{0x16, 0x03}, "could not parse record header"},
+		{[]byte{0x17, 0x03, 0x03, 0x00, 0x01, 0x00}, "Not a TLS handshake record"},
+		{[]byte{0x16, 0x03, 0x03, 0x00, 0x04, 0x0b, 0x00, 0x00, 0x00}, "Unexpected TLS handshake type 11"},
+		// the hello continues in the next segment
+		{tlsClientHello[:64], "could not parse record"},
+		{[]byte{0x16, 0x03, 0x03, 0x00, 0x04, 0x02, 0x00, 0x00, 0x33}, "could not parse handshake"},
+	}
+
+	for _, u := range units {
+		if _, err := ParseTLSHello(u.data); err == nil || !strings.Contains(err.Error(), u.err) {
+			t.Fatalf("expected '%s', got '%v'", u.err, err)
+		}
+	}
+}
+
+func TestTLSGREASE(t *testing.T) {
+	for _, v := range []uint16{0x0a0a, 0x1a1a, 0xfafa} {
+		if !IsTLSGREASE(v) {
+			t.Fatalf("expected 0x%04x to be GREASE", v)
+		}
+	}
+	for _, v := range []uint16{0x0a1a, 0x1301, 0x000a} {
+		if IsTLSGREASE(v) {
+			t.Fatalf("expected 0x%04x not to be GREASE", v)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package session
+
+import (
+	"fmt"
+)
+
+// AssociationPreparer is implemented by modules which need to change
+// the interface before it associates to a WiFi network.
+type AssociationPreparer interface {
+	BeforeAssociation(iface string, ssid string) error
+}
+
+// PrepareAssociation is called by every command associating iface to
+// ssid before doing it, the first module failing aborts it.
+func (s *Session) PrepareAssociation(iface string, ssid string) error {
+	for _, m := range s.Modules {
+		if p, ok := m.(AssociationPreparer); ok {
+			if err := p.BeforeAssociation(iface, ssid); err != nil {
+				return fmt.Errorf("%s: %s", m.Name(), err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package session
+
+import (
+	"fmt"
+	"testing"
+)
+
+type associationTestModule struct {
+	lockTestModule
+	name  string
+	err   error
+	calls []string
+}
+
+func (m *associationTestModule) Name() string { return m.name }
+func (m *associationTestModule) BeforeAssociation(iface string, ssid string) error {
+	m.calls = append(m.calls, iface+" "+ssid)
+	return m.err
+}
+
+func TestSessionPrepareAssociation(t *testing.T) {
+	s, err := NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	first := &associationTestModule{name: "mac.changer"}
+	failing := &associationTestModule{name: "wifi.txpower", err: fmt.Errorf("nope")}
+	last := &associationTestModule{name: "net.recon"}
+	other := &lockTestModule{NewSessionModule("net.probe", s.Session)}
+	s.Modules = []Module{first, other, failing, last}
+
+	if err := s.PrepareAssociation("wlan0", "lab"); err == nil || err.Error() != "wifi.txpower: nope" {
+		t.Fatalf("expected 'wifi.txpower: nope', got '%v'", err)
+	} else if len(first.calls) != 1 || first.calls[0] != "wlan0 lab" {
+		t.Fatalf("expected the first module to be called, got '%v'", first.calls)
+	} else if len(last.calls) != 0 {
+		t.Fatalf("expected the association to be aborted, got '%v'", last.calls)
+	}
+
+	failing.err = nil
+	if err := s.PrepareAssociation("wlan0", "lab"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(last.calls) != 1 {
+		t.Fatalf("expected every module to be called, got '%v'", last.calls)
+	}
+}
@@ -0,0 +1,203 @@
+package session
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/bettercap/bettercap/core"
+)
+
+// Dependent is implemented by modules which have to be started after
+// some other ones when they are in the autostart list together.
+type Dependent interface {
+	Dependencies() []string
+}
+
+// the modules added with 'autostart add', one per line in file
+type autoStart struct {
+	sync.Mutex
+	file    string
+	modules []string
+}
+
+// autostartFile returns the autostart list inside the user config
+// folder, or an empty path to keep it in memory only.
+func autostartFile() string {
+	configDir, err := userConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	dir := filepath.Join(configDir, "bettercap")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "autostart")
+}
+
+func newAutoStart(file string) (*autoStart, error) {
+	a := &autoStart{
+		file:    file,
+		modules: make([]string, 0),
+	}
+
+	if file != "" && core.Exists(file) {
+		raw, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, line := range strings.Split(string(raw), "\n") {
+			if name := core.Trim(line); name != "" && !a.has(name) {
+				a.modules = append(a.modules, name)
+			}
+		}
+	}
+
+	return a, nil
+}
+
+func (a *autoStart) has(name string) bool {
+	for _, mod := range a.modules {
+		if mod == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *autoStart) save() error {
+	if a.file == "" {
+		return nil
+	}
+
+	data := ""
+	for _, name := range a.modules {
+		data += name + "\n"
+	}
+	return ioutil.WriteFile(a.file, []byte(data), 0600)
+}
+
+func (s *Session) autoStartList() *autoStart {
+	if s.autostart == nil {
+		s.autostart, _ = newAutoStart("")
+	}
+	return s.autostart
+}
+
+// AutoStartAdd adds the module to the ones started with the session.
+func (s *Session) AutoStartAdd(name string) error {
+	if err, _ := s.Module(name); err != nil {
+		return err
+	}
+
+	a := s.autoStartList()
+	a.Lock()
+	defer a.Unlock()
+
+	if a.has(name) {
+		return fmt.Errorf("%s is already started with the session.", name)
+	}
+
+	a.modules = append(a.modules, name)
+	return a.save()
+}
+
+// AutoStartRemove reverts AutoStartAdd.
+func (s *Session) AutoStartRemove(name string) error {
+	a := s.autoStartList()
+	a.Lock()
+	defer a.Unlock()
+
+	for i, mod := range a.modules {
+		if mod == name {
+			a.modules = append(a.modules[:i], a.modules[i+1:]...)
+			return a.save()
+		}
+	}
+	return fmt.Errorf("%s is not started with the session.", name)
+}
+
+// AutoStartModules returns the modules of the autostart list in the
+// order they are started: after their dependencies and otherwise in
+// the order they were added.
+func (s *Session) AutoStartModules() ([]string, error) {
+	a := s.autoStartList()
+	a.Lock()
+	names := append([]string{}, a.modules...)
+	a.Unlock()
+
+	deps := make(map[string][]string)
+	for _, name := range names {
+		if err, m := s.Module(name); err == nil {
+			if d, ok := m.(Dependent); ok {
+				deps[name] = d.Dependencies()
+			}
+		}
+	}
+
+	listed := make(map[string]bool)
+	for _, name := range names {
+		listed[name] = true
+	}
+
+	ordered := make([]string, 0, len(names))
+	done := make(map[string]bool)
+	visiting := make(map[string]bool)
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		if done[name] {
+			return nil
+		} else if visiting[name] {
+			return fmt.Errorf("Circular dependency between the autostart modules involving %s.", name)
+		}
+
+		visiting[name] = true
+		for _, dep := range deps[name] {
+			if listed[dep] {
+				if err := visit(dep); err != nil {
+					return err
+				}
+			}
+		}
+		visiting[name] = false
+
+		done[name] = true
+		ordered = append(ordered, name)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// AutoStart starts every module of the autostart list which is not
+// running yet.
+func (s *Session) AutoStart() error {
+	names, err := s.AutoStartModules()
+	if err != nil {
+		return err
+	} else if len(names) == 0 {
+		return nil
+	}
+
+	result := core.NewMultiError()
+	for _, name := range names {
+		err, m := s.Module(name)
+		if err == nil && !m.Running() {
+			s.Events.Log(core.INFO, "Autostarting %s ...", core.Bold(name))
+			err = m.Start()
+		}
+		result.Add(name, err)
+	}
+	return result.ErrorOrNil()
+}
@@ -0,0 +1,121 @@
+package session
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type autostartTestModule struct {
+	SessionModule
+	deps    []string
+	started *[]string
+}
+
+func (m *autostartTestModule) Name() string           { return m.SessionModule.Name }
+func (m *autostartTestModule) Description() string    { return "" }
+func (m *autostartTestModule) Author() string         { return "" }
+func (m *autostartTestModule) Dependencies() []string { return m.deps }
+func (m *autostartTestModule) Stop() error            { return nil }
+
+func (m *autostartTestModule) Start() error {
+	*m.started = append(*m.started, m.Name())
+	return m.SetRunning(true, nil)
+}
+
+func TestSessionAutoStart(t *testing.T) {
+	s, err := NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+	s.registerCoreHandlers()
+
+	dir, err := ioutil.TempDir("", "autostart")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "autostart")
+	if s.autostart, err = newAutoStart(file); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	started := make([]string, 0)
+	for name, deps := range map[string][]string{
+		"mac.changer": nil,
+		"net.recon":   {"mac.changer"},
+		"arp.spoof":   {"mac.changer", "net.recon"},
+		"mac.monitor": {"arp.spoof"},
+	} {
+		s.Register(&autostartTestModule{NewSessionModule(name, s.Session), deps, &started})
+	}
+
+	if err := s.Run("autostart add nope"); err == nil {
+		t.Fatal("expected error for unknown module")
+	}
+
+	for _, name := range []string{"arp.spoof", "net.recon", "mac.changer", "mac.monitor"} {
+		if err := s.Run("autostart add " + name); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := s.Run("autostart add net.recon"); err == nil {
+		t.Fatal("expected error for duplicate module")
+	} else if err := s.Run("autostart remove mac.monitor"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Run("autostart remove mac.monitor"); err == nil {
+		t.Fatal("expected error for module not in the list")
+	}
+
+	// reloaded as on the next boot
+	if s.autostart, err = newAutoStart(file); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"mac.changer", "net.recon", "arp.spoof"}
+	if names, err := s.AutoStartModules(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected '%v', got '%v'", expected, names)
+	} else if err := s.AutoStart(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !reflect.DeepEqual(started, expected) {
+		t.Fatalf("expected '%v', got '%v'", expected, started)
+	}
+
+	// running modules are not started again
+	if err := s.AutoStart(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(started) != len(expected) {
+		t.Fatalf("expected %d starts, got %d", len(expected), len(started))
+	}
+}
+
+func TestSessionAutoStartCycle(t *testing.T) {
+	s, err := NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	started := make([]string, 0)
+	s.Register(&autostartTestModule{NewSessionModule("a", s.Session), []string{"b"}, &started})
+	s.Register(&autostartTestModule{NewSessionModule("b", s.Session), []string{"a"}, &started})
+
+	for _, name := range []string{"a", "b"} {
+		if err := s.AutoStartAdd(name); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := s.AutoStart(); err == nil {
+		t.Fatal("expected error for circular dependency")
+	} else if len(started) != 0 {
+		t.Fatalf("expected no module to be started, got '%v'", started)
+	}
+}
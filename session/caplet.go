@@ -0,0 +1,310 @@
+package session
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/bettercap/bettercap/core"
+)
+
+var (
+	reCapletArg = regexp.MustCompile(`^([a-zA-Z_][a-zA-Z0-9_.\-]*)=(.*)$`)
+	reCapletVar = regexp.MustCompile(`{(arg\.[^}]+|os|arch|caplet\.dir)}`)
+	reCapletPos = regexp.MustCompile(`\$([0-9]+)`)
+	reCapletIf  = regexp.MustCompile(`^(!)?\s*([^\s!=]+)\s*(?:(==|!=)\s*(.*))?$`)
+)
+
+// CapletArgs are the arguments a caplet is run with, NAME=VALUE ones
+// are named and the others positional.
+type CapletArgs struct {
+	Positional []string
+	Named      map[string]string
+}
+
+func ParseCapletArgs(argv []string) CapletArgs {
+	args := CapletArgs{
+		Positional: make([]string, 0),
+		Named:      make(map[string]string),
+	}
+
+	for _, arg := range argv {
+		if m := reCapletArg.FindStringSubmatch(arg); m != nil {
+			args.Named[m[1]] = m[2]
+		} else if arg != "" {
+			args.Positional = append(args.Positional, arg)
+		}
+	}
+
+	return args
+}
+
+// caplet is a loaded caplet file, its lines are interpolated and its
+// conditionals evaluated while it runs, so that they see what the
+// previous commands did.
+type caplet struct {
+	path  string
+	lines []string
+	args  CapletArgs
+}
+
+func loadCaplet(filename string, args CapletArgs) (*caplet, error) {
+	input, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+
+	c := &caplet{
+		path:  filename,
+		lines: make([]string, 0),
+		args:  args,
+	}
+
+	scanner := bufio.NewScanner(input)
+	scanner.Split(bufio.ScanLines)
+	for scanner.Scan() {
+		c.lines = append(c.lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return c, c.checkBlocks()
+}
+
+func (c *caplet) errorf(line int, format string, args ...interface{}) error {
+	return fmt.Errorf("%s:%d: %s", c.path, line+1, fmt.Sprintf(format, args...))
+}
+
+// capletDirective returns the if, else, end or default keyword the
+// line starts with and its argument, if any.
+func capletDirective(line string) (string, string) {
+	line = core.Trim(line)
+	if line == "else" || line == "end" {
+		return line, ""
+	} else if strings.HasPrefix(line, "if ") || strings.HasPrefix(line, "default ") {
+		parts := strings.SplitN(line, " ", 2)
+		return parts[0], core.Trim(parts[1])
+	}
+	return "", ""
+}
+
+// checkBlocks makes sure every if has its end before running anything.
+func (c *caplet) checkBlocks() error {
+	opened := make([]int, 0)
+	withElse := make(map[int]bool)
+
+	for i, line := range c.lines {
+		switch directive, arg := capletDirective(line); directive {
+		case "if":
+			if arg == "" {
+				return c.errorf(i, "if without a condition")
+			}
+			opened = append(opened, i)
+		case "else":
+			if len(opened) == 0 {
+				return c.errorf(i, "else without if")
+			} else if last := opened[len(opened)-1]; withElse[last] {
+				return c.errorf(i, "more than one else for the if on line %d", last+1)
+			} else {
+				withElse[last] = true
+			}
+		case "end":
+			if len(opened) == 0 {
+				return c.errorf(i, "end without if")
+			}
+			opened = opened[:len(opened)-1]
+		}
+	}
+
+	if len(opened) > 0 {
+		return c.errorf(opened[len(opened)-1], "if without end")
+	}
+	return nil
+}
+
+// argument returns the value of the named or positional argument.
+func (c *caplet) argument(name string) (string, bool) {
+	if value, found := c.args.Named[name]; found {
+		return value, true
+	} else if idx, err := strconv.Atoi(name); err == nil && idx >= 0 && idx < len(c.args.Positional) {
+		return c.args.Positional[idx], true
+	}
+	return "", false
+}
+
+// lookup resolves name to an argument, the os or arch of this computer
+// or a session variable.
+func (c *caplet) lookup(s *Session, name string) (string, bool) {
+	if value, found := c.argument(name); found {
+		return value, true
+	} else if name == "os" {
+		return runtime.GOOS, true
+	} else if name == "arch" {
+		return runtime.GOARCH, true
+	}
+	found, value := s.Env.Get(name)
+	return value, found
+}
+
+// interpolate replaces $0, $1 ... with the positional arguments and the
+// {arg.NAME}, {os}, {arch} and {caplet.dir} variables with their values.
+func (c *caplet) interpolate(line string) (string, error) {
+	var err error
+
+	line = reCapletPos.ReplaceAllStringFunc(line, func(m string) string {
+		if value, found := c.argument(m[1:]); found {
+			return value
+		}
+		return m
+	})
+
+	line = reCapletVar.ReplaceAllStringFunc(line, func(m string) string {
+		switch name := strings.Trim(m, "{}"); name {
+		case "os":
+			return runtime.GOOS
+		case "arch":
+			return runtime.GOARCH
+		case "caplet.dir":
+			return filepath.Dir(c.path)
+		default:
+			name = strings.TrimPrefix(name, "arg.")
+			if value, found := c.argument(name); found {
+				return value
+			} else if err == nil {
+				err = fmt.Errorf("argument '%s' is not defined", name)
+			}
+			return m
+		}
+	})
+
+	return line, err
+}
+
+// eval evaluates the conditions "NAME", "!NAME", "NAME == VALUE" and
+// "NAME != VALUE", undefined names compare as themselves and are false.
+func (c *caplet) eval(s *Session, cond string) (bool, error) {
+	m := reCapletIf.FindStringSubmatch(core.Trim(cond))
+	if m == nil {
+		return false, fmt.Errorf("invalid condition '%s'", cond)
+	}
+
+	negate, name, op, expected := m[1] == "!", m[2], m[3], core.Trim(m[4])
+	value, found := c.lookup(s, name)
+
+	if op == "" {
+		result := found && value != "" && value != "false" && value != "0"
+		return result != negate, nil
+	} else if negate {
+		return false, fmt.Errorf("invalid condition '%s'", cond)
+	} else if !found {
+		value = name
+	}
+
+	expected = strings.Trim(expected, `"'`)
+	if op == "==" {
+		return value == expected, nil
+	}
+	return value != expected, nil
+}
+
+// capletBlock is an if being run, whether its branches run depends on
+// the blocks it is nested in and on its condition.
+type capletBlock struct {
+	parent bool
+	taken  bool
+}
+
+func (c *caplet) run(s *Session) error {
+	blocks := make([]capletBlock, 0)
+	running := true
+
+	for i, line := range c.lines {
+		// commands can be indented in the blocks
+		if line = core.Trim(line); line == "" || line[0] == '#' {
+			continue
+		}
+
+		var err error
+		switch directive, arg := capletDirective(line); directive {
+		case "if":
+			taken := false
+			if running {
+				if arg, err = c.interpolate(arg); err != nil {
+					return c.errorf(i, "%s", err)
+				} else if taken, err = c.eval(s, arg); err != nil {
+					return c.errorf(i, "%s", err)
+				}
+			}
+			blocks = append(blocks, capletBlock{parent: running, taken: taken})
+			running = taken
+		case "else":
+			block := blocks[len(blocks)-1]
+			running = block.parent && !block.taken
+		case "end":
+			running = blocks[len(blocks)-1].parent
+			blocks = blocks[:len(blocks)-1]
+		case "default":
+			parts := strings.SplitN(arg, " ", 2)
+			if len(parts) != 2 {
+				return c.errorf(i, "usage: default NAME VALUE")
+			} else if _, found := c.args.Named[parts[0]]; running && !found {
+				if c.args.Named[parts[0]], err = c.interpolate(core.Trim(parts[1])); err != nil {
+					return c.errorf(i, "%s", err)
+				}
+			}
+		default:
+			if !running {
+				continue
+			} else if line, err = c.interpolate(line); err != nil {
+				return c.errorf(i, "%s", err)
+			} else if err = s.RunFrom(OriginCaplet, line); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// RunCaplet runs the caplet in filename, or in its first match in
+// paths.caplets, with the given arguments.
+func (s *Session) RunCaplet(filename string, argv ...string) error {
+	// not a path, look for it in paths.caplets
+	if !core.Exists(filename) {
+		if found, ok := s.FindCaplet(filename); ok {
+			filename = found
+		}
+	}
+
+	s.Events.Log(core.INFO, "Reading from caplet %s ...", filename)
+
+	return s.runCaplet(filename, argv)
+}
+
+func (s *Session) runCaplet(filename string, argv []string) error {
+	c, err := loadCaplet(filename, ParseCapletArgs(argv))
+	if err != nil {
+		return err
+	}
+	return c.run(s)
+}
+
+func (s *Session) isCapletCommand(line string) (is bool, filename string, argv []string) {
+	parts := strings.Fields(line)
+	if len(parts) == 0 {
+		return false, "", nil
+	}
+
+	if filename, found := s.FindCaplet(parts[0]); found {
+		return true, filename, parts[1:]
+	}
+
+	return false, "", nil
+}
@@ -0,0 +1,150 @@
+package session
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func writeTestCaplet(t *testing.T, dir, name, data string) string {
+	fileName := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(fileName, []byte(data), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return fileName
+}
+
+func TestParseCapletArgs(t *testing.T) {
+	args := ParseCapletArgs([]string{"eth0", "target=192.168.1.10", "msg=a=b", "=x", ""})
+
+	if len(args.Positional) != 2 || args.Positional[0] != "eth0" || args.Positional[1] != "=x" {
+		t.Fatalf("unexpected positional arguments %v", args.Positional)
+	} else if args.Named["target"] != "192.168.1.10" {
+		t.Fatalf("expected '192.168.1.10', got '%s'", args.Named["target"])
+	} else if args.Named["msg"] != "a=b" {
+		t.Fatalf("expected 'a=b', got '%s'", args.Named["msg"])
+	}
+}
+
+func TestRunCaplet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bettercap-caplet")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+	s.registerCoreHandlers()
+
+	fileName := writeTestCaplet(t, dir, "spoof.cap", `# targets and mode
+default mode fast
+default gateway {arg.target}
+set c.target {arg.target}
+set c.iface $0
+set c.gateway {arg.gateway}
+set c.dir {caplet.dir}
+
+if os == `+runtime.GOOS+`
+  set c.os yes
+else
+  set c.os no
+end
+
+if mode != fast
+  set c.mode slow
+else
+  if verbose
+    set c.mode fast-verbose
+  else
+    set c.mode fast
+  end
+end
+
+if !quiet
+  set c.quiet no
+end
+`)
+
+	if err := s.RunCaplet(fileName, "eth0", "target=192.168.1.10"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var units = []struct {
+		name string
+		exp  string
+	}{
+		{"c.target", "192.168.1.10"},
+		{"c.iface", "eth0"},
+		{"c.gateway", "192.168.1.10"},
+		{"c.dir", dir},
+		{"c.os", "yes"},
+		{"c.mode", "fast"},
+		{"c.quiet", "no"},
+	}
+	for _, u := range units {
+		if _, got := s.Env.Get(u.name); got != u.exp {
+			t.Fatalf("expected '%s' for %s, got '%s'", u.exp, u.name, got)
+		}
+	}
+
+	// arguments override the defaults and are passed by include
+	if err := s.Run("include " + fileName + " eth1 target=10.0.0.1 gateway=10.0.0.254 mode=slow verbose=true quiet=1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if _, got := s.Env.Get("c.gateway"); got != "10.0.0.254" {
+		t.Fatalf("expected '10.0.0.254', got '%s'", got)
+	} else if _, got := s.Env.Get("c.mode"); got != "slow" {
+		t.Fatalf("expected 'slow', got '%s'", got)
+	}
+
+	// conditions see the variables set by the previous commands
+	cond := writeTestCaplet(t, dir, "cond.cap", "set c.flag on\nif c.flag == on\nset c.seen yes\nend\n")
+	if err := s.RunCaplet(cond); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if _, got := s.Env.Get("c.seen"); got != "yes" {
+		t.Fatalf("expected 'yes', got '%s'", got)
+	}
+}
+
+func TestRunCapletErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bettercap-caplet")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+	s.registerCoreHandlers()
+
+	var units = []struct {
+		data string
+		exp  string
+	}{
+		{"set c.ran yes\nif os == linux\nset a b\n", "bad.cap:2: if without end"},
+		{"set c.ran yes\nend\n", "bad.cap:2: end without if"},
+		{"if os\nelse\nelse\nend\n", "bad.cap:3: more than one else"},
+		{"set a {arg.target}\n", "bad.cap:1: argument 'target' is not defined"},
+		{"if == x\nend\n", "bad.cap:1: invalid condition"},
+		{"default mode\n", "bad.cap:1: usage: default NAME VALUE"},
+	}
+
+	for _, u := range units {
+		s.Env.Set("c.ran", "no")
+		fileName := writeTestCaplet(t, dir, "bad.cap", u.data)
+		if err := s.RunCaplet(fileName); err == nil || !strings.Contains(err.Error(), u.exp) {
+			t.Fatalf("expected '%s', got '%v'", u.exp, err)
+		} else if _, ran := s.Env.Get("c.ran"); ran != "no" {
+			t.Fatalf("expected no commands to run for '%s'", u.exp)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package session
+
+import (
+	"strings"
+	"sync"
+)
+
+// ParamCompleter returns the candidate values of a parameter
+// starting with the given prefix.
+type ParamCompleter func(prefix string) []string
+
+type completers struct {
+	sync.RWMutex
+	byParam map[string]ParamCompleter
+}
+
+func newCompleters() *completers {
+	return &completers{
+		byParam: make(map[string]ParamCompleter),
+	}
+}
+
+func (s *Session) RegisterCompleter(param string, cb ParamCompleter) {
+	if s.completers == nil {
+		s.completers = newCompleters()
+	}
+
+	s.completers.Lock()
+	defer s.completers.Unlock()
+	s.completers.byParam[param] = cb
+}
+
+// Complete returns the candidate values for the parameter param
+// starting with prefix, or nil if no completer was registered for it.
+func (s *Session) Complete(param string, prefix string) []string {
+	if s.completers == nil {
+		return nil
+	}
+
+	s.completers.RLock()
+	cb, found := s.completers.byParam[param]
+	s.completers.RUnlock()
+
+	if !found {
+		return nil
+	}
+
+	values := []string{}
+	for _, value := range cb(prefix) {
+		if strings.HasPrefix(value, prefix) {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// completes the VALUE of a 'set NAME VALUE' command line
+func (s *Session) completeSetValue(line string) []string {
+	parts := strings.Fields(line)
+	if len(parts) < 2 {
+		return nil
+	}
+
+	prefix := ""
+	if len(parts) > 2 {
+		prefix = parts[2]
+	}
+
+	return s.Complete(parts[1], prefix)
+}
@@ -0,0 +1,59 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSessionCompleteWithoutCompleter(t *testing.T) {
+	s := Session{}
+	if got := s.Complete("nope", ""); got != nil {
+		t.Fatalf("expected no completions, got %v", got)
+	}
+}
+
+func TestSessionComplete(t *testing.T) {
+	s := Session{}
+	s.RegisterCompleter("mod.iface", func(prefix string) []string {
+		return []string{"eth0", "eth1", "wlan0"}
+	})
+
+	var units = []struct {
+		prefix string
+		values []string
+	}{
+		{"", []string{"eth0", "eth1", "wlan0"}},
+		{"eth", []string{"eth0", "eth1"}},
+		{"wlan0", []string{"wlan0"}},
+		{"lo", []string{}},
+	}
+
+	for _, u := range units {
+		if got := s.Complete("mod.iface", u.prefix); !reflect.DeepEqual(got, u.values) {
+			t.Fatalf("expected %v for '%s', got %v", u.values, u.prefix, got)
+		}
+	}
+}
+
+func TestSessionCompleteSetValue(t *testing.T) {
+	s := Session{}
+	s.RegisterCompleter("mod.iface", func(prefix string) []string {
+		return []string{"eth0", "wlan0"}
+	})
+
+	var units = []struct {
+		line   string
+		values []string
+	}{
+		{"set", nil},
+		{"set mod.iface ", []string{"eth0", "wlan0"}},
+		{"set mod.iface wl", []string{"wlan0"}},
+		{"set other.param ", nil},
+	}
+
+	for _, u := range units {
+		if got := s.completeSetValue(u.line); !reflect.DeepEqual(got, u.values) {
+			t.Fatalf("expected %v for '%s', got %v", u.values, u.line, got)
+		}
+	}
+}
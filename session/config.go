@@ -0,0 +1,65 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bettercap/bettercap/core"
+)
+
+// LoadConfig reads a JSON object of parameter names and values from the
+// file and sets each of them, every invalid value is reported instead
+// of stopping at the first one.
+func (s *Session) LoadConfig(fileName string) error {
+	fileName, err := core.ExpandPath(fileName)
+	if err != nil {
+		return err
+	}
+
+	raw, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+
+	config := make(map[string]interface{})
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return fmt.Errorf("Could not parse %s: %s", fileName, err)
+	}
+
+	names := make([]string, 0, len(config))
+	for name := range config {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	errors := make([]string, 0)
+	for _, name := range names {
+		var value string
+		switch v := config[name].(type) {
+		case string:
+			value = v
+		case bool:
+			value = strconv.FormatBool(v)
+		case float64:
+			value = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			errors = append(errors, fmt.Sprintf("%s: unsupported value type %T", name, v))
+			continue
+		}
+
+		if err := s.SetParam(name, value); err != nil {
+			errors = append(errors, err.Error())
+		}
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%d of %d parameters could not be set:\n  %s", len(errors), len(names), strings.Join(errors, "\n  "))
+	}
+
+	s.Events.Log(core.INFO, "Loaded %d parameters from %s.", len(names), fileName)
+	return nil
+}
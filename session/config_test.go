@@ -0,0 +1,47 @@
+package session
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSessionLoadConfig(t *testing.T) {
+	s := buildLockSession(t)
+	s.Events = NewEventPool(false, true)
+	m := s.Modules[0].(*lockTestModule)
+	m.AddParam(NewBoolParameter("mac.changer.enabled", "false", ""))
+	m.AddParam(NewIntParameter("mac.changer.count", "1", ""))
+
+	fp, err := ioutil.TempFile("", "bettercap-config")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(fp.Name())
+
+	fp.WriteString(`{
+		"mac.changer.iface": "wlan0",
+		"mac.changer.enabled": "maybe",
+		"mac.changer.count": "many",
+		"custom.var": 42
+	}`)
+	fp.Close()
+
+	err = s.LoadConfig(fp.Name())
+	if err == nil {
+		t.Fatal("expected validation errors")
+	} else if msg := err.Error(); !strings.HasPrefix(msg, "2 of 4") {
+		t.Fatalf("expected 2 errors out of 4, got '%s'", msg)
+	} else if !strings.Contains(msg, "mac.changer.enabled") || !strings.Contains(msg, "mac.changer.count") {
+		t.Fatalf("expected both invalid params to be reported, got '%s'", msg)
+	}
+
+	if _, v := s.Env.Get("mac.changer.iface"); v != "wlan0" {
+		t.Fatalf("expected 'wlan0', got '%s'", v)
+	} else if _, v := s.Env.Get("custom.var"); v != "42" {
+		t.Fatalf("expected '42', got '%s'", v)
+	} else if _, v := s.Env.Get("mac.changer.count"); v != "1" {
+		t.Fatalf("expected '1', got '%s'", v)
+	}
+}
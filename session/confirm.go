@@ -0,0 +1,61 @@
+package session
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bettercap/bettercap/core"
+
+	"github.com/mattn/go-isatty"
+)
+
+const ConfirmDangerousVariable = "confirm.dangerous"
+
+// reads the answer to question through readline, which owns stdin, it
+// returns false if the session is not interactive, it must only be
+// called while running a command typed at the console as it reads
+// from the same readline instance
+func (s *Session) confirmAnswer(question string) (string, bool) {
+	if s.confirmInput != nil {
+		fmt.Print(question)
+		answer, _ := bufio.NewReader(s.confirmInput).ReadString('\n')
+		return answer, true
+	} else if s.Input == nil || !(isatty.IsTerminal(os.Stdin.Fd()) || isatty.IsCygwinTerminal(os.Stdin.Fd())) {
+		return "", false
+	}
+
+	// the prompt is rendered again before reading the next command
+	s.Input.SetPrompt(question)
+	answer, err := s.Input.Readline()
+	if err != nil {
+		return "", true
+	}
+	return answer, true
+}
+
+// ConfirmDangerous asks the user to confirm a command tagged as dangerous
+// if confirm.dangerous is true, the commands which are not typed at the
+// console and the non interactive sessions decline it unless --yes was
+// passed.
+func (s *Session) ConfirmDangerous(origin CommandOrigin, line string) error {
+	if _, enabled := s.Env.Get(ConfirmDangerousVariable); enabled != "true" {
+		return nil
+	} else if s.Options.AssumeYes != nil && *s.Options.AssumeYes {
+		return nil
+	} else if origin != OriginConsole {
+		return fmt.Errorf("'%s' declined since it comes from the %s and not from the console, use --yes to confirm dangerous commands.", line, origin)
+	}
+
+	question := fmt.Sprintf("%s is a dangerous command, are you sure? [y/N] ", core.Bold(line))
+	answer, interactive := s.confirmAnswer(question)
+	if !interactive {
+		return fmt.Errorf("'%s' declined since the session is not interactive, use --yes to confirm dangerous commands.", line)
+	}
+
+	if answer = strings.ToLower(core.Trim(answer)); answer != "y" && answer != "yes" {
+		return fmt.Errorf("'%s' declined.", line)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package session
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mattn/go-isatty"
+)
+
+func TestSessionConfirmDangerous(t *testing.T) {
+	s, err := NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	runs := 0
+	m := &lockTestModule{NewSessionModule("mac.changer", s.Session)}
+	m.AddHandler(NewDangerousModuleHandler("mac.changer on", "", "", func(args []string) error {
+		runs++
+		return nil
+	}))
+	m.AddHandler(NewModuleHandler("mac.changer.show", "", "", func(args []string) error {
+		runs++
+		return nil
+	}))
+	s.Register(m)
+
+	var units = []struct {
+		confirm string
+		answer  string
+		line    string
+		runs    int
+	}{
+		{"false", "", "mac.changer on", 1},
+		{"true", "n\n", "mac.changer on", 0},
+		{"true", "\n", "mac.changer on", 0},
+		{"true", "y\n", "mac.changer on", 1},
+		{"true", "YES\n", "mac.changer on", 1},
+		{"true", "", "mac.changer.show", 1},
+	}
+
+	for _, u := range units {
+		runs = 0
+		s.Env.Set(ConfirmDangerousVariable, u.confirm)
+		s.confirmInput = strings.NewReader(u.answer)
+
+		err := s.RunFrom(OriginConsole, u.line)
+		if runs != u.runs {
+			t.Fatalf("expected %d runs of '%s' (confirm=%s, answer=%q), got %d", u.runs, u.line, u.confirm, u.answer, runs)
+		} else if (err == nil) != (u.runs == 1) {
+			t.Fatalf("unexpected error for '%s': %v", u.line, err)
+		}
+	}
+}
+
+func TestSessionConfirmDangerousNonInteractive(t *testing.T) {
+	if isatty.IsTerminal(os.Stdin.Fd()) {
+		t.Skip("stdin is a terminal")
+	}
+
+	s, err := NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	s.Env.Set(ConfirmDangerousVariable, "true")
+	if err := s.ConfirmDangerous(OriginConsole, "wifi.deauth ff:ff:ff:ff:ff:ff"); err == nil {
+		t.Fatal("expected the command to be declined")
+	}
+
+	yes := true
+	s.Options.AssumeYes = &yes
+	if err := s.ConfirmDangerous(OriginConsole, "wifi.deauth ff:ff:ff:ff:ff:ff"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSessionConfirmDangerousOrigin(t *testing.T) {
+	s, err := NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	runs := 0
+	m := &lockTestModule{NewSessionModule("mac.changer", s.Session)}
+	m.AddHandler(NewDangerousModuleHandler("mac.changer on", "", "", func(args []string) error {
+		runs++
+		return nil
+	}))
+	s.Register(m)
+
+	s.Env.Set(ConfirmDangerousVariable, "true")
+	for _, origin := range []CommandOrigin{OriginAPI, OriginRPC, OriginTicker, OriginCaplet, OriginCommandLine, OriginInternal} {
+		// a line typed at the console is not taken as the answer
+		input := strings.NewReader("y\n")
+		s.confirmInput = input
+		if err := s.RunFrom(origin, "mac.changer on"); err == nil {
+			t.Fatalf("expected the command from the %s to be declined", origin)
+		} else if runs != 0 {
+			t.Fatalf("expected no runs from the %s, got %d", origin, runs)
+		} else if input.Len() == 0 {
+			t.Fatalf("expected no answer to be read for the %s", origin)
+		}
+	}
+
+	if err := s.Run("mac.changer on"); err == nil {
+		t.Fatal("expected the command to be declined")
+	}
+
+	yes := true
+	s.Options.AssumeYes = &yes
+	if err := s.RunFrom(OriginAPI, "mac.changer on"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if runs != 1 {
+		t.Fatalf("expected 1 run with --yes, got %d", runs)
+	}
+}
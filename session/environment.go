@@ -99,6 +99,16 @@ func (env *Environment) Set(name, value string) string {
 	return old
 }
 
+func (env *Environment) Unset(name string) string {
+	env.Lock()
+	defer env.Unlock()
+
+	old := env.Data[name]
+	delete(env.Data, name)
+
+	return old
+}
+
 func (env *Environment) Get(name string) (bool, string) {
 	env.Lock()
 	defer env.Unlock()
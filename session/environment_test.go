@@ -210,6 +210,26 @@ func TestSessionEnvironmentSetWithCallback(t *testing.T) {
 	}
 }
 
+func TestSessionEnvironmentUnset(t *testing.T) {
+	setup(t, true, true)
+	defer teardown(t)
+
+	env, err := NewEnvironment(testEnvFile)
+	if env == nil {
+		t.Fatal("expected environment")
+	} else if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if old := env.Unset("people"); old != "shit" {
+		t.Fatalf("unexpected old value: %s", old)
+	} else if env.Has("people") {
+		t.Fatal("key should have been removed")
+	} else if old := env.Unset("people"); old != "" {
+		t.Fatalf("unexpected old value: %s", old)
+	} else if len(env.Data) != len(testEnvData)-1 {
+		t.Fatalf("expected %d, found %d", len(testEnvData)-1, len(env.Data))
+	}
+}
+
 func TestSessionEnvironmentGet(t *testing.T) {
 	setup(t, true, true)
 	defer teardown(t)
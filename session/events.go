@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -36,21 +37,51 @@ func (e Event) Label() string {
 	return color + label + core.RESET
 }
 
+// events buffered for each subscriber before the oldest are dropped
+const SubscriptionBufferSize = 256
+
+type subscription struct {
+	prefix  string
+	events  chan Event
+	dropped uint64
+}
+
+// send never blocks, the oldest event is dropped if the buffer is full.
+func (s *subscription) send(e Event) {
+	for {
+		select {
+		case s.events <- e:
+			return
+		default:
+			select {
+			case <-s.events:
+				s.dropped++
+			default:
+			}
+		}
+	}
+}
+
 type EventPool struct {
 	sync.Mutex
 
-	debug     bool
-	silent    bool
-	events    []Event
-	listeners []chan Event
+	debug         bool
+	silent        bool
+	quiet         bool
+	events        []Event
+	listeners     []chan Event
+	subscriptions []*subscription
+	// total number of events, including the cleared ones
+	emitted uint64
 }
 
 func NewEventPool(debug bool, silent bool) *EventPool {
 	return &EventPool{
-		debug:     debug,
-		silent:    silent,
-		events:    make([]Event, 0),
-		listeners: make([]chan Event, 0),
+		debug:         debug,
+		silent:        silent,
+		events:        make([]Event, 0),
+		listeners:     make([]chan Event, 0),
+		subscriptions: make([]*subscription, 0),
 	}
 }
 
@@ -75,12 +106,62 @@ func (p *EventPool) Unlisten(listener <-chan Event) {
 	}
 }
 
+// Subscribe returns a buffered channel with the events with a tag
+// starting with tagPrefix, unlike the Listen ones it never blocks Add as
+// the oldest events are dropped when full. The returned function stops
+// the delivery and closes the channel, it can be called more than once.
+func (p *EventPool) Subscribe(tagPrefix string) (<-chan Event, func()) {
+	p.Lock()
+	defer p.Unlock()
+
+	sub := &subscription{
+		prefix: tagPrefix,
+		events: make(chan Event, SubscriptionBufferSize),
+	}
+	p.subscriptions = append(p.subscriptions, sub)
+
+	return sub.events, func() {
+		p.Lock()
+		defer p.Unlock()
+
+		for i, other := range p.subscriptions {
+			if other == sub {
+				close(sub.events)
+				p.subscriptions = append(p.subscriptions[:i], p.subscriptions[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Dropped returns how many events have been dropped because the buffer
+// of the subscription was full.
+func (p *EventPool) Dropped(events <-chan Event) uint64 {
+	p.Lock()
+	defer p.Unlock()
+
+	for _, sub := range p.subscriptions {
+		if (<-chan Event)(sub.events) == events {
+			return sub.dropped
+		}
+	}
+	return 0
+}
+
 func (p *EventPool) SetSilent(s bool) {
 	p.Lock()
 	defer p.Unlock()
 	p.silent = s
 }
 
+// SetQuiet suppresses the logs which are not errors like SetSilent, it
+// is controlled by ui.quiet and doesn't alter log.silent.
+func (p *EventPool) SetQuiet(q bool) {
+	p.Lock()
+	defer p.Unlock()
+	p.quiet = q
+}
+
 func (p *EventPool) SetDebug(d bool) {
 	p.Lock()
 	defer p.Unlock()
@@ -93,17 +174,31 @@ func (p *EventPool) Add(tag string, data interface{}) {
 
 	e := NewEvent(tag, data)
 	p.events = append([]Event{e}, p.events...)
+	p.emitted++
 
 	// broadcast the event to every listener
 	for _, l := range p.listeners {
 		l <- e
 	}
+
+	for _, sub := range p.subscriptions {
+		if strings.HasPrefix(tag, sub.prefix) {
+			sub.send(e)
+		}
+	}
+}
+
+// Emitted returns how many events have been added since the start.
+func (p *EventPool) Emitted() uint64 {
+	p.Lock()
+	defer p.Unlock()
+	return p.emitted
 }
 
 func (p *EventPool) Log(level int, format string, args ...interface{}) {
 	if level == core.DEBUG && !p.debug {
 		return
-	} else if level < core.ERROR && p.silent {
+	} else if level < core.ERROR && (p.silent || p.quiet) {
 		return
 	}
 
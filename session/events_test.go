@@ -0,0 +1,52 @@
+package session
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestEventPoolSubscribe(t *testing.T) {
+	p := NewEventPool(false, false)
+
+	events, unsubscribe := p.Subscribe("mac.")
+	all, unsubscribeAll := p.Subscribe("")
+	defer unsubscribeAll()
+
+	p.Add("mac.changed", "a")
+	p.Add("endpoint.new", "b")
+	p.Add("mac.duplicate", "c")
+
+	for _, exp := range []string{"mac.changed", "mac.duplicate"} {
+		if e := <-events; e.Tag != exp {
+			t.Fatalf("expected '%s', got '%s'", exp, e.Tag)
+		}
+	}
+	if len(events) != 0 {
+		t.Fatalf("expected no more events, got %d", len(events))
+	} else if len(all) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(all))
+	}
+
+	// the oldest are dropped instead of blocking
+	for i := 0; i < SubscriptionBufferSize+5; i++ {
+		p.Add("mac.changed", fmt.Sprintf("%d", i))
+	}
+	if dropped := p.Dropped(events); dropped != 5 {
+		t.Fatalf("expected 5 dropped events, got %d", dropped)
+	} else if e := <-events; e.Data.(string) != "5" {
+		t.Fatalf("expected '5', got '%v'", e.Data)
+	}
+
+	unsubscribe()
+	unsubscribe()
+
+	p.Add("mac.changed", "d")
+	for e := range events {
+		if e.Data.(string) == "d" {
+			t.Fatal("expected no events after unsubscribing")
+		}
+	}
+	if dropped := p.Dropped(events); dropped != 0 {
+		t.Fatalf("expected no subscription, got %d dropped events", dropped)
+	}
+}
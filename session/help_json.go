@@ -0,0 +1,107 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// HandlerInfo is a serializable description of a core or module command.
+type HandlerInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Pattern     string `json:"pattern,omitempty"`
+	Dangerous   bool   `json:"dangerous,omitempty"`
+}
+
+// ModuleInfo is a serializable description of a module, its parameters
+// and its handlers.
+type ModuleInfo struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Author      string          `json:"author"`
+	Running     bool            `json:"running"`
+	Parameters  []ParameterInfo `json:"parameters"`
+	Handlers    []HandlerInfo   `json:"handlers"`
+}
+
+// HelpInfo is what help.json prints, the same commands and modules of
+// help so that UIs can be built without scraping its output.
+type HelpInfo struct {
+	Commands []HandlerInfo `json:"commands"`
+	Modules  []ModuleInfo  `json:"modules"`
+}
+
+func (h *ModuleHandler) Info() HandlerInfo {
+	info := HandlerInfo{
+		Name:        h.Name,
+		Description: h.Description,
+		Dangerous:   h.Dangerous,
+	}
+	if h.Parser != nil {
+		info.Pattern = h.Parser.String()
+	}
+	return info
+}
+
+func (h *CommandHandler) Info() HandlerInfo {
+	return HandlerInfo{
+		Name:        h.Name,
+		Description: h.Description,
+		Pattern:     h.Parser.String(),
+	}
+}
+
+// ModuleInfo describes m with the current values of its parameters.
+func (s *Session) ModuleInfo(m Module) ModuleInfo {
+	info := ModuleInfo{
+		Name:        m.Name(),
+		Description: m.Description(),
+		Author:      m.Author(),
+		Running:     m.Running(),
+		Parameters:  make([]ParameterInfo, 0),
+		Handlers:    make([]HandlerInfo, 0),
+	}
+
+	for _, p := range sortedParams(m.Parameters()) {
+		info.Parameters = append(info.Parameters, p.Info(s))
+	}
+
+	for _, h := range m.Handlers() {
+		info.Handlers = append(info.Handlers, h.Info())
+	}
+
+	return info
+}
+
+func (s *Session) HelpInfo() HelpInfo {
+	info := HelpInfo{
+		Commands: make([]HandlerInfo, 0),
+		Modules:  make([]ModuleInfo, 0),
+	}
+
+	for _, h := range s.CoreHandlers {
+		info.Commands = append(info.Commands, h.Info())
+	}
+
+	for _, m := range s.Modules {
+		info.Modules = append(info.Modules, s.ModuleInfo(m))
+	}
+
+	return info
+}
+
+func (s *Session) writeHelpJSON(w io.Writer) error {
+	raw, err := json.Marshal(s.HelpInfo())
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "%s\n", raw)
+	return err
+}
+
+func (s *Session) helpJSONHandler(args []string, sess *Session) error {
+	return s.writeHelpJSON(os.Stdout)
+}
@@ -0,0 +1,68 @@
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSessionHelpJSON(t *testing.T) {
+	s, err := NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	s.registerCoreHandlers()
+
+	m := &lockTestModule{NewSessionModule("mac.changer", s.Session)}
+	m.AddParam(NewStringParameter("mac.changer.address", ParamRandomMAC, testMacValidator, "Hardware address."))
+	m.AddHandler(NewModuleHandler("mac.changer on", "", "Start.", func(args []string) error { return nil }))
+	m.AddHandler(NewDangerousModuleHandler("mac.changer.ssid SSID", `mac\.changer\.ssid\s+(.+)`, "Set.", func(args []string) error { return nil }))
+	s.Register(m)
+
+	found := false
+	for _, h := range s.CoreHandlers {
+		if parsed, _ := h.Parse("help.json"); parsed {
+			if h.Name != "help.json" {
+				t.Fatalf("expected 'help.json', got '%s'", h.Name)
+			}
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Fatal("expected help.json to be parsed")
+	}
+
+	buf := bytes.Buffer{}
+	if err := s.writeHelpJSON(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	info := HelpInfo{}
+	if err := json.Unmarshal(buf.Bytes(), &info); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(info.Commands) != len(s.CoreHandlers) {
+		t.Fatalf("expected %d commands, got %d", len(s.CoreHandlers), len(info.Commands))
+	} else if len(info.Modules) != 1 {
+		t.Fatalf("expected 1 module, got %d", len(info.Modules))
+	}
+
+	exp := ModuleInfo{
+		Name:        "mac.changer",
+		Description: "",
+		Author:      "",
+		Parameters: []ParameterInfo{
+			{"mac.changer.address", "string", ParamRandomMAC, ParamRandomMAC, "Hardware address.", testMacValidator},
+		},
+		Handlers: []HandlerInfo{
+			{"mac.changer on", "Start.", "", false},
+			{"mac.changer.ssid SSID", "Set.", `mac\.changer\.ssid\s+(.+)`, true},
+		},
+	}
+	if got := info.Modules[0]; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected '%+v', got '%+v'", exp, got)
+	}
+}
@@ -0,0 +1,63 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bettercap/bettercap/core"
+)
+
+// commands setting one of these variables are not saved to the history
+var historySecretRe = regexp.MustCompile(`(?i)^set\s+\S*(pass|passwd|password|secret|token|psk|apikey|api\.key)\s`)
+
+func isSecretHistoryLine(line string) bool {
+	for _, cmd := range ParseCommands(line) {
+		if historySecretRe.MatchString(core.Trim(cmd) + " ") {
+			return true
+		}
+	}
+	return false
+}
+
+// historyPath returns the history file inside configDir, moving there
+// the legacy one from the home folder if it's the first time.
+func historyPath(configDir, legacy string) string {
+	if configDir == "" {
+		return legacy
+	}
+
+	dir := filepath.Join(configDir, "bettercap")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return legacy
+	}
+
+	path := filepath.Join(dir, "history")
+	if !core.Exists(path) && legacy != "" && core.Exists(legacy) {
+		if err := os.Rename(legacy, path); err != nil {
+			return legacy
+		}
+	}
+	return path
+}
+
+func historyFile() string {
+	legacy, _ := core.ExpandPath(HistoryFile)
+	configDir, err := userConfigDir()
+	if err != nil {
+		configDir = ""
+	}
+	return historyPath(configDir, legacy)
+}
+
+// saveHistory adds the line to the history unless it's
+// empty or it contains an obvious secret.
+func (s *Session) saveHistory(line string) {
+	if s.Input == nil || *s.Options.NoHistory {
+		return
+	} else if line = strings.TrimSpace(line); line == "" || isSecretHistoryLine(line) {
+		return
+	}
+	s.Input.SaveHistory(line)
+}
@@ -0,0 +1,71 @@
+package session
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bettercap/bettercap/core"
+)
+
+func TestSessionIsSecretHistoryLine(t *testing.T) {
+	var units = []struct {
+		line string
+		exp  bool
+	}{
+		{"set mac.changer.address seed:lab-run-1", false},
+		{"set api.rest.password hunter2", true},
+		{"SET api.rest.password hunter2", true},
+		{"set wifi.ap.passwd hunter2", true},
+		{"set api.rest.password", true},
+		{"set github.token abc", true},
+		{"set shodan.api.key abc", true},
+		{"set arp.spoof.targets 192.168.1.10; set api.rest.password x", true},
+		{"set passthrough.enabled true", false},
+		{"api.rest on", false},
+		{"get api.rest.password", false},
+		{"set ticker.commands 'echo password'", false},
+	}
+
+	for _, u := range units {
+		if got := isSecretHistoryLine(u.line); got != u.exp {
+			t.Fatalf("expected '%v' for '%s', got '%v'", u.exp, u.line, got)
+		}
+	}
+}
+
+func TestSessionHistoryPath(t *testing.T) {
+	base, err := ioutil.TempDir("", "bettercap-history")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(base)
+
+	legacy := filepath.Join(base, "bettercap.history")
+	if err := ioutil.WriteFile(legacy, []byte("net.probe on\n"), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	configDir := filepath.Join(base, "config")
+	exp := filepath.Join(configDir, "bettercap", "history")
+	if got := historyPath(configDir, legacy); got != exp {
+		t.Fatalf("expected '%s', got '%s'", exp, got)
+	} else if core.Exists(legacy) {
+		t.Fatal("expected legacy history to be moved")
+	} else if data, err := ioutil.ReadFile(exp); err != nil || string(data) != "net.probe on\n" {
+		t.Fatalf("expected legacy history to be kept, got '%s' (%v)", data, err)
+	}
+
+	// an existing history is never overwritten
+	ioutil.WriteFile(legacy, []byte("old\n"), 0600)
+	if got := historyPath(configDir, legacy); got != exp {
+		t.Fatalf("expected '%s', got '%s'", exp, got)
+	} else if !core.Exists(legacy) {
+		t.Fatal("expected legacy history to be left alone")
+	}
+
+	if got := historyPath("", legacy); got != legacy {
+		t.Fatalf("expected '%s', got '%s'", legacy, got)
+	}
+}
@@ -0,0 +1,32 @@
+package session
+
+import (
+	"fmt"
+)
+
+// UpdateInjection makes the packet queue send on net.inject.iface, or on
+// the capture interface if it's empty, injecting modules call it before
+// starting so that an invalid interface is reported right away.
+func (s *Session) UpdateInjection() error {
+	if s.Queue == nil {
+		return fmt.Errorf("No packet queue available on %s.", s.Interface.Name())
+	}
+
+	_, name := s.Env.Get(InjectIfaceVariable)
+	if name == s.Interface.Name() {
+		name = ""
+	}
+
+	if name != s.Queue.SendInterface() {
+		return s.Queue.SetSendInterface(name)
+	}
+	return nil
+}
+
+// Inject writes a raw frame on the interface selected by UpdateInjection.
+func (s *Session) Inject(raw []byte) error {
+	if err := s.UpdateInjection(); err != nil {
+		return err
+	}
+	return s.Queue.Send(raw)
+}
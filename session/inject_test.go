@@ -0,0 +1,19 @@
+package session
+
+import (
+	"testing"
+)
+
+func TestSessionInjectWithoutQueue(t *testing.T) {
+	s, err := NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	if err := s.UpdateInjection(); err == nil {
+		t.Fatal("expected error without a packet queue")
+	} else if err := s.Inject([]byte{0x00}); err == nil {
+		t.Fatal("expected error without a packet queue")
+	}
+}
@@ -0,0 +1,128 @@
+package session
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/bettercap/bettercap/core"
+)
+
+type paramLocks struct {
+	sync.RWMutex
+	locked map[string]bool
+}
+
+func newParamLocks() *paramLocks {
+	return &paramLocks{
+		locked: make(map[string]bool),
+	}
+}
+
+// resolves a lock target to the list of parameters it refers to, either
+// every parameter of a module or a single variable
+func (s *Session) lockTargets(target string) ([]string, error) {
+	if err, mod := s.Module(target); err == nil {
+		names := make([]string, 0)
+		for name := range mod.Parameters() {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names, nil
+	} else if s.Env.Has(target) {
+		return []string{target}, nil
+	}
+	return nil, fmt.Errorf("%s is neither a module nor a parameter.", target)
+}
+
+// Lock marks the parameter, or every parameter of the module, with the
+// given name as read-only and returns the names of the locked parameters.
+func (s *Session) Lock(target string) ([]string, error) {
+	names, err := s.lockTargets(target)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.locks == nil {
+		s.locks = newParamLocks()
+	}
+
+	s.locks.Lock()
+	defer s.locks.Unlock()
+	for _, name := range names {
+		s.locks.locked[name] = true
+	}
+	return names, nil
+}
+
+// Unlock reverts Lock, making the parameters writable again.
+func (s *Session) Unlock(target string) ([]string, error) {
+	names, err := s.lockTargets(target)
+	if err != nil || s.locks == nil {
+		return names, err
+	}
+
+	s.locks.Lock()
+	defer s.locks.Unlock()
+	for _, name := range names {
+		delete(s.locks.locked, name)
+	}
+	return names, nil
+}
+
+func (s *Session) IsLocked(name string) bool {
+	if s.locks == nil {
+		return false
+	}
+
+	s.locks.RLock()
+	defer s.locks.RUnlock()
+	return s.locks.locked[name]
+}
+
+func errLocked(name string) error {
+	return fmt.Errorf("%s is locked, use 'unlock %s' to change it.", name, name)
+}
+
+// finds the module parameter with the given name, if any
+func (s *Session) moduleParam(name string) *ModuleParam {
+	for _, m := range s.Modules {
+		if p, found := m.Parameters()[name]; found {
+			return p
+		}
+	}
+	return nil
+}
+
+func isParamPlaceholder(value string) bool {
+	switch value {
+	case ParamIfaceName, ParamIfaceAddress, ParamSubnet, ParamRandomMAC:
+		return true
+	}
+	return false
+}
+
+// SetParam sets a variable unless it's been locked by the user, values
+// of module parameters and of ui.colors are validated first.
+func (s *Session) SetParam(name, value string) error {
+	if err := s.checkParam(name, value); err != nil {
+		return err
+	}
+	s.Env.Set(name, value)
+	return nil
+}
+
+func (s *Session) checkParam(name, value string) error {
+	if s.IsLocked(name) {
+		return errLocked(name)
+	} else if p := s.moduleParam(name); p != nil && !isParamPlaceholder(value) {
+		if err, _ := p.Validate(value); err != nil {
+			return err
+		}
+	} else if name == UIColorsVariable {
+		if _, err := core.ThemeFor(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
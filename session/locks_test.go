@@ -0,0 +1,114 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/bettercap/bettercap/core"
+)
+
+type lockTestModule struct {
+	SessionModule
+}
+
+func (m lockTestModule) Name() string        { return "mac.changer" }
+func (m lockTestModule) Description() string { return "" }
+func (m lockTestModule) Author() string      { return "" }
+func (m lockTestModule) Start() error        { return nil }
+func (m lockTestModule) Stop() error         { return nil }
+
+func buildLockSession(t *testing.T) *Session {
+	env, err := NewEnvironment("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s := &Session{Env: env}
+	m := &lockTestModule{NewSessionModule("mac.changer", s)}
+	m.AddParam(NewStringParameter("mac.changer.iface", "eth0", "", ""))
+	m.AddParam(NewStringParameter("mac.changer.address", "aa:bb:cc:dd:ee:ff", "", ""))
+	s.Modules = []Module{m}
+	env.Set("other", "value")
+
+	return s
+}
+
+func TestSessionLockParam(t *testing.T) {
+	s := buildLockSession(t)
+
+	if names, err := s.Lock("mac.changer.iface"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(names) != 1 {
+		t.Fatalf("expected 1 locked param, got %v", names)
+	}
+
+	if err := s.SetParam("mac.changer.iface", "wlan0"); err == nil {
+		t.Fatal("expected error setting a locked param")
+	} else if _, v := s.Env.Get("mac.changer.iface"); v != "eth0" {
+		t.Fatalf("expected 'eth0', got '%s'", v)
+	} else if err := s.SetParam("mac.changer.address", "seed:x"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := s.Unlock("mac.changer.iface"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.SetParam("mac.changer.iface", "wlan0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSessionLockModule(t *testing.T) {
+	s := buildLockSession(t)
+
+	names, err := s.Lock("mac.changer")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(names) != 2 || names[0] != "mac.changer.address" || names[1] != "mac.changer.iface" {
+		t.Fatalf("expected both module params, got %v", names)
+	}
+
+	for _, name := range names {
+		if !s.IsLocked(name) {
+			t.Fatalf("expected %s to be locked", name)
+		}
+	}
+
+	if s.IsLocked("other") {
+		t.Fatal("expected 'other' not to be locked")
+	} else if _, err := s.Lock("nope"); err == nil {
+		t.Fatal("expected error for unknown target")
+	}
+
+	s.Unlock("mac.changer")
+	for _, name := range names {
+		if s.IsLocked(name) {
+			t.Fatalf("expected %s to be unlocked", name)
+		}
+	}
+}
+
+func TestSessionSetColors(t *testing.T) {
+	s, err := NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+	defer core.SetTheme(core.ThemeDefault)
+
+	if err := s.SetParam(UIColorsVariable, "mono"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if core.CurrentTheme != "mono" {
+		t.Fatalf("expected 'mono', got '%s'", core.CurrentTheme)
+	}
+
+	if err := s.SetParam(UIColorsVariable, "neon"); err == nil {
+		t.Fatal("expected error for an unknown theme")
+	} else if _, v := s.Env.Get(UIColorsVariable); v != "mono" {
+		t.Fatalf("expected 'mono', got '%s'", v)
+	}
+
+	if err := s.SetParam(UIColorsVariable, "off"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if got := core.Bold("gohpers"); got != "gohpers" {
+		t.Fatalf("expected 'gohpers', got '%s'", got)
+	}
+}
@@ -0,0 +1,25 @@
+package session
+
+// Metric is a counter or a gauge a module publishes about its activity,
+// metrics with the same name are told apart by their labels.
+type Metric struct {
+	Name   string
+	Type   string
+	Help   string
+	Labels map[string]string
+	Value  float64
+}
+
+func NewCounter(name string, help string, value uint64, labels map[string]string) Metric {
+	return Metric{Name: name, Type: "counter", Help: help, Labels: labels, Value: float64(value)}
+}
+
+func NewGauge(name string, help string, value float64, labels map[string]string) Metric {
+	return Metric{Name: name, Type: "gauge", Help: help, Labels: labels, Value: value}
+}
+
+// MetricsReporter is implemented by modules with statistics worth
+// monitoring, like the packets they sniffed or injected.
+type MetricsReporter interface {
+	Metrics() []Metric
+}
@@ -2,6 +2,7 @@ package session
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -19,16 +20,28 @@ type Module interface {
 	Running() bool
 	Start() error
 	Stop() error
+
+	Paused() bool
+	Pause() error
+	Resume() error
+}
+
+// StatusDetailer is implemented by modules with more to tell about their
+// state than whether they're running, like the backend they're using.
+type StatusDetailer interface {
+	StatusDetail() string
 }
 
 type SessionModule struct {
 	Name       string        `json:"name"`
 	Session    *Session      `json:"-"`
 	Started    bool          `json:"started"`
+	IsPaused   bool          `json:"paused"`
 	StatusLock *sync.RWMutex `json:"-"`
 
 	handlers []ModuleHandler
 	params   map[string]*ModuleParam
+	progress int32
 }
 
 func NewSessionModule(name string, s *Session) SessionModule {
@@ -40,6 +53,7 @@ func NewSessionModule(name string, s *Session) SessionModule {
 
 		handlers: make([]ModuleHandler, 0),
 		params:   make(map[string]*ModuleParam),
+		progress: -1,
 	}
 
 	return m
@@ -53,6 +67,29 @@ func (m *SessionModule) Parameters() map[string]*ModuleParam {
 	return m.params
 }
 
+// sortedParams returns the parameters sorted by name, so that listings
+// don't depend on the map iteration order.
+func sortedParams(params map[string]*ModuleParam) []*ModuleParam {
+	sorted := make([]*ModuleParam, 0, len(params))
+	for _, p := range params {
+		sorted = append(sorted, p)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// Params returns a description of every parameter of the module with its
+// current value, sorted by name.
+func (m *SessionModule) Params() []ParameterInfo {
+	infos := make([]ParameterInfo, 0, len(m.params))
+	for _, p := range sortedParams(m.params) {
+		infos = append(infos, p.Info(m.Session))
+	}
+	return infos
+}
+
 func (m *SessionModule) Param(name string) *ModuleParam {
 	return m.params[name]
 }
@@ -99,6 +136,16 @@ func (m SessionModule) IntParam(name string) (error, int) {
 	}
 }
 
+func (m SessionModule) DurationParam(name string) (error, time.Duration) {
+	if err, v := m.StringParam(name); err != nil {
+		return err, 0
+	} else if d, err := time.ParseDuration(v); err != nil {
+		return fmt.Errorf("Parameter %s is not a valid duration: %s.", name, err), 0
+	} else {
+		return nil, d
+	}
+}
+
 func (m SessionModule) BoolParam(name string) (error, bool) {
 	if err, v := m.params[name].Get(m.Session); err != nil {
 		return err, false
@@ -134,6 +181,7 @@ func (m *SessionModule) SetRunning(running bool, cb func()) error {
 
 	m.StatusLock.Lock()
 	m.Started = running
+	m.IsPaused = false
 	m.StatusLock.Unlock()
 
 	if *m.Session.Options.Debug {
@@ -167,3 +215,47 @@ func (m *SessionModule) SetRunning(running bool, cb func()) error {
 
 	return nil
 }
+
+func (m *SessionModule) Paused() bool {
+	m.StatusLock.RLock()
+	defer m.StatusLock.RUnlock()
+	return m.Started && m.IsPaused
+}
+
+// SetPaused changes the paused state of a running module, it's up
+// to the module workers to check Paused() and hold their work.
+func (m *SessionModule) SetPaused(paused bool) error {
+	m.StatusLock.Lock()
+	if !m.Started {
+		m.StatusLock.Unlock()
+		return ErrAlreadyStopped
+	} else if m.IsPaused == paused {
+		m.StatusLock.Unlock()
+		if paused {
+			return ErrAlreadyPaused
+		}
+		return ErrNotPaused
+	}
+	m.IsPaused = paused
+	m.StatusLock.Unlock()
+
+	if *m.Session.Options.Debug {
+		if paused {
+			m.Session.Events.Add("mod.paused", m.Name)
+		} else {
+			m.Session.Events.Add("mod.resumed", m.Name)
+		}
+	}
+
+	return nil
+}
+
+// Pause is a no-op for the modules that can't be paused.
+func (m *SessionModule) Pause() error {
+	return nil
+}
+
+// Resume is a no-op for the modules that can't be paused.
+func (m *SessionModule) Resume() error {
+	return nil
+}
@@ -15,6 +15,8 @@ type ModuleHandler struct {
 	Description string
 	Parser      *regexp.Regexp
 	Exec        func(args []string) error
+	// if true, the user is asked to confirm it when confirm.dangerous is enabled
+	Dangerous bool
 }
 
 func NewModuleHandler(name string, expr string, desc string, exec func(args []string) error) ModuleHandler {
@@ -32,6 +34,14 @@ func NewModuleHandler(name string, expr string, desc string, exec func(args []st
 	return h
 }
 
+// NewDangerousModuleHandler creates a handler which is disruptive for
+// the network or the system and may need to be confirmed.
+func NewDangerousModuleHandler(name string, expr string, desc string, exec func(args []string) error) ModuleHandler {
+	h := NewModuleHandler(name, expr, desc, exec)
+	h.Dangerous = true
+	return h
+}
+
 func (h *ModuleHandler) Help(padding int) string {
 	return fmt.Sprintf("  "+core.Bold("%"+strconv.Itoa(padding)+"s")+" : %s\n", h.Name, h.Description)
 }
@@ -19,6 +19,18 @@ const (
 	INT              = iota
 )
 
+func (t ParamType) String() string {
+	switch t {
+	case STRING:
+		return "string"
+	case BOOL:
+		return "bool"
+	case INT:
+		return "int"
+	}
+	return fmt.Sprintf("unknown(%d)", int(t))
+}
+
 type ModuleParam struct {
 	Name        string
 	Type        ParamType
@@ -56,6 +68,10 @@ func NewIntParameter(name string, def_value string, desc string) *ModuleParam {
 	return NewModuleParameter(name, def_value, INT, "^[\\d]+$", desc)
 }
 
+func NewDurationParameter(name string, def_value string, desc string) *ModuleParam {
+	return NewModuleParameter(name, def_value, STRING, "^(0|([\\d\\.]+(ns|us|ms|s|m|h))+)$", desc)
+}
+
 func (p ModuleParam) Validate(value string) (error, interface{}) {
 	if p.Validator != nil {
 		if !p.Validator.MatchString(value) {
@@ -114,6 +130,39 @@ func (p ModuleParam) Help(padding int) string {
 		"%s "+core.DIM+"(default=%s"+core.RESET+")\n", p.Name, p.Description, p.Value)
 }
 
+// ParameterInfo is a serializable snapshot of a module parameter, meant
+// for tooling that needs to render or validate values before a set.
+type ParameterInfo struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Value       string `json:"value"`
+	Default     string `json:"default"`
+	Description string `json:"description"`
+	Validator   string `json:"validator,omitempty"`
+}
+
+func (p ModuleParam) Info(s *Session) ParameterInfo {
+	info := ParameterInfo{
+		Name:        p.Name,
+		Type:        p.Type.String(),
+		Value:       p.Value,
+		Default:     p.Value,
+		Description: p.Description,
+	}
+
+	if s != nil && s.Env != nil {
+		if found, v := s.Env.Get(p.Name); found {
+			info.Value = v
+		}
+	}
+
+	if p.Validator != nil {
+		info.Validator = p.Validator.String()
+	}
+
+	return info
+}
+
 func (p ModuleParam) Register(s *Session) {
 	s.Env.Set(p.Name, p.Value)
 }
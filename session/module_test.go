@@ -0,0 +1,161 @@
+package session
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testMacValidator = "^seed:.+|[a-fA-F0-9]{2}:[a-fA-F0-9]{2}:[a-fA-F0-9]{2}:[a-fA-F0-9]{2}:[a-fA-F0-9]{2}:[a-fA-F0-9]{2}"
+
+func TestSessionModuleParams(t *testing.T) {
+	env, err := NewEnvironment("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s := &Session{Env: env}
+	m := NewSessionModule("mac.changer", s)
+	m.AddParam(NewStringParameter("mac.changer.iface", ParamIfaceName, "", "Name of the interface to use."))
+	m.AddParam(NewStringParameter("mac.changer.address", ParamRandomMAC, testMacValidator, "Hardware address to apply to the interface."))
+	m.AddParam(NewBoolParameter("mac.changer.dummy", "false", "Dummy."))
+
+	env.Set("mac.changer.address", "seed:lab")
+
+	params := m.Params()
+	if len(params) != 3 {
+		t.Fatalf("expected 3 params, got %d", len(params))
+	}
+
+	var units = []ParameterInfo{
+		{"mac.changer.address", "string", "seed:lab", ParamRandomMAC, "Hardware address to apply to the interface.", testMacValidator},
+		{"mac.changer.dummy", "bool", "false", "false", "Dummy.", "^(true|false)$"},
+		{"mac.changer.iface", "string", ParamIfaceName, ParamIfaceName, "Name of the interface to use.", ""},
+	}
+
+	for i, u := range units {
+		if params[i] != u {
+			t.Fatalf("expected '%+v', got '%+v'", u, params[i])
+		}
+	}
+
+	raw, err := json.Marshal(params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var decoded []ParameterInfo
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if decoded[0].Validator != testMacValidator {
+		t.Fatalf("expected '%s', got '%s'", testMacValidator, decoded[0].Validator)
+	}
+}
+
+func TestSessionModuleDurationParam(t *testing.T) {
+	env, err := NewEnvironment("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m := NewSessionModule("net.recon", &Session{Env: env})
+	p := m.AddParam(NewDurationParameter("net.recon.aging", "0", "Aging."))
+
+	var units = []struct {
+		value string
+		valid bool
+		exp   time.Duration
+	}{
+		{"0", true, 0},
+		{"90s", true, 90 * time.Second},
+		{"1m30s", true, 90 * time.Second},
+		{"-5m", false, 0},
+		{"5", false, 0},
+	}
+
+	for _, u := range units {
+		if err, _ := p.Validate(u.value); (err == nil) != u.valid {
+			t.Fatalf("expected '%s' valid=%v, got error '%v'", u.value, u.valid, err)
+		} else if u.valid {
+			env.Set(p.Name, u.value)
+			if err, got := m.DurationParam(p.Name); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			} else if got != u.exp {
+				t.Fatalf("expected '%s', got '%s'", u.exp, got)
+			}
+		}
+	}
+}
+
+func TestSessionModulePause(t *testing.T) {
+	s, err := NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	m := NewSessionModule("net.sniff", s.Session)
+	if err := m.SetPaused(true); err != ErrAlreadyStopped {
+		t.Fatalf("expected '%v', got '%v'", ErrAlreadyStopped, err)
+	}
+
+	m.SetRunning(true, nil)
+	if err := m.SetPaused(false); err != ErrNotPaused {
+		t.Fatalf("expected '%v', got '%v'", ErrNotPaused, err)
+	} else if err := m.SetPaused(true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !m.Paused() || !m.Running() {
+		t.Fatal("expected module to be running and paused")
+	} else if err := m.SetPaused(true); err != ErrAlreadyPaused {
+		t.Fatalf("expected '%v', got '%v'", ErrAlreadyPaused, err)
+	}
+
+	if raw, err := json.Marshal(&m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !strings.Contains(string(raw), `"paused":true`) {
+		t.Fatalf("expected paused state in '%s'", raw)
+	}
+
+	if err := m.SetPaused(false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if m.Paused() {
+		t.Fatal("expected module to be resumed")
+	}
+
+	m.SetPaused(true)
+	m.SetRunning(false, nil)
+	if m.Paused() {
+		t.Fatal("expected stopping the module to clear the paused state")
+	}
+}
+
+func TestSessionModuleSortedParams(t *testing.T) {
+	env, err := NewEnvironment("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m := NewSessionModule("arp.spoof", &Session{Env: env})
+	for _, name := range []string{"arp.spoof.whitelist", "arp.spoof.auto", "arp.spoof.targets", "arp.spoof.internal", "arp.spoof.filter"} {
+		m.AddParam(NewStringParameter(name, "", "", "Dummy."))
+	}
+
+	exp := []string{"arp.spoof.auto", "arp.spoof.filter", "arp.spoof.internal", "arp.spoof.targets", "arp.spoof.whitelist"}
+	// map iteration order changes every time
+	for i := 0; i < 20; i++ {
+		got := make([]string, 0)
+		for _, p := range sortedParams(m.Parameters()) {
+			got = append(got, p.Name)
+		}
+		if strings.Join(got, ",") != strings.Join(exp, ",") {
+			t.Fatalf("expected '%v', got '%v'", exp, got)
+		}
+
+		for j, p := range m.Params() {
+			if p.Name != exp[j] {
+				t.Fatalf("expected '%s', got '%s'", exp[j], p.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package session
+
+import (
+	"github.com/bettercap/bettercap/network"
+)
+
+// updateGateway applies the gateway found after a refresh, the endpoint
+// is updated in place as the LAN references it.
+func (s *Session) updateGateway(gw *network.Endpoint) {
+	if gw == nil || gw == s.Interface || gw.IpAddress == s.Interface.IpAddress {
+		return
+	} else if s.Gateway == nil || s.Gateway == s.Interface {
+		s.Gateway = gw
+	} else {
+		s.Gateway.SetIP(gw.IpAddress)
+		s.Gateway.HW = gw.HW
+		s.Gateway.HwAddress = gw.HwAddress
+		s.Gateway.Vendor = gw.Vendor
+	}
+
+	s.Env.Set("gateway.address", s.Gateway.IpAddress)
+	s.Env.Set("gateway.mac", s.Gateway.HwAddress)
+}
+
+// RefreshNetwork reads the addresses of the interface and looks the
+// gateway up again, as both can change with a new DHCP lease.
+func (s *Session) RefreshNetwork() error {
+	if err := s.Interface.Refresh(); err != nil {
+		return err
+	}
+
+	s.Env.Set("iface.ipv4", s.Interface.IpAddress)
+	s.Env.Set("iface.ipv6", s.Interface.Ip6Address)
+	s.Env.Set("iface.mac", s.Interface.HwAddress)
+
+	gw, err := network.FindGateway(s.Interface)
+	if err != nil {
+		return err
+	}
+	s.updateGateway(gw)
+	return nil
+}
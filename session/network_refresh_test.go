@@ -0,0 +1,42 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/bettercap/bettercap/network"
+)
+
+func TestSessionUpdateGateway(t *testing.T) {
+	s, err := NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	gateway := s.Gateway
+	s.updateGateway(network.NewEndpointNoResolve("10.0.0.1", "00:11:22:aa:bb:cc", "", 0))
+
+	if s.Gateway != gateway {
+		t.Fatal("expected the gateway endpoint to be updated in place")
+	} else if s.Gateway.IpAddress != "10.0.0.1" || s.Gateway.HwAddress != "00:11:22:aa:bb:cc" {
+		t.Fatalf("expected '10.0.0.1 00:11:22:aa:bb:cc', got '%s %s'", s.Gateway.IpAddress, s.Gateway.HwAddress)
+	} else if _, v := s.Env.Get("gateway.address"); v != "10.0.0.1" {
+		t.Fatalf("expected '10.0.0.1', got '%s'", v)
+	} else if _, v := s.Env.Get("gateway.mac"); v != "00:11:22:aa:bb:cc" {
+		t.Fatalf("expected '00:11:22:aa:bb:cc', got '%s'", v)
+	}
+
+	// not a gateway
+	s.updateGateway(nil)
+	s.updateGateway(s.Interface)
+	if s.Gateway != gateway || s.Gateway.IpAddress != "10.0.0.1" {
+		t.Fatalf("expected the gateway to be kept, got '%s'", s.Gateway.IpAddress)
+	}
+
+	// found after the session started without one
+	s.Gateway = s.Interface
+	s.updateGateway(gateway)
+	if s.Gateway != gateway {
+		t.Fatalf("expected the new gateway, got '%s'", s.Gateway.IpAddress)
+	}
+}
@@ -0,0 +1,124 @@
+package session
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/network"
+)
+
+const (
+	// colon separated directories caplets are searched in, in order
+	CapletsPathsVariable = "paths.caplets"
+	// colon separated directories data files like network.OuiFileName
+	// are searched in, in order
+	DataPathsVariable = "paths.data"
+)
+
+// defaultCapletPaths returns CapPaths followed by the ones in $CAPSPATH.
+func defaultCapletPaths() []string {
+	paths := append([]string{}, CapPaths...)
+	for _, path := range core.SepSplit(core.Trim(os.Getenv("CAPSPATH")), ":") {
+		if path = core.Trim(path); len(path) > 0 {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// userConfigDir returns $XDG_CONFIG_HOME or ~/.config, where the history,
+// the autostart list and the data overrides are kept. os.UserConfigDir
+// is not available on the older Go versions we support.
+func userConfigDir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return dir, nil
+	} else if home := os.Getenv("HOME"); home != "" {
+		return filepath.Join(home, ".config"), nil
+	}
+	return "", errors.New("neither $XDG_CONFIG_HOME nor $HOME are defined")
+}
+
+// defaultDataPaths returns the user folder, for personal overrides, and
+// then the system wide one.
+func defaultDataPaths() []string {
+	paths := []string{}
+	if configDir, err := userConfigDir(); err == nil && configDir != "" {
+		paths = append(paths, filepath.Join(configDir, "bettercap"))
+	}
+	return append(paths, "/usr/share/bettercap/")
+}
+
+// splitPaths parses a colon separated list of directories, expanding ~.
+func splitPaths(value string) []string {
+	paths := make([]string, 0)
+	for _, path := range core.SepSplit(value, ":") {
+		if expanded, err := core.ExpandPath(path); err == nil && expanded != "" {
+			path = expanded
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// findInPaths returns the first of the paths containing name.
+func findInPaths(paths []string, name string) (string, bool) {
+	for _, path := range paths {
+		fileName := filepath.Join(path, name)
+		if core.Exists(fileName) {
+			return fileName, true
+		}
+	}
+	return "", false
+}
+
+// CapletPaths returns the directories of paths.caplets.
+func (s *Session) CapletPaths() []string {
+	if found, value := s.Env.Get(CapletsPathsVariable); found && core.Trim(value) != "" {
+		return splitPaths(value)
+	}
+	return defaultCapletPaths()
+}
+
+// FindCaplet resolves the name of a caplet, with or without the .cap
+// extension, to the first match in paths.caplets.
+func (s *Session) FindCaplet(name string) (string, bool) {
+	if !strings.HasSuffix(name, ".cap") {
+		name += ".cap"
+	}
+	if fileName, found := findInPaths(s.CapletPaths(), name); found {
+		s.Events.Log(core.DEBUG, "Caplet %s loaded from %s", name, fileName)
+		return fileName, true
+	}
+	return "", false
+}
+
+// loadDataFiles loads the overrides of the builtin network data from the
+// first of the paths containing them.
+func (s *Session) loadDataFiles(value string) {
+	fileName, found := findInPaths(splitPaths(value), network.OuiFileName)
+	if !found {
+		network.ResetOuiOverrides()
+		return
+	}
+
+	if n, err := network.LoadOuiOverrides(fileName); err != nil {
+		s.Events.Log(core.WARNING, "Could not load %s: %s", fileName, err)
+	} else {
+		s.Events.Log(core.DEBUG, "%d vendors loaded from %s", n, fileName)
+	}
+}
+
+func (s *Session) setupPaths() {
+	if found, v := s.Env.Get(CapletsPathsVariable); !found || v == "" {
+		s.Env.Set(CapletsPathsVariable, strings.Join(defaultCapletPaths(), ":"))
+	}
+
+	data := strings.Join(defaultDataPaths(), ":")
+	if found, v := s.Env.Get(DataPathsVariable); found && v != "" {
+		data = v
+	}
+	s.Env.WithCallback(DataPathsVariable, data, s.loadDataFiles)
+}
@@ -0,0 +1,135 @@
+package session
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bettercap/bettercap/network"
+)
+
+func TestSessionCapletPaths(t *testing.T) {
+	base, err := ioutil.TempDir("", "bettercap-paths")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(base)
+
+	personal := filepath.Join(base, "personal")
+	shared := filepath.Join(base, "shared")
+	files := map[string]string{
+		filepath.Join(personal, "recon.cap"):      "set a personal",
+		filepath.Join(shared, "recon.cap"):        "set a shared",
+		filepath.Join(shared, "team", "mitm.cap"): "set b shared",
+	}
+	for fileName, data := range files {
+		if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if err := ioutil.WriteFile(fileName, []byte(data+"\n"), 0644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	s, err := NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+	s.registerCoreHandlers()
+
+	if err := s.Run("set " + CapletsPathsVariable + " " + personal + ":" + shared); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var units = []struct {
+		name string
+		exp  string
+	}{
+		{"recon", filepath.Join(personal, "recon.cap")},
+		{"recon.cap", filepath.Join(personal, "recon.cap")},
+		{"team/mitm", filepath.Join(shared, "team", "mitm.cap")},
+		{"nope", ""},
+	}
+
+	for _, u := range units {
+		if got, _ := s.FindCaplet(u.name); got != u.exp {
+			t.Fatalf("expected '%s', got '%s'", u.exp, got)
+		}
+	}
+
+	if err := s.Run("recon"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if _, v := s.Env.Get("a"); v != "personal" {
+		t.Fatalf("expected 'personal', got '%s'", v)
+	} else if err := s.RunCaplet("team/mitm"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if _, v := s.Env.Get("b"); v != "shared" {
+		t.Fatalf("expected 'shared', got '%s'", v)
+	}
+}
+
+func TestSessionDataPaths(t *testing.T) {
+	base, err := ioutil.TempDir("", "bettercap-paths")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(base)
+	defer network.ResetOuiOverrides()
+
+	personal := filepath.Join(base, "personal")
+	shared := filepath.Join(base, "shared")
+	for dir, vendor := range map[string]string{personal: "Personal Labs", shared: "Shared Labs"} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if err := ioutil.WriteFile(filepath.Join(dir, network.OuiFileName), []byte("e0:0c:7f "+vendor+"\n"), 0644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	s, err := NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	var units = []struct {
+		paths string
+		exp   string
+	}{
+		{personal + ":" + shared, "Personal Labs"},
+		{shared + ":" + personal, "Shared Labs"},
+		{filepath.Join(base, "nope"), "Nintendo Co."},
+	}
+
+	for _, u := range units {
+		s.Env.Set(DataPathsVariable, u.paths)
+		if got := network.OuiLookup("e0:0c:7f:00:00:01"); got != u.exp {
+			t.Fatalf("expected '%s', got '%s'", u.exp, got)
+		}
+	}
+}
+
+func TestSessionUserConfigDir(t *testing.T) {
+	xdg, home := os.Getenv("XDG_CONFIG_HOME"), os.Getenv("HOME")
+	defer func() {
+		os.Setenv("XDG_CONFIG_HOME", xdg)
+		os.Setenv("HOME", home)
+	}()
+
+	os.Setenv("HOME", "/home/test")
+	os.Setenv("XDG_CONFIG_HOME", "/tmp/config")
+	if dir, err := userConfigDir(); err != nil || dir != "/tmp/config" {
+		t.Fatalf("expected '/tmp/config', got '%s' (%v)", dir, err)
+	}
+
+	os.Setenv("XDG_CONFIG_HOME", "")
+	if dir, err := userConfigDir(); err != nil || dir != filepath.Join("/home/test", ".config") {
+		t.Fatalf("expected '/home/test/.config', got '%s' (%v)", dir, err)
+	}
+
+	os.Setenv("HOME", "")
+	if _, err := userConfigDir(); err == nil {
+		t.Fatal("expected an error without $HOME")
+	}
+}
@@ -0,0 +1,97 @@
+package session
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/bettercap/bettercap/core"
+)
+
+type Capability string
+
+const (
+	CapNetAdmin Capability = "CAP_NET_ADMIN"
+	CapNetRaw   Capability = "CAP_NET_RAW"
+)
+
+var allCapabilities = []Capability{CapNetAdmin, CapNetRaw}
+
+// bits of the Linux capabilities in the CapEff mask
+var capabilityBits = map[Capability]uint{
+	CapNetAdmin: 12,
+	CapNetRaw:   13,
+}
+
+// Privileges are the ones of the process, detected when the session
+// starts so that privileged modules can refuse to start without them.
+type Privileges struct {
+	// false if they could not be detected, nothing is refused then
+	Known bool `json:"known"`
+	Root  bool `json:"root"`
+	// effective capabilities on Linux, elsewhere root has all of them
+	Capabilities []Capability `json:"capabilities"`
+}
+
+func (p Privileges) Has(c Capability) bool {
+	if !p.Known {
+		return true
+	}
+
+	for _, have := range p.Capabilities {
+		if have == c {
+			return true
+		}
+	}
+	return false
+}
+
+// Missing returns the capabilities among the given ones the process lacks.
+func (p Privileges) Missing(caps ...Capability) []Capability {
+	missing := make([]Capability, 0)
+	for _, c := range caps {
+		if !p.Has(c) {
+			missing = append(missing, c)
+		}
+	}
+	return missing
+}
+
+// parses the effective capabilities out of /proc/self/status
+func parseCapEff(status string) ([]Capability, error) {
+	for _, line := range strings.Split(status, "\n") {
+		if parts := strings.SplitN(line, ":", 2); len(parts) == 2 && parts[0] == "CapEff" {
+			mask, err := strconv.ParseUint(core.Trim(parts[1]), 16, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Could not parse CapEff '%s': %s", core.Trim(parts[1]), err)
+			}
+
+			caps := make([]Capability, 0)
+			for _, c := range allCapabilities {
+				if mask&(1<<capabilityBits[c]) != 0 {
+					caps = append(caps, c)
+				}
+			}
+			return caps, nil
+		}
+	}
+	return nil, fmt.Errorf("CapEff not found.")
+}
+
+// RequirePrivileges returns an error naming what's missing if the module
+// can't run with the privileges of the process.
+func (s *Session) RequirePrivileges(module string, caps ...Capability) error {
+	missing := s.Privileges.Missing(caps...)
+	if len(missing) == 0 {
+		return nil
+	} else if runtime.GOOS != "linux" && runtime.GOOS != "android" {
+		return fmt.Errorf("Insufficient privileges to start %s: need root.", module)
+	}
+
+	names := make([]string, 0, len(missing))
+	for _, c := range missing {
+		names = append(names, string(c))
+	}
+	return fmt.Errorf("Insufficient privileges to start %s: need %s.", module, strings.Join(names, ", "))
+}
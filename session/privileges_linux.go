@@ -0,0 +1,20 @@
+package session
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+func DetectPrivileges() Privileges {
+	p := Privileges{Root: os.Geteuid() == 0}
+
+	raw, err := ioutil.ReadFile("/proc/self/status")
+	if err != nil {
+		return p
+	} else if p.Capabilities, err = parseCapEff(string(raw)); err != nil {
+		return p
+	}
+
+	p.Known = true
+	return p
+}
@@ -0,0 +1,19 @@
+// +build !linux
+
+package session
+
+import "os"
+
+func DetectPrivileges() Privileges {
+	euid := os.Geteuid()
+	// -1 on Windows, where it can't be told
+	p := Privileges{
+		Known:        euid >= 0,
+		Root:         euid == 0,
+		Capabilities: make([]Capability, 0),
+	}
+	if p.Root {
+		p.Capabilities = append(p.Capabilities, allCapabilities...)
+	}
+	return p
+}
@@ -0,0 +1,61 @@
+package session
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestParseCapEff(t *testing.T) {
+	var units = []struct {
+		status   string
+		expected []Capability
+		err      bool
+	}{
+		{"Name:\tbettercap\nCapEff:\t0000003fffffffff\n", []Capability{CapNetAdmin, CapNetRaw}, false},
+		{"CapEff:\t0000000000002000\n", []Capability{CapNetRaw}, false},
+		{"CapEff:\t0000000000000000\n", []Capability{}, false},
+		{"CapEff:\tnope\n", nil, true},
+		{"Name:\tbettercap\n", nil, true},
+	}
+
+	for _, u := range units {
+		got, err := parseCapEff(u.status)
+		if u.err {
+			if err == nil {
+				t.Fatalf("expected error for '%s'", u.status)
+			}
+		} else if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if !reflect.DeepEqual(got, u.expected) {
+			t.Fatalf("expected '%v', got '%v'", u.expected, got)
+		}
+	}
+}
+
+func TestSessionRequirePrivileges(t *testing.T) {
+	s, err := NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	// nothing is refused if they can't be detected
+	if err := s.RequirePrivileges("mac.changer", CapNetAdmin); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s.Privileges = Privileges{Known: true, Capabilities: []Capability{CapNetRaw}}
+	exp := "Insufficient privileges to start mac.changer: need CAP_NET_ADMIN."
+	if runtime.GOOS != "linux" {
+		exp = "Insufficient privileges to start mac.changer: need root."
+	}
+
+	if err := s.RequirePrivileges("net.sniff", CapNetRaw); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.RequirePrivileges("mac.changer", CapNetRaw, CapNetAdmin); err == nil {
+		t.Fatal("expected error")
+	} else if err.Error() != exp {
+		t.Fatalf("expected '%s', got '%s'", exp, err)
+	}
+}
@@ -0,0 +1,47 @@
+package session
+
+import (
+	"sync/atomic"
+)
+
+const ProgressEventTag = "progress"
+
+// ProgressEvent is published by modules performing long operations.
+type ProgressEvent struct {
+	Module  string `json:"module"`
+	Label   string `json:"label"`
+	Percent int    `json:"percent"`
+}
+
+func progressPercent(done, total int) int {
+	if total <= 0 || done >= total {
+		return 100
+	} else if done <= 0 {
+		return 0
+	}
+	return done * 100 / total
+}
+
+// StartProgress publishes the 0% event of a new operation, so that the
+// following one reaching 100% is sent even if the previous one did too.
+func (m *SessionModule) StartProgress(label string, total int) {
+	atomic.StoreInt32(&m.progress, -1)
+	m.Progress(label, 0, total)
+}
+
+// Progress publishes a progress event for the module, events are only
+// sent when the percentage changes so it's safe to call it often.
+func (m *SessionModule) Progress(label string, done, total int) {
+	percent := progressPercent(done, total)
+	if int(atomic.SwapInt32(&m.progress, int32(percent))) == percent {
+		return
+	}
+
+	if m.Session != nil && m.Session.Events != nil {
+		m.Session.Events.Add(ProgressEventTag, ProgressEvent{
+			Module:  m.Name,
+			Label:   label,
+			Percent: percent,
+		})
+	}
+}
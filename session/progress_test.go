@@ -0,0 +1,64 @@
+package session
+
+import (
+	"testing"
+)
+
+func TestProgressPercent(t *testing.T) {
+	var units = []struct {
+		done  int
+		total int
+		exp   int
+	}{
+		{0, 10, 0},
+		{-1, 10, 0},
+		{1, 3, 33},
+		{5, 10, 50},
+		{10, 10, 100},
+		{11, 10, 100},
+		{0, 0, 100},
+	}
+
+	for _, u := range units {
+		if got := progressPercent(u.done, u.total); got != u.exp {
+			t.Fatalf("expected '%d', got '%d'", u.exp, got)
+		}
+	}
+}
+
+func TestSessionModuleProgress(t *testing.T) {
+	s := &Session{Events: NewEventPool(false, true)}
+	m := NewSessionModule("syn.scan", s)
+
+	for done := 0; done <= 1000; done++ {
+		m.Progress("scanning", done, 1000)
+	}
+
+	events := s.Events.Sorted()
+	if len(events) != 101 {
+		t.Fatalf("expected 101 events, got %d", len(events))
+	}
+
+	last := events[len(events)-1]
+	if last.Tag != ProgressEventTag {
+		t.Fatalf("expected '%s', got '%s'", ProgressEventTag, last.Tag)
+	} else if p := last.Data.(ProgressEvent); p.Percent != 100 || p.Module != "syn.scan" || p.Label != "scanning" {
+		t.Fatalf("unexpected last event %+v", p)
+	}
+}
+
+func TestSessionModuleStartProgress(t *testing.T) {
+	s := &Session{Events: NewEventPool(false, true)}
+	m := NewSessionModule("net.replay", s)
+
+	// empty runs only publish 100% once each
+	for run := 0; run < 2; run++ {
+		m.StartProgress("replaying", 0)
+		m.Progress("replaying", 0, 0)
+		m.Progress("replaying", 0, 0)
+	}
+
+	if events := s.Events.Sorted(); len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+}
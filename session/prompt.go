@@ -15,24 +15,6 @@ const (
 )
 
 var (
-	// these are here because if colors are disabled,
-	// we need the updated core.* variables
-	effects = map[string]string{
-		"{bold}":  core.BOLD,
-		"{dim}":   core.DIM,
-		"{r}":     core.RED,
-		"{g}":     core.GREEN,
-		"{b}":     core.BLUE,
-		"{y}":     core.YELLOW,
-		"{fb}":    core.FG_BLACK,
-		"{fw}":    core.FG_WHITE,
-		"{bdg}":   core.BG_DGRAY,
-		"{br}":    core.BG_RED,
-		"{bg}":    core.BG_GREEN,
-		"{by}":    core.BG_YELLOW,
-		"{blb}":   core.BG_LBLUE, // Ziggy this is for you <3
-		"{reset}": core.RESET,
-	}
 	PromptCallbacks = map[string]func(s *Session) string{
 		"{cidr}": func(s *Session) string {
 			return s.Interface.CIDR()
@@ -58,6 +40,27 @@ var (
 	}
 )
 
+// effects are resolved at every render, so that
+// the prompt follows the current theme
+func effects() map[string]string {
+	return map[string]string{
+		"{bold}":  core.BOLD,
+		"{dim}":   core.DIM,
+		"{r}":     core.RED,
+		"{g}":     core.GREEN,
+		"{b}":     core.BLUE,
+		"{y}":     core.YELLOW,
+		"{fb}":    core.FG_BLACK,
+		"{fw}":    core.FG_WHITE,
+		"{bdg}":   core.BG_DGRAY,
+		"{br}":    core.BG_RED,
+		"{bg}":    core.BG_GREEN,
+		"{by}":    core.BG_YELLOW,
+		"{blb}":   core.BG_LBLUE, // Ziggy this is for you <3
+		"{reset}": core.RESET,
+	}
+}
+
 type Prompt struct {
 }
 
@@ -71,7 +74,7 @@ func (p Prompt) Render(s *Session) string {
 		prompt = DefaultPrompt
 	}
 
-	for tok, effect := range effects {
+	for tok, effect := range effects() {
 		prompt = strings.Replace(prompt, tok, effect, -1)
 	}
 
@@ -0,0 +1,52 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bettercap/bettercap/core"
+)
+
+const UIQuietVariable = "ui.quiet"
+
+// ReadyMessage is printed as a single JSON line once the session is
+// initialized in quiet mode, so that scripts know when to send commands.
+type ReadyMessage struct {
+	Ready     bool   `json:"ready"`
+	Version   string `json:"version"`
+	Interface string `json:"interface"`
+	MAC       string `json:"mac"`
+}
+
+// Quiet is true if the banner, the prompt and the logs which are not
+// errors are suppressed, either by --quiet or by ui.quiet.
+func (s *Session) Quiet() bool {
+	_, quiet := s.Env.Get(UIQuietVariable)
+	return quiet == "true"
+}
+
+func (s *Session) writeReady(w io.Writer) error {
+	if !s.Quiet() {
+		return nil
+	}
+
+	raw, err := json.Marshal(ReadyMessage{
+		Ready:     true,
+		Version:   core.Version,
+		Interface: s.Interface.Name(),
+		MAC:       s.Interface.HW.String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "%s\n", raw)
+	return err
+}
+
+// Ready prints the ready line if the session is quiet.
+func (s *Session) Ready() error {
+	return s.writeReady(os.Stdout)
+}
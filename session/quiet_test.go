@@ -0,0 +1,49 @@
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/bettercap/bettercap/core"
+)
+
+func TestSessionQuiet(t *testing.T) {
+	s, err := NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	buf := bytes.Buffer{}
+	if s.Quiet() {
+		t.Fatal("expected session not to be quiet by default")
+	} else if err := s.writeReady(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if buf.Len() > 0 {
+		t.Fatalf("expected no ready line, got '%s'", buf.String())
+	}
+
+	s.Env.Set("log.silent", "false")
+	s.Env.Set(UIQuietVariable, "true")
+	if !s.Quiet() {
+		t.Fatal("expected session to be quiet")
+	} else if !s.Events.quiet {
+		t.Fatal("expected logs to be suppressed")
+	} else if err := s.writeReady(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	msg := ReadyMessage{}
+	if err := json.Unmarshal(buf.Bytes(), &msg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if exp := (ReadyMessage{true, core.Version, TestInterfaceName, TestInterfaceMAC}); msg != exp {
+		t.Fatalf("expected '%v', got '%v'", exp, msg)
+	} else if bytes.Count(buf.Bytes(), []byte("\n")) != 1 {
+		t.Fatalf("expected a single line, got '%s'", buf.String())
+	}
+
+	if s.Env.Set(UIQuietVariable, "false"); s.Events.quiet {
+		t.Fatal("expected logs not to be suppressed")
+	}
+}
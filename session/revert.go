@@ -0,0 +1,42 @@
+package session
+
+import (
+	"github.com/bettercap/bettercap/core"
+)
+
+// Reverter is implemented by modules which change the state of the
+// system and can restore it, Revert returns true if anything had to be
+// restored.
+type Reverter interface {
+	Revert() (bool, error)
+}
+
+// RevertAll restores the state changed by every module implementing
+// Reverter and then the firewall one, collecting the errors of all of
+// them. The modules owning redirections stop when reverted, so that
+// restoring the firewall doesn't remove the rules of a running one.
+// Only the modules which restored something and the firewall, if the
+// forwarding changed, are counted as reverted.
+func (s *Session) RevertAll() *core.MultiError {
+	result := core.NewMultiError()
+	for _, m := range s.Modules {
+		if r, ok := m.(Reverter); ok {
+			if changed, err := r.Revert(); err != nil {
+				result.Add(m.Name(), err)
+			} else if changed {
+				result.Add(m.Name(), nil)
+			}
+		}
+	}
+
+	// takes care of forwarding and redirections
+	if s.Firewall != nil {
+		forwarding := s.Firewall.IsForwardingEnabled()
+		s.Firewall.Restore()
+		if s.Firewall.IsForwardingEnabled() != forwarding {
+			result.Add("firewall", nil)
+		}
+	}
+
+	return result
+}
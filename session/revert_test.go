@@ -0,0 +1,92 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bettercap/bettercap/firewall"
+)
+
+type revertTestModule struct {
+	lockTestModule
+	name     string
+	err      error
+	running  bool
+	changed  bool
+	reverted bool
+	// set if the firewall was already restored when reverted
+	late bool
+	fw   *revertTestFirewall
+}
+
+func (m *revertTestModule) Name() string  { return m.name }
+func (m *revertTestModule) Running() bool { return m.running }
+func (m *revertTestModule) Revert() (bool, error) {
+	m.reverted = true
+	m.late = m.fw != nil && m.fw.restored
+	changed := m.running || m.changed
+	if m.err == nil {
+		m.running, m.changed = false, false
+	}
+	return changed, m.err
+}
+
+type revertTestFirewall struct {
+	forwarding bool
+	original   bool
+	restored   bool
+}
+
+func (f *revertTestFirewall) IsForwardingEnabled() bool { return f.forwarding }
+func (f *revertTestFirewall) EnableForwarding(enabled bool) error {
+	f.forwarding = enabled
+	return nil
+}
+func (f *revertTestFirewall) EnableRedirection(r *firewall.Redirection, enabled bool) error {
+	return nil
+}
+func (f *revertTestFirewall) Restore() {
+	f.forwarding = f.original
+	f.restored = true
+}
+
+func TestSessionRevertAll(t *testing.T) {
+	s, err := NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	fw := &revertTestFirewall{forwarding: true}
+	s.Firewall = fw
+	ok := &revertTestModule{name: "mac.changer", running: true}
+	failing := &revertTestModule{name: "arp.spoof", err: fmt.Errorf("no route"), running: true}
+	idle := &revertTestModule{name: "net.sniff"}
+	// restores something without being stopped
+	txpower := &revertTestModule{name: "wifi", changed: true}
+	proxy := &revertTestModule{name: "http.proxy", running: true, fw: fw}
+	other := &lockTestModule{NewSessionModule("net.probe", s.Session)}
+	s.Modules = []Module{ok, other, failing, idle, txpower, proxy}
+
+	result := s.RevertAll()
+	if !ok.reverted || !failing.reverted || !idle.reverted || !txpower.reverted || !proxy.reverted {
+		t.Fatal("expected every reverter to be called")
+	} else if proxy.late {
+		t.Fatal("expected the firewall to be restored after the modules")
+	} else if result.Succeeded != 4 {
+		t.Fatalf("expected 4 successes, got %d", result.Succeeded)
+	} else if err := result.ErrorOrNil(); err == nil {
+		t.Fatal("expected an error")
+	} else if !strings.Contains(err.Error(), "arp.spoof: no route") {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	// only arp.spoof is left running, the forwarding is unchanged
+	failing.err = nil
+	if result := s.RevertAll(); result.ErrorOrNil() != nil {
+		t.Fatalf("unexpected error: %v", result.ErrorOrNil())
+	} else if result.Succeeded != 1 {
+		t.Fatalf("expected 1 success, got %d", result.Succeeded)
+	}
+}
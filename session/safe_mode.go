@@ -0,0 +1,57 @@
+package session
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/network"
+)
+
+const SafeOverrideVariable = "safe.override"
+
+// parses the client address out of the SSH_CONNECTION or SSH_CLIENT
+// variables exported by sshd, both begin with "client_ip client_port"
+func sshClientAddress(getenv func(string) string) net.IP {
+	for _, name := range []string{"SSH_CONNECTION", "SSH_CLIENT"} {
+		if fields := strings.Fields(getenv(name)); len(fields) > 0 {
+			if ip := net.ParseIP(fields[0]); ip != nil {
+				return ip
+			}
+		}
+	}
+	return nil
+}
+
+// ManagementInterface returns the name of the interface carrying the
+// SSH connection bettercap is being controlled from and the address
+// of the SSH client, or empty values if not running over SSH.
+func (s *Session) ManagementInterface() (string, net.IP) {
+	client := sshClientAddress(os.Getenv)
+	if client == nil {
+		return "", nil
+	}
+
+	iface, err := network.FindInterfaceForAddress(client)
+	if err != nil {
+		s.Events.Log(core.DEBUG, "Could not find the interface routing to %s: %s", client, err)
+		return "", client
+	}
+
+	return iface.Name, client
+}
+
+// CheckSafe returns an error if the given module is about to disrupt
+// the interface carrying the management connection, unless the
+// safe.override variable is set to true.
+func (s *Session) CheckSafe(module string, iface string) error {
+	if _, override := s.Env.Get(SafeOverrideVariable); override == "true" {
+		return nil
+	} else if mgmt, client := s.ManagementInterface(); mgmt != "" && mgmt == iface {
+		return fmt.Errorf("Interface %s is carrying the management connection from %s, refusing to start %s (use 'set %s true' to do it anyway).",
+			core.Bold(iface), client, core.Bold(module), SafeOverrideVariable)
+	}
+	return nil
+}
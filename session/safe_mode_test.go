@@ -0,0 +1,28 @@
+package session
+
+import (
+	"net"
+	"testing"
+)
+
+func TestSessionSSHClientAddress(t *testing.T) {
+	var units = []struct {
+		env map[string]string
+		ip  net.IP
+	}{
+		{map[string]string{}, nil},
+		{map[string]string{"SSH_CONNECTION": "192.168.1.5 51234 192.168.1.1 22"}, net.ParseIP("192.168.1.5")},
+		{map[string]string{"SSH_CLIENT": "10.0.0.2 51234 22"}, net.ParseIP("10.0.0.2")},
+		{map[string]string{"SSH_CONNECTION": "fe80::1 51234 fe80::2 22", "SSH_CLIENT": "10.0.0.2 51234 22"}, net.ParseIP("fe80::1")},
+		{map[string]string{"SSH_CONNECTION": "garbage"}, nil},
+	}
+
+	for _, u := range units {
+		got := sshClientAddress(func(name string) string {
+			return u.env[name]
+		})
+		if !got.Equal(u.ip) {
+			t.Fatalf("expected '%v', got '%v'", u.ip, got)
+		}
+	}
+}
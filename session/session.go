@@ -1,13 +1,12 @@
 package session
 
 import (
-	"bufio"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"regexp"
 	"runtime"
 	"runtime/pprof"
@@ -28,7 +27,11 @@ import (
 )
 
 const (
-	HistoryFile = "~/bettercap.history"
+	// legacy location, moved to the user config folder
+	HistoryFile         = "~/bettercap.history"
+	ShowSecretsVariable = "show.secrets"
+	UIColorsVariable    = "ui.colors"
+	InjectIfaceVariable = "net.inject.iface"
 )
 
 var (
@@ -36,6 +39,8 @@ var (
 
 	ErrAlreadyStarted = errors.New("Module is already running.")
 	ErrAlreadyStopped = errors.New("Module is not running.")
+	ErrAlreadyPaused  = errors.New("Module is already paused.")
+	ErrNotPaused      = errors.New("Module is not paused.")
 	ErrNotSupported   = errors.New("This component is not supported on this OS.")
 
 	reCmdSpaceCleaner = regexp.MustCompile(`^([^\s]+)\s+(.+)$`)
@@ -58,18 +63,28 @@ type Session struct {
 	Lan       *network.LAN             `json:"lan"`
 	WiFi      *network.WiFi            `json:"wifi"`
 	BLE       *network.BLE             `json:"ble"`
+	HID       *network.HID             `json:"hid"`
 	Queue     *packets.Queue           `json:"packets"`
 	Input     *readline.Instance       `json:"-"`
 	StartedAt time.Time                `json:"started_at"`
 	Active    bool                     `json:"active"`
 	GPS       nmea.GNGGA               `json:"gps"`
 	Prompt    Prompt                   `json:"-"`
+	// detected when the session starts
+	Privileges Privileges `json:"privileges"`
 
 	CoreHandlers []CommandHandler `json:"-"`
 	Modules      []Module         `json:"-"`
 
 	Events *EventPool `json:"-"`
 
+	completers *completers
+	locks      *paramLocks
+	tx         *paramTransaction
+	autostart  *autoStart
+	// if set, confirmations are read from here instead of readline
+	confirmInput io.Reader
+
 	UnkCmdCallback UnknownCommandCallback `json:"-"`
 }
 
@@ -150,6 +165,10 @@ func New() (*Session, error) {
 		Modules:        make([]Module, 0),
 		Events:         nil,
 		UnkCmdCallback: nil,
+
+		completers: newCompleters(),
+		locks:      newParamLocks(),
+		tx:         newParamTransaction(),
 	}
 
 	if s.Options, err = core.ParseOptions(); err != nil {
@@ -170,7 +189,16 @@ func New() (*Session, error) {
 		return nil, err
 	}
 
+	if *s.Options.Quiet {
+		s.Env.Set(UIQuietVariable, "true")
+	}
+
 	s.Events = NewEventPool(*s.Options.Debug, *s.Options.Silent)
+	s.Events.SetQuiet(s.Quiet())
+
+	if s.autostart, err = newAutoStart(autostartFile()); err != nil {
+		return nil, err
+	}
 
 	s.registerCoreHandlers()
 
@@ -220,13 +248,7 @@ func (s *Session) setupReadline() error {
 		}
 	}
 
-	for _, path := range core.SepSplit(core.Trim(os.Getenv("CAPSPATH")), ":") {
-		if path = core.Trim(path); len(path) > 0 {
-			CapPaths = append(CapPaths, path)
-		}
-	}
-
-	for _, path := range CapPaths {
+	for _, path := range s.CapletPaths() {
 		buildCapletsTree(path, tree)
 	}
 
@@ -243,14 +265,18 @@ func (s *Session) setupReadline() error {
 
 	history := ""
 	if !*s.Options.NoHistory {
-		history, _ = core.ExpandPath(HistoryFile)
+		history = historyFile()
 	}
 
 	cfg := readline.Config{
-		HistoryFile:     history,
-		InterruptPrompt: "^C",
-		EOFPrompt:       "exit",
-		AutoComplete:    readline.NewPrefixCompleter(pcompleters...),
+		HistoryFile: history,
+		// lines are saved by ReadLine, skipping the secrets
+		DisableAutoSaveHistory: true,
+		// ctrl+r searches are case insensitive
+		HistorySearchFold: true,
+		InterruptPrompt:   "^C",
+		EOFPrompt:         "exit",
+		AutoComplete:      readline.NewPrefixCompleter(pcompleters...),
 	}
 
 	s.Input, err = readline.NewEx(&cfg)
@@ -293,7 +319,10 @@ func (s *Session) Close() {
 		}
 	}
 
-	s.Firewall.Restore()
+	// also restores the firewall state
+	if err := s.RevertAll().ErrorOrNil(); err != nil {
+		fmt.Printf("Error while reverting the system changes: %s\n", err)
+	}
 
 	if *s.Options.EnvFile != "" {
 		envFile, _ := core.ExpandPath(*s.Options.EnvFile)
@@ -333,13 +362,21 @@ func (s *Session) startNetMon() {
 				return
 			}
 
-			if s.IsOn("net.recon") && event.Source {
+			if s.IsOn("net.recon") {
 				addr := event.IP.String()
-				mac := event.MAC.String()
+				mac := network.NormalizeMac(event.MAC.String())
+
+				if event.Source {
+					existing := s.Lan.AddIfNew(addr, mac)
+					if existing != nil {
+						existing.LastSeen = time.Now()
+					}
+				}
 
-				existing := s.Lan.AddIfNew(addr, mac)
-				if existing != nil {
-					existing.LastSeen = time.Now()
+				if mac == s.Gateway.HwAddress {
+					s.Gateway.Traffic.Track(event.Size, event.Source)
+				} else if e, found := s.Lan.Get(mac); found {
+					e.Traffic.Track(event.Size, event.Source)
 				}
 			}
 		}
@@ -368,10 +405,24 @@ func (s *Session) setupEnv() {
 	s.Env.Set("gateway.address", s.Gateway.IpAddress)
 	s.Env.Set("gateway.mac", s.Gateway.HwAddress)
 
+	s.setupPaths()
+
 	if found, v := s.Env.Get(PromptVariable); !found || v == "" {
 		s.Env.Set(PromptVariable, DefaultPrompt)
 	}
 
+	if found, _ := s.Env.Get(ShowSecretsVariable); !found {
+		s.Env.Set(ShowSecretsVariable, "false")
+	}
+
+	if found, _ := s.Env.Get(SafeOverrideVariable); !found {
+		s.Env.Set(SafeOverrideVariable, "false")
+	}
+
+	if found, _ := s.Env.Get(ConfirmDangerousVariable); !found {
+		s.Env.Set(ConfirmDangerousVariable, "false")
+	}
+
 	dbg := "false"
 	if *s.Options.Debug {
 		dbg = "true"
@@ -395,6 +446,33 @@ func (s *Session) setupEnv() {
 		}
 		s.Events.SetSilent(newSilent)
 	})
+
+	// colors disabled by the command line or the terminal can still
+	// be turned back on, otherwise a previously saved theme is kept
+	colors := "off"
+	if core.HasColors {
+		colors = "on"
+		if found, v := s.Env.Get(UIColorsVariable); found {
+			if _, err := core.ThemeFor(v); err == nil {
+				colors = v
+			}
+		}
+	}
+	s.Env.WithCallback(UIColorsVariable, colors, func(newValue string) {
+		// invalid values are rejected by SetParam
+		core.SetColors(newValue)
+	})
+	s.RegisterCompleter(UIColorsVariable, func(prefix string) []string {
+		return append([]string{"on"}, core.ThemeNames()...)
+	})
+
+	quiet := "false"
+	if s.Quiet() {
+		quiet = "true"
+	}
+	s.Env.WithCallback(UIQuietVariable, quiet, func(newValue string) {
+		s.Events.SetQuiet(newValue == "true")
+	})
 }
 
 func (s *Session) Start() error {
@@ -405,11 +483,19 @@ func (s *Session) Start() error {
 		return s.Modules[i].Name() < s.Modules[j].Name()
 	})
 
+	s.Privileges = DetectPrivileges()
+	if missing := s.Privileges.Missing(allCapabilities...); len(missing) > 0 {
+		s.Events.Log(core.WARNING, "Running without %v, the modules needing them won't start.", missing)
+	}
+
 	if s.Interface, err = network.FindInterface(*s.Options.InterfaceName); err != nil {
 		return err
 	}
 
 	if s.Queue, err = packets.NewQueue(s.Interface); err != nil {
+		if !s.Privileges.Has(CapNetRaw) {
+			return fmt.Errorf("Insufficient privileges to capture on %s: need %s (%s).", s.Interface.Name(), CapNetRaw, err)
+		}
 		return err
 	}
 
@@ -429,6 +515,12 @@ func (s *Session) Start() error {
 		s.Events.Add("ble.device.lost", dev)
 	})
 
+	s.HID = network.NewHID(func(dev *network.HIDDevice) {
+		s.Events.Add("hid.device.new", dev)
+	}, func(dev *network.HIDDevice) {
+		s.Events.Add("hid.device.lost", dev)
+	})
+
 	s.WiFi = network.NewWiFi(s.Interface, func(ap *network.AccessPoint) {
 		s.Events.Add("wifi.ap.new", ap)
 	}, func(ap *network.AccessPoint) {
@@ -472,6 +564,11 @@ func (s *Session) Skip(ip net.IP) bool {
 	return false
 }
 
+func (s *Session) ShowSecrets() bool {
+	_, v := s.Env.Get(ShowSecretsVariable)
+	return v == "true"
+}
+
 func (s *Session) IsOn(moduleName string) bool {
 	for _, m := range s.Modules {
 		if m.Name() == moduleName {
@@ -495,95 +592,47 @@ func (s *Session) parseEnvTokens(str string) (string, error) {
 }
 
 func (s *Session) Refresh() {
-	p, _ := s.parseEnvTokens(s.Prompt.Render(s))
+	p := ""
+	if !s.Quiet() {
+		p, _ = s.parseEnvTokens(s.Prompt.Render(s))
+	}
 	s.Input.SetPrompt(p)
 	s.Input.Refresh()
 }
 
 func (s *Session) ReadLine() (string, error) {
 	s.Refresh()
-	return s.Input.Readline()
-}
-
-func (s *Session) RunCaplet(filename string) error {
-	s.Events.Log(core.INFO, "Reading from caplet %s ...", filename)
-
-	input, err := os.Open(filename)
-	if err != nil {
-		return err
+	line, err := s.Input.Readline()
+	if err == nil {
+		s.saveHistory(line)
 	}
-	defer input.Close()
-
-	scanner := bufio.NewScanner(input)
-	scanner.Split(bufio.ScanLines)
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" || line[0] == '#' {
-			continue
-		}
-
-		if err = s.Run(line); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return line, err
 }
 
-func (s *Session) isCapletCommand(line string) (is bool, filename string, argv []string) {
-	file := core.Trim(line)
-	parts := strings.Split(file, " ")
-	argc := len(parts)
-	argv = make([]string, 0)
-	// check for any arguments
-	if argc > 1 {
-		file = core.Trim(parts[0])
-		if argc >= 2 {
-			argv = parts[1:]
-		}
-	}
+// CommandOrigin is where a command comes from, only the ones typed at
+// the console can be confirmed interactively.
+type CommandOrigin string
 
-	for _, path := range CapPaths {
-		filename := filepath.Join(path, file) + ".cap"
-		if core.Exists(filename) {
-			return true, filename, argv
-		}
-	}
-
-	return false, "", nil
-}
-
-func (s *Session) runCapletCommand(filename string, argv []string) error {
-	input, err := os.Open(filename)
-	if err != nil {
-		return err
-	}
-	defer input.Close()
-
-	scanner := bufio.NewScanner(input)
-	scanner.Split(bufio.ScanLines)
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" || line[0] == '#' {
-			continue
-		}
-
-		// replace $0 with argv[0], $1 with argv[1] and so on
-		for i, arg := range argv {
-			line = strings.Replace(line, fmt.Sprintf("$%d", i), arg, -1)
-		}
-
-		if err = s.Run(line); err != nil {
-			return err
-		}
-	}
+const (
+	OriginConsole CommandOrigin = "console"
+	// -eval and -autostart
+	OriginCommandLine CommandOrigin = "command line"
+	OriginCaplet      CommandOrigin = "caplet"
+	OriginAPI         CommandOrigin = "api"
+	OriginRPC         CommandOrigin = "rpc"
+	OriginTicker      CommandOrigin = "ticker"
+	// every other caller of Run
+	OriginInternal CommandOrigin = "session"
+)
 
-	return nil
+// Run executes line as a command not typed at the console.
+func (s *Session) Run(line string) error {
+	return s.RunFrom(OriginInternal, line)
 }
 
-func (s *Session) Run(line string) error {
+// RunFrom executes line, origin tells whether the dangerous commands
+// can be confirmed by the user.
+func (s *Session) RunFrom(origin CommandOrigin, line string) error {
 	line = core.TrimRight(line)
 	// remove extra spaces after the first command
 	// so that 'arp.spoof      on' is normalized
@@ -606,6 +655,11 @@ func (s *Session) Run(line string) error {
 	for _, m := range s.Modules {
 		for _, h := range m.Handlers() {
 			if parsed, args := h.Parse(line); parsed {
+				if h.Dangerous {
+					if err := s.ConfirmDangerous(origin, line); err != nil {
+						return err
+					}
+				}
 				return h.Exec(args)
 			}
 		}
@@ -613,7 +667,7 @@ func (s *Session) Run(line string) error {
 
 	// is it a caplet command?
 	if is, filename, argv := s.isCapletCommand(line); is {
-		return s.runCapletCommand(filename, argv)
+		return s.runCaplet(filename, argv)
 	}
 
 	// is it a proxy module custom command?
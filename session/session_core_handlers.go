@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -44,18 +43,25 @@ func (s *Session) generalHelp() {
 	pad = "%" + strconv.Itoa(maxLen) + "s"
 
 	for _, m := range s.Modules {
-		status := ""
-		if m.Running() {
-			status = core.Green("running")
-		} else {
-			status = core.Red("not running")
-		}
-		fmt.Printf("  "+core.Yellow(pad)+" > %s\n", m.Name(), status)
+		fmt.Printf("  "+core.Yellow(pad)+" > %s\n", m.Name(), moduleStatus(m))
 	}
 
 	fmt.Println()
 }
 
+func moduleStatus(m Module) string {
+	if m.Paused() {
+		return core.Yellow("paused")
+	} else if !m.Running() {
+		return core.Red("not running")
+	} else if d, ok := m.(StatusDetailer); ok {
+		if detail := d.StatusDetail(); detail != "" {
+			return core.Green("running") + " " + core.Dim(detail)
+		}
+	}
+	return core.Green("running")
+}
+
 func (s *Session) moduleHelp(filter string) error {
 	err, m := s.Module(filter)
 	if err != nil {
@@ -63,13 +69,7 @@ func (s *Session) moduleHelp(filter string) error {
 	}
 
 	fmt.Println()
-	status := ""
-	if m.Running() {
-		status = core.Green("running")
-	} else {
-		status = core.Red("not running")
-	}
-	fmt.Printf("%s (%s): %s\n\n", core.Yellow(m.Name()), status, core.Dim(m.Description()))
+	fmt.Printf("%s (%s): %s\n\n", core.Yellow(m.Name()), moduleStatus(m), core.Dim(m.Description()))
 
 	maxLen := 0
 	handlers := m.Handlers()
@@ -85,22 +85,16 @@ func (s *Session) moduleHelp(filter string) error {
 	}
 	fmt.Println()
 
-	params := m.Parameters()
-	if len(params) > 0 {
-		v := make([]*ModuleParam, 0)
+	v := sortedParams(m.Parameters())
+	if len(v) > 0 {
 		maxLen := 0
-		for _, h := range params {
+		for _, h := range v {
 			len := len(h.Name)
 			if len > maxLen {
 				maxLen = len
 			}
-			v = append(v, h)
 		}
 
-		sort.Slice(v, func(i, j int) bool {
-			return v[i].Name < v[j].Name
-		})
-
 		fmt.Print("  Parameters\n\n")
 		for _, p := range v {
 			fmt.Print(p.Help(maxLen))
@@ -135,7 +129,7 @@ func (s *Session) activeHandler(args []string, sess *Session) error {
 		}
 
 		fmt.Printf("%s (%s)\n", core.Bold(m.Name()), core.Dim(m.Description()))
-		params := m.Parameters()
+		params := sortedParams(m.Parameters())
 		if len(params) > 0 {
 			fmt.Println()
 			for _, p := range params {
@@ -225,7 +219,69 @@ func (s *Session) setHandler(args []string, sess *Session) error {
 		value = ""
 	}
 
-	s.Env.Set(key, value)
+	if s.InTransaction() {
+		s.bufferParam(key, value)
+		return nil
+	}
+
+	return s.SetParam(key, value)
+}
+
+func (s *Session) configLoadHandler(args []string, sess *Session) error {
+	return s.LoadConfig(core.Trim(args[0]))
+}
+
+func (s *Session) sessionSaveHandler(args []string, sess *Session) error {
+	return s.SaveState(core.Trim(args[0]))
+}
+
+func (s *Session) sessionRestoreHandler(args []string, sess *Session) error {
+	return s.RestoreState(core.Trim(args[0]))
+}
+
+func (s *Session) autostartHandler(args []string, sess *Session) error {
+	switch args[1] {
+	case "add":
+		return s.AutoStartAdd(args[2])
+	case "remove":
+		return s.AutoStartRemove(args[2])
+	}
+
+	names, err := s.AutoStartModules()
+	if err != nil {
+		return err
+	} else if len(names) == 0 {
+		fmt.Println("No modules are started with the session, use 'autostart add MODULE' to add one.")
+	} else {
+		fmt.Printf("Modules started with the session: %s\n", strings.Join(names, ", "))
+	}
+	return nil
+}
+
+func (s *Session) lockHandler(args []string, sess *Session) error {
+	if names, err := s.Lock(args[0]); err != nil {
+		return err
+	} else {
+		fmt.Printf("Locked %s.\n", strings.Join(names, ", "))
+	}
+	return nil
+}
+
+func (s *Session) unlockHandler(args []string, sess *Session) error {
+	if names, err := s.Unlock(args[0]); err != nil {
+		return err
+	} else {
+		fmt.Printf("Unlocked %s.\n", strings.Join(names, ", "))
+	}
+	return nil
+}
+
+func (s *Session) revertAllHandler(args []string, sess *Session) error {
+	result := s.RevertAll()
+	if err := result.ErrorOrNil(); err != nil {
+		return err
+	}
+	fmt.Printf("Reverted %d system changes.\n", result.Succeeded)
 	return nil
 }
 
@@ -233,6 +289,10 @@ func (s *Session) readHandler(args []string, sess *Session) error {
 	key := args[0]
 	prompt := args[1]
 
+	if s.IsLocked(key) {
+		return errLocked(key)
+	}
+
 	reader := bufio.NewReader(os.Stdin)
 	fmt.Printf("%s ", prompt)
 
@@ -242,8 +302,12 @@ func (s *Session) readHandler(args []string, sess *Session) error {
 		value = ""
 	}
 
-	s.Env.Set(key, value)
-	return nil
+	if s.InTransaction() {
+		s.bufferParam(key, value)
+		return nil
+	}
+
+	return s.SetParam(key, value)
 }
 
 func (s *Session) clsHandler(args []string, sess *Session) error {
@@ -258,7 +322,8 @@ func (s *Session) clsHandler(args []string, sess *Session) error {
 }
 
 func (s *Session) includeHandler(args []string, sess *Session) error {
-	return s.RunCaplet(args[0])
+	argv := strings.Fields(args[0])
+	return s.RunCaplet(argv[0], argv[1:]...)
 }
 
 func (s *Session) shHandler(args []string, sess *Session) error {
@@ -286,6 +351,13 @@ func (s *Session) addHandler(h CommandHandler, c *readline.PrefixCompleter) {
 }
 
 func (s *Session) registerCoreHandlers() {
+	// before help, which would parse it as the help of the .json module
+	s.addHandler(NewCommandHandler("help.json",
+		"^help\\.json$",
+		"Print every command and module with its parameters and handlers as a single JSON document.",
+		s.helpJSONHandler),
+		readline.PcItem("help.json"))
+
 	s.addHandler(NewCommandHandler("help MODULE",
 		"^(help|\\?)(.*)$",
 		"List available commands or show module specific help if no module name is provided.",
@@ -326,7 +398,7 @@ func (s *Session) registerCoreHandlers() {
 		readline.PcItem("get", readline.PcItemDynamic(func(prefix string) []string {
 			prefix = core.Trim(prefix[3:])
 			varNames := []string{""}
-			for key := range s.Env.Data {
+			for _, key := range s.Env.Sorted() {
 				if prefix == "" || strings.HasPrefix(key, prefix) {
 					varNames = append(varNames, key)
 				}
@@ -341,14 +413,119 @@ func (s *Session) registerCoreHandlers() {
 		readline.PcItem("set", readline.PcItemDynamic(func(prefix string) []string {
 			prefix = core.Trim(prefix[3:])
 			varNames := []string{""}
-			for key := range s.Env.Data {
+			for _, key := range s.Env.Sorted() {
 				if prefix == "" || strings.HasPrefix(key, prefix) {
 					varNames = append(varNames, key)
 				}
 			}
 			return varNames
+		}, readline.PcItemDynamic(s.completeSetValue))))
+
+	s.addHandler(NewCommandHandler("begin",
+		"^begin$",
+		"Start a transaction, the following set commands are only applied on commit if all their values are valid.",
+		s.beginHandler),
+		readline.PcItem("begin"))
+
+	s.addHandler(NewCommandHandler("commit",
+		"^commit$",
+		"Validate and set every parameter of the transaction, none of them is set if any value is invalid.",
+		s.commitHandler),
+		readline.PcItem("commit"))
+
+	s.addHandler(NewCommandHandler("rollback",
+		"^rollback$",
+		"Discard the parameters of the transaction.",
+		s.rollbackHandler),
+		readline.PcItem("rollback"))
+
+	s.addHandler(NewCommandHandler("config.load FILE",
+		"^config\\.load\\s+(.+)$",
+		"Set every parameter of the JSON object in FILE, reporting all the invalid values.",
+		s.configLoadHandler),
+		readline.PcItem("config.load", readline.PcItemDynamic(func(prefix string) []string {
+			prefix = core.Trim(prefix[11:])
+			if prefix == "" {
+				prefix = "."
+			}
+
+			files, _ := filepath.Glob(prefix + "*")
+			return files
+		})))
+
+	s.addHandler(NewCommandHandler("session.save FILE",
+		"^session\\.save\\s+(.+)$",
+		"Save the hosts, WiFi access points and BLE devices found so far and the changed module parameters to FILE.",
+		s.sessionSaveHandler),
+		readline.PcItem("session.save"))
+
+	s.addHandler(NewCommandHandler("session.restore FILE",
+		"^session\\.restore\\s+(.+)$",
+		"Restore the hosts, WiFi access points, BLE devices and module parameters saved to FILE by session.save.",
+		s.sessionRestoreHandler),
+		readline.PcItem("session.restore", readline.PcItemDynamic(func(prefix string) []string {
+			prefix = core.Trim(prefix[15:])
+			if prefix == "" {
+				prefix = "."
+			}
+
+			files, _ := filepath.Glob(prefix + "*")
+			return files
 		})))
 
+	autostartCompleter := func(prefix string) []string {
+		prefix = core.Trim(prefix[strings.LastIndex(prefix, " ")+1:])
+		names := []string{""}
+		for _, m := range s.Modules {
+			if prefix == "" || strings.HasPrefix(m.Name(), prefix) {
+				names = append(names, m.Name())
+			}
+		}
+		return names
+	}
+
+	s.addHandler(NewCommandHandler("autostart add|remove MODULE",
+		`^autostart(\s+(add|remove)\s+([^\s]+))?$`,
+		"Start MODULE every time bettercap starts after the caplet has been executed, stop starting it, or show the modules started with the session if no arguments are given.",
+		s.autostartHandler),
+		readline.PcItem("autostart",
+			readline.PcItem("add", readline.PcItemDynamic(autostartCompleter)),
+			readline.PcItem("remove", readline.PcItemDynamic(autostartCompleter))))
+
+	lockCompleter := func(prefix string) []string {
+		prefix = core.Trim(prefix[strings.Index(prefix, "lock")+4:])
+		names := []string{""}
+		for _, m := range s.Modules {
+			if prefix == "" || strings.HasPrefix(m.Name(), prefix) {
+				names = append(names, m.Name())
+			}
+		}
+		for _, key := range s.Env.Sorted() {
+			if prefix == "" || strings.HasPrefix(key, prefix) {
+				names = append(names, key)
+			}
+		}
+		return names
+	}
+
+	s.addHandler(NewCommandHandler("lock NAME",
+		"^lock\\s+([^\\s]+)$",
+		"Make the parameter NAME, or every parameter of the module NAME, read-only.",
+		s.lockHandler),
+		readline.PcItem("lock", readline.PcItemDynamic(lockCompleter)))
+
+	s.addHandler(NewCommandHandler("unlock NAME",
+		"^unlock\\s+([^\\s]+)$",
+		"Make the parameter NAME, or every parameter of the module NAME, writable again.",
+		s.unlockHandler),
+		readline.PcItem("unlock", readline.PcItemDynamic(lockCompleter)))
+
+	s.addHandler(NewCommandHandler("revert.all",
+		"^revert\\.all$",
+		"Restore every change made to the system by the modules (hardware address, forwarding, ARP caches, promiscuous mode), stopping the proxies owning firewall redirections.",
+		s.revertAllHandler),
+		readline.PcItem("revert.all"))
+
 	s.addHandler(NewCommandHandler("read VARIABLE PROMPT",
 		`^read\s+([^\s]+)\s+(.+)$`,
 		"Show a PROMPT to ask the user for input that will be saved inside VARIABLE.",
@@ -361,9 +538,9 @@ func (s *Session) registerCoreHandlers() {
 		s.clsHandler),
 		readline.PcItem("clear"))
 
-	s.addHandler(NewCommandHandler("include CAPLET",
+	s.addHandler(NewCommandHandler("include CAPLET ARGS",
 		"^include\\s+(.+)",
-		"Load and run this caplet in the current session.",
+		"Load and run this caplet in the current session, NAME=VALUE arguments are available to it as {arg.NAME} and the others as $0, $1 ...",
 		s.includeHandler),
 		readline.PcItem("include", readline.PcItemDynamic(func(prefix string) []string {
 			prefix = core.Trim(prefix[8:])
@@ -0,0 +1,149 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/network"
+)
+
+// StateVersion is the version of the files written by SaveState, bumped
+// whenever they change in a way older versions can't read.
+const StateVersion = 1
+
+type sessionState struct {
+	Version      int                    `json:"version"`
+	SavedAt      time.Time              `json:"saved_at"`
+	Interface    string                 `json:"interface"`
+	Gateway      *network.Endpoint      `json:"gateway"`
+	Hosts        []*network.Endpoint    `json:"hosts"`
+	AccessPoints []*network.AccessPoint `json:"aps"`
+	BLEDevices   []*network.BLEDevice   `json:"ble"`
+	Params       map[string]string      `json:"params"`
+}
+
+// changedParams returns the module parameters which are not set to their
+// default value.
+func (s *Session) changedParams() map[string]string {
+	params := make(map[string]string)
+	for _, m := range s.Modules {
+		for name, p := range m.Parameters() {
+			if found, value := s.Env.Get(name); found && value != p.Value {
+				params[name] = value
+			}
+		}
+	}
+	return params
+}
+
+// SaveState writes the hosts, access points and BLE devices found so far
+// and the module parameters changed from their defaults to the file, so
+// that RestoreState can bring them back in another session.
+func (s *Session) SaveState(fileName string) error {
+	fileName, err := core.ExpandPath(fileName)
+	if err != nil {
+		return err
+	}
+
+	state := sessionState{
+		Version:      StateVersion,
+		SavedAt:      time.Now(),
+		Interface:    s.Interface.Name(),
+		Gateway:      s.Gateway,
+		Hosts:        s.Lan.List(),
+		AccessPoints: make([]*network.AccessPoint, 0),
+		BLEDevices:   make([]*network.BLEDevice, 0),
+		Params:       s.changedParams(),
+	}
+	if s.WiFi != nil {
+		state.AccessPoints = s.WiFi.List()
+	}
+	if s.BLE != nil {
+		state.BLEDevices = s.BLE.Devices()
+	}
+
+	raw, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	} else if err = ioutil.WriteFile(fileName, raw, 0600); err != nil {
+		return err
+	}
+
+	s.Events.Log(core.INFO, "Saved %d hosts, %d access points, %d BLE devices and %d parameters to %s.",
+		len(state.Hosts), len(state.AccessPoints), len(state.BLEDevices), len(state.Params), fileName)
+	return nil
+}
+
+func loadState(fileName string) (*sessionState, error) {
+	raw, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &sessionState{}
+	if err := json.Unmarshal(raw, state); err != nil {
+		return nil, fmt.Errorf("Could not parse %s: %s", fileName, err)
+	} else if state.Version < 1 || state.Version > StateVersion {
+		return nil, fmt.Errorf("%s has version %d, only sessions up to version %d can be restored.", fileName, state.Version, StateVersion)
+	}
+	return state, nil
+}
+
+// RestoreState adds what a previous session saved with SaveState to this
+// one, the hosts and devices already known are completed rather than
+// replaced, and sets the saved parameters reporting all the invalid ones.
+func (s *Session) RestoreState(fileName string) error {
+	fileName, err := core.ExpandPath(fileName)
+	if err != nil {
+		return err
+	}
+
+	state, err := loadState(fileName)
+	if err != nil {
+		return err
+	}
+
+	if state.Interface != s.Interface.Name() {
+		s.Events.Log(core.WARNING, "%s was saved on %s, restoring it on %s.", fileName, state.Interface, s.Interface.Name())
+	}
+
+	hosts := state.Hosts
+	if state.Gateway != nil {
+		hosts = append(hosts, state.Gateway)
+	}
+
+	nHosts := s.Lan.Restore(hosts)
+	nAPs, nDevs := 0, 0
+	if s.WiFi != nil {
+		nAPs = s.WiFi.Restore(state.AccessPoints)
+	}
+	if s.BLE != nil {
+		nDevs = s.BLE.Restore(state.BLEDevices)
+	}
+
+	names := make([]string, 0, len(state.Params))
+	for name := range state.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	errors := make([]string, 0)
+	for _, name := range names {
+		if err := s.SetParam(name, state.Params[name]); err != nil {
+			errors = append(errors, err.Error())
+		}
+	}
+
+	s.Events.Log(core.INFO, "Restored %d hosts, %d access points and %d BLE devices saved on %s.",
+		nHosts, nAPs, nDevs, state.SavedAt.Format("2006-01-02 15:04:05"))
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%d of %d parameters could not be set:\n  %s", len(errors), len(names), strings.Join(errors, "\n  "))
+	}
+	return nil
+}
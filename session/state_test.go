@@ -0,0 +1,119 @@
+package session
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bettercap/bettercap/network"
+)
+
+func buildStateSession(t *testing.T) *TestSession {
+	s, err := NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s.WiFi = network.NewWiFi(s.Interface, nil, nil)
+	m := &lockTestModule{NewSessionModule("mac.changer", s.Session)}
+	m.AddParam(NewStringParameter("mac.changer.iface", "eth0", "", ""))
+	m.AddParam(NewIntParameter("mac.changer.count", "1", ""))
+	s.Modules = []Module{m}
+
+	return s
+}
+
+func TestSessionSaveRestoreState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bettercap-state")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "session.json")
+
+	s := buildStateSession(t)
+	s.Lan.AddIfNew("192.168.1.10", "66:77:88:99:aa:bb")
+	s.Lan.Tag("66:77:88:99:aa:bb", "printer")
+	s.Lan.SetIPv6For("66:77:88:99:aa:bb", "fe80::10")
+	host, _ := s.Lan.Get("66:77:88:99:aa:bb")
+	host.Meta.Set("mdns:model", "laserjet")
+	host.Traffic.Track(100, true)
+	s.Gateway.Hostname = "router.lan"
+
+	s.WiFi.AddIfNew("home", "00:de:ad:be:ef:00", 2437, -40)
+	ap, _ := s.WiFi.Get("00:de:ad:be:ef:00")
+	ap.Encryption = "WPA2"
+	ap.AddClient("11:22:33:44:55:66", 2437, -60)
+
+	s.Env.Set("mac.changer.count", "5")
+
+	if err := s.SaveState(fileName); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s.Close()
+
+	s = buildStateSession(t)
+	defer s.Close()
+
+	s.Gateway.Hostname = ""
+	if err := s.RestoreState(fileName); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	host, found := s.Lan.Get("66:77:88:99:aa:bb")
+	if !found {
+		t.Fatal("expected the host to be restored")
+	} else if host.IpAddress != "192.168.1.10" || !host.IP.Equal(net.ParseIP("192.168.1.10")) {
+		t.Fatalf("expected '192.168.1.10', got '%s'", host.IpAddress)
+	} else if host.Ip6Address != "fe80::10" || !host.HasTag("printer") {
+		t.Fatalf("unexpected host %+v", host)
+	} else if model := host.Meta.Get("mdns:model"); model != "laserjet" {
+		t.Fatalf("expected 'laserjet', got '%v'", model)
+	} else if sent, _ := host.Traffic.Bytes(); sent != 100 {
+		t.Fatalf("expected 100 bytes sent, got %d", sent)
+	} else if s.Gateway.Hostname != "router.lan" {
+		t.Fatalf("expected 'router.lan', got '%s'", s.Gateway.Hostname)
+	}
+
+	ap, found = s.WiFi.Get("00:de:ad:be:ef:00")
+	if !found {
+		t.Fatal("expected the access point to be restored")
+	} else if ap.ESSID() != "home" || ap.Encryption != "WPA2" || ap.Channel() != 6 {
+		t.Fatalf("unexpected access point %+v", ap.Station)
+	} else if _, found := ap.Get("11:22:33:44:55:66"); !found {
+		t.Fatal("expected the client to be restored")
+	}
+
+	if _, v := s.Env.Get("mac.changer.count"); v != "5" {
+		t.Fatalf("expected '5', got '%s'", v)
+	}
+
+	// restoring twice adds nothing
+	before := len(s.Lan.List())
+	if err := s.RestoreState(fileName); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if after := len(s.Lan.List()); after != before {
+		t.Fatalf("expected %d hosts, got %d", before, after)
+	}
+}
+
+func TestSessionRestoreStateVersion(t *testing.T) {
+	s := buildStateSession(t)
+	defer s.Close()
+
+	fp, err := ioutil.TempFile("", "bettercap-state")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(fp.Name())
+
+	fp.WriteString(`{"version": 99, "hosts": []}`)
+	fp.Close()
+
+	if err := s.RestoreState(fp.Name()); err == nil || !strings.Contains(err.Error(), "version 99") {
+		t.Fatalf("expected a version error, got '%v'", err)
+	}
+}
@@ -0,0 +1,121 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bettercap/bettercap/core"
+	"github.com/bettercap/bettercap/network"
+)
+
+const (
+	TestInterfaceName = "test0"
+	TestInterfaceMAC  = "aa:bb:cc:dd:ee:ff"
+	TestInterfaceIP   = "192.168.1.2"
+	TestGatewayMAC    = "00:11:22:33:44:55"
+	TestGatewayIP     = "192.168.1.1"
+)
+
+// TestSession is an in memory session to exercise modules in unit
+// tests, it has a fake interface and records every executed command
+// instead of running it.
+type TestSession struct {
+	*Session
+
+	mu       sync.Mutex
+	Commands []string
+	// if set, called for every command to provide its output
+	ExecOutput func(executable string, args []string) (string, error)
+
+	prevExec func(executable string, args []string) (string, error)
+	prevI    *Session
+}
+
+func NewTestSession() (*TestSession, error) {
+	env, err := NewEnvironment("")
+	if err != nil {
+		return nil, err
+	}
+
+	iface := network.NewEndpointNoResolve(TestInterfaceIP, TestInterfaceMAC, TestInterfaceName, 24)
+	gateway := network.NewEndpointNoResolve(TestGatewayIP, TestGatewayMAC, "gateway", 24)
+
+	debug, silent, noColors := false, true, true
+	s := &Session{
+		Options: core.Options{
+			Debug:    &debug,
+			Silent:   &silent,
+			NoColors: &noColors,
+		},
+		Interface:    iface,
+		Gateway:      gateway,
+		Env:          env,
+		Events:       NewEventPool(debug, silent),
+		CoreHandlers: make([]CommandHandler, 0),
+		Modules:      make([]Module, 0),
+		completers:   newCompleters(),
+		locks:        newParamLocks(),
+		tx:           newParamTransaction(),
+	}
+	s.Lan = network.NewLAN(iface, gateway, func(e *network.Endpoint) {}, func(e *network.Endpoint) {})
+	s.setupEnv()
+
+	t := &TestSession{
+		Session:  s,
+		Commands: make([]string, 0),
+		prevExec: core.ExecHandler,
+		prevI:    I,
+	}
+	core.ExecHandler = t.exec
+	// the log package writes to the global session
+	I = s
+
+	return t, nil
+}
+
+func (t *TestSession) exec(executable string, args []string) (string, error) {
+	t.mu.Lock()
+	t.Commands = append(t.Commands, strings.TrimSpace(executable+" "+strings.Join(args, " ")))
+	cb := t.ExecOutput
+	t.mu.Unlock()
+
+	if cb != nil {
+		return cb(executable, args)
+	}
+	return "", nil
+}
+
+// Close restores the real command executor and global session.
+func (t *TestSession) Close() {
+	core.ExecHandler = t.prevExec
+	I = t.prevI
+}
+
+// Executed returns the commands executed so far.
+func (t *TestSession) Executed() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string{}, t.Commands...)
+}
+
+// Set sets a parameter through the same path of the set command.
+func (t *TestSession) Set(name, value string) error {
+	return t.SetParam(name, value)
+}
+
+// Handle runs the handler of the module with the given name, for
+// instance Handle("mac.changer", "mac.changer on").
+func (t *TestSession) Handle(module, handler string, args ...string) error {
+	err, mod := t.Module(module)
+	if err != nil {
+		return err
+	}
+
+	for _, h := range mod.Handlers() {
+		if h.Name == handler {
+			return h.Exec(args)
+		}
+	}
+	return fmt.Errorf("Module %s has no handler %s.", module, handler)
+}
@@ -0,0 +1,51 @@
+package session
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bettercap/bettercap/core"
+)
+
+func TestTestSession(t *testing.T) {
+	s, err := NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	called := ""
+	m := &lockTestModule{NewSessionModule("mac.changer", s.Session)}
+	m.AddParam(NewIntParameter("mac.changer.count", "1", ""))
+	m.AddHandler(NewModuleHandler("mac.changer on", "", "", func(args []string) error {
+		called = "on"
+		_, err := core.Exec("ifconfig", []string{"test0", "up"})
+		return err
+	}))
+	s.Register(m)
+
+	if s.Interface.Name() != TestInterfaceName {
+		t.Fatalf("expected '%s', got '%s'", TestInterfaceName, s.Interface.Name())
+	} else if err := s.Set("mac.changer.count", "nope"); err == nil {
+		t.Fatal("expected validation error")
+	} else if err := s.Handle("mac.changer", "mac.changer on"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if called != "on" {
+		t.Fatal("expected handler to be called")
+	} else if cmds := s.Executed(); len(cmds) != 1 || cmds[0] != "ifconfig test0 up" {
+		t.Fatalf("unexpected commands %v", cmds)
+	} else if err := s.Handle("mac.changer", "mac.changer nope"); err == nil {
+		t.Fatal("expected error for unknown handler")
+	}
+
+	s.ExecOutput = func(executable string, args []string) (string, error) {
+		return "", fmt.Errorf("failed")
+	}
+	if _, err := core.ExecSilent("true", nil); err == nil {
+		t.Fatal("expected injected error")
+	}
+
+	s.Close()
+	if I == s.Session {
+		t.Fatal("expected global session to be restored")
+	}
+}
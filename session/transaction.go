@@ -0,0 +1,134 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bettercap/bettercap/core"
+)
+
+type pendingParam struct {
+	name  string
+	value string
+}
+
+// paramTransaction buffers the set commands in between begin and commit.
+type paramTransaction struct {
+	sync.Mutex
+	open    bool
+	pending []pendingParam
+}
+
+func newParamTransaction() *paramTransaction {
+	return &paramTransaction{
+		pending: make([]pendingParam, 0),
+	}
+}
+
+func (s *Session) InTransaction() bool {
+	s.tx.Lock()
+	defer s.tx.Unlock()
+	return s.tx.open
+}
+
+// Begin starts buffering the set commands until Commit or Rollback.
+func (s *Session) Begin() error {
+	s.tx.Lock()
+	defer s.tx.Unlock()
+
+	if s.tx.open {
+		return fmt.Errorf("A transaction is already open, use commit or rollback first.")
+	}
+	s.tx.open = true
+	s.tx.pending = make([]pendingParam, 0)
+	return nil
+}
+
+// bufferParam is what set does while a transaction is open, a parameter
+// set more than once keeps its position and the last value.
+func (s *Session) bufferParam(name, value string) {
+	s.tx.Lock()
+	defer s.tx.Unlock()
+
+	for i := range s.tx.pending {
+		if s.tx.pending[i].name == name {
+			s.tx.pending[i].value = value
+			return
+		}
+	}
+	s.tx.pending = append(s.tx.pending, pendingParam{name, value})
+}
+
+// Commit validates every buffered value and, only if they're all valid,
+// sets them. Otherwise nothing is set and the transaction is kept open
+// so that the invalid ones can be fixed.
+func (s *Session) Commit() (int, error) {
+	s.tx.Lock()
+	defer s.tx.Unlock()
+
+	if !s.tx.open {
+		return 0, fmt.Errorf("No transaction is open, use begin first.")
+	}
+
+	errors := make([]string, 0)
+	for _, p := range s.tx.pending {
+		if err := s.checkParam(p.name, p.value); err != nil {
+			errors = append(errors, err.Error())
+		}
+	}
+
+	if len(errors) > 0 {
+		return 0, fmt.Errorf("%d of %d parameters are not valid, nothing has been set:\n  %s", len(errors), len(s.tx.pending), strings.Join(errors, "\n  "))
+	}
+
+	for _, p := range s.tx.pending {
+		s.Env.Set(p.name, p.value)
+	}
+
+	n := len(s.tx.pending)
+	s.tx.open = false
+	s.tx.pending = make([]pendingParam, 0)
+	return n, nil
+}
+
+// Rollback discards the buffered values and closes the transaction.
+func (s *Session) Rollback() (int, error) {
+	s.tx.Lock()
+	defer s.tx.Unlock()
+
+	if !s.tx.open {
+		return 0, fmt.Errorf("No transaction is open, use begin first.")
+	}
+
+	n := len(s.tx.pending)
+	s.tx.open = false
+	s.tx.pending = make([]pendingParam, 0)
+	return n, nil
+}
+
+func (s *Session) beginHandler(args []string, sess *Session) error {
+	if err := s.Begin(); err != nil {
+		return err
+	}
+	s.Events.Log(core.INFO, "Transaction started, parameters will be set on commit.")
+	return nil
+}
+
+func (s *Session) commitHandler(args []string, sess *Session) error {
+	n, err := s.Commit()
+	if err != nil {
+		return err
+	}
+	s.Events.Log(core.INFO, "Transaction committed, %d parameters set.", n)
+	return nil
+}
+
+func (s *Session) rollbackHandler(args []string, sess *Session) error {
+	n, err := s.Rollback()
+	if err != nil {
+		return err
+	}
+	s.Events.Log(core.INFO, "Transaction rolled back, %d parameters discarded.", n)
+	return nil
+}
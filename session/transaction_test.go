@@ -0,0 +1,81 @@
+package session
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSessionTransaction(t *testing.T) {
+	s, err := NewTestSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	s.registerCoreHandlers()
+
+	m := &lockTestModule{NewSessionModule("mac.changer", s.Session)}
+	m.AddParam(NewStringParameter("mac.changer.iface", ParamIfaceName, "", "Name of the interface to use."))
+	m.AddParam(NewStringParameter("mac.changer.address", ParamRandomMAC, testMacValidator, "Hardware address to apply to the interface."))
+	s.Register(m)
+
+	for _, bad := range []string{"commit", "rollback"} {
+		if err := s.Run(bad); err == nil {
+			t.Fatalf("expected error for '%s' without a transaction", bad)
+		}
+	}
+
+	if err := s.Run("begin"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Run("begin"); err == nil {
+		t.Fatal("expected error for nested transaction")
+	} else if err := s.Run("set mac.changer.iface eth1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Run("set mac.changer.address aa:bb:cc"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if _, v := s.Env.Get("mac.changer.iface"); v != ParamIfaceName {
+		t.Fatalf("expected '%s', got '%s'", ParamIfaceName, v)
+	}
+
+	// nothing is set, and the transaction is kept open to fix it
+	if err := s.Run("commit"); err == nil {
+		t.Fatal("expected error")
+	} else if !strings.Contains(err.Error(), "mac.changer.address") {
+		t.Fatalf("expected the invalid parameter to be reported, got '%s'", err)
+	} else if _, v := s.Env.Get("mac.changer.iface"); v != ParamIfaceName {
+		t.Fatalf("expected '%s', got '%s'", ParamIfaceName, v)
+	} else if !s.InTransaction() {
+		t.Fatal("expected the transaction to be kept open")
+	}
+
+	if err := s.Run("set mac.changer.address aa:bb:cc:dd:ee:01"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Run("commit"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if s.InTransaction() {
+		t.Fatal("expected the transaction to be closed")
+	}
+
+	for name, exp := range map[string]string{
+		"mac.changer.iface":   "eth1",
+		"mac.changer.address": "aa:bb:cc:dd:ee:01",
+	} {
+		if _, v := s.Env.Get(name); v != exp {
+			t.Fatalf("expected '%s', got '%s'", exp, v)
+		}
+	}
+
+	if err := s.Run("begin"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Run("set mac.changer.iface eth2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.Run("rollback"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if _, v := s.Env.Get("mac.changer.iface"); v != "eth1" {
+		t.Fatalf("expected 'eth1', got '%s'", v)
+	} else if err := s.Run("set mac.changer.iface eth3"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if _, v := s.Env.Get("mac.changer.iface"); v != "eth3" {
+		t.Fatalf("expected 'eth3', got '%s'", v)
+	}
+}
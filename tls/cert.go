@@ -6,8 +6,11 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"fmt"
+	"io/ioutil"
 	"math/big"
 	"os"
+	"path/filepath"
 	"strconv"
 	"time"
 
@@ -138,3 +141,27 @@ func Generate(cfg CertConfig, certPath string, keyPath string) error {
 
 	return pem.Encode(certFile, &pem.Block{Type: "CERTIFICATE", Bytes: cert})
 }
+
+// ExportCertificate copies the certificate blocks found in the PEM file
+// certPath to outPath, any private key block is left out.
+func ExportCertificate(certPath string, outPath string) error {
+	raw, err := ioutil.ReadFile(certPath)
+	if err != nil {
+		return err
+	}
+
+	exported := make([]byte, 0)
+	for block, rest := pem.Decode(raw); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type == "CERTIFICATE" {
+			exported = append(exported, pem.EncodeToMemory(block)...)
+		}
+	}
+
+	if len(exported) == 0 {
+		return fmt.Errorf("No certificate found in %s.", certPath)
+	} else if err = os.MkdirAll(filepath.Dir(outPath), os.ModePerm); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(outPath, exported, 0644)
+}